@@ -0,0 +1,28 @@
+package alphabet_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/alphabet"
+)
+
+func ExampleComplementDNA() {
+	fmt.Println(string(alphabet.ComplementDNA('A')), string(alphabet.ComplementDNA('r')))
+
+	// Output: T y
+}
+
+func ExampleAmbiguityCode() {
+	fmt.Println(alphabet.Ambiguities('R'))
+	fmt.Println(string(alphabet.AmbiguityCode("CT")))
+
+	// Output:
+	// GA
+	// Y
+}
+
+func ExampleDNAToRNA() {
+	fmt.Println(alphabet.DNAToRNA("ATGAAATAA"))
+
+	// Output: AUGAAAUAA
+}
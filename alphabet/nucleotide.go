@@ -0,0 +1,190 @@
+package alphabet
+
+import "strings"
+
+/******************************************************************************
+
+Nucleotide complementation and ambiguity codes begin here.
+
+Every package that needs to know what pairs with what, or what an N means,
+should ask this file instead of rolling its own switch statement.
+
+See https://www.bioinformatics.org/sms/iupac.html for the IUPAC codes.
+
+******************************************************************************/
+
+// ComplementDNA returns the complement of a DNA base, including IUPAC
+// ambiguity codes, preserving case. It returns 0 for bytes that are not
+// DNA bases.
+func ComplementDNA(base byte) byte {
+	return dnaComplements[base]
+}
+
+// ComplementRNA returns the complement of an RNA base, including IUPAC
+// ambiguity codes, preserving case. It returns 0 for bytes that are not
+// RNA bases.
+func ComplementRNA(base byte) byte {
+	return rnaComplements[base]
+}
+
+// IsPurine reports whether a base is a purine (A or G, or the ambiguity code R).
+func IsPurine(base byte) bool {
+	switch base {
+	case 'A', 'G', 'R', 'a', 'g', 'r':
+		return true
+	}
+	return false
+}
+
+// IsPyrimidine reports whether a base is a pyrimidine (C, T or U, or the ambiguity code Y).
+func IsPyrimidine(base byte) bool {
+	switch base {
+	case 'C', 'T', 'U', 'Y', 'c', 't', 'u', 'y':
+		return true
+	}
+	return false
+}
+
+// Ambiguities returns the uppercase DNA bases an IUPAC code stands for, so
+// Ambiguities('R') is "GA". Unambiguous bases return themselves and U is
+// treated as T. It returns "" for bytes that are not IUPAC nucleotide codes.
+func Ambiguities(code byte) string {
+	return ambiguities[code]
+}
+
+// AmbiguityCode returns the uppercase IUPAC code that covers every base in
+// bases, so AmbiguityCode("AG") is 'R'. Bases may themselves be ambiguity
+// codes. It returns 0 if bases is empty or contains a non-nucleotide.
+func AmbiguityCode(bases string) byte {
+	var mask int
+	for index := 0; index < len(bases); index++ {
+		expanded := ambiguities[bases[index]]
+		if expanded == "" {
+			return 0
+		}
+		for _, base := range []byte(expanded) {
+			mask |= baseMasks[base]
+		}
+	}
+	return ambiguityCodesByMask[mask]
+}
+
+// DNAToRNA converts a DNA sequence to RNA by replacing T with U, preserving case.
+func DNAToRNA(sequence string) string {
+	return dnaToRNAReplacer.Replace(sequence)
+}
+
+// RNAToDNA converts an RNA sequence to DNA by replacing U with T, preserving case.
+func RNAToDNA(sequence string) string {
+	return rnaToDNAReplacer.Replace(sequence)
+}
+
+var (
+	dnaToRNAReplacer = strings.NewReplacer("T", "U", "t", "u")
+	rnaToDNAReplacer = strings.NewReplacer("U", "T", "u", "t")
+)
+
+// dnaComplements provides 1:1 mapping between DNA bases and their complements.
+var dnaComplements = [256]byte{
+	'A': 'T',
+	'B': 'V',
+	'C': 'G',
+	'D': 'H',
+	'G': 'C',
+	'H': 'D',
+	'K': 'M',
+	'M': 'K',
+	'N': 'N',
+	'R': 'Y',
+	'S': 'S',
+	'T': 'A',
+	'V': 'B',
+	'W': 'W',
+	'Y': 'R',
+	'a': 't',
+	'b': 'v',
+	'c': 'g',
+	'd': 'h',
+	'g': 'c',
+	'h': 'd',
+	'k': 'm',
+	'm': 'k',
+	'n': 'n',
+	'r': 'y',
+	's': 's',
+	't': 'a',
+	'v': 'b',
+	'w': 'w',
+	'y': 'r',
+}
+
+// rnaComplements provides 1:1 mapping between RNA bases and their complements.
+var rnaComplements = [256]byte{
+	'A': 'U',
+	'B': 'V',
+	'C': 'G',
+	'D': 'H',
+	'G': 'C',
+	'H': 'D',
+	'K': 'M',
+	'M': 'K',
+	'N': 'N',
+	'R': 'Y',
+	'S': 'S',
+	'U': 'A',
+	'V': 'B',
+	'W': 'W',
+	'Y': 'R',
+	'X': 'X',
+	'a': 'u',
+	'b': 'v',
+	'c': 'g',
+	'd': 'h',
+	'g': 'c',
+	'h': 'd',
+	'k': 'm',
+	'm': 'k',
+	'n': 'n',
+	'r': 'y',
+	's': 's',
+	'u': 'a',
+	'v': 'b',
+	'w': 'w',
+	'y': 'r',
+	'x': 'x',
+}
+
+// ambiguities maps IUPAC codes to the bases they represent.
+var ambiguities = func() [256]string {
+	var table [256]string
+	codes := map[byte]string{
+		'A': "A",
+		'C': "C",
+		'G': "G",
+		'T': "T",
+		'U': "T",
+		'R': "GA",
+		'Y': "TC",
+		'M': "AC",
+		'K': "GT",
+		'S': "GC",
+		'W': "AT",
+		'H': "ACT",
+		'B': "GTC",
+		'V': "GCA",
+		'D': "GAT",
+		'N': "GATC",
+	}
+	for code, bases := range codes {
+		table[code] = bases
+		table[code+('a'-'A')] = bases
+	}
+	return table
+}()
+
+var baseMasks = [256]int{'A': 1, 'C': 2, 'G': 4, 'T': 8}
+
+var ambiguityCodesByMask = [16]byte{
+	1: 'A', 2: 'C', 3: 'M', 4: 'G', 5: 'R', 6: 'S', 7: 'V',
+	8: 'T', 9: 'W', 10: 'Y', 11: 'H', 12: 'K', 13: 'D', 14: 'B', 15: 'N',
+}
@@ -0,0 +1,85 @@
+package alphabet_test
+
+import (
+	"testing"
+
+	"github.com/bebop/poly/alphabet"
+)
+
+func TestComplementIsInvolution(t *testing.T) {
+	for base := 0; base < 256; base++ {
+		if complement := alphabet.ComplementDNA(byte(base)); complement != 0 && alphabet.ComplementDNA(complement) != byte(base) {
+			t.Errorf("complement of DNA complement of %q is %q", base, alphabet.ComplementDNA(complement))
+		}
+		if complement := alphabet.ComplementRNA(byte(base)); complement != 0 && alphabet.ComplementRNA(complement) != byte(base) {
+			t.Errorf("complement of RNA complement of %q is %q", base, alphabet.ComplementRNA(complement))
+		}
+	}
+	if alphabet.ComplementDNA('U') != 0 || alphabet.ComplementRNA('T') != 0 {
+		t.Errorf("expected T and U to only be complemented in their own alphabets")
+	}
+}
+
+func TestAmbiguityComplements(t *testing.T) {
+	// the complement of an ambiguity code must stand for the complements of its bases.
+	for _, code := range []byte("ACGTRYMKSWHBVDN") {
+		var complementedBases []byte
+		for _, base := range []byte(alphabet.Ambiguities(code)) {
+			complementedBases = append(complementedBases, alphabet.ComplementDNA(base))
+		}
+		if got := alphabet.AmbiguityCode(string(complementedBases)); got != alphabet.ComplementDNA(code) {
+			t.Errorf("ambiguity code of complemented %q bases is %q, but ComplementDNA(%q) is %q", code, got, code, alphabet.ComplementDNA(code))
+		}
+	}
+}
+
+func TestAmbiguityCode(t *testing.T) {
+	testCases := map[string]byte{
+		"AG":   'R',
+		"GA":   'R',
+		"CT":   'Y',
+		"cu":   'Y',
+		"ACGT": 'N',
+		"RY":   'N',
+		"AAA":  'A',
+		"AGC":  'V',
+		"":     0,
+		"AZ":   0,
+	}
+	for bases, expected := range testCases {
+		if got := alphabet.AmbiguityCode(bases); got != expected {
+			t.Errorf("AmbiguityCode(%q) = %q, expected %q", bases, got, expected)
+		}
+	}
+	if got := alphabet.Ambiguities('n'); got != "GATC" {
+		t.Errorf("Ambiguities('n') = %q, expected GATC", got)
+	}
+	if got := alphabet.Ambiguities('X'); got != "" {
+		t.Errorf("Ambiguities('X') = %q, expected empty", got)
+	}
+}
+
+func TestPurinesAndPyrimidines(t *testing.T) {
+	for _, base := range []byte("AGRag") {
+		if !alphabet.IsPurine(base) || alphabet.IsPyrimidine(base) {
+			t.Errorf("%q should be a purine", base)
+		}
+	}
+	for _, base := range []byte("CTUYctuy") {
+		if !alphabet.IsPyrimidine(base) || alphabet.IsPurine(base) {
+			t.Errorf("%q should be a pyrimidine", base)
+		}
+	}
+	if alphabet.IsPurine('N') || alphabet.IsPyrimidine('N') {
+		t.Errorf("N is neither a purine nor a pyrimidine")
+	}
+}
+
+func TestRNAConversion(t *testing.T) {
+	if got := alphabet.DNAToRNA("ATGcttN"); got != "AUGcuuN" {
+		t.Errorf("DNAToRNA = %q", got)
+	}
+	if got := alphabet.RNAToDNA("AUGcuuN"); got != "ATGcttN" {
+		t.Errorf("RNAToDNA = %q", got)
+	}
+}
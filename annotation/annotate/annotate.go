@@ -0,0 +1,301 @@
+/*
+Package annotate finds common parts in plasmids, like promoters, origins of
+replication, resistance markers, and tags.
+
+Most plasmids are built from the same few hundred parts. A plasmid that comes
+back from sequencing, or from a collaborator who didn't annotate it, almost
+always has an origin and a resistance marker from a short list, and usually a
+promoter and a tag or two from a slightly longer one. Finding them by hand
+means BLASTing the plasmid and reading the hits, which is what tools like
+pLannotate automate.
+
+Annotate does the same with a small database of common parts bundled with
+poly. It seeds a search for every part with k-mers shared with the plasmid, on
+both strands and across the origin of circular plasmids, then aligns the part
+where its seeds are to see how much of it is there and how well it matches.
+Parts that match well enough become GenBank features, labeled with the part's
+name and with how well they matched in identity and match_length qualifiers,
+the way pLannotate labels them.
+
+The bundled database is only a start. ParseDatabase reads more parts from
+FASTA, and Options takes any parts to search for.
+*/
+package annotate
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bebop/poly/annotation"
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/search/align"
+	"github.com/bebop/poly/transform"
+	"lukechampine.com/blake3"
+)
+
+//go:embed data/features.fasta
+var bundledDatabase []byte
+
+// Part is a sequence to look for.
+type Part struct {
+	Name        string
+	Type        string // the GenBank feature type of matches, like "promoter".
+	Description string
+	Sequence    string
+}
+
+// Options changes how parts are found. Zero values are replaced with the
+// defaults noted on each field.
+type Options struct {
+	// Parts are the parts to look for. Defaults to the bundled database.
+	Parts []Part
+	// MinIdentity is the smallest fraction of a match's alignment columns
+	// that have to be matches. Defaults to 0.95.
+	MinIdentity float64
+	// MinCoverage is the smallest fraction of a part that has to be in a
+	// match. Defaults to 0.95.
+	MinCoverage float64
+}
+
+// Match is where a part was found.
+type Match struct {
+	Part Part
+	// Location is where the part is. It's complemented if the part is on
+	// the bottom strand, and a join if it crosses the origin.
+	Location genbank.Location
+	// Identity is the fraction of the alignment's columns that are matches,
+	// and Coverage the fraction of the part that aligned.
+	Identity float64
+	Coverage float64
+}
+
+const (
+	defaultMinIdentity = 0.95
+	defaultMinCoverage = 0.95
+	// seedLength is the length of the k-mers used to seed alignments.
+	seedLength = 11
+	// band is how far alignments may stray from the diagonals of their
+	// seeds.
+	band = 16
+)
+
+var (
+	parseBundled sync.Once
+	bundledParts []Part
+	bundledErr   error
+)
+
+// Database returns the parts bundled with poly.
+func Database() []Part {
+	parseBundled.Do(func() {
+		bundledParts, bundledErr = ParseDatabase(bytes.NewReader(bundledDatabase))
+	})
+	if bundledErr != nil {
+		panic(fmt.Sprintf("bundled parts database is malformed: %v", bundledErr))
+	}
+	return append([]Part(nil), bundledParts...)
+}
+
+// ParseDatabase reads parts from FASTA. Each part's header is its name, type,
+// and description, separated by "|", like
+// ">T7 promoter|promoter|promoter for T7 RNA polymerase".
+func ParseDatabase(r io.Reader) ([]Part, error) {
+	records, err := fasta.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]Part, len(records))
+	for index, record := range records {
+		fields := strings.SplitN(record.Name, "|", 3)
+		if len(fields) < 2 || fields[0] == "" || fields[1] == "" {
+			return nil, fmt.Errorf("part %q needs a name and a type, separated by |", record.Name)
+		}
+		if len(record.Sequence) < seedLength {
+			return nil, fmt.Errorf("part %s is shorter than %d bases", fields[0], seedLength)
+		}
+		parts[index] = Part{Name: fields[0], Type: fields[1], Sequence: strings.ToUpper(record.Sequence)}
+		if len(fields) == 3 {
+			parts[index].Description = fields[2]
+		}
+	}
+	return parts, nil
+}
+
+// Annotate finds parts in a sequence, sorted by where they start.
+func Annotate(sequence string, circular bool, options Options) ([]Match, error) {
+	if options.Parts == nil {
+		options.Parts = Database()
+	}
+	if options.MinIdentity == 0 {
+		options.MinIdentity = defaultMinIdentity
+	}
+	if options.MinCoverage == 0 {
+		options.MinCoverage = defaultMinCoverage
+	}
+	scoring, err := align.NewAffineScoring(nil, -5, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	// circular sequences are searched with enough of their start appended
+	// to find the longest part across the origin.
+	length := len(sequence)
+	searched := strings.ToUpper(sequence)
+	if circular {
+		longest := 0
+		for _, part := range options.Parts {
+			longest = max(longest, len(part.Sequence))
+		}
+		searched += searched[:min(longest-1, length)]
+	}
+	seeds := make(map[string][]int)
+	for position := 0; position+seedLength <= len(searched); position++ {
+		seeds[searched[position:position+seedLength]] = append(seeds[searched[position:position+seedLength]], position)
+	}
+
+	var matches []Match
+	for _, part := range options.Parts {
+		for _, complement := range []bool{false, true} {
+			query := strings.ToUpper(part.Sequence)
+			if complement {
+				query = transform.ReverseComplement(query)
+			}
+			for _, diagonals := range seedDiagonals(query, seeds) {
+				center := (diagonals[0] + diagonals[1]) / 2
+				alignment, err := align.SemiGlobalAffine(query, searched, scoring, align.BandOptions{Band: (diagonals[1]-diagonals[0])/2 + band, Diagonal: center})
+				if err != nil {
+					return nil, err
+				}
+				match := Match{Part: part, Identity: identity(alignment), Coverage: float64(alignment.EndA-alignment.StartA) / float64(len(query))}
+				// matches starting in the appended bases were found already.
+				if match.Identity < options.MinIdentity || match.Coverage < options.MinCoverage || alignment.StartB >= length {
+					continue
+				}
+				match.Location = genbank.RangeLocation(alignment.StartB, alignment.EndB, length, complement)
+				matches = append(matches, match)
+			}
+		}
+	}
+	return best(matches), nil
+}
+
+// seedDiagonals returns the ranges of diagonals, as position in the searched
+// sequence minus position in the query, that a query shares seeds on, with
+// diagonals less than a band apart grouped together.
+func seedDiagonals(query string, seeds map[string][]int) [][2]int {
+	var diagonals []int
+	for position := 0; position+seedLength <= len(query); position++ {
+		for _, searchedPosition := range seeds[query[position:position+seedLength]] {
+			diagonals = append(diagonals, searchedPosition-position)
+		}
+	}
+	sort.Ints(diagonals)
+	var groups [][2]int
+	for _, diagonal := range diagonals {
+		if last := len(groups) - 1; last >= 0 && diagonal-groups[last][1] <= band {
+			groups[last][1] = diagonal
+			continue
+		}
+		groups = append(groups, [2]int{diagonal, diagonal})
+	}
+	return groups
+}
+
+// identity returns the fraction of an alignment's columns that are matches.
+func identity(alignment align.Alignment) float64 {
+	if len(alignment.AlignedA) == 0 {
+		return 0
+	}
+	matches := 0
+	for column := 0; column < len(alignment.AlignedA); column++ {
+		if alignment.AlignedA[column] == alignment.AlignedB[column] {
+			matches++
+		}
+	}
+	return float64(matches) / float64(len(alignment.AlignedA))
+}
+
+// best keeps the best of the matches of each part that overlap on the same
+// strand, like those found from two groups of seeds, and sorts them.
+func best(matches []Match) []Match {
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Identity*matches[i].Coverage > matches[j].Identity*matches[j].Coverage
+	})
+	var kept []Match
+	for _, match := range matches {
+		duplicate := false
+		for _, other := range kept {
+			if other.Part.Name == match.Part.Name && other.Location.Complement == match.Location.Complement && other.Location.Overlaps(match.Location) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			kept = append(kept, match)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		return start(kept[i].Location) < start(kept[j].Location)
+	})
+	return kept
+}
+
+// start returns where a match's location starts.
+func start(location genbank.Location) int {
+	if len(location.SubLocations) > 0 {
+		return location.SubLocations[0].Start
+	}
+	return location.Start
+}
+
+// Feature returns a match as a feature, labeled with its part's name and
+// description, and with its identity and the percentage of the part it
+// covers in identity and match_length qualifiers.
+func (match Match) Feature() genbank.Feature {
+	attributes := map[string]string{
+		"label":        match.Part.Name,
+		"identity":     strconv.FormatFloat(100*match.Identity, 'f', 1, 64),
+		"match_length": strconv.FormatFloat(100*match.Coverage, 'f', 1, 64),
+	}
+	if match.Part.Description != "" {
+		attributes["note"] = match.Part.Description
+	}
+	return genbank.Feature{Type: match.Part.Type, Attributes: attributes, Location: match.Location}
+}
+
+// Pipeline returns an annotation pipeline that adds the parts Annotate finds
+// to a record. Its version changes with the parts and options, so changing
+// either annotates records again.
+func Pipeline(options Options) annotation.Pipeline {
+	parts := options.Parts
+	if parts == nil {
+		parts = Database()
+	}
+	hasher := blake3.New(16, nil)
+	fmt.Fprintf(hasher, "%g\x00%g", options.MinIdentity, options.MinCoverage)
+	for _, part := range parts {
+		fmt.Fprintf(hasher, "\x00%s\x00%s\x00%s\x00%s", part.Name, part.Type, part.Description, part.Sequence)
+	}
+	return annotation.Pipeline{
+		Name:    "annotate",
+		Version: fmt.Sprintf("%x", hasher.Sum(nil)),
+		Annotate: func(record genbank.Genbank) ([]genbank.Feature, error) {
+			matches, err := Annotate(record.Sequence, record.Meta.Locus.Circular, options)
+			if err != nil {
+				return nil, err
+			}
+			features := make([]genbank.Feature, len(matches))
+			for index, match := range matches {
+				features[index] = match.Feature()
+			}
+			return features, nil
+		},
+	}
+}
@@ -0,0 +1,134 @@
+package annotate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/transform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// puc19Parts are the bundled parts pUC19 has, and where they are.
+var puc19Parts = map[string]string{
+	"CAP binding site": "505..526",
+	"lac promoter":     "541..571",
+	"lac operator":     "579..595",
+	"lacZ-alpha":       "615..938",
+	"MCS":              "632..688",
+	"AmpR promoter":    "1179..1283",
+	"AmpR":             "1284..2144",
+	"ori":              "join(2315..2686,1..217)",
+}
+
+func TestDatabase(t *testing.T) {
+	parts := Database()
+	require.NotEmpty(t, parts)
+	names := make(map[string]bool)
+	for _, part := range parts {
+		assert.NotEmpty(t, part.Type, part.Name)
+		assert.NotEmpty(t, part.Description, part.Name)
+		assert.False(t, names[part.Name], "%s is in the database twice", part.Name)
+		names[part.Name] = true
+	}
+	// changing the returned parts doesn't change the database.
+	parts[0].Name = "changed"
+	assert.NotEqual(t, "changed", Database()[0].Name)
+}
+
+func TestAnnotatePuc19(t *testing.T) {
+	puc19, err := genbank.Read("../../data/puc19.gbk")
+	require.NoError(t, err)
+	matches, err := Annotate(puc19.Sequence, true, Options{})
+	require.NoError(t, err)
+	found := make(map[string]string)
+	for _, match := range matches {
+		assert.Equal(t, 1.0, match.Identity, match.Part.Name)
+		assert.Equal(t, 1.0, match.Coverage, match.Part.Name)
+		found[match.Part.Name] = genbank.BuildLocationString(match.Location)
+	}
+	assert.Equal(t, puc19Parts, found)
+}
+
+func TestAnnotateReverseRotated(t *testing.T) {
+	puc19, err := genbank.Read("../../data/puc19.gbk")
+	require.NoError(t, err)
+	sequence := transform.ReverseComplement(puc19.Sequence[1000:] + puc19.Sequence[:1000])
+	matches, err := Annotate(sequence, true, Options{})
+	require.NoError(t, err)
+	require.Len(t, matches, len(puc19Parts))
+	for _, match := range matches {
+		assert.True(t, match.Location.Complement, match.Part.Name)
+		found, err := match.Location.Extract(sequence)
+		require.NoError(t, err)
+		assert.Equal(t, match.Part.Sequence, strings.ToUpper(found), match.Part.Name)
+	}
+}
+
+func TestAnnotateMismatches(t *testing.T) {
+	part := Part{Name: "T7 terminator", Type: "terminator", Sequence: "CTAGCATAACCCCTTGGGGCCTCTAAACGGGTCTTGAGGGGTTTTTTG"}
+	flank := "GATCGATCGGCTAGCTAGGATCCATGCATG"
+	// three mismatches.
+	mutated := "CTAGCATAACCCGTTGGGGCCTCTAAACGCGTCTTGAGGGGTTTTATG"
+	matches, err := Annotate(flank+mutated+flank, false, Options{Parts: []Part{part}})
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+
+	matches, err = Annotate(flank+mutated+flank, false, Options{Parts: []Part{part}, MinIdentity: 0.9})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.InDelta(t, 45.0/48, matches[0].Identity, 0.001)
+	feature := matches[0].Feature()
+	assert.Equal(t, "terminator", feature.Type)
+	assert.Equal(t, "93.8", feature.Attributes["identity"])
+	assert.Equal(t, "100.0", feature.Attributes["match_length"])
+	assert.Equal(t, "31..78", genbank.BuildLocationString(feature.Location))
+}
+
+func TestAnnotateLinear(t *testing.T) {
+	puc19, err := genbank.Read("../../data/puc19.gbk")
+	require.NoError(t, err)
+	// the ori crosses the origin, so it's only part there in a linear copy.
+	matches, err := Annotate(puc19.Sequence, false, Options{})
+	require.NoError(t, err)
+	for _, match := range matches {
+		assert.NotEqual(t, "ori", match.Part.Name)
+	}
+	matches, err = Annotate(puc19.Sequence, false, Options{MinCoverage: 0.3})
+	require.NoError(t, err)
+	var ori []Match
+	for _, match := range matches {
+		if match.Part.Name == "ori" {
+			ori = append(ori, match)
+		}
+	}
+	require.Len(t, ori, 2)
+	assert.Equal(t, "1..217", genbank.BuildLocationString(ori[0].Location))
+	assert.Equal(t, "2315..2686", genbank.BuildLocationString(ori[1].Location))
+}
+
+func TestParseDatabase(t *testing.T) {
+	parts, err := ParseDatabase(strings.NewReader(">tag|CDS|a tag\natgcatgcatgcatgc\n>bare|misc_feature\nGATTACAGATTACA\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []Part{
+		{Name: "tag", Type: "CDS", Description: "a tag", Sequence: "ATGCATGCATGCATGC"},
+		{Name: "bare", Type: "misc_feature", Sequence: "GATTACAGATTACA"},
+	}, parts)
+
+	_, err = ParseDatabase(strings.NewReader(">untyped\nGATTACAGATTACA\n"))
+	assert.Error(t, err)
+	_, err = ParseDatabase(strings.NewReader(">short|CDS\nGATTACA\n"))
+	assert.Error(t, err)
+}
+
+func TestPipeline(t *testing.T) {
+	puc19, err := genbank.Read("../../data/puc19.gbk")
+	require.NoError(t, err)
+	pipeline := Pipeline(Options{})
+	features, err := pipeline.Annotate(puc19)
+	require.NoError(t, err)
+	assert.Len(t, features, len(puc19Parts))
+	assert.NotEqual(t, pipeline.Version, Pipeline(Options{MinIdentity: 0.8}).Version)
+	assert.Equal(t, pipeline.Version, Pipeline(Options{}).Version)
+}
@@ -0,0 +1,24 @@
+package annotate_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/annotation/annotate"
+	"github.com/bebop/poly/io/genbank"
+)
+
+func ExampleAnnotate() {
+	// a T7 expression cassette with a FLAG tag.
+	cassette := "GATCTCGATCCCGCGAAATTAATACGACTCACTATAGGGGAATTGTGAGCGGATAACAATTCCCCTCTAGAAATAATTTTGTTTAACTTTAAGAAGGAGATATACATATG" +
+		"GACTACAAAGACGATGACGACAAGTAA" + "CTAGCATAACCCCTTGGGGCCTCTAAACGGGTCTTGAGGGGTTTTTTG"
+	matches, _ := annotate.Annotate(cassette, false, annotate.Options{})
+	for _, match := range matches {
+		feature := match.Feature()
+		fmt.Println(feature.Type, feature.Attributes["label"], genbank.BuildLocationString(feature.Location), feature.Attributes["identity"])
+	}
+	// Output:
+	// promoter T7 promoter 20..38 100.0
+	// protein_bind lac operator 43..59 100.0
+	// CDS FLAG 111..134 100.0
+	// terminator T7 terminator 138..185 100.0
+}
@@ -0,0 +1,209 @@
+/*
+Package annotation runs annotation pipelines over collections of records,
+skipping the records it has already annotated.
+
+Annotating a big collection of plasmids or genomes takes a while, and most of
+the time most of the collection hasn't changed since the last run. Rather than
+redo all of it, Annotate remembers the features a pipeline found for every
+sequence in a Store, keyed by the sequence's seqhash and the pipeline's name
+and version. The next run over the same collection only runs the pipeline on
+records that are new or changed, and copies the stored features onto the
+rest, so re-annotating a collection after adding a few records is nearly
+instant.
+
+Bumping a pipeline's Version, like after changing its parameters or the parts
+it matches against, makes every stored result for it stale, so records are
+annotated again the next time they're seen.
+
+Seqhashes are the same for every rotation and strand of a sequence, but
+feature locations aren't. Stored features are only reused for a record with
+exactly the same sequence as the one that was annotated, and a record with a
+rotated or flipped copy of it is annotated again.
+*/
+package annotation
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bebop/poly/cache"
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/seqhash"
+	"lukechampine.com/blake3"
+)
+
+// Pipeline is a named, versioned way to annotate a record.
+type Pipeline struct {
+	Name string
+	// Version identifies the pipeline's code and parameters. Changing it
+	// invalidates everything stored for the pipeline.
+	Version string
+	// Annotate returns the features to add to a record.
+	Annotate func(record genbank.Genbank) ([]genbank.Feature, error)
+}
+
+// Entry is what a Store keeps for an annotated sequence.
+type Entry struct {
+	Seqhash  string `json:"seqhash"`
+	Pipeline string `json:"pipeline"`
+	Version  string `json:"version"`
+	// SequenceDigest is a hash of the exact sequence that was annotated,
+	// which unlike the seqhash differs between rotations and strands.
+	SequenceDigest string            `json:"sequence_digest"`
+	Annotated      time.Time         `json:"annotated"`
+	Features       []genbank.Feature `json:"features"`
+}
+
+/******************************************************************************
+
+Stores begin here.
+
+******************************************************************************/
+
+// Store keeps entries as JSON files in a directory, with the most recently
+// used ones also kept in memory. It's safe to use from multiple goroutines.
+type Store struct {
+	dir    string
+	memory *cache.Cache[string, Entry]
+}
+
+// NewStore returns a Store that keeps entries in dir, creating it if it
+// doesn't exist, and keeps up to capacity entries in memory.
+func NewStore(dir string, capacity int) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir, memory: cache.New[string, Entry](capacity)}, nil
+}
+
+// Key is the name an entry for a seqhash and pipeline is stored under.
+func Key(hash string, pipeline Pipeline) string {
+	// pipeline names and versions can have any characters in them, so
+	// they're hashed to make a safe file name.
+	pipelineHash := blake3.Sum256([]byte(pipeline.Name + "\x00" + pipeline.Version))
+	return hash + "_" + hex.EncodeToString(pipelineHash[:8])
+}
+
+// Get returns the entry stored under key, if there is one.
+func (store *Store) Get(key string) (Entry, bool, error) {
+	if entry, ok := store.memory.Get(key); ok {
+		return entry, true, nil
+	}
+	data, err := os.ReadFile(store.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return Entry{}, false, nil
+	}
+	if err != nil {
+		return Entry{}, false, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return Entry{}, false, fmt.Errorf("reading stored annotation %s: %w", key, err)
+	}
+	store.memory.Add(key, entry)
+	return entry, true, nil
+}
+
+// Put stores an entry under key, replacing any entry already there.
+func (store *Store) Put(key string, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	// entries are written to a temporary file and renamed into place so a
+	// run that's interrupted never leaves half an entry behind.
+	temporary, err := os.CreateTemp(store.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary.Name())
+	if _, err := temporary.Write(data); err != nil {
+		temporary.Close()
+		return err
+	}
+	if err := temporary.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(temporary.Name(), store.path(key)); err != nil {
+		return err
+	}
+	store.memory.Add(key, entry)
+	return nil
+}
+
+func (store *Store) path(key string) string {
+	return filepath.Join(store.dir, key+".json")
+}
+
+/******************************************************************************
+
+Annotation begins here.
+
+******************************************************************************/
+
+// Stats counts what Annotate did with every record.
+type Stats struct {
+	Annotated int // records the pipeline was run on.
+	Reused    int // records given stored features.
+}
+
+// Annotate adds the features pipeline finds to every record, reusing the
+// features stored for records that were annotated by the same pipeline
+// version before, and storing the features of those that weren't.
+func Annotate(records []genbank.Genbank, pipeline Pipeline, store *Store) (Stats, error) {
+	var stats Stats
+	for index := range records {
+		record := &records[index]
+		hash, digest, err := identify(*record)
+		if err != nil {
+			return stats, fmt.Errorf("record %s: %w", record.Meta.Locus.Name, err)
+		}
+		key := Key(hash, pipeline)
+		entry, found, err := store.Get(key)
+		if err != nil {
+			return stats, err
+		}
+		if !found || entry.SequenceDigest != digest {
+			features, err := pipeline.Annotate(*record)
+			if err != nil {
+				return stats, fmt.Errorf("annotating record %s: %w", record.Meta.Locus.Name, err)
+			}
+			entry = Entry{Seqhash: hash, Pipeline: pipeline.Name, Version: pipeline.Version, SequenceDigest: digest, Annotated: time.Now(), Features: features}
+			if err := store.Put(key, entry); err != nil {
+				return stats, err
+			}
+			stats.Annotated++
+		} else {
+			stats.Reused++
+		}
+		for _, feature := range entry.Features {
+			feature := feature
+			_ = record.AddFeature(&feature)
+		}
+	}
+	return stats, nil
+}
+
+// identify returns a record's seqhash and a digest of its exact sequence.
+func identify(record genbank.Genbank) (hash, digest string, err error) {
+	moleculeType := strings.ToUpper(record.Meta.Locus.MoleculeType)
+	sequenceType, doubleStranded := seqhash.DNA, true
+	if strings.Contains(moleculeType, "RNA") {
+		sequenceType, doubleStranded = seqhash.RNA, false
+	}
+	if strings.HasPrefix(moleculeType, "SS-") {
+		doubleStranded = false
+	}
+	hash, err = seqhash.Hash(record.Sequence, sequenceType, record.Meta.Locus.Circular, doubleStranded)
+	if err != nil {
+		return "", "", err
+	}
+	sequenceHash := blake3.Sum256([]byte(strings.ToUpper(record.Sequence)))
+	return hash, hex.EncodeToString(sequenceHash[:]), nil
+}
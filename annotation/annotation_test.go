@@ -0,0 +1,115 @@
+package annotation
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingPipeline finds every GAATTC and counts how often it was run.
+func countingPipeline(version string, runs *int) Pipeline {
+	return Pipeline{Name: "EcoRI sites", Version: version, Annotate: func(record genbank.Genbank) ([]genbank.Feature, error) {
+		*runs++
+		var features []genbank.Feature
+		for start := 0; ; start++ {
+			index := strings.Index(strings.ToUpper(record.Sequence[start:]), "GAATTC")
+			if index == -1 {
+				return features, nil
+			}
+			start += index
+			features = append(features, genbank.Feature{Type: "misc_feature", Attributes: map[string]string{"label": "EcoRI"}, Location: genbank.Location{Start: start, End: start + 6}})
+		}
+	}}
+}
+
+func testRecords(t *testing.T) []genbank.Genbank {
+	puc19, err := genbank.Read("../data/puc19.gbk")
+	require.NoError(t, err)
+	puc19.Features = nil
+	linear := genbank.Genbank{Sequence: "ATGAATTCGGGAATTCTT"}
+	linear.Meta.Locus.Name = "linear"
+	return []genbank.Genbank{puc19, linear}
+}
+
+func TestAnnotate(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir, 8)
+	require.NoError(t, err)
+	runs := 0
+	pipeline := countingPipeline("1", &runs)
+
+	records := testRecords(t)
+	stats, err := Annotate(records, pipeline, store)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Annotated: 2}, stats)
+	assert.Len(t, records[0].Features, 1)
+	assert.Len(t, records[1].Features, 2)
+
+	// a new store over the same directory reads what the first one wrote.
+	store, err = NewStore(dir, 8)
+	require.NoError(t, err)
+	again := testRecords(t)
+	stats, err = Annotate(again, pipeline, store)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Reused: 2}, stats)
+	assert.Equal(t, 2, runs)
+	assert.Equal(t, records[1].Features[1].Location, again[1].Features[1].Location)
+	assert.Same(t, &again[1], again[1].Features[0].ParentSequence)
+
+	// a new version runs the pipeline again.
+	stats, err = Annotate(testRecords(t), countingPipeline("2", &runs), store)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Annotated: 2}, stats)
+}
+
+func TestAnnotateRotated(t *testing.T) {
+	store, err := NewStore(t.TempDir(), 8)
+	require.NoError(t, err)
+	runs := 0
+	pipeline := countingPipeline("1", &runs)
+
+	records := testRecords(t)[:1]
+	_, err = Annotate(records, pipeline, store)
+	require.NoError(t, err)
+
+	// a rotation has the same seqhash but its features are somewhere else.
+	rotated := testRecords(t)[:1]
+	rotated[0].Sequence = rotated[0].Sequence[100:] + rotated[0].Sequence[:100]
+	stats, err := Annotate(rotated, pipeline, store)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Annotated: 1}, stats)
+	assert.Equal(t, records[0].Features[0].Location.Start-100, rotated[0].Features[0].Location.Start)
+}
+
+func TestAnnotateErrors(t *testing.T) {
+	store, err := NewStore(t.TempDir(), 8)
+	require.NoError(t, err)
+	failing := Pipeline{Name: "failing", Version: "1", Annotate: func(genbank.Genbank) ([]genbank.Feature, error) {
+		return nil, errors.New("out of memory")
+	}}
+	_, err = Annotate(testRecords(t), failing, store)
+	assert.ErrorContains(t, err, "out of memory")
+
+	invalid := []genbank.Genbank{{Sequence: "ATGJ"}}
+	_, err = Annotate(invalid, failing, store)
+	assert.Error(t, err)
+}
+
+func TestStoreCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir, 8)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o644))
+	_, _, err = store.Get("broken")
+	assert.Error(t, err)
+
+	_, found, err := store.Get("missing")
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
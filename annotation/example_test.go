@@ -0,0 +1,33 @@
+package annotation_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bebop/poly/annotation"
+	"github.com/bebop/poly/io/genbank"
+)
+
+func ExampleAnnotate() {
+	dir, _ := os.MkdirTemp("", "annotations")
+	defer os.RemoveAll(dir)
+	store, _ := annotation.NewStore(dir, 100)
+
+	pipeline := annotation.Pipeline{Name: "start codons", Version: "1", Annotate: func(record genbank.Genbank) ([]genbank.Feature, error) {
+		start := strings.Index(record.Sequence, "ATG")
+		return []genbank.Feature{{Type: "misc_feature", Location: genbank.Location{Start: start, End: start + 3}}}, nil
+	}}
+
+	records := []genbank.Genbank{{Sequence: "CCATGGCC"}}
+	stats, _ := annotation.Annotate(records, pipeline, store)
+	fmt.Println(stats.Annotated, stats.Reused)
+
+	// the second run reuses what the first one found.
+	records = []genbank.Genbank{{Sequence: "CCATGGCC"}}
+	stats, _ = annotation.Annotate(records, pipeline, store)
+	fmt.Println(stats.Annotated, stats.Reused, records[0].Features[0].Location.Start)
+	// Output:
+	// 1 0
+	// 0 1 2
+}
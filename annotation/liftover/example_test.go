@@ -0,0 +1,29 @@
+package liftover_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/annotation/liftover"
+	"github.com/bebop/poly/io/genbank"
+)
+
+func ExampleLiftover() {
+	source := genbank.Genbank{Sequence: "TTTTTATGGCTAGCAAAGGAGAAGAACTTTTCACTGGAGTTTAATTTTT"}
+	source.Features = []genbank.Feature{
+		{Type: "CDS", Location: genbank.Location{Start: 5, End: 44}},
+		{Type: "misc_feature", Location: genbank.Location{Start: 10, End: 16}},
+	}
+	// the resequenced target has lost a few bases of the misc_feature.
+	target := genbank.Genbank{Sequence: "TTTTTATGGCTAAAGGAGAAGAACTTTTCACTGGAGTTTAATTTTT"}
+
+	result, _ := liftover.Liftover(source, target, liftover.Options{})
+	for _, transfer := range result.Transferred {
+		fmt.Println("transferred", transfer.Target.Type, genbank.BuildLocationString(transfer.Target.Location))
+	}
+	for _, transfer := range result.Failed {
+		fmt.Println("failed", transfer.Source.Type, transfer.Reason)
+	}
+	// Output:
+	// transferred CDS 6..41
+	// failed misc_feature 50.0% of its bases align, below the minimum of 90.0%
+}
@@ -0,0 +1,417 @@
+/*
+Package liftover transfers features from an annotated sequence onto a related
+one that isn't annotated.
+
+Resequencing a plasmid gives back its bases, but none of the features that
+made it worth sequencing. The plasmid it was built from usually has them, and
+most of its features are still there in the new sequence, maybe with a point
+mutation or two, maybe shifted by an insertion, maybe with the whole thing
+read from the other strand or starting somewhere else around the circle.
+
+Liftover aligns the annotated source to the target and moves every feature of
+the source to where its bases ended up. Features that changed too much to
+trust, because too few of their bases align or too many of those that do are
+different, aren't transferred. They're reported as failures instead, along
+with why, so they can be checked by hand.
+
+Unless it's given an alignment, Liftover works out which strand of the target
+matches the source, and for circular targets where around the circle the
+source starts, before aligning them. Since the alignment is banded, sequences
+that differ by more than a few dozen bases in length, or have big
+rearrangements, are best aligned some other way and passed in as an
+alignment.
+*/
+package liftover
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/search/align"
+	"github.com/bebop/poly/transform"
+)
+
+// Options changes how features are lifted over. Zero values are replaced with
+// the defaults noted on each field.
+type Options struct {
+	// MinIdentity is the smallest fraction of a feature's alignment columns
+	// that have to be matches for it to be transferred. Defaults to 0.9.
+	MinIdentity float64
+	// MinCoverage is the smallest fraction of a feature's bases that have to
+	// align to the target for it to be transferred. Defaults to 0.9.
+	MinCoverage float64
+	// Alignment is an alignment of the source, as stringA, to the target, as
+	// stringB. If it's nil, Liftover aligns them itself.
+	Alignment *align.Alignment
+}
+
+// Transfer is what happened to one feature of the source.
+type Transfer struct {
+	Source genbank.Feature // the feature on the source.
+	Target genbank.Feature // the feature on the target, if it was transferred.
+	// Identity is the fraction of the feature's alignment columns that are
+	// matches, and Coverage the fraction of its bases that align.
+	Identity float64
+	Coverage float64
+	Reason   string // why the feature wasn't transferred.
+}
+
+// Result is the outcome of a liftover.
+type Result struct {
+	Target      genbank.Genbank // the target, with the transferred features added.
+	Transferred []Transfer
+	Failed      []Transfer
+}
+
+const (
+	defaultMinIdentity = 0.9
+	defaultMinCoverage = 0.9
+	// band is how far the alignment may stray from the diagonal, on top of
+	// the difference in length of the sequences.
+	band = 64
+	// maxAlignmentCells is the most cells Liftover aligns with.
+	maxAlignmentCells = 1 << 22
+	// anchorLength is the length of the source k-mers looked for in the
+	// target to orient it, and maxAnchors how many are tried.
+	anchorLength = 20
+	maxAnchors   = 16
+)
+
+// Liftover transfers the features of source onto target.
+func Liftover(source, target genbank.Genbank, options Options) (Result, error) {
+	if options.MinIdentity == 0 {
+		options.MinIdentity = defaultMinIdentity
+	}
+	if options.MinCoverage == 0 {
+		options.MinCoverage = defaultMinCoverage
+	}
+
+	var orientation frame
+	var alignment align.Alignment
+	if options.Alignment != nil {
+		alignment = *options.Alignment
+	} else {
+		orientation = orient(source.Sequence, target.Sequence, target.Meta.Locus.Circular)
+		var err error
+		alignment, err = alignSequences(source.Sequence, orientation.apply(target.Sequence))
+		if err != nil {
+			return Result{}, err
+		}
+	}
+	mapping, err := newPositionMap(alignment, len(source.Sequence), len(target.Sequence))
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{Target: target}
+	result.Target.Features = append([]genbank.Feature(nil), target.Features...)
+	for _, feature := range source.Features {
+		transfer := Transfer{Source: feature}
+		transfer.Identity, transfer.Coverage = mapping.score(feature.Location)
+		location, aligned := mapping.lift(feature.Location)
+		switch {
+		case !aligned:
+			transfer.Reason = "none of its bases align to the target"
+		case transfer.Coverage < options.MinCoverage:
+			transfer.Reason = fmt.Sprintf("%.1f%% of its bases align, below the minimum of %.1f%%", 100*transfer.Coverage, 100*options.MinCoverage)
+		case transfer.Identity < options.MinIdentity:
+			transfer.Reason = fmt.Sprintf("its alignment is %.1f%% identical, below the minimum of %.1f%%", 100*transfer.Identity, 100*options.MinIdentity)
+		}
+		if transfer.Reason != "" {
+			result.Failed = append(result.Failed, transfer)
+			continue
+		}
+		lifted := feature
+		lifted.Location = orientation.restore(location, len(target.Sequence))
+		lifted.Attributes = make(map[string]string, len(feature.Attributes))
+		for key, value := range feature.Attributes {
+			lifted.Attributes[key] = value
+		}
+		result.Target.Features = append(result.Target.Features, lifted)
+		transfer.Target = lifted
+		result.Transferred = append(result.Transferred, transfer)
+	}
+	for index := range result.Target.Features {
+		result.Target.Features[index].ParentSequence = &result.Target
+	}
+	for index := range result.Transferred {
+		result.Transferred[index].Target.ParentSequence = &result.Target
+	}
+	return result, nil
+}
+
+// alignSequences globally aligns source to target, both oriented the same
+// way.
+func alignSequences(source, target string) (align.Alignment, error) {
+	width := align.GlobalBand(len(source), len(target), band)
+	if len(source)*min(2*width.Band+1, len(target)+1) > maxAlignmentCells {
+		return align.Alignment{}, errors.New("sequences are too long or too different in length to align; pass an alignment in Options instead")
+	}
+	scoring, err := align.NewAffineScoring(nil, -5, -1)
+	if err != nil {
+		return align.Alignment{}, err
+	}
+	return align.NeedlemanWunschAffine(strings.ToUpper(source), strings.ToUpper(target), scoring, width)
+}
+
+/******************************************************************************
+
+Orienting begins here.
+
+A resequenced plasmid can be read from either strand, and start anywhere
+around the circle. Before aligning, the target is reverse complemented if
+that's the strand matching the source, and rotated so the source's start lines
+up with its start, by finding k-mers of the source in it. Lifted locations are
+then moved back from the oriented target to the target itself.
+
+******************************************************************************/
+
+// frame is how a target was oriented to align to the source: reverse
+// complemented if flipped, then rotated to start at origin.
+type frame struct {
+	flipped bool
+	origin  int
+}
+
+// orient works out how to orient target to match source.
+func orient(source, target string, circular bool) frame {
+	upperSource, upperTarget := strings.ToUpper(source), strings.ToUpper(target)
+	length := min(anchorLength, len(source))
+	if length == 0 || len(target) == 0 {
+		return frame{}
+	}
+	step := max((len(source)-length)/maxAnchors, 1)
+	for offset := 0; offset+length <= len(source); offset += step {
+		anchor := upperSource[offset : offset+length]
+		for _, flipped := range []bool{false, true} {
+			oriented := upperTarget
+			if flipped {
+				oriented = transform.ReverseComplement(upperTarget)
+			}
+			searched := oriented
+			if circular {
+				searched += oriented[:min(length-1, len(oriented))]
+			}
+			position := strings.Index(searched, anchor)
+			if position == -1 {
+				continue
+			}
+			if !circular {
+				return frame{flipped: flipped}
+			}
+			return frame{flipped: flipped, origin: ((position-offset)%len(target) + len(target)) % len(target)}
+		}
+	}
+	return frame{}
+}
+
+// apply orients a target.
+func (orientation frame) apply(target string) string {
+	if orientation.flipped {
+		target = transform.ReverseComplement(target)
+	}
+	return target[orientation.origin:] + target[:orientation.origin]
+}
+
+// restore moves a location on the oriented target back to the target, of
+// length bases.
+func (orientation frame) restore(location genbank.Location, length int) genbank.Location {
+	if orientation.origin != 0 {
+		location = unrotate(location, orientation.origin, length)
+	}
+	if orientation.flipped {
+		location = flip(location, length)
+	}
+	return location
+}
+
+// unrotate moves a location on a sequence rotated to start at origin back to
+// the sequence, splitting leaves that cross its origin into a join of their
+// two halves.
+func unrotate(location genbank.Location, origin, length int) genbank.Location {
+	if len(location.SubLocations) > 0 {
+		subLocations := make([]genbank.Location, len(location.SubLocations))
+		for index, subLocation := range location.SubLocations {
+			subLocations[index] = unrotate(subLocation, origin, length)
+		}
+		location.SubLocations = subLocations
+		return location
+	}
+	shifted := location.Shift(origin)
+	switch {
+	case !shifted.Overlaps(genbank.Location{Start: 0, End: length}):
+		return shifted.Shift(-length)
+	case !shifted.Overlaps(genbank.Location{Start: length, End: 2 * length}):
+		return shifted
+	}
+	before := genbank.Location{Start: shifted.Start, End: length, FivePrimePartial: location.FivePrimePartial}
+	after := genbank.Location{Start: 0, End: shifted.End - length, ThreePrimePartial: location.ThreePrimePartial}
+	return genbank.Location{Join: true, Complement: location.Complement, SubLocations: []genbank.Location{before, after}}
+}
+
+// flip moves a location on the reverse complement of a sequence back to the
+// sequence. Complementing every leaf, rather than the whole location, keeps
+// the parts of joins in the right order.
+func flip(location genbank.Location, length int) genbank.Location {
+	if len(location.SubLocations) > 0 {
+		subLocations := make([]genbank.Location, len(location.SubLocations))
+		for index, subLocation := range location.SubLocations {
+			subLocations[index] = flip(subLocation, length)
+		}
+		location.SubLocations = subLocations
+		return location
+	}
+	location.Start, location.End = length-location.End, length-location.Start
+	location.FivePrimePartial, location.ThreePrimePartial = location.ThreePrimePartial, location.FivePrimePartial
+	location.Complement = !location.Complement
+	return location
+}
+
+/******************************************************************************
+
+Position mapping begins here.
+
+An alignment says which base of the target every base of the source lined up
+with, if any. Features are lifted leaf by leaf, from the first of their bases
+that aligned to the last, and are scored by the alignment columns between
+their first and last bases.
+
+******************************************************************************/
+
+// positionMap is an alignment read off base by base.
+type positionMap struct {
+	// target is the position in the target each base of the source aligns
+	// to, or -1.
+	target []int
+	// matches, aligned, and insertions count, before each base of the source,
+	// the bases that matched, the bases that aligned, and the bases of the
+	// target inserted after the previous base.
+	matches, aligned, insertions []int
+}
+
+// newPositionMap reads an alignment of a source of sourceLength bases to a
+// target of targetLength bases.
+func newPositionMap(alignment align.Alignment, sourceLength, targetLength int) (positionMap, error) {
+	if len(alignment.AlignedA) != len(alignment.AlignedB) {
+		return positionMap{}, errors.New("the aligned sequences aren't the same length")
+	}
+	mapping := positionMap{
+		target:     make([]int, sourceLength),
+		matches:    make([]int, sourceLength+1),
+		aligned:    make([]int, sourceLength+1),
+		insertions: make([]int, sourceLength+1),
+	}
+	for index := range mapping.target {
+		mapping.target[index] = -1
+	}
+	sourcePosition, targetPosition := alignment.StartA, alignment.StartB
+	for column := 0; column < len(alignment.AlignedA); column++ {
+		sourceBase, targetBase := alignment.AlignedA[column], alignment.AlignedB[column]
+		if sourcePosition > sourceLength || targetPosition > targetLength || (sourcePosition == sourceLength && sourceBase != '-') || (targetPosition == targetLength && targetBase != '-') {
+			return positionMap{}, fmt.Errorf("the alignment doesn't fit a %d base source and %d base target", sourceLength, targetLength)
+		}
+		switch {
+		case sourceBase == '-' && targetBase == '-':
+		case sourceBase == '-':
+			mapping.insertions[sourcePosition]++
+			targetPosition++
+		case targetBase == '-':
+			sourcePosition++
+		default:
+			mapping.target[sourcePosition] = targetPosition
+			mapping.aligned[sourcePosition+1]++
+			if strings.EqualFold(alignment.AlignedA[column:column+1], alignment.AlignedB[column:column+1]) {
+				mapping.matches[sourcePosition+1]++
+			}
+			sourcePosition++
+			targetPosition++
+		}
+	}
+	// turn the counts into running totals.
+	for index := 1; index <= sourceLength; index++ {
+		mapping.matches[index] += mapping.matches[index-1]
+		mapping.aligned[index] += mapping.aligned[index-1]
+		mapping.insertions[index] += mapping.insertions[index-1]
+	}
+	return mapping, nil
+}
+
+// score returns the identity and coverage of a location's alignment.
+func (mapping positionMap) score(location genbank.Location) (identity, coverage float64) {
+	// bases past the ends of the source can't align, so they count against
+	// coverage.
+	bases, columns, matches, aligned := location.Length(), 0, 0, 0
+	for _, leaf := range leaves(location) {
+		start, end := mapping.clamp(leaf)
+		if start >= end {
+			continue
+		}
+		// insertions between the leaf's first and last base.
+		columns += end - start + mapping.insertions[end-1] - mapping.insertions[start]
+		matches += mapping.matches[end] - mapping.matches[start]
+		aligned += mapping.aligned[end] - mapping.aligned[start]
+	}
+	if bases == 0 {
+		return 0, 0
+	}
+	return float64(matches) / float64(columns), float64(aligned) / float64(bases)
+}
+
+// lift moves a location to where its bases aligned on the target. It returns
+// false if none of them did.
+func (mapping positionMap) lift(location genbank.Location) (genbank.Location, bool) {
+	location.GbkLocationString = ""
+	if len(location.SubLocations) > 0 {
+		var subLocations []genbank.Location
+		for _, subLocation := range location.SubLocations {
+			if lifted, aligned := mapping.lift(subLocation); aligned {
+				subLocations = append(subLocations, lifted)
+			}
+		}
+		switch len(subLocations) {
+		case 0:
+			return genbank.Location{}, false
+		case 1:
+			only := subLocations[0]
+			only.Complement = only.Complement != location.Complement
+			return only, true
+		}
+		location.SubLocations = subLocations
+		return location, true
+	}
+
+	start, end := mapping.clamp(location)
+	first, last := start, end-1
+	for first < end && mapping.target[first] == -1 {
+		first++
+	}
+	for last >= first && mapping.target[last] == -1 {
+		last--
+	}
+	if first > last {
+		return genbank.Location{}, false
+	}
+	location.FivePrimePartial = location.FivePrimePartial || first > location.Start
+	location.ThreePrimePartial = location.ThreePrimePartial || last < max(location.End, location.Start+1)-1
+	location.Start, location.End = mapping.target[first], mapping.target[last]+1
+	return location, true
+}
+
+// clamp returns the range of the source a leaf covers.
+func (mapping positionMap) clamp(leaf genbank.Location) (start, end int) {
+	return max(leaf.Start, 0), min(max(leaf.End, leaf.Start+1), len(mapping.target))
+}
+
+// leaves flattens a location into the locations that carry coordinates.
+func leaves(location genbank.Location) []genbank.Location {
+	if len(location.SubLocations) == 0 {
+		return []genbank.Location{location}
+	}
+	var flattened []genbank.Location
+	for _, subLocation := range location.SubLocations {
+		flattened = append(flattened, leaves(subLocation)...)
+	}
+	return flattened
+}
@@ -0,0 +1,151 @@
+package liftover
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/search/align"
+	"github.com/bebop/poly/transform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unannotated returns a copy of a sequence with no features.
+func unannotated(sequence genbank.Genbank, bases string) genbank.Genbank {
+	target := sequence
+	target.Sequence = bases
+	target.Features = nil
+	return target
+}
+
+func TestLiftoverRotatedAndFlipped(t *testing.T) {
+	puc19, err := genbank.Read("../../data/puc19.gbk")
+	require.NoError(t, err)
+	for _, origin := range []int{0, 1, 1000, 2685} {
+		for _, flipped := range []bool{false, true} {
+			bases := puc19.Sequence[origin:] + puc19.Sequence[:origin]
+			if flipped {
+				bases = transform.ReverseComplement(bases)
+			}
+			result, err := Liftover(puc19, unannotated(puc19, bases), Options{})
+			require.NoError(t, err)
+			assert.Empty(t, result.Failed)
+			require.Len(t, result.Transferred, len(puc19.Features))
+			for _, transfer := range result.Transferred {
+				assert.Equal(t, 1.0, transfer.Identity)
+				assert.Equal(t, 1.0, transfer.Coverage)
+				want, err := transfer.Source.Location.Extract(puc19.Sequence)
+				require.NoError(t, err)
+				got, err := transfer.Target.Location.Extract(bases)
+				require.NoError(t, err)
+				assert.Equal(t, want, got, "%s at origin %d, flipped %v", transfer.Source.Type, origin, flipped)
+			}
+		}
+	}
+}
+
+func TestLiftoverEdited(t *testing.T) {
+	puc19, err := genbank.Read("../../data/puc19.gbk")
+	require.NoError(t, err)
+	var promoter genbank.Feature
+	for _, feature := range puc19.Features {
+		if feature.Attributes["label"] == "AmpR promoter" {
+			promoter = feature
+		}
+	}
+	require.NotEmpty(t, promoter.Type)
+
+	// an insertion before the promoter shifts it, and changing a third of its
+	// bases makes it fail.
+	start, end := promoter.Location.Start, promoter.Location.End
+	var scrambled strings.Builder
+	for index := start; index < end; index++ {
+		base := puc19.Sequence[index]
+		if (index-start)%3 == 0 {
+			base = map[byte]byte{'a': 'c', 'c': 'g', 'g': 't', 't': 'a'}[base|0x20]
+		}
+		scrambled.WriteByte(base)
+	}
+	bases := puc19.Sequence[:start-10] + "GGGGGG" + puc19.Sequence[start-10:start] + scrambled.String() + puc19.Sequence[end:]
+	result, err := Liftover(puc19, unannotated(puc19, bases), Options{})
+	require.NoError(t, err)
+	require.Len(t, result.Failed, 1)
+	assert.Equal(t, "AmpR promoter", result.Failed[0].Source.Attributes["label"])
+	assert.InDelta(t, 2.0/3, result.Failed[0].Identity, 0.01)
+	assert.Contains(t, result.Failed[0].Reason, "identical")
+	assert.Len(t, result.Transferred, len(puc19.Features)-1)
+	assert.Len(t, result.Target.Features, len(puc19.Features)-1)
+
+	// a lower threshold lets it through, six bases along.
+	result, err = Liftover(puc19, unannotated(puc19, bases), Options{MinIdentity: 0.5})
+	require.NoError(t, err)
+	assert.Empty(t, result.Failed)
+	for _, transfer := range result.Transferred {
+		if transfer.Source.Attributes["label"] == "AmpR promoter" {
+			assert.Equal(t, genbank.Location{Start: start + 6, End: end + 6}, transfer.Target.Location)
+		}
+	}
+}
+
+func TestLiftoverDeleted(t *testing.T) {
+	source := genbank.Genbank{Sequence: "AAAAACCCCCGGGGGTTTTTACGTACGTACGTACGTACGT"}
+	source.Features = []genbank.Feature{
+		{Type: "misc_feature", Location: genbank.Location{Start: 5, End: 15}},
+		{Type: "gene", Location: genbank.Location{Start: 20, End: 30}},
+	}
+	target := genbank.Genbank{Sequence: "AAAAATTTTTACGTACGTACGTACGTACGT"}
+	result, err := Liftover(source, target, Options{})
+	require.NoError(t, err)
+	require.Len(t, result.Failed, 1)
+	assert.Equal(t, "misc_feature", result.Failed[0].Source.Type)
+	assert.Equal(t, 0.0, result.Failed[0].Coverage)
+	require.Len(t, result.Transferred, 1)
+	assert.Equal(t, genbank.Location{Start: 10, End: 20}, result.Transferred[0].Target.Location)
+}
+
+func TestLiftoverPartial(t *testing.T) {
+	source := genbank.Genbank{Sequence: "ACGTTGCAAGCTTGCATGCCTGCAGGTCGACTCTAGAGGATCC"}
+	source.Features = []genbank.Feature{{Type: "misc_feature", Location: genbank.Location{Start: 0, End: 20}}}
+	// the target is missing the feature's first two bases.
+	target := genbank.Genbank{Sequence: source.Sequence[2:]}
+	result, err := Liftover(source, target, Options{})
+	require.NoError(t, err)
+	require.Len(t, result.Transferred, 1)
+	assert.Equal(t, 0.9, result.Transferred[0].Coverage)
+	assert.Equal(t, "<1..18", genbank.BuildLocationString(result.Transferred[0].Target.Location))
+}
+
+func TestLiftoverJoin(t *testing.T) {
+	source := genbank.Genbank{Sequence: "ATGAAACCCGGGTTTTAGCATGCATGCATGCATGCAAAGGGCCCTTTTAA"}
+	source.Meta.Locus.Circular = true
+	join := genbank.Location{Join: true, SubLocations: []genbank.Location{{Start: 0, End: 12}, {Start: 36, End: 50}}}
+	source.Features = []genbank.Feature{{Type: "CDS", Location: join}}
+	target := source
+	target.Sequence = transform.ReverseComplement(source.Sequence[30:] + source.Sequence[:30])
+	target.Features = nil
+	result, err := Liftover(source, target, Options{})
+	require.NoError(t, err)
+	require.Len(t, result.Transferred, 1)
+	want, err := join.Extract(source.Sequence)
+	require.NoError(t, err)
+	got, err := result.Transferred[0].Target.Location.Extract(target.Sequence)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestLiftoverAlignment(t *testing.T) {
+	source := genbank.Genbank{Sequence: "GATTACAGATTACA"}
+	source.Features = []genbank.Feature{{Type: "misc_feature", Location: genbank.Location{Start: 7, End: 14}}}
+	target := genbank.Genbank{Sequence: "CCGATTACA"}
+	// the second GATTACA is the one that's kept.
+	alignment := align.Alignment{StartA: 7, EndA: 14, StartB: 2, EndB: 9, AlignedA: "GATTACA", AlignedB: "GATTACA"}
+	result, err := Liftover(source, target, Options{Alignment: &alignment})
+	require.NoError(t, err)
+	require.Len(t, result.Transferred, 1)
+	assert.Equal(t, genbank.Location{Start: 2, End: 9}, result.Transferred[0].Target.Location)
+
+	alignment.StartB = 5
+	_, err = Liftover(source, target, Options{Alignment: &alignment})
+	assert.Error(t, err)
+}
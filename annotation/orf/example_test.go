@@ -0,0 +1,18 @@
+package orf_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/annotation/orf"
+	"github.com/bebop/poly/io/genbank"
+)
+
+func ExampleFind() {
+	// a short ORF, flanked by stop codons in every frame.
+	sequence := "TAATAATAA" + "ATGGCTAGCAAAGGAGAAGAACTTTTCACTGGAGTTGTCCCAATTTAA" + "TTATTATTA"
+	orfs, _ := orf.Find(sequence, orf.Options{MinLength: 10})
+	for _, found := range orfs {
+		fmt.Println(found.Frame, genbank.BuildLocationString(found.Location), found.Protein)
+	}
+	// Output: 1 10..57 MASKGEELFTGVVPI
+}
@@ -0,0 +1,323 @@
+/*
+Package orf finds open reading frames.
+
+An open reading frame (ORF) is a stretch of codons from a start codon to the
+next stop codon in the same frame. Any sequence has six frames to read, three
+on each strand, and a long ORF in any of them is a good hint there's a gene
+there, since random sequence runs into a stop codon every twenty or so
+codons.
+
+Which codons start and stop a frame depends on who's reading it. Find takes
+any NCBI translation table, so the same sequence can be scanned as a
+bacterium (table 11), a mitochondrion (tables 2, 4, 5...), or a ciliate
+(table 6, where TAA and TAG code for glutamine), or with a code that isn't
+in nature at all, from the geneticcode package. Bacteria start a fair number
+of their genes at GTG and TTG as well as ATG, so alternative start codons can
+be turned on too.
+
+Every stop codon can have several start codons upstream of it in frame, each
+making an ORF nested in the one before it. By default only the longest of
+them is kept, since that's the one an annotator would usually report, but
+all of them or only those not inside any longer ORF can be kept instead.
+
+ORFs can be turned into CDS features to add to a record with Feature.
+*/
+package orf
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bebop/poly/geneticcode"
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/synthesis/codon"
+	"github.com/bebop/poly/transform"
+)
+
+// NestedMode is how ORFs sharing a stop codon, or inside each other, are
+// handled.
+type NestedMode int
+
+const (
+	// Longest keeps one ORF per stop codon, from its most upstream start.
+	Longest NestedMode = iota
+	// AllStarts keeps an ORF from every start codon, so ORFs nested in the
+	// same frame are all kept.
+	AllStarts
+	// Outermost keeps one ORF per stop codon, like Longest, and then drops
+	// any ORF that's entirely inside a longer one in any frame.
+	Outermost
+)
+
+// Options changes how ORFs are found. Zero values are replaced with the
+// defaults noted on each field.
+type Options struct {
+	// Table is the NCBI translation table to read codons with. Defaults to
+	// 11, the bacterial, archaeal, and plant plastid code.
+	Table int
+	// Code is a genetic code to read codons with instead of Table, like a
+	// recoded one made with geneticcode.Reassign.
+	Code geneticcode.Code
+	// MinLength is the fewest amino acids an ORF can encode, not counting
+	// its stop codon. Defaults to 75.
+	MinLength int
+	// AlternativeStarts starts ORFs at every start codon of Table, like GTG
+	// and TTG in table 11, rather than only at ATG.
+	AlternativeStarts bool
+	// Nested is how nested ORFs are handled. Defaults to Longest.
+	Nested NestedMode
+	// Circular reads through the end of the sequence into its start.
+	Circular bool
+	// IncludePartial keeps ORFs that run off the end of a linear sequence
+	// before reaching a stop codon.
+	IncludePartial bool
+}
+
+// ORF is an open reading frame.
+type ORF struct {
+	// Start and End are where the ORF is on the top strand, as a half-open
+	// range that includes the start and stop codons. End is past the end of
+	// a circular sequence for ORFs that cross its origin.
+	Start, End int
+	// Frame is 1, 2, or 3 for ORFs on the top strand, and -1, -2, or -3 for
+	// ORFs on the bottom strand, counting from the start of each strand.
+	Frame      int
+	StartCodon string
+	// Protein is the ORF's translation, without its stop. The start codon is
+	// always read as methionine.
+	Protein string
+	// Partial is whether the ORF runs off the end of the sequence without a
+	// stop codon.
+	Partial bool
+	// Location is where the ORF is, as a feature location.
+	Location genbank.Location
+}
+
+// Find finds the ORFs of a sequence on both strands, sorted by where they
+// start.
+func Find(sequence string, options Options) ([]ORF, error) {
+	if options.Table == 0 {
+		options.Table = 11
+	}
+	if options.MinLength == 0 {
+		options.MinLength = 75
+	}
+	code := options.Code
+	if code.AminoAcids == "" {
+		var err error
+		code, err = geneticcode.Get(options.Table)
+		if err != nil {
+			return nil, err
+		}
+	}
+	table, err := codon.NewTranslationTableFromCode(code)
+	if err != nil {
+		return nil, err
+	}
+	starts := map[string]bool{"ATG": true}
+	if options.AlternativeStarts {
+		for _, startCodon := range table.StartCodons {
+			starts[startCodon] = true
+		}
+	}
+	stops := make(map[string]bool)
+	for _, stopCodon := range table.StopCodons {
+		stops[stopCodon] = true
+	}
+
+	sequence = strings.ToUpper(sequence)
+	length := len(sequence)
+	var orfs []ORF
+	for _, reverse := range []bool{false, true} {
+		strand := sequence
+		if reverse {
+			strand = transform.ReverseComplement(sequence)
+		}
+		for _, found := range scan(strand, table, starts, stops, options) {
+			orf := found
+			frame := orf.Start%3 + 1
+			if reverse {
+				// positions on the bottom strand are mirrored onto the top.
+				orf.Start, orf.End = length-found.End, length-found.Start
+				if orf.Start < 0 {
+					orf.Start, orf.End = orf.Start+length, orf.End+length
+				}
+				frame = -frame
+			}
+			orf.Frame = frame
+			orf.Location = location(orf, length, reverse)
+			orfs = append(orfs, orf)
+		}
+	}
+
+	if options.Nested == Outermost {
+		orfs = outermost(orfs, length, options.Circular)
+	}
+	sort.SliceStable(orfs, func(i, j int) bool {
+		if orfs[i].Start != orfs[j].Start {
+			return orfs[i].Start < orfs[j].Start
+		}
+		return orfs[i].End > orfs[j].End
+	})
+	return orfs, nil
+}
+
+// scan finds the ORFs of one strand, with positions on that strand.
+func scan(strand string, table *codon.TranslationTable, starts, stops map[string]bool, options Options) []ORF {
+	length := len(strand)
+	extended := strand
+	if options.Circular {
+		// reading two copies of a circular sequence, plus enough to finish a
+		// codon, reads every stop codon with the whole circle upstream of it.
+		extended = strand + strand + strand[:min(2, length)]
+	}
+	var orfs []ORF
+	for frame := 0; frame < 3; frame++ {
+		var upstream []int // start codons since the last stop codon.
+		for position := frame; position+3 <= len(extended); position += 3 {
+			triplet := extended[position : position+3]
+			switch {
+			case stops[triplet]:
+				// in a circular sequence, every stop is read in the second copy
+				// so nothing upstream of it is cut off.
+				if !options.Circular || (position >= length && position < 2*length) {
+					orfs = append(orfs, orfsEndingAt(extended, upstream, position+3, length, table, options, false)...)
+				}
+				upstream = upstream[:0]
+			case starts[triplet]:
+				upstream = append(upstream, position)
+			}
+		}
+		if options.IncludePartial && !options.Circular && len(upstream) > 0 {
+			end := frame + (len(extended)-frame)/3*3
+			orfs = append(orfs, orfsEndingAt(extended, upstream, end, length, table, options, true)...)
+		}
+	}
+	return orfs
+}
+
+// orfsEndingAt makes the ORFs from start codons upstream that end at end.
+func orfsEndingAt(extended string, upstream []int, end, length int, table *codon.TranslationTable, options Options, partial bool) []ORF {
+	var orfs []ORF
+	for _, start := range upstream {
+		// an ORF can't be longer than a circular sequence.
+		if options.Circular && end-start > length {
+			continue
+		}
+		coding := extended[start:end]
+		if !partial {
+			coding = coding[:len(coding)-3]
+		}
+		if len(coding)/3 >= options.MinLength {
+			orf := ORF{Start: start, End: end, StartCodon: extended[start : start+3], Protein: translate(coding, table), Partial: partial}
+			if orf.Start >= length {
+				orf.Start, orf.End = orf.Start-length, orf.End-length
+			}
+			orfs = append(orfs, orf)
+		}
+		if options.Nested != AllStarts {
+			break
+		}
+	}
+	return orfs
+}
+
+// translate translates codons, reading the first as methionine and codons
+// with ambiguous bases as X.
+func translate(coding string, table *codon.TranslationTable) string {
+	var protein strings.Builder
+	protein.WriteByte('M')
+	for position := 3; position+3 <= len(coding); position += 3 {
+		aminoAcid, ok := table.TranslationMap[coding[position:position+3]]
+		if !ok {
+			aminoAcid = "X"
+		}
+		protein.WriteString(aminoAcid)
+	}
+	return protein.String()
+}
+
+// location returns where an ORF is as a feature location. Partial ORFs run
+// off the end of the sequence they're read from, which is their start on the
+// top strand when they're read from the bottom strand.
+func location(orf ORF, length int, reverse bool) genbank.Location {
+	location := genbank.RangeLocation(orf.Start, orf.End, length, reverse)
+	if !orf.Partial {
+		return location
+	}
+	leaf := &location
+	if location.Join && reverse {
+		leaf = &location.SubLocations[0]
+	} else if location.Join {
+		leaf = &location.SubLocations[len(location.SubLocations)-1]
+	}
+	if reverse {
+		leaf.FivePrimePartial = true
+	} else {
+		leaf.ThreePrimePartial = true
+	}
+	return location
+}
+
+// outermost drops ORFs that are entirely inside a longer ORF.
+func outermost(orfs []ORF, length int, circular bool) []ORF {
+	var kept []ORF
+	for index, orf := range orfs {
+		contained := false
+		for otherIndex, other := range orfs {
+			if otherIndex == index || other.End-other.Start < orf.End-orf.Start {
+				continue
+			}
+			// ORFs as long as each other only count as contained once, so one
+			// of them is kept.
+			if other.End-other.Start == orf.End-orf.Start && otherIndex > index {
+				continue
+			}
+			offset := orf.Start - other.Start
+			if circular && offset < 0 {
+				offset += length
+			}
+			if offset >= 0 && offset+orf.End-orf.Start <= other.End-other.Start {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			kept = append(kept, orf)
+		}
+	}
+	return kept
+}
+
+// Feature returns an ORF as a CDS feature, translated with table.
+func (orf ORF) Feature(table int) genbank.Feature {
+	if table == 0 {
+		table = 11
+	}
+	strand := "+"
+	if orf.Frame < 0 {
+		strand = ""
+	}
+	return genbank.Feature{
+		Type: "CDS",
+		Attributes: map[string]string{
+			"label":        fmt.Sprintf("ORF frame %s%d", strand, orf.Frame),
+			"note":         fmt.Sprintf("%d aa open reading frame starting at %s", len(orf.Protein), orf.StartCodon),
+			"codon_start":  "1",
+			"transl_table": strconv.Itoa(table),
+			"translation":  orf.Protein,
+		},
+		Location: orf.Location,
+	}
+}
+
+// Features returns ORFs as CDS features, translated with table.
+func Features(orfs []ORF, table int) []genbank.Feature {
+	features := make([]genbank.Feature, len(orfs))
+	for index, orf := range orfs {
+		features[index] = orf.Feature(table)
+	}
+	return features
+}
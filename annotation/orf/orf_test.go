@@ -0,0 +1,161 @@
+package orf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/geneticcode"
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/transform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gfp = "ATGGCTAGCAAAGGAGAAGAACTTTTCACTGGAGTTGTCCCAATTCTTGTTGAATTAGATGGTGATGTTAATGGGCACAAATTTTCTGTCAGTGGAGAGGGTGAAGGTGATGCTACATACGGAAAGCTTACCCTTAAATTTATTTGCACTACTGGAAAACTACCTGTTCCATGGCCAACACTTGTCACTACTTTCTCTTATGGTGTTCAATGCTTTTCCCGTTATCCGGATCATATGAAACGGCATGACTTTTTCAAGAGTGCCATGCCCGAAGGTTATGTACAGGAACGCACTATATCTTTCAAAGATGACGGGAACTACAAGACGCGTGCTGAAGTCAAGTTTGAAGGTGATACCCTTGTTAATCGTATCGAGTTAAAAGGTATTGATTTTAAAGAAGATGGAAACATTCTCGGACACAAACTCGAGTACAACTATAACTCACACAATGTATACATCACGGCAGACAAACAAAAGAATGGAATCAAAGCTAACTTCAAAATTCGCCACAACATTGAAGATGGATCCGTTCAACTAGCAGACCATTATCAACAAAATACTCCAATTGGCGATGGCCCTGTCCTTTTACCAGACAACCATTACCTGTCGACACAATCTGCCCTTTCGAAAGATCCCAACGAAAAGCGTGACCACATGGTCCTTCTTGAGTTTGTAACTGCTGCTGGGATTACACATGGCATGGATGAGCTCTACAAATAA"
+
+const gfpProtein = "MASKGEELFTGVVPILVELDGDVNGHKFSVSGEGEGDATYGKLTLKFICTTGKLPVPWPTLVTTFSYGVQCFSRYPDHMKRHDFFKSAMPEGYVQERTISFKDDGNYKTRAEVKFEGDTLVNRIELKGIDFKEDGNILGHKLEYNYNSHNVYITADKQKNGIKANFKIRHNIEDGSVQLADHYQQNTPIGDGPVLLPDNHYLSTQSALSKDPNEKRDHMVLLEFVTAAGITHGMDELYK"
+
+// spacer has a stop codon in every frame of both strands.
+const spacer = "TAATAATAATTATTATTA"
+
+func TestFindForward(t *testing.T) {
+	orfs, err := Find(spacer+gfp+spacer, Options{})
+	require.NoError(t, err)
+	require.Len(t, orfs, 1)
+	orf := orfs[0]
+	assert.Equal(t, len(spacer), orf.Start)
+	assert.Equal(t, len(spacer)+len(gfp), orf.End)
+	assert.Equal(t, len(spacer)%3+1, orf.Frame)
+	assert.Equal(t, gfpProtein, orf.Protein)
+	assert.Equal(t, "ATG", orf.StartCodon)
+	assert.Equal(t, "19..738", genbank.BuildLocationString(orf.Location))
+}
+
+func TestFindReverse(t *testing.T) {
+	orfs, err := Find(spacer+transform.ReverseComplement(gfp)+"A", Options{})
+	require.NoError(t, err)
+	require.Len(t, orfs, 1)
+	assert.Equal(t, len(spacer), orfs[0].Start)
+	assert.Equal(t, len(spacer)+len(gfp), orfs[0].End)
+	assert.Equal(t, -2, orfs[0].Frame)
+	assert.Equal(t, gfpProtein, orfs[0].Protein)
+	assert.Equal(t, "complement(19..738)", genbank.BuildLocationString(orfs[0].Location))
+}
+
+func TestFindCircular(t *testing.T) {
+	plasmid := spacer + gfp
+	rotated := plasmid[300:] + plasmid[:300]
+
+	orfs, err := Find(rotated, Options{})
+	require.NoError(t, err)
+	assert.Empty(t, orfs)
+
+	orfs, err = Find(rotated, Options{Circular: true})
+	require.NoError(t, err)
+	require.Len(t, orfs, 1)
+	start := len(plasmid) - 300 + len(spacer)
+	assert.Equal(t, start, orfs[0].Start)
+	assert.Equal(t, start+len(gfp), orfs[0].End)
+	assert.Equal(t, gfpProtein, orfs[0].Protein)
+	assert.Equal(t, "join(457..738,1..438)", genbank.BuildLocationString(orfs[0].Location))
+
+	// and on the bottom strand.
+	orfs, err = Find(transform.ReverseComplement(rotated), Options{Circular: true})
+	require.NoError(t, err)
+	require.Len(t, orfs, 1)
+	assert.Equal(t, gfpProtein, orfs[0].Protein)
+	assert.Equal(t, "complement(join(301..738,1..282))", genbank.BuildLocationString(orfs[0].Location))
+}
+
+func TestFindTable(t *testing.T) {
+	// GFP ends in TAA, which ciliates read as glutamine.
+	gfpORF := func(orfs []ORF) (ORF, bool) {
+		for _, orf := range orfs {
+			if orf.Start == len(spacer) && orf.Frame == 1 {
+				return orf, true
+			}
+		}
+		return ORF{}, false
+	}
+	orfs, err := Find(spacer+gfp, Options{Table: 6})
+	require.NoError(t, err)
+	_, found := gfpORF(orfs)
+	assert.False(t, found)
+
+	orfs, err = Find(spacer+gfp, Options{Table: 6, IncludePartial: true})
+	require.NoError(t, err)
+	orf, found := gfpORF(orfs)
+	require.True(t, found)
+	assert.True(t, orf.Partial)
+	assert.Equal(t, gfpProtein+"Q", orf.Protein)
+	assert.Equal(t, "19..>738", genbank.BuildLocationString(orf.Location))
+
+	_, err = Find(gfp, Options{Table: 7})
+	assert.Error(t, err)
+}
+
+func TestFindCode(t *testing.T) {
+	// an amber codon early in GFP, read through with amber suppression.
+	amberGFP := gfp[:30] + "TAG" + gfp[33:]
+	bacterial, err := geneticcode.Get(11)
+	require.NoError(t, err)
+	amber, err := bacterial.Reassign("TAG", 'O')
+	require.NoError(t, err)
+
+	orfs, err := Find(spacer+amberGFP+spacer, Options{})
+	require.NoError(t, err)
+	for _, orf := range orfs {
+		assert.NotEqual(t, len(spacer), orf.Start)
+	}
+
+	// other frames open up too, since TAG no longer stops them.
+	orfs, err = Find(spacer+amberGFP+spacer, Options{Code: amber})
+	require.NoError(t, err)
+	require.NotEmpty(t, orfs)
+	assert.Equal(t, len(spacer), orfs[0].Start)
+	assert.Equal(t, len(spacer)+len(gfp), orfs[0].End)
+	assert.Equal(t, gfpProtein[:10]+"O"+gfpProtein[11:], orfs[0].Protein)
+}
+
+func TestFindAlternativeStarts(t *testing.T) {
+	sequence := "GTG" + strings.Repeat("GCT", 10) + "TAA"
+	orfs, err := Find(sequence, Options{MinLength: 10})
+	require.NoError(t, err)
+	assert.Empty(t, orfs)
+
+	orfs, err = Find(sequence, Options{MinLength: 10, AlternativeStarts: true})
+	require.NoError(t, err)
+	require.Len(t, orfs, 1)
+	assert.Equal(t, "GTG", orfs[0].StartCodon)
+	assert.Equal(t, "M"+strings.Repeat("A", 10), orfs[0].Protein)
+}
+
+func TestFindNested(t *testing.T) {
+	// an ORF with a second in-frame ATG, and a shorter ORF inside it in
+	// another frame.
+	sequence := "ATG" + strings.Repeat("GCT", 5) + "ATG" + "CATGGCTGCTGCTGCTTAAGC" + "TAA"
+	longest, err := Find(sequence, Options{MinLength: 4})
+	require.NoError(t, err)
+	assert.Len(t, longest, 2)
+
+	all, err := Find(sequence, Options{MinLength: 4, Nested: AllStarts})
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	outermost, err := Find(sequence, Options{MinLength: 4, Nested: Outermost})
+	require.NoError(t, err)
+	require.Len(t, outermost, 1)
+	assert.Equal(t, 0, outermost[0].Start)
+	assert.Equal(t, len(sequence), outermost[0].End)
+}
+
+func TestFeatures(t *testing.T) {
+	orfs, err := Find(spacer+transform.ReverseComplement(gfp), Options{})
+	require.NoError(t, err)
+	features := Features(orfs, 0)
+	require.Len(t, features, 1)
+	assert.Equal(t, "CDS", features[0].Type)
+	assert.Equal(t, gfpProtein, features[0].Attributes["translation"])
+	assert.Equal(t, "11", features[0].Attributes["transl_table"])
+	assert.Equal(t, "ORF frame -1", features[0].Attributes["label"])
+}
@@ -0,0 +1,20 @@
+package terminator_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/annotation/terminator"
+	"github.com/bebop/poly/io/genbank"
+)
+
+func ExampleFind() {
+	// the rrnB T1 terminator, between two stretches without any runs of Ts.
+	sequence := "GATCGATCGGCTAGCTAGG" + "CAAATAAAACGAAAGGCTCAGTCGAAAGACTGGGCCTTTCGTTTTAT" + "GATCGATCGGCTAGCTAGG"
+	terminators, _ := terminator.Find(sequence, terminator.Options{})
+	for _, found := range terminators {
+		if !found.Complement {
+			fmt.Println(genbank.BuildLocationString(found.Location), found.Hairpin, found.Structure, found.Tail)
+		}
+	}
+	// Output: 29..68 CGAAAGGCUCAGUCGAAAGACUGGGCCUUUCG ((((((((.(((((....))))).)))))))) UUUUAUGA
+}
@@ -0,0 +1,329 @@
+/*
+Package terminator finds intrinsic, rho-independent transcription terminators
+and estimates how well they terminate.
+
+Bacterial RNA polymerase stops transcribing when the RNA it's making folds
+into a stable hairpin just before a run of Us. The hairpin stalls the
+polymerase, and the weak rA:dU pairs of the run can't hold the RNA to the
+template while it's stalled, so the transcript falls off. Terminators built
+this way don't need any protein to work, which makes them easy to find from
+sequence alone, and they're the terminators synthetic biologists put after
+their genes.
+
+They also turn up where they aren't wanted. A hairpin and a run of Ts inside
+a coding sequence, or between the genes of an operon, can cut transcription
+short, and finding those before building a construct saves debugging it
+after.
+
+Find looks for runs of Ts on both strands, folds the RNA just upstream of
+each run with the fold package, and keeps runs with a hairpin closing right
+before them. Each is scored by the free energy of its hairpin and by how
+T-rich its tail is, weighting the first bases of the tail most, as in
+d'Aubenton Carafa et al. 1990 (https://doi.org/10.1016/S0022-2836(05)80269-X).
+The two are combined into an estimated termination efficiency by a logistic
+model with hand-set weights. It's a rough estimate, good for ranking candidates and for flagging
+the strong ones, but terminators should be measured where their strength
+matters.
+*/
+package terminator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bebop/poly/alphabet"
+	"github.com/bebop/poly/checks"
+	"github.com/bebop/poly/fold"
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/transform"
+)
+
+// Options changes how terminators are found. Zero values are replaced with
+// the defaults noted on each field.
+type Options struct {
+	// Temperature is what hairpins are folded at, in Celsius. Defaults to
+	// 37.
+	Temperature float64
+	// MinEfficiency is the lowest estimated efficiency of terminators that
+	// are kept. Defaults to 0.5.
+	MinEfficiency float64
+	// MaxHairpinLength is the longest hairpin looked for. Defaults to 40.
+	MaxHairpinLength int
+	// Circular finds terminators across the origin of the sequence.
+	Circular bool
+}
+
+// Terminator is a rho-independent terminator.
+type Terminator struct {
+	// Start and End are where the terminator is on the top strand, as a
+	// half-open range from the start of its hairpin to the end of its tail.
+	// End is past the end of a circular sequence for terminators that cross
+	// its origin.
+	Start, End int
+	// Complement is whether the terminator ends transcription on the bottom
+	// strand.
+	Complement bool
+	// Hairpin is the RNA of the hairpin, and Structure its dot-bracket
+	// structure.
+	Hairpin   string
+	Structure string
+	// Tail is the RNA just after the hairpin.
+	Tail string
+	// HairpinEnergy is the free energy of the hairpin, in kcal/mol.
+	HairpinEnergy float64
+	// TailScore is how U-rich the tail is, from 0 to about 7.
+	TailScore float64
+	// Efficiency is the estimated fraction of transcripts that end at the
+	// terminator.
+	Efficiency float64
+	// Location is where the terminator is, as a feature location.
+	Location genbank.Location
+}
+
+const (
+	defaultTemperature      = 37
+	defaultMinEfficiency    = 0.5
+	defaultMaxHairpinLength = 40
+	// tailLength is how many bases after a hairpin are scored as its tail.
+	tailLength = 15
+	// keptTail is how many of them are part of the terminator.
+	keptTail = 8
+	// minTailScore is the lowest tail score of runs that are folded, about
+	// that of three Ts in a row.
+	minTailScore = 2.4
+	// minStem is the fewest base pairs of a hairpin, and maxGap the most
+	// bases between a hairpin and its tail.
+	minStem = 4
+	maxGap  = 2
+	// weights of the efficiency model. They weren't fitted to measured
+	// efficiencies, and no dataset is behind them. They were set by hand so
+	// that every kcal/mol of hairpin raises the odds of termination by about
+	// a third, every point of tail score (about one T next to the hairpin)
+	// raises them about fivefold, and the odds are even for a -15.5 kcal/mol
+	// hairpin with the weakest tail that's folded, or a -12 kcal/mol hairpin
+	// followed by four Ts. A fit to measured efficiencies, like those of Chen
+	// et al. 2013 (https://doi.org/10.1038/nmeth.2515), would do better.
+	energyWeight = 0.3
+	tailWeight   = 1.6
+	intercept    = -8.5
+)
+
+// Find finds the terminators of a sequence on both strands, sorted by where
+// they start.
+func Find(sequence string, options Options) ([]Terminator, error) {
+	if options.Temperature == 0 {
+		options.Temperature = defaultTemperature
+	}
+	if options.MinEfficiency == 0 {
+		options.MinEfficiency = defaultMinEfficiency
+	}
+	if options.MaxHairpinLength == 0 {
+		options.MaxHairpinLength = defaultMaxHairpinLength
+	}
+
+	sequence = strings.ToUpper(sequence)
+	length := len(sequence)
+	var terminators []Terminator
+	for _, complement := range []bool{false, true} {
+		strand := sequence
+		if complement {
+			strand = transform.ReverseComplement(sequence)
+		}
+		found, err := scan(strand, options)
+		if err != nil {
+			return nil, err
+		}
+		for _, terminator := range found {
+			terminator.Complement = complement
+			if complement {
+				// positions on the bottom strand are mirrored onto the top.
+				terminator.Start, terminator.End = length-terminator.End, length-terminator.Start
+				if terminator.Start < 0 {
+					terminator.Start, terminator.End = terminator.Start+length, terminator.End+length
+				}
+			}
+			terminator.Location = genbank.RangeLocation(terminator.Start, terminator.End, length, complement)
+			terminators = append(terminators, terminator)
+		}
+	}
+	return strongest(terminators), nil
+}
+
+// scan finds the terminators of one strand, with positions on that strand.
+func scan(strand string, options Options) ([]Terminator, error) {
+	length := len(strand)
+	extended, offset := strand, 0
+	if options.Circular && length > 0 {
+		// enough of either end is added to the other to read every
+		// terminator with all of its hairpin and tail.
+		upstream := min(options.MaxHairpinLength+maxGap, length)
+		extended = strand[length-upstream:] + strand + strand[:min(tailLength, length)]
+		offset = upstream
+	}
+
+	var terminators []Terminator
+	for tail := offset; tail < offset+length; tail++ {
+		// a tail starts at the first T of a run.
+		if extended[tail] != 'T' || (tail > 0 && extended[tail-1] == 'T') {
+			continue
+		}
+		tailScore := score(extended[tail:min(tail+tailLength, len(extended))])
+		if tailScore < minTailScore {
+			continue
+		}
+		window := extended[max(tail-options.MaxHairpinLength-maxGap, 0):tail]
+		if !checks.IsDNA(window) {
+			continue
+		}
+		hairpinStart, hairpinEnd, ok, err := findHairpin(window, options.Temperature)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		hairpin := alphabet.DNAToRNA(window[hairpinStart:hairpinEnd])
+		result, err := fold.Zuker(hairpin, options.Temperature)
+		if err != nil {
+			return nil, err
+		}
+		energy := result.MinimumFreeEnergy()
+		terminator := Terminator{
+			Start:         tail - len(window) + hairpinStart - offset,
+			Hairpin:       hairpin,
+			Structure:     pad(result.DotBracket(), len(hairpin)),
+			Tail:          alphabet.DNAToRNA(extended[tail:min(tail+keptTail, len(extended))]),
+			HairpinEnergy: energy,
+			TailScore:     tailScore,
+			Efficiency:    efficiency(energy, tailScore),
+		}
+		terminator.End = tail + len(terminator.Tail) - offset
+		if terminator.Start < 0 {
+			terminator.Start, terminator.End = terminator.Start+length, terminator.End+length
+		}
+		if terminator.Efficiency >= options.MinEfficiency {
+			terminators = append(terminators, terminator)
+		}
+	}
+	return terminators, nil
+}
+
+// findHairpin folds the RNA of window, and returns the range of the hairpin
+// closing at most maxGap bases from its end, if there's one with at least
+// minStem base pairs.
+func findHairpin(window string, temperature float64) (start, end int, ok bool, err error) {
+	if len(window) < 2*minStem+3 {
+		return 0, 0, false, nil
+	}
+	result, err := fold.Zuker(alphabet.DNAToRNA(window), temperature)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	structure := pad(result.DotBracket(), len(window))
+	closing := strings.LastIndexByte(structure, ')')
+	if closing == -1 || closing < len(window)-1-maxGap {
+		return 0, 0, false, nil
+	}
+	// walk back to the base the hairpin's outermost pair opens with.
+	depth, opening := 0, -1
+	for index := closing; index >= 0; index-- {
+		switch structure[index] {
+		case ')':
+			depth++
+		case '(':
+			depth--
+		}
+		if depth == 0 {
+			opening = index
+			break
+		}
+	}
+	hairpin := structure[opening : closing+1]
+	// a hairpin is one stem, so every pair opens before any closes.
+	if strings.Contains(strings.ReplaceAll(hairpin, ".", ""), ")(") {
+		return 0, 0, false, nil
+	}
+	if strings.Count(hairpin, "(") < minStem {
+		return 0, 0, false, nil
+	}
+	return opening, closing + 1, true, nil
+}
+
+// score scores how T-rich a tail is. Every base after the first makes the
+// ones after it count less, Ts by a little and other bases by more, and the
+// score is the sum of what the Ts count for.
+func score(tail string) float64 {
+	weight, total := 1.0, 0.0
+	for index := 0; index < len(tail); index++ {
+		if tail[index] == 'T' {
+			weight *= 0.9
+			total += weight
+		} else {
+			weight *= 0.6
+		}
+	}
+	return total
+}
+
+// efficiency estimates the termination efficiency of a terminator from the
+// free energy of its hairpin and the score of its tail, with the hand-set
+// weights above.
+func efficiency(hairpinEnergy, tailScore float64) float64 {
+	return 1 / (1 + math.Exp(-(energyWeight*-hairpinEnergy + tailWeight*tailScore + intercept)))
+}
+
+// pad pads a dot-bracket structure, which leaves off trailing unpaired
+// bases, to length.
+func pad(structure string, length int) string {
+	return structure + strings.Repeat(".", max(length-len(structure), 0))
+}
+
+// strongest keeps the most efficient of the terminators that overlap on the
+// same strand, and sorts them by where they start.
+func strongest(terminators []Terminator) []Terminator {
+	sort.SliceStable(terminators, func(i, j int) bool {
+		return terminators[i].Efficiency > terminators[j].Efficiency
+	})
+	var kept []Terminator
+	for _, terminator := range terminators {
+		overlapping := false
+		for _, other := range kept {
+			if other.Complement == terminator.Complement && other.Location.Overlaps(terminator.Location) {
+				overlapping = true
+				break
+			}
+		}
+		if !overlapping {
+			kept = append(kept, terminator)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].Start < kept[j].Start
+	})
+	return kept
+}
+
+// Feature returns a terminator as a terminator feature.
+func (terminator Terminator) Feature() genbank.Feature {
+	return genbank.Feature{
+		Type: "terminator",
+		Attributes: map[string]string{
+			"label": "terminator",
+			"note": fmt.Sprintf("predicted rho-independent terminator, %s%% estimated efficiency, %s kcal/mol hairpin",
+				strconv.FormatFloat(100*terminator.Efficiency, 'f', 0, 64), strconv.FormatFloat(terminator.HairpinEnergy, 'f', 1, 64)),
+		},
+		Location: terminator.Location,
+	}
+}
+
+// Features returns terminators as terminator features.
+func Features(terminators []Terminator) []genbank.Feature {
+	features := make([]genbank.Feature, len(terminators))
+	for index, terminator := range terminators {
+		features[index] = terminator.Feature()
+	}
+	return features
+}
@@ -0,0 +1,125 @@
+package terminator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/transform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rrnB T1 is the first terminator of the E. coli rrnB operon.
+const rrnBT1 = "CAAATAAAACGAAAGGCTCAGTCGAAAGACTGGGCCTTTCGTTTTAT"
+
+// flank has no run of Ts to end a terminator with.
+const flank = "GATCGATCGGCTAGCTAGGATCCATGCATG"
+
+func TestFind(t *testing.T) {
+	sequence := flank + rrnBT1 + flank
+	terminators, err := Find(sequence, Options{})
+	require.NoError(t, err)
+	var forward []Terminator
+	for _, terminator := range terminators {
+		if !terminator.Complement {
+			forward = append(forward, terminator)
+		}
+	}
+	require.Len(t, forward, 1)
+	terminator := forward[0]
+	assert.Equal(t, "CGAAAGGCUCAGUCGAAAGACUGGGCCUUUCG", terminator.Hairpin)
+	assert.Equal(t, "((((((((.(((((....))))).))))))))", terminator.Structure)
+	assert.Equal(t, "UUUUAUGA", terminator.Tail)
+	assert.Less(t, terminator.HairpinEnergy, -20.0)
+	assert.Greater(t, terminator.Efficiency, 0.95)
+	assert.Equal(t, strings.Index(sequence, "CGAAAGG"), terminator.Start)
+	assert.Equal(t, terminator.Start+len(terminator.Hairpin)+len(terminator.Tail), terminator.End)
+	assert.Equal(t, genbank.Location{Start: terminator.Start, End: terminator.End}, terminator.Location)
+}
+
+func TestFindComplement(t *testing.T) {
+	sequence := flank + rrnBT1 + flank
+	forward, err := Find(sequence, Options{})
+	require.NoError(t, err)
+	reverse, err := Find(transform.ReverseComplement(sequence), Options{})
+	require.NoError(t, err)
+	require.Equal(t, len(forward), len(reverse))
+	for index, terminator := range reverse {
+		mirrored := forward[len(forward)-1-index]
+		assert.NotEqual(t, mirrored.Complement, terminator.Complement)
+		assert.Equal(t, len(sequence)-mirrored.End, terminator.Start)
+		assert.Equal(t, mirrored.Hairpin, terminator.Hairpin)
+		rna, err := terminator.Location.Extract(transform.ReverseComplement(sequence))
+		require.NoError(t, err)
+		assert.Equal(t, terminator.Hairpin+terminator.Tail, strings.ReplaceAll(rna, "T", "U"))
+	}
+}
+
+func TestFindCircular(t *testing.T) {
+	sequence := flank + rrnBT1 + flank
+	// rotated so the terminator's hairpin crosses the origin.
+	origin := len(flank) + 20
+	rotated := sequence[origin:] + sequence[:origin]
+	linear, err := Find(rotated, Options{})
+	require.NoError(t, err)
+	for _, terminator := range linear {
+		assert.NotEqual(t, "UUUUAUGA", terminator.Tail)
+	}
+
+	circular, err := Find(rotated, Options{Circular: true})
+	require.NoError(t, err)
+	var found *Terminator
+	for index := range circular {
+		if circular[index].Tail == "UUUUAUGA" {
+			found = &circular[index]
+		}
+	}
+	require.NotNil(t, found)
+	assert.Greater(t, found.End, len(rotated))
+	assert.True(t, found.Location.Join)
+	rna, err := found.Location.Extract(rotated)
+	require.NoError(t, err)
+	assert.Equal(t, found.Hairpin+found.Tail, strings.ReplaceAll(rna, "T", "U"))
+}
+
+func TestFindNone(t *testing.T) {
+	terminators, err := Find(strings.Repeat(flank, 5), Options{})
+	require.NoError(t, err)
+	assert.Empty(t, terminators)
+
+	// a run of Ts without a hairpin isn't a terminator.
+	terminators, err = Find(flank+"TTTTTTTT"+flank, Options{})
+	require.NoError(t, err)
+	assert.Empty(t, terminators)
+}
+
+func TestScore(t *testing.T) {
+	assert.Equal(t, 0.0, score(""))
+	assert.Equal(t, 0.0, score("GGGG"))
+	assert.InDelta(t, 0.9, score("T"), 1e-9)
+	assert.InDelta(t, 0.9+0.9*0.6*0.9, score("TGT"), 1e-9)
+	assert.InDelta(t, 7.15, score(strings.Repeat("T", tailLength)), 0.01)
+}
+
+func TestEfficiency(t *testing.T) {
+	assert.Greater(t, efficiency(-25, 5), efficiency(-15, 5))
+	assert.Greater(t, efficiency(-15, 5), efficiency(-15, 3))
+	assert.Less(t, efficiency(-5, 2), 0.1)
+	assert.Greater(t, efficiency(-25, 5), 0.99)
+	// the even odds the weights were set for.
+	assert.InDelta(t, 0.5, efficiency(-15.5, minTailScore), 0.01)
+	assert.InDelta(t, 0.5, efficiency(-12, score("TTTTA")), 0.02)
+}
+
+func TestFeatures(t *testing.T) {
+	terminators, err := Find(flank+rrnBT1+flank, Options{})
+	require.NoError(t, err)
+	features := Features(terminators)
+	require.Len(t, features, len(terminators))
+	for index, feature := range features {
+		assert.Equal(t, "terminator", feature.Type)
+		assert.Equal(t, terminators[index].Location, feature.Location)
+		assert.Contains(t, feature.Attributes["note"], "estimated efficiency")
+	}
+}
@@ -0,0 +1,200 @@
+/*
+Package cache provides a small, concurrency safe, size limited LRU cache.
+
+Lots of things in poly are expensive to build and cheap to reuse: codon tables,
+parsed REBASE data, energy parameters, registries of parts. Building them once
+per request is wasteful, but stuffing them into an unbounded global map is a
+great way to run a long lived service out of memory.
+
+Cache is a least recently used (LRU) cache with a fixed capacity. When a new
+entry would push it over capacity the entry that was used least recently is
+evicted. Every method is safe to call from multiple goroutines.
+
+Hooks let you plug in whatever metrics you like (Prometheus counters, logs,
+etc.) without this package depending on any of them, and Stats gives you
+running totals for free.
+
+	tables := cache.New[int, *codon.TranslationTable](32)
+	table, err := tables.GetOrLoad(11, func() (*codon.TranslationTable, error) {
+		return codon.NewTranslationTable(11)
+	})
+*/
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Hooks are optional callbacks for metrics. They are called while the cache
+// is locked, so they should be fast and must not call back into the cache.
+type Hooks[K comparable, V any] struct {
+	Hit   func(key K)
+	Miss  func(key K)
+	Evict func(key K, value V)
+}
+
+// Stats are running totals of cache activity.
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// entry is what is stored in the recency list.
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// Cache is a size limited least recently used cache. The zero value is not
+// usable, use New.
+type Cache[K comparable, V any] struct {
+	mutex    sync.Mutex
+	capacity int
+	entries  map[K]*list.Element
+	recency  *list.List // front is most recently used.
+	hooks    Hooks[K, V]
+	stats    Stats
+}
+
+// New returns an empty Cache that holds at most capacity entries. A capacity
+// below one is treated as one.
+func New[K comparable, V any](capacity int) *Cache[K, V] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Cache[K, V]{
+		capacity: capacity,
+		entries:  make(map[K]*list.Element),
+		recency:  list.New(),
+	}
+}
+
+// SetHooks replaces the cache's metrics hooks.
+func (cache *Cache[K, V]) SetHooks(hooks Hooks[K, V]) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.hooks = hooks
+}
+
+// Get returns the value stored for key and marks it as recently used.
+func (cache *Cache[K, V]) Get(key K) (V, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	element, ok := cache.entries[key]
+	if !ok {
+		cache.stats.Misses++
+		if cache.hooks.Miss != nil {
+			cache.hooks.Miss(key)
+		}
+		var zero V
+		return zero, false
+	}
+	cache.stats.Hits++
+	if cache.hooks.Hit != nil {
+		cache.hooks.Hit(key)
+	}
+	cache.recency.MoveToFront(element)
+	return element.Value.(*entry[K, V]).value, true
+}
+
+// Add stores value for key, evicting the least recently used entry if the
+// cache is full. It reports whether an entry was evicted.
+func (cache *Cache[K, V]) Add(key K, value V) bool {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if element, ok := cache.entries[key]; ok {
+		element.Value.(*entry[K, V]).value = value
+		cache.recency.MoveToFront(element)
+		return false
+	}
+	cache.entries[key] = cache.recency.PushFront(&entry[K, V]{key: key, value: value})
+	if cache.recency.Len() <= cache.capacity {
+		return false
+	}
+	cache.removeElement(cache.recency.Back())
+	cache.stats.Evictions++
+	return true
+}
+
+// GetOrLoad returns the value stored for key, calling load to create and
+// store it on a miss. Errors from load are returned and nothing is stored.
+//
+// load is called without holding the cache's lock so slow loads don't block
+// other keys. Two goroutines missing on the same key at the same time may
+// both call load, in which case the last value stored wins.
+func (cache *Cache[K, V]) GetOrLoad(key K, load func() (V, error)) (V, error) {
+	if value, ok := cache.Get(key); ok {
+		return value, nil
+	}
+	value, err := load()
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	cache.Add(key, value)
+	return value, nil
+}
+
+// Remove deletes key from the cache, reporting whether it was present.
+// Removed entries do not count as evictions.
+func (cache *Cache[K, V]) Remove(key K) bool {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	element, ok := cache.entries[key]
+	if !ok {
+		return false
+	}
+	cache.recency.Remove(element)
+	delete(cache.entries, key)
+	return true
+}
+
+// removeElement evicts an element, calling the Evict hook.
+func (cache *Cache[K, V]) removeElement(element *list.Element) {
+	evicted := cache.recency.Remove(element).(*entry[K, V])
+	delete(cache.entries, evicted.key)
+	if cache.hooks.Evict != nil {
+		cache.hooks.Evict(evicted.key, evicted.value)
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (cache *Cache[K, V]) Len() int {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	return cache.recency.Len()
+}
+
+// Capacity returns the maximum number of entries the cache holds.
+func (cache *Cache[K, V]) Capacity() int {
+	return cache.capacity
+}
+
+// Keys returns the cached keys from most to least recently used.
+func (cache *Cache[K, V]) Keys() []K {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	keys := make([]K, 0, cache.recency.Len())
+	for element := cache.recency.Front(); element != nil; element = element.Next() {
+		keys = append(keys, element.Value.(*entry[K, V]).key)
+	}
+	return keys
+}
+
+// Purge removes every entry from the cache without calling the Evict hook.
+// Stats are kept.
+func (cache *Cache[K, V]) Purge() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.entries = make(map[K]*list.Element)
+	cache.recency.Init()
+}
+
+// Stats returns a snapshot of the cache's running totals.
+func (cache *Cache[K, V]) Stats() Stats {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	return cache.stats
+}
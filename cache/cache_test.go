@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestEviction(t *testing.T) {
+	cache := New[string, int](2)
+	var evicted []string
+	cache.SetHooks(Hooks[string, int]{Evict: func(key string, _ int) { evicted = append(evicted, key) }})
+
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Get("a") // b is now the least recently used.
+	if cache.Add("c", 3) != true {
+		t.Errorf("expected adding a third entry to evict")
+	}
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("expected b to be evicted")
+	}
+	if want := []string{"b"}; !reflect.DeepEqual(evicted, want) {
+		t.Errorf("evicted %v, expected %v", evicted, want)
+	}
+	if want := []string{"c", "a"}; !reflect.DeepEqual(cache.Keys(), want) {
+		t.Errorf("keys %v, expected %v", cache.Keys(), want)
+	}
+
+	// updating an existing key doesn't evict.
+	if cache.Add("a", 10) {
+		t.Errorf("expected updating a key not to evict")
+	}
+	if value, _ := cache.Get("a"); value != 10 {
+		t.Errorf("expected updated value 10, got %d", value)
+	}
+}
+
+func TestStatsAndHooks(t *testing.T) {
+	cache := New[int, int](1)
+	var hits, misses int
+	cache.SetHooks(Hooks[int, int]{
+		Hit:  func(int) { hits++ },
+		Miss: func(int) { misses++ },
+	})
+	cache.Get(1)
+	cache.Add(1, 1)
+	cache.Get(1)
+	cache.Add(2, 2)
+	cache.Get(1)
+
+	expected := Stats{Hits: 1, Misses: 2, Evictions: 1}
+	if stats := cache.Stats(); stats != expected {
+		t.Errorf("stats %+v, expected %+v", stats, expected)
+	}
+	if hits != 1 || misses != 2 {
+		t.Errorf("hooks saw %d hits and %d misses, expected 1 and 2", hits, misses)
+	}
+}
+
+func TestRemoveAndPurge(t *testing.T) {
+	cache := New[int, string](0)
+	if cache.Capacity() != 1 {
+		t.Errorf("expected capacity below one to be treated as one, got %d", cache.Capacity())
+	}
+	cache.Add(1, "one")
+	if !cache.Remove(1) || cache.Remove(1) {
+		t.Errorf("expected Remove to report presence")
+	}
+	cache.Add(2, "two")
+	cache.Purge()
+	if cache.Len() != 0 {
+		t.Errorf("expected empty cache after Purge, got %d entries", cache.Len())
+	}
+	if cache.Stats().Evictions != 0 {
+		t.Errorf("Remove and Purge should not count as evictions")
+	}
+}
+
+func TestGetOrLoad(t *testing.T) {
+	cache := New[string, int](4)
+	loads := 0
+	load := func() (int, error) {
+		loads++
+		return 42, nil
+	}
+	for i := 0; i < 3; i++ {
+		value, err := cache.GetOrLoad("answer", load)
+		if err != nil || value != 42 {
+			t.Errorf("GetOrLoad returned %d, %v", value, err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("expected 1 load, got %d", loads)
+	}
+
+	loadErr := errors.New("nope")
+	if _, err := cache.GetOrLoad("broken", func() (int, error) { return 0, loadErr }); !errors.Is(err, loadErr) {
+		t.Errorf("expected load error, got %v", err)
+	}
+	if _, ok := cache.Get("broken"); ok {
+		t.Errorf("failed loads should not be cached")
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	cache := New[string, int](64)
+	var waitGroup sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		waitGroup.Add(1)
+		go func(worker int) {
+			defer waitGroup.Done()
+			for i := 0; i < 1000; i++ {
+				key := strconv.Itoa((worker*1000 + i) % 200)
+				_, _ = cache.GetOrLoad(key, func() (int, error) { return i, nil })
+				cache.Keys()
+			}
+		}(worker)
+	}
+	waitGroup.Wait()
+	if cache.Len() > cache.Capacity() {
+		t.Errorf("cache grew to %d entries, over its capacity of %d", cache.Len(), cache.Capacity())
+	}
+}
+
+func BenchmarkGetOrLoad(b *testing.B) {
+	cache := New[int, int](1024)
+	for i := 0; i < b.N; i++ {
+		_, _ = cache.GetOrLoad(i%2048, func() (int, error) { return i, nil })
+	}
+}
@@ -0,0 +1,39 @@
+package cache_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/cache"
+	"github.com/bebop/poly/synthesis/codon"
+)
+
+func ExampleCache_GetOrLoad() {
+	tables := cache.New[int, *codon.TranslationTable](8)
+
+	for i := 0; i < 3; i++ {
+		table, _ := tables.GetOrLoad(11, func() (*codon.TranslationTable, error) {
+			return codon.NewTranslationTable(11)
+		})
+		protein, _ := table.Translate("ATGAAATAA")
+		fmt.Println(protein)
+	}
+	fmt.Printf("%+v\n", tables.Stats())
+
+	// Output:
+	// MK*
+	// MK*
+	// MK*
+	// {Hits:2 Misses:1 Evictions:0}
+}
+
+func ExampleCache_Add() {
+	recent := cache.New[string, string](2)
+	recent.Add("puc19", "circular")
+	recent.Add("pichia", "linear")
+	recent.Get("puc19")
+	recent.Add("phix174", "circular") // evicts pichia, the least recently used.
+
+	fmt.Println(recent.Keys())
+
+	// Output: [phix174 puc19]
+}
@@ -43,12 +43,14 @@ package clone
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
 	"sort"
 	"strings"
 
 	"github.com/bebop/poly/checks"
 	"github.com/bebop/poly/seqhash"
+	"github.com/bebop/poly/sequence"
 	"github.com/bebop/poly/transform"
 )
 
@@ -61,6 +63,14 @@ type Part struct {
 	Circular bool
 }
 
+// NewPart returns a Part of a DNA sequence, keeping its topology.
+func NewPart(input sequence.Sequence) (Part, error) {
+	if input.Molecule != sequence.DNA {
+		return Part{}, fmt.Errorf("%s is %s, only DNA parts can be cloned", input.Name, input.Molecule)
+	}
+	return Part{Sequence: input.Sequence, Circular: input.Circular}, nil
+}
+
 // Overhang is a struct that represents the ends of a linearized sequence where Enzymes had cut.
 type Overhang struct {
 	Length                        int
@@ -144,39 +154,7 @@ func CutWithEnzyme(part Part, directional bool, enzyme Enzyme) []Fragment {
 		sequence = strings.ToUpper(part.Sequence)
 	}
 
-	// Check for palindromes
-	palindromic := checks.IsPalindromic(enzyme.RecognitionSite)
-
-	// Find and define overhangs
-	var overhangs []Overhang
-	var forwardOverhangs []Overhang
-	var reverseOverhangs []Overhang
-	forwardCuts := enzyme.RegexpFor.FindAllStringIndex(sequence, -1)
-	for _, forwardCut := range forwardCuts {
-		forwardOverhangs = append(forwardOverhangs, Overhang{Length: enzyme.OverheadLength, Position: forwardCut[1] + enzyme.Skip, Forward: true, RecognitionSitePlusSkipLength: len(enzyme.RecognitionSite) + enzyme.Skip})
-	}
-	// Palindromic enzymes won't need reverseCuts
-	if !palindromic {
-		reverseCuts := enzyme.RegexpRev.FindAllStringIndex(sequence, -1)
-		for _, reverseCut := range reverseCuts {
-			reverseOverhangs = append(reverseOverhangs, Overhang{Length: enzyme.OverheadLength, Position: reverseCut[0] - enzyme.Skip, Forward: false, RecognitionSitePlusSkipLength: len(enzyme.RecognitionSite) + enzyme.Skip})
-		}
-	}
-
-	// If, on a linear sequence, the last overhang's position + EnzymeSkip + EnzymeOverhangLength is over the length of the sequence, remove that overhang.
-	for _, overhangSet := range [][]Overhang{forwardOverhangs, reverseOverhangs} {
-		if len(overhangSet) > 0 {
-			if !part.Circular && (overhangSet[len(overhangSet)-1].Position+enzyme.Skip+enzyme.OverheadLength > len(sequence)) {
-				overhangSet = overhangSet[:len(overhangSet)-1]
-			}
-		}
-		overhangs = append(overhangs, overhangSet...)
-	}
-
-	// Sort overhangs
-	sort.SliceStable(overhangs, func(i, j int) bool {
-		return overhangs[i].Position < overhangs[j].Position
-	})
+	overhangs, forwardOverhangs, palindromic := findOverhangs(part, sequence, enzyme)
 
 	// Convert Overhangs into Fragments
 	var fragments []Fragment
@@ -241,12 +219,12 @@ func CutWithEnzyme(part Part, directional bool, enzyme Enzyme) []Fragment {
 				}
 				// We have to subtract RecognitionSitePlusSkipLength in case we have a recognition site on
 				// one side of the origin of a circular sequence and the cut site on the other side of the origin
-				if nextOverhang.Position-nextOverhang.RecognitionSitePlusSkipLength > len(part.Sequence) {
+				if nextOverhang.Position-nextOverhang.RecognitionSitePlusSkipLength >= len(part.Sequence) {
 					break
 				}
 			} else {
 				fragmentSequences = append(fragmentSequences, sequence[currentOverhang.Position:nextOverhang.Position])
-				if nextOverhang.Position-nextOverhang.RecognitionSitePlusSkipLength > len(part.Sequence) {
+				if nextOverhang.Position-nextOverhang.RecognitionSitePlusSkipLength >= len(part.Sequence) {
 					break
 				}
 			}
@@ -267,6 +245,45 @@ func CutWithEnzyme(part Part, directional bool, enzyme Enzyme) []Fragment {
 	return fragments
 }
 
+// findOverhangs finds every cut an enzyme makes in sequence, which is
+// part.Sequence doubled for circular parts, sorted by position. It also
+// returns the forward cuts on their own and whether the enzyme is palindromic.
+func findOverhangs(part Part, sequence string, enzyme Enzyme) (overhangs []Overhang, forwardOverhangs []Overhang, palindromic bool) {
+	// Check for palindromes
+	palindromic = checks.IsPalindromic(enzyme.RecognitionSite)
+
+	// Find and define overhangs
+	var reverseOverhangs []Overhang
+	forwardCuts := enzyme.RegexpFor.FindAllStringIndex(sequence, -1)
+	for _, forwardCut := range forwardCuts {
+		forwardOverhangs = append(forwardOverhangs, Overhang{Length: enzyme.OverheadLength, Position: forwardCut[1] + enzyme.Skip, Forward: true, RecognitionSitePlusSkipLength: len(enzyme.RecognitionSite) + enzyme.Skip})
+	}
+	// Palindromic enzymes won't need reverseCuts
+	if !palindromic {
+		reverseCuts := enzyme.RegexpRev.FindAllStringIndex(sequence, -1)
+		for _, reverseCut := range reverseCuts {
+			reverseOverhangs = append(reverseOverhangs, Overhang{Length: enzyme.OverheadLength, Position: reverseCut[0] - enzyme.Skip, Forward: false, RecognitionSitePlusSkipLength: len(enzyme.RecognitionSite) + enzyme.Skip})
+		}
+	}
+
+	// If, on a linear sequence, the last overhang's position + EnzymeSkip + EnzymeOverhangLength is over the length of the sequence, remove that overhang.
+	for _, overhangSet := range [][]Overhang{forwardOverhangs, reverseOverhangs} {
+		if len(overhangSet) > 0 {
+			if !part.Circular && (overhangSet[len(overhangSet)-1].Position+enzyme.Skip+enzyme.OverheadLength > len(sequence)) {
+				overhangSet = overhangSet[:len(overhangSet)-1]
+			}
+		}
+		overhangs = append(overhangs, overhangSet...)
+	}
+
+	// Sort overhangs
+	sort.SliceStable(overhangs, func(i, j int) bool {
+		return overhangs[i].Position < overhangs[j].Position
+	})
+
+	return overhangs, forwardOverhangs, palindromic
+}
+
 func recurseLigate(seedFragment Fragment, fragmentList []Fragment, usedFragments []Fragment, existingSeqhashes map[string]struct{}) (openConstructs []string, infiniteConstructs []string) {
 	// Recurse ligate simulates all possible ligations of a series of fragments. Each possible combination begins with a "seed" that fragments from the pool can be added to.
 	// If the seed ligates to itself, we can call it done with a successful circularization!
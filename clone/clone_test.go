@@ -2,6 +2,8 @@ package clone
 
 import (
 	"testing"
+
+	"github.com/bebop/poly/sequence"
 )
 
 // pOpen plasmid series (https://stanford.freegenes.org/collections/open-genes/products/open-plasmids#description). I use it for essentially all my cloning. -Keoni
@@ -227,6 +229,20 @@ func TestCircularCutRegression(t *testing.T) {
 	}
 }
 
+func TestCircularCutAtOriginRegression(t *testing.T) {
+	enzymeManager := NewEnzymeManager(GetBaseRestrictionEnzymes())
+	// This used to give the same fragment twice, since a recognition site
+	// starting right at the origin was found again in the doubled sequence.
+	plasmid := Part{"GGTCTCACGCTACTAGTAGCGGCCGCTGCAGTCCGGCAAAAAAGGGCAAGGTGTCACCACCCTGCCCTTTTTCTTTAAAACCGGGAGAGAGACC", true}
+	newFragments, err := enzymeManager.CutWithEnzymeByName(plasmid, true, "BsaI")
+	if err != nil {
+		t.Errorf("Failed to cut: %s", err)
+	}
+	if len(newFragments) != 1 {
+		t.Errorf("Expected 1 new fragment, got: %d", len(newFragments))
+	}
+}
+
 func benchmarkGoldenGate(b *testing.B, enzymeManager EnzymeManager, parts []Part) {
 	bbsI, err := enzymeManager.GetEnzymeByName("BbsI")
 	if err != nil {
@@ -244,3 +260,15 @@ func BenchmarkGoldenGate3Parts(b *testing.B) {
 
 	benchmarkGoldenGate(b, enzymeManager, []Part{fragment1, fragment2, popen})
 }
+
+func TestNewPart(t *testing.T) {
+	plasmid := sequence.New("pOpen", popen.Sequence)
+	plasmid.Circular = true
+	part, err := NewPart(plasmid)
+	if err != nil || part != popen {
+		t.Errorf("NewPart() = %v, want pOpen", err)
+	}
+	if _, err := NewPart(sequence.New("protein", "MKLV")); err == nil {
+		t.Errorf("NewPart() of a protein should fail")
+	}
+}
@@ -0,0 +1,275 @@
+/*
+Package compatibility checks whether plasmids can share a host.
+
+Putting two or three plasmids into the same cell is routine, like a
+co-expression system or a helper plasmid alongside a reporter, but it only
+works if the plasmids can stay there together. Two things decide that:
+
+Their origins of replication have to be in different incompatibility groups.
+Plasmids whose origins share a group share a copy number control system too,
+so the cell can't tell them apart, and within a few generations of growth
+one of them is lost. pUC and pBR322 are both ColE1 origins, for instance,
+while ColE1, p15A, and pSC101 origins all get along.
+
+They need different selection markers. Two plasmids that both carry AmpR
+can't both be selected for, so nothing keeps either of them around.
+
+Rules holds tables of common origins and markers. Identify finds them on a
+plasmid map by their feature names, and Check compares every pair of
+plasmids for a shared incompatibility group or marker. The default tables
+cover the replicons and resistance genes common in E. coli, and anything
+else can be added to them.
+
+For more on plasmid incompatibility:
+Novick, 1987
+https://doi.org/10.1128/mr.51.4.381-395.1987
+*/
+package compatibility
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bebop/poly/io/genbank"
+)
+
+// Origin is an origin of replication.
+type Origin struct {
+	Name string
+	// Group is the incompatibility group of the origin. Origins in the same
+	// group can't be maintained in the same cell.
+	Group string
+	// MinCopies and MaxCopies are the usual range of copies a cell keeps.
+	MinCopies, MaxCopies int
+	// Aliases are names the origin goes by on plasmid maps, in lowercase.
+	Aliases []string
+	// Note is anything else to know before using the origin, like a host
+	// it needs.
+	Note string
+}
+
+// Marker is a selection marker.
+type Marker struct {
+	Name string
+	// Selection is what the marker is selected with. Markers selected with
+	// the same thing can't tell two plasmids apart.
+	Selection string
+	// Aliases are names the marker goes by on plasmid maps, in lowercase.
+	Aliases []string
+}
+
+// Rules are the origins and markers plasmids are checked against.
+type Rules struct {
+	Origins []Origin
+	Markers []Marker
+}
+
+// DefaultRules returns rules for common E. coli origins and resistance
+// markers. Origins are in order of how specific their aliases are, since
+// the first one that matches a feature is taken.
+func DefaultRules() Rules {
+	return Rules{
+		Origins: []Origin{
+			{Name: "pUC", Group: "ColE1", MinCopies: 500, MaxCopies: 700, Aliases: []string{"puc", "puc ori"}},
+			{Name: "pBR322", Group: "ColE1", MinCopies: 15, MaxCopies: 20, Aliases: []string{"pbr322", "pbr322 ori", "pbr322ori", "pmb1"}},
+			{Name: "ColE1", Group: "ColE1", MinCopies: 15, MaxCopies: 20, Aliases: []string{"cole1", "cole1 ori"}},
+			{Name: "p15A", Group: "p15A", MinCopies: 10, MaxCopies: 12, Aliases: []string{"p15a", "p15a ori"}},
+			{Name: "pSC101", Group: "pSC101", MinCopies: 3, MaxCopies: 5, Aliases: []string{"psc101", "psc101 ori"}},
+			{Name: "CloDF13", Group: "CloDF13", MinCopies: 20, MaxCopies: 40, Aliases: []string{"clodf13", "cdf", "cdf ori"}},
+			{Name: "RSF1030", Group: "RSF1030", MinCopies: 100, MaxCopies: 100, Aliases: []string{"rsf1030", "rsf", "rsf ori"}},
+			{Name: "ColA", Group: "ColA", MinCopies: 20, MaxCopies: 40, Aliases: []string{"cola", "cola ori"}},
+			{Name: "RSF1010", Group: "IncQ", MinCopies: 10, MaxCopies: 12, Aliases: []string{"rsf1010", "incq"}},
+			{Name: "RK2", Group: "IncP", MinCopies: 4, MaxCopies: 7, Aliases: []string{"rk2", "rp4", "incp", "rk2 oriv"}},
+			{Name: "pBBR1", Group: "pBBR1", MinCopies: 30, MaxCopies: 40, Aliases: []string{"pbbr1", "pbbr1 oriv"}},
+			{Name: "R6K", Group: "R6K", MinCopies: 15, MaxCopies: 20, Aliases: []string{"r6k", "r6k gamma", "r6k γ ori"}, Note: "only replicates in hosts expressing pir"},
+			{Name: "F", Group: "IncFI", MinCopies: 1, MaxCopies: 2, Aliases: []string{"mini-f", "f plasmid", "oris", "bac ori"}},
+		},
+		Markers: []Marker{
+			{Name: "AmpR", Selection: "ampicillin", Aliases: []string{"ampr", "bla", "beta-lactamase", "ampicillin"}},
+			{Name: "KanR", Selection: "kanamycin", Aliases: []string{"kanr", "nptii", "neo", "aph(3')-ia", "aph(3')-ii", "kanamycin"}},
+			{Name: "CmR", Selection: "chloramphenicol", Aliases: []string{"cmr", "cat", "chloramphenicol"}},
+			{Name: "TetR", Selection: "tetracycline", Aliases: []string{"teta", "tet(a)", "tetracycline"}},
+			{Name: "SmR", Selection: "spectinomycin", Aliases: []string{"smr", "specr", "aada", "spectinomycin"}},
+			{Name: "GmR", Selection: "gentamicin", Aliases: []string{"gmr", "gentr", "aacc1", "aac(3)-i", "gentamicin"}},
+			{Name: "ZeoR", Selection: "zeocin", Aliases: []string{"zeor", "ble", "sh ble", "bleor", "zeocin", "bleomycin"}},
+			{Name: "HygR", Selection: "hygromycin", Aliases: []string{"hygr", "hph", "hygromycin"}},
+		},
+	}
+}
+
+// Plasmid is a plasmid's origins and markers, by name.
+type Plasmid struct {
+	Name    string
+	Origins []string
+	Markers []string
+}
+
+// Identify finds the origins and markers of a plasmid map. Origins are
+// looked for on rep_origin features and markers on CDS and gene features,
+// by their label, gene, product, and note qualifiers. Origins that aren't
+// plasmid replicons, like f1, aren't in the rules so they aren't found.
+func (rules Rules) Identify(record genbank.Genbank) Plasmid {
+	plasmid := Plasmid{Name: record.Meta.Locus.Name}
+	for _, feature := range record.Features {
+		switch feature.Type {
+		case "rep_origin":
+			for _, origin := range rules.Origins {
+				if matches(feature, origin.Aliases) {
+					plasmid.Origins = appendUnique(plasmid.Origins, origin.Name)
+					break
+				}
+			}
+		case "CDS", "gene":
+			for _, marker := range rules.Markers {
+				if matches(feature, marker.Aliases) {
+					plasmid.Markers = appendUnique(plasmid.Markers, marker.Name)
+					break
+				}
+			}
+		}
+	}
+	return plasmid
+}
+
+// matches is whether any qualifier naming a feature mentions an alias.
+func matches(feature genbank.Feature, aliases []string) bool {
+	for _, qualifier := range []string{"label", "gene", "product", "note"} {
+		value := strings.ToLower(feature.Attributes[qualifier])
+		for _, alias := range aliases {
+			if containsWord(value, alias) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsWord is whether word is in text with no letters or digits
+// directly either side of it, so "cat" is found in "cat gene" but not in
+// "location".
+func containsWord(text, word string) bool {
+	for start := 0; start < len(text); {
+		index := strings.Index(text[start:], word)
+		if index == -1 {
+			return false
+		}
+		index += start
+		end := index + len(word)
+		if (index == 0 || !isAlphanumeric(text[index-1])) && (end == len(text) || !isAlphanumeric(text[end])) {
+			return true
+		}
+		start = index + 1
+	}
+	return false
+}
+
+func isAlphanumeric(character byte) bool {
+	return ('a' <= character && character <= 'z') || ('0' <= character && character <= '9')
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+/******************************************************************************
+
+Compatibility checks begin here.
+
+******************************************************************************/
+
+// Conflict is a reason two plasmids can't be maintained together.
+type Conflict struct {
+	First, Second string // names of the plasmids.
+	Reason        string
+}
+
+// Report is the result of checking a set of plasmids that share a host.
+type Report struct {
+	Conflicts []Conflict
+	// Warnings are things that don't stop the plasmids being maintained
+	// together but are worth knowing, like an origin that wasn't identified.
+	Warnings []string
+	// Copies are the usual range of copies of every plasmid, from its
+	// highest copy origin, by name.
+	Copies     map[string]CopyNumber
+	Compatible bool // whether there are no conflicts.
+}
+
+// CopyNumber is a range of copies per cell.
+type CopyNumber struct {
+	Min, Max int
+}
+
+// Check checks every pair of plasmids for origins in the same
+// incompatibility group and for markers selected the same way, and looks up
+// their copy numbers.
+func (rules Rules) Check(plasmids []Plasmid) Report {
+	origins := make(map[string]Origin)
+	for _, origin := range rules.Origins {
+		origins[origin.Name] = origin
+	}
+	markers := make(map[string]Marker)
+	for _, marker := range rules.Markers {
+		markers[marker.Name] = marker
+	}
+
+	report := Report{Copies: make(map[string]CopyNumber)}
+	for _, plasmid := range plasmids {
+		if len(plasmid.Origins) == 0 {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s has no known origin", plasmid.Name))
+		}
+		if len(plasmid.Markers) == 0 {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s has no known marker, so nothing selects for it", plasmid.Name))
+		}
+		for _, name := range plasmid.Origins {
+			origin, ok := origins[name]
+			if !ok {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%s has origin %s, which isn't in the rules", plasmid.Name, name))
+				continue
+			}
+			if origin.Note != "" {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%s has origin %s, which %s", plasmid.Name, name, origin.Note))
+			}
+			if origin.MaxCopies > report.Copies[plasmid.Name].Max {
+				report.Copies[plasmid.Name] = CopyNumber{Min: origin.MinCopies, Max: origin.MaxCopies}
+			}
+		}
+	}
+
+	for firstIndex, first := range plasmids {
+		for _, second := range plasmids[firstIndex+1:] {
+			for _, firstOrigin := range first.Origins {
+				for _, secondOrigin := range second.Origins {
+					group := origins[firstOrigin].Group
+					if group != "" && group == origins[secondOrigin].Group {
+						report.Conflicts = append(report.Conflicts, Conflict{First: first.Name, Second: second.Name, Reason: fmt.Sprintf("origins %s and %s are both in incompatibility group %s", firstOrigin, secondOrigin, group)})
+					}
+				}
+			}
+			for _, firstMarker := range first.Markers {
+				for _, secondMarker := range second.Markers {
+					selection := markers[firstMarker].Selection
+					if firstMarker == secondMarker || (selection != "" && selection == markers[secondMarker].Selection) {
+						report.Conflicts = append(report.Conflicts, Conflict{First: first.Name, Second: second.Name, Reason: fmt.Sprintf("markers %s and %s are both selected with %s", firstMarker, secondMarker, selectionName(markers, firstMarker))})
+					}
+				}
+			}
+		}
+	}
+	report.Compatible = len(report.Conflicts) == 0
+	return report
+}
+
+// selectionName is what a marker is selected with, or its own name if it
+// isn't in the rules.
+func selectionName(markers map[string]Marker, name string) string {
+	if selection := markers[name].Selection; selection != "" {
+		return selection
+	}
+	return name
+}
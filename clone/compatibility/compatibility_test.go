@@ -0,0 +1,81 @@
+package compatibility
+
+import (
+	"testing"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentify(t *testing.T) {
+	puc19, err := genbank.Read("../../data/puc19.gbk")
+	require.NoError(t, err)
+	plasmid := DefaultRules().Identify(puc19)
+	assert.Equal(t, []string{"pUC"}, plasmid.Origins)
+	assert.Equal(t, []string{"AmpR"}, plasmid.Markers)
+
+	record := genbank.Genbank{Meta: genbank.Meta{Locus: genbank.Locus{Name: "pACYC184"}}}
+	for _, feature := range []genbank.Feature{
+		{Type: "rep_origin", Attributes: map[string]string{"label": "p15A ori"}},
+		{Type: "rep_origin", Attributes: map[string]string{"label": "f1 ori"}},
+		{Type: "CDS", Attributes: map[string]string{"label": "CmR", "gene": "cat"}},
+		{Type: "CDS", Attributes: map[string]string{"label": "TetR", "note": "confers resistance to tetracycline"}},
+		{Type: "primer_bind", Attributes: map[string]string{"label": "AmpR"}},
+		{Type: "CDS", Attributes: map[string]string{"label": "relocation protein"}},
+	} {
+		feature := feature
+		require.NoError(t, record.AddFeature(&feature))
+	}
+	plasmid = DefaultRules().Identify(record)
+	assert.Equal(t, "pACYC184", plasmid.Name)
+	assert.Equal(t, []string{"p15A"}, plasmid.Origins)
+	assert.Equal(t, []string{"CmR", "TetR"}, plasmid.Markers)
+}
+
+func TestContainsWord(t *testing.T) {
+	assert.True(t, containsWord("cat", "cat"))
+	assert.True(t, containsWord("colE1/pmb1/pbr322", "pmb1"))
+	assert.True(t, containsWord("aph(3')-ia", "aph(3')-ia"))
+	assert.False(t, containsWord("location", "cat"))
+	assert.False(t, containsWord("category cat5", "cat"))
+	assert.False(t, containsWord("", "cat"))
+}
+
+func TestCheck(t *testing.T) {
+	rules := DefaultRules()
+	report := rules.Check([]Plasmid{
+		{Name: "pET", Origins: []string{"pBR322"}, Markers: []string{"KanR"}},
+		{Name: "pACYC", Origins: []string{"p15A"}, Markers: []string{"CmR"}},
+		{Name: "pCDF", Origins: []string{"CloDF13"}, Markers: []string{"SmR"}},
+	})
+	assert.True(t, report.Compatible)
+	assert.Empty(t, report.Conflicts)
+	assert.Empty(t, report.Warnings)
+	assert.Equal(t, CopyNumber{Min: 15, Max: 20}, report.Copies["pET"])
+
+	report = rules.Check([]Plasmid{
+		{Name: "pUC19", Origins: []string{"pUC"}, Markers: []string{"AmpR"}},
+		{Name: "pBR322", Origins: []string{"pBR322"}, Markers: []string{"AmpR", "TetR"}},
+		{Name: "pir116", Origins: []string{"R6K", "mystery"}},
+	})
+	assert.False(t, report.Compatible)
+	assert.Equal(t, []Conflict{
+		{First: "pUC19", Second: "pBR322", Reason: "origins pUC and pBR322 are both in incompatibility group ColE1"},
+		{First: "pUC19", Second: "pBR322", Reason: "markers AmpR and AmpR are both selected with ampicillin"},
+	}, report.Conflicts)
+	assert.Equal(t, []string{
+		"pir116 has no known marker, so nothing selects for it",
+		"pir116 has origin R6K, which only replicates in hosts expressing pir",
+		"pir116 has origin mystery, which isn't in the rules",
+	}, report.Warnings)
+	assert.Equal(t, CopyNumber{Min: 500, Max: 700}, report.Copies["pUC19"])
+
+	// shuttle vectors are checked on every origin they have.
+	custom := Rules{Origins: append(rules.Origins, Origin{Name: "pMB1", Group: "ColE1"}), Markers: rules.Markers}
+	report = custom.Check([]Plasmid{
+		{Name: "shuttle", Origins: []string{"RK2", "pMB1"}, Markers: []string{"GmR"}},
+		{Name: "reporter", Origins: []string{"ColE1"}, Markers: []string{"KanR"}},
+	})
+	assert.Len(t, report.Conflicts, 1)
+}
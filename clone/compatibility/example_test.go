@@ -0,0 +1,26 @@
+package compatibility_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/clone/compatibility"
+	"github.com/bebop/poly/io/genbank"
+)
+
+func ExampleRules_Check() {
+	rules := compatibility.DefaultRules()
+	puc19, _ := genbank.Read("../../data/puc19.gbk")
+
+	// can pUC19 share a cell with a p15A plasmid carrying AmpR?
+	report := rules.Check([]compatibility.Plasmid{
+		rules.Identify(puc19),
+		{Name: "pACYC177", Origins: []string{"p15A"}, Markers: []string{"AmpR", "KanR"}},
+	})
+	fmt.Println(report.Compatible)
+	for _, conflict := range report.Conflicts {
+		fmt.Println(conflict.Reason)
+	}
+	// Output:
+	// false
+	// markers AmpR and AmpR are both selected with ampicillin
+}
@@ -0,0 +1,556 @@
+package clone
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"github.com/bebop/poly/search/motif"
+	"github.com/bebop/poly/transform"
+)
+
+/******************************************************************************
+
+Diagnostic digest functions begin here.
+
+The quickest check that a miniprep holds the plasmid you think it does is a
+diagnostic digest: cut it with an enzyme or two, run it on a gel, and see if
+the bands are the sizes you expected. Reading the gel is easy when you have
+one candidate. When you've got a plate of colonies from a library, or a tube
+with a smudged label, you've got a set of candidates and a set of bands, and
+you'd like to know which candidate explains the bands best.
+
+Digest predicts the band sizes of a construct using the same cut sites as
+CutWithEnzyme, and RankDigests scores every candidate against the observed
+bands. Gel sizing is only so accurate, so bands match if they are within a
+relative tolerance of each other, and predicted bands that are too close
+together to resolve are merged into a single band, just like on a real gel.
+
+******************************************************************************/
+
+// Digest returns the sizes of the fragments of part cut with every enzyme,
+// largest first. An uncut circular part gives a single band of its full
+// length, though in reality uncut plasmids run as several supercoiled and
+// nicked bands.
+func Digest(part Part, enzymes ...Enzyme) []int {
+	result, _ := DigestWithConditions(part, DigestConditions{}, enzymes...)
+	return result.Bands
+}
+
+// bands returns the sizes of the fragments of a part of length cut at the
+// sorted, distinct positions of cuts, largest first.
+func bands(length int, circular bool, cuts []int) []int {
+	var sizes []int
+	switch {
+	case len(cuts) == 0:
+		sizes = []int{length}
+	case circular:
+		for index := range cuts {
+			next := index + 1
+			if next == len(cuts) {
+				sizes = append(sizes, cuts[0]+length-cuts[index])
+			} else {
+				sizes = append(sizes, cuts[next]-cuts[index])
+			}
+		}
+	default:
+		previous := 0
+		for _, cut := range append(cuts, length) {
+			sizes = append(sizes, cut-previous)
+			previous = cut
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+	return sizes
+}
+
+// Candidate is a construct that might explain the bands of a digest.
+type Candidate struct {
+	Name string
+	Part Part
+}
+
+// DigestOptions changes how RankDigests compares bands.
+type DigestOptions struct {
+	// Tolerance is the relative difference in size at which two bands still
+	// match. Defaults to 0.1, or 10%, which is about how well agarose gels are
+	// sized by eye.
+	Tolerance float64
+	// MinimumBandSize drops predicted bands smaller than this many base pairs,
+	// which run off the gel or are too faint to see. Defaults to 0, keeping
+	// every band.
+	MinimumBandSize int
+	// Conditions are the conditions candidates are digested under. If they
+	// include partial digestion, observed bands that only a partial digest
+	// explains count as matched, but partial bands don't have to be seen.
+	Conditions DigestConditions
+}
+
+// DigestScore is how well a candidate explains a set of observed bands.
+type DigestScore struct {
+	Name        string
+	Bands       []int   // predicted bands, after merging and dropping small bands.
+	Matched     int     // number of observed bands matched by a predicted band.
+	Unexplained []int   // observed bands not matched by any predicted band.
+	Missing     []int   // predicted bands not matched by any observed band.
+	Score       float64 // matched bands over all distinct bands, from 0 to 1.
+	Error       float64 // mean relative size difference of matched bands.
+}
+
+// RankDigests digests every candidate with enzymes and ranks them by how well
+// their predicted bands match the observed band sizes, best first. Ties in
+// score are broken by the smaller sizing error.
+func RankDigests(observed []int, candidates []Candidate, enzymes []Enzyme, options DigestOptions) []DigestScore {
+	tolerance := options.Tolerance
+	if tolerance <= 0 {
+		tolerance = 0.1
+	}
+	observed = append([]int{}, observed...)
+	sort.Sort(sort.Reverse(sort.IntSlice(observed)))
+
+	scores := make([]DigestScore, len(candidates))
+	for candidateIndex, candidate := range candidates {
+		conditions := options.Conditions
+		conditions.Partial = false
+		result, _ := DigestWithConditions(candidate.Part, conditions, enzymes...)
+		var predicted []int
+		for _, band := range result.Bands {
+			if band >= options.MinimumBandSize {
+				predicted = append(predicted, band)
+			}
+		}
+		predicted = mergeBands(predicted, tolerance)
+		scores[candidateIndex] = scoreBands(candidate.Name, observed, predicted, tolerance)
+		if options.Conditions.Partial {
+			explainPartials(&scores[candidateIndex], partialBands(len(candidate.Part.Sequence), candidate.Part.Circular, cutPositions(result.Cuts)), options.MinimumBandSize, tolerance)
+		}
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Error < scores[j].Error
+	})
+	return scores
+}
+
+// bandsMatch checks if two band sizes are within a relative tolerance.
+func bandsMatch(first, second int, tolerance float64) bool {
+	return math.Abs(float64(first-second)) <= tolerance*float64(max(first, second))
+}
+
+// mergeBands merges neighboring bands of a descending list that would run
+// together on a gel into a single band of their average size.
+func mergeBands(bands []int, tolerance float64) []int {
+	var merged []int
+	for start := 0; start < len(bands); {
+		end, total := start, 0
+		for end < len(bands) && bandsMatch(bands[start], bands[end], tolerance) {
+			total += bands[end]
+			end++
+		}
+		merged = append(merged, total/(end-start))
+		start = end
+	}
+	return merged
+}
+
+// scoreBands matches two descending lists of bands and scores the result.
+func scoreBands(name string, observed, predicted []int, tolerance float64) DigestScore {
+	score := DigestScore{Name: name, Bands: predicted}
+	totalError := 0.0
+	observedIndex, predictedIndex := 0, 0
+	for observedIndex < len(observed) && predictedIndex < len(predicted) {
+		observedBand, predictedBand := observed[observedIndex], predicted[predictedIndex]
+		switch {
+		case bandsMatch(observedBand, predictedBand, tolerance):
+			score.Matched++
+			totalError += math.Abs(float64(observedBand-predictedBand)) / float64(predictedBand)
+			observedIndex++
+			predictedIndex++
+		case observedBand > predictedBand:
+			score.Unexplained = append(score.Unexplained, observedBand)
+			observedIndex++
+		default:
+			score.Missing = append(score.Missing, predictedBand)
+			predictedIndex++
+		}
+	}
+	score.Unexplained = append(score.Unexplained, observed[observedIndex:]...)
+	score.Missing = append(score.Missing, predicted[predictedIndex:]...)
+
+	if distinct := len(observed) + len(predicted) - score.Matched; distinct > 0 {
+		score.Score = float64(score.Matched) / float64(distinct)
+	}
+	if score.Matched > 0 {
+		score.Error = totalError / float64(score.Matched)
+	}
+	return score
+}
+
+// explainPartials matches the unexplained bands of a score against the bands
+// of partial digests, which are fainter than complete digest bands and so
+// aren't missing if they aren't seen.
+func explainPartials(score *DigestScore, partials []int, minimumBandSize int, tolerance float64) {
+	totalError := score.Error * float64(score.Matched)
+	var unexplained []int
+	for _, observedBand := range score.Unexplained {
+		matched := false
+		for _, partial := range partials {
+			if partial >= minimumBandSize && bandsMatch(observedBand, partial, tolerance) {
+				score.Matched++
+				totalError += math.Abs(float64(observedBand-partial)) / float64(partial)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			unexplained = append(unexplained, observedBand)
+		}
+	}
+	score.Unexplained = unexplained
+	if distinct := score.Matched + len(score.Unexplained) + len(score.Missing); distinct > 0 {
+		score.Score = float64(score.Matched) / float64(distinct)
+	}
+	if score.Matched > 0 {
+		score.Error = totalError / float64(score.Matched)
+	}
+}
+
+/******************************************************************************
+
+Digest conditions begin here.
+
+Digest assumes every enzyme cuts every one of its sites and nothing else,
+which isn't always what the gel shows:
+
+Methylation blocks some enzymes. Plasmids grown in most E. coli strains are
+methylated by Dam at GATC and by Dcm at CCWGG, and K-12 strains also have the
+EcoKI methylase. An enzyme that's sensitive to one of them doesn't cut a site
+that includes one of the bases it methylates, so a site that happens to
+overlap a GATC, like XbaI's TCTAGA followed by TC, is left uncut.
+
+Star activity lets enzymes cut sites that are close to their own. Too much
+enzyme, glycerol, or time, or the wrong buffer, relaxes specificity until
+sites a single base off are cut too.
+
+Partial digests leave some sites uncut. Too little enzyme or time gives every
+combination of cut and uncut sites, and the extra bands on the gel are the
+fragments that span an uncut site.
+
+DigestWithConditions models all three. Methylation sensitivity depends on the
+enzyme, so which methylases block which enzymes is a table that defaults to
+MethylationSensitivity, and can be replaced for enzymes it doesn't cover.
+
+******************************************************************************/
+
+// Methylase is a DNA methyltransferase of a host.
+type Methylase struct {
+	Name string
+	Site string // recognition site, with IUPAC ambiguity codes.
+	// Methylated are the offsets of the bases in Site paired with a
+	// methylated base, on either strand.
+	Methylated []int
+}
+
+// Methylases returns the methylases of common E. coli strains: Dam, Dcm, and
+// the EcoKI methylase of K-12 strains.
+func Methylases() []Methylase {
+	return []Methylase{
+		{Name: "Dam", Site: "GATC", Methylated: []int{1, 2}},
+		{Name: "Dcm", Site: "CCWGG", Methylated: []int{1, 3}},
+		{Name: "EcoKI", Site: "AACNNNNNNGTGC", Methylated: []int{1, 10}},
+	}
+}
+
+// MethylationSensitivity returns the methylases that block common enzymes,
+// including where the methylated site only overlaps the recognition site, by
+// enzyme name.
+func MethylationSensitivity() map[string][]string {
+	return map[string][]string{
+		"BclI":   {"Dam"},
+		"BspHI":  {"Dam"},
+		"ClaI":   {"Dam"},
+		"DpnII":  {"Dam"},
+		"HphI":   {"Dam"},
+		"MboI":   {"Dam"},
+		"MboII":  {"Dam"},
+		"NruI":   {"Dam"},
+		"TaqI":   {"Dam"},
+		"XbaI":   {"Dam"},
+		"Acc65I": {"Dcm"},
+		"ApaI":   {"Dcm"},
+		"AvaII":  {"Dcm"},
+		"BsaI":   {"Dcm"},
+		"EaeI":   {"Dcm"},
+		"EcoRII": {"Dcm"},
+		"MscI":   {"Dcm"},
+		"PflMI":  {"Dcm"},
+		"SfiI":   {"Dcm"},
+		"StuI":   {"Dcm"},
+	}
+}
+
+// DigestConditions are the conditions of a digest.
+type DigestConditions struct {
+	// Methylases are the methylases of the host the DNA was grown in. None
+	// means unmethylated DNA, like a PCR product.
+	Methylases []Methylase
+	// Sensitivity is the names of the methylases that block an enzyme, by
+	// enzyme name. Defaults to MethylationSensitivity.
+	Sensitivity map[string][]string
+	// Star is whether enzymes also cut sites a single base off their own.
+	Star bool
+	// Partial is whether to find the bands of every partial digest.
+	Partial bool
+}
+
+// Cut is a site an enzyme cuts, or would cut if it weren't blocked.
+type Cut struct {
+	Enzyme    string
+	Position  int    // where the top strand is cut.
+	Star      bool   // whether the site is only cut by star activity.
+	BlockedBy string // the methylase that blocks the site, if any.
+}
+
+// DigestResult is the outcome of a digest.
+type DigestResult struct {
+	Cuts    []Cut // sites that are cut, by position.
+	Blocked []Cut // sites that methylation blocks, by position.
+	Bands   []int // fragment sizes of the complete digest, largest first.
+	// Partials are the fragment sizes of every partial digest, from every
+	// combination of cut and uncut positions, fewest cuts first. Only found
+	// for partial digests.
+	Partials [][]int
+	// PartialBands are the distinct fragment sizes of every partial digest,
+	// largest first. Only found for partial digests.
+	PartialBands []int
+}
+
+// maxPartialCuts is the most cut positions DigestWithConditions finds every
+// partial digest of, since there are two to the power of their number.
+const maxPartialCuts = 16
+
+// DigestWithConditions digests part with every enzyme under conditions.
+func DigestWithConditions(part Part, conditions DigestConditions, enzymes ...Enzyme) (DigestResult, error) {
+	length := len(part.Sequence)
+	if length == 0 {
+		return DigestResult{}, nil
+	}
+	sequence := strings.ToUpper(part.Sequence)
+	if part.Circular {
+		sequence += sequence
+	}
+	sensitivity := conditions.Sensitivity
+	if sensitivity == nil {
+		sensitivity = MethylationSensitivity()
+	}
+	methylated, err := methylatedPositions(sequence, length, part.Circular, conditions.Methylases)
+	if err != nil {
+		return DigestResult{}, err
+	}
+
+	var result DigestResult
+	seen := make(map[Cut]bool)
+	for _, enzyme := range enzymes {
+		blockers := make(map[string]bool)
+		for _, methylase := range sensitivity[enzyme.Name] {
+			blockers[methylase] = true
+		}
+		for _, site := range enzymeSites(part, sequence, enzyme, conditions.Star) {
+			position := site.cut
+			if part.Circular {
+				position = ((position % length) + length) % length
+			} else if position <= 0 || position >= length {
+				continue
+			}
+			cut := Cut{Enzyme: enzyme.Name, Position: position, Star: site.star}
+			if seen[cut] {
+				continue
+			}
+			seen[cut] = true
+			for offset := site.start; offset < site.end && cut.BlockedBy == ""; offset++ {
+				for _, methylase := range methylated[offset%length] {
+					if blockers[methylase] {
+						cut.BlockedBy = methylase
+						break
+					}
+				}
+			}
+			if cut.BlockedBy != "" {
+				result.Blocked = append(result.Blocked, cut)
+			} else {
+				result.Cuts = append(result.Cuts, cut)
+			}
+		}
+	}
+	for _, cuts := range [][]Cut{result.Cuts, result.Blocked} {
+		sort.SliceStable(cuts, func(i, j int) bool { return cuts[i].Position < cuts[j].Position })
+	}
+	positions := cutPositions(result.Cuts)
+	result.Bands = bands(length, part.Circular, positions)
+
+	if conditions.Partial {
+		if len(positions) > maxPartialCuts {
+			return DigestResult{}, fmt.Errorf("partial digest of %d cut positions has too many combinations, expected at most %d", len(positions), maxPartialCuts)
+		}
+		masks := make([]int, 1<<len(positions))
+		for mask := range masks {
+			masks[mask] = mask
+		}
+		sort.SliceStable(masks, func(i, j int) bool { return bits.OnesCount(uint(masks[i])) < bits.OnesCount(uint(masks[j])) })
+		for _, mask := range masks {
+			var subset []int
+			for index, position := range positions {
+				if mask&(1<<index) != 0 {
+					subset = append(subset, position)
+				}
+			}
+			result.Partials = append(result.Partials, bands(length, part.Circular, subset))
+		}
+		result.PartialBands = partialBands(length, part.Circular, positions)
+	}
+	return result, nil
+}
+
+// site is a place an enzyme recognizes, in the coordinates of the sequence
+// it was found in.
+type site struct {
+	start, end int // span of the recognition site.
+	cut        int // where the top strand is cut.
+	star       bool
+}
+
+// enzymeSites finds every site of enzyme in sequence, which is part.Sequence
+// doubled for circular parts, using the same cut positions as CutWithEnzyme.
+// With star activity, sites a single base off are found too.
+func enzymeSites(part Part, sequence string, enzyme Enzyme, star bool) []site {
+	siteLength := len(enzyme.RecognitionSite)
+	var sites []site
+	overhangs, _, palindromic := findOverhangs(part, sequence, enzyme)
+	for _, overhang := range overhangs {
+		if overhang.Forward {
+			end := overhang.Position - enzyme.Skip
+			sites = append(sites, site{start: end - siteLength, end: end, cut: overhang.Position})
+		} else {
+			start := overhang.Position + enzyme.Skip
+			sites = append(sites, site{start: start, end: start + siteLength, cut: overhang.Position})
+		}
+	}
+	if !star {
+		return sites
+	}
+	forward := strings.ToUpper(enzyme.RecognitionSite)
+	reverse := transform.ReverseComplement(forward)
+	for start := 0; start+siteLength <= len(sequence); start++ {
+		window := sequence[start : start+siteLength]
+		if mismatches(window, forward) == 1 {
+			sites = append(sites, site{start: start, end: start + siteLength, cut: start + siteLength + enzyme.Skip, star: true})
+		}
+		if !palindromic && mismatches(window, reverse) == 1 {
+			sites = append(sites, site{start: start, end: start + siteLength, cut: start - enzyme.Skip, star: true})
+		}
+	}
+	return sites
+}
+
+// mismatches counts the positions two strings of the same length differ at.
+func mismatches(first, second string) int {
+	count := 0
+	for index := range first {
+		if first[index] != second[index] {
+			count++
+		}
+	}
+	return count
+}
+
+// methylatedPositions finds the positions of a part of length paired with a
+// methylated base, and the methylases that methylate them. sequence is the
+// part's sequence, doubled if it's circular.
+func methylatedPositions(sequence string, length int, circular bool, methylases []Methylase) (map[int][]string, error) {
+	methylated := make(map[int][]string)
+	mark := func(position int, name string) {
+		position %= length
+		for _, existing := range methylated[position] {
+			if existing == name {
+				return
+			}
+		}
+		methylated[position] = append(methylated[position], name)
+	}
+	for _, methylase := range methylases {
+		site := strings.ToUpper(methylase.Site)
+		forward, err := motif.CompileIUPAC(site)
+		if err != nil {
+			return nil, fmt.Errorf("methylase %s: %w", methylase.Name, err)
+		}
+		reverseSite := transform.ReverseComplement(site)
+		reverse, err := motif.CompileIUPAC(reverseSite)
+		if err != nil {
+			return nil, fmt.Errorf("methylase %s: %w", methylase.Name, err)
+		}
+		end := len(sequence) - len(site)
+		if circular {
+			// motifs spanning the origin start in the first copy.
+			end = min(end, length-1)
+		}
+		for start := 0; start <= end; start++ {
+			window := sequence[start : start+len(site)]
+			if forward.MatchString(window) {
+				for _, offset := range methylase.Methylated {
+					mark(start+offset, methylase.Name)
+				}
+			}
+			if reverseSite != site && reverse.MatchString(window) {
+				for _, offset := range methylase.Methylated {
+					mark(start+len(site)-1-offset, methylase.Name)
+				}
+			}
+		}
+	}
+	return methylated, nil
+}
+
+// cutPositions returns the distinct, sorted positions of cuts.
+func cutPositions(cuts []Cut) []int {
+	var positions []int
+	for _, cut := range cuts {
+		if len(positions) == 0 || positions[len(positions)-1] != cut.Position {
+			positions = append(positions, cut.Position)
+		}
+	}
+	return positions
+}
+
+// partialBands returns every distinct fragment size a partial digest at the
+// sorted, distinct positions of cuts can give, largest first. Every fragment
+// runs from one cut, or the end of a linear part, to another.
+func partialBands(length int, circular bool, cuts []int) []int {
+	sizes := make(map[int]bool)
+	if circular {
+		sizes[length] = true
+		for first := range cuts {
+			for second := range cuts {
+				if first != second {
+					sizes[((cuts[second]-cuts[first])%length+length)%length] = true
+				}
+			}
+		}
+	} else {
+		ends := append(append([]int{0}, cuts...), length)
+		for first := range ends {
+			for second := first + 1; second < len(ends); second++ {
+				sizes[ends[second]-ends[first]] = true
+			}
+		}
+	}
+	var distinct []int
+	for size := range sizes {
+		distinct = append(distinct, size)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(distinct)))
+	return distinct
+}
@@ -0,0 +1,227 @@
+package clone
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// siteConstruct builds a construct of the given length with a BsaI site
+// starting at each of sites. BsaI cuts 7 bases after the start of its site.
+func siteConstruct(length int, circular bool, sites ...int) Part {
+	sequence := []byte(strings.Repeat("ATGCATCGAT", length/10+1)[:length])
+	for _, site := range sites {
+		copy(sequence[site:], "GGTCTC")
+	}
+	return Part{Sequence: string(sequence), Circular: circular}
+}
+
+func TestDigest(t *testing.T) {
+	bsai, _ := NewEnzymeManager(GetBaseRestrictionEnzymes()).GetEnzymeByName("BsaI")
+	bbsi, _ := NewEnzymeManager(GetBaseRestrictionEnzymes()).GetEnzymeByName("BbsI")
+	tests := []struct {
+		name    string
+		part    Part
+		enzymes []Enzyme
+		want    []int
+	}{
+		{"uncut circular", siteConstruct(1000, true), []Enzyme{bsai}, []int{1000}},
+		{"single cut circular", siteConstruct(1000, true, 100), []Enzyme{bsai}, []int{1000}},
+		{"double cut circular", siteConstruct(1000, true, 100, 400), []Enzyme{bsai}, []int{700, 300}},
+		{"double cut linear", siteConstruct(1000, false, 100, 400), []Enzyme{bsai}, []int{593, 300, 107}},
+		{"site across origin", Part{Sequence: "CTC" + strings.Repeat("ATGCATCGAT", 50) + "GGT", Circular: true}, []Enzyme{bsai}, []int{506}},
+		{"lowercase", Part{Sequence: strings.ToLower(siteConstruct(1000, true, 100, 400).Sequence), Circular: true}, []Enzyme{bsai}, []int{700, 300}},
+		{"no enzymes", siteConstruct(1000, true, 100, 400), nil, []int{1000}},
+		{"empty", Part{}, []Enzyme{bsai}, nil},
+	}
+	for _, test := range tests {
+		if got := Digest(test.part, test.enzymes...); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: Digest = %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	// a double digest with a BbsI site at 700.
+	part := siteConstruct(1000, true, 100, 400)
+	sequence := []byte(part.Sequence)
+	copy(sequence[700:], "GAAGAC")
+	part.Sequence = string(sequence)
+	if got := Digest(part, bsai, bbsi); !reflect.DeepEqual(got, []int{399, 301, 300}) {
+		t.Errorf("double digest = %v, want [399 301 300]", got)
+	}
+}
+
+func TestRankDigests(t *testing.T) {
+	bsai, _ := NewEnzymeManager(GetBaseRestrictionEnzymes()).GetEnzymeByName("BsaI")
+	candidates := []Candidate{
+		{"empty vector", siteConstruct(3000, true)},
+		{"insert", siteConstruct(3000, true, 100, 1000)},
+		{"doublet", siteConstruct(3000, true, 100, 1550)},
+		{"wrong insert", siteConstruct(3000, true, 100, 600)},
+	}
+
+	// a gel showing a ~2.1kb and ~0.9kb band should pick the insert.
+	scores := RankDigests([]int{880, 2150}, candidates, []Enzyme{bsai}, DigestOptions{})
+	if scores[0].Name != "insert" || scores[0].Score != 1 || scores[0].Matched != 2 {
+		t.Errorf("expected insert to explain the gel, got %+v", scores[0])
+	}
+	if scores[len(scores)-1].Score != 0 {
+		t.Errorf("expected the worst candidate to explain nothing, got %+v", scores[len(scores)-1])
+	}
+
+	// two 1.5kb bands run together as a single band.
+	scores = RankDigests([]int{1500}, candidates, []Enzyme{bsai}, DigestOptions{})
+	if scores[0].Name != "doublet" || !reflect.DeepEqual(scores[0].Bands, []int{1500}) {
+		t.Errorf("expected the doublet to explain a single band, got %+v", scores[0])
+	}
+
+	// small bands can be dropped, which changes which bands are missing.
+	scores = RankDigests([]int{2500}, candidates[3:], []Enzyme{bsai}, DigestOptions{MinimumBandSize: 600})
+	if scores[0].Score != 1 || len(scores[0].Missing) != 0 {
+		t.Errorf("expected the 500bp band to be dropped, got %+v", scores[0])
+	}
+	scores = RankDigests([]int{2500}, candidates[3:], []Enzyme{bsai}, DigestOptions{})
+	if scores[0].Score != 0.5 || !reflect.DeepEqual(scores[0].Missing, []int{500}) {
+		t.Errorf("expected the 500bp band to be missing, got %+v", scores[0])
+	}
+
+	// a tighter tolerance rejects sloppy sizing, and extra bands are unexplained.
+	scores = RankDigests([]int{880, 2150, 400}, candidates[1:2], []Enzyme{bsai}, DigestOptions{Tolerance: 0.01})
+	if scores[0].Matched != 0 || !reflect.DeepEqual(scores[0].Unexplained, []int{2150, 880, 400}) {
+		t.Errorf("expected no matches at 1%% tolerance, got %+v", scores[0])
+	}
+}
+
+func TestRankDigestsTieBreak(t *testing.T) {
+	bsai, _ := NewEnzymeManager(GetBaseRestrictionEnzymes()).GetEnzymeByName("BsaI")
+	candidates := []Candidate{
+		{"close", siteConstruct(3000, true, 100, 1050)},
+		{"exact", siteConstruct(3000, true, 100, 1000)},
+	}
+	scores := RankDigests([]int{2100, 900}, candidates, []Enzyme{bsai}, DigestOptions{})
+	if scores[0].Name != "exact" || scores[0].Error != 0 {
+		t.Errorf("expected the exact match to rank first, got %+v", scores)
+	}
+}
+
+func TestDigestWithConditionsMethylation(t *testing.T) {
+	xbai := Enzyme{"XbaI", regexp.MustCompile("TCTAGA"), regexp.MustCompile("TCTAGA"), -5, 4, "TCTAGA"}
+	// the first XbaI site is followed by TC, making a GATC that overlaps it.
+	part := Part{Sequence: strings.Repeat("A", 100) + "TCTAGATC" + strings.Repeat("A", 200) + "TCTAGAGG" + strings.Repeat("A", 100)}
+	result, err := DigestWithConditions(part, DigestConditions{}, xbai)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Cuts) != 2 || len(result.Blocked) != 0 {
+		t.Errorf("expected unmethylated DNA to be cut twice, got %+v", result)
+	}
+
+	result, err = DigestWithConditions(part, DigestConditions{Methylases: Methylases()}, xbai)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Cuts, []Cut{{Enzyme: "XbaI", Position: 309}}) || !reflect.DeepEqual(result.Blocked, []Cut{{Enzyme: "XbaI", Position: 101, BlockedBy: "Dam"}}) {
+		t.Errorf("expected Dam to block the first site, got %+v", result)
+	}
+	if !reflect.DeepEqual(result.Bands, []int{309, 107}) {
+		t.Errorf("expected bands of the unblocked cut, got %v", result.Bands)
+	}
+
+	// a GATC next to the site that doesn't reach a methylated base into it
+	// doesn't block it.
+	part = Part{Sequence: strings.Repeat("A", 100) + "TCTAGGATC" + strings.Repeat("A", 100)}
+	xbai.RegexpFor, xbai.RegexpRev, xbai.RecognitionSite = regexp.MustCompile("TCTAGG"), regexp.MustCompile("CCTAGA"), "TCTAGG"
+	result, _ = DigestWithConditions(part, DigestConditions{Methylases: Methylases(), Sensitivity: map[string][]string{"XbaI": {"Dam"}}}, xbai)
+	if len(result.Blocked) != 0 {
+		t.Errorf("expected a GATC only overlapping the site's last base not to block it, got %+v", result.Blocked)
+	}
+
+	// Dcm blocks BsaI at CCAGGTCTC, on a circular part.
+	bsai, _ := NewEnzymeManager(GetBaseRestrictionEnzymes()).GetEnzymeByName("BsaI")
+	part = siteConstruct(1000, true, 100, 400)
+	sequence := []byte(part.Sequence)
+	copy(sequence[97:], "CCA")
+	part.Sequence = string(sequence)
+	result, _ = DigestWithConditions(part, DigestConditions{Methylases: Methylases()}, bsai)
+	if len(result.Cuts) != 1 || len(result.Blocked) != 1 || result.Blocked[0].BlockedBy != "Dcm" || !reflect.DeepEqual(result.Bands, []int{1000}) {
+		t.Errorf("expected Dcm to block one BsaI site, got %+v", result)
+	}
+
+	// methylases that aren't palindromic are found on both strands.
+	test := Methylase{Name: "M.Test", Site: "CTCA", Methylated: []int{0}}
+	sensitivity := map[string][]string{"BsaI": {"M.Test"}}
+	part = Part{Sequence: strings.Repeat("A", 50) + "GGTCTCA" + strings.Repeat("A", 50) + "TGAGACC" + strings.Repeat("A", 50)}
+	result, _ = DigestWithConditions(part, DigestConditions{Methylases: []Methylase{test}, Sensitivity: sensitivity}, bsai)
+	if len(result.Cuts) != 0 || len(result.Blocked) != 2 {
+		t.Errorf("expected M.Test to block both BsaI sites, got %+v", result)
+	}
+
+	// Dam sites spanning the origin of a circular part are found.
+	methylated, err := methylatedPositions(strings.Repeat("ATCAAAAAAG", 2), 10, true, Methylases()[:1])
+	if err != nil || !reflect.DeepEqual(methylated, map[int][]string{0: {"Dam"}, 1: {"Dam"}}) {
+		t.Errorf("expected a GATC across the origin to be methylated, got %v, %v", methylated, err)
+	}
+
+	// methylases with sites that aren't IUPAC codes are errors.
+	invalid := Methylase{Name: "M.Invalid", Site: "GA1C"}
+	if _, err := DigestWithConditions(part, DigestConditions{Methylases: []Methylase{invalid}}, bsai); err == nil {
+		t.Errorf("expected an error for a methylase site with a digit in it")
+	}
+}
+
+func TestDigestWithConditionsStar(t *testing.T) {
+	bsai, _ := NewEnzymeManager(GetBaseRestrictionEnzymes()).GetEnzymeByName("BsaI")
+	part := siteConstruct(1000, false, 100)
+	sequence := []byte(part.Sequence)
+	copy(sequence[400:], "GGTCTA")
+	copy(sequence[700:], "GAGTCC")
+	part.Sequence = string(sequence)
+
+	result, _ := DigestWithConditions(part, DigestConditions{}, bsai)
+	if !reflect.DeepEqual(result.Bands, []int{893, 107}) {
+		t.Errorf("expected one cut without star activity, got %v", result.Bands)
+	}
+	result, _ = DigestWithConditions(part, DigestConditions{Star: true}, bsai)
+	expected := []Cut{{Enzyme: "BsaI", Position: 107}, {Enzyme: "BsaI", Position: 407, Star: true}, {Enzyme: "BsaI", Position: 699, Star: true}}
+	if !reflect.DeepEqual(result.Cuts, expected) {
+		t.Errorf("expected star sites on both strands to be cut, got %+v", result.Cuts)
+	}
+}
+
+func TestDigestWithConditionsPartial(t *testing.T) {
+	bsai, _ := NewEnzymeManager(GetBaseRestrictionEnzymes()).GetEnzymeByName("BsaI")
+	result, err := DigestWithConditions(siteConstruct(1000, false, 100, 400), DigestConditions{Partial: true}, bsai)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Partials, [][]int{{1000}, {893, 107}, {593, 407}, {593, 300, 107}}) {
+		t.Errorf("unexpected linear partial digests %v", result.Partials)
+	}
+	if !reflect.DeepEqual(result.PartialBands, []int{1000, 893, 593, 407, 300, 107}) {
+		t.Errorf("unexpected linear partial bands %v", result.PartialBands)
+	}
+
+	result, _ = DigestWithConditions(siteConstruct(1000, true, 100, 400), DigestConditions{Partial: true}, bsai)
+	if !reflect.DeepEqual(result.Partials, [][]int{{1000}, {1000}, {1000}, {700, 300}}) || !reflect.DeepEqual(result.PartialBands, []int{1000, 700, 300}) {
+		t.Errorf("unexpected circular partial digests %v %v", result.Partials, result.PartialBands)
+	}
+
+	var sites []int
+	for site := 0; site < 17; site++ {
+		sites = append(sites, 50*site)
+	}
+	if _, err := DigestWithConditions(siteConstruct(1000, true, sites...), DigestConditions{Partial: true}, bsai); err == nil {
+		t.Errorf("expected too many cuts for a partial digest to fail")
+	}
+
+	// the 407bp band only shows up if the digest is partial.
+	candidates := []Candidate{{"insert", siteConstruct(1000, false, 100, 400)}}
+	scores := RankDigests([]int{593, 407, 300, 107}, candidates, []Enzyme{bsai}, DigestOptions{})
+	if scores[0].Matched != 3 || !reflect.DeepEqual(scores[0].Unexplained, []int{407}) {
+		t.Errorf("expected the partial band to be unexplained, got %+v", scores[0])
+	}
+	scores = RankDigests([]int{593, 407, 300, 107}, candidates, []Enzyme{bsai}, DigestOptions{Conditions: DigestConditions{Partial: true}})
+	if scores[0].Matched != 4 || scores[0].Score != 1 || len(scores[0].Unexplained) != 0 {
+		t.Errorf("expected the partial band to be explained, got %+v", scores[0])
+	}
+}
@@ -3,6 +3,7 @@ package clone_test
 import (
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/bebop/poly/clone"
 	"github.com/bebop/poly/seqhash"
@@ -29,3 +30,72 @@ func ExampleGoldenGate() {
 	fmt.Println(seqhash.RotateSequence(Clones[0]))
 	// Output: AAAAAAAGGATCTCAAGAAGGCCTACTATTAGCAACAACGATCCTTTGATCTTTTCTACGGGGTCTGACGCTCAGTGGAACGAAAACTCACGTTAAGGGATTTTGGTCATGAGATTATCAAAAAGGATCTTCACCTAGATCCTTTTAAATTAAAAATGAAGTTTTAAATCAATCTAAAGTATATATGAGTAAACTTGGTCTGACAGTTACCAATGCTTAATCAGTGAGGCACCTATCTCAGCGATCTGTCTATTTCGTTCATCCATAGTTGCCTGACTCCCCGTCGTGTAGATAACTACGATACGGGAGGGCTTACCATCTGGCCCCAGTGCTGCAATGATACCGCGAGAACCACGCTCACCGGCTCCAGATTTATCAGCAATAAACCAGCCAGCCGGAAGGGCCGAGCGCAGAAGTGGTCCTGCAACTTTATCCGCCTCCATCCAGTCTATTAATTGTTGCCGGGAAGCTAGAGTAAGTAGTTCGCCAGTTAATAGTTTGCGCAACGTTGTTGCCATTGCTACAGGCATCGTGGTGTCACGCTCGTCGTTTGGTATGGCTTCATTCAGCTCCGGTTCCCAACGATCAAGGCGAGTTACATGATCCCCCATGTTGTGCAAAAAAGCGGTTAGCTCCTTCGGTCCTCCGATCGTTGTCAGAAGTAAGTTGGCCGCAGTGTTATCACTCATGGTTATGGCAGCACTGCATAATTCTCTTACTGTCATGCCATCCGTAAGATGCTTTTCTGTGACTGGTGAGTACTCAACCAAGTCATTCTGAGAATAGTGTATGCGGCGACCGAGTTGCTCTTGCCCGGCGTCAATACGGGATAATACCGCGCCACATAGCAGAACTTTAAAAGTGCTCATCATTGGAAAACGTTCTTCGGGGCGAAAACTCTCAAGGATCTTACCGCTGTTGAGATCCAGTTCGATGTAACCCACTCGTGCACCCAACTGATCTTCAGCATCTTTTACTTTCACCAGCGTTTCTGGGTGAGCAAAAACAGGAAGGCAAAATGCCGCAAAAAAGGGAATAAGGGCGACACGGAAATGTTGAATACTCATACTCTTCCTTTTTCAATATTATTGAAGCATTTATCAGGGTTATTGTCTCATGAGCGGATACATATTTGAATGTATTTAGAAAAATAAACAAATAGGGGTTCCGCGCACCTGCACCAGTCAGTAAAACGACGGCCAGTAGTCAAAAGCCTCCGACCGGAGGCTTTTGACTTGGTTCAGGTGGAGTGGGAGAAACACGTGGCAAACATTCCGGTCTCAAATGGAAAAGAGCAACGAAACCAACGGCTACCTTGACAGCGCTCAAGCCGGCCCTGCAGCTGGCCCGGGCGCTCCGGGTACCGCCGCGGGTCGTGCACGTCGTTGCGCGGGCTTCCTGCGGCGCCAAGCGCTGGTGCTGCTCACGGTGTCTGGTGTTCTGGCAGGCGCCGGTTTGGGCGCGGCACTGCGTGGGCTCAGCCTGAGCCGCACCCAGGTCACCTACCTGGCCTTCCCCGGCGAGATGCTGCTCCGCATGCTGCGCATGATCATCCTGCCGCTGGTGGTCTGCAGCCTGGTGTCGGGCGCCGCCTCCCTCGATGCCAGCTGCCTCGGGCGTCTGGGCGGTATCGCTGTCGCCTACTTTGGCCTCACCACACTGAGTGCCTCGGCGCTCGCCGTGGCCTTGGCGTTCATCATCAAGCCAGGATCCGGTGCGCAGACCCTTCAGTCCAGCGACCTGGGGCTGGAGGACTCGGGGCCTCCTCCTGTCCCCAAAGAAACGGTGGACTCTTTCCTCGACCTGGCCAGAAACCTGTTTCCCTCCAATCTTGTGGTTGCAGCTTTCCGTACGTATGCAACCGATTATAAAGTCGTGACCCAGAACAGCAGCTCTGGAAATGTAACCCATGAAAAGATCCCCATAGGCACTGAGATAGAAGGGATGAACATTTTAGGATTGGTCCTGTTTGCTCTGGTGTTAGGAGTGGCCTTAAAGAAACTAGGCTCCGAAGGAGAGGACCTCATCCGTTTCTTCAATTCCCTCAACGAGGCGACGATGGTGCTGGTGTCCTGGATTATGTGGTACGTACCTGTGGGCATCATGTTCCTTGTTGGAAGCAAGATCGTGGAAATGAAAGACATCATCGTGCTGGTGACCAGCCTGGGGAAATACATCTTCGCATCTATATTGGGCCACGTCATTCATGGTGGTATCGTCCTGCCGCTGATTTATTTTGTTTTCACACGAAAAAACCCATTCAGATTCCTCCTGGGCCTCCTCGCCCCATTTGCGACAGCATTTGCTACGTGCTCCAGCTCAGCGACCCTTCCCTCTATGATGAAGTGCATTGAAGAGAACAATGGTGTGGACAAGAGGATCTCCAGGTTTATTCTCCCCATCGGGGCCACCGTGAACATGGACGGAGCAGCCATCTTCCAGTGTGTGGCCGCGGTGTTCATTGCGCAACTCAACAACGTAGAGCTCAACGCAGGACAGATTTTCACCATTCTAGTGACTGCCACAGCGTCCAGTGTTGGAGCAGCAGGCGTGCCAGCTGGAGGGGTCCTCACCATTGCCATTATCCTGGAGGCCATTGGGCTGCCTACTCATGATCTGCCTCTGATCCTGGCTGTGGACTGGATTGTGGACCGGACCACCACGGTGGTGAATGTGGAAGGGGATGCCCTGGGTGCAGGCATTCTCCACCACCTGAATCAGAAGGCAACAAAGAAAGGCGAGCAGGAACTTGCTGAGGTGAAAGTGGAAGCCATCCCCAACTGCAAGTCTGAGGAGGAAACCTCGCCCCTGGTGACACACCAGAACCCCGCTGGCCCCGTGGCCAGTGCCCCAGAACTGGAATCCAAGGAGTCGGTTCTGTGAAGAGCTTAGAGACCGACGACTGCCTAAGGACATTCGCTGAGGTGTCAATCGTCGGAGCCGCTGAGCAATAACTAGCATAACCCCTTGGGGCCTCTAAACGGGTCTTGAGGGGTTTTTTGCATGGTCATAGCTGTTTCCTGAGAGCTTGGCAGGTGATGACACACATTAACAAATTTCGTGAGGAGTCTCCAGAAGAATGCCATTAATTTCCATAGGCTCCGCCCCCCTGACGAGCATCACAAAAATCGACGCTCAAGTCAGAGGTGGCGAAACCCGACAGGACTATAAAGATACCAGGCGTTTCCCCCTGGAAGCTCCCTCGTGCGCTCTCCTGTTCCGACCCTGCCGCTTACCGGATACCTGTCCGCCTTTCTCCCTTCGGGAAGCGTGGCGCTTTCTCATAGCTCACGCTGTAGGTATCTCAGTTCGGTGTAGGTCGTTCGCTCCAAGCTGGGCTGTGTGCACGAACCCCCCGTTCAGCCCGACCGCTGCGCCTTATCCGGTAACTATCGTCTTGAGTCCAACCCGGTAAGACACGACTTATCGCCACTGGCAGCAGCCACTGGTAACAGGATTAGCAGAGCGAGGTATGTAGGCGGTGCTACAGAGTTCTTGAAGTGGTGGCCTAACTACGGCTACACTAGAAGAACAGTATTTGGTATCTGCGCTCTGCTGAAGCCAGTTACCTTCGGAAAAAGAGTTGGTAGCTCTTGATCCGGCAAACAAACCACCGCTGGTAGCGGTGGTTTTTTTGTTTGCAAGCAGCAGATTACGCGCAG
 }
+
+func ExampleRankDigests() {
+	enzymeManager := clone.NewEnzymeManager(clone.GetBaseRestrictionEnzymes())
+	bbsi, _ := enzymeManager.GetEnzymeByName("BbsI")
+
+	// which colony has the insert? Build two candidates around a BbsI site.
+	backbone := strings.Repeat("ATGCATCGAT", 300)
+	candidates := []clone.Candidate{
+		{Name: "empty", Part: clone.Part{Sequence: "GAAGAC" + backbone, Circular: true}},
+		{Name: "insert", Part: clone.Part{Sequence: "GAAGAC" + backbone[:1000] + "GTCTTC" + backbone[1000:], Circular: true}},
+	}
+
+	// the gel shows bands at about 2kb and 1kb.
+	scores := clone.RankDigests([]int{2000, 1000}, candidates, []clone.Enzyme{bbsi}, clone.DigestOptions{})
+	for _, score := range scores {
+		fmt.Println(score.Name, score.Bands, score.Score)
+	}
+	// Output:
+	// insert [2016 996] 1
+	// empty [3006] 0
+}
+
+func ExampleDigestWithConditions() {
+	enzymeManager := clone.NewEnzymeManager(clone.GetBaseRestrictionEnzymes())
+	bsai, _ := enzymeManager.GetEnzymeByName("BsaI")
+
+	// the second BsaI site overlaps a Dcm site, CCAGG, so it isn't cut in
+	// plasmids grown in a dcm+ strain.
+	backbone := strings.Repeat("ATGCATCGAT", 100)
+	plasmid := clone.Part{Sequence: "GGTCTC" + backbone[:300] + "CCAGGTCTC" + backbone[300:], Circular: true}
+	result, _ := clone.DigestWithConditions(plasmid, clone.DigestConditions{Methylases: clone.Methylases(), Partial: true}, bsai)
+	fmt.Println(result.Bands)
+	fmt.Println(result.Blocked[0].BlockedBy)
+
+	unmethylated, _ := clone.DigestWithConditions(plasmid, clone.DigestConditions{Partial: true}, bsai)
+	fmt.Println(unmethylated.PartialBands)
+	// Output:
+	// [1015]
+	// Dcm
+	// [1015 706 309]
+}
+
+func ExampleGel() {
+	// a 3 kb plasmid with two BsaI sites and a BbsI site, a kb apart.
+	filler := strings.Repeat("ATGCATCGAT", 100)[:994]
+	plasmid := clone.Part{Sequence: "GGTCTC" + filler + "GGTCTC" + filler + "GAAGAC" + filler, Circular: true}
+	enzymes := clone.NewEnzymeManager(clone.GetBaseRestrictionEnzymes())
+	bsaI, _ := enzymes.GetEnzymeByName("BsaI")
+	bbsI, _ := enzymes.GetEnzymeByName("BbsI")
+	lanes := []clone.Lane{
+		{Name: "BsaI", Bands: clone.Digest(plasmid, bsaI)},
+		{Name: "BsaI+BbsI", Bands: clone.Digest(plasmid, bsaI, bbsI)},
+	}
+	gel, err := clone.Gel(lanes, clone.GelOptions{Ladder: []int{3000, 2000, 1000, 500}, Rows: 6})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(gel)
+	// Output:
+	//       ladder  BsaI    BsaI+BbsI
+	//       ______  ______  _________
+	// 3000  ======
+	// 2000  ======  ======
+	//
+	// 1000  ======  ======  =========
+	//
+	//  500  ======
+}
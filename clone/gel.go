@@ -0,0 +1,114 @@
+package clone
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+/******************************************************************************
+
+Gel drawing begins here.
+
+A list of band sizes is what a digest predicts, but a gel is what it gets
+compared to, and it's easier to compare a gel to a picture of a gel than to a
+list. Gel draws lanes of bands as text, spaced the way DNA runs through
+agarose: a fragment's distance down the gel falls off with the log of its
+size, so the big bands bunch up at the top and the small ones spread out at
+the bottom. A ladder runs in the first lane, with its sizes down the side to
+read the other lanes against.
+
+******************************************************************************/
+
+// Lane is a lane of a gel.
+type Lane struct {
+	Name  string
+	Bands []int
+}
+
+// GelOptions changes how Gel draws a gel. Zero values are replaced with the
+// defaults noted on each field.
+type GelOptions struct {
+	// Ladder is the sizes of the ladder's bands. Defaults to OneKbLadder.
+	Ladder []int
+	// Rows is how many lines the gel is drawn on. Defaults to 24.
+	Rows int
+}
+
+// OneKbLadder is a common 1 kb DNA ladder, from 500 bases to 10 kb.
+var OneKbLadder = []int{10000, 8000, 6000, 5000, 4000, 3000, 2000, 1500, 1000, 500}
+
+// Gel draws lanes of bands as text, after a ladder. Bands too big or too small
+// for the ladder are drawn at the top or bottom of the gel. It returns an
+// error for a negative number of rows or a ladder band that isn't positive.
+func Gel(lanes []Lane, options GelOptions) (string, error) {
+	if options.Ladder == nil {
+		options.Ladder = OneKbLadder
+	}
+	if options.Rows == 0 {
+		options.Rows = 24
+	}
+	if options.Rows < 0 {
+		return "", fmt.Errorf("a gel can't have %d rows", options.Rows)
+	}
+	for _, size := range options.Ladder {
+		if size <= 0 {
+			return "", fmt.Errorf("ladder band of %d bases isn't a positive size", size)
+		}
+	}
+	lanes = append([]Lane{{Name: "ladder", Bands: options.Ladder}}, lanes...)
+
+	largest, smallest := 0, math.MaxInt
+	for _, size := range options.Ladder {
+		largest, smallest = max(largest, size), min(smallest, size)
+	}
+	// row returns the line a band runs to.
+	row := func(size int) int {
+		if largest <= smallest {
+			return 0
+		}
+		size = max(min(size, largest), smallest)
+		distance := (math.Log(float64(largest)) - math.Log(float64(size))) / (math.Log(float64(largest)) - math.Log(float64(smallest)))
+		return int(math.Round(distance * float64(options.Rows-1)))
+	}
+
+	labels := make([]string, options.Rows)
+	labelWidth := 0
+	for _, size := range options.Ladder {
+		labels[row(size)] = strconv.Itoa(size)
+		labelWidth = max(labelWidth, len(labels[row(size)]))
+	}
+	widths := make([]int, len(lanes))
+	occupied := make([][]bool, len(lanes))
+	for index, lane := range lanes {
+		widths[index] = max(len(lane.Name), 6)
+		occupied[index] = make([]bool, options.Rows)
+		for _, size := range lane.Bands {
+			occupied[index][row(size)] = true
+		}
+	}
+
+	var gel strings.Builder
+	writeLine := func(label string, cell func(lane int) string) {
+		line := strings.Repeat(" ", labelWidth-len(label)) + label
+		for index := range lanes {
+			line += "  " + cell(index)
+		}
+		gel.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	writeLine("", func(lane int) string {
+		return lanes[lane].Name + strings.Repeat(" ", widths[lane]-len(lanes[lane].Name))
+	})
+	// the wells the lanes were loaded into.
+	writeLine("", func(lane int) string { return strings.Repeat("_", widths[lane]) })
+	for line := 0; line < options.Rows; line++ {
+		writeLine(labels[line], func(lane int) string {
+			if occupied[lane][line] {
+				return strings.Repeat("=", widths[lane])
+			}
+			return strings.Repeat(" ", widths[lane])
+		})
+	}
+	return gel.String(), nil
+}
@@ -0,0 +1,55 @@
+package clone
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGel(t *testing.T) {
+	gel, err := Gel([]Lane{{Name: "EcoRI", Bands: []int{10000, 500}}, {Name: "too big", Bands: []int{50000}}}, GelOptions{Rows: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(gel, "\n"), "\n")
+	if len(lines) != 12 {
+		t.Fatalf("gel has %d lines, not 12:\n%s", len(lines), gel)
+	}
+	if !strings.Contains(lines[0], "ladder") || !strings.Contains(lines[0], "EcoRI") || !strings.Contains(lines[0], "too big") {
+		t.Errorf("header %q doesn't name every lane", lines[0])
+	}
+	// the largest ladder band runs to the top, and the smallest to the bottom.
+	top, bottom := lines[2], lines[len(lines)-1]
+	if !strings.HasPrefix(top, "10000") || !strings.HasPrefix(bottom, "  500") {
+		t.Errorf("ladder sizes aren't at the top and bottom:\n%s", gel)
+	}
+	// every lane has a band at the top, since bands too big for the ladder
+	// are drawn there, but only the EcoRI lane has one at the bottom.
+	if strings.Count(top, "=") != len("ladder")+len("EcoRI ")+len("too big") {
+		t.Errorf("top row %q doesn't have a band in every lane", top)
+	}
+	if strings.Count(bottom, "=") != len("ladder")+len("EcoRI ") {
+		t.Errorf("bottom row %q doesn't have bands in the ladder and EcoRI lanes", bottom)
+	}
+}
+
+func TestGelBandOrder(t *testing.T) {
+	ladder := []int{10000, 1000, 100}
+	options := GelOptions{Ladder: ladder, Rows: 21}
+	gel, err := Gel(nil, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(gel, "\n")
+	// on a log scale, 1000 is halfway between 10000 and 100.
+	if !strings.HasPrefix(lines[2+10], " 1000") {
+		t.Errorf("1000 isn't halfway down:\n%s", gel)
+	}
+}
+
+func TestGelErrors(t *testing.T) {
+	for _, options := range []GelOptions{{Rows: -1}, {Ladder: []int{1000, 0}}, {Ladder: []int{-500}}} {
+		if _, err := Gel(nil, options); err == nil {
+			t.Errorf("Gel with %+v should have failed", options)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package gibson_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/clone/gibson"
+	"github.com/bebop/poly/io/fasta"
+)
+
+func ExampleAssemble() {
+	// two fragments sharing a 22 base overlap.
+	fragments := []fasta.Fasta{
+		{Name: "promoter", Sequence: "TTGACAGCTAGCTCAGTCCTAGGTATAATGCTAGCGAATTCGCGGCCGCTTCTAGAG"},
+		{Name: "gfp", Sequence: "GAATTCGCGGCCGCTTCTAGAGATGCGTAAAGGAGAAGAACTTTTCACTGGAGTTGTCCC"},
+	}
+	assembly, _ := gibson.Assemble(fragments, gibson.Options{})
+	fmt.Println(assembly.Junctions[0].Overlap)
+	fmt.Println(assembly.Sequence)
+	fmt.Println(assembly.Problems(fragments))
+	// Output:
+	// GAATTCGCGGCCGCTTCTAGAG
+	// TTGACAGCTAGCTCAGTCCTAGGTATAATGCTAGCGAATTCGCGGCCGCTTCTAGAGATGCGTAAAGGAGAAGAACTTTTCACTGGAGTTGTCCC
+	// []
+}
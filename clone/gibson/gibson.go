@@ -0,0 +1,280 @@
+/*
+Package gibson simulates and designs Gibson assemblies.
+
+Gibson assembly joins DNA fragments whose ends overlap. An exonuclease chews
+back the 5' ends of every fragment, the exposed 3' ends of neighboring
+fragments anneal where they overlap, and a polymerase and ligase seal the
+gaps. Since nothing but the overlaps decides what joins to what, the overlaps
+are all there is to get right:
+
+They need to be long enough, and have a high enough melting temperature, to
+stay annealed at 50 °C. Somewhere from 20 to 40 bases usually does it.
+
+They need to be unique. An overlap that also shows up somewhere else in the
+assembly gives the annealing end a second place to go.
+
+They shouldn't be palindromic, since a palindromic end can anneal to itself
+instead of to its neighbor.
+
+Assemble checks the overlaps between a list of fragments in order and
+simulates the product, reporting every junction and anything wrong with it.
+Design goes the other way: given parts without overlaps, it designs primers
+that add them, so the PCR products can go straight into Assemble.
+
+For more on Gibson assembly:
+Gibson et al., 2009
+https://doi.org/10.1038/nmeth.1318
+*/
+package gibson
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/primers"
+	"github.com/bebop/poly/primers/pcr"
+	"github.com/bebop/poly/transform"
+)
+
+// Options changes how overlaps are checked and designed. Zero values are
+// replaced with the defaults noted on each field.
+type Options struct {
+	// MinOverlap is the shortest overlap that counts. Defaults to 15.
+	MinOverlap int
+	// MaxOverlap is the longest overlap Design makes. Defaults to 40.
+	MaxOverlap int
+	// MinTm is the lowest melting temperature an overlap can have, in °C.
+	// Defaults to 50.
+	MinTm float64
+	// PrimerTm is the melting temperature of the part of Design's primers
+	// that anneals to its part. Defaults to 60.
+	PrimerTm float64
+	// Circular assemblies also join the last fragment to the first.
+	Circular bool
+}
+
+// Junction is where two fragments of an assembly join.
+type Junction struct {
+	Left, Right int    // indices of the fragments on either side.
+	Overlap     string // sequence shared by the end of Left and the start of Right.
+	Tm          float64
+	Problems    []string // anything that could make the junction fail.
+}
+
+// Assembly is the product of a Gibson assembly.
+type Assembly struct {
+	Sequence  string
+	Circular  bool
+	Junctions []Junction
+}
+
+// Problems returns the problems of every junction, prefixed with the names
+// of the fragments either side.
+func (assembly Assembly) Problems(fragments []fasta.Fasta) []string {
+	var problems []string
+	for _, junction := range assembly.Junctions {
+		for _, problem := range junction.Problems {
+			problems = append(problems, fmt.Sprintf("%s to %s: %s", fragments[junction.Left].Name, fragments[junction.Right].Name, problem))
+		}
+	}
+	return problems
+}
+
+func withDefaults(options Options) Options {
+	if options.MinOverlap == 0 {
+		options.MinOverlap = 15
+	}
+	if options.MaxOverlap == 0 {
+		options.MaxOverlap = 40
+	}
+	if options.MinTm == 0 {
+		options.MinTm = 50
+	}
+	if options.PrimerTm == 0 {
+		options.PrimerTm = 60
+	}
+	return options
+}
+
+// Assemble joins fragments in order by the overlaps between their ends, which
+// are always taken as long as they can be. It fails if two neighboring
+// fragments don't overlap by at least MinOverlap bases. Other problems, like
+// a low melting temperature or an overlap that isn't unique, are reported
+// on their junctions.
+func Assemble(fragments []fasta.Fasta, options Options) (Assembly, error) {
+	options = withDefaults(options)
+	if len(fragments) < 2 && !options.Circular {
+		return Assembly{}, fmt.Errorf("need at least two fragments to assemble, got %d", len(fragments))
+	}
+	if len(fragments) == 0 {
+		return Assembly{}, fmt.Errorf("need at least one fragment to assemble")
+	}
+	sequences := make([]string, len(fragments))
+	for index, fragment := range fragments {
+		sequences[index] = strings.ToUpper(fragment.Sequence)
+	}
+
+	junctionCount := len(fragments) - 1
+	if options.Circular {
+		junctionCount = len(fragments)
+	}
+	assembly := Assembly{Circular: options.Circular}
+	for left := 0; left < junctionCount; left++ {
+		right := (left + 1) % len(fragments)
+		overlap := longestOverlap(sequences[left], sequences[right])
+		if len(overlap) < options.MinOverlap {
+			return Assembly{}, fmt.Errorf("%s and %s overlap by %d bases, expected at least %d", fragments[left].Name, fragments[right].Name, len(overlap), options.MinOverlap)
+		}
+		junction := Junction{Left: left, Right: right, Overlap: overlap}
+		junction.Tm, _ = primers.Tm(overlap, primers.DefaultConditions)
+		if junction.Tm < options.MinTm {
+			junction.Problems = append(junction.Problems, fmt.Sprintf("overlap Tm of %.1f °C is below %.1f °C", junction.Tm, options.MinTm))
+		}
+		if occurrences := countOccurrences(sequences, overlap); occurrences > 2 {
+			junction.Problems = append(junction.Problems, fmt.Sprintf("overlap occurs %d times in the fragments, expected 2", occurrences))
+		}
+		if palindrome := longestPalindrome(overlap); palindrome*2 >= len(overlap) {
+			junction.Problems = append(junction.Problems, fmt.Sprintf("overlap has a %d base palindrome", palindrome))
+		}
+		assembly.Junctions = append(assembly.Junctions, junction)
+	}
+
+	var product strings.Builder
+	product.WriteString(sequences[0])
+	for _, junction := range assembly.Junctions {
+		if junction.Right == 0 {
+			// closing the circle trims the overlap off the end instead.
+			trimmed := product.String()
+			product.Reset()
+			product.WriteString(trimmed[:len(trimmed)-len(junction.Overlap)])
+			continue
+		}
+		product.WriteString(sequences[junction.Right][len(junction.Overlap):])
+	}
+	assembly.Sequence = product.String()
+	return assembly, nil
+}
+
+// longestOverlap is the longest end of left that's also the start of right,
+// short of the whole of either.
+func longestOverlap(left, right string) string {
+	for length := min(len(left), len(right)) - 1; length > 0; length-- {
+		if left[len(left)-length:] == right[:length] {
+			return right[:length]
+		}
+	}
+	return ""
+}
+
+// countOccurrences counts the places an overlap, or its reverse complement,
+// occurs in sequences. A unique overlap occurs exactly twice, once at each
+// end of its junction.
+func countOccurrences(sequences []string, overlap string) int {
+	reverse := transform.ReverseComplement(overlap)
+	count := 0
+	for _, sequence := range sequences {
+		for _, query := range []string{overlap, reverse} {
+			for start := 0; ; start++ {
+				index := strings.Index(sequence[start:], query)
+				if index == -1 {
+					break
+				}
+				count++
+				start += index
+			}
+			if reverse == overlap {
+				break
+			}
+		}
+	}
+	return count
+}
+
+// longestPalindrome is the length of the longest stretch of sequence that is
+// its own reverse complement.
+func longestPalindrome(sequence string) int {
+	longest := 0
+	for center := 1; center < len(sequence); center++ {
+		// DNA palindromes have even length, centered between two bases.
+		length := 0
+		for center-length-1 >= 0 && center+length < len(sequence) && transform.ComplementBase(rune(sequence[center-length-1])) == rune(sequence[center+length]) {
+			length++
+		}
+		longest = max(longest, 2*length)
+	}
+	return longest
+}
+
+/******************************************************************************
+
+Overlap design begins here.
+
+Every junction gets an overlap centered on it, half from the end of the
+part on its left and half from the start of the part on its right, grown from
+MinOverlap until it reaches MinTm. The left part's reverse primer carries the
+start of the overlap that's on the right part, and the right part's forward
+primer carries the end of the overlap that's on the left part, so after PCR
+the two products share the whole overlap.
+
+******************************************************************************/
+
+// Amplification is a part with the primers that add its overlaps.
+type Amplification struct {
+	Part             string // name of the part.
+	Forward, Reverse string
+	Product          fasta.Fasta // the part with its overlaps, as amplified.
+}
+
+// Design designs primers that add overlaps between parts, in order, so that
+// their products assemble into a single construct.
+func Design(parts []fasta.Fasta, options Options) ([]Amplification, error) {
+	options = withDefaults(options)
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("need at least two parts to design overlaps, got %d", len(parts))
+	}
+	sequences := make([]string, len(parts))
+	for index, part := range parts {
+		sequences[index] = strings.ToUpper(part.Sequence)
+	}
+
+	// heads[index] and tails[index] are added before and after part index.
+	heads, tails := make([]string, len(parts)), make([]string, len(parts))
+	junctionCount := len(parts) - 1
+	if options.Circular {
+		junctionCount = len(parts)
+	}
+	for left := 0; left < junctionCount; left++ {
+		right := (left + 1) % len(parts)
+		leftSequence, rightSequence := sequences[left], sequences[right]
+		found := false
+		for length := options.MinOverlap; length <= options.MaxOverlap && !found; length++ {
+			leftLength := length / 2
+			rightLength := length - leftLength
+			if leftLength > len(leftSequence) || rightLength > len(rightSequence) {
+				break
+			}
+			overlap := leftSequence[len(leftSequence)-leftLength:] + rightSequence[:rightLength]
+			if tm, err := primers.Tm(overlap, primers.DefaultConditions); err == nil && tm >= options.MinTm {
+				heads[right] = leftSequence[len(leftSequence)-leftLength:]
+				tails[left] = rightSequence[:rightLength]
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("couldn't design an overlap between %s and %s reaching %.1f °C in %d bases", parts[left].Name, parts[right].Name, options.MinTm, options.MaxOverlap)
+		}
+	}
+
+	amplifications := make([]Amplification, len(parts))
+	for index, part := range parts {
+		forward, reverse := pcr.DesignPrimersWithOverhangs(sequences[index], heads[index], tails[index], options.PrimerTm)
+		amplifications[index] = Amplification{
+			Part:    part.Name,
+			Forward: forward,
+			Reverse: reverse,
+			Product: fasta.Fasta{Name: part.Name, Sequence: heads[index] + sequences[index] + tails[index]},
+		}
+	}
+	return amplifications, nil
+}
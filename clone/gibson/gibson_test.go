@@ -0,0 +1,103 @@
+package gibson
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/random"
+	"github.com/bebop/poly/transform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func randomParts(seed int64, lengths ...int) []fasta.Fasta {
+	var parts []fasta.Fasta
+	for index, length := range lengths {
+		sequence, _ := random.DNASequence(length, seed+int64(index))
+		parts = append(parts, fasta.Fasta{Name: "part" + string(rune('A'+index)), Sequence: sequence})
+	}
+	return parts
+}
+
+func TestDesignAndAssemble(t *testing.T) {
+	parts := randomParts(1, 300, 500, 400)
+	joined := parts[0].Sequence + parts[1].Sequence + parts[2].Sequence
+
+	for _, circular := range []bool{false, true} {
+		amplifications, err := Design(parts, Options{Circular: circular})
+		require.NoError(t, err)
+		require.Len(t, amplifications, 3)
+		var products []fasta.Fasta
+		for _, amplification := range amplifications {
+			product := amplification.Product.Sequence
+			assert.True(t, strings.HasPrefix(product, amplification.Forward))
+			assert.True(t, strings.HasSuffix(product, transform.ReverseComplement(amplification.Reverse)))
+			products = append(products, amplification.Product)
+		}
+
+		assembly, err := Assemble(products, Options{Circular: circular})
+		require.NoError(t, err)
+		assert.Equal(t, circular, assembly.Circular)
+		assert.Empty(t, assembly.Problems(products))
+		for _, junction := range assembly.Junctions {
+			assert.GreaterOrEqual(t, junction.Tm, 50.0)
+			assert.True(t, len(junction.Overlap) >= 15 && len(junction.Overlap) <= 40)
+		}
+		if circular {
+			require.Len(t, assembly.Junctions, 3)
+			assert.Equal(t, 0, assembly.Junctions[2].Right)
+			assert.Len(t, assembly.Sequence, len(joined))
+			assert.Contains(t, assembly.Sequence+assembly.Sequence, joined)
+		} else {
+			require.Len(t, assembly.Junctions, 2)
+			assert.Equal(t, joined, assembly.Sequence)
+		}
+	}
+
+	_, err := Design(parts[:1], Options{})
+	assert.Error(t, err)
+	_, err = Design([]fasta.Fasta{{Name: "a", Sequence: strings.Repeat("AT", 100)}, {Name: "b", Sequence: strings.Repeat("TA", 100)}}, Options{})
+	assert.Error(t, err)
+}
+
+func TestAssembleProblems(t *testing.T) {
+	parts := randomParts(2, 200, 200, 200, 200)
+	a, b, c, d := parts[0].Sequence, parts[1].Sequence, parts[2].Sequence, parts[3].Sequence
+
+	// no overlap at all.
+	_, err := Assemble(parts, Options{})
+	assert.Error(t, err)
+
+	// an overlap that's repeated inside the last fragment.
+	overlap := a[170:]
+	fragments := []fasta.Fasta{{Name: "a", Sequence: a}, {Name: "b", Sequence: overlap + b + c[:25]}, {Name: "c", Sequence: c + overlap + d}}
+	assembly, err := Assemble(fragments, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, a+b+c+overlap+d, assembly.Sequence)
+	require.Len(t, assembly.Junctions[0].Problems, 1)
+	assert.Contains(t, assembly.Junctions[0].Problems[0], "occurs 3 times")
+	assert.Empty(t, assembly.Junctions[1].Problems)
+	assert.Equal(t, []string{"a to b: " + assembly.Junctions[0].Problems[0]}, assembly.Problems(fragments))
+
+	// a palindromic overlap.
+	palindrome := "GCGAATTCCGGAATTCGC"
+	assembly, err = Assemble([]fasta.Fasta{{Name: "a", Sequence: a + palindrome}, {Name: "b", Sequence: palindrome + b}}, Options{})
+	require.NoError(t, err)
+	assert.Contains(t, strings.Join(assembly.Junctions[0].Problems, "\n"), "palindrome")
+
+	// an overlap that's too AT rich to stay annealed.
+	weak := "ATTATAATTAAATATTAT"
+	assembly, err = Assemble([]fasta.Fasta{{Name: "a", Sequence: a + weak}, {Name: "b", Sequence: weak + b}}, Options{})
+	require.NoError(t, err)
+	assert.Contains(t, strings.Join(assembly.Junctions[0].Problems, "\n"), "Tm")
+
+	_, err = Assemble(parts[:1], Options{})
+	assert.Error(t, err)
+}
+
+func TestLongestPalindrome(t *testing.T) {
+	assert.Equal(t, 6, longestPalindrome("AAGAATTCAA"))
+	assert.Equal(t, 0, longestPalindrome("AAAA"))
+	assert.Equal(t, 4, longestPalindrome("TTACGTTT"))
+}
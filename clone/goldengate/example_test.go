@@ -0,0 +1,33 @@
+package goldengate_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/clone"
+	"github.com/bebop/poly/clone/goldengate"
+)
+
+func ExampleAssemble() {
+	bsai := goldengate.Enzymes()[0]
+	// a promoter and a CDS go into a backbone, joined by MoClo overhangs.
+	parts := []clone.Part{
+		{Sequence: "TTGGTCTCAGGAGTTGACAGCTAGCTCAGTCCTAGGTATAATGCTAGCAATGAGAGACCTT"},
+		{Sequence: "TTGGTCTCAAATGCGTAAAGGAGAAGAACTTTTCACTGGAGTTGTCCCGCTTAGAGACCTT"},
+		{Sequence: "GGTCTCAGCTTACTAGTAGCGGCCGCTGCAGTCCGGCAAAAAAGGGCAAGGTGTCACCACCCTGCCCGGAGAGAGACC", Circular: true},
+	}
+	assembly, _ := goldengate.Assemble(parts, bsai, goldengate.Options{})
+	fmt.Println(len(assembly.Constructs))
+	fmt.Println(assembly.Ends)
+	fmt.Println(assembly.Problems)
+
+	// swapping GGAG for CATG would be a bad idea.
+	for _, problem := range goldengate.CheckOverhangs([]string{"AATG", "CATG", "GCTT"}) {
+		fmt.Println(problem)
+	}
+	// Output:
+	// 1
+	// [AAGC AATG CATT CTCC GCTT GGAG]
+	// []
+	// end AATG differs from CATG, the partner of CATG, by a single base
+	// end CATG is palindromic
+}
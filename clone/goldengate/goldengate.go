@@ -0,0 +1,268 @@
+/*
+Package goldengate simulates Golden Gate and MoClo assemblies and checks their overhangs.
+
+clone.GoldenGate already digests parts with a Type IIS enzyme and ligates the
+fragments into every construct they can make. That answers what you'd get if
+every overhang only ever ligated to its partner, which is the assumption
+Golden Gate designs live and die by. Ligase isn't that picky: overhangs that
+differ by a single base, especially G:T mismatches, ligate to each other often
+enough to give wrong clones, and the more parts an assembly has, the more
+chances it has to go wrong.
+
+Assemble simulates the assembly the same way clone.GoldenGate does, and then
+checks the overhangs of every fragment:
+
+Palindromic overhangs, which are their own partner, so a fragment can ligate
+to a flipped copy of itself.
+
+Nonunique overhangs, where more than one fragment has the same end and they
+compete for the same partner.
+
+Overhangs that differ from another end's partner by a single base, which is
+the usual rule of thumb for mismatch ligation.
+
+Low fidelity, if a ligation frequency table like the ones from Potapov et al.
+is given. The fidelity of an assembly is the chance that every end ligates
+to its proper partner rather than to any other end in the reaction, which is
+the product over every end of its proper ligations over all its ligations.
+
+The ligation frequency data is published, but it's a large table per enzyme
+and temperature, so it isn't bundled here. Pass it in as a FidelityTable.
+
+For more on ligation fidelity:
+Potapov et al., 2018
+https://doi.org/10.1021/acssynbio.8b00333
+*/
+package goldengate
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bebop/poly/clone"
+	"github.com/bebop/poly/search/align"
+	"github.com/bebop/poly/transform"
+)
+
+// Enzymes returns the Type IIS enzymes common in Golden Gate and MoClo
+// assemblies.
+func Enzymes() []clone.Enzyme {
+	// BsaI and BbsI are taken from clone's own enzymes, so the two lists
+	// can't drift apart.
+	base := make(map[string]clone.Enzyme)
+	for _, enzyme := range clone.GetBaseRestrictionEnzymes() {
+		base[enzyme.Name] = enzyme
+	}
+	return []clone.Enzyme{
+		base["BsaI"],
+		{Name: "BsmBI", RegexpFor: regexp.MustCompile("CGTCTC"), RegexpRev: regexp.MustCompile("GAGACG"), Skip: 1, OverheadLength: 4, RecognitionSite: "CGTCTC"},
+		{Name: "Esp3I", RegexpFor: regexp.MustCompile("CGTCTC"), RegexpRev: regexp.MustCompile("GAGACG"), Skip: 1, OverheadLength: 4, RecognitionSite: "CGTCTC"},
+		base["BbsI"],
+		{Name: "PaqCI", RegexpFor: regexp.MustCompile("CACCTGC"), RegexpRev: regexp.MustCompile("GCAGGTG"), Skip: 4, OverheadLength: 4, RecognitionSite: "CACCTGC"},
+		{Name: "SapI", RegexpFor: regexp.MustCompile("GCTCTTC"), RegexpRev: regexp.MustCompile("GAAGAGC"), Skip: 1, OverheadLength: 3, RecognitionSite: "GCTCTTC"},
+	}
+}
+
+// Options changes how an assembly is checked.
+type Options struct {
+	// Fidelity is a ligation frequency table for the enzyme and conditions
+	// of the assembly. Without one, fidelity isn't estimated.
+	Fidelity *FidelityTable
+	// MinFidelity is the lowest estimated fidelity that isn't reported as a
+	// problem. Defaults to 0.95.
+	MinFidelity float64
+}
+
+// Assembly is the result of a simulated Golden Gate assembly.
+type Assembly struct {
+	Constructs    []string // circular constructs, as from clone.GoldenGate.
+	InfiniteLoops []string // constructs that could ligate forever, as from clone.GoldenGate.
+	// Ends are the distinct 5' overhangs of every fragment, written 5' to 3'
+	// on the strand they overhang from.
+	Ends []string
+	// Fidelity is the estimated fraction of correct ligations, or 0 without
+	// a fidelity table.
+	Fidelity float64
+	Problems []string
+}
+
+// Assemble digests parts with enzyme, ligates the fragments into circular
+// constructs, and checks their overhangs.
+func Assemble(parts []clone.Part, enzyme clone.Enzyme, options Options) (Assembly, error) {
+	if options.MinFidelity == 0 {
+		options.MinFidelity = 0.95
+	}
+	var fragments []clone.Fragment
+	for _, part := range parts {
+		fragments = append(fragments, clone.CutWithEnzyme(part, true, enzyme)...)
+	}
+	if len(fragments) == 0 {
+		return Assembly{}, fmt.Errorf("%s doesn't release any fragments from the parts", enzyme.Name)
+	}
+
+	var assembly Assembly
+	assembly.Constructs, assembly.InfiniteLoops = clone.CircularLigate(fragments)
+
+	// every fragment has a 5' overhang on the top strand at its start, and
+	// one on the bottom strand at its end.
+	endCounts := make(map[string]int)
+	for _, fragment := range fragments {
+		for _, end := range []string{fragment.ForwardOverhang, transform.ReverseComplement(fragment.ReverseOverhang)} {
+			if end != "" {
+				endCounts[end]++
+			}
+		}
+	}
+	for end := range endCounts {
+		assembly.Ends = append(assembly.Ends, end)
+	}
+	sort.Strings(assembly.Ends)
+	assembly.Problems = CheckOverhangs(assembly.Ends)
+	for _, end := range assembly.Ends {
+		if endCounts[end] > 1 {
+			assembly.Problems = append(assembly.Problems, fmt.Sprintf("%d fragments have the end %s", endCounts[end], end))
+		}
+	}
+
+	if options.Fidelity != nil {
+		fidelity, err := options.Fidelity.Fidelity(assembly.Ends)
+		if err != nil {
+			return Assembly{}, err
+		}
+		assembly.Fidelity = fidelity
+		if fidelity < options.MinFidelity {
+			assembly.Problems = append(assembly.Problems, fmt.Sprintf("estimated fidelity of %.3f is below %.3f", fidelity, options.MinFidelity))
+		}
+	}
+
+	if len(assembly.Constructs) == 0 {
+		assembly.Problems = append(assembly.Problems, "fragments don't ligate into any circular construct")
+	}
+	for index, construct := range assembly.Constructs {
+		if sites := countSites(construct, enzyme); sites > 0 {
+			assembly.Problems = append(assembly.Problems, fmt.Sprintf("construct %d still has %d %s sites", index+1, sites, enzyme.Name))
+		}
+	}
+	return assembly, nil
+}
+
+// countSites counts the recognition sites of enzyme on both strands of a
+// circular construct, which would get cut again.
+func countSites(construct string, enzyme clone.Enzyme) int {
+	wrapped := construct + construct[:min(len(construct), len(enzyme.RecognitionSite)-1)]
+	return len(enzyme.RegexpFor.FindAllStringIndex(wrapped, -1)) + len(enzyme.RegexpRev.FindAllStringIndex(wrapped, -1))
+}
+
+// CheckOverhangs checks a set of ends, written 5' to 3' on the strand they
+// overhang from, for palindromes and for ends that differ from another end's
+// partner by a single base.
+func CheckOverhangs(ends []string) []string {
+	var problems []string
+	for index, end := range ends {
+		if end == transform.ReverseComplement(end) {
+			problems = append(problems, fmt.Sprintf("end %s is palindromic", end))
+		}
+		for _, other := range ends[index+1:] {
+			partner := transform.ReverseComplement(other)
+			if partner == end {
+				continue
+			}
+			if len(end) == len(partner) && align.Hamming(end, partner) == 1 {
+				problems = append(problems, fmt.Sprintf("end %s differs from %s, the partner of %s, by a single base", end, partner, other))
+			}
+		}
+	}
+	return problems
+}
+
+/******************************************************************************
+
+Ligation fidelity begins here.
+
+******************************************************************************/
+
+// FidelityTable holds ligation frequencies between overhangs.
+// Frequencies[end][partner] is how often end, written 5' to 3', ligated to
+// partner, also written 5' to 3'. An end's proper partner is its reverse
+// complement.
+type FidelityTable struct {
+	Frequencies map[string]map[string]float64
+}
+
+// ParseFidelity reads a ligation frequency table from CSV: a header row of
+// partners, then a row for every end starting with the end, as in the
+// supplementary data of Potapov et al., 2018.
+func ParseFidelity(r io.Reader) (FidelityTable, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return FidelityTable{}, err
+	}
+	if len(records) < 2 {
+		return FidelityTable{}, fmt.Errorf("fidelity table has %d rows, expected a header and at least one end", len(records))
+	}
+	partners := records[0][1:]
+	table := FidelityTable{Frequencies: make(map[string]map[string]float64)}
+	for line, record := range records[1:] {
+		if len(record) != len(partners)+1 {
+			return FidelityTable{}, fmt.Errorf("row %d has %d columns, expected %d", line+2, len(record), len(partners)+1)
+		}
+		end := strings.ToUpper(strings.TrimSpace(record[0]))
+		table.Frequencies[end] = make(map[string]float64)
+		for column, value := range record[1:] {
+			frequency, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return FidelityTable{}, fmt.Errorf("row %d: %w", line+2, err)
+			}
+			table.Frequencies[end][strings.ToUpper(strings.TrimSpace(partners[column]))] = frequency
+		}
+	}
+	return table, nil
+}
+
+// ReadFidelity reads a ligation frequency table from a CSV file.
+func ReadFidelity(path string) (FidelityTable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return FidelityTable{}, err
+	}
+	defer file.Close()
+	return ParseFidelity(file)
+}
+
+// Fidelity estimates the fraction of assemblies in which every end ligates
+// to its proper partner, given every end in the reaction and its partner.
+func (table FidelityTable) Fidelity(ends []string) (float64, error) {
+	present := make(map[string]bool)
+	for _, end := range ends {
+		present[end] = true
+		present[transform.ReverseComplement(end)] = true
+	}
+	// sorted, so the products and sums below are the same from run to run.
+	sorted := make([]string, 0, len(present))
+	for end := range present {
+		sorted = append(sorted, end)
+	}
+	sort.Strings(sorted)
+	fidelity := 1.0
+	for _, end := range sorted {
+		frequencies, ok := table.Frequencies[end]
+		if !ok {
+			return 0, fmt.Errorf("end %s isn't in the fidelity table", end)
+		}
+		total := 0.0
+		for _, partner := range sorted {
+			total += frequencies[partner]
+		}
+		if total == 0 {
+			return 0, fmt.Errorf("end %s never ligates in the fidelity table", end)
+		}
+		fidelity *= frequencies[transform.ReverseComplement(end)] / total
+	}
+	return fidelity, nil
+}
@@ -0,0 +1,147 @@
+package goldengate
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/clone"
+	"github.com/bebop/poly/transform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var bsai = Enzymes()[0]
+
+// mocloPart flanks an insert with BsaI sites that release it with the
+// overhangs left and right.
+func mocloPart(left, insert, right string) clone.Part {
+	return clone.Part{Sequence: "TTTT" + "GGTCTC" + "A" + left + insert + right + "A" + "GAGACC" + "TTTT"}
+}
+
+var (
+	promoter   = mocloPart("GGAG", "TTGACAGCTAGCTCAGTCCTAGGTATAATGCTAGC", "AATG")
+	cds        = mocloPart("AATG", "CGTAAAGGAGAAGAACTTTTCACTGGAGTTGTCCCAATTCTTGTTGAATTAGATGGTGATGTTAATGGGCACAAATTTTCT", "GCTT")
+	terminator = mocloPart("GCTT", "CCAGGCATCAAATAAAACGAAAGGCTCAGTCGAAAGACTGGGCCTTTCGTTTTAT", "CGCT")
+	backbone   = clone.Part{Sequence: "GGTCTC" + "A" + "CGCT" + "ACTAGTAGCGGCCGCTGCAGTCCGGCAAAAAAGGGCAAGGTGTCACCACCCTGCCCTTTTTCTTTAAAACCGAAAAGATTACTTCGCGTTATGCAGGCTTCCTCGCTCACTGACTCGCTG" + "GGAG" + "A" + "GAGACC", Circular: true}
+)
+
+func TestEnzymes(t *testing.T) {
+	enzymes := make(map[string]clone.Enzyme)
+	for _, enzyme := range Enzymes() {
+		enzymes[enzyme.Name] = enzyme
+	}
+	for _, enzyme := range clone.GetBaseRestrictionEnzymes() {
+		if golden, ok := enzymes[enzyme.Name]; ok {
+			assert.Equal(t, enzyme, golden, enzyme.Name)
+		}
+	}
+	assert.Contains(t, enzymes, "BsmBI")
+}
+
+func TestAssemble(t *testing.T) {
+	assembly, err := Assemble([]clone.Part{promoter, cds, terminator, backbone}, bsai, Options{})
+	require.NoError(t, err)
+	require.Len(t, assembly.Constructs, 1)
+	assert.Empty(t, assembly.InfiniteLoops)
+	assert.Len(t, assembly.Ends, 8)
+	assert.Empty(t, assembly.Problems)
+	assert.Zero(t, assembly.Fidelity)
+
+	construct := assembly.Constructs[0] + assembly.Constructs[0]
+	assert.Contains(t, construct, "GGAGTTGACAGCTAGCTCAGTCCTAGGTATAATGCTAGCAATGCGTAAAGG")
+	assert.NotContains(t, construct, "GGTCTC")
+}
+
+func TestAssembleProblems(t *testing.T) {
+	// two parts with the same ends compete for the same partners.
+	duplicate := mocloPart("AATG", "ATGAGTAAAGGAGAAGAACTTTTCACTGGA", "GCTT")
+	assembly, err := Assemble([]clone.Part{promoter, cds, duplicate, terminator, backbone}, bsai, Options{})
+	require.NoError(t, err)
+	assert.Len(t, assembly.Constructs, 2)
+	assert.Contains(t, assembly.Problems, "2 fragments have the end AATG")
+	assert.Contains(t, assembly.Problems, "2 fragments have the end AAGC")
+
+	// a palindromic end ligates to a flipped copy of its own fragment.
+	palindromic := mocloPart("AATG", "CGTAAAGGAGAAGAACTTTTCACTGGA", "GATC")
+	ending := mocloPart("GATC", "CCAGGCATCAAATAAAACGAAAGGCTCAGTCG", "CGCT")
+	assembly, err = Assemble([]clone.Part{promoter, palindromic, ending, backbone}, bsai, Options{})
+	require.NoError(t, err)
+	assert.Contains(t, assembly.Problems, "end GATC is palindromic")
+
+	// a part left in with a BsaI site inside would get cut again.
+	internal := mocloPart("AATG", "CGTAAAGGAGAAGGTCTCTTCACTGGA", "GCTT")
+	assembly, err = Assemble([]clone.Part{promoter, internal, terminator, backbone}, bsai, Options{})
+	require.NoError(t, err)
+	assert.Contains(t, strings.Join(assembly.Problems, "\n"), "fragments don't ligate into any circular construct")
+
+	_, err = Assemble([]clone.Part{{Sequence: "ATGCATGCATGC"}}, bsai, Options{})
+	assert.Error(t, err)
+}
+
+func TestCheckOverhangs(t *testing.T) {
+	// the partner of AATG is CATT, a single base from CATG.
+	problems := CheckOverhangs([]string{"CATG", "AATG", "GGAG"})
+	assert.Contains(t, problems, "end CATG is palindromic")
+	assert.Contains(t, problems, "end CATG differs from CATT, the partner of AATG, by a single base")
+	assert.Empty(t, CheckOverhangs([]string{"GGAG", "AATG", "GCTT", "CGCT"}))
+}
+
+// fidelityTable writes a table over every end and its partner where every
+// end ligates 100 times to its partner, plus mismatches.
+func fidelityTable(t *testing.T, ends []string, mismatches map[[2]string]int) FidelityTable {
+	var all []string
+	for _, end := range ends {
+		all = append(all, end, transform.ReverseComplement(end))
+	}
+	var csv strings.Builder
+	csv.WriteString("overhang," + strings.Join(all, ",") + "\n")
+	for _, end := range all {
+		csv.WriteString(end)
+		for _, partner := range all {
+			count := mismatches[[2]string{end, partner}]
+			if partner == transform.ReverseComplement(end) {
+				count = 100
+			}
+			csv.WriteString("," + strconv.Itoa(count))
+		}
+		csv.WriteString("\n")
+	}
+	table, err := ParseFidelity(strings.NewReader(csv.String()))
+	require.NoError(t, err)
+	return table
+}
+
+func TestFidelity(t *testing.T) {
+	ends := []string{"GGAG", "AATG", "GCTT", "CGCT"}
+	table := fidelityTable(t, ends, nil)
+	fidelity, err := table.Fidelity(ends)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, fidelity)
+
+	// AATG also ligates to AGCG, the partner of CGCT, a quarter as often as
+	// to CATT, so both ends lose a fifth of their ligations.
+	table = fidelityTable(t, ends, map[[2]string]int{{"AATG", "AGCG"}: 25, {"AGCG", "AATG"}: 25})
+	fidelity, err = table.Fidelity(ends)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.8*0.8, fidelity, 1e-9)
+
+	_, err = table.Fidelity([]string{"TTTT"})
+	assert.Error(t, err)
+
+	assembly, err := Assemble([]clone.Part{promoter, cds, terminator, backbone}, bsai, Options{Fidelity: &table})
+	require.NoError(t, err)
+	assert.InDelta(t, 0.64, assembly.Fidelity, 1e-9)
+	assert.Contains(t, assembly.Problems, "estimated fidelity of 0.640 is below 0.950")
+}
+
+func TestParseFidelity(t *testing.T) {
+	_, err := ParseFidelity(strings.NewReader("overhang,AATG\n"))
+	assert.Error(t, err)
+	_, err = ParseFidelity(strings.NewReader("overhang,AATG,CATT\nAATG,1\n"))
+	assert.Error(t, err)
+	_, err = ParseFidelity(strings.NewReader("overhang,AATG\nAATG,lots\n"))
+	assert.Error(t, err)
+	_, err = ReadFidelity("data/missing.csv")
+	assert.Error(t, err)
+}
@@ -0,0 +1,211 @@
+package crispr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bebop/poly/alphabet"
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/transform"
+)
+
+/******************************************************************************
+
+Guide array building begins here.
+
+Knocking out a whole pathway, or every copy of a gene family, takes more than
+one guide. The guides can each get their own promoter and terminator, but
+that makes for long constructs full of repeated promoters. The alternative is
+to transcribe every guide as one long RNA and let the cell cut it apart:
+
+Csy4 arrays flank every guide with the 28 base hairpin that the Csy4
+endonuclease from Pseudomonas aeruginosa recognizes and cuts. Csy4 has to be
+expressed alongside Cas9.
+Tsai et al., 2014
+https://doi.org/10.1038/nbt.2908
+
+tRNA arrays (polycistronic tRNA-gRNA, or PTG) put a tRNA in front of every
+guide. RNase P and RNase Z, which every cell already has, cut the tRNAs off
+precisely, releasing the guides.
+Xie, Minkenberg, and Yang, 2015
+https://doi.org/10.1073/pnas.1420294112
+
+Either way the array is the same few parts over and over, with only the
+spacers changing. Spacers that share a long stretch of sequence with each
+other, or with the repeated parts, make the array prone to recombining and
+the guides prone to misfolding, so BuildArray refuses to build them.
+
+******************************************************************************/
+
+// Processing is how the guides of an array are expressed and released.
+type Processing int
+
+// Ways of expressing several guides at once.
+const (
+	Cassettes Processing = iota // every guide gets its own promoter and terminator.
+	Csy4                        // one transcript, cut apart at Csy4 hairpins.
+	TRNA                        // one transcript, cut apart at tRNAs by the cell's own RNases.
+)
+
+const (
+	// SpCas9Scaffold is the standard sgRNA scaffold of SpCas9, which follows
+	// the spacer of every guide.
+	SpCas9Scaffold = "GTTTTAGAGCTAGAAATAGCAAGTTAAAATAAGGCTAGTCCGTTATCAACTTGAAAAAGTGGCACCGAGTCGGTGC"
+	// Csy4Site is the Csy4 recognition hairpin from Tsai et al., 2014.
+	Csy4Site = "GTTCACTGCCGTATAGGCAGCTAAGAAA"
+	// GlycineTRNA is the rice tRNA-Gly used in tRNA arrays by Xie et al., 2015.
+	GlycineTRNA = "GCACCAGTGGTCTAGTGGTAGAATAGTACCCTGCCACGGTACAGACCCGGGTTCGATTCCCGGCTGGTGCA"
+	// PolIIITerminator ends the transcripts of Pol III promoters like U6.
+	PolIIITerminator = "TTTTTT"
+)
+
+// ArrayOptions describes the parts of a guide array. Zero values are
+// replaced with the defaults noted on each field.
+type ArrayOptions struct {
+	Name       string // defaults to "guide_array".
+	Processing Processing
+	// Promoter goes in front of the array, or in front of every guide for
+	// Cassettes. There's no default, since the right one depends on the
+	// organism, so it's left out if empty.
+	Promoter   string
+	Scaffold   string // defaults to SpCas9Scaffold.
+	Terminator string // defaults to PolIIITerminator.
+	// RepeatLength is the length of the shortest stretch spacers can't share
+	// with each other or with the repeated parts of the array, on either
+	// strand. Defaults to 12.
+	RepeatLength int
+}
+
+// BuildArray assembles spacers, written 5' to 3' without their PAM, into an
+// annotated guide expression array.
+func BuildArray(spacers []string, options ArrayOptions) (genbank.Genbank, error) {
+	if len(spacers) == 0 {
+		return genbank.Genbank{}, fmt.Errorf("no spacers to build an array from")
+	}
+	if options.Scaffold == "" {
+		options.Scaffold = SpCas9Scaffold
+	}
+	if options.Terminator == "" {
+		options.Terminator = PolIIITerminator
+	}
+	if options.RepeatLength == 0 {
+		options.RepeatLength = 12
+	}
+	if options.Name == "" {
+		options.Name = "guide_array"
+	}
+	options.Promoter = strings.ToUpper(options.Promoter)
+	options.Scaffold = alphabet.RNAToDNA(strings.ToUpper(options.Scaffold))
+	options.Terminator = strings.ToUpper(options.Terminator)
+
+	var processingSite, processingType, processingLabel string
+	switch options.Processing {
+	case Cassettes:
+	case Csy4:
+		processingSite, processingType, processingLabel = Csy4Site, "protein_bind", "Csy4 site"
+	case TRNA:
+		processingSite, processingType, processingLabel = GlycineTRNA, "tRNA", "tRNA-Gly"
+	default:
+		return genbank.Genbank{}, fmt.Errorf("unknown processing %d", options.Processing)
+	}
+
+	cleanSpacers := make([]string, len(spacers))
+	for index, spacer := range spacers {
+		spacer = alphabet.RNAToDNA(strings.ToUpper(spacer))
+		if spacer == "" || strings.Trim(spacer, "ACGT") != "" {
+			return genbank.Genbank{}, fmt.Errorf("spacer %d (%q) has bases other than A, C, G, and T", index+1, spacers[index])
+		}
+		// a run of T's ends Pol III transcription partway through the array.
+		if strings.HasPrefix(options.Terminator, "TTTT") && strings.Contains(spacer, "TTTT") {
+			return genbank.Genbank{}, fmt.Errorf("spacer %d (%s) has a run of four T's, which terminates Pol III transcription", index+1, spacer)
+		}
+		cleanSpacers[index] = spacer
+	}
+	parts := [][2]string{{"scaffold", options.Scaffold}}
+	if processingSite != "" {
+		parts = append(parts, [2]string{processingLabel, processingSite})
+	}
+	if err := checkRepeats(cleanSpacers, parts, options.RepeatLength); err != nil {
+		return genbank.Genbank{}, err
+	}
+
+	array := genbank.Genbank{}
+	var sequence strings.Builder
+	var features []genbank.Feature
+	add := func(part, featureType, label string) {
+		if part == "" {
+			return
+		}
+		features = append(features, genbank.Feature{
+			Type:       featureType,
+			Attributes: map[string]string{"label": label},
+			Location:   genbank.Location{Start: sequence.Len(), End: sequence.Len() + len(part)},
+		})
+		sequence.WriteString(part)
+	}
+	if options.Processing != Cassettes {
+		add(options.Promoter, "promoter", "promoter")
+	}
+	for index, spacer := range cleanSpacers {
+		if options.Processing == Cassettes {
+			add(options.Promoter, "promoter", fmt.Sprintf("guide %d promoter", index+1))
+		}
+		add(processingSite, processingType, processingLabel)
+		add(spacer, "misc_RNA", fmt.Sprintf("guide %d spacer", index+1))
+		add(options.Scaffold, "misc_RNA", "sgRNA scaffold")
+		if options.Processing == Cassettes {
+			add(options.Terminator, "terminator", fmt.Sprintf("guide %d terminator", index+1))
+		}
+	}
+	if options.Processing == Csy4 {
+		// the last guide needs a Csy4 site after it too, to be cut free.
+		add(processingSite, processingType, processingLabel)
+	}
+	if options.Processing != Cassettes {
+		add(options.Terminator, "terminator", "terminator")
+	}
+
+	array.Sequence = sequence.String()
+	array.Meta.Name = options.Name
+	array.Meta.Locus = genbank.Locus{Name: options.Name, SequenceLength: strconv.Itoa(len(array.Sequence)), MoleculeType: "DNA"}
+	for index := range features {
+		_ = array.AddFeature(&features[index])
+	}
+	return array, nil
+}
+
+// checkRepeats makes sure no two spacers, and no spacer and part, share a
+// stretch of repeatLength bases on either strand.
+// Parts are given as {name, sequence}.
+func checkRepeats(spacers []string, parts [][2]string, repeatLength int) error {
+	// owners maps every k-mer to the first spacer that has it.
+	owners := make(map[string]int)
+	for index, spacer := range spacers {
+		for start := 0; start+repeatLength <= len(spacer); start++ {
+			kmer := spacer[start : start+repeatLength]
+			for _, strand := range []string{kmer, transform.ReverseComplement(kmer)} {
+				if owner, ok := owners[strand]; ok && owner != index {
+					return fmt.Errorf("spacers %d and %d share the repeat %s", owner+1, index+1, kmer)
+				}
+			}
+		}
+		for start := 0; start+repeatLength <= len(spacer); start++ {
+			if _, ok := owners[spacer[start:start+repeatLength]]; !ok {
+				owners[spacer[start:start+repeatLength]] = index
+			}
+		}
+	}
+	for _, part := range parts {
+		name, part := part[0], part[1]
+		for start := 0; start+repeatLength <= len(part); start++ {
+			kmer := part[start : start+repeatLength]
+			for _, strand := range []string{kmer, transform.ReverseComplement(kmer)} {
+				if owner, ok := owners[strand]; ok {
+					return fmt.Errorf("spacer %d shares the repeat %s with the %s", owner+1, kmer, name)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package crispr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/transform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var arraySpacers = []string{"GAGTCCGAGCAGAAGAAGAA", "GACCCCCTCCACCCCGCCTC", "GGTGAGTGAGTGTGTGCGTG"}
+
+func TestBuildArray(t *testing.T) {
+	promoter := "GAGGGCCTATTTCCCATGATTCC"
+	tests := []struct {
+		processing Processing
+		labels     []string
+		sequence   string
+	}{
+		{
+			processing: Cassettes,
+			labels:     []string{"guide 1 promoter", "guide 1 spacer", "sgRNA scaffold", "guide 1 terminator", "guide 2 promoter", "guide 2 spacer", "sgRNA scaffold", "guide 2 terminator", "guide 3 promoter", "guide 3 spacer", "sgRNA scaffold", "guide 3 terminator"},
+			sequence:   promoter + arraySpacers[0] + SpCas9Scaffold + PolIIITerminator + promoter + arraySpacers[1] + SpCas9Scaffold + PolIIITerminator + promoter + arraySpacers[2] + SpCas9Scaffold + PolIIITerminator,
+		},
+		{
+			processing: Csy4,
+			labels:     []string{"promoter", "Csy4 site", "guide 1 spacer", "sgRNA scaffold", "Csy4 site", "guide 2 spacer", "sgRNA scaffold", "Csy4 site", "guide 3 spacer", "sgRNA scaffold", "Csy4 site", "terminator"},
+			sequence:   promoter + Csy4Site + arraySpacers[0] + SpCas9Scaffold + Csy4Site + arraySpacers[1] + SpCas9Scaffold + Csy4Site + arraySpacers[2] + SpCas9Scaffold + Csy4Site + PolIIITerminator,
+		},
+		{
+			processing: TRNA,
+			labels:     []string{"promoter", "tRNA-Gly", "guide 1 spacer", "sgRNA scaffold", "tRNA-Gly", "guide 2 spacer", "sgRNA scaffold", "tRNA-Gly", "guide 3 spacer", "sgRNA scaffold", "terminator"},
+			sequence:   promoter + GlycineTRNA + arraySpacers[0] + SpCas9Scaffold + GlycineTRNA + arraySpacers[1] + SpCas9Scaffold + GlycineTRNA + arraySpacers[2] + SpCas9Scaffold + PolIIITerminator,
+		},
+	}
+	for _, test := range tests {
+		array, err := BuildArray(arraySpacers, ArrayOptions{Processing: test.processing, Promoter: strings.ToLower(promoter)})
+		require.NoError(t, err)
+		assert.Equal(t, test.sequence, array.Sequence)
+		assert.Equal(t, "guide_array", array.Meta.Locus.Name)
+
+		var labels []string
+		end := 0
+		for _, feature := range array.Features {
+			labels = append(labels, feature.Attributes["label"])
+			// features tile the whole array.
+			assert.Equal(t, end, feature.Location.Start)
+			end = feature.Location.End
+			sequence, err := feature.GetSequence()
+			require.NoError(t, err)
+			if strings.HasSuffix(feature.Attributes["label"], "spacer") {
+				assert.Contains(t, arraySpacers, sequence)
+			}
+		}
+		assert.Equal(t, len(array.Sequence), end)
+		assert.Equal(t, test.labels, labels)
+	}
+
+	// without a promoter there's no promoter feature.
+	array, err := BuildArray(arraySpacers[:1], ArrayOptions{Processing: TRNA, Name: "single"})
+	require.NoError(t, err)
+	assert.Equal(t, GlycineTRNA+arraySpacers[0]+SpCas9Scaffold+PolIIITerminator, array.Sequence)
+	assert.Equal(t, "tRNA", array.Features[0].Type)
+	assert.Equal(t, "single", array.Meta.Name)
+}
+
+func TestBuildArrayErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		spacers []string
+		options ArrayOptions
+	}{
+		{"no spacers", nil, ArrayOptions{}},
+		{"bad base", []string{"GAGTCCGAGCAGAAGAAGNA"}, ArrayOptions{}},
+		{"empty spacer", []string{""}, ArrayOptions{}},
+		{"Pol III terminator", []string{"GAGTCCGATTTTAAGAAGAA"}, ArrayOptions{}},
+		{"shared repeat", []string{arraySpacers[0], "CCCCCCCC" + arraySpacers[0][:12]}, ArrayOptions{}},
+		{"reverse complement repeat", []string{arraySpacers[0], transform.ReverseComplement(arraySpacers[0])}, ArrayOptions{}},
+		{"repeat with the scaffold", []string{"CCC" + SpCas9Scaffold[10:27]}, ArrayOptions{}},
+		{"repeat with the tRNA", []string{"CCC" + GlycineTRNA[20:37]}, ArrayOptions{Processing: TRNA}},
+		{"unknown processing", arraySpacers, ArrayOptions{Processing: 7}},
+	}
+	for _, test := range tests {
+		_, err := BuildArray(test.spacers, test.options)
+		assert.Error(t, err, test.name)
+	}
+
+	// a Pol II terminator lets T runs through, and a longer repeat length
+	// lets short repeats through.
+	_, err := BuildArray([]string{"GAGTCCGATTTTAAGAAGAA"}, ArrayOptions{Terminator: "AATAAA"})
+	assert.NoError(t, err)
+	_, err = BuildArray([]string{arraySpacers[0], "CCCCCCCC" + arraySpacers[0][:12]}, ArrayOptions{RepeatLength: 13})
+	assert.NoError(t, err)
+	// the same spacer twice is a repeat too.
+	_, err = BuildArray([]string{arraySpacers[0], arraySpacers[0]}, ArrayOptions{})
+	assert.Error(t, err)
+}
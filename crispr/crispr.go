@@ -0,0 +1,487 @@
+/*
+Package crispr contains functions for designing CRISPR guides and scoring
+them against a genome.
+
+Cas9 cuts wherever its guide RNA pairs with the genome next to a PAM, a short
+motif like NGG. Unfortunately it isn't picky: sites with a few mismatches to
+the guide, or even a base too many or too few (a bulge), can still get cut.
+These off-target cuts are why guide design is mostly about specificity, and
+why every guide design tool searches the whole genome for near matches.
+
+This package finds every site in a genome within a few mismatches and bulges
+of a guide, using an FM-index so that the genome only has to be read once,
+and scores them with two widely used models:
+
+The MIT score from Hsu et al., 2013 (https://doi.org/10.1038/nbt.2647)
+weighs each mismatch by its position in the guide, and penalizes mismatches
+that are clustered together and guides with many mismatches.
+
+The CFD score from Doench et al., 2016 (https://doi.org/10.1038/nbt.3437)
+multiplies the measured activity of every individual mismatch type at every
+position, and of the PAM. Its tables come from the paper's supplementary data
+and aren't bundled here, so they are passed in as a CFDModel.
+
+Each guide's off-target scores are summed into a specificity from 0 to 1,
+the way the CRISPOR guide design tool does it: 1/(1 + the sum of the scores
+of every off-target). A guide with no off-targets at all has a specificity
+of 1. Neither model was built for bulges, so bulged sites are reported but
+left out of the scores.
+
+FindGuides lists the guides that could cut a target in the first place, with
+an estimate of how well SpCas9 guides cut from Doench et al., 2014
+(https://doi.org/10.1038/nbt.3026), ready to be scored for specificity.
+
+Once you've picked your guides, BuildArray assembles them into a single
+construct that expresses them all at once.
+*/
+package crispr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bebop/poly/alphabet"
+	"github.com/bebop/poly/search/fmindex"
+	"github.com/bebop/poly/search/motif"
+	"github.com/bebop/poly/transform"
+)
+
+// Nuclease describes a Cas nuclease that cuts next to a PAM, on the 3' side
+// of its protospacer unless FivePrimePAM is set.
+type Nuclease struct {
+	Name              string
+	PAM               string   // IUPAC PAM of on-target sites, like "NGG".
+	OffTargetPAMs     []string // IUPAC PAMs the nuclease still cuts at, less efficiently, including PAM.
+	ProtospacerLength int
+	// FivePrimePAM is whether the PAM is on the 5' side of the protospacer,
+	// like the TTTV of Cas12a, rather than on the 3' side like Cas9's NGG.
+	FivePrimePAM bool
+	// CutOffset is how many bases from the 5' end of the protospacer the
+	// nuclease cuts the strand the protospacer is on.
+	CutOffset int
+}
+
+// SpCas9 is Streptococcus pyogenes Cas9, which cuts next to NGG, and to a
+// lesser extent NAG and NGA.
+var SpCas9 = Nuclease{
+	Name:              "SpCas9",
+	PAM:               "NGG",
+	OffTargetPAMs:     []string{"NGG", "NAG", "NGA"},
+	ProtospacerLength: 20,
+	CutOffset:         17,
+}
+
+// SaCas9 is Staphylococcus aureus Cas9, which is small enough to fit in an
+// AAV with room to spare, and cuts next to NNGRRT.
+// Ran et al., 2015 (https://doi.org/10.1038/nature14299)
+var SaCas9 = Nuclease{
+	Name:              "SaCas9",
+	PAM:               "NNGRRT",
+	OffTargetPAMs:     []string{"NNGRRT"},
+	ProtospacerLength: 21,
+	CutOffset:         18,
+}
+
+// AsCas12a is Acidaminococcus Cas12a (Cpf1), which cuts next to a TTTV PAM on
+// the 5' side of its protospacer, leaving sticky ends.
+// Zetsche et al., 2015 (https://doi.org/10.1016/j.cell.2015.09.038)
+var AsCas12a = Nuclease{
+	Name:              "AsCas12a",
+	PAM:               "TTTV",
+	OffTargetPAMs:     []string{"TTTV"},
+	ProtospacerLength: 23,
+	FivePrimePAM:      true,
+	CutOffset:         18,
+}
+
+// Options limits how far off-targets can be from the guide.
+type Options struct {
+	MaxMismatches int // mismatches between the guide and an off-target.
+	MaxBulges     int // extra or missing bases in an off-target, relative to the guide.
+	// CFD scores off-targets if not nil.
+	CFD *CFDModel
+}
+
+// OffTarget is a site in the genome a guide might cut.
+type OffTarget struct {
+	Name     string // name of the sequence the site is in.
+	Position int    // 0-based position of the start of the site, protospacer and PAM, on the forward strand.
+	Forward  bool   // whether the site is on the forward strand.
+	Sequence string // site as read 5' to 3' on its own strand, protospacer and PAM.
+	// AlignedGuide and AlignedSite are the guide and PAM aligned to the
+	// site, with "-" for the missing side of a bulge.
+	AlignedGuide string
+	AlignedSite  string
+	Mismatches   int     // mismatches between the guide and the protospacer.
+	Bulges       int     // bulges between the guide and the protospacer.
+	MIT          float64 // MIT score from 0 to 1, or 0 for bulged sites.
+	CFD          float64 // CFD score from 0 to 1, or 0 for bulged sites or without a CFD model.
+}
+
+// GuideScore is the genome wide specificity of a guide.
+type GuideScore struct {
+	Guide string
+	// OnTarget is the guide's perfect match next to the nuclease's PAM, if
+	// there is one. If there's more than one, the rest count as off-targets.
+	OnTarget       *OffTarget
+	OffTargets     []OffTarget
+	MITSpecificity float64
+	CFDSpecificity float64 // 0 without a CFD model.
+}
+
+// ScoreGuides finds the off-targets of every guide in an indexed genome,
+// on both strands, and scores each guide's specificity. Guides are written 5'
+// to 3' without their PAM. The MIT and CFD models were measured with SpCas9,
+// so the off-targets of nucleases with a 5' PAM are found but not scored.
+func ScoreGuides(index *fmindex.Index, guides []string, nuclease Nuclease, options Options) ([]GuideScore, error) {
+	pams := nuclease.OffTargetPAMs
+	if len(pams) == 0 {
+		pams = []string{nuclease.PAM}
+	}
+	onTargetPAM, err := motif.CompileIUPAC(nuclease.PAM)
+	if err != nil {
+		return nil, fmt.Errorf("nuclease %s: %w", nuclease.Name, err)
+	}
+	scores := make([]GuideScore, len(guides))
+	for guideIndex, guide := range guides {
+		guide = alphabet.RNAToDNA(strings.ToUpper(guide))
+		if len(guide) != nuclease.ProtospacerLength {
+			return nil, fmt.Errorf("guide %q is %d bases long, %s guides are %d bases long", guide, len(guide), nuclease.Name, nuclease.ProtospacerLength)
+		}
+		if strings.Trim(guide, "ACGT") != "" {
+			return nil, fmt.Errorf("guide %q has bases other than A, C, G, and T", guide)
+		}
+		offTargets := findOffTargets(index, guide, pams, nuclease.FivePrimePAM, options)
+
+		score := GuideScore{Guide: guide}
+		mitTotal, cfdTotal := 0.0, 0.0
+		for offTargetIndex := range offTargets {
+			offTarget := &offTargets[offTargetIndex]
+			pam := offTarget.Sequence[len(offTarget.Sequence)-len(nuclease.PAM):]
+			if nuclease.FivePrimePAM {
+				pam = offTarget.Sequence[:len(nuclease.PAM)]
+			}
+			if score.OnTarget == nil && offTarget.Mismatches == 0 && offTarget.Bulges == 0 && onTargetPAM.MatchString(pam) {
+				onTarget := *offTarget
+				score.OnTarget = &onTarget
+				continue
+			}
+			if offTarget.Bulges == 0 && !nuclease.FivePrimePAM {
+				protospacer := offTarget.Sequence[:len(guide)]
+				if len(guide) == len(mitWeights) {
+					offTarget.MIT, _ = MIT(guide, protospacer)
+				}
+				if options.CFD != nil {
+					cfd, err := options.CFD.Score(guide, protospacer, pam)
+					if err != nil {
+						return nil, err
+					}
+					offTarget.CFD = cfd
+				}
+			}
+			mitTotal += offTarget.MIT
+			cfdTotal += offTarget.CFD
+			score.OffTargets = append(score.OffTargets, *offTarget)
+		}
+		score.MITSpecificity = 1 / (1 + mitTotal)
+		if options.CFD != nil {
+			score.CFDSpecificity = 1 / (1 + cfdTotal)
+		}
+		scores[guideIndex] = score
+	}
+	return scores, nil
+}
+
+/******************************************************************************
+
+Off-target search begins here.
+
+Every site is searched for as a single pattern, protospacer and PAM, on
+each strand. The search backtracks through the FM-index from the 3' end of
+the pattern, so a 3' PAM, which has to match, prunes most of the genome before
+any mismatches are spent. A 5' PAM only does that on the reverse strand, so
+searches for nucleases like Cas12a are a bit slower. At every step of the protospacer the search can
+also take a mismatch, a DNA bulge (an extra base in the genome), or an RNA
+bulge (a guide base with nothing to pair to), as long as it has some left.
+
+Bulges make every close site show up a few more times, shifted over by a
+base and paying for it with extra mismatches. Those copies are dropped in
+favor of the closer site next to them.
+
+******************************************************************************/
+
+// siteSearch holds the state of a single pattern's off-target search.
+type siteSearch struct {
+	index         *fmindex.Index
+	symbols       []byte
+	pattern       string
+	guideStart    int // first position of the protospacer in pattern.
+	guideEnd      int // position just past the protospacer in pattern.
+	forward       bool
+	options       Options
+	order         map[string]int       // position of each sequence in the index.
+	found         map[[2]int]OffTarget // keyed by sequence and position.
+	patternBuffer []byte               // guide side of the alignment, built backwards.
+	textBuffer    []byte               // genome side of the alignment, built backwards.
+}
+
+// findOffTargets searches both strands for guide next to any of pams, on
+// its 5' side if fivePrime is set and its 3' side otherwise.
+func findOffTargets(index *fmindex.Index, guide string, pams []string, fivePrime bool, options Options) []OffTarget {
+	found := make(map[[2]int]OffTarget)
+	order := make(map[string]int)
+	for position, name := range index.Names() {
+		order[name] = position
+	}
+	for _, pam := range pams {
+		pam = strings.ToUpper(pam)
+		forward, guideStart := guide+pam, 0
+		if fivePrime {
+			forward, guideStart = pam+guide, len(pam)
+		}
+		guideEnd := guideStart + len(guide)
+		reverse := transform.ReverseComplement(forward)
+		searches := []siteSearch{
+			{pattern: forward, guideStart: guideStart, guideEnd: guideEnd, forward: true},
+			{pattern: reverse, guideStart: len(forward) - guideEnd, guideEnd: len(forward) - guideStart, forward: false},
+		}
+		for _, search := range searches {
+			search.index = index
+			search.symbols = index.Symbols()
+			search.options = options
+			search.order = order
+			search.found = found
+			search.walk(index.Full(), len(search.pattern)-1, 0, 0)
+		}
+	}
+
+	offTargets := make([]OffTarget, 0, len(found))
+	for key, offTarget := range found {
+		if offTarget.Bulges > 0 && shadowed(found, key, offTarget, options.MaxBulges) {
+			continue
+		}
+		offTargets = append(offTargets, offTarget)
+	}
+	sort.Slice(offTargets, func(i, j int) bool {
+		first, second := offTargets[i], offTargets[j]
+		switch {
+		case first.Name != second.Name:
+			return order[first.Name] < order[second.Name]
+		case first.Position != second.Position:
+			return first.Position < second.Position
+		}
+		return first.Forward && !second.Forward
+	})
+	return offTargets
+}
+
+// shadowed checks if a bulged site is just a closer site next to it, shifted
+// over by a bulge or two and paying for it in mismatches.
+func shadowed(found map[[2]int]OffTarget, key [2]int, offTarget OffTarget, maxBulges int) bool {
+	for shift := -maxBulges; shift <= maxBulges; shift++ {
+		neighbor, ok := found[[2]int{key[0], key[1] + shift}]
+		if shift != 0 && ok && neighbor.Mismatches+neighbor.Bulges < offTarget.Mismatches+offTarget.Bulges {
+			return true
+		}
+	}
+	return false
+}
+
+// walk extends rows with the pattern from position down to its start.
+func (search *siteSearch) walk(rows fmindex.Range, position, mismatches, bulges int) {
+	if position < 0 {
+		search.record(rows, mismatches, bulges)
+		return
+	}
+	code := search.pattern[position]
+	inGuide := position >= search.guideStart && position < search.guideEnd
+	// bulges aren't allowed at the edges of the protospacer, where they'd
+	// be indistinguishable from a shorter or shifted site.
+	canBulge := inGuide && bulges < search.options.MaxBulges && position > search.guideStart && position < search.guideEnd-1
+
+	for _, symbol := range search.symbols {
+		next := search.index.Extend(rows, symbol)
+		if next.Empty() {
+			continue
+		}
+		matches := strings.IndexByte(alphabet.Ambiguities(code), symbol) != -1
+		switch {
+		case matches:
+			search.push(code, symbol)
+			search.walk(next, position-1, mismatches, bulges)
+			search.pop()
+		case inGuide && mismatches < search.options.MaxMismatches:
+			search.push(code, symbol)
+			search.walk(next, position-1, mismatches+1, bulges)
+			search.pop()
+		}
+		if canBulge {
+			// DNA bulge: the genome has an extra base here.
+			search.push('-', symbol)
+			search.walk(next, position, mismatches, bulges+1)
+			search.pop()
+		}
+	}
+	if canBulge {
+		// RNA bulge: the guide base has nothing to pair to.
+		search.push(code, '-')
+		search.walk(rows, position-1, mismatches, bulges+1)
+		search.pop()
+	}
+}
+
+func (search *siteSearch) push(patternSymbol, textSymbol byte) {
+	search.patternBuffer = append(search.patternBuffer, patternSymbol)
+	search.textBuffer = append(search.textBuffer, textSymbol)
+}
+
+func (search *siteSearch) pop() {
+	search.patternBuffer = search.patternBuffer[:len(search.patternBuffer)-1]
+	search.textBuffer = search.textBuffer[:len(search.textBuffer)-1]
+}
+
+// record adds every site of rows to the search's results, keeping the
+// closest alignment of sites found more than once.
+func (search *siteSearch) record(rows fmindex.Range, mismatches, bulges int) {
+	// the buffers were built from the end of the pattern, so they're
+	// backwards, which is the right way around for the reverse strand once
+	// they're complemented.
+	patternAligned, textAligned := make([]byte, len(search.patternBuffer)), make([]byte, len(search.textBuffer))
+	for index := range search.patternBuffer {
+		last := len(search.patternBuffer) - 1 - index
+		if search.forward {
+			patternAligned[index], textAligned[index] = search.patternBuffer[last], search.textBuffer[last]
+		} else {
+			patternAligned[index], textAligned[index] = complement(search.patternBuffer[index]), complement(search.textBuffer[index])
+		}
+	}
+	sequence := strings.ReplaceAll(string(textAligned), "-", "")
+
+	for _, match := range search.index.LocateRange(rows) {
+		offTarget := OffTarget{
+			Name:         match.Name,
+			Position:     match.Position,
+			Forward:      search.forward,
+			Sequence:     sequence,
+			AlignedGuide: string(patternAligned),
+			AlignedSite:  string(textAligned),
+			Mismatches:   mismatches,
+			Bulges:       bulges,
+		}
+		key := [2]int{search.order[match.Name], match.Position}
+		if !search.forward {
+			// keep each strand's sites apart.
+			key[0] = -key[0] - 1
+		}
+		if existing, ok := search.found[key]; ok && existing.Bulges+existing.Mismatches <= bulges+mismatches {
+			continue
+		}
+		search.found[key] = offTarget
+	}
+}
+
+// complement complements a DNA base, leaving gaps alone.
+func complement(base byte) byte {
+	if base == '-' {
+		return base
+	}
+	return alphabet.ComplementDNA(base)
+}
+
+/******************************************************************************
+
+Off-target scoring begins here.
+
+******************************************************************************/
+
+// mitWeights is how much a mismatch at each position of a 20 base guide,
+// counting from the 5' end, lowers cutting, from Hsu et al., 2013.
+var mitWeights = [20]float64{0, 0, 0.014, 0, 0, 0.395, 0.317, 0, 0.389, 0.079, 0.445, 0.508, 0.613, 0.851, 0.732, 0.828, 0.615, 0.804, 0.685, 0.583}
+
+// MIT returns the MIT off-target score of a 20 base protospacer against a 20
+// base guide, from 0 (won't be cut) to 1 (a perfect match).
+func MIT(guide, protospacer string) (float64, error) {
+	if len(guide) != len(mitWeights) || len(protospacer) != len(mitWeights) {
+		return 0, fmt.Errorf("MIT scores need a %d base guide and protospacer, got %d and %d", len(mitWeights), len(guide), len(protospacer))
+	}
+	guide, protospacer = alphabet.RNAToDNA(strings.ToUpper(guide)), alphabet.RNAToDNA(strings.ToUpper(protospacer))
+	score := 1.0
+	var mismatches []int
+	for position := range mitWeights {
+		if guide[position] != protospacer[position] {
+			score *= 1 - mitWeights[position]
+			mismatches = append(mismatches, position)
+		}
+	}
+	if len(mismatches) >= 2 {
+		// mean distance between neighboring mismatches.
+		meanDistance := float64(mismatches[len(mismatches)-1]-mismatches[0]) / float64(len(mismatches)-1)
+		score /= (19-meanDistance)/19*4 + 1
+	}
+	if len(mismatches) > 0 {
+		score /= float64(len(mismatches) * len(mismatches))
+	}
+	return score, nil
+}
+
+// CFDModel holds the activity tables of the CFD score. Mismatches is keyed
+// by the guide RNA base, the mismatched DNA base of the target strand, and
+// the 1-based position from the 5' end of the guide, like "rU:dG,14". PAMs
+// is keyed by the last two bases of the PAM, like "AG".
+type CFDModel struct {
+	Mismatches map[string]float64 `json:"mismatches"`
+	PAMs       map[string]float64 `json:"pams"`
+}
+
+// ParseCFDModel reads a CFD model from JSON.
+func ParseCFDModel(r io.Reader) (CFDModel, error) {
+	var model CFDModel
+	if err := json.NewDecoder(r).Decode(&model); err != nil {
+		return CFDModel{}, err
+	}
+	return model, nil
+}
+
+// ReadCFDModel reads a CFD model from a JSON file.
+func ReadCFDModel(path string) (CFDModel, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return CFDModel{}, err
+	}
+	defer file.Close()
+	return ParseCFDModel(file)
+}
+
+// Score returns the CFD score of a protospacer and its PAM against a guide of
+// the same length, from 0 to 1.
+func (model CFDModel) Score(guide, protospacer, pam string) (float64, error) {
+	if len(guide) != len(protospacer) {
+		return 0, fmt.Errorf("guide and protospacer have different lengths (%d and %d)", len(guide), len(protospacer))
+	}
+	if len(pam) < 2 {
+		return 0, fmt.Errorf("PAM %q is too short", pam)
+	}
+	guide, protospacer = alphabet.DNAToRNA(strings.ToUpper(guide)), alphabet.RNAToDNA(strings.ToUpper(protospacer))
+	score := 1.0
+	for position := 0; position < len(guide); position++ {
+		if alphabet.RNAToDNA(guide[position:position+1]) == protospacer[position:position+1] {
+			continue
+		}
+		key := fmt.Sprintf("r%c:d%c,%d", guide[position], alphabet.ComplementDNA(protospacer[position]), position+1)
+		activity, ok := model.Mismatches[key]
+		if !ok {
+			return 0, fmt.Errorf("CFD model has no score for mismatch %s", key)
+		}
+		score *= activity
+	}
+	pamKey := alphabet.RNAToDNA(strings.ToUpper(pam[len(pam)-2:]))
+	activity, ok := model.PAMs[pamKey]
+	if !ok {
+		return 0, fmt.Errorf("CFD model has no score for PAM %s", pamKey)
+	}
+	return score * activity, nil
+}
@@ -0,0 +1,236 @@
+package crispr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/random"
+	"github.com/bebop/poly/search/fmindex"
+	"github.com/bebop/poly/transform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mutate swaps the base at each position for a different one.
+func mutate(sequence string, positions ...int) string {
+	mutated := []byte(sequence)
+	for _, position := range positions {
+		mutated[position] = "CGTA"[strings.IndexByte("ACGT", mutated[position])]
+	}
+	return string(mutated)
+}
+
+func TestMIT(t *testing.T) {
+	guide := "GAGTCCGAGCAGAAGAAGAA"
+	score, err := MIT(guide, guide)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, score)
+
+	// mismatches at the 5' end barely matter.
+	score, err = MIT(guide, mutate(guide, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, score)
+
+	score, err = MIT(guide, mutate(guide, 19))
+	require.NoError(t, err)
+	assert.InDelta(t, 1-0.583, score, 1e-9)
+
+	// two mismatches 14 bases apart.
+	score, err = MIT(guide, mutate(guide, 3, 17))
+	require.NoError(t, err)
+	assert.InDelta(t, (1-0.804)/((19-14.0)/19*4+1)/4, score, 1e-9)
+
+	// RNA guides and lowercase are fine.
+	score, err = MIT(strings.ToLower(strings.ReplaceAll(guide, "T", "U")), guide)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, score)
+
+	_, err = MIT(guide[:19], guide[:19])
+	assert.Error(t, err)
+}
+
+func TestCFDModel(t *testing.T) {
+	model, err := ParseCFDModel(strings.NewReader(`{"mismatches": {"rA:dG,1": 0.5, "rU:dT,20": 0.25}, "pams": {"GG": 1, "AG": 0.2}}`))
+	require.NoError(t, err)
+
+	guide := "ACGTACGTACGTACGTACGT"
+	score, err := model.Score(guide, guide, "TGG")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, score)
+
+	// guide A against protospacer C pairs the RNA with a G on the target strand.
+	score, err = model.Score(guide, "C"+guide[1:], "TAG")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.5*0.2, score, 1e-9)
+
+	score, err = model.Score(guide, "C"+guide[1:19]+"A", "CGG")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.5*0.25, score, 1e-9)
+
+	_, err = model.Score(guide, "G"+guide[1:], "TGG")
+	assert.Error(t, err)
+	_, err = model.Score(guide, guide, "TCC")
+	assert.Error(t, err)
+	_, err = model.Score(guide, guide[1:], "TGG")
+	assert.Error(t, err)
+	_, err = ParseCFDModel(strings.NewReader("{"))
+	assert.Error(t, err)
+	_, err = ReadCFDModel("data/does_not_exist.json")
+	assert.Error(t, err)
+}
+
+func TestScoreGuides(t *testing.T) {
+	guide := "GAGTCCGAGCAGAAGAAGAA"
+
+	chromosome, _ := random.DNASequence(14000, 1)
+	genome := []byte(chromosome)
+	plant := func(position int, site string) {
+		copy(genome[position:], site)
+	}
+	plant(1000, guide+"TGG")                                          // on-target.
+	plant(3000, mutate(guide, 3, 17)+"AGG")                           // two mismatches.
+	plant(5000, mutate(guide, 19)+"CAG")                              // one mismatch, NAG PAM.
+	plant(7000, transform.ReverseComplement(mutate(guide, 10)+"GGG")) // one mismatch, reverse strand.
+	plant(9000, guide[:10]+"T"+guide[10:]+"TGG")                      // DNA bulge.
+	plant(11000, guide[:8]+guide[9:]+"AGG")                           // RNA bulge.
+	plant(13000, mutate(guide, 0, 5, 9, 13)+"TGG")                    // too many mismatches.
+	plasmid, _ := random.DNASequence(200, 2)
+	sequences := []fasta.Fasta{
+		{Name: "chromosome", Sequence: string(genome)},
+		{Name: "plasmid", Sequence: strings.ToLower(plasmid + guide + "CCA")}, // no PAM.
+	}
+	index, err := fmindex.New(sequences)
+	require.NoError(t, err)
+
+	scores, err := ScoreGuides(index, []string{guide}, SpCas9, Options{MaxMismatches: 3, MaxBulges: 1})
+	require.NoError(t, err)
+	require.Len(t, scores, 1)
+	score := scores[0]
+
+	require.NotNil(t, score.OnTarget)
+	assert.Equal(t, OffTarget{
+		Name:         "chromosome",
+		Position:     1000,
+		Forward:      true,
+		Sequence:     guide + "TGG",
+		AlignedGuide: guide + "NGG",
+		AlignedSite:  guide + "TGG",
+	}, *score.OnTarget)
+
+	type site struct {
+		position   int
+		forward    bool
+		mismatches int
+		bulges     int
+	}
+	var sites []site
+	for _, offTarget := range score.OffTargets {
+		assert.Equal(t, "chromosome", offTarget.Name)
+		sites = append(sites, site{offTarget.Position, offTarget.Forward, offTarget.Mismatches, offTarget.Bulges})
+	}
+	assert.Equal(t, []site{
+		{3000, true, 2, 0},
+		{5000, true, 1, 0},
+		{7000, false, 1, 0},
+		{9000, true, 0, 1},
+		{11000, true, 0, 1},
+	}, sites)
+
+	reverse := score.OffTargets[2]
+	assert.Equal(t, mutate(guide, 10)+"GGG", reverse.Sequence)
+	assert.InDelta(t, 1-0.445, reverse.MIT, 1e-9)
+
+	dnaBulge := score.OffTargets[3]
+	assert.Equal(t, guide[:10]+"T"+guide[10:]+"TGG", dnaBulge.Sequence)
+	assert.Equal(t, len(dnaBulge.AlignedGuide), len(dnaBulge.AlignedSite))
+	assert.Equal(t, 1, strings.Count(dnaBulge.AlignedGuide, "-"))
+	assert.Equal(t, 0.0, dnaBulge.MIT)
+
+	rnaBulge := score.OffTargets[4]
+	assert.Equal(t, guide[:8]+guide[9:]+"AGG", rnaBulge.Sequence)
+	assert.Equal(t, 1, strings.Count(rnaBulge.AlignedSite, "-"))
+
+	mitTotal := 0.0
+	for _, offTarget := range score.OffTargets {
+		mitTotal += offTarget.MIT
+	}
+	assert.InDelta(t, 1/(1+mitTotal), score.MITSpecificity, 1e-9)
+	assert.Equal(t, 0.0, score.CFDSpecificity)
+
+	// without bulges or with fewer mismatches, sites drop out.
+	scores, err = ScoreGuides(index, []string{guide}, SpCas9, Options{MaxMismatches: 1})
+	require.NoError(t, err)
+	assert.Len(t, scores[0].OffTargets, 2)
+
+	// a guide nothing matches is perfectly specific.
+	scores, err = ScoreGuides(index, []string{"ACACACACACACACACACAC"}, SpCas9, Options{})
+	require.NoError(t, err)
+	assert.Nil(t, scores[0].OnTarget)
+	assert.Empty(t, scores[0].OffTargets)
+	assert.Equal(t, 1.0, scores[0].MITSpecificity)
+}
+
+func TestScoreGuidesCFD(t *testing.T) {
+	guide := "GAGTCCGAGCAGAAGAAGAA"
+	index, err := fmindex.New([]fasta.Fasta{
+		{Name: "genome", Sequence: "TTTT" + guide + "TAG" + "TTTT" + mutate(guide, 19) + "TGG" + "TTTT"},
+	})
+	require.NoError(t, err)
+	// guide A against protospacer C at position 20.
+	model := CFDModel{
+		Mismatches: map[string]float64{"rA:dG,20": 0.5},
+		PAMs:       map[string]float64{"GG": 1, "AG": 0.25},
+	}
+	scores, err := ScoreGuides(index, []string{guide}, SpCas9, Options{MaxMismatches: 1, CFD: &model})
+	require.NoError(t, err)
+	score := scores[0]
+
+	// the perfect match has a NAG PAM, so it's an off-target.
+	assert.Nil(t, score.OnTarget)
+	require.Len(t, score.OffTargets, 2)
+	assert.Equal(t, 0.25, score.OffTargets[0].CFD)
+	assert.Equal(t, 1.0, score.OffTargets[0].MIT)
+	assert.Equal(t, 0.5, score.OffTargets[1].CFD)
+	assert.InDelta(t, 1/(1+0.75), score.CFDSpecificity, 1e-9)
+
+	// the model has to cover every mismatch it's asked about.
+	model.Mismatches = map[string]float64{}
+	_, err = ScoreGuides(index, []string{guide}, SpCas9, Options{MaxMismatches: 1, CFD: &model})
+	assert.Error(t, err)
+}
+
+func TestScoreGuidesFivePrimePAM(t *testing.T) {
+	guide := "GAGTCCGAGCAGAAGAAGAACCA"
+	chromosome, _ := random.DNASequence(4000, 3)
+	genome := []byte(chromosome)
+	copy(genome[1000:], "TTTA"+guide)
+	copy(genome[3000:], transform.ReverseComplement("TTTC"+mutate(guide, 5)))
+	index, err := fmindex.New([]fasta.Fasta{{Name: "chromosome", Sequence: string(genome)}})
+	require.NoError(t, err)
+
+	scores, err := ScoreGuides(index, []string{guide}, AsCas12a, Options{MaxMismatches: 1})
+	require.NoError(t, err)
+	score := scores[0]
+	require.NotNil(t, score.OnTarget)
+	assert.Equal(t, 1000, score.OnTarget.Position)
+	assert.Equal(t, "TTTA"+guide, score.OnTarget.Sequence)
+	require.Len(t, score.OffTargets, 1)
+	offTarget := score.OffTargets[0]
+	assert.Equal(t, 3000, offTarget.Position)
+	assert.False(t, offTarget.Forward)
+	assert.Equal(t, "TTTC"+mutate(guide, 5), offTarget.Sequence)
+	assert.Equal(t, 1, offTarget.Mismatches)
+	// the MIT score is for SpCas9.
+	assert.Equal(t, 0.0, offTarget.MIT)
+	assert.Equal(t, 1.0, score.MITSpecificity)
+}
+
+func TestScoreGuidesErrors(t *testing.T) {
+	index, err := fmindex.New([]fasta.Fasta{{Name: "genome", Sequence: "ACGT"}})
+	require.NoError(t, err)
+	_, err = ScoreGuides(index, []string{"ACGT"}, SpCas9, Options{})
+	assert.Error(t, err)
+	_, err = ScoreGuides(index, []string{"ACGTACGTACGTACGTACGN"}, SpCas9, Options{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,210 @@
+package crispr
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/bebop/poly/alphabet"
+	"github.com/bebop/poly/search/motif"
+	"github.com/bebop/poly/transform"
+)
+
+/******************************************************************************
+
+Guide design begins here.
+
+Designing guides for a gene starts with every protospacer in it that sits next
+to a PAM, on either strand, which FindGuides lists along with where each one
+would cut. Most of them are no good: ones with very low or high GC content
+don't work as well, and ones with four Ts in a row end their own transcript
+early when they're expressed from a Pol III promoter like U6.
+
+The rest still differ a lot in how well they cut. Rule Set 1 from Doench et
+al., 2014 (https://doi.org/10.1038/nbt.3026) is a logistic regression that
+predicts it from the bases in and around the protospacer of an SpCas9 site.
+Later rule sets predict it better, but need trained models too big to bundle.
+
+Cutting well is only half of a good guide, so the guides worth keeping should
+then be run through ScoreGuides against the genome they're for, to find the
+ones that don't cut anywhere else.
+
+******************************************************************************/
+
+// Guide is a candidate guide for a target sequence.
+type Guide struct {
+	Spacer string // protospacer the guide targets, 5' to 3', which is also the guide's spacer as DNA.
+	PAM    string
+	// Start and End are where the site, protospacer and PAM, is on the target
+	// as written, as a half-open range.
+	Start, End int
+	Forward    bool // whether the site is on the forward strand of the target.
+	// Cut is where the nuclease cuts the protospacer's strand, as the
+	// position on the target as written of the base just after the cut.
+	Cut int
+	GC  float64 // fraction of the spacer that's G or C.
+	// OnTarget is the spacer's Rule Set 1 score from 0 to 1, or 0 if it
+	// can't be scored, like for nucleases other than SpCas9 or for sites too
+	// close to the end of the target.
+	OnTarget float64
+}
+
+// DesignOptions changes which guides FindGuides keeps. The zero value keeps
+// every guide without four Ts in a row.
+type DesignOptions struct {
+	// Start and End only keep guides that cut between them, as a half-open
+	// range of the target. An End of 0 is the end of the target.
+	Start, End int
+	// MinGC and MaxGC are the lowest and highest fraction of a guide's spacer
+	// that can be G or C. A MaxGC of 0 is 1.
+	MinGC, MaxGC float64
+	// AllowPolyT keeps guides with four Ts in a row.
+	AllowPolyT bool
+}
+
+// ruleSet1Weight is one feature of Rule Set 1: bases, one or two of them, at
+// a 0-based position of the 30 base context of a site.
+type ruleSet1Weight struct {
+	position int
+	bases    string
+	weight   float64
+}
+
+// ruleSet1Weights are the weights of Rule Set 1, from Doench et al., 2014.
+var ruleSet1Weights = []ruleSet1Weight{
+	{1, "G", -0.2753771}, {2, "A", -0.3238875}, {2, "C", 0.17212887}, {3, "C", -0.1006662},
+	{4, "C", -0.2018029}, {4, "G", 0.24595663}, {5, "A", 0.03644004}, {5, "C", 0.09837684},
+	{6, "C", -0.7411813}, {6, "G", -0.3932644}, {11, "A", -0.466099}, {14, "A", 0.08537695},
+	{14, "C", -0.013814}, {15, "A", 0.27262051}, {15, "C", -0.1190226}, {15, "T", -0.2859442},
+	{16, "A", 0.09745459}, {16, "G", -0.1755462}, {17, "C", -0.3457955}, {17, "G", -0.6780964},
+	{18, "A", 0.22508903}, {18, "C", -0.5077941}, {19, "G", -0.4173736}, {19, "T", -0.054307},
+	{20, "G", 0.37989937}, {20, "T", -0.0907126}, {21, "C", 0.05782332}, {21, "T", -0.5305673},
+	{22, "T", -0.8770074}, {23, "C", -0.8762358}, {23, "G", 0.27891626}, {23, "T", -0.4031022},
+	{24, "A", -0.0773007}, {24, "C", 0.28793562}, {24, "T", -0.2216372}, {27, "G", -0.6890167},
+	{27, "T", 0.11787758}, {28, "C", -0.1604453}, {29, "G", 0.38634258}, {1, "GT", -0.6257787},
+	{4, "GC", 0.30004332}, {5, "AA", -0.8348362}, {5, "TA", 0.76062777}, {6, "GG", -0.4908167},
+	{11, "GG", -1.5169074}, {11, "TA", 0.7092612}, {11, "TC", 0.49629861}, {11, "TT", -0.5868739},
+	{12, "GG", -0.3345637}, {13, "GA", 0.76384993}, {13, "GC", -0.5370252}, {16, "TG", -0.7981461},
+	{18, "GG", -0.6668087}, {18, "TC", 0.35318325}, {19, "CC", 0.74807209}, {19, "TG", -0.3672668},
+	{20, "AC", 0.56820913}, {20, "CG", 0.32907207}, {20, "GA", -0.8364568}, {20, "GG", -0.7822076},
+	{21, "TC", -1.029693}, {22, "CG", 0.85619782}, {22, "CT", -0.4632077}, {23, "AA", -0.5794924},
+	{23, "AG", 0.64907554}, {24, "AG", -0.0773007}, {24, "CG", 0.28793562}, {24, "TG", -0.2216372},
+	{26, "GT", 0.11787758}, {28, "GG", -0.69774},
+}
+
+const (
+	ruleSet1Intercept = 0.59763615
+	// ruleSet1GCLow and ruleSet1GCHigh weigh every spacer G or C below or
+	// above 10.
+	ruleSet1GCLow  = -0.2026259
+	ruleSet1GCHigh = -0.1665878
+	// ruleSet1ContextLength is 4 bases before the protospacer, its 20 bases,
+	// the 3 base PAM, and 3 bases after it.
+	ruleSet1ContextLength = 30
+)
+
+// RuleSet1 returns the Rule Set 1 on-target score of an SpCas9 site, from 0
+// to 1, from the 30 bases around it: 4 bases, the 20 base protospacer, the
+// PAM, and 3 more bases.
+func RuleSet1(context string) (float64, error) {
+	context = alphabet.RNAToDNA(strings.ToUpper(context))
+	if len(context) != ruleSet1ContextLength {
+		return 0, fmt.Errorf("Rule Set 1 needs %d bases around a site, got %d", ruleSet1ContextLength, len(context))
+	}
+	if strings.Trim(context, "ACGT") != "" {
+		return 0, fmt.Errorf("site context %q has bases other than A, C, G, and T", context)
+	}
+	score := ruleSet1Intercept
+	gc := gcCount(context[4:24])
+	if gc <= 10 {
+		score += float64(10-gc) * ruleSet1GCLow
+	} else {
+		score += float64(gc-10) * ruleSet1GCHigh
+	}
+	for _, feature := range ruleSet1Weights {
+		if strings.HasPrefix(context[feature.position:], feature.bases) {
+			score += feature.weight
+		}
+	}
+	return 1 / (1 + math.Exp(-score)), nil
+}
+
+// gcCount counts the Gs and Cs of a sequence.
+func gcCount(sequence string) int {
+	return strings.Count(sequence, "G") + strings.Count(sequence, "C")
+}
+
+// FindGuides returns every guide a nuclease could use to cut a target, on
+// both strands, sorted by where they cut. Sites with ambiguous bases in their
+// protospacer are skipped.
+func FindGuides(target string, nuclease Nuclease, options DesignOptions) ([]Guide, error) {
+	target = alphabet.RNAToDNA(strings.ToUpper(target))
+	length := len(target)
+	if options.End == 0 {
+		options.End = length
+	}
+	if options.Start < 0 || options.Start > options.End || options.End > length {
+		return nil, fmt.Errorf("region %d to %d isn't inside the %d base target", options.Start, options.End, length)
+	}
+	if options.MaxGC == 0 {
+		options.MaxGC = 1
+	}
+	if nuclease.ProtospacerLength < 1 || nuclease.PAM == "" {
+		return nil, fmt.Errorf("nuclease %s needs a PAM and a protospacer length", nuclease.Name)
+	}
+	pamPattern, err := motif.CompileIUPAC(nuclease.PAM)
+	if err != nil {
+		return nil, fmt.Errorf("nuclease %s: %w", nuclease.Name, err)
+	}
+	pamLength := len(nuclease.PAM)
+	siteLength := nuclease.ProtospacerLength + pamLength
+	scorable := !nuclease.FivePrimePAM && nuclease.ProtospacerLength == 20 && pamLength == 3
+
+	var guides []Guide
+	for _, forward := range []bool{true, false} {
+		strand := target
+		if !forward {
+			strand = transform.ReverseComplement(target)
+		}
+		for position := 0; position+siteLength <= length; position++ {
+			site := strand[position : position+siteLength]
+			protospacerStart := position
+			pam, spacer := site[nuclease.ProtospacerLength:], site[:nuclease.ProtospacerLength]
+			if nuclease.FivePrimePAM {
+				protospacerStart += pamLength
+				pam, spacer = site[:pamLength], site[pamLength:]
+			}
+			if !pamPattern.MatchString(pam) || strings.Trim(spacer, "ACGT") != "" {
+				continue
+			}
+			guide := Guide{
+				Spacer:  spacer,
+				PAM:     pam,
+				Start:   position,
+				End:     position + siteLength,
+				Forward: forward,
+				Cut:     protospacerStart + nuclease.CutOffset,
+				GC:      float64(gcCount(spacer)) / float64(len(spacer)),
+			}
+			if !forward {
+				// positions on the reverse strand are mirrored onto the forward.
+				guide.Start, guide.End, guide.Cut = length-guide.End, length-guide.Start, length-guide.Cut
+			}
+			if guide.Cut < options.Start || guide.Cut >= options.End || guide.GC < options.MinGC || guide.GC > options.MaxGC {
+				continue
+			}
+			if !options.AllowPolyT && strings.Contains(spacer, "TTTT") {
+				continue
+			}
+			if scorable && position >= 4 && position+ruleSet1ContextLength-4 <= length {
+				guide.OnTarget, _ = RuleSet1(strand[position-4 : position+ruleSet1ContextLength-4])
+			}
+			guides = append(guides, guide)
+		}
+	}
+	sort.SliceStable(guides, func(i, j int) bool {
+		return guides[i].Cut < guides[j].Cut
+	})
+	return guides, nil
+}
@@ -0,0 +1,99 @@
+package crispr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/transform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleSet1(t *testing.T) {
+	score, err := RuleSet1("TATAGCTGCGATCTGAGGTAGGGAGGGACC")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.713, score, 1e-3)
+	lower, err := RuleSet1("tccgcacctgtcacggtcggggcttggcgc")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.019, lower, 1e-3)
+
+	_, err = RuleSet1("TATAGCTGCGATCTGAGGTAGGGAGGGAC")
+	assert.Error(t, err)
+	_, err = RuleSet1("TATAGCTGCGATCTGAGGTAGGGAGGGANN")
+	assert.Error(t, err)
+}
+
+func TestFindGuides(t *testing.T) {
+	// one NGG site on each strand, and one with a poly-T spacer.
+	// nothing else has a GG or CC, so there are no other sites.
+	forwardSite := "GAGTACGAGCAGAAGAAGAA" + "TGG"
+	reverseSite := "CAGTATCAGTACATGTACAT" + "AGG"
+	polyT := "GATTTTCAGCAGAAGAAGAA" + "AGG"
+	target := "ACAT" + forwardSite + "ACATACATAT" + transform.ReverseComplement(reverseSite) + "ACATACATAC" + polyT + "A"
+
+	guides, err := FindGuides(target, SpCas9, DesignOptions{})
+	require.NoError(t, err)
+	require.Len(t, guides, 2)
+
+	forward := guides[0]
+	assert.Equal(t, "GAGTACGAGCAGAAGAAGAA", forward.Spacer)
+	assert.Equal(t, "TGG", forward.PAM)
+	assert.True(t, forward.Forward)
+	assert.Equal(t, 4, forward.Start)
+	assert.Equal(t, 27, forward.End)
+	assert.Equal(t, 4+17, forward.Cut)
+	assert.InDelta(t, 0.45, forward.GC, 1e-9)
+	context := target[forward.Start-4 : forward.End+3]
+	onTarget, err := RuleSet1(context)
+	require.NoError(t, err)
+	assert.Equal(t, onTarget, forward.OnTarget)
+	assert.Greater(t, forward.OnTarget, 0.0)
+
+	reverse := guides[1]
+	assert.Equal(t, "CAGTATCAGTACATGTACAT", reverse.Spacer)
+	assert.False(t, reverse.Forward)
+	assert.Equal(t, 37, reverse.Start)
+	assert.Equal(t, 60, reverse.End)
+	assert.Equal(t, transform.ReverseComplement(reverseSite), target[reverse.Start:reverse.End])
+	// the reverse strand is cut 3 bases from its PAM too.
+	assert.Equal(t, 40+3, reverse.Cut)
+
+	guides, err = FindGuides(target, SpCas9, DesignOptions{AllowPolyT: true})
+	require.NoError(t, err)
+	assert.Len(t, guides, 3)
+	assert.Equal(t, 0.0, guides[2].OnTarget) // too close to the end of the target.
+
+	guides, err = FindGuides(target, SpCas9, DesignOptions{Start: 30})
+	require.NoError(t, err)
+	assert.Len(t, guides, 1)
+	guides, err = FindGuides(target, SpCas9, DesignOptions{MaxGC: 0.4})
+	require.NoError(t, err)
+	assert.Len(t, guides, 1)
+
+	_, err = FindGuides(target, SpCas9, DesignOptions{Start: 10, End: 5})
+	assert.Error(t, err)
+	_, err = FindGuides(target, SpCas9, DesignOptions{End: len(target) + 1})
+	assert.Error(t, err)
+	_, err = FindGuides(target, Nuclease{Name: "empty"}, DesignOptions{})
+	assert.Error(t, err)
+}
+
+func TestFindGuidesFivePrimePAM(t *testing.T) {
+	spacer := "GAGTCCGAGCAGAAGAAGAACCA"
+	target := "ACAG" + "TTTA" + spacer + "ACAG"
+	guides, err := FindGuides(target, AsCas12a, DesignOptions{})
+	require.NoError(t, err)
+	require.Len(t, guides, 1)
+	assert.Equal(t, spacer, guides[0].Spacer)
+	assert.Equal(t, "TTTA", guides[0].PAM)
+	assert.Equal(t, 4, guides[0].Start)
+	assert.Equal(t, 8+18, guides[0].Cut)
+	assert.Equal(t, 0.0, guides[0].OnTarget)
+
+	guides, err = FindGuides(transform.ReverseComplement(target), AsCas12a, DesignOptions{})
+	require.NoError(t, err)
+	require.Len(t, guides, 1)
+	assert.False(t, guides[0].Forward)
+	assert.Equal(t, len(target)-8-18, guides[0].Cut)
+	assert.Equal(t, strings.ToUpper(spacer), guides[0].Spacer)
+}
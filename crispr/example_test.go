@@ -0,0 +1,58 @@
+package crispr_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/crispr"
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/search/fmindex"
+)
+
+func ExampleScoreGuides() {
+	index, _ := fmindex.New([]fasta.Fasta{
+		{Name: "chromosome", Sequence: "TTGAGTCCGAGCAGAAGAAGAAGGGCTTTAGAGTCCGAGCAGAAGAAGTACAGTT"},
+	})
+	scores, _ := crispr.ScoreGuides(index, []string{"GAGTCCGAGCAGAAGAAGAA"}, crispr.SpCas9, crispr.Options{MaxMismatches: 2})
+	score := scores[0]
+	fmt.Println(score.OnTarget.Position, score.OnTarget.Sequence)
+	for _, offTarget := range score.OffTargets {
+		fmt.Println(offTarget.Position, offTarget.Sequence, offTarget.Mismatches)
+	}
+	fmt.Printf("%.2f\n", score.MITSpecificity)
+	// Output:
+	// 2 GAGTCCGAGCAGAAGAAGAAGGG
+	// 30 GAGTCCGAGCAGAAGAAGTACAG 1
+	// 0.76
+}
+
+func ExampleMIT() {
+	score, _ := crispr.MIT("GAGTCCGAGCAGAAGAAGAA", "GAGTCCGAGCAGAAGAAGAT")
+	fmt.Printf("%.3f\n", score)
+	// Output: 0.417
+}
+
+func ExampleBuildArray() {
+	array, _ := crispr.BuildArray([]string{"GAGTCCGAGCAGAAGAAGAA", "GACCCCCTCCACCCCGCCTC"}, crispr.ArrayOptions{Processing: crispr.TRNA})
+	for _, feature := range array.Features {
+		fmt.Println(feature.Location.Start, feature.Location.End, feature.Attributes["label"])
+	}
+	// Output:
+	// 0 71 tRNA-Gly
+	// 71 91 guide 1 spacer
+	// 91 167 sgRNA scaffold
+	// 167 238 tRNA-Gly
+	// 238 258 guide 2 spacer
+	// 258 334 sgRNA scaffold
+	// 334 340 terminator
+}
+
+func ExampleFindGuides() {
+	target := "ACATGAGTACGAGCAGAAGAAGAATGGACATACATATCCTATGTACATGTACTGATACTGACATACATAC"
+	guides, _ := crispr.FindGuides(target, crispr.SpCas9, crispr.DesignOptions{MinGC: 0.3})
+	for _, guide := range guides {
+		fmt.Printf("%s %s %t %d %.2f\n", guide.Spacer, guide.PAM, guide.Forward, guide.Cut, guide.OnTarget)
+	}
+	// Output:
+	// GAGTACGAGCAGAAGAAGAA TGG true 21 0.28
+	// CAGTATCAGTACATGTACAT AGG false 43 0.28
+}
@@ -0,0 +1,240 @@
+/*
+Package evolution contains types for keeping track of directed evolution campaigns.
+
+Directed evolution is breeding for molecules. You start with a parent
+sequence, make a library of mutants of it (by error prone PCR, site
+saturation, DNA shuffling, or whatever you like), select or screen the
+library under some condition, and take the best hits as the parents of the
+next round. A few rounds later, and with some luck, you have an enzyme that
+works at 70°C or a binder that's a hundred times tighter.
+
+After a few rounds it gets surprisingly hard to remember which hit came from
+which parent under which condition, and that history usually ends up in a
+spreadsheet nobody can find. A Campaign keeps it next to the sequences
+instead: every Variant is identified by its seqhash and points at the seqhash
+of its parent, so the lineage of any hit can be walked back to the starting
+sequence, and the whole campaign can be saved as JSON.
+
+For more on directed evolution:
+https://doi.org/10.1038/nrm.2017.12
+*/
+package evolution
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bebop/poly/seqhash"
+	"github.com/bebop/poly/transform/hgvs"
+)
+
+// Campaign is a directed evolution campaign: a starting sequence and the
+// rounds of mutagenesis and selection applied to it.
+type Campaign struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Goal        string  `json:"goal"` // what the campaign is selecting for, like "thermostability".
+	Founder     Variant `json:"founder"`
+	Rounds      []Round `json:"rounds"`
+}
+
+// Variant is a single sequence of a campaign.
+type Variant struct {
+	Name           string               `json:"name"`
+	Seqhash        string               `json:"seqhash"`
+	Sequence       string               `json:"sequence"`
+	SequenceType   seqhash.SequenceType `json:"sequence_type"`
+	Circular       bool                 `json:"circular"`
+	DoubleStranded bool                 `json:"double_stranded"`
+	Parent         string               `json:"parent"`    // seqhash of the variant this one was made from, empty for the founder.
+	Mutations      []string             `json:"mutations"` // HGVS descriptions of the changes from Parent.
+}
+
+// Round is one round of mutagenesis and selection.
+type Round struct {
+	Number    int       `json:"number"`
+	Date      time.Time `json:"date"`
+	Parents   []string  `json:"parents"` // seqhashes of the variants the library was made from.
+	Library   Library   `json:"library"`
+	Selection Selection `json:"selection"`
+	Hits      []Hit     `json:"hits"`
+	Notes     string    `json:"notes"`
+}
+
+// Library describes how a round's library of variants was made.
+type Library struct {
+	Method       string  `json:"method"`        // like "error-prone PCR" or "site saturation".
+	Size         int     `json:"size"`          // number of transformants or variants screened.
+	MutationRate float64 `json:"mutation_rate"` // mean mutations per variant, if known.
+}
+
+// Selection describes the condition a round's library was selected under.
+type Selection struct {
+	Method    string            `json:"method"`    // like "FACS" or "plate screen".
+	Condition string            `json:"condition"` // like "15 minutes at 65°C".
+	Settings  map[string]string `json:"settings"`  // anything else worth remembering, like antibiotic concentrations.
+}
+
+// Hit is a variant picked out of a round's library, along with how it did.
+type Hit struct {
+	Variant      Variant            `json:"variant"`
+	Score        float64            `json:"score"`        // the round's main readout, like activity relative to parent.
+	Measurements map[string]float64 `json:"measurements"` // any other readouts.
+}
+
+// NewVariant makes a variant of sequence, hashing it with seqhash. parent is
+// the variant it was made from, or nil for a founder. If parent is given its
+// sequence type and topology are used, and the mutations from it are
+// described in HGVS nomenclature.
+func NewVariant(name, sequence string, sequenceType seqhash.SequenceType, circular, doubleStranded bool, parent *Variant) (Variant, error) {
+	if parent != nil {
+		sequenceType, circular, doubleStranded = parent.SequenceType, parent.Circular, parent.DoubleStranded
+	}
+	hash, err := seqhash.Hash(sequence, sequenceType, circular, doubleStranded)
+	if err != nil {
+		return Variant{}, err
+	}
+	variant := Variant{
+		Name:           name,
+		Seqhash:        hash,
+		Sequence:       sequence,
+		SequenceType:   sequenceType,
+		Circular:       circular,
+		DoubleStranded: doubleStranded,
+	}
+	if parent != nil {
+		variant.Parent = parent.Seqhash
+		variant.Mutations = describe(parent.Sequence, sequence, sequenceType, circular)
+	}
+	return variant, nil
+}
+
+// describe writes the differences between two sequences as HGVS strings.
+func describe(parent, child string, sequenceType seqhash.SequenceType, circular bool) []string {
+	system := hgvs.Genomic
+	switch {
+	case sequenceType == seqhash.PROTEIN:
+		system = hgvs.Protein
+	case circular:
+		system = hgvs.Circular
+	}
+	var mutations []string
+	for _, variant := range hgvs.Describe(parent, child, system) {
+		if variant.Kind != hgvs.Identity {
+			mutations = append(mutations, variant.String())
+		}
+	}
+	return mutations
+}
+
+// Variants returns every variant of the campaign keyed by seqhash.
+func (campaign Campaign) Variants() map[string]Variant {
+	variants := map[string]Variant{campaign.Founder.Seqhash: campaign.Founder}
+	for _, round := range campaign.Rounds {
+		for _, hit := range round.Hits {
+			if _, ok := variants[hit.Variant.Seqhash]; !ok {
+				variants[hit.Variant.Seqhash] = hit.Variant
+			}
+		}
+	}
+	return variants
+}
+
+// AddRound appends a round to the campaign. Rounds are numbered in order
+// starting at 1, and every parent of the round and of its hits must be the
+// founder or a hit of an earlier round (or, for hits, of the same round).
+func (campaign *Campaign) AddRound(round Round) error {
+	if campaign.Founder.Seqhash == "" {
+		return fmt.Errorf("campaign %q has no founder", campaign.Name)
+	}
+	if want := len(campaign.Rounds) + 1; round.Number != want {
+		return fmt.Errorf("expected round %d, got round %d", want, round.Number)
+	}
+	variants := campaign.Variants()
+	for _, parent := range round.Parents {
+		if _, ok := variants[parent]; !ok {
+			return fmt.Errorf("round %d parent %s isn't part of the campaign", round.Number, parent)
+		}
+	}
+	for _, hit := range round.Hits {
+		variants[hit.Variant.Seqhash] = hit.Variant
+	}
+	for _, hit := range round.Hits {
+		if hit.Variant.Seqhash == "" {
+			return fmt.Errorf("round %d hit %q has no seqhash", round.Number, hit.Variant.Name)
+		}
+		if _, ok := variants[hit.Variant.Parent]; !ok {
+			return fmt.Errorf("round %d hit %q has parent %q, which isn't part of the campaign", round.Number, hit.Variant.Name, hit.Variant.Parent)
+		}
+	}
+	campaign.Rounds = append(campaign.Rounds, round)
+	return nil
+}
+
+// Lineage returns the variant with the given seqhash and all of its
+// ancestors, ending with the founder.
+func (campaign Campaign) Lineage(hash string) ([]Variant, error) {
+	variants := campaign.Variants()
+	var lineage []Variant
+	seen := make(map[string]bool)
+	for hash != "" {
+		variant, ok := variants[hash]
+		if !ok {
+			return nil, fmt.Errorf("variant %s isn't part of the campaign", hash)
+		}
+		if seen[hash] {
+			return nil, fmt.Errorf("variant %s is its own ancestor", hash)
+		}
+		seen[hash] = true
+		lineage = append(lineage, variant)
+		hash = variant.Parent
+	}
+	return lineage, nil
+}
+
+// Best returns the highest scoring hit of the campaign's latest round, and
+// false if there are no hits.
+func (campaign Campaign) Best() (Hit, bool) {
+	if len(campaign.Rounds) == 0 {
+		return Hit{}, false
+	}
+	hits := campaign.Rounds[len(campaign.Rounds)-1].Hits
+	if len(hits) == 0 {
+		return Hit{}, false
+	}
+	best := hits[0]
+	for _, hit := range hits[1:] {
+		if hit.Score > best.Score {
+			best = hit
+		}
+	}
+	return best, true
+}
+
+/******************************************************************************
+
+JSON import and export begin here.
+
+******************************************************************************/
+
+// Read reads a campaign from a JSON file.
+func Read(path string) (Campaign, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return Campaign{}, err
+	}
+	var campaign Campaign
+	err = json.Unmarshal(file, &campaign)
+	return campaign, err
+}
+
+// Write writes a campaign to a JSON file.
+func Write(campaign Campaign, path string) error {
+	file, err := json.MarshalIndent(campaign, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, file, 0644)
+}
@@ -0,0 +1,178 @@
+package evolution
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bebop/poly/seqhash"
+)
+
+// the first 70 residues of GFP, and a couple of its famous mutations.
+const gfp = "MSKGEELFTGVVPILVELDGDVNGHKFSVSGEGEGDATYGKLTLKFICTTGKLPVPWPTLVTTFSYGVQC"
+
+func mutate(sequence string, position int, residue byte) string {
+	mutated := []byte(sequence)
+	mutated[position-1] = residue
+	return string(mutated)
+}
+
+func testCampaign(t *testing.T) Campaign {
+	founder, err := NewVariant("wild type", gfp, seqhash.PROTEIN, false, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	campaign := Campaign{Name: "brighter GFP", Goal: "fluorescence", Founder: founder}
+
+	s65t, err := NewVariant("S65T", mutate(gfp, 65, 'T'), "", false, false, &founder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f64l, _ := NewVariant("F64L", mutate(gfp, 64, 'L'), "", false, false, &founder)
+	err = campaign.AddRound(Round{
+		Number:    1,
+		Parents:   []string{founder.Seqhash},
+		Library:   Library{Method: "error-prone PCR", Size: 10000, MutationRate: 1.5},
+		Selection: Selection{Method: "FACS", Condition: "top 0.1% by 488nm excitation"},
+		Hits:      []Hit{{Variant: s65t, Score: 6}, {Variant: f64l, Score: 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	egfp, _ := NewVariant("EGFP", mutate(s65t.Sequence, 64, 'L'), "", false, false, &s65t)
+	err = campaign.AddRound(Round{
+		Number:    2,
+		Parents:   []string{s65t.Seqhash, f64l.Seqhash},
+		Library:   Library{Method: "DNA shuffling", Size: 5000},
+		Selection: Selection{Method: "plate screen", Condition: "37°C", Settings: map[string]string{"host": "E. coli"}},
+		Hits:      []Hit{{Variant: egfp, Score: 35, Measurements: map[string]float64{"folding at 37°C": 0.9}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return campaign
+}
+
+func TestNewVariant(t *testing.T) {
+	founder, err := NewVariant("wild type", gfp, seqhash.PROTEIN, false, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if founder.Parent != "" || founder.Mutations != nil || founder.Seqhash == "" {
+		t.Errorf("unexpected founder %+v", founder)
+	}
+	mutant, err := NewVariant("S65T", mutate(gfp, 65, 'T'), seqhash.DNA, true, true, &founder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mutant.SequenceType != seqhash.PROTEIN || mutant.Circular {
+		t.Errorf("expected the mutant to take its parent's sequence type, got %+v", mutant)
+	}
+	if mutant.Parent != founder.Seqhash || !reflect.DeepEqual(mutant.Mutations, []string{"p.Ser65Thr"}) {
+		t.Errorf("unexpected mutant lineage %v %v", mutant.Parent, mutant.Mutations)
+	}
+
+	plasmid, _ := NewVariant("plasmid", "ATGCATGCATGC", seqhash.DNA, true, true, nil)
+	mutantPlasmid, _ := NewVariant("mutant", "ATGCATGAATGC", "", false, false, &plasmid)
+	if !reflect.DeepEqual(mutantPlasmid.Mutations, []string{"o.8C>A"}) {
+		t.Errorf("expected circular mutations, got %v", mutantPlasmid.Mutations)
+	}
+
+	if _, err := NewVariant("bad", "ATGJ", seqhash.DNA, false, true, nil); err == nil {
+		t.Errorf("expected an error hashing an invalid sequence")
+	}
+}
+
+func TestLineage(t *testing.T) {
+	campaign := testCampaign(t)
+	best, ok := campaign.Best()
+	if !ok || best.Variant.Name != "EGFP" {
+		t.Fatalf("expected EGFP to be the best hit, got %+v", best)
+	}
+	lineage, err := campaign.Lineage(best.Variant.Seqhash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, variant := range lineage {
+		names = append(names, variant.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"EGFP", "S65T", "wild type"}) {
+		t.Errorf("unexpected lineage %v", names)
+	}
+	if _, err := campaign.Lineage("not a seqhash"); err == nil {
+		t.Errorf("expected an error for an unknown variant")
+	}
+
+	// a loop in the lineage shouldn't hang.
+	loop := Campaign{Founder: Variant{Name: "a", Seqhash: "a", Parent: "b"}, Rounds: []Round{{Hits: []Hit{{Variant: Variant{Name: "b", Seqhash: "b", Parent: "a"}}}}}}
+	if _, err := loop.Lineage("a"); err == nil {
+		t.Errorf("expected an error for a lineage loop")
+	}
+}
+
+func TestAddRoundErrors(t *testing.T) {
+	if err := (&Campaign{}).AddRound(Round{Number: 1}); err == nil {
+		t.Errorf("expected an error adding a round to a campaign without a founder")
+	}
+	campaign := testCampaign(t)
+	stranger, _ := NewVariant("stranger", "MKLV", seqhash.PROTEIN, false, false, nil)
+	child, _ := NewVariant("child", "MKLA", "", false, false, &stranger)
+	tests := []struct {
+		name  string
+		round Round
+	}{
+		{"out of order", Round{Number: 4}},
+		{"unknown parent", Round{Number: 3, Parents: []string{stranger.Seqhash}}},
+		{"orphan hit", Round{Number: 3, Hits: []Hit{{Variant: child}}}},
+		{"unhashed hit", Round{Number: 3, Hits: []Hit{{Variant: Variant{Name: "nameless"}}}}},
+	}
+	for _, test := range tests {
+		if err := campaign.AddRound(test.round); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+	if len(campaign.Rounds) != 2 {
+		t.Errorf("failed rounds shouldn't be added, got %d rounds", len(campaign.Rounds))
+	}
+
+	// hits can descend from other hits of the same round.
+	grandchild, _ := NewVariant("grandchild", "MKLT", "", false, false, &child)
+	if err := campaign.AddRound(Round{Number: 3, Hits: []Hit{{Variant: child}, {Variant: grandchild}}}); err == nil {
+		t.Errorf("expected an error for a hit descending from outside the campaign")
+	}
+	best, _ := campaign.Best()
+	s65t, _ := NewVariant("S65T+A", best.Variant.Sequence+"A", "", false, false, &best.Variant)
+	s65tA, _ := NewVariant("S65T+AA", s65t.Sequence+"A", "", false, false, &s65t)
+	if err := campaign.AddRound(Round{Number: 3, Hits: []Hit{{Variant: s65tA}, {Variant: s65t}}}); err != nil {
+		t.Errorf("expected hits descending from same round hits to be allowed, got %s", err)
+	}
+}
+
+func TestBestEmpty(t *testing.T) {
+	if _, ok := (Campaign{}).Best(); ok {
+		t.Errorf("expected no best hit without rounds")
+	}
+	if _, ok := (Campaign{Rounds: []Round{{Number: 1}}}).Best(); ok {
+		t.Errorf("expected no best hit without hits")
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	campaign := testCampaign(t)
+	path := filepath.Join(t.TempDir(), "campaign.json")
+	if err := Write(campaign, path); err != nil {
+		t.Fatal(err)
+	}
+	read, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, campaign) {
+		t.Errorf("campaign changed writing to and reading from JSON:\n%+v\n%+v", campaign, read)
+	}
+	if _, err := Read(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("expected an error reading a missing file")
+	}
+}
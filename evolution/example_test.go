@@ -0,0 +1,42 @@
+package evolution_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/evolution"
+	"github.com/bebop/poly/seqhash"
+)
+
+func ExampleCampaign_Lineage() {
+	founder, _ := evolution.NewVariant("wild type", "MSKGEELFTG", seqhash.PROTEIN, false, false, nil)
+	campaign := evolution.Campaign{Name: "example", Goal: "stability", Founder: founder}
+
+	first, _ := evolution.NewVariant("round 1 hit", "MSKGEELFTA", "", false, false, &founder)
+	_ = campaign.AddRound(evolution.Round{
+		Number:    1,
+		Parents:   []string{founder.Seqhash},
+		Library:   evolution.Library{Method: "error-prone PCR", Size: 2000},
+		Selection: evolution.Selection{Method: "plate screen", Condition: "10 minutes at 60°C"},
+		Hits:      []evolution.Hit{{Variant: first, Score: 1.8}},
+	})
+
+	second, _ := evolution.NewVariant("round 2 hit", "MSKPEELFTA", "", false, false, &first)
+	_ = campaign.AddRound(evolution.Round{
+		Number:    2,
+		Parents:   []string{first.Seqhash},
+		Library:   evolution.Library{Method: "site saturation", Size: 500},
+		Selection: evolution.Selection{Method: "plate screen", Condition: "10 minutes at 65°C"},
+		Hits:      []evolution.Hit{{Variant: second, Score: 3.1}},
+	})
+
+	best, _ := campaign.Best()
+	lineage, _ := campaign.Lineage(best.Variant.Seqhash)
+	for _, variant := range lineage {
+		fmt.Println(variant.Name, variant.Mutations)
+	}
+
+	// Output:
+	// round 2 hit [p.Gly4Pro]
+	// round 1 hit [p.Gly10Ala]
+	// wild type []
+}
@@ -0,0 +1,263 @@
+package fold
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/bebop/poly/fold/structure"
+)
+
+/******************************************************************************
+
+Constrained folding begins here.
+
+Sometimes you know something about a structure before you fold it. Maybe a
+ribosome binding site has to stay single stranded, a pair of bases is
+crosslinked, or you've got SHAPE reactivities saying which bases are
+flexible. Constraints feed that knowledge into folding.
+
+Hard constraints decide which pairs are allowed at all: bases can be forced
+to stay unpaired, specific pairs can be prohibited, and specific pairs can be
+forced to form (which prohibits every pair that would conflict with them).
+
+Soft constraints nudge the energy instead: every base gets a pseudo-energy,
+in kcal/mol, that's added to the structure's energy when the base is
+unpaired. Negative values favor a base being unpaired and positive values
+favor it pairing. This is how SHAPE data is usually folded in, see:
+Deigan, Li, Mathews, and Weeks, 2009
+https://doi.org/10.1073/pnas.0806929106
+
+Since a base is either paired or unpaired, adding u to every unpaired base is
+the same as adding u to every base and taking it back from every paired base.
+Every pair is formed in exactly one pairedMinimumFreeEnergyV(start, end), so
+that's where the pseudo-energies are taken back, and the sum over every base
+is added on at the end.
+
+******************************************************************************/
+
+// forcedPairBonus is the pseudo-energy added to forced pairs so that they
+// always form when they can. It's taken back out of the final energy.
+const forcedPairBonus = -1e5
+
+// Constraints restrict and guide folding. All positions are 0-based.
+type Constraints struct {
+	// Unpaired bases can't pair with anything.
+	Unpaired []int
+	// Prohibited pairs can't form.
+	Prohibited [][2]int
+	// Paired pairs must form. Folding fails if they can't.
+	Paired [][2]int
+	// UnpairedEnergies, if not nil, holds one pseudo-energy in kcal/mol per
+	// base, added to the energy of the structure when that base is unpaired.
+	UnpairedEnergies []float64
+}
+
+// ParseConstraints reads hard constraints from a ViennaRNA style constraint
+// string the length of the sequence: "x" marks bases that must stay unpaired,
+// matching brackets mark pairs that must form, and "." leaves a base
+// unconstrained.
+//
+//	"xxxx.......((....))......"
+func ParseConstraints(constraint string) (Constraints, error) {
+	var constraints Constraints
+	for index := 0; index < len(constraint); index++ {
+		switch constraint[index] {
+		case 'x':
+			constraints.Unpaired = append(constraints.Unpaired, index)
+		case '.', '(', ')', '[', ']', '{', '}', '<', '>':
+		default:
+			return Constraints{}, fmt.Errorf("unexpected %q at position %d of constraint", constraint[index], index+1)
+		}
+	}
+	// 'x' is also valid dot-bracket, so only the brackets become pairs.
+	pairs, err := structure.PairTable(constraint)
+	if err != nil {
+		return Constraints{}, err
+	}
+	for index, partner := range pairs {
+		if partner > index {
+			constraints.Paired = append(constraints.Paired, [2]int{index, partner})
+		}
+	}
+	return constraints, nil
+}
+
+// constraintTable is Constraints compiled for fast lookup during folding.
+type constraintTable struct {
+	allowed [][]bool    // allowed[start][end] for start < end.
+	bonus   [][]float64 // pseudo-energy added when start and end pair.
+	offset  float64     // pseudo-energy added to every structure.
+	paired  [][2]int
+}
+
+// newConstraintTable checks constraints against a sequence of the given
+// length and compiles them.
+func newConstraintTable(length int, constraints Constraints) (*constraintTable, error) {
+	table := &constraintTable{
+		allowed: make([][]bool, length),
+		bonus:   make([][]float64, length),
+		paired:  constraints.Paired,
+	}
+	for start := range table.allowed {
+		table.allowed[start] = make([]bool, length)
+		table.bonus[start] = make([]float64, length)
+		for end := start + 1; end < length; end++ {
+			table.allowed[start][end] = true
+		}
+	}
+	inRange := func(position int) bool { return position >= 0 && position < length }
+
+	for _, position := range constraints.Unpaired {
+		if !inRange(position) {
+			return nil, fmt.Errorf("unpaired constraint %d is outside of the sequence", position)
+		}
+		for other := 0; other < length; other++ {
+			table.prohibit(position, other)
+		}
+	}
+	for _, pair := range constraints.Prohibited {
+		if !inRange(pair[0]) || !inRange(pair[1]) {
+			return nil, fmt.Errorf("prohibited pair %d-%d is outside of the sequence", pair[0], pair[1])
+		}
+		table.prohibit(pair[0], pair[1])
+	}
+
+	forced := make(map[int]int)
+	for _, pair := range constraints.Paired {
+		open, closing := min(pair[0], pair[1]), max(pair[0], pair[1])
+		if !inRange(open) || !inRange(closing) || open == closing {
+			return nil, fmt.Errorf("forced pair %d-%d is outside of the sequence", pair[0], pair[1])
+		}
+		for _, position := range []int{open, closing} {
+			if _, ok := forced[position]; ok {
+				return nil, fmt.Errorf("position %d is forced to pair more than once", position)
+			}
+			forced[position] = 0
+		}
+		if !table.allowed[open][closing] {
+			return nil, fmt.Errorf("forced pair %d-%d is also prohibited", open, closing)
+		}
+		// prohibit every pair sharing a base with or crossing the forced pair.
+		for start := 0; start < length; start++ {
+			for end := start + 1; end < length; end++ {
+				if start == open && end == closing {
+					continue
+				}
+				sharesBase := start == open || start == closing || end == open || end == closing
+				crosses := (start < open && open < end && end < closing) || (open < start && start < closing && closing < end)
+				if sharesBase || crosses {
+					table.allowed[start][end] = false
+				}
+			}
+		}
+		table.bonus[open][closing] += forcedPairBonus
+		table.offset -= forcedPairBonus
+	}
+
+	if constraints.UnpairedEnergies != nil {
+		if len(constraints.UnpairedEnergies) != length {
+			return nil, fmt.Errorf("got %d unpaired energies for a sequence of length %d", len(constraints.UnpairedEnergies), length)
+		}
+		for start, energy := range constraints.UnpairedEnergies {
+			if math.IsNaN(energy) || math.IsInf(energy, 0) {
+				return nil, fmt.Errorf("unpaired energy of position %d is %f", start, energy)
+			}
+			table.offset += energy
+			for end := start + 1; end < length; end++ {
+				table.bonus[start][end] -= energy + constraints.UnpairedEnergies[end]
+			}
+		}
+	}
+	return table, nil
+}
+
+// prohibit keeps two positions from pairing, in either order.
+func (table *constraintTable) prohibit(first, second int) {
+	if first > second {
+		first, second = second, first
+	}
+	if first != second {
+		table.allowed[first][second] = false
+	}
+}
+
+// canPair checks if start and end (start < end) are allowed to pair. A nil
+// table allows everything.
+func (table *constraintTable) canPair(start, end int) bool {
+	return table == nil || table.allowed[start][end]
+}
+
+// pairEnergy is the pseudo-energy of start and end (start < end) pairing.
+func (table *constraintTable) pairEnergy(start, end int) float64 {
+	if table == nil {
+		return 0
+	}
+	return table.bonus[start][end]
+}
+
+// ZukerConstrained folds a sequence like Zuker, subject to constraints. The
+// minimum free energy of the result includes the pseudo-energies of any soft
+// constraints. If the constraints leave nothing able to pair, the result is
+// the open chain, which has no pairs and a minimum free energy of just the
+// pseudo-energies of the soft constraints.
+func ZukerConstrained(seq string, temp float64, constraints Constraints) (Result, error) {
+	table, err := newConstraintTable(len(seq), constraints)
+	if err != nil {
+		return Result{}, err
+	}
+	foldContext, err := newConstrainedFoldingContext(seq, temp, table)
+	if err != nil {
+		return Result{}, fmt.Errorf("error creating folding context: %w", err)
+	}
+	// nothing can pair, which leaves the open chain.
+	if len(seq) == 0 || !foldContext.unpairedMinimumFreeEnergyW[0][len(seq)-1].Valid() {
+		if len(table.paired) > 0 {
+			return Result{}, fmt.Errorf("forced pairs can't form")
+		}
+		return Result{structs: []nucleicAcidStructure{{description: "OPEN CHAIN"}}, energyOffset: table.offset}, nil
+	}
+	result := Result{
+		structs:      traceback(0, len(seq)-1, foldContext),
+		energyOffset: table.offset,
+	}
+
+	dotBracket := result.DotBracket()
+	for _, pair := range table.paired {
+		open, closing := min(pair[0], pair[1]), max(pair[0], pair[1])
+		if closing >= len(dotBracket) || dotBracket[open] != '(' || dotBracket[closing] != ')' {
+			return Result{}, fmt.Errorf("forced pair %d-%d can't form", open, closing)
+		}
+	}
+	return result, nil
+}
+
+// Evaluate returns the free energy of seq folded into the given dot-bracket
+// structure, by folding with every pair of the structure forced and every
+// other base forced unpaired. Like Zuker, it only handles structures the
+// folding traceback can produce.
+func Evaluate(seq, dotBracket string, temp float64) (float64, error) {
+	if len(dotBracket) != len(seq) {
+		return 0, fmt.Errorf("structure length %d doesn't match sequence length %d", len(dotBracket), len(seq))
+	}
+	pairs, err := structure.PairTable(dotBracket)
+	if err != nil {
+		return 0, err
+	}
+	var constraints Constraints
+	for index, partner := range pairs {
+		switch {
+		case partner == -1:
+			constraints.Unpaired = append(constraints.Unpaired, index)
+		case partner > index:
+			constraints.Paired = append(constraints.Paired, [2]int{index, partner})
+		}
+	}
+	if len(constraints.Paired) == 0 {
+		return 0, nil
+	}
+	result, err := ZukerConstrained(seq, temp, constraints)
+	if err != nil {
+		return 0, err
+	}
+	return result.MinimumFreeEnergy(), nil
+}
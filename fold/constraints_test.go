@@ -0,0 +1,164 @@
+package fold
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/fold/structure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConstraints(t *testing.T) {
+	constraints, err := ParseConstraints("xx..((..x..))..")
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 8}, constraints.Unpaired)
+	assert.Equal(t, [][2]int{{4, 12}, {5, 11}}, constraints.Paired)
+
+	_, err = ParseConstraints("..((..)..")
+	assert.ErrorIs(t, err, structure.ErrUnbalanced)
+	_, err = ParseConstraints("..|..")
+	assert.Error(t, err)
+}
+
+func TestZukerConstrained(t *testing.T) {
+	seq := "ACCCCCUCCUUCCUUGGAUCAAGGGGCUCAA"
+
+	t.Run("Unconstrained", func(t *testing.T) {
+		want, err := Zuker(seq, 37)
+		require.NoError(t, err)
+		got, err := ZukerConstrained(seq, 37, Constraints{})
+		require.NoError(t, err)
+		assert.Equal(t, want.DotBracket(), got.DotBracket())
+		assert.InDelta(t, want.MinimumFreeEnergy(), got.MinimumFreeEnergy(), 1e-9)
+	})
+
+	t.Run("Unpaired", func(t *testing.T) {
+		unconstrained, err := Zuker(seq, 37)
+		require.NoError(t, err)
+		// keep the bases of the first pair of the unconstrained structure unpaired.
+		first := strings.IndexByte(unconstrained.DotBracket(), '(')
+		result, err := ZukerConstrained(seq, 37, Constraints{Unpaired: []int{first, first + 1}})
+		require.NoError(t, err)
+		dotBracket := result.DotBracket()
+		for _, position := range []int{first, first + 1} {
+			if position < len(dotBracket) {
+				assert.Equal(t, byte('.'), dotBracket[position])
+			}
+		}
+		assert.GreaterOrEqual(t, result.MinimumFreeEnergy(), unconstrained.MinimumFreeEnergy())
+	})
+
+	t.Run("Prohibited", func(t *testing.T) {
+		unconstrained, err := Zuker(seq, 37)
+		require.NoError(t, err)
+		pairs, err := structure.PairTable(unconstrained.DotBracket())
+		require.NoError(t, err)
+		var prohibited [][2]int
+		for index, partner := range pairs {
+			if partner > index {
+				prohibited = append(prohibited, [2]int{index, partner})
+			}
+		}
+		result, err := ZukerConstrained(seq, 37, Constraints{Prohibited: prohibited})
+		require.NoError(t, err)
+		constrainedPairs, err := structure.PairTable(result.DotBracket())
+		require.NoError(t, err)
+		for _, pair := range prohibited {
+			if pair[0] < len(constrainedPairs) {
+				assert.NotEqual(t, pair[1], constrainedPairs[pair[0]])
+			}
+		}
+	})
+
+	t.Run("Paired", func(t *testing.T) {
+		result, err := ZukerConstrained(seq, 37, Constraints{Paired: [][2]int{{2, 25}}})
+		require.NoError(t, err)
+		pairs, err := structure.PairTable(result.DotBracket())
+		require.NoError(t, err)
+		assert.Equal(t, 25, pairs[2])
+		energy, err := Evaluate(seq, result.DotBracket()+strings.Repeat(".", len(seq)-len(result.DotBracket())), 37)
+		require.NoError(t, err)
+		assert.InDelta(t, energy, result.MinimumFreeEnergy(), 1e-6)
+	})
+
+	t.Run("SoftConstraints", func(t *testing.T) {
+		unconstrained, err := Zuker(seq, 37)
+		require.NoError(t, err)
+		// the same pseudo-energy on every base shifts every structure by the
+		// same amount, so the structure stays put.
+		energies := make([]float64, len(seq))
+		for index := range energies {
+			energies[index] = 0.1
+		}
+		result, err := ZukerConstrained(seq, 37, Constraints{UnpairedEnergies: energies})
+		require.NoError(t, err)
+		assert.Equal(t, unconstrained.DotBracket(), result.DotBracket())
+		unpaired := strings.Count(unconstrained.DotBracket(), ".") + len(seq) - len(unconstrained.DotBracket())
+		assert.InDelta(t, unconstrained.MinimumFreeEnergy()+0.1*float64(unpaired), result.MinimumFreeEnergy(), 1e-6)
+
+		// strongly favoring unpaired bases unfolds most of the sequence.
+		for index := range energies {
+			energies[index] = -10
+		}
+		result, err = ZukerConstrained(seq, 37, Constraints{UnpairedEnergies: energies})
+		require.NoError(t, err)
+		assert.Less(t, strings.Count(result.DotBracket(), "("), strings.Count(unconstrained.DotBracket(), "("))
+	})
+
+	t.Run("NothingCanPair", func(t *testing.T) {
+		unpaired := make([]int, len(seq))
+		for index := range unpaired {
+			unpaired[index] = index
+		}
+		result, err := ZukerConstrained(seq, 37, Constraints{Unpaired: unpaired})
+		require.NoError(t, err)
+		assert.Equal(t, "", result.DotBracket())
+		assert.Equal(t, 0.0, result.MinimumFreeEnergy())
+
+		// the open chain still gets the pseudo-energies of unpaired bases.
+		energies := make([]float64, 4)
+		for index := range energies {
+			energies[index] = -0.5
+		}
+		result, err = ZukerConstrained("ACGT", 37, Constraints{UnpairedEnergies: energies})
+		require.NoError(t, err)
+		assert.Equal(t, "", result.DotBracket())
+		assert.InDelta(t, -2.0, result.MinimumFreeEnergy(), 1e-9)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for name, constraints := range map[string]Constraints{
+			"UnpairedOutOfRange":   {Unpaired: []int{len(seq)}},
+			"ProhibitedOutOfRange": {Prohibited: [][2]int{{-1, 4}}},
+			"PairedOutOfRange":     {Paired: [][2]int{{0, len(seq)}}},
+			"PairedTwice":          {Paired: [][2]int{{0, 10}, {10, 20}}},
+			"PairedAndProhibited":  {Paired: [][2]int{{0, 10}}, Prohibited: [][2]int{{10, 0}}},
+			"PairedAndUnpaired":    {Paired: [][2]int{{0, 10}}, Unpaired: []int{10}},
+			"TooFewEnergies":       {UnpairedEnergies: []float64{0.1, 0.2}},
+			"ImpossiblePair":       {Paired: [][2]int{{0, 2}}},
+		} {
+			_, err := ZukerConstrained(seq, 37, constraints)
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestEvaluate(t *testing.T) {
+	seq := "ACCCCCUCCUUCCUUGGAUCAAGGGGCUCAA"
+	result, err := Zuker(seq, 37)
+	require.NoError(t, err)
+	dotBracket := result.DotBracket() + strings.Repeat(".", len(seq)-len(result.DotBracket()))
+	energy, err := Evaluate(seq, dotBracket, 37)
+	require.NoError(t, err)
+	assert.InDelta(t, result.MinimumFreeEnergy(), energy, 1e-6)
+
+	energy, err = Evaluate(seq, strings.Repeat(".", len(seq)), 37)
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, energy)
+
+	_, err = Evaluate(seq, "((..))", 37)
+	assert.Error(t, err)
+	_, err = Evaluate(seq, strings.Repeat("(", len(seq)), 37)
+	assert.ErrorIs(t, err, structure.ErrUnbalanced)
+}
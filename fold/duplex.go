@@ -0,0 +1,183 @@
+package fold
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/bebop/poly/checks"
+)
+
+/******************************************************************************
+
+Duplex hybridization begins here.
+
+Zuker folds a single strand onto itself. Plenty of design problems are about
+two strands instead: will an antisense oligo bind its target, how strongly
+does a toehold switch's trigger open it up, will two primers stick to each
+other. Duplex answers them by finding the most stable way for two strands to
+base pair with each other, using the same nearest neighbor energies as
+Zuker.
+
+Like RNAduplex from the ViennaRNA package, only pairs between the two
+strands are considered, so intramolecular structure (a hairpin in the target
+that has to melt before the oligo can bind) isn't accounted for. What is
+accounted for is the cost of bringing two strands together in the first
+place, the duplex initiation penalty, along with stacks, bulges, and
+interior loops within the duplex and penalties for terminal AT pairs.
+
+For more on the energies:
+SantaLucia and Hicks, 2004 (DNA)
+https://doi.org/10.1146/annurev.biophys.32.110601.141800
+Xia et al., 1998 (RNA)
+https://doi.org/10.1021/bi9809425
+
+******************************************************************************/
+
+// maxDuplexLoop is the largest bulge or interior loop Duplex considers, counting
+// the unpaired bases on both strands.
+const maxDuplexLoop = 30
+
+var (
+	// dnaDuplexInitiation is the initiation penalty of a DNA duplex, from
+	// SantaLucia and Hicks, 2004.
+	dnaDuplexInitiation = energy{enthalpyH: 0.2, entropyS: -5.7}
+	// rnaDuplexInitiation is the initiation penalty of an RNA duplex, from
+	// Xia et al., 1998.
+	rnaDuplexInitiation = energy{enthalpyH: 3.61, entropyS: -1.5}
+)
+
+// DuplexResult is the most stable duplex formed between two strands.
+type DuplexResult struct {
+	// Energy is the free energy of hybridization in kcal/mol, including the
+	// initiation penalty. It's 0 if the strands can't pair at all.
+	Energy float64
+	// Structure is the duplex in dot-bracket notation, with the two strands
+	// separated by "&", as in "((((.((&)).))))". The first strand's pairs
+	// open with "(" and the second strand's close with ")".
+	Structure string
+	// Pairs lists the 0-based positions of every pair as {first, second}, in
+	// order along the first strand.
+	Pairs [][2]int
+}
+
+// Duplex finds the minimum free energy duplex of two DNA or RNA strands at
+// temp degrees Celsius. Both strands are written 5' to 3'.
+func Duplex(first, second string, temp float64) (DuplexResult, error) {
+	first, second = strings.ToUpper(first), strings.ToUpper(second)
+	var (
+		energyMap  energies
+		initiation energy
+	)
+	switch {
+	case checks.IsDNA(first) && checks.IsDNA(second):
+		energyMap, initiation = dnaEnergies, dnaDuplexInitiation
+	case checks.IsRNA(first) && checks.IsRNA(second):
+		energyMap, initiation = rnaEnergies, rnaDuplexInitiation
+	default:
+		return DuplexResult{}, fmt.Errorf("the sequences %s and %s are not both RNA or both DNA", first, second)
+	}
+
+	// the strands are joined into one sequence so the loop energy functions
+	// Zuker uses can be reused as is. A pad on either end keeps stack from
+	// treating the outermost bases as the dangling ends of a hairpin.
+	offset := len(first) + 1
+	foldContext := context{
+		energies: energyMap,
+		seq:      "N" + first + second + "N",
+		temp:     temp + 273.15, // kelvin
+	}
+	canPair := func(index, partner int) bool {
+		return energyMap.complement(rune(first[index])) == rune(second[partner])
+	}
+	// terminalPenalty is the penalty of a pair ending a helix.
+	terminalPenalty := func(index, partner int) float64 {
+		if first[index] == 'A' || second[partner] == 'A' {
+			return closingATPenalty
+		}
+		return 0
+	}
+
+	// duplexEnergies[index][partner] is the lowest energy of a duplex whose
+	// first pair along the first strand is (index, partner), not counting
+	// initiation or the penalty of that first pair.
+	duplexEnergies := make([][]float64, len(first))
+	next := make([][][2]int, len(first))
+	for index := range duplexEnergies {
+		duplexEnergies[index] = make([]float64, len(second))
+		next[index] = make([][2]int, len(second))
+	}
+
+	best, bestStart := math.Inf(1), [2]int{-1, -1}
+	for index := len(first) - 1; index >= 0; index-- {
+		for partner := 0; partner < len(second); partner++ {
+			duplexEnergies[index][partner] = math.Inf(1)
+			next[index][partner] = [2]int{-1, -1}
+			if !canPair(index, partner) {
+				continue
+			}
+			// the duplex can end right here...
+			lowest := terminalPenalty(index, partner)
+			// ...or continue through a stack, bulge, or interior loop.
+			for inner := index + 1; inner < len(first) && inner-index-1 <= maxDuplexLoop; inner++ {
+				for innerPartner := partner - 1; innerPartner >= 0 && (inner-index-1)+(partner-innerPartner-1) <= maxDuplexLoop; innerPartner-- {
+					rest := duplexEnergies[inner][innerPartner]
+					if math.IsInf(rest, 1) {
+						continue
+					}
+					loop, err := duplexLoop(index+1, inner+1, offset+partner, offset+innerPartner, foldContext)
+					if err != nil {
+						return DuplexResult{}, err
+					}
+					if total := loop + rest; total < lowest {
+						lowest = total
+						next[index][partner] = [2]int{inner, innerPartner}
+					}
+				}
+			}
+			duplexEnergies[index][partner] = lowest
+
+			if total := lowest + terminalPenalty(index, partner); total < best {
+				best, bestStart = total, [2]int{index, partner}
+			}
+		}
+	}
+
+	result := DuplexResult{}
+	if bestStart[0] == -1 {
+		// nothing pairs.
+		result.Structure = strings.Repeat(".", len(first)) + "&" + strings.Repeat(".", len(second))
+		return result, nil
+	}
+	result.Energy = best + deltaG(initiation.enthalpyH, initiation.entropyS, foldContext.temp)
+
+	firstStructure := []byte(strings.Repeat(".", len(first)))
+	secondStructure := []byte(strings.Repeat(".", len(second)))
+	for current := bestStart; current[0] != -1; current = next[current[0]][current[1]] {
+		result.Pairs = append(result.Pairs, current)
+		firstStructure[current[0]] = '('
+		secondStructure[current[1]] = ')'
+	}
+	result.Structure = string(firstStructure) + "&" + string(secondStructure)
+	return result, nil
+}
+
+// duplexLoop returns the energy of the stack, bulge, or interior loop between
+// the pairs (start, end) and (rightOfStart, leftOfEnd) of a joined duplex
+// sequence, where start < rightOfStart < leftOfEnd < end.
+func duplexLoop(start, rightOfStart, end, leftOfEnd int, foldContext context) (float64, error) {
+	firstLoop, secondLoop := rightOfStart-start-1, end-leftOfEnd-1
+	switch {
+	case firstLoop == 0 && secondLoop == 0:
+		return stack(start, rightOfStart, end, leftOfEnd, foldContext), nil
+	case firstLoop == 0 || secondLoop == 0:
+		return Bulge(start, rightOfStart, end, leftOfEnd, foldContext)
+	case firstLoop == 1 && secondLoop == 1:
+		// a single mismatch, scored as the two stacks on either side of it.
+		// internalLoop looks these up from the closing pairs instead of the
+		// mismatched bases, which scores them about as well as a real pair.
+		return stack(start, start+1, end, end-1, foldContext) + stack(rightOfStart-1, rightOfStart, leftOfEnd+1, leftOfEnd, foldContext), nil
+	default:
+		return internalLoop(start, rightOfStart, end, leftOfEnd, foldContext)
+	}
+}
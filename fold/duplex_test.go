@@ -0,0 +1,77 @@
+package fold
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/transform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuplex(t *testing.T) {
+	t.Run("PerfectComplement", func(t *testing.T) {
+		// SantaLucia and Hicks, 2004 nearest neighbors: -7.36 for the stacks,
+		// 1.96 to initiate, and 0.5 for the terminal AT pair.
+		result, err := Duplex("CGTTGA", "TCAACG", 37)
+		require.NoError(t, err)
+		assert.Equal(t, "((((((&))))))", result.Structure)
+		assert.InDelta(t, -4.9, result.Energy, 0.05)
+		assert.Equal(t, [][2]int{{0, 5}, {1, 4}, {2, 3}, {3, 2}, {4, 1}, {5, 0}}, result.Pairs)
+	})
+
+	t.Run("Symmetric", func(t *testing.T) {
+		oligo := "GGACTGACGATTCG"
+		target := "ATCGAATCGTCAGTCCAT"
+		forward, err := Duplex(oligo, target, 37)
+		require.NoError(t, err)
+		reverse, err := Duplex(target, oligo, 37)
+		require.NoError(t, err)
+		assert.InDelta(t, forward.Energy, reverse.Energy, 1e-9)
+	})
+
+	t.Run("Mismatches", func(t *testing.T) {
+		oligo := "GGACTGACGATTCGACTG"
+		perfect, err := Duplex(oligo, transform.ReverseComplement(oligo), 37)
+		require.NoError(t, err)
+		mismatched, err := Duplex(oligo, "CAGTCGAATAGTCAGTCC", 37)
+		require.NoError(t, err)
+		bulged, err := Duplex(oligo, "CAGTCGAATTCGTCAGTCC", 37)
+		require.NoError(t, err)
+		assert.Less(t, perfect.Energy, mismatched.Energy)
+		assert.Less(t, perfect.Energy, bulged.Energy)
+		assert.Less(t, mismatched.Energy, 0.0)
+		assert.Less(t, bulged.Energy, 0.0)
+		assert.Equal(t, 1, strings.Count(strings.Split(bulged.Structure, "&")[1], "."))
+	})
+
+	t.Run("Temperature", func(t *testing.T) {
+		cold, err := Duplex("GGACTGACGATTCG", "CGAATCGTCAGTCC", 25)
+		require.NoError(t, err)
+		hot, err := Duplex("GGACTGACGATTCG", "CGAATCGTCAGTCC", 65)
+		require.NoError(t, err)
+		assert.Less(t, cold.Energy, hot.Energy)
+	})
+
+	t.Run("RNA", func(t *testing.T) {
+		result, err := Duplex("GGCUAGCUAGCC", "ggcuagcuagcc", 37)
+		require.NoError(t, err)
+		assert.Equal(t, "((((((((((((&))))))))))))", result.Structure)
+		assert.Less(t, result.Energy, -15.0)
+	})
+
+	t.Run("NoPairs", func(t *testing.T) {
+		result, err := Duplex("AAAAAA", "GGGG", 37)
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, result.Energy)
+		assert.Equal(t, "......&....", result.Structure)
+		assert.Empty(t, result.Pairs)
+	})
+
+	t.Run("MixedTypes", func(t *testing.T) {
+		_, err := Duplex("ACGT", "ACGU", 37)
+		assert.Error(t, err)
+		_, err = Duplex("ACGT", "ACGX", 37)
+		assert.Error(t, err)
+	})
+}
@@ -12,3 +12,30 @@ func ExampleZuker() {
 	fmt.Println(brackets)
 	// Output: .((((.(((......)))....))))
 }
+
+func ExampleZukerConstrained() {
+	// keep the first three bases from pairing.
+	constraints, _ := fold.ParseConstraints("xxx............................")
+	result, _ := fold.ZukerConstrained("ACCCCCUCCUUCCUUGGAUCAAGGGGCUCAA", 37.0, constraints)
+	fmt.Println(result.DotBracket()[:3])
+	// Output: ...
+}
+
+func ExampleEvaluate() {
+	energy, _ := fold.Evaluate("ACCCCCUCCUUCCUUGGAUCAAGGGGCUCAA", ".((((.(((......)))....)))).....", 37.0)
+	fmt.Printf("%.2f\n", energy)
+	// Output: -9.42
+}
+
+func ExampleDuplex() {
+	// an antisense oligo with a single mismatch against its target.
+	result, _ := fold.Duplex("GGACTGACGATTCG", "CGAATCGTTAGTCC", 37.0)
+	fmt.Println(result.Structure)
+	// Output: (((((.((((((((&)))))))).)))))
+}
+
+func ExampleFold() {
+	result, _ := fold.Fold("ACCCCCUCCUUCCUUGGAUCAAGGGGCUCAA", fold.Options{Temperature: 37})
+	fmt.Println(result.DotBracket())
+	// Output: .((((.(((......)))....))))
+}
@@ -33,6 +33,8 @@ import (
 	"math"
 	"strings"
 
+	"github.com/bebop/poly/alphabet"
+	"github.com/bebop/poly/sequence"
 	"github.com/bebop/poly/transform"
 )
 
@@ -66,6 +68,19 @@ func Zuker(seq string, temp float64) (Result, error) {
 	}, nil
 }
 
+// ZukerSequence folds a sequence like Zuker, with the energies of the
+// molecule it is rather than the ones its letters suggest, so an RNA
+// sequence written with Ts folds as RNA.
+func ZukerSequence(input sequence.Sequence, temp float64) (Result, error) {
+	switch input.Molecule {
+	case sequence.DNA:
+		return Zuker(alphabet.RNAToDNA(strings.ToUpper(input.Sequence)), temp)
+	case sequence.RNA:
+		return Zuker(alphabet.DNAToRNA(strings.ToUpper(input.Sequence)), temp)
+	}
+	return Result{}, fmt.Errorf("can't fold %s, a %s", input.Name, input.Molecule)
+}
+
 // unpairedMinimumFreeEnergyW returns the minimum free energy of a subsequence
 // at start and terminating at end.
 //
@@ -141,11 +156,14 @@ func pairedMinimumFreeEnergyV(start, end int, foldContext context) (nucleicAcidS
 		return foldContext.pairedMinimumFreeEnergyV[start][end], nil
 	}
 
-	// the ends must basepair for pairedMinimumFreeEnergyV(start,end)
-	if foldContext.energies.complement(rune(foldContext.seq[start])) != rune(foldContext.seq[end]) {
+	// the ends must basepair for pairedMinimumFreeEnergyV(start,end), and be
+	// allowed to by any constraints.
+	if foldContext.energies.complement(rune(foldContext.seq[start])) != rune(foldContext.seq[end]) || !foldContext.constraints.canPair(start, end) {
 		foldContext.pairedMinimumFreeEnergyV[start][end] = invalidStructure
 		return foldContext.pairedMinimumFreeEnergyV[start][end], nil
 	}
+	// soft constraint pseudo-energy of this pair, added to every way of forming it.
+	constraintEnergy := foldContext.constraints.pairEnergy(start, end)
 	// if the basepair is isolated, and the seq large, penalize at 1,600 kcal/mol
 	// heuristic for speeding this up
 	// from https://www.ncbi.nlm.nih.gov/pubmed/10329189
@@ -156,7 +174,7 @@ func pairedMinimumFreeEnergyV(start, end int, foldContext context) (nucleicAcidS
 	isolatedInner := foldContext.energies.complement(rune(foldContext.seq[start+1])) != rune(foldContext.seq[end-1])
 
 	if isolatedOuter && isolatedInner {
-		foldContext.pairedMinimumFreeEnergyV[start][end] = nucleicAcidStructure{energy: isolatedBasePairPenalty}
+		foldContext.pairedMinimumFreeEnergyV[start][end] = nucleicAcidStructure{energy: isolatedBasePairPenalty + constraintEnergy}
 		return foldContext.pairedMinimumFreeEnergyV[start][end], nil
 	}
 
@@ -165,7 +183,7 @@ func pairedMinimumFreeEnergyV(start, end int, foldContext context) (nucleicAcidS
 	if err != nil {
 		return defaultStructure, fmt.Errorf("v: subsequence (%d, %d): %w", start, end, err)
 	}
-	e1 := nucleicAcidStructure{energy: hairpin, description: "HAIRPIN:" + paired}
+	e1 := nucleicAcidStructure{energy: hairpin + constraintEnergy, description: "HAIRPIN:" + paired}
 	if end-start == minLenForStruct { // small hairpin; 4bp
 		foldContext.pairedMinimumFreeEnergyV[start][end] = e1
 		foldContext.unpairedMinimumFreeEnergyW[start][end] = e1
@@ -246,7 +264,7 @@ func pairedMinimumFreeEnergyV(start, end int, foldContext context) (nucleicAcidS
 			if err != nil {
 				return defaultStructure, fmt.Errorf("v: subsequence (%d, %d): %w", start, end, err)
 			}
-			e2Test += tv.energy
+			e2Test += tv.energy + constraintEnergy
 			if e2Test != math.Inf(-1) && e2Test < e2.energy {
 				e2 = nucleicAcidStructure{energy: e2Test, description: e2TestType, inner: []subsequence{{rightOfStart, leftOfEnd}}}
 			}
@@ -261,6 +279,7 @@ func pairedMinimumFreeEnergyV(start, end int, foldContext context) (nucleicAcidS
 				return defaultStructure, fmt.Errorf("v: subsequence (%d, %d): %w", start, end, err)
 			}
 
+			e3Test.energy += constraintEnergy
 			if e3Test.Valid() && e3Test.energy < e3.energy {
 				e3 = e3Test
 			}
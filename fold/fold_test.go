@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/bebop/poly/sequence"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -212,3 +213,15 @@ func TestZuker_ErrorCreatingFoldingContext(t *testing.T) {
 	require.Error(t, err)
 	assert.Equal(t, expectedErr.Error(), err.Error())
 }
+
+func TestZukerSequence(t *testing.T) {
+	rna := sequence.Sequence{Name: "hairpin", Sequence: "GGGAGGTCGTTACATCTGGGTAACACCGGTACTGATCCGGTGACCTCCC", Molecule: sequence.RNA}
+	result, err := ZukerSequence(rna, 37)
+	require.NoError(t, err)
+	want, err := Zuker(strings.ReplaceAll(rna.Sequence, "T", "U"), 37)
+	require.NoError(t, err)
+	assert.Equal(t, want.MinimumFreeEnergy(), result.MinimumFreeEnergy())
+
+	_, err = ZukerSequence(sequence.New("protein", "MKLV"), 37)
+	assert.Error(t, err)
+}
@@ -0,0 +1,50 @@
+package fold
+
+/******************************************************************************
+
+Options begin here.
+
+Zuker, ZukerConstrained, and Evaluate each take their settings as arguments,
+so every new setting means a new function or a broken signature. Fold takes
+an Options struct instead, and new settings become new fields whose zero
+values keep the old behavior.
+
+******************************************************************************/
+
+// Options changes how Fold folds a sequence. Zero values are replaced with
+// the defaults noted on each field.
+type Options struct {
+	// Temperature is what the sequence is folded at, in Celsius. Defaults to
+	// 37. Folding at exactly 0°C needs Zuker.
+	Temperature float64
+	// Constraints are hard and soft constraints on the structure, if any.
+	Constraints *Constraints
+}
+
+// Fold folds a sequence into its minimum free energy structure.
+func Fold(seq string, options Options) (Result, error) {
+	options = options.withDefaults()
+	if options.Constraints != nil {
+		return ZukerConstrained(seq, options.Temperature, *options.Constraints)
+	}
+	return Zuker(seq, options.Temperature)
+}
+
+// EvaluateWithOptions returns the free energy of seq folded into the given
+// dot-bracket structure, like Evaluate. Constraints aren't used.
+func EvaluateWithOptions(seq, dotBracket string, options Options) (float64, error) {
+	return Evaluate(seq, dotBracket, options.withDefaults().Temperature)
+}
+
+// DuplexWithOptions returns the minimum free energy of hybridization of two
+// strands, like Duplex. Constraints aren't used.
+func DuplexWithOptions(first, second string, options Options) (DuplexResult, error) {
+	return Duplex(first, second, options.withDefaults().Temperature)
+}
+
+func (options Options) withDefaults() Options {
+	if options.Temperature == 0 {
+		options.Temperature = 37
+	}
+	return options
+}
@@ -0,0 +1,45 @@
+package fold
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFoldOptions(t *testing.T) {
+	seq := "ACCCCCUCCUUCCUUGGAUCAAGGGGCUCAA"
+
+	// the zero value folds like Zuker at 37°C.
+	want, err := Zuker(seq, 37)
+	require.NoError(t, err)
+	got, err := Fold(seq, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, want.DotBracket(), got.DotBracket())
+	assert.InDelta(t, want.MinimumFreeEnergy(), got.MinimumFreeEnergy(), 1e-9)
+
+	want, err = Zuker(seq, 60)
+	require.NoError(t, err)
+	got, err = Fold(seq, Options{Temperature: 60})
+	require.NoError(t, err)
+	assert.InDelta(t, want.MinimumFreeEnergy(), got.MinimumFreeEnergy(), 1e-9)
+
+	constraints := Constraints{Unpaired: []int{1, 2, 3, 4}}
+	want, err = ZukerConstrained(seq, 37, constraints)
+	require.NoError(t, err)
+	got, err = Fold(seq, Options{Constraints: &constraints})
+	require.NoError(t, err)
+	assert.Equal(t, want.DotBracket(), got.DotBracket())
+
+	energy, err := EvaluateWithOptions(seq, ".((((.(((......)))....)))).....", Options{})
+	require.NoError(t, err)
+	wantEnergy, err := Evaluate(seq, ".((((.(((......)))....)))).....", 37)
+	require.NoError(t, err)
+	assert.InDelta(t, wantEnergy, energy, 1e-9)
+
+	duplex, err := DuplexWithOptions("GGACTGACGATTCG", "CGAATCGTCAGTCC", Options{})
+	require.NoError(t, err)
+	wantDuplex, err := Duplex("GGACTGACGATTCG", "CGAATCGTCAGTCC", 37)
+	require.NoError(t, err)
+	assert.Equal(t, wantDuplex, duplex)
+}
@@ -0,0 +1,260 @@
+package plot
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+/******************************************************************************
+
+Arc diagrams begin here.
+
+Arc diagrams lay a sequence out on a straight line and draw every base pair
+as an arc above it. They are worse than Layout at showing what a structure
+looks like, but much better at comparing structures: every structure of the
+same sequence puts every base in the same place, so stacking them on top of
+each other makes the differences between design variants jump out. They
+also draw pseudoknots without any fuss.
+
+******************************************************************************/
+
+// Arc is a base pair between the 0-based positions Open and Close. Weight,
+// between 0 and 1, sets how strongly the arc is drawn, which is how pairing
+// probabilities are shown.
+type Arc struct {
+	Open, Close int
+	Weight      float64
+}
+
+// Arcs returns every base pair of a dot-bracket structure, including
+// pseudoknots, as fully weighted arcs.
+func Arcs(dotBracket string) ([]Arc, error) {
+	_, pairs, err := pairTables(dotBracket)
+	if err != nil {
+		return nil, err
+	}
+	var arcs []Arc
+	for index, partner := range pairs {
+		if partner > index {
+			arcs = append(arcs, Arc{Open: index, Close: partner, Weight: 1})
+		}
+	}
+	return arcs, nil
+}
+
+// ProbabilityArcs returns an arc for every pair in a base pair probability
+// matrix with a probability of at least cutoff. Only the upper triangle of
+// probabilities (probabilities[i][j] with i < j) is read.
+func ProbabilityArcs(probabilities [][]float64, cutoff float64) []Arc {
+	var arcs []Arc
+	for open, row := range probabilities {
+		for closing := open + 1; closing < len(row); closing++ {
+			if probability := row[closing]; probability > 0 && probability >= cutoff {
+				arcs = append(arcs, Arc{Open: open, Close: closing, Weight: math.Min(probability, 1)})
+			}
+		}
+	}
+	return arcs
+}
+
+// arcSpacing is the distance between neighboring bases of an arc diagram.
+const arcSpacing = scale / 2
+
+// ArcSVG draws one arc diagram per structure of sequence, stacked on top of
+// each other so they can be compared. Options.Labels names each structure and
+// Options.Title is drawn above them all.
+func ArcSVG(sequence string, structures [][]Arc, options Options) (string, error) {
+	if options.Labels != nil && len(options.Labels) != len(structures) {
+		return "", fmt.Errorf("got %d labels for %d structures", len(options.Labels), len(structures))
+	}
+	rowHeights := make([]float64, len(structures))
+	for row, arcs := range structures {
+		for _, arc := range arcs {
+			if arc.Open < 0 || arc.Close >= len(sequence) || arc.Open >= arc.Close {
+				return "", fmt.Errorf("arc %d-%d of structure %d doesn't fit a sequence of length %d", arc.Open+1, arc.Close+1, row+1, len(sequence))
+			}
+			rowHeights[row] = math.Max(rowHeights[row], float64(arc.Close-arc.Open)*arcSpacing/2)
+		}
+		// leave room for the row's label and bases.
+		rowHeights[row] += 2.5 * scale
+	}
+
+	margin := 2 * scale
+	top := margin
+	if options.Title != "" {
+		top += scale
+	}
+	width := float64(max(len(sequence)-1, 0))*arcSpacing + 2*margin
+	height := top + margin
+	for _, rowHeight := range rowHeights {
+		height += rowHeight
+	}
+
+	var svg strings.Builder
+	writeHeader(&svg, width, height, margin, options.Title)
+	baseline := top
+	for row, arcs := range structures {
+		baseline += rowHeights[row] - scale
+		if options.Labels != nil {
+			fmt.Fprintf(&svg, `<text class="label" x="%.1f" y="%.1f" font-size="12">%s</text>`+"\n", margin, baseline-rowHeights[row]+2*scale, escape(options.Labels[row]))
+		}
+		fmt.Fprintf(&svg, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#888" stroke-width="1"/>`+"\n", margin, baseline, width-margin, baseline)
+		for _, arc := range arcs {
+			start := margin + float64(arc.Open)*arcSpacing
+			end := margin + float64(arc.Close)*arcSpacing
+			radius := (end - start) / 2
+			fmt.Fprintf(&svg, `<path class="arc" d="M %.1f %.1f A %.1f %.1f 0 0 1 %.1f %.1f" fill="none" stroke="%s" stroke-width="1.5" stroke-opacity="%.2f"/>`+"\n", start, baseline, radius, radius, end, baseline, seriesColor(row), arc.Weight)
+		}
+		for index := 0; index < len(sequence); index++ {
+			x := margin + float64(index)*arcSpacing
+			color := "#444"
+			if options.PositionColors != nil && index < len(options.PositionColors) {
+				color = options.PositionColors[index]
+			}
+			fmt.Fprintf(&svg, `<text class="base" x="%.1f" y="%.1f" text-anchor="middle" font-size="9" fill="%s">%s</text>`+"\n", x, baseline+scale/2, escape(color), escape(sequence[index:index+1]))
+		}
+		if options.NumberEvery > 0 {
+			writeNumbers(&svg, len(sequence), options.NumberEvery, margin, baseline+scale)
+		}
+	}
+	svg.WriteString("</svg>\n")
+	return svg.String(), nil
+}
+
+/******************************************************************************
+
+Mountain plots begin here.
+
+A mountain plot turns a structure into a line: the height at every position
+is the number of base pairs enclosing it. Helices are slopes, loops are
+plateaus, and the whole structure reads left to right like a skyline. Since
+a mountain is just a series of numbers, it's easy to compare across designs,
+both by eye and by computing the distance between series.
+
+For a base pair probability matrix the height is the expected number of
+enclosing pairs, which is the same thing averaged over the whole ensemble.
+
+******************************************************************************/
+
+// Mountain returns the mountain height of every position of a dot-bracket
+// structure: the number of base pairs (i, j), pseudoknots included, with
+// i <= position <= j.
+func Mountain(dotBracket string) ([]float64, error) {
+	arcs, err := Arcs(dotBracket)
+	if err != nil {
+		return nil, err
+	}
+	return mountain(len(dotBracket), arcs), nil
+}
+
+// ProbabilityMountain returns the expected mountain height of every position
+// given a base pair probability matrix. Only the upper triangle of
+// probabilities is read.
+func ProbabilityMountain(probabilities [][]float64) []float64 {
+	return mountain(len(probabilities), ProbabilityArcs(probabilities, 0))
+}
+
+// mountain sums the weight of every arc over the positions it spans.
+func mountain(length int, arcs []Arc) []float64 {
+	changes := make([]float64, length+1)
+	for _, arc := range arcs {
+		changes[arc.Open] += arc.Weight
+		changes[arc.Close+1] -= arc.Weight
+	}
+	heights := make([]float64, length)
+	height := 0.0
+	for index := range heights {
+		height += changes[index]
+		heights[index] = height
+	}
+	return heights
+}
+
+// mountainScale is the vertical size of one unit of mountain height.
+const mountainScale = scale / 2
+
+// MountainSVG draws mountain series, such as those returned by Mountain or
+// ProbabilityMountain, as lines on the same axes. Options.Labels names each
+// series in a legend and Options.Title is drawn above the plot.
+func MountainSVG(series [][]float64, options Options) (string, error) {
+	if options.Labels != nil && len(options.Labels) != len(series) {
+		return "", fmt.Errorf("got %d labels for %d series", len(options.Labels), len(series))
+	}
+	length, peak := 0, 1.0
+	for _, heights := range series {
+		length = max(length, len(heights))
+		for _, height := range heights {
+			peak = math.Max(peak, height)
+		}
+	}
+
+	margin := 2 * scale
+	top := margin
+	if options.Title != "" {
+		top += scale
+	}
+	width := float64(max(length-1, 0))*arcSpacing + 2*margin
+	plotHeight := peak * mountainScale
+	height := top + plotHeight + 2*margin
+	if options.Labels != nil {
+		height += float64(len(series)) * scale
+	}
+	baseline := top + plotHeight
+
+	var svg strings.Builder
+	writeHeader(&svg, width, height, margin, options.Title)
+	fmt.Fprintf(&svg, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#888" stroke-width="1"/>`+"\n", margin, baseline, width-margin, baseline)
+	fmt.Fprintf(&svg, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#888" stroke-width="1"/>`+"\n", margin, top, margin, baseline)
+	for index, heights := range series {
+		fmt.Fprintf(&svg, `<polyline class="mountain" fill="none" stroke="%s" stroke-width="2" points="`, seriesColor(index))
+		for position, value := range heights {
+			if position > 0 {
+				svg.WriteByte(' ')
+			}
+			fmt.Fprintf(&svg, "%.1f,%.1f", margin+float64(position)*arcSpacing, baseline-value*mountainScale)
+		}
+		svg.WriteString("\"/>\n")
+	}
+	if options.NumberEvery > 0 {
+		writeNumbers(&svg, length, options.NumberEvery, margin, baseline+scale/2)
+	}
+	for index, label := range options.Labels {
+		y := baseline + 1.5*scale + float64(index)*scale
+		fmt.Fprintf(&svg, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="2"/>`+"\n", margin, y, margin+scale, y, seriesColor(index))
+		fmt.Fprintf(&svg, `<text class="label" x="%.1f" y="%.1f" dominant-baseline="central" font-size="12">%s</text>`+"\n", margin+1.5*scale, y, escape(label))
+	}
+	svg.WriteString("</svg>\n")
+	return svg.String(), nil
+}
+
+/******************************************************************************
+
+Shared drawing helpers begin here.
+
+******************************************************************************/
+
+// seriesColors tells apart structures drawn on the same image.
+var seriesColors = []string{"#1f77b4", "#d62728", "#2ca02c", "#ff7f0e", "#9467bd", "#8c564b", "#e377c2", "#7f7f7f"}
+
+func seriesColor(index int) string {
+	return seriesColors[index%len(seriesColors)]
+}
+
+// writeHeader opens an SVG image and draws its title.
+func writeHeader(svg *strings.Builder, width, height, margin float64, title string) {
+	fmt.Fprintf(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" font-family="Helvetica, Arial, sans-serif">`+"\n", width, height, width, height)
+	if title != "" {
+		fmt.Fprintf(svg, `<text x="%.1f" y="%.1f" text-anchor="middle" font-size="16">%s</text>`+"\n", width/2, margin, escape(title))
+	}
+}
+
+// writeNumbers labels the first and every nth position along a horizontal axis.
+func writeNumbers(svg *strings.Builder, length, every int, margin, y float64) {
+	for index := 0; index < length; index++ {
+		if index != 0 && (index+1)%every != 0 {
+			continue
+		}
+		fmt.Fprintf(svg, `<text class="number" x="%.1f" y="%.1f" text-anchor="middle" dominant-baseline="central" font-size="8" fill="#666">%d</text>`+"\n", margin+float64(index)*arcSpacing, y, index+1)
+	}
+}
@@ -0,0 +1,136 @@
+package plot
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMountain(t *testing.T) {
+	tests := []struct {
+		structure string
+		want      []float64
+	}{
+		{"((..))", []float64{1, 2, 2, 2, 2, 1}},
+		{".(.).(.).", []float64{0, 1, 1, 1, 0, 1, 1, 1, 0}},
+		{"((.[.))]", []float64{1, 2, 2, 3, 3, 3, 2, 1}},
+		{"", []float64{}},
+	}
+	for _, test := range tests {
+		got, err := Mountain(test.structure)
+		if err != nil {
+			t.Fatalf("Mountain(%q) returned error: %s", test.structure, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Mountain(%q) = %v, want %v", test.structure, got, test.want)
+		}
+	}
+	if _, err := Mountain("((.)"); err == nil {
+		t.Errorf("Mountain should fail on unbalanced structures")
+	}
+}
+
+// probabilities returns the base pair probability matrix of a single structure.
+func probabilities(t *testing.T, dotBracket string) [][]float64 {
+	matrix := make([][]float64, len(dotBracket))
+	for index := range matrix {
+		matrix[index] = make([]float64, len(dotBracket))
+	}
+	arcs, err := Arcs(dotBracket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, arc := range arcs {
+		matrix[arc.Open][arc.Close] = 1
+		matrix[arc.Close][arc.Open] = 1
+	}
+	return matrix
+}
+
+func TestProbabilityMountain(t *testing.T) {
+	// a structure's own probability matrix should give the same mountain.
+	want, _ := Mountain(cloverleaf)
+	got := ProbabilityMountain(probabilities(t, cloverleaf))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ProbabilityMountain = %v, want %v", got, want)
+	}
+
+	// two equally likely hairpins.
+	matrix := [][]float64{
+		{0, 0, 0, 0.5, 0.5},
+		{0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0},
+	}
+	if got := ProbabilityMountain(matrix); !reflect.DeepEqual(got, []float64{1, 1, 1, 1, 0.5}) {
+		t.Errorf("ProbabilityMountain = %v", got)
+	}
+}
+
+func TestProbabilityArcs(t *testing.T) {
+	matrix := [][]float64{
+		{0, 0, 0.1, 0.9},
+		{0, 0, 0, 0.3},
+		{0.1, 0, 0, 0},
+		{0.9, 0.3, 0, 0},
+	}
+	got := ProbabilityArcs(matrix, 0.2)
+	want := []Arc{{Open: 0, Close: 3, Weight: 0.9}, {Open: 1, Close: 3, Weight: 0.3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ProbabilityArcs = %v, want %v", got, want)
+	}
+}
+
+func TestArcSVG(t *testing.T) {
+	sequence := "GGGGAAACCCCAUA"
+	first, _ := Arcs("((((...))))...")
+	second, _ := Arcs("(((.....)))...")
+	svg, err := ArcSVG(sequence, [][]Arc{first, second}, Options{Labels: []string{"design <1>", "design 2"}, NumberEvery: 5, Title: "variants"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(svg, "</svg>\n") {
+		t.Errorf("not an SVG image:\n%s", svg)
+	}
+	if got := strings.Count(svg, `class="arc"`); got != 7 {
+		t.Errorf("expected 7 arcs, got %d", got)
+	}
+	if got := strings.Count(svg, `class="base"`); got != 2*len(sequence) {
+		t.Errorf("expected %d bases, got %d", 2*len(sequence), got)
+	}
+	if !strings.Contains(svg, "design &lt;1&gt;") {
+		t.Errorf("expected escaped label")
+	}
+
+	if _, err := ArcSVG("ACGU", [][]Arc{{{Open: 0, Close: 4, Weight: 1}}}, Options{}); err == nil {
+		t.Errorf("expected an error for an arc past the end of the sequence")
+	}
+	if _, err := ArcSVG("ACGU", [][]Arc{{{Open: 2, Close: 2, Weight: 1}}}, Options{}); err == nil {
+		t.Errorf("expected an error for an arc pairing a base with itself")
+	}
+	if _, err := ArcSVG("ACGU", [][]Arc{first}, Options{Labels: []string{"a", "b"}}); err == nil {
+		t.Errorf("expected an error for mismatched labels")
+	}
+}
+
+func TestMountainSVG(t *testing.T) {
+	first, _ := Mountain("((((...))))...")
+	second, _ := Mountain("(((.....)))...")
+	svg, err := MountainSVG([][]float64{first, second}, Options{Labels: []string{"first", "second"}, NumberEvery: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(svg, `class="mountain"`); got != 2 {
+		t.Errorf("expected 2 mountains, got %d", got)
+	}
+	if got := strings.Count(svg, `class="label"`); got != 2 {
+		t.Errorf("expected 2 labels, got %d", got)
+	}
+	if _, err := MountainSVG([][]float64{first}, Options{Labels: []string{}}); err == nil {
+		t.Errorf("expected an error for mismatched labels")
+	}
+	if svg, err := MountainSVG(nil, Options{}); err != nil || !strings.HasSuffix(svg, "</svg>\n") {
+		t.Errorf("expected an empty plot, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package plot_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bebop/poly/fold"
+	"github.com/bebop/poly/fold/plot"
+)
+
+func ExampleSVG() {
+	sequence := "ACCCCCUCCUUCCUUGGAUCAAGGGGCUCAA"
+	result, _ := fold.Zuker(sequence, 37.0)
+
+	svg, _ := plot.SVG(sequence, result.DotBracket(), plot.Options{NumberEvery: 10})
+	fmt.Println(strings.Count(svg, `class="base"`), strings.Count(svg, `class="pair"`))
+
+	// Output: 31 7
+}
+
+func ExampleLayout() {
+	points, _ := plot.Layout("((...))")
+	for _, point := range points[:3] {
+		fmt.Printf("%.2f %.2f\n", point.X, point.Y)
+	}
+
+	// Output:
+	// 0.00 0.00
+	// 1.00 0.00
+	// 1.95 -0.31
+}
+
+func ExampleMountain() {
+	heights, _ := plot.Mountain("((..))..")
+	fmt.Println(heights)
+
+	// Output: [1 2 2 2 2 1 0 0]
+}
+
+func ExampleArcSVG() {
+	sequence := "GGGGAAACCCCAUA"
+	first, _ := plot.Arcs("((((...))))...")
+	second, _ := plot.Arcs("(((.....)))...")
+
+	svg, _ := plot.ArcSVG(sequence, [][]plot.Arc{first, second}, plot.Options{Labels: []string{"design 1", "design 2"}})
+	fmt.Println(strings.Count(svg, `class="arc"`))
+
+	// Output: 7
+}
+
+func ExampleMountainSVG() {
+	first, _ := plot.Mountain("((((...))))...")
+	second, _ := plot.Mountain("(((.....)))...")
+
+	svg, _ := plot.MountainSVG([][]float64{first, second}, plot.Options{Labels: []string{"design 1", "design 2"}})
+	fmt.Println(strings.Count(svg, `class="mountain"`))
+
+	// Output: 2
+}
+
+func ExampleWriteSparse() {
+	probabilities := [][]float64{
+		{0, 0, 0, 0.8},
+		{0, 0, 0, 0},
+		{0, 0, 0, 0},
+		{0.8, 0, 0, 0},
+	}
+	var sparse strings.Builder
+	_ = plot.WriteSparse(&sparse, plot.ProbabilityArcs(probabilities, 0.01))
+	fmt.Print(sparse.String())
+
+	// Output: 1 4 0.8
+}
+
+func ExampleHeatmapSVG() {
+	arcs, _ := plot.ReadSparse(strings.NewReader("1 6 0.9\n2 5 0.7\n"))
+	probabilities, _ := plot.Matrix(6, arcs)
+
+	svg, _ := plot.HeatmapSVG("GGAACC", probabilities, plot.Options{})
+	fmt.Println(strings.Count(svg, `class="cell"`))
+
+	// Output: 4
+}
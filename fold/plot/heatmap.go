@@ -0,0 +1,188 @@
+package plot
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+)
+
+/******************************************************************************
+
+Base pair probability heatmaps begin here.
+
+Partition function folding doesn't return a single structure, it returns the
+probability of every possible base pair across the whole ensemble of
+structures. That's an n by n matrix, almost all of which is zero, so it's
+stored sparsely as one "i j probability" line per pair with 1-based
+positions, the same format as LinearPartition's and RNAfold's base pair
+probability output. ProbabilityArcs picks the pairs out of a matrix, and
+Matrix fills a matrix back in.
+
+The heatmap (or dot plot) is the classic way to look at an ensemble: every
+pair is a cell colored by its probability, so well defined helices show up
+as dark diagonals and alternative structures as fainter ones.
+
+******************************************************************************/
+
+// WriteSparse writes base pairs as "i j probability" lines with 1-based
+// positions.
+func WriteSparse(w io.Writer, arcs []Arc) error {
+	writer := bufio.NewWriter(w)
+	for _, arc := range arcs {
+		if _, err := fmt.Fprintf(writer, "%d %d %.4g\n", arc.Open+1, arc.Close+1, arc.Weight); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// ReadSparse reads base pairs written as "i j probability" lines with
+// 1-based positions. Blank lines and lines starting with # are skipped.
+func ReadSparse(r io.Reader) ([]Arc, error) {
+	var arcs []Arc
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected 3 fields, got %d", lineNumber, len(fields))
+		}
+		open, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		closing, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		probability, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		if open < 1 || closing <= open {
+			return nil, fmt.Errorf("line %d: invalid pair %d-%d", lineNumber, open, closing)
+		}
+		arcs = append(arcs, Arc{Open: open - 1, Close: closing - 1, Weight: probability})
+	}
+	return arcs, scanner.Err()
+}
+
+// Matrix returns the symmetric length by length base pair probability
+// matrix of arcs.
+func Matrix(length int, arcs []Arc) ([][]float64, error) {
+	matrix := make([][]float64, length)
+	for index := range matrix {
+		matrix[index] = make([]float64, length)
+	}
+	for _, arc := range arcs {
+		if arc.Open < 0 || arc.Close >= length || arc.Open >= arc.Close {
+			return nil, fmt.Errorf("pair %d-%d doesn't fit a sequence of length %d", arc.Open+1, arc.Close+1, length)
+		}
+		matrix[arc.Open][arc.Close] = arc.Weight
+		matrix[arc.Close][arc.Open] = arc.Weight
+	}
+	return matrix, nil
+}
+
+// heatmapCutoff is the lowest probability drawn by HeatmapSVG, which keeps
+// images of long sequences from filling up with invisible cells.
+const heatmapCutoff = 1e-3
+
+// HeatmapSVG draws a base pair probability matrix as a heatmap, with sequence
+// written along the top and left edges. sequence may be empty.
+func HeatmapSVG(sequence string, probabilities [][]float64, options Options) (string, error) {
+	length := len(probabilities)
+	if err := checkSquare(probabilities); err != nil {
+		return "", err
+	}
+	if sequence != "" && len(sequence) != length {
+		return "", fmt.Errorf("got a %d by %d matrix for a sequence of length %d", length, length, len(sequence))
+	}
+
+	margin := 2 * scale
+	top := margin
+	if options.Title != "" {
+		top += scale
+	}
+	size := float64(length) * arcSpacing
+	width := size + 2*margin
+	height := size + top + margin
+
+	var svg strings.Builder
+	writeHeader(&svg, width, height, margin, options.Title)
+	fmt.Fprintf(&svg, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="none" stroke="#888"/>`+"\n", margin, top, size, size)
+	for row := range probabilities {
+		for column, probability := range probabilities[row] {
+			if probability < heatmapCutoff {
+				continue
+			}
+			fmt.Fprintf(&svg, `<rect class="cell" x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`+"\n", margin+float64(column)*arcSpacing, top+float64(row)*arcSpacing, arcSpacing, arcSpacing, heatColor(probability))
+		}
+	}
+	for index := 0; index < len(sequence); index++ {
+		center := float64(index)*arcSpacing + arcSpacing/2
+		fmt.Fprintf(&svg, `<text x="%.1f" y="%.1f" text-anchor="middle" font-size="8">%s</text>`+"\n", margin+center, top-2, escape(sequence[index:index+1]))
+		fmt.Fprintf(&svg, `<text x="%.1f" y="%.1f" text-anchor="end" dominant-baseline="central" font-size="8">%s</text>`+"\n", margin-2, top+center, escape(sequence[index:index+1]))
+	}
+	svg.WriteString("</svg>\n")
+	return svg.String(), nil
+}
+
+// HeatmapPNG writes a base pair probability matrix as a PNG heatmap with
+// cellSize by cellSize pixels per pair.
+func HeatmapPNG(w io.Writer, probabilities [][]float64, cellSize int) error {
+	if err := checkSquare(probabilities); err != nil {
+		return err
+	}
+	if cellSize < 1 {
+		return fmt.Errorf("cell size must be positive, got %d", cellSize)
+	}
+	size := len(probabilities) * cellSize
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for row := range probabilities {
+		for column, probability := range probabilities[row] {
+			cellColor := heatRGBA(probability)
+			for y := row * cellSize; y < (row+1)*cellSize; y++ {
+				for x := column * cellSize; x < (column+1)*cellSize; x++ {
+					img.SetRGBA(x, y, cellColor)
+				}
+			}
+		}
+	}
+	return png.Encode(w, img)
+}
+
+// checkSquare makes sure probabilities is a square matrix.
+func checkSquare(probabilities [][]float64) error {
+	for row, values := range probabilities {
+		if len(values) != len(probabilities) {
+			return fmt.Errorf("row %d of probability matrix has %d values, expected %d", row+1, len(values), len(probabilities))
+		}
+	}
+	return nil
+}
+
+// heatRGBA shades from white at probability 0 to dark blue at probability 1.
+func heatRGBA(probability float64) color.RGBA {
+	probability = min(max(probability, 0), 1)
+	return color.RGBA{
+		R: uint8(255 - probability*(255-8)),
+		G: uint8(255 - probability*(255-48)),
+		B: uint8(255 - probability*(255-107)),
+		A: 255,
+	}
+}
+
+// heatColor is heatRGBA as an SVG color.
+func heatColor(probability float64) string {
+	heat := heatRGBA(probability)
+	return fmt.Sprintf("#%02x%02x%02x", heat.R, heat.G, heat.B)
+}
@@ -0,0 +1,90 @@
+package plot
+
+import (
+	"bytes"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSparseRoundTrip(t *testing.T) {
+	arcs := []Arc{{Open: 0, Close: 9, Weight: 0.95}, {Open: 1, Close: 8, Weight: 0.5}, {Open: 2, Close: 4, Weight: 0.0012}}
+	var buffer bytes.Buffer
+	if err := WriteSparse(&buffer, arcs); err != nil {
+		t.Fatal(err)
+	}
+	if buffer.String() != "1 10 0.95\n2 9 0.5\n3 5 0.0012\n" {
+		t.Errorf("unexpected sparse output:\n%s", buffer.String())
+	}
+	got, err := ReadSparse(strings.NewReader("# comment\n\n" + buffer.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, arcs) {
+		t.Errorf("ReadSparse = %v, want %v", got, arcs)
+	}
+
+	matrix, err := Matrix(10, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matrix[1][8] != 0.5 || matrix[8][1] != 0.5 {
+		t.Errorf("Matrix should be symmetric, got %f and %f", matrix[1][8], matrix[8][1])
+	}
+	if !reflect.DeepEqual(ProbabilityArcs(matrix, 0), arcs) {
+		t.Errorf("ProbabilityArcs(Matrix(arcs)) should give back arcs")
+	}
+}
+
+func TestReadSparseErrors(t *testing.T) {
+	for _, input := range []string{"1 2", "a 2 0.5", "1 b 0.5", "1 2 c", "0 2 0.5", "3 2 0.5"} {
+		if _, err := ReadSparse(strings.NewReader(input)); err == nil {
+			t.Errorf("ReadSparse(%q) should have failed", input)
+		}
+	}
+	if _, err := Matrix(3, []Arc{{Open: 0, Close: 3, Weight: 1}}); err == nil {
+		t.Errorf("Matrix should fail on pairs past the end of the sequence")
+	}
+}
+
+func TestHeatmapSVG(t *testing.T) {
+	matrix, _ := Matrix(6, []Arc{{Open: 0, Close: 5, Weight: 0.9}, {Open: 1, Close: 4, Weight: 0.0001}})
+	svg, err := HeatmapSVG("GGAACC", matrix, Options{Title: "ensemble"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(svg, `class="cell"`); got != 2 {
+		t.Errorf("expected 2 cells above the cutoff, got %d", got)
+	}
+	if _, err := HeatmapSVG("GGAAC", matrix, Options{}); err == nil {
+		t.Errorf("expected an error for a sequence that doesn't match the matrix")
+	}
+	if _, err := HeatmapSVG("", [][]float64{{0, 1}, {1}}, Options{}); err == nil {
+		t.Errorf("expected an error for a ragged matrix")
+	}
+}
+
+func TestHeatmapPNG(t *testing.T) {
+	matrix, _ := Matrix(4, []Arc{{Open: 0, Close: 3, Weight: 1}})
+	var buffer bytes.Buffer
+	if err := HeatmapPNG(&buffer, matrix, 3); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 12 || img.Bounds().Dy() != 12 {
+		t.Errorf("expected a 12x12 image, got %v", img.Bounds())
+	}
+	if r, g, b, _ := img.At(10, 1).RGBA(); r>>8 != 8 || g>>8 != 48 || b>>8 != 107 {
+		t.Errorf("expected a fully paired cell to be dark blue, got %d %d %d", r>>8, g>>8, b>>8)
+	}
+	if r, _, _, _ := img.At(1, 1).RGBA(); r>>8 != 255 {
+		t.Errorf("expected an unpaired cell to be white")
+	}
+	if err := HeatmapPNG(&buffer, matrix, 0); err == nil {
+		t.Errorf("expected an error for a zero cell size")
+	}
+}
@@ -0,0 +1,311 @@
+/*
+Package plot draws nucleic acid secondary structures.
+
+Dot-bracket strings are great for computers and terrible for people. Quick,
+is this a cloverleaf?
+
+	(((((((..((((........)))).((((.........)))).....(((((.......))))))))))))....
+
+Drawing the structure answers that at a glance, which is what you want when
+you're comparing designs in a report or a notebook.
+
+Structure lays out a dot-bracket structure with the same simple loop based
+algorithm as ViennaRNA's RNAplot: every loop is drawn as a regular polygon
+and every helix as a straight ladder between them. It never draws helices on
+top of each other for nested structures, and is fast enough to run on
+thousands of designs. Layout returns the raw coordinates if you'd like to
+draw them yourself, and SVG renders them with colored bases, base pair bonds,
+and position numbers.
+
+Pseudoknotted pairs (written with [], {}, or <> brackets) don't fit in a
+planar layout, so they are laid out as if unpaired and drawn as extra bonds
+on top.
+
+For comparing structures, say the predicted folds of a handful of design
+variants, ArcSVG stacks arc diagrams of each structure and MountainSVG
+overlays their mountain plots. Both work from dot-bracket structures or from
+base pair probabilities, and Mountain returns the plain numbers if you'd
+rather compare them yourself.
+
+Layout algorithm adapted from:
+Bruccoleri and Heinrich, 1988
+https://doi.org/10.1016/0097-8485(88)85015-7
+and ViennaRNA's simple_xy_coordinates (https://github.com/ViennaRNA/ViennaRNA)
+*/
+package plot
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/bebop/poly/fold/structure"
+)
+
+// Point is a position in a structure drawing.
+type Point struct {
+	X, Y float64
+}
+
+// Options changes how SVG draws a structure.
+type Options struct {
+	// Colors maps bases to fill colors. Bases missing from the map are drawn
+	// white. Defaults to DefaultColors when nil.
+	Colors map[byte]string
+	// PositionColors, if not nil, overrides Colors with one fill color per
+	// position, which is handy for coloring by reactivity or pairing probability.
+	PositionColors []string
+	// NumberEvery labels every nth position (and the first). Zero disables numbering.
+	NumberEvery int
+	// Title is drawn above the structure if not empty.
+	Title string
+	// Labels names each structure or series of ArcSVG and MountainSVG.
+	Labels []string
+}
+
+// DefaultColors is a forna-like coloring of nucleotides.
+var DefaultColors = map[byte]string{
+	'A': "#f7c480", 'a': "#f7c480",
+	'C': "#9bd3f5", 'c': "#9bd3f5",
+	'G': "#a8e0a1", 'g': "#a8e0a1",
+	'U': "#f59b9b", 'u': "#f59b9b",
+	'T': "#f59b9b", 't': "#f59b9b",
+}
+
+// pairTables returns the partner of every position in dotBracket (-1 for
+// unpaired). nested holds only () pairs, which are laid out, while all holds
+// every pair including pseudoknots.
+func pairTables(dotBracket string) (nested, all []int, err error) {
+	all, err = structure.PairTable(dotBracket)
+	if err != nil {
+		return nil, nil, err
+	}
+	nested, err = structure.NestedPairTable(dotBracket)
+	return nested, all, err
+}
+
+/******************************************************************************
+
+Layout begins here.
+
+******************************************************************************/
+
+// layout holds the state of the recursive loop layout.
+type layout struct {
+	pairs []int     // 1-based pair table, pairs[0] is the length, 0 is unpaired.
+	angle []float64 // bending angle at every position.
+}
+
+// Layout returns the position of every base of a dot-bracket structure, one
+// unit apart along the backbone.
+func Layout(dotBracket string) ([]Point, error) {
+	nested, _, err := pairTables(dotBracket)
+	if err != nil {
+		return nil, err
+	}
+	length := len(dotBracket)
+	if length == 0 {
+		return []Point{}, nil
+	}
+
+	state := layout{
+		pairs: make([]int, length+2),
+		angle: make([]float64, length+5),
+	}
+	state.pairs[0] = length
+	for index, partner := range nested {
+		if partner >= 0 {
+			state.pairs[index+1] = partner + 1
+		}
+	}
+	state.loop(0, length+1)
+
+	points := make([]Point, length)
+	alpha := 0.0
+	for index := 1; index < length; index++ {
+		points[index].X = points[index-1].X + math.Cos(alpha)
+		points[index].Y = points[index-1].Y + math.Sin(alpha)
+		alpha += math.Pi - state.angle[index+1]
+	}
+	return points, nil
+}
+
+// loop lays out the loop closed by the pair (start-1, end+1), adding the
+// bending angle of each position to state.angle and recursing into every
+// helix that branches off of it.
+func (state *layout) loop(start, end int) {
+	vertices := 2 // the closing pair counts as two vertices of the loop polygon.
+	remember := []int{0}
+	previous := start - 1
+	position := start
+	end++
+	for position != end {
+		partner := state.pairs[position]
+		if partner == 0 || position == 0 {
+			position++
+			vertices++
+			continue
+		}
+		vertices += 2
+		left, right := position, partner
+		remember = append(remember, left, right)
+		position = partner + 1
+
+		helixStartLeft, helixStartRight := left, right
+		ladder := 0
+		for {
+			left, right, ladder = left+1, right-1, ladder+1
+			if state.pairs[left] != right || state.pairs[left] <= left {
+				break
+			}
+		}
+		fill := ladder - 2
+		if ladder >= 2 {
+			// helix entries and exits get an extra quarter turn.
+			state.angle[helixStartLeft+1+fill] += math.Pi / 2
+			state.angle[helixStartRight-1-fill] += math.Pi / 2
+			state.angle[helixStartLeft] += math.Pi / 2
+			state.angle[helixStartRight] += math.Pi / 2
+			for ; fill >= 1; fill-- {
+				state.angle[helixStartLeft+fill] = math.Pi
+				state.angle[helixStartRight-fill] = math.Pi
+			}
+		}
+		if left <= right {
+			state.loop(left, right)
+		}
+	}
+
+	polygon := math.Pi * float64(vertices-2) / float64(vertices)
+	remember = append(remember, end)
+	begin := max(previous, 0)
+	for index := 1; index < len(remember); index += 2 {
+		for fill := 0; fill <= remember[index]-begin; fill++ {
+			state.angle[begin+fill] += polygon
+		}
+		if index+1 < len(remember) {
+			begin = remember[index+1]
+		}
+	}
+}
+
+/******************************************************************************
+
+SVG drawing begins here.
+
+******************************************************************************/
+
+// scale is the distance between neighboring bases in SVG units.
+const scale = 20.0
+
+// SVG draws a sequence folded into a dot-bracket structure as an SVG image.
+// Structures shorter than the sequence are padded with unpaired positions,
+// since fold.Result.DotBracket stops at the last paired base.
+func SVG(sequence, dotBracket string, options Options) (string, error) {
+	if len(dotBracket) > len(sequence) {
+		return "", fmt.Errorf("dot-bracket structure is longer (%d) than sequence (%d)", len(dotBracket), len(sequence))
+	}
+	dotBracket += strings.Repeat(".", len(sequence)-len(dotBracket))
+	if options.PositionColors != nil && len(options.PositionColors) != len(sequence) {
+		return "", fmt.Errorf("got %d position colors for a sequence of length %d", len(options.PositionColors), len(sequence))
+	}
+	points, err := Layout(dotBracket)
+	if err != nil {
+		return "", err
+	}
+	_, pairs, _ := pairTables(dotBracket)
+	colors := options.Colors
+	if colors == nil {
+		colors = DefaultColors
+	}
+
+	// scale and translate points into a positive viewport with a margin.
+	minimum := Point{math.Inf(1), math.Inf(1)}
+	maximum := Point{math.Inf(-1), math.Inf(-1)}
+	for _, point := range points {
+		minimum.X, minimum.Y = math.Min(minimum.X, point.X), math.Min(minimum.Y, point.Y)
+		maximum.X, maximum.Y = math.Max(maximum.X, point.X), math.Max(maximum.Y, point.Y)
+	}
+	margin := 2 * scale
+	top := margin
+	if options.Title != "" {
+		top += scale
+	}
+	for index := range points {
+		points[index].X = (points[index].X-minimum.X)*scale + margin
+		points[index].Y = (points[index].Y-minimum.Y)*scale + top
+	}
+	width := (maximum.X-minimum.X)*scale + 2*margin
+	height := (maximum.Y-minimum.Y)*scale + top + margin
+	if len(points) == 0 {
+		width, height = 2*margin, top+margin
+	}
+
+	var svg strings.Builder
+	writeHeader(&svg, width, height, margin, options.Title)
+
+	// backbone
+	if len(points) > 1 {
+		svg.WriteString(`<polyline fill="none" stroke="#888" stroke-width="2" points="`)
+		for index, point := range points {
+			if index > 0 {
+				svg.WriteByte(' ')
+			}
+			fmt.Fprintf(&svg, "%.1f,%.1f", point.X, point.Y)
+		}
+		svg.WriteString("\"/>\n")
+	}
+
+	// base pairs
+	for index, partner := range pairs {
+		if partner > index {
+			dash := ""
+			if dotBracket[index] != '(' {
+				dash = ` stroke-dasharray="4,3"`
+			}
+			fmt.Fprintf(&svg, `<line class="pair" x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#444" stroke-width="2"%s/>`+"\n", points[index].X, points[index].Y, points[partner].X, points[partner].Y, dash)
+		}
+	}
+
+	// bases
+	for index, point := range points {
+		color, ok := colors[sequence[index]]
+		if !ok {
+			color = "white"
+		}
+		if options.PositionColors != nil {
+			color = options.PositionColors[index]
+		}
+		fmt.Fprintf(&svg, `<circle class="base" cx="%.1f" cy="%.1f" r="%.1f" fill="%s" stroke="#444"/>`+"\n", point.X, point.Y, scale*0.45, escape(color))
+		fmt.Fprintf(&svg, `<text x="%.1f" y="%.1f" text-anchor="middle" dominant-baseline="central" font-size="12">%s</text>`+"\n", point.X, point.Y, escape(sequence[index:index+1]))
+	}
+
+	// numbering, placed just outside the base away from the structure's center.
+	if options.NumberEvery > 0 {
+		var center Point
+		for _, point := range points {
+			center.X += point.X / float64(len(points))
+			center.Y += point.Y / float64(len(points))
+		}
+		for index, point := range points {
+			if index != 0 && (index+1)%options.NumberEvery != 0 {
+				continue
+			}
+			directionX, directionY := point.X-center.X, point.Y-center.Y
+			distance := math.Hypot(directionX, directionY)
+			if distance == 0 {
+				directionX, distance = 1, 1
+			}
+			labelX := point.X + directionX/distance*scale
+			labelY := point.Y + directionY/distance*scale
+			fmt.Fprintf(&svg, `<text class="number" x="%.1f" y="%.1f" text-anchor="middle" dominant-baseline="central" font-size="10" fill="#666">%d</text>`+"\n", labelX, labelY, index+1)
+		}
+	}
+	svg.WriteString("</svg>\n")
+	return svg.String(), nil
+}
+
+// escape escapes text for use in SVG attributes and elements.
+func escape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(text)
+}
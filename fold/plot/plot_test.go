@@ -0,0 +1,129 @@
+package plot
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/fold/structure"
+)
+
+// a tRNA-like cloverleaf.
+const cloverleaf = "(((((((..((((........)))).(((((.......))))).....(((((.......))))))))))))...."
+
+func distance(a, b Point) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
+
+func TestLayout(t *testing.T) {
+	structures := []string{
+		cloverleaf,
+		"((((....))))",
+		"..((((....))))..((((....))))..",
+		"(((..((...))..((...))..)))",
+		"((..((...))))",
+		"......",
+		"((((((((....))))))))",
+		"(((...[[[...)))...]]]",
+	}
+	for _, structure := range structures {
+		points, err := Layout(structure)
+		if err != nil {
+			t.Fatalf("Layout(%s) returned error: %s", structure, err)
+		}
+		if len(points) != len(structure) {
+			t.Fatalf("Layout(%s) returned %d points", structure, len(points))
+		}
+		nested, _, _ := pairTables(structure)
+		for index := range points {
+			// neighbors along the backbone are always one unit apart.
+			if index > 0 {
+				if gap := distance(points[index-1], points[index]); math.Abs(gap-1) > 1e-9 {
+					t.Errorf("Layout(%s): backbone gap between %d and %d is %f", structure, index, index+1, gap)
+				}
+			}
+			// as are paired bases, since each pair is an edge of a loop polygon.
+			if partner := nested[index]; partner > index {
+				if gap := distance(points[index], points[partner]); math.Abs(gap-1) > 1e-6 {
+					t.Errorf("Layout(%s): pair %d-%d is %f apart", structure, index+1, partner+1, gap)
+				}
+			}
+			// and no two bases sit on top of each other.
+			for other := index + 1; other < len(points); other++ {
+				if gap := distance(points[index], points[other]); gap < 0.5 {
+					t.Errorf("Layout(%s): bases %d and %d overlap (%f apart)", structure, index+1, other+1, gap)
+				}
+			}
+		}
+	}
+}
+
+func TestLayoutErrors(t *testing.T) {
+	for _, structure := range []string{"((..)", "(..))", "((..]]", "..?.."} {
+		if _, err := Layout(structure); err == nil {
+			t.Errorf("Layout(%s) should have failed", structure)
+		}
+	}
+	if _, err := Layout("(()"); !errors.Is(err, structure.ErrUnbalanced) {
+		t.Errorf("expected structure.ErrUnbalanced, got %v", err)
+	}
+	if points, err := Layout(""); err != nil || len(points) != 0 {
+		t.Errorf("expected no points for an empty structure, got %v, %v", points, err)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	sequence := "GGGGAAACCCCAUA"
+	svg, err := SVG(sequence, "((((...))))", Options{NumberEvery: 5, Title: "hairpin <1>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(svg, "</svg>\n") {
+		t.Errorf("SVG is not a complete svg element")
+	}
+	counts := map[string]int{
+		`class="pair"`:      4,
+		`class="base"`:      len(sequence),
+		`class="number"`:    3, // 1, 5, and 10
+		`fill="#a8e0a1"`:    4, // four Gs
+		"hairpin &lt;1&gt;": 1,
+	}
+	for substring, expected := range counts {
+		if got := strings.Count(svg, substring); got != expected {
+			t.Errorf("expected %d of %q in SVG, got %d", expected, substring, got)
+		}
+	}
+
+	pseudoknotted, err := SVG("GGGAAACCCAAAGGG", "(((...[[[)))]]]", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(pseudoknotted, `stroke-dasharray`); got != 3 {
+		t.Errorf("expected 3 dashed pseudoknot pairs, got %d", got)
+	}
+
+	colors := make([]string, len(sequence))
+	for index := range colors {
+		colors[index] = "red"
+	}
+	colored, err := SVG(sequence, "", Options{PositionColors: colors})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(colored, `fill="red"`); got != len(sequence) {
+		t.Errorf("expected every base to be red, got %d", got)
+	}
+}
+
+func TestSVGErrors(t *testing.T) {
+	if _, err := SVG("GGG", "((....))", Options{}); err == nil {
+		t.Errorf("expected an error for a structure longer than the sequence")
+	}
+	if _, err := SVG("GGGAAACCC", "(((...)))", Options{PositionColors: []string{"red"}}); err == nil {
+		t.Errorf("expected an error for the wrong number of position colors")
+	}
+	if _, err := SVG("GGGAAACCC", "((....)))", Options{}); err == nil {
+		t.Errorf("expected an error for an unbalanced structure")
+	}
+}
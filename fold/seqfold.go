@@ -141,11 +141,17 @@ type context struct {
 	pairedMinimumFreeEnergyV   [][]nucleicAcidStructure
 	unpairedMinimumFreeEnergyW [][]nucleicAcidStructure
 	temp                       float64
+	constraints                *constraintTable // nil when folding without constraints.
 }
 
 // newFoldingContext returns a context ready to use, in case of error
 // the returned FoldingContext is empty.
 func newFoldingContext(seq string, temp float64) (context, error) {
+	return newConstrainedFoldingContext(seq, temp, nil)
+}
+
+// newConstrainedFoldingContext is newFoldingContext with folding constraints.
+func newConstrainedFoldingContext(seq string, temp float64, constraints *constraintTable) (context, error) {
 	seq = strings.ToUpper(seq)
 
 	// figure out whether it's DNA or rna, choose energy map
@@ -181,6 +187,7 @@ func newFoldingContext(seq string, temp float64) (context, error) {
 		pairedMinimumFreeEnergyV:   vCache,
 		unpairedMinimumFreeEnergyW: wCache,
 		temp:                       temp + 273.15, // kelvin
+		constraints:                constraints,
 	}
 
 	// fill the cache
@@ -193,7 +200,8 @@ func newFoldingContext(seq string, temp float64) (context, error) {
 
 // Result holds the resulting structures of the folded s
 type Result struct {
-	structs []nucleicAcidStructure
+	structs      []nucleicAcidStructure
+	energyOffset float64 // pseudo-energy of constraints not attributed to any structure.
 }
 
 // DotBracket returns the dot-bracket notation of the secondary nucleic acid
@@ -206,7 +214,7 @@ func (r Result) DotBracket() string {
 	if len(r.structs) == 0 {
 		return ""
 	}
-	lastStructEnd := 0
+	lastStructEnd := -1
 	for _, structure := range r.structs {
 		for _, innerSubsequence := range structure.inner {
 			if innerSubsequence.end > lastStructEnd {
@@ -214,6 +222,10 @@ func (r Result) DotBracket() string {
 			}
 		}
 	}
+	// an open chain has nothing paired.
+	if lastStructEnd < 0 {
+		return ""
+	}
 	lastStructEnd += 1
 	result := make([]byte, lastStructEnd)
 	for i := range result {
@@ -239,7 +251,7 @@ func (r Result) MinimumFreeEnergy() float64 {
 		return math.Inf(1)
 	}
 
-	summedEnergy := 0.0
+	summedEnergy := r.energyOffset
 	for _, structure := range r.structs {
 		summedEnergy += structure.energy
 	}
@@ -0,0 +1,58 @@
+package structure_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/fold/structure"
+)
+
+func ExamplePairTable() {
+	pairs, _ := structure.PairTable("((..[[))..]]")
+	fmt.Println(pairs)
+
+	// Output: [7 6 -1 -1 11 10 1 0 -1 -1 5 4]
+}
+
+func ExampleDotBracket() {
+	dotBracket, _ := structure.DotBracket([]int{7, 6, -1, -1, 11, 10, 1, 0, -1, -1, 5, 4})
+	fmt.Println(dotBracket)
+
+	// Output: ((..[[))..]]
+}
+
+func ExampleDistance() {
+	distance, _ := structure.Distance("((((....))))", ".(((....))).")
+	fmt.Println(distance)
+
+	// Output: 1
+}
+
+func ExampleTree() {
+	root, _ := structure.Tree("((..((...))..((...))))")
+	root.Walk(func(loop *structure.Loop) bool {
+		if loop.Kind != structure.Exterior {
+			fmt.Println(loop.Kind, loop.Open+1, loop.Close+1)
+		}
+		return true
+	})
+
+	// Output:
+	// stack 1 22
+	// multi 2 21
+	// stack 5 11
+	// hairpin 6 10
+	// stack 14 20
+	// hairpin 15 19
+}
+
+func ExampleUnpairedProbabilities() {
+	probabilities := [][]float64{
+		{0, 0, 0, 0.9},
+		{0, 0, 0, 0},
+		{0, 0, 0, 0},
+		{0.9, 0, 0, 0},
+	}
+	fmt.Printf("%.1f\n", structure.UnpairedProbabilities(probabilities))
+
+	// Output: [0.1 1.0 1.0 0.1]
+}
@@ -0,0 +1,326 @@
+/*
+Package structure contains utilities for working with RNA secondary structures.
+
+Folding algorithms hand back structures as dot-bracket strings, where every
+base pair is a matching set of brackets and every unpaired base is a dot:
+
+	((((....))))..((((....))))
+
+That's a fine way to write a structure down, but almost anything you'd like
+to do with one, like comparing two designs, finding the hairpins, or
+scoring each loop, is easier with the structure in another shape. This
+package converts dot-brackets to pair tables (the partner of every base) and
+back, measures how different two structures are, and breaks a structure down
+into a tree of loops.
+
+Pseudoknots are written with [], {}, and <> brackets on top of the nested
+() pairs:
+
+	(((..[[[..)))..]]]
+
+Pair tables and distances include every pair, pseudoknots and all. Loops
+only make sense for nested structures, so Tree only uses the () pairs.
+*/
+package structure
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnbalanced is returned for dot-bracket structures with brackets that
+// don't pair up.
+var ErrUnbalanced = errors.New("unbalanced brackets in dot-bracket structure")
+
+// brackets lists the opening and closing bracket of every pseudoknot layer,
+// nested pairs first.
+var brackets = [][2]byte{{'(', ')'}, {'[', ']'}, {'{', '}'}, {'<', '>'}}
+
+// openingBrackets maps each closing bracket to its opening bracket.
+var openingBrackets = map[byte]byte{')': '(', ']': '[', '}': '{', '>': '<'}
+
+// PairTable returns the 0-based partner of every position in dotBracket, or
+// -1 for unpaired positions. Pairs written with any bracket are included.
+func PairTable(dotBracket string) ([]int, error) {
+	pairs, _, err := parse(dotBracket)
+	return pairs, err
+}
+
+// NestedPairTable is like PairTable, but only includes pairs written with (),
+// leaving out pseudoknots.
+func NestedPairTable(dotBracket string) ([]int, error) {
+	_, nested, err := parse(dotBracket)
+	return nested, err
+}
+
+// parse reads every pair of dotBracket into pairs, and the () pairs into nested.
+func parse(dotBracket string) (pairs, nested []int, err error) {
+	pairs = make([]int, len(dotBracket))
+	nested = make([]int, len(dotBracket))
+	stacks := make(map[byte][]int)
+	for index := 0; index < len(dotBracket); index++ {
+		pairs[index], nested[index] = -1, -1
+		symbol := dotBracket[index]
+		switch symbol {
+		case '(', '[', '{', '<':
+			stacks[symbol] = append(stacks[symbol], index)
+		case ')', ']', '}', '>':
+			opening := openingBrackets[symbol]
+			stack := stacks[opening]
+			if len(stack) == 0 {
+				return nil, nil, fmt.Errorf("%w: unmatched %q at position %d", ErrUnbalanced, symbol, index+1)
+			}
+			partner := stack[len(stack)-1]
+			stacks[opening] = stack[:len(stack)-1]
+			pairs[index], pairs[partner] = partner, index
+			if symbol == ')' {
+				nested[index], nested[partner] = partner, index
+			}
+		case '.', ',', ':', '_', '-', 'x':
+		default:
+			return nil, nil, fmt.Errorf("unexpected %q at position %d of dot-bracket structure", symbol, index+1)
+		}
+	}
+	for _, bracket := range brackets {
+		if stack := stacks[bracket[0]]; len(stack) > 0 {
+			return nil, nil, fmt.Errorf("%w: unmatched %q at position %d", ErrUnbalanced, bracket[0], stack[0]+1)
+		}
+	}
+	return pairs, nested, nil
+}
+
+// DotBracket writes a pair table as a dot-bracket structure. Pairs that
+// cross are written as pseudoknots with [], {}, and <> brackets, filling
+// each layer from left to right.
+func DotBracket(pairs []int) (string, error) {
+	if err := checkPairTable(pairs); err != nil {
+		return "", err
+	}
+	dotBracket := []byte(strings.Repeat(".", len(pairs)))
+	// open pairs of each bracket layer, as a stack of closing positions.
+	layers := make([][]int, len(brackets))
+	for index, partner := range pairs {
+		for layer := range layers {
+			// pairs closed before this position no longer constrain anything.
+			for len(layers[layer]) > 0 && layers[layer][len(layers[layer])-1] < index {
+				layers[layer] = layers[layer][:len(layers[layer])-1]
+			}
+		}
+		if partner <= index {
+			continue
+		}
+		placed := false
+		for layer := range layers {
+			stack := layers[layer]
+			// a pair fits in a layer if it closes before every open pair of that layer.
+			if len(stack) == 0 || partner < stack[len(stack)-1] {
+				layers[layer] = append(stack, partner)
+				dotBracket[index], dotBracket[partner] = brackets[layer][0], brackets[layer][1]
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			return "", fmt.Errorf("pair %d-%d needs more than %d bracket types", index+1, partner+1, len(brackets))
+		}
+	}
+	return string(dotBracket), nil
+}
+
+// checkPairTable makes sure every pair in a pair table points back at itself.
+func checkPairTable(pairs []int) error {
+	for index, partner := range pairs {
+		if partner == -1 {
+			continue
+		}
+		if partner < 0 || partner >= len(pairs) || partner == index || pairs[partner] != index {
+			return fmt.Errorf("position %d of pair table pairs with %d, which doesn't pair back", index+1, partner+1)
+		}
+	}
+	return nil
+}
+
+// Distance returns the base pair distance between two structures of the same
+// length: the number of pairs found in one structure but not the other.
+func Distance(first, second string) (int, error) {
+	if len(first) != len(second) {
+		return 0, fmt.Errorf("structures have different lengths (%d and %d)", len(first), len(second))
+	}
+	firstPairs, err := PairTable(first)
+	if err != nil {
+		return 0, err
+	}
+	secondPairs, err := PairTable(second)
+	if err != nil {
+		return 0, err
+	}
+	distance := 0
+	for index := range firstPairs {
+		if firstPairs[index] == secondPairs[index] {
+			continue
+		}
+		if firstPairs[index] > index {
+			distance++
+		}
+		if secondPairs[index] > index {
+			distance++
+		}
+	}
+	return distance, nil
+}
+
+// UnpairedProbabilities returns the probability that each position is
+// unpaired, often called its accessibility, from a base pair probability
+// matrix. Only the upper triangle of probabilities (probabilities[i][j] with
+// i < j) is read.
+func UnpairedProbabilities(probabilities [][]float64) []float64 {
+	unpaired := make([]float64, len(probabilities))
+	for index := range unpaired {
+		unpaired[index] = 1
+	}
+	for open, row := range probabilities {
+		for closing := open + 1; closing < len(row) && closing < len(unpaired); closing++ {
+			unpaired[open] -= row[closing]
+			unpaired[closing] -= row[closing]
+		}
+	}
+	for index, probability := range unpaired {
+		// rounding can push fully paired positions just below zero.
+		unpaired[index] = max(probability, 0)
+	}
+	return unpaired
+}
+
+/******************************************************************************
+
+Loop decomposition begins here.
+
+Every nested structure breaks down into loops, each closed by one base pair
+and containing the unpaired bases and pairs directly inside of it. This is
+the same decomposition nearest neighbor energy models score, so it's where
+you'd start if you wanted to know which hairpin is costing you stability.
+
+The loops nest into a tree. The root is the exterior loop, which isn't closed
+by any pair, and each loop's children are the loops closed by the pairs
+directly inside of it.
+
+******************************************************************************/
+
+// LoopKind is the kind of a loop, based on how many pairs and unpaired bases
+// it contains.
+type LoopKind int
+
+const (
+	// Exterior is the loop outside of every pair.
+	Exterior LoopKind = iota
+	// Hairpin loops are closed by a pair and contain no other pairs.
+	Hairpin
+	// Stack loops are two pairs directly on top of each other.
+	Stack
+	// Bulge loops are two pairs with unpaired bases on only one side.
+	Bulge
+	// Interior loops are two pairs with unpaired bases on both sides.
+	Interior
+	// Multi loops are closed by a pair and contain two or more other pairs.
+	Multi
+)
+
+// String returns the name of a loop kind.
+func (kind LoopKind) String() string {
+	switch kind {
+	case Exterior:
+		return "exterior"
+	case Hairpin:
+		return "hairpin"
+	case Stack:
+		return "stack"
+	case Bulge:
+		return "bulge"
+	case Interior:
+		return "interior"
+	case Multi:
+		return "multi"
+	}
+	return fmt.Sprintf("LoopKind(%d)", int(kind))
+}
+
+// Loop is a single loop of a structure. Open and Close are the 0-based
+// positions of the pair closing the loop, which are -1 and the length of the
+// structure for the exterior loop.
+type Loop struct {
+	Kind        LoopKind
+	Open, Close int
+	Unpaired    []int   // unpaired positions directly inside the loop.
+	Children    []*Loop // loops closed by the pairs directly inside this loop.
+}
+
+// Tree breaks the nested () pairs of a dot-bracket structure down into a
+// tree of loops, rooted at the exterior loop.
+func Tree(dotBracket string) (*Loop, error) {
+	nested, err := NestedPairTable(dotBracket)
+	if err != nil {
+		return nil, err
+	}
+	return loop(nested, -1, len(nested)), nil
+}
+
+// loop builds the loop closed by the pair (open, closing) and all of its children.
+func loop(pairs []int, open, closing int) *Loop {
+	current := &Loop{Open: open, Close: closing}
+	for index := open + 1; index < closing; index++ {
+		if pairs[index] == -1 {
+			current.Unpaired = append(current.Unpaired, index)
+			continue
+		}
+		current.Children = append(current.Children, loop(pairs, index, pairs[index]))
+		index = pairs[index]
+	}
+
+	switch {
+	case open == -1:
+		current.Kind = Exterior
+	case len(current.Children) == 0:
+		current.Kind = Hairpin
+	case len(current.Children) > 1:
+		current.Kind = Multi
+	case len(current.Unpaired) == 0:
+		current.Kind = Stack
+	default:
+		child := current.Children[0]
+		if child.Open == open+1 || child.Close == closing-1 {
+			current.Kind = Bulge
+		} else {
+			current.Kind = Interior
+		}
+	}
+	return current
+}
+
+// Walk calls visit on loop and every loop below it, parents before children.
+// Returning false from visit skips that loop's children.
+func (loop *Loop) Walk(visit func(*Loop) bool) {
+	if !visit(loop) {
+		return
+	}
+	for _, child := range loop.Children {
+		child.Walk(visit)
+	}
+}
+
+// Loops returns every loop of a dot-bracket structure of the given kind, in
+// order of their closing pair.
+func Loops(dotBracket string, kind LoopKind) ([]*Loop, error) {
+	root, err := Tree(dotBracket)
+	if err != nil {
+		return nil, err
+	}
+	var loops []*Loop
+	root.Walk(func(current *Loop) bool {
+		if current.Kind == kind {
+			loops = append(loops, current)
+		}
+		return true
+	})
+	return loops, nil
+}
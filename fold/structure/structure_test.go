@@ -0,0 +1,210 @@
+package structure
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPairTable(t *testing.T) {
+	tests := []struct {
+		dotBracket string
+		pairs      []int
+		nested     []int
+	}{
+		{"((..))", []int{5, 4, -1, -1, 1, 0}, []int{5, 4, -1, -1, 1, 0}},
+		{"(.[.).]", []int{4, -1, 6, -1, 0, -1, 2}, []int{4, -1, -1, -1, 0, -1, -1}},
+		{"<{[()]}>", []int{7, 6, 5, 4, 3, 2, 1, 0}, []int{-1, -1, -1, 4, 3, -1, -1, -1}},
+		{"", []int{}, []int{}},
+	}
+	for _, test := range tests {
+		pairs, err := PairTable(test.dotBracket)
+		if err != nil {
+			t.Fatalf("PairTable(%q) returned error: %s", test.dotBracket, err)
+		}
+		if !reflect.DeepEqual(pairs, test.pairs) {
+			t.Errorf("PairTable(%q) = %v, want %v", test.dotBracket, pairs, test.pairs)
+		}
+		nested, _ := NestedPairTable(test.dotBracket)
+		if !reflect.DeepEqual(nested, test.nested) {
+			t.Errorf("NestedPairTable(%q) = %v, want %v", test.dotBracket, nested, test.nested)
+		}
+	}
+}
+
+func TestPairTableErrors(t *testing.T) {
+	for _, dotBracket := range []string{"((..)", "(..))", "((..]]", "[(])x)"} {
+		if _, err := PairTable(dotBracket); !errors.Is(err, ErrUnbalanced) {
+			t.Errorf("PairTable(%q) should return ErrUnbalanced, got %v", dotBracket, err)
+		}
+	}
+	if _, err := PairTable("..?.."); err == nil || errors.Is(err, ErrUnbalanced) {
+		t.Errorf("expected an unexpected character error, got %v", err)
+	}
+}
+
+func TestDotBracketRoundTrip(t *testing.T) {
+	for _, dotBracket := range []string{
+		"(((((((..((((........)))).(((((.......))))).....(((((.......))))))))))))....",
+		"(((..[[[..)))..]]]",
+		"((..[[..{{..))..]]..}}",
+		"((([[[{{{<<<)))]]]}}}>>>",
+		"......",
+		"",
+	} {
+		pairs, err := PairTable(dotBracket)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := DotBracket(pairs)
+		if err != nil {
+			t.Fatalf("DotBracket(%v) returned error: %s", pairs, err)
+		}
+		if got != dotBracket {
+			t.Errorf("DotBracket(PairTable(%q)) = %q", dotBracket, got)
+		}
+	}
+
+	// bracket types are assigned fresh, so an unusual first layer is rewritten.
+	pairs, _ := PairTable("[[..]]")
+	if got, _ := DotBracket(pairs); got != "((..))" {
+		t.Errorf("expected nested pairs to be written with (), got %q", got)
+	}
+}
+
+func TestDotBracketErrors(t *testing.T) {
+	for _, pairs := range [][]int{{1, 1}, {0}, {3, -1}, {-2, -1}, {2, -1, -1}} {
+		if _, err := DotBracket(pairs); err == nil {
+			t.Errorf("DotBracket(%v) should have failed", pairs)
+		}
+	}
+	// five mutually crossing pairs need five bracket types.
+	pairs := []int{5, 6, 7, 8, 9, 0, 1, 2, 3, 4}
+	if _, err := DotBracket(pairs); err == nil {
+		t.Errorf("expected an error when running out of bracket types")
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		first, second string
+		want          int
+	}{
+		{"((..))", "((..))", 0},
+		{"((..))", "......", 2},
+		{"((..))", ".(..).", 1},
+		{"((....))", ".((..)).", 2},
+		{"((..[[))]]", "((....))..", 2},
+	}
+	for _, test := range tests {
+		got, err := Distance(test.first, test.second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", test.first, test.second, got, test.want)
+		}
+		if reverse, _ := Distance(test.second, test.first); reverse != got {
+			t.Errorf("Distance(%q, %q) isn't symmetric", test.first, test.second)
+		}
+	}
+	if _, err := Distance("((..))", "(..)"); err == nil {
+		t.Errorf("expected an error for structures of different lengths")
+	}
+	if _, err := Distance("((..))", "((..)."); err == nil {
+		t.Errorf("expected an error for an unbalanced structure")
+	}
+}
+
+func TestTree(t *testing.T) {
+	//             0123456789012345678901234
+	dotBracket := ".((.((..))..(((...))).))."
+	root, err := Tree(dotBracket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Kind != Exterior || root.Open != -1 || root.Close != len(dotBracket) {
+		t.Errorf("unexpected root %+v", root)
+	}
+	if !reflect.DeepEqual(root.Unpaired, []int{0, 24}) || len(root.Children) != 1 {
+		t.Errorf("unexpected exterior loop %+v", root)
+	}
+
+	var kinds []LoopKind
+	root.Walk(func(loop *Loop) bool {
+		kinds = append(kinds, loop.Kind)
+		return true
+	})
+	want := []LoopKind{Exterior, Stack, Multi, Stack, Hairpin, Stack, Stack, Hairpin}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("Walk visited %v, want %v", kinds, want)
+	}
+
+	multi := root.Children[0].Children[0]
+	if multi.Open != 2 || multi.Close != 22 || !reflect.DeepEqual(multi.Unpaired, []int{3, 10, 11, 21}) {
+		t.Errorf("unexpected multiloop %+v", multi)
+	}
+
+	visited := 0
+	root.Walk(func(loop *Loop) bool {
+		visited++
+		return loop.Kind != Multi
+	})
+	if visited != 3 {
+		t.Errorf("expected Walk to skip the children of the multiloop, visited %d loops", visited)
+	}
+}
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		dotBracket string
+		kind       LoopKind
+		want       [][2]int
+	}{
+		{"((...))", Hairpin, [][2]int{{1, 5}}},
+		{"((.((...))))", Bulge, [][2]int{{1, 10}}},
+		{"((.((...)).))", Interior, [][2]int{{1, 11}}},
+		{"((..[[..))..]]", Stack, [][2]int{{0, 9}}},
+		{"......", Hairpin, nil},
+	}
+	for _, test := range tests {
+		loops, err := Loops(test.dotBracket, test.kind)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got [][2]int
+		for _, loop := range loops {
+			got = append(got, [2]int{loop.Open, loop.Close})
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Loops(%q, %s) = %v, want %v", test.dotBracket, test.kind, got, test.want)
+		}
+	}
+	if _, err := Loops("((.)", Hairpin); err == nil {
+		t.Errorf("expected an error for an unbalanced structure")
+	}
+}
+
+func TestLoopKindString(t *testing.T) {
+	if Interior.String() != "interior" || LoopKind(42).String() != "LoopKind(42)" {
+		t.Errorf("unexpected loop kind names %s and %s", Interior, LoopKind(42))
+	}
+}
+
+func TestUnpairedProbabilities(t *testing.T) {
+	probabilities := [][]float64{
+		{0, 0, 0, 0.6, 0.4},
+		{0, 0, 0, 0, 0.3},
+		{0, 0, 0, 0, 0},
+		{0.6, 0, 0, 0, 0},
+		{0.4, 0.3, 0, 0, 0},
+	}
+	got := UnpairedProbabilities(probabilities)
+	want := []float64{0, 0.7, 1, 0.4, 0.3}
+	for index := range want {
+		if diff := got[index] - want[index]; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("UnpairedProbabilities = %v, want %v", got, want)
+			break
+		}
+	}
+}
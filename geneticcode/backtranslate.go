@@ -0,0 +1,84 @@
+package geneticcode
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bebop/poly/alphabet"
+)
+
+/******************************************************************************
+
+Back-translation begins here.
+
+Translation throws away which codons a protein was written with, so going back
+from a protein to DNA has a choice to make at every amino acid. Degenerate
+primers and libraries make all of them at once: the codon is written with
+IUPAC codes covering every codon of its amino acid, so GCN for alanine, and
+whatever synthesizes it makes a mix of every choice.
+
+IUPAC codes are per base, not per codon, so a degenerate codon covers every
+combination of the bases at each position of its amino acid's codons. For
+most amino acids that's exactly their codons, but leucine's CTN and TTR come
+out as YTN, which covers TTC and TTT for phenylalanine too. That's the usual
+compromise of degenerate back-translation, and why degenerate primers are
+designed from stretches of amino acids with few codons.
+
+For a concrete sequence, with one codon per amino acid, use the Optimize or
+BackTranslate methods of a translation table from synthesis/codon.
+
+******************************************************************************/
+
+// DegenerateCodon returns a codon of IUPAC codes that covers every codon of an
+// amino acid, or of the stop codons for *. X, for any amino acid, is NNN.
+func (code Code) DegenerateCodon(aminoAcid byte) (string, error) {
+	if aminoAcid == 'X' {
+		return "NNN", nil
+	}
+	var codons []string
+	if aminoAcid == '*' {
+		codons = code.StopCodons()
+	} else {
+		codons = code.Codons(aminoAcid)
+	}
+	if len(codons) == 0 {
+		return "", fmt.Errorf("no codon reads as %q", aminoAcid)
+	}
+	return Degenerate(codons), nil
+}
+
+// Degenerate returns a codon of IUPAC codes that covers every codon of
+// codons, which should all be three bases long.
+func Degenerate(codons []string) string {
+	degenerate := make([]byte, 3)
+	for position := range degenerate {
+		var bases strings.Builder
+		for _, codon := range codons {
+			bases.WriteByte(codon[position])
+		}
+		degenerate[position] = alphabet.AmbiguityCode(bases.String())
+	}
+	return string(degenerate)
+}
+
+// BackTranslate returns DNA that covers every way a protein could be encoded,
+// written with IUPAC codes. See DegenerateCodon.
+func (code Code) BackTranslate(protein string) (string, error) {
+	protein = strings.ToUpper(protein)
+	var dna strings.Builder
+	degenerateCodons := make(map[byte]string)
+	for position := 0; position < len(protein); position++ {
+		aminoAcid := protein[position]
+		degenerate, ok := degenerateCodons[aminoAcid]
+		if !ok {
+			var err error
+			degenerate, err = code.DegenerateCodon(aminoAcid)
+			if err != nil {
+				return "", fmt.Errorf("amino acid %d: %w", position+1, err)
+			}
+			degenerateCodons[aminoAcid] = degenerate
+		}
+		dna.WriteString(degenerate)
+	}
+	return dna.String(), nil
+}
@@ -0,0 +1,34 @@
+package geneticcode_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/geneticcode"
+)
+
+func ExampleCode_Reassign() {
+	bacterial, _ := geneticcode.Get(11)
+
+	// read the amber stop codon as pyrrolysine.
+	amber, err := bacterial.Reassign("TAG", 'O')
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	aminoAcid, _ := amber.AminoAcid("TAG")
+	fmt.Println(string(aminoAcid), amber.StopCodons())
+	// Output: O [TAA TGA]
+}
+
+func ExampleCode_BackTranslate() {
+	standard, _ := geneticcode.Get(1)
+
+	// a degenerate primer for the start of GFP.
+	primer, err := standard.BackTranslate("MSKGEE")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(primer)
+	// Output: ATGWSNAARGGNGARGAR
+}
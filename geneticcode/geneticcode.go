@@ -0,0 +1,240 @@
+/*
+Package geneticcode provides the genetic codes that map codons to amino acids.
+
+Almost everything reads DNA with the same code, the standard one, but not
+quite everything. Mitochondria read TGA as tryptophan, ciliates read TAA and
+TAG as glutamine, and bacteria start genes at GTG and TTG as well as ATG. The
+NCBI keeps every code that's been found in nature as a numbered table
+(https://www.ncbi.nlm.nih.gov/Taxonomy/Utils/wprintgc.cgi), and Get returns
+any of them by number.
+
+Codes aren't only found in nature anymore, though. Recoded organisms have had
+a codon freed up by replacing it everywhere in their genome, and amber
+suppression reads the TAG stop codon as a noncanonical amino acid. Reassign
+and WithStarts make codes like those from the natural ones, and New makes a
+code from scratch.
+
+A Code is written the way the NCBI writes them: 64 amino acids, and 64 marks
+of which codons start and stop, both in the order TTT, TTC, TTA, TTG, TCT, and
+so on, with T, C, A, G as the order of every position. Translation tables in
+synthesis/codon, and through them ORF finding and codon optimization, are
+built from Codes.
+*/
+package geneticcode
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Code is a genetic code.
+type Code struct {
+	// ID is the code's NCBI table number, or 0 for codes that aren't NCBI
+	// tables.
+	ID   int
+	Name string
+	// AminoAcids is the one letter code of the amino acid every codon reads
+	// as, or * for stop codons.
+	AminoAcids string
+	// Starts marks codons that can start translation with M, and codons
+	// that can stop it with *. Other codons are marked with -.
+	Starts string
+}
+
+// bases is the order of the bases of every position of a codon.
+const bases = "TCAG"
+
+// index returns the position of a codon in a code's strings. Us are read as
+// Ts.
+func index(triplet string) (int, bool) {
+	if len(triplet) != 3 {
+		return 0, false
+	}
+	position := 0
+	for _, base := range strings.ToUpper(triplet) {
+		if base == 'U' {
+			base = 'T'
+		}
+		value := strings.IndexRune(bases, base)
+		if value == -1 {
+			return 0, false
+		}
+		position = 4*position + value
+	}
+	return position, true
+}
+
+// triplet returns the codon at a position of a code's strings.
+func triplet(position int) string {
+	return string([]byte{bases[position/16], bases[position/4%4], bases[position%4]})
+}
+
+// New returns a code that isn't an NCBI table, after checking that
+// aminoAcids and starts are written like the NCBI writes them.
+func New(name, aminoAcids, starts string) (Code, error) {
+	if len(aminoAcids) != 64 || len(starts) != 64 {
+		return Code{}, fmt.Errorf("a genetic code needs 64 amino acids and 64 start marks, not %d and %d", len(aminoAcids), len(starts))
+	}
+	for position := 0; position < 64; position++ {
+		aminoAcid, start := aminoAcids[position], starts[position]
+		if !(aminoAcid == '*' || aminoAcid >= 'A' && aminoAcid <= 'Z') {
+			return Code{}, fmt.Errorf("%s reads as %q, which isn't an amino acid", triplet(position), aminoAcid)
+		}
+		if start != '-' && start != 'M' && start != '*' {
+			return Code{}, fmt.Errorf("%s is marked %q, which isn't -, M, or *", triplet(position), start)
+		}
+		if aminoAcid == '*' && start != '*' {
+			return Code{}, fmt.Errorf("%s reads as a stop, so it needs to be marked as one", triplet(position))
+		}
+	}
+	return Code{Name: name, AminoAcids: aminoAcids, Starts: starts}, nil
+}
+
+// Get returns the NCBI table numbered id.
+func Get(id int) (Code, error) {
+	code, ok := ncbiCodes[id]
+	if !ok {
+		return Code{}, fmt.Errorf("there is no NCBI translation table %d", id)
+	}
+	return code, nil
+}
+
+// Codes returns every NCBI table, by number.
+func Codes() []Code {
+	codes := make([]Code, 0, len(ncbiCodes))
+	for _, code := range ncbiCodes {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i].ID < codes[j].ID })
+	return codes
+}
+
+// AminoAcid returns the amino acid a codon reads as, or * for a stop codon.
+// It's false if the codon isn't three of A, C, G, T, and U.
+func (code Code) AminoAcid(triplet string) (byte, bool) {
+	position, ok := index(triplet)
+	if !ok {
+		return 0, false
+	}
+	return code.AminoAcids[position], true
+}
+
+// IsStart returns whether a codon can start translation.
+func (code Code) IsStart(triplet string) bool {
+	position, ok := index(triplet)
+	return ok && code.Starts[position] == 'M'
+}
+
+// IsStop returns whether a codon can stop translation. Some codes have codons
+// that can either stop translation or read as an amino acid, depending on
+// where they are.
+func (code Code) IsStop(triplet string) bool {
+	position, ok := index(triplet)
+	return ok && code.Starts[position] == '*'
+}
+
+// StartCodons returns the codons that can start translation.
+func (code Code) StartCodons() []string {
+	return code.marked('M')
+}
+
+// StopCodons returns the codons that can stop translation.
+func (code Code) StopCodons() []string {
+	return code.marked('*')
+}
+
+// marked returns the codons marked with mark in Starts.
+func (code Code) marked(mark byte) []string {
+	var codons []string
+	for position := 0; position < len(code.Starts); position++ {
+		if code.Starts[position] == mark {
+			codons = append(codons, triplet(position))
+		}
+	}
+	return codons
+}
+
+// Codons returns the codons that read as an amino acid, or as stops for *.
+func (code Code) Codons(aminoAcid byte) []string {
+	var codons []string
+	for position := 0; position < len(code.AminoAcids); position++ {
+		if code.AminoAcids[position] == aminoAcid {
+			codons = append(codons, triplet(position))
+		}
+	}
+	return codons
+}
+
+// Reassign returns a copy of a code with a codon read as another amino acid,
+// or as a stop for *. A stop codon reassigned to an amino acid no longer
+// stops translation, like TAG with amber suppression. The copy isn't an NCBI
+// table, so its ID is 0.
+func (code Code) Reassign(codon string, aminoAcid byte) (Code, error) {
+	position, ok := index(codon)
+	if !ok {
+		return Code{}, fmt.Errorf("%q isn't a codon", codon)
+	}
+	aminoAcids, starts := []byte(code.AminoAcids), []byte(code.Starts)
+	aminoAcids[position] = aminoAcid
+	switch {
+	case aminoAcid == '*':
+		starts[position] = '*'
+	case starts[position] == '*':
+		starts[position] = '-'
+	}
+	return New(code.Name, string(aminoAcids), string(starts))
+}
+
+// WithStarts returns a copy of a code where only startCodons start
+// translation. The copy isn't an NCBI table, so its ID is 0.
+func (code Code) WithStarts(startCodons ...string) (Code, error) {
+	starts := []byte(code.Starts)
+	for position := range starts {
+		if starts[position] == 'M' {
+			starts[position] = '-'
+		}
+	}
+	for _, codon := range startCodons {
+		position, ok := index(codon)
+		if !ok {
+			return Code{}, fmt.Errorf("%q isn't a codon", codon)
+		}
+		if starts[position] == '*' {
+			return Code{}, fmt.Errorf("%s is a stop codon, so it can't start translation", codon)
+		}
+		starts[position] = 'M'
+	}
+	return New(code.Name, code.AminoAcids, string(starts))
+}
+
+// ncbiCodes are the NCBI translation tables by number, from
+// https://www.ncbi.nlm.nih.gov/Taxonomy/Utils/wprintgc.cgi.
+var ncbiCodes = map[int]Code{
+	1:  {1, "Standard", "FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "---M------**--*----M---------------M----------------------------"},
+	2:  {2, "Vertebrate Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSS**VVVVAAAADDEEGGGG", "----------**--------------------MMMM----------**---M------------"},
+	3:  {3, "Yeast Mitochondrial", "FFLLSSSSYY**CCWWTTTTPPPPHHQQRRRRIIMMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------**----------------------MM---------------M------------"},
+	4:  {4, "Mold, Protozoan, and Coelenterate Mitochondrial and Mycoplasma/Spiroplasma", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--MM------**-------M------------MMMM---------------M------------"},
+	5:  {5, "Invertebrate Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSSSVVVVAAAADDEEGGGG", "---M------**--------------------MMMM---------------M------------"},
+	6:  {6, "Ciliate, Dasycladacean and Hexamita Nuclear", "FFLLSSSSYYQQCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--------------*--------------------M----------------------------"},
+	9:  {9, "Echinoderm and Flatworm Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG", "----------**-----------------------M---------------M------------"},
+	10: {10, "Euplotid Nuclear", "FFLLSSSSYY**CCCWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------**-----------------------M----------------------------"},
+	11: {11, "Bacterial, Archaeal and Plant Plastid", "FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "---M------**--*----M------------MMMM---------------M------------"},
+	12: {12, "Alternative Yeast Nuclear", "FFLLSSSSYY**CC*WLLLSPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------**--*----M---------------M----------------------------"},
+	13: {13, "Ascidian Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSGGVVVVAAAADDEEGGGG", "---M------**----------------------MM---------------M------------"},
+	14: {14, "Alternative Flatworm Mitochondrial", "FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG", "-----------*-----------------------M----------------------------"},
+	16: {16, "Chlorophycean Mitochondrial", "FFLLSSSSYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------*---*--------------------M----------------------------"},
+	21: {21, "Trematode Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNNKSSSSVVVVAAAADDEEGGGG", "----------**-----------------------M---------------M------------"},
+	22: {22, "Scenedesmus obliquus Mitochondrial", "FFLLSS*SYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "------*---*---*--------------------M----------------------------"},
+	23: {23, "Thraustochytrium Mitochondrial", "FF*LSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--*-------**--*-----------------M--M---------------M------------"},
+	24: {24, "Rhabdopleuridae Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG", "---M------**-------M---------------M---------------M------------"},
+	25: {25, "Candidate Division SR1 and Gracilibacteria", "FFLLSSSSYY**CCGWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "---M------**-----------------------M---------------M------------"},
+	26: {26, "Pachysolen tannophilus Nuclear", "FFLLSSSSYY**CC*WLLLAPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------**--*----M---------------M----------------------------"},
+	27: {27, "Karyorelict Nuclear", "FFLLSSSSYYQQCCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--------------*--------------------M----------------------------"},
+	28: {28, "Condylostoma Nuclear", "FFLLSSSSYYQQCCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------**--*--------------------M----------------------------"},
+	29: {29, "Mesodinium Nuclear", "FFLLSSSSYYYYCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--------------*--------------------M----------------------------"},
+	30: {30, "Peritrich Nuclear", "FFLLSSSSYYEECC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--------------*--------------------M----------------------------"},
+	31: {31, "Blastocrithidia Nuclear", "FFLLSSSSYYEECCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------**-----------------------M----------------------------"},
+	32: {32, "Balanophoraceae Plastid", "FFLLSSSSYY*WCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "---M------*---*----M------------MMMM---------------M------------"},
+	33: {33, "Cephalodiscidae Mitochondrial", "FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG", "---M-------*-------M---------------M---------------M------------"},
+}
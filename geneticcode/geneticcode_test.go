@@ -0,0 +1,139 @@
+package geneticcode_test
+
+import (
+	"testing"
+
+	"github.com/bebop/poly/alphabet"
+	"github.com/bebop/poly/geneticcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodes(t *testing.T) {
+	codes := geneticcode.Codes()
+	require.Len(t, codes, 26)
+	for index, code := range codes {
+		if index > 0 {
+			assert.Greater(t, code.ID, codes[index-1].ID)
+		}
+		// every NCBI table is a valid code.
+		_, err := geneticcode.New(code.Name, code.AminoAcids, code.Starts)
+		assert.NoError(t, err, code.ID)
+		assert.NotEmpty(t, code.StopCodons(), code.ID)
+		assert.NotEmpty(t, code.StartCodons(), code.ID)
+	}
+
+	_, err := geneticcode.Get(7)
+	assert.Error(t, err)
+}
+
+func TestGet(t *testing.T) {
+	standard, err := geneticcode.Get(1)
+	require.NoError(t, err)
+	aminoAcid, ok := standard.AminoAcid("ATG")
+	assert.True(t, ok)
+	assert.Equal(t, byte('M'), aminoAcid)
+	aminoAcid, _ = standard.AminoAcid("ugg")
+	assert.Equal(t, byte('W'), aminoAcid)
+	_, ok = standard.AminoAcid("ATN")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"TAA", "TAG", "TGA"}, standard.StopCodons())
+	assert.Equal(t, []string{"TTG", "CTG", "ATG"}, standard.StartCodons())
+	assert.Equal(t, []string{"TGG"}, standard.Codons('W'))
+	assert.Len(t, standard.Codons('L'), 6)
+
+	// vertebrate mitochondria read TGA as tryptophan, and stop at AGA and AGG.
+	mitochondrial, err := geneticcode.Get(2)
+	require.NoError(t, err)
+	aminoAcid, _ = mitochondrial.AminoAcid("TGA")
+	assert.Equal(t, byte('W'), aminoAcid)
+	assert.True(t, mitochondrial.IsStop("AGA"))
+	assert.False(t, mitochondrial.IsStop("TGA"))
+
+	// ciliates read TAA and TAG as glutamine.
+	ciliate, err := geneticcode.Get(6)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TGA"}, ciliate.StopCodons())
+	assert.Equal(t, []string{"TAA", "TAG", "CAA", "CAG"}, ciliate.Codons('Q'))
+}
+
+func TestReassign(t *testing.T) {
+	bacterial, err := geneticcode.Get(11)
+	require.NoError(t, err)
+
+	// amber suppression with pyrrolysine.
+	amber, err := bacterial.Reassign("TAG", 'O')
+	require.NoError(t, err)
+	assert.Equal(t, 0, amber.ID)
+	assert.False(t, amber.IsStop("TAG"))
+	aminoAcid, _ := amber.AminoAcid("TAG")
+	assert.Equal(t, byte('O'), aminoAcid)
+	assert.Equal(t, []string{"TAA", "TGA"}, amber.StopCodons())
+	// the original is unchanged.
+	assert.True(t, bacterial.IsStop("TAG"))
+
+	// a sense codon made a stop.
+	freed, err := bacterial.Reassign("AGG", '*')
+	require.NoError(t, err)
+	assert.True(t, freed.IsStop("AGG"))
+
+	_, err = bacterial.Reassign("TAGG", 'O')
+	assert.Error(t, err)
+	_, err = bacterial.Reassign("TAG", '1')
+	assert.Error(t, err)
+}
+
+func TestWithStarts(t *testing.T) {
+	bacterial, err := geneticcode.Get(11)
+	require.NoError(t, err)
+	atgOnly, err := bacterial.WithStarts("ATG")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ATG"}, atgOnly.StartCodons())
+	assert.True(t, atgOnly.IsStop("TAA"))
+
+	_, err = bacterial.WithStarts("TAA")
+	assert.Error(t, err)
+	_, err = bacterial.WithStarts("AT")
+	assert.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	standard, err := geneticcode.Get(1)
+	require.NoError(t, err)
+	custom, err := geneticcode.New("custom", standard.AminoAcids, standard.Starts)
+	require.NoError(t, err)
+	assert.Equal(t, 0, custom.ID)
+
+	_, err = geneticcode.New("short", standard.AminoAcids[:63], standard.Starts)
+	assert.Error(t, err)
+	// a stop has to be marked as one.
+	_, err = geneticcode.New("unmarked", standard.AminoAcids, "---M---------------M---------------M----------------------------")
+	assert.Error(t, err)
+	_, err = geneticcode.New("bad mark", standard.AminoAcids, "---X------**--*----M---------------M----------------------------")
+	assert.Error(t, err)
+}
+
+func TestBackTranslate(t *testing.T) {
+	standard, err := geneticcode.Get(1)
+	require.NoError(t, err)
+
+	dna, err := standard.BackTranslate("mawlx*")
+	require.NoError(t, err)
+	// leucine's CTN and TTR come out as YTN, and the stops as TRR.
+	assert.Equal(t, "ATG"+"GCN"+"TGG"+"YTN"+"NNN"+"TRR", dna)
+
+	// every codon of every amino acid is covered by its degenerate codon.
+	for _, aminoAcid := range []byte("ACDEFGHIKLMNPQRSTVWY*") {
+		degenerate, err := standard.DegenerateCodon(aminoAcid)
+		require.NoError(t, err)
+		codons := standard.Codons(aminoAcid)
+		for _, codon := range codons {
+			for position := 0; position < 3; position++ {
+				assert.Contains(t, alphabet.Ambiguities(degenerate[position]), string(codon[position]), string(aminoAcid))
+			}
+		}
+	}
+
+	_, err = standard.BackTranslate("MAB")
+	assert.EqualError(t, err, "amino acid 3: no codon reads as 'B'")
+}
@@ -0,0 +1,264 @@
+package integration_test
+
+/******************************************************************************
+
+Integration tests begin here.
+
+Every package in poly has its own tests, but the bugs that hurt most live
+between packages: a parser that counts from 1 feeding a finder that counts
+from 0, a circular sequence losing its topology on the way through a writer,
+a reverse complement that disagrees with the translation table about what
+a lowercase base is.
+
+These tests run whole workflows over the real public records vendored in
+data/, parse → annotate → optimize → fold → write → parse again, and check
+that everything agrees with what the records themselves say, like every CDS
+translating to its own /translation qualifier. They're ordinary Go tests, so
+go test ./... runs them before every release.
+
+******************************************************************************/
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/annotation"
+	"github.com/bebop/poly/annotation/orf"
+	"github.com/bebop/poly/fold"
+	"github.com/bebop/poly/io/embl"
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/io/gff"
+	"github.com/bebop/poly/seqhash"
+	"github.com/bebop/poly/synthesis/codon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// corpus are the real GenBank records the workflows run over.
+var corpus = []string{
+	"../data/puc19.gbk",
+	"../data/phix174.gb",
+	"../data/t4_intron.gb",
+	"../data/bsub.gbk",
+}
+
+// translatedCDSs returns every CDS of a record with a /translation and no
+// ribosomal slippage or unusual start, with the sequence its location gives.
+func translatedCDSs(t *testing.T, record genbank.Genbank) map[string]string {
+	cdss := make(map[string]string)
+	for _, feature := range record.Features {
+		translation := strings.ReplaceAll(feature.Attributes["translation"], " ", "")
+		if feature.Type != "CDS" || translation == "" || feature.Attributes["ribosomal_slippage"] != "" {
+			continue
+		}
+		sequence, err := feature.GetSequence()
+		require.NoError(t, err)
+		cdss[translation] = sequence
+	}
+	return cdss
+}
+
+// TestCodingSequences checks that every CDS in the corpus, wherever it is
+// and whichever strand it's on, translates to its own /translation.
+func TestCodingSequences(t *testing.T) {
+	for _, path := range corpus {
+		record, err := genbank.Read(path)
+		require.NoError(t, err, path)
+		table, err := codon.NewTranslationTable(11)
+		require.NoError(t, err)
+		checked := 0
+		for translation, sequence := range translatedCDSs(t, record) {
+			protein, err := table.Translate(sequence)
+			require.NoError(t, err)
+			// the first codon is always read as methionine, whatever it is.
+			protein = "M" + strings.TrimSuffix(protein, "*")[1:]
+			assert.Equal(t, translation, protein, "%s: CDS translating to %.20s...", path, translation)
+			checked++
+		}
+		assert.NotZero(t, checked, path)
+	}
+}
+
+// TestRoundTrip checks that writing every record as GenBank and EMBL and
+// reading it back keeps its sequence, topology, and feature locations.
+func TestRoundTrip(t *testing.T) {
+	for _, path := range corpus {
+		record, err := genbank.Read(path)
+		require.NoError(t, err, path)
+
+		built, err := genbank.Build(record)
+		require.NoError(t, err)
+		fromGenbank, err := genbank.Parse(bytes.NewReader(built))
+		require.NoError(t, err, path)
+
+		built, err = embl.Build(record)
+		require.NoError(t, err)
+		fromEMBL, err := embl.Parse(bytes.NewReader(built))
+		require.NoError(t, err, path)
+
+		for format, reread := range map[string]genbank.Genbank{"GenBank": fromGenbank, "EMBL": fromEMBL} {
+			assert.Equal(t, record.Sequence, reread.Sequence, "%s as %s", path, format)
+			assert.Equal(t, record.Meta.Locus.Circular, reread.Meta.Locus.Circular, "%s as %s", path, format)
+			require.Len(t, reread.Features, len(record.Features), "%s as %s", path, format)
+			for index, feature := range record.Features {
+				assert.Equal(t, genbank.BuildLocationString(feature.Location), genbank.BuildLocationString(reread.Features[index].Location), "%s as %s", path, format)
+			}
+			assert.Equal(t, translatedCDSs(t, record), translatedCDSs(t, reread), "%s as %s", path, format)
+		}
+	}
+}
+
+// TestTopology checks that seqhashes treat circular records as circles, so
+// rotating one or writing it from the other strand doesn't change it.
+func TestTopology(t *testing.T) {
+	record, err := genbank.Read("../data/puc19.gbk")
+	require.NoError(t, err)
+	require.True(t, record.Meta.Locus.Circular)
+	options := seqhash.Options{Circular: true, Strandedness: seqhash.DoubleStranded}
+	hash, err := seqhash.HashWithOptions(record.Sequence, options)
+	require.NoError(t, err)
+
+	rotated := record.Sequence[1000:] + record.Sequence[:1000]
+	rotatedHash, err := seqhash.HashWithOptions(strings.ToLower(rotated), options)
+	require.NoError(t, err)
+	assert.Equal(t, hash, rotatedHash)
+
+	linearHash, err := seqhash.HashWithOptions(rotated, seqhash.Options{Strandedness: seqhash.DoubleStranded})
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, linearHash)
+}
+
+// TestAnnotation checks that the ORF finder recovers the annotated genes of
+// real records, including ones that cross the origin of a circular genome,
+// and that merging its features into the record reconciles them with the
+// originals.
+func TestAnnotation(t *testing.T) {
+	for _, path := range []string{"../data/puc19.gbk", "../data/phix174.gb"} {
+		record, err := genbank.Read(path)
+		require.NoError(t, err)
+		options := orf.Options{Circular: record.Meta.Locus.Circular, MinLength: 50}
+
+		annotated := make(map[string]bool)
+		for _, feature := range record.Features {
+			if feature.Type == "CDS" && feature.Attributes["ribosomal_slippage"] == "" {
+				annotated[genbank.BuildLocationString(feature.Location)] = true
+			}
+		}
+
+		pipeline := annotation.Pipeline{Name: "orf", Version: "1", Annotate: func(record genbank.Genbank) ([]genbank.Feature, error) {
+			orfs, err := orf.Find(record.Sequence, options)
+			return orf.Features(orfs, 11), err
+		}}
+		store, err := annotation.NewStore(t.TempDir(), 8)
+		require.NoError(t, err)
+		records := []genbank.Genbank{record}
+		_, err = annotation.Annotate(records, pipeline, store)
+		require.NoError(t, err)
+
+		found := 0
+		for _, feature := range records[0].Features[len(record.Features):] {
+			if annotated[genbank.BuildLocationString(feature.Location)] {
+				found++
+				// the ORF's translation is the annotated one.
+				for _, original := range record.Features {
+					if original.Type == "CDS" && genbank.BuildLocationString(original.Location) == genbank.BuildLocationString(feature.Location) {
+						assert.Equal(t, original.Attributes["translation"], feature.Attributes["translation"], path)
+					}
+				}
+			}
+		}
+		assert.NotZero(t, found, "%s: no annotated CDS was found as an ORF", path)
+
+		// merging puts every recovered ORF back into the CDS it came from.
+		before := len(records[0].Features)
+		merged := records[0].MergeFeatures(genbank.MergeOptions{MinOverlap: 0.99})
+		assert.GreaterOrEqual(t, merged, found, path)
+		assert.Equal(t, before-merged, len(records[0].Features))
+	}
+}
+
+// TestOptimizeAndFold recodes a real gene under synthesis constraints, checks
+// it still encodes the same protein, and folds its 5' end.
+func TestOptimizeAndFold(t *testing.T) {
+	record, err := genbank.Read("../data/puc19.gbk")
+	require.NoError(t, err)
+	var protein string
+	for _, feature := range record.Features {
+		if feature.Attributes["label"] == "lacZ-alpha" {
+			protein = feature.Attributes["translation"]
+		}
+	}
+	require.NotEmpty(t, protein)
+
+	table, err := codon.NewTranslationTable(11)
+	require.NoError(t, err)
+	require.NoError(t, table.UpdateWeightsWithSequence(record))
+	constraints := codon.Constraints{
+		Forbidden:       []string{"GGTCTC", "CGTCTC", "GAATTC", "GGATCC"},
+		MaxHomopolymer:  5,
+		MinGC:           0.3,
+		MaxGC:           0.7,
+		FivePrimeWindow: 30,
+	}
+	sequence, err := table.OptimizeConstrained(protein+"*", constraints)
+	require.NoError(t, err)
+	assert.Empty(t, constraints.Violations(sequence))
+	translation, err := table.Translate(sequence)
+	require.NoError(t, err)
+	assert.Equal(t, protein+"*", translation)
+
+	result, err := fold.Fold(sequence[:60], fold.Options{})
+	require.NoError(t, err)
+	// dot-brackets stop at the last paired base.
+	assert.LessOrEqual(t, len(result.DotBracket()), 60)
+	assert.Less(t, result.MinimumFreeEnergy(), 10.0)
+
+	// and the recoded gene can be written into the record and read back.
+	recoded := record
+	recoded.Sequence = sequence
+	recoded.Features = nil
+	recoded.Meta.Locus.Circular = false
+	recoded.Meta.Locus.SequenceLength = strconv.Itoa(len(sequence))
+	built, err := genbank.Build(recoded)
+	require.NoError(t, err)
+	reread, err := genbank.Parse(bytes.NewReader(built))
+	require.NoError(t, err)
+	assert.Equal(t, strings.ToLower(sequence), strings.ToLower(reread.Sequence))
+}
+
+// TestGFF checks that the CDSs of a real GFF file translate to their own
+// translation attributes, and survive being written and read again.
+func TestGFF(t *testing.T) {
+	record, err := gff.Read("../data/ecoli-mg1655-short.gff")
+	require.NoError(t, err)
+	table, err := codon.NewTranslationTable(11)
+	require.NoError(t, err)
+
+	translations := func(record gff.Gff) map[string]string {
+		found := make(map[string]string)
+		for _, feature := range record.Features {
+			if feature.Type != "CDS" || feature.Attributes["translation"] == "" {
+				continue
+			}
+			sequence, err := feature.GetSequence()
+			require.NoError(t, err)
+			protein, err := table.Translate(sequence)
+			require.NoError(t, err)
+			found[feature.Attributes["translation"]] = "M" + strings.TrimSuffix(protein, "*")[1:]
+		}
+		return found
+	}
+	original := translations(record)
+	require.NotEmpty(t, original)
+	for want, got := range original {
+		assert.Equal(t, want, got)
+	}
+
+	built, err := gff.Build(record)
+	require.NoError(t, err)
+	reread, err := gff.Parse(bytes.NewReader(built))
+	require.NoError(t, err)
+	assert.Equal(t, original, translations(reread))
+}
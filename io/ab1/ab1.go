@@ -0,0 +1,322 @@
+/*
+Package ab1 contains a parser for AB1 (ABIF) Sanger sequencing trace files.
+
+Sanger sequencing is still the cheapest way to check that a plasmid you built
+is the plasmid you wanted. The sequencer hands back an .ab1 file, which is
+Applied Biosystems' binary ABIF format. Inside is a small directory of tagged
+entries holding the basecalls, the quality of every call, where each call's
+peak sits in the trace, and the fluorescence traces for all four dyes.
+
+This package reads those entries into a Trace. The called sequence can then
+be aligned against a reference plasmid, trimmed of its noisy ends, or
+converted into a fastq read for tools that already speak fastq.
+
+The ABIF specification can be found here:
+https://projects.nfstc.org/workshops/resources/articles/ABIF_File_Format.pdf
+*/
+package ab1
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	polyio "github.com/bebop/poly/io"
+	"github.com/bebop/poly/io/fastq"
+)
+
+/******************************************************************************
+Oct 16, 2026
+
+AB1 parser begins here.
+
+An ABIF file starts with the magic "ABIF", a version number, and a single
+directory entry that points to the real directory. Every directory entry is 28
+big endian bytes:
+
+	name         4 bytes  four letter tag name, like "PBAS"
+	number       int32    tag number, since names repeat (DATA1 ... DATA12)
+	elementType  int16    type of each element (char, short, pString ...)
+	elementSize  int16    size of each element in bytes
+	numElements  int32    number of elements
+	dataSize     int32    total size of the data in bytes
+	dataOffset   int32    where the data starts, or the data itself if it
+	                      fits in 4 bytes
+	dataHandle   int32    reserved
+
+Everything we care about is a list of chars, bytes, or shorts, so that's all
+we decode.
+
+******************************************************************************/
+
+// Trace is a parsed Sanger sequencing trace.
+type Trace struct {
+	Name          string         `json:"name"`           // sample name (SMPL1)
+	Sequence      string         `json:"sequence"`       // called bases (PBAS2, falling back to PBAS1)
+	Qualities     []int          `json:"qualities"`      // phred quality of every called base (PCON2, falling back to PCON1)
+	PeakLocations []int          `json:"peak_locations"` // trace index of every called base's peak (PLOC2, falling back to PLOC1)
+	BaseOrder     string         `json:"base_order"`     // dye order of the channels (FWO_1), like "GATC"
+	Channels      map[byte][]int `json:"channels"`       // analyzed traces keyed by base (DATA9-12)
+	RawChannels   map[byte][]int `json:"raw_channels"`   // raw traces keyed by base (DATA1-4)
+}
+
+const (
+	headerSize = 6 + entrySize // magic, version, and the root directory entry.
+	entrySize  = 28
+)
+
+// ABIF element types used by this package.
+const (
+	typeByte    = 1
+	typeChar    = 2
+	typeShort   = 4
+	typePString = 18
+	typeCString = 19
+)
+
+var (
+	errNotABIF = errors.New("not an ABIF file")
+	errBounds  = errors.New("ABIF entry points outside of file")
+)
+
+// entry is a single ABIF directory entry.
+type entry struct {
+	name        string
+	number      int32
+	elementType int16
+	elementSize int16
+	numElements int32
+	data        []byte
+}
+
+// Read reads an AB1 file from path.
+func Read(path string) (Trace, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Trace{}, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+// Parse parses an AB1 file, which can be gzipped. ABIF files point back and
+// forth within themselves, so the whole reader is read into memory first.
+func Parse(r io.Reader) (Trace, error) {
+	file, err := io.ReadAll(polyio.Decompress(r))
+	if err != nil {
+		return Trace{}, err
+	}
+	entries, err := parseDirectory(file)
+	if err != nil {
+		return Trace{}, err
+	}
+
+	var trace Trace
+	if data, ok := entries["SMPL1"]; ok {
+		trace.Name = decodeString(data)
+	}
+	for _, tag := range []string{"PBAS2", "PBAS1"} {
+		if data, ok := entries[tag]; ok {
+			trace.Sequence = string(data.data)
+			break
+		}
+	}
+	for _, tag := range []string{"PCON2", "PCON1"} {
+		if data, ok := entries[tag]; ok {
+			trace.Qualities = decodeInts(data)
+			break
+		}
+	}
+	for _, tag := range []string{"PLOC2", "PLOC1"} {
+		if data, ok := entries[tag]; ok {
+			trace.PeakLocations = decodeInts(data)
+			break
+		}
+	}
+	if trace.Sequence == "" {
+		return Trace{}, fmt.Errorf("AB1 file has no basecalls (PBAS tag)")
+	}
+	if trace.Qualities != nil && len(trace.Qualities) != len(trace.Sequence) {
+		return Trace{}, fmt.Errorf("AB1 file has %d qualities for %d bases", len(trace.Qualities), len(trace.Sequence))
+	}
+
+	if data, ok := entries["FWO_1"]; ok {
+		trace.BaseOrder = strings.ToUpper(string(data.data))
+	}
+	if len(trace.BaseOrder) == 4 {
+		trace.Channels = make(map[byte][]int)
+		trace.RawChannels = make(map[byte][]int)
+		for index := 0; index < 4; index++ {
+			base := trace.BaseOrder[index]
+			if data, ok := entries[fmt.Sprintf("DATA%d", index+9)]; ok {
+				trace.Channels[base] = decodeInts(data)
+			}
+			if data, ok := entries[fmt.Sprintf("DATA%d", index+1)]; ok {
+				trace.RawChannels[base] = decodeInts(data)
+			}
+		}
+	}
+	return trace, nil
+}
+
+// parseDirectory reads every directory entry of an ABIF file, keyed by name
+// and number like "PBAS2".
+func parseDirectory(file []byte) (map[string]entry, error) {
+	if len(file) < headerSize || string(file[:4]) != "ABIF" {
+		return nil, errNotABIF
+	}
+	root, err := parseEntry(file, 6)
+	if err != nil {
+		return nil, err
+	}
+	if root.numElements < 0 || len(root.data) < int(root.numElements)*entrySize {
+		return nil, errBounds
+	}
+	directoryOffset := int(binary.BigEndian.Uint32(file[6+20:]))
+	entries := make(map[string]entry, root.numElements)
+	for index := 0; index < int(root.numElements); index++ {
+		current, err := parseEntry(file, directoryOffset+index*entrySize)
+		if err != nil {
+			return nil, err
+		}
+		entries[fmt.Sprintf("%s%d", current.name, current.number)] = current
+	}
+	return entries, nil
+}
+
+// parseEntry parses the directory entry starting at offset. Data of four
+// bytes or less is stored in the offset field itself.
+func parseEntry(file []byte, offset int) (entry, error) {
+	if offset < 0 || offset+entrySize > len(file) {
+		return entry{}, errBounds
+	}
+	raw := file[offset : offset+entrySize]
+	current := entry{
+		name:        string(raw[:4]),
+		number:      int32(binary.BigEndian.Uint32(raw[4:])),
+		elementType: int16(binary.BigEndian.Uint16(raw[8:])),
+		elementSize: int16(binary.BigEndian.Uint16(raw[10:])),
+		numElements: int32(binary.BigEndian.Uint32(raw[12:])),
+	}
+	dataSize := int(int32(binary.BigEndian.Uint32(raw[16:])))
+	if dataSize < 0 {
+		return entry{}, errBounds
+	}
+	if dataSize <= 4 {
+		current.data = raw[20 : 20+dataSize]
+		return current, nil
+	}
+	dataOffset := int(binary.BigEndian.Uint32(raw[20:]))
+	if dataOffset < 0 || dataOffset+dataSize > len(file) {
+		return entry{}, fmt.Errorf("%w: %s%d", errBounds, current.name, current.number)
+	}
+	current.data = file[dataOffset : dataOffset+dataSize]
+	return current, nil
+}
+
+// decodeInts decodes an entry of bytes, chars, or shorts into ints.
+func decodeInts(data entry) []int {
+	if data.elementType == typeShort {
+		values := make([]int, len(data.data)/2)
+		for index := range values {
+			values[index] = int(int16(binary.BigEndian.Uint16(data.data[index*2:])))
+		}
+		return values
+	}
+	values := make([]int, len(data.data))
+	for index, value := range data.data {
+		if data.elementType == typeChar {
+			values[index] = int(int8(value))
+		} else {
+			values[index] = int(value)
+		}
+	}
+	return values
+}
+
+// decodeString decodes a pString (length prefixed) or cString (null
+// terminated) entry.
+func decodeString(data entry) string {
+	switch data.elementType {
+	case typePString:
+		if len(data.data) == 0 {
+			return ""
+		}
+		length := min(int(data.data[0]), len(data.data)-1)
+		return string(data.data[1 : 1+length])
+	case typeCString:
+		return string(bytes.TrimRight(data.data, "\x00"))
+	}
+	return string(data.data)
+}
+
+/******************************************************************************
+
+Trace utilities begin here.
+
+******************************************************************************/
+
+// Fastq converts a trace into a fastq read, with phred qualities capped at
+// 93 so they stay printable.
+func (trace Trace) Fastq() fastq.Fastq {
+	quality := make([]byte, len(trace.Sequence))
+	for index := range quality {
+		score := 0
+		if index < len(trace.Qualities) {
+			score = min(max(trace.Qualities[index], 0), 93)
+		}
+		quality[index] = byte(score + 33)
+	}
+	return fastq.Fastq{
+		Identifier: trace.Name,
+		Optionals:  map[string]string{},
+		Sequence:   trace.Sequence,
+		Quality:    string(quality),
+	}
+}
+
+// Trim returns the trace trimmed to its best scoring region using the
+// modified Mott algorithm, the same as phred's trim_alt. Every base scores
+// errorLimit minus its error probability, and the highest scoring run of
+// bases is kept. An errorLimit of 0.05 is common. Traces without qualities
+// are returned as is.
+func (trace Trace) Trim(errorLimit float64) Trace {
+	if trace.Qualities == nil {
+		return trace
+	}
+	start, end := trace.trimRange(errorLimit)
+	trimmed := trace
+	trimmed.Sequence = trace.Sequence[start:end]
+	trimmed.Qualities = trace.Qualities[start:end]
+	if len(trace.PeakLocations) == len(trace.Sequence) {
+		trimmed.PeakLocations = trace.PeakLocations[start:end]
+	}
+	return trimmed
+}
+
+// trimRange returns the [start, end) range of the highest scoring run of
+// bases.
+func (trace Trace) trimRange(errorLimit float64) (start, end int) {
+	bestScore, score, runStart := 0.0, 0.0, 0
+	for index := range trace.Qualities {
+		score += errorLimit - errorProbability(trace.Qualities[index])
+		if score <= 0 {
+			score, runStart = 0, index+1
+			continue
+		}
+		if score > bestScore {
+			bestScore, start, end = score, runStart, index+1
+		}
+	}
+	return start, end
+}
+
+// errorProbability converts a phred quality score into an error probability.
+func errorProbability(quality int) float64 {
+	return math.Pow(10, -float64(quality)/10)
+}
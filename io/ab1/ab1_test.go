@@ -0,0 +1,131 @@
+package ab1
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+const synthetic = "data/synthetic_puc19.ab1"
+
+func TestRead(t *testing.T) {
+	trace, err := Read(synthetic)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %s", synthetic, err)
+	}
+	if trace.Name != "synthetic_puc19" {
+		t.Errorf("Expected name synthetic_puc19, got %q", trace.Name)
+	}
+	if !strings.Contains(trace.Sequence, "GAGATACCTACAGCGTGAGC") {
+		t.Errorf("Expected pUC19 basecalls, got %s", trace.Sequence)
+	}
+	if len(trace.Qualities) != len(trace.Sequence) || len(trace.PeakLocations) != len(trace.Sequence) {
+		t.Errorf("Expected one quality and peak per base, got %d and %d for %d bases", len(trace.Qualities), len(trace.PeakLocations), len(trace.Sequence))
+	}
+	if trace.BaseOrder != "GATC" {
+		t.Errorf("Expected base order GATC, got %s", trace.BaseOrder)
+	}
+	for _, base := range []byte("ACGT") {
+		if len(trace.Channels[base]) == 0 || len(trace.RawChannels[base]) == 0 {
+			t.Errorf("Missing trace channel for %c", base)
+		}
+	}
+
+	// every called base should be the tallest channel at its peak.
+	for index, peak := range trace.PeakLocations {
+		base := trace.Sequence[index]
+		if base == 'N' {
+			continue
+		}
+		for other, channel := range trace.Channels {
+			if other != base && channel[peak] >= trace.Channels[base][peak] {
+				t.Errorf("Base %d (%c) is not the tallest peak at %d", index, base, peak)
+			}
+		}
+	}
+}
+
+func TestReadMissing(t *testing.T) {
+	if _, err := Read("data/does_not_exist.ab1"); err == nil {
+		t.Errorf("Expected an error reading a missing file")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	file, err := os.ReadFile(synthetic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootOffset := 6 + 20
+
+	badOffset := bytes.Clone(file)
+	binary.BigEndian.PutUint32(badOffset[rootOffset:], uint32(len(file)))
+
+	noBases := bytes.Clone(file)
+	for index := bytes.Index(noBases, []byte("PBAS")); index >= 0; index = bytes.Index(noBases, []byte("PBAS")) {
+		copy(noBases[index:], "XXXX")
+	}
+
+	tests := []struct {
+		name    string
+		file    []byte
+		wantErr error
+	}{
+		{"empty", nil, errNotABIF},
+		{"not ABIF", []byte(strings.Repeat("LOCUS ", 10)), errNotABIF},
+		{"truncated", file[:len(file)-10], errBounds},
+		{"bad directory offset", badOffset, errBounds},
+		{"no basecalls", noBases, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(bytes.NewReader(test.file))
+			if err == nil {
+				t.Fatalf("Expected an error")
+			}
+			if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+				t.Errorf("Expected %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTrim(t *testing.T) {
+	trace, err := Read(synthetic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trimmed := trace.Trim(0.05)
+	if trimmed.Sequence != trace.Sequence[6:len(trace.Sequence)-6] {
+		t.Errorf("Expected the low quality ends to be trimmed, got %s", trimmed.Sequence)
+	}
+	if len(trimmed.Qualities) != len(trimmed.Sequence) || len(trimmed.PeakLocations) != len(trimmed.Sequence) {
+		t.Errorf("Trimmed qualities and peaks don't match trimmed sequence")
+	}
+	if trimmed.PeakLocations[0] != trace.PeakLocations[6] {
+		t.Errorf("Expected trimmed peaks to start at %d, got %d", trace.PeakLocations[6], trimmed.PeakLocations[0])
+	}
+
+	noQualities := Trace{Sequence: "ACGT"}
+	if got := noQualities.Trim(0.05).Sequence; got != "ACGT" {
+		t.Errorf("Expected a trace without qualities to be untouched, got %s", got)
+	}
+	allBad := Trace{Sequence: "ACGT", Qualities: []int{2, 2, 2, 2}}
+	if got := allBad.Trim(0.05).Sequence; got != "" {
+		t.Errorf("Expected an all low quality trace to be trimmed away, got %s", got)
+	}
+}
+
+func TestFastq(t *testing.T) {
+	trace := Trace{Name: "read", Sequence: "ACGT", Qualities: []int{0, 20, 40, 120}}
+	read := trace.Fastq()
+	if read.Identifier != "read" || read.Sequence != "ACGT" {
+		t.Errorf("Unexpected fastq %+v", read)
+	}
+	if read.Quality != "!5I~" {
+		t.Errorf("Expected quality !5I~, got %s", read.Quality)
+	}
+}
@@ -0,0 +1,38 @@
+package ab1_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/io/ab1"
+)
+
+func ExampleRead() {
+	trace, _ := ab1.Read("data/synthetic_puc19.ab1")
+	fmt.Println(trace.Name)
+	fmt.Println(trace.Sequence[:20])
+	fmt.Println(trace.Qualities[:5])
+	// Output:
+	// synthetic_puc19
+	// NNGAGATACCTACAGCGTGA
+	// [2 2 10 11 12]
+}
+
+func ExampleTrace_Trim() {
+	trace, _ := ab1.Read("data/synthetic_puc19.ab1")
+	trimmed := trace.Trim(0.05)
+	fmt.Println(len(trace.Sequence), len(trimmed.Sequence))
+	fmt.Println(trimmed.Sequence[:10])
+	// Output:
+	// 72 60
+	// TACCTACAGC
+}
+
+func ExampleTrace_Fastq() {
+	trace, _ := ab1.Read("data/synthetic_puc19.ab1")
+	read := trace.Trim(0.05).Fastq()
+	fmt.Println(read.Identifier)
+	fmt.Println(read.Quality[:10])
+	// Output:
+	// synthetic_puc19
+	// OPQRSTIJKL
+}
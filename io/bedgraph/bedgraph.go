@@ -0,0 +1,230 @@
+/*
+Package bedgraph contains bedGraph parsers and writers.
+
+bedGraph is the UCSC genome browser's format for continuous data along a
+sequence, like coverage, conservation, or how likely every base is to be
+unpaired. Each line gives a value to a 0-based, end exclusive range of a
+sequence, and a "track" line above the data names it:
+
+	track type=bedGraph name="accessibility" description="unpaired probability"
+	pUC19	0	12	0.91
+	pUC19	12	18	0.25
+
+Since the ranges are named after a sequence, a bedGraph track written against
+a construct's name can be loaded next to its genbank features in genome
+browsers like IGV, which is a nice way to check whether your ribosome binding
+site sits in a well folded region.
+
+More information on bedGraph can be found here:
+https://genome.ucsc.edu/goldenPath/help/bedgraph.html
+*/
+package bedgraph
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	polyio "github.com/bebop/poly/io"
+)
+
+// Track is a single bedGraph track.
+type Track struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Options     map[string]string `json:"options"` // any other track line settings, like color or visibility.
+	Records     []Record          `json:"records"`
+}
+
+// Record gives Value to the 0-based, end exclusive range Start to End of
+// Chromosome.
+type Record struct {
+	Chromosome string  `json:"chromosome"`
+	Start      int     `json:"start"`
+	End        int     `json:"end"`
+	Value      float64 `json:"value"`
+}
+
+// FromProfile converts one value per position into records on chromosome,
+// starting at start. Neighboring positions with the same value are merged
+// into a single record, which keeps tracks of long, flat profiles small.
+func FromProfile(chromosome string, start int, values []float64) []Record {
+	var records []Record
+	for index, value := range values {
+		position := start + index
+		if last := len(records) - 1; last >= 0 && records[last].Value == value && records[last].End == position {
+			records[last].End++
+			continue
+		}
+		records = append(records, Record{Chromosome: chromosome, Start: position, End: position + 1, Value: value})
+	}
+	return records
+}
+
+/******************************************************************************
+
+bedGraph parser begins here.
+
+******************************************************************************/
+
+// Parse parses every track of a bedGraph file. Data before the first track
+// line goes into an unnamed track. Browser lines and comments are skipped.
+// Gzipped files are decompressed.
+func Parse(r io.Reader) ([]Track, error) {
+	var tracks []Track
+	scanner := bufio.NewScanner(polyio.Decompress(r))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "", strings.HasPrefix(line, "#"), strings.HasPrefix(line, "browser"):
+			continue
+		case strings.HasPrefix(line, "track"):
+			track, err := parseTrackLine(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			tracks = append(tracks, track)
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("line %d: expected 4 fields, got %d", lineNumber, len(fields))
+		}
+		start, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid start: %w", lineNumber, err)
+		}
+		end, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid end: %w", lineNumber, err)
+		}
+		value, err := strconv.ParseFloat(fields[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid value: %w", lineNumber, err)
+		}
+		if start < 0 || end <= start {
+			return nil, fmt.Errorf("line %d: invalid range %d-%d", lineNumber, start, end)
+		}
+		if len(tracks) == 0 {
+			tracks = append(tracks, Track{Options: map[string]string{}})
+		}
+		last := &tracks[len(tracks)-1]
+		last.Records = append(last.Records, Record{Chromosome: fields[0], Start: start, End: end, Value: value})
+	}
+	return tracks, scanner.Err()
+}
+
+// parseTrackLine parses the key=value settings of a track line, some of
+// which may be quoted.
+func parseTrackLine(line string) (Track, error) {
+	track := Track{Options: map[string]string{}}
+	rest := strings.TrimSpace(strings.TrimPrefix(line, "track"))
+	for rest != "" {
+		equals := strings.IndexByte(rest, '=')
+		if equals <= 0 {
+			return Track{}, fmt.Errorf("malformed track setting %q", rest)
+		}
+		key := rest[:equals]
+		rest = rest[equals+1:]
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			closing := strings.IndexByte(rest[1:], '"')
+			if closing < 0 {
+				return Track{}, fmt.Errorf("unterminated quote in track setting %q", key)
+			}
+			value, rest = rest[1:closing+1], rest[closing+2:]
+		} else {
+			value, rest, _ = strings.Cut(rest, " ")
+		}
+		rest = strings.TrimSpace(rest)
+
+		switch key {
+		case "name":
+			track.Name = value
+		case "description":
+			track.Description = value
+		case "type":
+			if value != "bedGraph" {
+				return Track{}, fmt.Errorf("expected a bedGraph track, got type %q", value)
+			}
+		default:
+			track.Options[key] = value
+		}
+	}
+	return track, nil
+}
+
+// Read reads a bedGraph file from path.
+func Read(path string) ([]Track, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+/******************************************************************************
+
+bedGraph writer begins here.
+
+******************************************************************************/
+
+// Build builds bedGraph tracks into a byte slice.
+func Build(tracks []Track) ([]byte, error) {
+	var buffer bytes.Buffer
+	for _, track := range tracks {
+		buffer.WriteString("track type=bedGraph")
+		settings := [][2]string{}
+		if track.Name != "" {
+			settings = append(settings, [2]string{"name", track.Name})
+		}
+		if track.Description != "" {
+			settings = append(settings, [2]string{"description", track.Description})
+		}
+		keys := make([]string, 0, len(track.Options))
+		for key := range track.Options {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			settings = append(settings, [2]string{key, track.Options[key]})
+		}
+		for _, setting := range settings {
+			key, value := setting[0], setting[1]
+			// track lines quote with plain double quotes and have no escapes,
+			// so a value can't have a double quote or a line break in it.
+			if strings.ContainsAny(value, "\"\r\n") {
+				return nil, fmt.Errorf("track setting %s can't be written with a double quote or line break in it: %q", key, value)
+			}
+			if key == "name" || key == "description" || strings.ContainsAny(value, " \t") {
+				fmt.Fprintf(&buffer, ` %s="%s"`, key, value)
+			} else {
+				fmt.Fprintf(&buffer, " %s=%s", key, value)
+			}
+		}
+		buffer.WriteByte('\n')
+		for _, record := range track.Records {
+			if strings.ContainsAny(record.Chromosome, " \t") || record.Chromosome == "" {
+				return nil, fmt.Errorf("invalid chromosome name %q", record.Chromosome)
+			}
+			fmt.Fprintf(&buffer, "%s\t%d\t%d\t%s\n", record.Chromosome, record.Start, record.End, strconv.FormatFloat(record.Value, 'g', -1, 64))
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+// Write writes bedGraph tracks to path.
+func Write(tracks []Track, path string) error {
+	output, err := Build(tracks)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, output, 0644)
+}
@@ -0,0 +1,112 @@
+package bedgraph
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	tracks, err := Read("data/puc19_accessibility.bedgraph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracks) != 1 {
+		t.Fatalf("expected 1 track, got %d", len(tracks))
+	}
+	track := tracks[0]
+	if track.Name != "accessibility" || track.Description != "unpaired probability" {
+		t.Errorf("unexpected track settings %+v", track)
+	}
+	if !reflect.DeepEqual(track.Options, map[string]string{"visibility": "full", "color": "0,0,255"}) {
+		t.Errorf("unexpected track options %v", track.Options)
+	}
+	if len(track.Records) != 4 || track.Records[1] != (Record{Chromosome: "pUC19", Start: 12, End: 18, Value: 0.25}) {
+		t.Errorf("unexpected records %v", track.Records)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tracks, err := Read("data/puc19_accessibility.bedgraph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracks = append(tracks, Track{Name: "second", Options: map[string]string{"color": "255,0,0", "note": "two words"}, Records: FromProfile("pUC19", 100, []float64{1, 1, 0.5})})
+	// backslashes aren't escapes in track lines.
+	tracks = append(tracks, Track{Name: `C:\runs\day 1`, Description: `tab\t and it's`, Options: map[string]string{"url": `http://example.com/\x`, "note": `\`}})
+	path := filepath.Join(t.TempDir(), "round_trip.bedgraph")
+	if err := Write(tracks, path); err != nil {
+		t.Fatal(err)
+	}
+	written, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(written, tracks) {
+		t.Errorf("round trip changed tracks:\n%v\n%v", tracks, written)
+	}
+}
+
+func TestParseWithoutTrackLine(t *testing.T) {
+	tracks, err := Parse(strings.NewReader("chr1\t0\t10\t1.5\nchr1\t10\t20\t-2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracks) != 1 || tracks[0].Name != "" || len(tracks[0].Records) != 2 {
+		t.Errorf("expected a single unnamed track, got %+v", tracks)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		"chr1\t0\t10",
+		"chr1\ta\t10\t1",
+		"chr1\t0\tb\t1",
+		"chr1\t0\t10\tc",
+		"chr1\t10\t10\t1",
+		"chr1\t-1\t10\t1",
+		"track type=wiggle_0",
+		`track name="unterminated`,
+		"track name",
+	} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Parse(%q) should have failed", input)
+		}
+	}
+	if _, err := Read("data/does_not_exist.bedgraph"); err == nil {
+		t.Errorf("expected an error reading a missing file")
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	for _, track := range []Track{
+		{Name: `a "quoted" name`},
+		{Description: "two\nlines"},
+		{Options: map[string]string{"note": `"`}},
+	} {
+		if _, err := Build([]Track{track}); err == nil {
+			t.Errorf("Build should fail on track settings %+v", track)
+		}
+	}
+	for _, chromosome := range []string{"", "two words"} {
+		if _, err := Build([]Track{{Records: []Record{{Chromosome: chromosome, Start: 0, End: 1}}}}); err == nil {
+			t.Errorf("Build should fail on chromosome %q", chromosome)
+		}
+	}
+}
+
+func TestFromProfile(t *testing.T) {
+	got := FromProfile("construct", 10, []float64{0.5, 0.5, 0.5, 1, 0.5})
+	want := []Record{
+		{Chromosome: "construct", Start: 10, End: 13, Value: 0.5},
+		{Chromosome: "construct", Start: 13, End: 14, Value: 1},
+		{Chromosome: "construct", Start: 14, End: 15, Value: 0.5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FromProfile = %v, want %v", got, want)
+	}
+	if records := FromProfile("construct", 0, nil); records != nil {
+		t.Errorf("expected no records for an empty profile, got %v", records)
+	}
+}
@@ -0,0 +1,47 @@
+package bedgraph_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/fold/structure"
+	"github.com/bebop/poly/io/bedgraph"
+)
+
+func ExampleFromProfile() {
+	// base pair probabilities of a small hairpin, with the last 2 bases
+	// pairing with the first 2 most of the time.
+	probabilities := make([][]float64, 8)
+	for index := range probabilities {
+		probabilities[index] = make([]float64, 8)
+	}
+	probabilities[0][7], probabilities[1][6] = 0.75, 0.5
+
+	accessibility := structure.UnpairedProbabilities(probabilities)
+	track := bedgraph.Track{
+		Name:    "accessibility",
+		Records: bedgraph.FromProfile("hairpin", 0, accessibility),
+	}
+	output, _ := bedgraph.Build([]bedgraph.Track{track})
+	fmt.Print(string(output))
+
+	// Output:
+	// track type=bedGraph name="accessibility"
+	// hairpin	0	1	0.25
+	// hairpin	1	2	0.5
+	// hairpin	2	6	1
+	// hairpin	6	7	0.5
+	// hairpin	7	8	0.25
+}
+
+func ExampleRead() {
+	tracks, _ := bedgraph.Read("data/puc19_accessibility.bedgraph")
+	for _, record := range tracks[0].Records {
+		fmt.Println(record.Start, record.End, record.Value)
+	}
+
+	// Output:
+	// 0 12 0.91
+	// 12 18 0.25
+	// 18 24 0.04
+	// 24 60 0.63
+}
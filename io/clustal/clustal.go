@@ -0,0 +1,313 @@
+/*
+Package clustal contains Clustal alignment parsers and writers.
+
+Clustal is the format Clustal Omega, ClustalW, and MUSCLE write multiple
+sequence alignments in, and the one most alignment viewers expect. The
+alignment is split into blocks of a few dozen columns, with the name of each
+sequence before its part of the block and a line of symbols under each block
+marking how conserved its columns are:
+
+	CLUSTAL W (1.83) multiple sequence alignment
+
+	enzyme1         MKV-LLAG
+	enzyme2         MKVELLSG
+	enzyme3         MRV-LLAG
+	                *:* **:*
+
+A * marks a column where every sequence has the same residue, a : a column of
+residues with strongly similar properties, and a . a column of weakly similar
+ones. Blocks are joined back together when they're parsed, and the rows of a
+parsed alignment can be handed straight to the msa package to score how
+conserved each column is or to build a consensus.
+
+More information on Clustal can be found here:
+http://www.clustal.org/clustal2/
+*/
+package clustal
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	polyio "github.com/bebop/poly/io"
+)
+
+// DefaultHeader is the first line of written alignments without a header.
+const DefaultHeader = "CLUSTAL W multiple sequence alignment"
+
+// Alignment is a Clustal alignment.
+type Alignment struct {
+	// Header is the first line of the file, which names the program that
+	// made the alignment.
+	Header    string     `json:"header"`
+	Sequences []Sequence `json:"sequences"`
+	// Conservation is the line of conservation symbols under the alignment,
+	// one per column, or empty if it doesn't have one.
+	Conservation string `json:"conservation"`
+}
+
+// Sequence is an aligned sequence.
+type Sequence struct {
+	Name     string `json:"name"`
+	Sequence string `json:"sequence"`
+}
+
+// Rows returns the aligned sequences of an alignment, in order, for the msa
+// package.
+func (alignment Alignment) Rows() []string {
+	rows := make([]string, len(alignment.Sequences))
+	for index, sequence := range alignment.Sequences {
+		rows[index] = sequence.Sequence
+	}
+	return rows
+}
+
+// Names returns the names of the sequences of an alignment, in order.
+func (alignment Alignment) Names() []string {
+	names := make([]string, len(alignment.Sequences))
+	for index, sequence := range alignment.Sequences {
+		names[index] = sequence.Name
+	}
+	return names
+}
+
+/******************************************************************************
+
+Clustal parser begins here.
+
+Sequence lines are a name, the sequence's part of the block, and sometimes a
+running count of its residues. The conservation line under a block lines up
+with the sequences above it, so it's read from the column the block's
+sequences start at. Blocks where no column is conserved have a conservation
+line of only spaces, which can't be told apart from a blank line, so missing
+conservation is filled in with spaces.
+
+******************************************************************************/
+
+// Parse parses a Clustal alignment, gzipped or not.
+func Parse(r io.Reader) (Alignment, error) {
+	var alignment Alignment
+	indexes := map[string]int{}
+	var conservation strings.Builder
+	hasConservation := false
+	// blockStart is the column of the alignment the current block starts at,
+	// blockWidth how many columns it has, and offset where its sequences
+	// start on their lines.
+	blockStart, blockWidth, offset := 0, 0, 0
+	inBlock := false
+	scanner := bufio.NewScanner(polyio.Decompress(r))
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1<<26)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if alignment.Header == "" {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if !strings.HasPrefix(line, "CLUSTAL") && !strings.HasPrefix(line, "MUSCLE") {
+				return Alignment{}, fmt.Errorf("line %d: expected a CLUSTAL header, got %q", lineNumber, line)
+			}
+			alignment.Header = strings.TrimSpace(line)
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" || line[0] == ' ' || line[0] == '\t' {
+			if inBlock && strings.TrimSpace(line) != "" {
+				// the conservation line of the block.
+				symbols := ""
+				if offset < len(line) {
+					symbols = line[offset:min(len(line), offset+blockWidth)]
+				}
+				if strings.Trim(symbols, " *:.") != "" {
+					return Alignment{}, fmt.Errorf("line %d: unexpected conservation symbols %q", lineNumber, symbols)
+				}
+				conservation.WriteString(strings.Repeat(" ", blockStart-conservation.Len()))
+				conservation.WriteString(symbols)
+				hasConservation = true
+			}
+			inBlock = false
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields) > 3 {
+			return Alignment{}, fmt.Errorf("line %d: expected a name and aligned sequence, got %q", lineNumber, line)
+		}
+		name, residues := fields[0], fields[1]
+		index, ok := indexes[name]
+		if !ok {
+			index = len(alignment.Sequences)
+			indexes[name] = index
+			alignment.Sequences = append(alignment.Sequences, Sequence{Name: name})
+		}
+		if !inBlock {
+			inBlock = true
+			blockStart = len(alignment.Sequences[index].Sequence)
+			blockWidth = len(residues)
+			offset = len(name) + strings.Index(line[len(name):], residues)
+		}
+		alignment.Sequences[index].Sequence += residues
+	}
+	if err := scanner.Err(); err != nil {
+		return Alignment{}, err
+	}
+	if alignment.Header == "" {
+		return Alignment{}, fmt.Errorf("alignment is empty")
+	}
+	if err := check(alignment); err != nil {
+		return Alignment{}, err
+	}
+	if hasConservation {
+		length := len(alignment.Sequences[0].Sequence)
+		alignment.Conservation = conservation.String()
+		alignment.Conservation += strings.Repeat(" ", length-len(alignment.Conservation))
+	}
+	return alignment, nil
+}
+
+// check makes sure every sequence of an alignment, and its conservation
+// line, is as long as the alignment.
+func check(alignment Alignment) error {
+	if len(alignment.Sequences) == 0 {
+		return fmt.Errorf("alignment has no sequences")
+	}
+	length := len(alignment.Sequences[0].Sequence)
+	for _, sequence := range alignment.Sequences {
+		if len(sequence.Sequence) != length {
+			return fmt.Errorf("sequence %q has length %d, expected %d", sequence.Name, len(sequence.Sequence), length)
+		}
+	}
+	if alignment.Conservation != "" && len(alignment.Conservation) != length {
+		return fmt.Errorf("conservation line has length %d, expected %d", len(alignment.Conservation), length)
+	}
+	return nil
+}
+
+// Read reads a Clustal alignment from path.
+func Read(path string) (Alignment, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Alignment{}, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+/******************************************************************************
+
+Clustal writer begins here.
+
+******************************************************************************/
+
+// columnsPerBlock is how many columns written blocks have.
+const columnsPerBlock = 60
+
+// Build builds a Clustal alignment into a byte slice.
+func Build(alignment Alignment) ([]byte, error) {
+	if err := check(alignment); err != nil {
+		return nil, err
+	}
+	width := 0
+	for _, sequence := range alignment.Sequences {
+		if sequence.Name == "" || strings.IndexFunc(sequence.Name, unicode.IsSpace) != -1 {
+			return nil, fmt.Errorf("invalid sequence name %q", sequence.Name)
+		}
+		width = max(width, len(sequence.Name))
+	}
+	// names are padded to line the blocks up, with at least 6 spaces after the
+	// longest.
+	width = max(width+6, 16)
+
+	header := alignment.Header
+	if header == "" {
+		header = DefaultHeader
+	}
+	var buffer bytes.Buffer
+	buffer.WriteString(header + "\n\n")
+	length := len(alignment.Sequences[0].Sequence)
+	for start := 0; start < length; start += columnsPerBlock {
+		end := min(start+columnsPerBlock, length)
+		buffer.WriteByte('\n')
+		for _, sequence := range alignment.Sequences {
+			fmt.Fprintf(&buffer, "%-*s%s\n", width, sequence.Name, sequence.Sequence[start:end])
+		}
+		if alignment.Conservation != "" {
+			buffer.WriteString(strings.TrimRight(strings.Repeat(" ", width)+alignment.Conservation[start:end], " ") + "\n")
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+// Write writes a Clustal alignment to path.
+func Write(alignment Alignment, path string) error {
+	output, err := Build(alignment)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, output, 0644)
+}
+
+/******************************************************************************
+
+Conservation begins here.
+
+Clustal marks a column with a : when all of its residues are in one of the
+strong groups below, and with a . when they're all in one of the weak groups.
+These are the groups of amino acids that score over 0.5, and 0 or less, in
+the Gonnet PAM 250 matrix, as Clustal uses them.
+
+******************************************************************************/
+
+var (
+	strongGroups = []string{"STA", "NEQK", "NHQK", "NDEQ", "QHRK", "MILV", "MILF", "HY", "FYW"}
+	weakGroups   = []string{"CSA", "ATV", "SAG", "STNK", "STPA", "SGND", "SNDEQK", "NDEQHK", "NEQHRK", "FVLIM", "HFY"}
+)
+
+// ConservationLine returns the Clustal conservation symbols of the columns
+// of an alignment: * for columns of one residue, : and . for columns of
+// strongly and weakly similar amino acids, and a space for the rest,
+// including every column with a gap.
+func ConservationLine(rows []string) (string, error) {
+	if len(rows) == 0 {
+		return "", fmt.Errorf("alignment has no sequences")
+	}
+	for index, row := range rows {
+		if len(row) != len(rows[0]) {
+			return "", fmt.Errorf("row %d has length %d, expected %d", index, len(row), len(rows[0]))
+		}
+	}
+	symbols := make([]byte, len(rows[0]))
+	for column := range symbols {
+		residues := make([]byte, len(rows))
+		for index, row := range rows {
+			residues[index] = byte(unicode.ToUpper(rune(row[column])))
+		}
+		switch {
+		case strings.ContainsAny(string(residues), "-."):
+			symbols[column] = ' '
+		case strings.Count(string(residues), string(residues[0])) == len(residues):
+			symbols[column] = '*'
+		case inOneGroup(residues, strongGroups):
+			symbols[column] = ':'
+		case inOneGroup(residues, weakGroups):
+			symbols[column] = '.'
+		default:
+			symbols[column] = ' '
+		}
+	}
+	return string(symbols), nil
+}
+
+// inOneGroup checks whether every residue is in the same one of groups.
+func inOneGroup(residues []byte, groups []string) bool {
+	for _, group := range groups {
+		if strings.Trim(string(residues), group) == "" {
+			return true
+		}
+	}
+	return false
+}
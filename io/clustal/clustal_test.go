@@ -0,0 +1,107 @@
+package clustal
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// conservation is the conservation line of data/rps2.aln.
+const conservation = "**.*:*:::*.:*.******* ****** ***  ** ::**:*::*:. :::*   ::: . : *.:***"
+
+func TestRead(t *testing.T) {
+	alignment, err := Read("data/rps2.aln")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alignment.Header != "CLUSTAL O(1.2.4) multiple sequence alignment" {
+		t.Errorf("unexpected header %q", alignment.Header)
+	}
+	if !reflect.DeepEqual(alignment.Names(), []string{"sp|P0A7V0|RS2_ECOLI", "sp|Q9ZBR5|RS2_SALTY", "sp|P66536|RS2_MYCTU"}) {
+		t.Errorf("unexpected names %v", alignment.Names())
+	}
+	if alignment.Sequences[1].Sequence != "MATVSMRDMLQAGVHFGHQTRYWNPKMKPFIFGARNKVHIINLEKTVPMFNEALAELNKIA-RKGKILFV" {
+		t.Errorf("blocks weren't joined: %s", alignment.Sequences[1].Sequence)
+	}
+	if alignment.Conservation != conservation {
+		t.Errorf("unexpected conservation\n%q\n%q", alignment.Conservation, conservation)
+	}
+}
+
+func TestConservationLine(t *testing.T) {
+	alignment, err := Read("data/rps2.aln")
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, err := ConservationLine(alignment.Rows())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != conservation {
+		t.Errorf("unexpected conservation\n%q\n%q", line, conservation)
+	}
+	if _, err := ConservationLine([]string{"MKV", "MK"}); err == nil {
+		t.Errorf("expected an error for rows of different lengths")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	alignment, err := Read("data/rps2.aln")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the last block has no conserved columns, so its conservation line is
+	// blank.
+	alignment.Sequences = append(alignment.Sequences, Sequence{Name: "gaps", Sequence: strings.Repeat("-", 70)})
+	alignment.Conservation = conservation[:60] + strings.Repeat(" ", 10)
+	path := filepath.Join(t.TempDir(), "round_trip.aln")
+	if err := Write(alignment, path); err != nil {
+		t.Fatal(err)
+	}
+	written, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(written, alignment) {
+		t.Errorf("round trip changed alignment:\n%v\n%v", alignment, written)
+	}
+}
+
+func TestParseWithoutConservation(t *testing.T) {
+	alignment, err := Parse(strings.NewReader("MUSCLE (3.8) multiple sequence alignment\n\nseq1 MKV-LLAG\nseq2 MKVELLSG\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alignment.Conservation != "" || len(alignment.Sequences) != 2 {
+		t.Errorf("unexpected alignment %+v", alignment)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"# STOCKHOLM 1.0\n",
+		"CLUSTAL W\n\n",
+		"CLUSTAL W\n\nseq1 MKV\nseq2 MK\n",
+		"CLUSTAL W\n\nseq1 MKV 3 extra\n",
+		"CLUSTAL W\n\nseq1 MKV\nseq2 MKV\n     *X*\n",
+	} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("expected an error parsing %q", input)
+		}
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	for _, alignment := range []Alignment{
+		{},
+		{Sequences: []Sequence{{Name: "two words", Sequence: "MKV"}}},
+		{Sequences: []Sequence{{Name: "a", Sequence: "MKV"}, {Name: "b", Sequence: "MK"}}},
+		{Sequences: []Sequence{{Name: "a", Sequence: "MKV"}}, Conservation: "**"},
+	} {
+		if _, err := Build(alignment); err == nil {
+			t.Errorf("expected an error building %v", alignment)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package clustal_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/io/clustal"
+	"github.com/bebop/poly/search/align/msa"
+)
+
+// This example reads a Clustal alignment of ribosomal proteins and finds the
+// columns that vary the most between species.
+func ExampleRead() {
+	alignment, _ := clustal.Read("data/rps2.aln")
+
+	columns, _ := msa.Reference(alignment.Rows(), 0, msa.Options{})
+	for _, position := range msa.Variable(columns, 0.7) {
+		fmt.Printf("%c%d ", columns[position].Residue, position+1)
+	}
+	// Output: K11 S62
+}
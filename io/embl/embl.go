@@ -0,0 +1,670 @@
+/*
+Package embl provides EMBL flat file parsers and writers.
+
+EMBL is the flat file format used by the European Nucleotide Archive (ENA).
+It carries the same information as GenBank (the two databases, along with
+DDBJ, exchange data every day as part of the INSDC), just dressed differently:
+every line starts with a two letter code, and the feature table is prefixed
+with "FT".
+
+	ID   X56734; SV 1; linear; mRNA; STD; PLN; 1859 BP.
+	XX
+	AC   X56734; S46826;
+	XX
+	FT   CDS             14..1495
+	FT                   /product="beta-glucosidase"
+
+Since the information is the same, this package doesn't invent new structs.
+Records are parsed into the same genbank.Genbank struct io/genbank uses, so
+reading an EMBL file and writing it with genbank.Write (or the other way
+around) converts between the two formats.
+
+A few EMBL details have no home in a genbank.Genbank and are dropped while
+parsing: the data class and release numbers in the ID and DT lines, and
+reference cross references other than PubMed. Metadata that only exists in
+GenBank files (like DBLINK) is dropped while writing.
+
+Spec: https://ftp.ebi.ac.uk/pub/databases/embl/doc/usrman.txt
+*/
+package embl
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	polyio "github.com/bebop/poly/io"
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/warning"
+	"github.com/mitchellh/go-wordwrap"
+)
+
+/******************************************************************************
+
+EMBL specific IO related things begin here.
+
+******************************************************************************/
+
+var (
+	readFileFn = os.ReadFile
+)
+
+// lineWidth is the maximum width of an EMBL line.
+const lineWidth = 80
+
+// qualifierIndent is where feature locations and qualifiers start on an FT line.
+const qualifierIndent = 21
+
+// otherLineCodes are the line codes written back out from genbank.Meta.Other.
+// COMMENT, GenBank's name for a comment, is written as CC.
+var otherLineCodes = map[string]bool{
+	"AH": true, // assembly header
+	"AS": true, // assembly information
+	"CO": true, // contig assembly
+	"DR": true, // database cross reference
+	"OG": true, // organelle
+	"PR": true, // project
+}
+
+// Precompiled regular expressions:
+var (
+	sequenceRegex   = regexp.MustCompile("[^a-zA-Z]+")
+	dateRegex       = regexp.MustCompile(`\d{2}-[A-Z]{3}-\d{4}`)
+	rangeRegex      = regexp.MustCompile(`(\d+)\s*(?:-|to)\s*(\d+)`)
+	baseCountRegex  = regexp.MustCompile(`(\d+) ([A-Za-z]+);`)
+	commonNameRegex = regexp.MustCompile(`\s*\(.*\)$`)
+)
+
+// Read reads an EMBL file into a genbank.Genbank struct.
+func Read(path string) (genbank.Genbank, error) {
+	file, err := readFileFn(path)
+	if err != nil {
+		return genbank.Genbank{}, err
+	}
+	return Parse(bytes.NewReader(file))
+}
+
+// ReadMulti reads a file containing multiple EMBL records into a slice of genbank.Genbank structs.
+func ReadMulti(path string) ([]genbank.Genbank, error) {
+	file, err := readFileFn(path)
+	if err != nil {
+		return []genbank.Genbank{}, err
+	}
+	return ParseMulti(bytes.NewReader(file))
+}
+
+// Write takes a genbank.Genbank struct and writes it to path in EMBL format.
+func Write(sequence genbank.Genbank, path string) error {
+	return WriteMulti([]genbank.Genbank{sequence}, path)
+}
+
+// WriteMulti takes a slice of genbank.Genbank structs and writes them to path in EMBL format.
+func WriteMulti(sequences []genbank.Genbank, path string) error {
+	embl, err := BuildMulti(sequences)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, embl, 0644)
+}
+
+/******************************************************************************
+
+EMBL parsing begins here.
+
+******************************************************************************/
+
+// Parse takes in a reader representing a single EMBL record and parses it into a genbank.Genbank struct.
+func Parse(r io.Reader) (genbank.Genbank, error) {
+	sequences, err := ParseMultiNth(r, 1)
+	if err != nil {
+		return genbank.Genbank{}, err
+	}
+	if len(sequences) == 0 {
+		return genbank.Genbank{}, fmt.Errorf("no EMBL records found")
+	}
+	return sequences[0], nil
+}
+
+// ParseMulti takes in a reader representing a multi record EMBL file and parses it into a slice of genbank.Genbank structs.
+func ParseMulti(r io.Reader) ([]genbank.Genbank, error) {
+	return ParseMultiNth(r, -1)
+}
+
+// record collects the lines of a single EMBL record while it is being parsed.
+type record struct {
+	sequence        genbank.Genbank
+	lines           map[string][]string // content of metadata lines keyed by line code.
+	lineCodes       []string            // order in which line codes were first seen.
+	dates           []string
+	reference       *genbank.Reference
+	referenceLines  map[string][]string
+	feature         *genbank.Feature
+	locationString  string
+	qualifier       string
+	qualifierLines  []string
+	sequenceBuilder strings.Builder
+	idLine          int
+}
+
+// ParseMultiNth takes in a reader representing a multi record EMBL file and parses the first count records into a slice of genbank.Genbank structs.
+// A negative count parses every record. Gzipped files are decompressed.
+func ParseMultiNth(r io.Reader, count int) ([]genbank.Genbank, error) {
+	scanner := bufio.NewScanner(polyio.Decompress(r))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var sequences []genbank.Genbank
+	var current *record
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if count >= 0 && len(sequences) >= count {
+			break
+		}
+		line := strings.TrimRight(scanner.Text(), " \r")
+		if len(line) < 2 {
+			continue
+		}
+		code := line[:2]
+		var content string
+		if len(line) > 5 {
+			content = line[5:]
+		}
+
+		if current == nil {
+			if code != "ID" {
+				continue
+			}
+			current = &record{lines: make(map[string][]string), idLine: lineNumber}
+			current.sequence.Meta.Other = make(map[string]string)
+			if err := current.parseID(content); err != nil {
+				return sequences, fmt.Errorf("failed to parse ID on line %d: %w", lineNumber, err)
+			}
+			continue
+		}
+
+		var err error
+		switch code {
+		case "//":
+			var sequence genbank.Genbank
+			sequence, err = current.finish(lineNumber)
+			sequences = append(sequences, sequence)
+			current = nil
+		case "XX", "FH":
+		case "SQ":
+			current.parseBaseCount(content)
+		case "  ":
+			current.sequenceBuilder.WriteString(sequenceRegex.ReplaceAllString(line, ""))
+		case "DT":
+			if date := dateRegex.FindString(content); date != "" {
+				current.dates = append(current.dates, date)
+			}
+		case "RN":
+			current.finishReference()
+			current.reference = &genbank.Reference{}
+			current.referenceLines = make(map[string][]string)
+		case "RP", "RX", "RA", "RT", "RL", "RG", "RC":
+			if current.reference == nil {
+				return sequences, fmt.Errorf("reference line outside of a reference on line %d", lineNumber)
+			}
+			current.referenceLines[code] = append(current.referenceLines[code], strings.TrimSpace(content))
+		case "FT":
+			err = current.parseFeatureLine(line)
+		default:
+			if _, seen := current.lines[code]; !seen {
+				current.lineCodes = append(current.lineCodes, code)
+			}
+			current.lines[code] = append(current.lines[code], strings.TrimSpace(content))
+		}
+		if err != nil {
+			return sequences, fmt.Errorf("failed to parse line %d: %w", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return sequences, err
+	}
+	if current != nil {
+		return sequences, fmt.Errorf("EMBL record starting on line %d is missing its terminating //", current.idLine)
+	}
+	return sequences, nil
+}
+
+// parseID parses an ID line in either the current format
+//
+//	X56734; SV 1; linear; mRNA; STD; PLN; 1859 BP.
+//
+// or the pre 2006 format
+//
+//	AA03518    standard; DNA; FUN; 237 BP.
+func (current *record) parseID(content string) error {
+	fields := strings.Split(strings.TrimSuffix(strings.TrimSpace(content), "."), ";")
+	nameFields := strings.Fields(fields[0])
+	if len(nameFields) == 0 {
+		return fmt.Errorf("missing entry name")
+	}
+	locus := &current.sequence.Meta.Locus
+	locus.Name = nameFields[0]
+	locus.SequenceCoding = "bp"
+
+	var remaining []string
+	topologyFound := false
+	for _, field := range fields[1:] {
+		field = strings.TrimSpace(field)
+		switch {
+		case strings.HasPrefix(field, "SV "):
+			current.sequence.Meta.Version = locus.Name + "." + strings.TrimSpace(strings.TrimPrefix(field, "SV "))
+		case field == "circular" || field == "linear":
+			locus.Circular = field == "circular"
+			topologyFound = true
+		case strings.HasSuffix(field, " BP") || strings.HasSuffix(field, " AA"):
+			locus.SequenceLength = strings.Fields(field)[0]
+		case field != "":
+			remaining = append(remaining, field)
+		}
+	}
+	switch len(remaining) {
+	case 3: // molecule type; data class; taxonomic division
+		locus.MoleculeType, locus.GenbankDivision = remaining[0], remaining[2]
+	case 2: // molecule type; taxonomic division
+		locus.MoleculeType, locus.GenbankDivision = remaining[0], remaining[1]
+	case 1:
+		locus.MoleculeType = remaining[0]
+	}
+	if !topologyFound {
+		current.sequence.Warnings = append(current.sequence.Warnings, warning.AtLine(current.idLine, "ID does not specify topology, assuming linear"))
+	}
+	return nil
+}
+
+// parseBaseCount parses the base counts of an SQ line like
+//
+//	Sequence 1859 BP; 609 A; 314 C; 355 G; 581 T; 0 other;
+func (current *record) parseBaseCount(content string) {
+	for _, match := range baseCountRegex.FindAllStringSubmatch(content, -1) {
+		if match[2] == "BP" {
+			continue
+		}
+		count, _ := strconv.Atoi(match[1])
+		current.sequence.Meta.BaseCount = append(current.sequence.Meta.BaseCount, genbank.BaseCount{Base: strings.ToLower(match[2]), Count: count})
+	}
+}
+
+// parseFeatureLine parses a single FT line of the feature table.
+func (current *record) parseFeatureLine(line string) error {
+	if len(line) <= qualifierIndent {
+		return nil
+	}
+	text := strings.TrimSpace(line[qualifierIndent:])
+
+	// a new feature has its key in columns 6 to 20.
+	if key := strings.TrimSpace(line[5:qualifierIndent]); key != "" {
+		if err := current.finishFeature(); err != nil {
+			return err
+		}
+		current.feature = &genbank.Feature{Type: key, Attributes: make(map[string]string)}
+		current.locationString = text
+		return nil
+	}
+	if current.feature == nil {
+		return fmt.Errorf("feature qualifier before any feature key")
+	}
+
+	switch {
+	case current.qualifier != "" && isOpenQuote(strings.Join(current.qualifierLines, "")):
+		current.qualifierLines = append(current.qualifierLines, text)
+	case strings.HasPrefix(text, "/"):
+		current.finishQualifier()
+		key, value, _ := strings.Cut(text[1:], "=")
+		current.qualifier = key
+		current.qualifierLines = []string{value}
+	case current.qualifier == "":
+		current.locationString += text
+	default:
+		current.qualifierLines = append(current.qualifierLines, text)
+	}
+	return nil
+}
+
+// isOpenQuote reports whether a qualifier value starts a quoted string that hasn't been closed yet.
+func isOpenQuote(value string) bool {
+	return strings.HasPrefix(value, "\"") && strings.Count(value, "\"")%2 == 1
+}
+
+// finishQualifier stores the qualifier currently being parsed on the current feature.
+func (current *record) finishQualifier() {
+	if current.qualifier == "" {
+		return
+	}
+	separator := " "
+	if current.qualifier == "translation" {
+		separator = ""
+	}
+	value := strings.Join(current.qualifierLines, separator)
+	if len(value) >= 2 && strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
+		value = strings.ReplaceAll(value[1:len(value)-1], "\"\"", "\"")
+	}
+	current.feature.Attributes[current.qualifier] = value
+	current.feature.Qualifiers = append(current.feature.Qualifiers, genbank.Qualifier{Key: current.qualifier, Value: value})
+	current.qualifier = ""
+	current.qualifierLines = nil
+}
+
+// finishFeature parses the location of the current feature and adds it to the record.
+func (current *record) finishFeature() error {
+	if current.feature == nil {
+		return nil
+	}
+	current.finishQualifier()
+	location, err := genbank.ParseLocation(current.locationString)
+	if err != nil {
+		return fmt.Errorf("failed to parse location %q of %s feature: %w", current.locationString, current.feature.Type, err)
+	}
+	current.feature.Location = location
+	err = current.sequence.AddFeature(current.feature)
+	current.feature = nil
+	return err
+}
+
+// finishReference stores the reference currently being parsed on the record.
+func (current *record) finishReference() {
+	if current.reference == nil {
+		return
+	}
+	reference := current.reference
+	lines := current.referenceLines
+
+	if ranges := rangeRegex.FindAllStringSubmatch(strings.Join(lines["RP"], " "), -1); len(ranges) > 0 {
+		var basesRanges []string
+		for _, match := range ranges {
+			basesRanges = append(basesRanges, match[1]+" to "+match[2])
+		}
+		reference.Range = "(bases " + strings.Join(basesRanges, "; ") + ")"
+	}
+	for _, crossReference := range lines["RX"] {
+		database, identifier, found := strings.Cut(crossReference, ";")
+		if found && strings.TrimSpace(database) == "PUBMED" {
+			reference.PubMed = strings.TrimSuffix(strings.TrimSpace(identifier), ".")
+		}
+	}
+	reference.Authors = strings.TrimSuffix(strings.Join(lines["RA"], " "), ";")
+	title := strings.TrimSuffix(strings.Join(lines["RT"], " "), ";")
+	reference.Title = strings.TrimSuffix(strings.TrimPrefix(title, "\""), "\"")
+	reference.Journal = strings.Join(lines["RL"], " ")
+	reference.Consortium = strings.TrimSuffix(strings.Join(lines["RG"], " "), ";")
+	reference.Remark = strings.Join(lines["RC"], " ")
+
+	current.sequence.Meta.References = append(current.sequence.Meta.References, *reference)
+	current.reference = nil
+	current.referenceLines = nil
+}
+
+// finish turns the collected lines of a record into a genbank.Genbank.
+func (current *record) finish(lineNumber int) (genbank.Genbank, error) {
+	current.finishReference()
+	if err := current.finishFeature(); err != nil {
+		return genbank.Genbank{}, err
+	}
+	meta := &current.sequence.Meta
+
+	var accessions []string
+	for _, line := range current.lines["AC"] {
+		for _, accession := range strings.Split(line, ";") {
+			if accession = strings.TrimSpace(accession); accession != "" {
+				accessions = append(accessions, accession)
+			}
+		}
+	}
+	meta.Accession = strings.Join(accessions, " ")
+	meta.Definition = strings.Join(current.lines["DE"], " ")
+	meta.Keywords = strings.Join(current.lines["KW"], " ")
+	meta.Source = strings.Join(current.lines["OS"], " ")
+	meta.Organism = commonNameRegex.ReplaceAllString(meta.Source, "")
+	if taxonomy := strings.TrimSuffix(strings.Join(current.lines["OC"], " "), "."); taxonomy != "" {
+		meta.Taxonomy = strings.Split(taxonomy, "; ")
+	}
+	if len(current.dates) > 0 {
+		meta.Date = current.dates[0]
+		meta.Locus.ModificationDate = current.dates[len(current.dates)-1]
+	}
+	for _, code := range current.lineCodes {
+		switch code {
+		case "AC", "DE", "KW", "OS", "OC":
+		case "CC":
+			meta.Other["COMMENT"] = strings.Join(current.lines[code], "\n")
+		default:
+			meta.Other[code] = strings.Join(current.lines[code], "\n")
+		}
+	}
+
+	current.sequence.Sequence = current.sequenceBuilder.String()
+	if length := meta.Locus.SequenceLength; length != "" && length != strconv.Itoa(len(current.sequence.Sequence)) {
+		current.sequence.Warnings = append(current.sequence.Warnings, warning.AtLine(lineNumber, "ID declares %s BP but the sequence is %d BP", length, len(current.sequence.Sequence)))
+	}
+	return current.sequence, nil
+}
+
+/******************************************************************************
+
+EMBL writing begins here.
+
+******************************************************************************/
+
+// Build builds an EMBL byte slice from a genbank.Genbank struct.
+func Build(sequence genbank.Genbank) ([]byte, error) {
+	return BuildMulti([]genbank.Genbank{sequence})
+}
+
+// BuildMulti builds a multi record EMBL byte slice from a slice of genbank.Genbank structs.
+func BuildMulti(sequences []genbank.Genbank) ([]byte, error) {
+	var embl bytes.Buffer
+	for _, sequence := range sequences {
+		meta := sequence.Meta
+		locus := meta.Locus
+
+		topology := "linear"
+		if locus.Circular {
+			topology = "circular"
+		}
+		version := "1"
+		if fields := strings.Fields(meta.Version); len(fields) > 0 {
+			if sequenceVersion := fields[0][strings.LastIndex(fields[0], ".")+1:]; isNumber(sequenceVersion) {
+				version = sequenceVersion
+			}
+		}
+		moleculeType := locus.MoleculeType
+		if moleculeType == "" {
+			moleculeType = "unassigned DNA"
+		}
+		division := locus.GenbankDivision
+		if division == "" {
+			division = "UNC"
+		}
+		fmt.Fprintf(&embl, "ID   %s; SV %s; %s; %s; STD; %s; %d BP.\n", locus.Name, version, topology, moleculeType, division, len(sequence.Sequence))
+		embl.WriteString("XX\n")
+
+		if meta.Accession != "" {
+			writeLines(&embl, "AC", strings.Join(strings.Fields(meta.Accession), "; ")+";")
+			embl.WriteString("XX\n")
+		}
+		if meta.Other["PR"] != "" {
+			writeRawLines(&embl, "PR", meta.Other["PR"])
+			embl.WriteString("XX\n")
+		}
+		if meta.Date != "" || locus.ModificationDate != "" {
+			created, updated := meta.Date, locus.ModificationDate
+			if created == "" {
+				created = updated
+			}
+			if updated == "" {
+				updated = created
+			}
+			fmt.Fprintf(&embl, "DT   %s (Created)\nDT   %s (Last updated)\nXX\n", created, updated)
+		}
+		if meta.Definition != "" {
+			writeLines(&embl, "DE", meta.Definition)
+			embl.WriteString("XX\n")
+		}
+		if meta.Keywords != "" {
+			writeLines(&embl, "KW", meta.Keywords)
+			embl.WriteString("XX\n")
+		}
+		source := meta.Source
+		if source == "" {
+			source = meta.Organism
+		}
+		if source != "" {
+			writeLines(&embl, "OS", source)
+			if len(meta.Taxonomy) > 0 {
+				writeLines(&embl, "OC", strings.Join(meta.Taxonomy, "; ")+".")
+			}
+			if meta.Other["OG"] != "" {
+				writeRawLines(&embl, "OG", meta.Other["OG"])
+			}
+			embl.WriteString("XX\n")
+		}
+
+		for referenceIndex, reference := range meta.References {
+			fmt.Fprintf(&embl, "RN   [%d]\n", referenceIndex+1)
+			if reference.Remark != "" {
+				writeLines(&embl, "RC", reference.Remark)
+			}
+			if ranges := rangeRegex.FindAllStringSubmatch(reference.Range, -1); len(ranges) > 0 {
+				var positions []string
+				for _, match := range ranges {
+					positions = append(positions, match[1]+"-"+match[2])
+				}
+				writeLines(&embl, "RP", strings.Join(positions, ", "))
+			}
+			if reference.PubMed != "" {
+				writeLines(&embl, "RX", "PUBMED; "+reference.PubMed+".")
+			}
+			if reference.Consortium != "" {
+				writeLines(&embl, "RG", reference.Consortium)
+			}
+			writeLines(&embl, "RA", reference.Authors+";")
+			if reference.Title != "" {
+				writeLines(&embl, "RT", "\""+reference.Title+"\";")
+			} else {
+				writeLines(&embl, "RT", ";")
+			}
+			writeLines(&embl, "RL", reference.Journal)
+			embl.WriteString("XX\n")
+		}
+
+		var otherCodes []string
+		for code := range meta.Other {
+			if otherLineCodes[code] && code != "PR" && code != "OG" {
+				otherCodes = append(otherCodes, code)
+			}
+		}
+		sort.Strings(otherCodes)
+		for _, code := range otherCodes {
+			writeRawLines(&embl, code, meta.Other[code])
+			embl.WriteString("XX\n")
+		}
+		if comment := meta.Other["COMMENT"]; comment != "" {
+			writeRawLines(&embl, "CC", comment)
+			embl.WriteString("XX\n")
+		}
+
+		embl.WriteString("FH   Key             Location/Qualifiers\nFH\n")
+		for _, feature := range sequence.Features {
+			embl.WriteString(BuildFeatureString(feature))
+		}
+		embl.WriteString("XX\n")
+
+		writeSequence(&embl, sequence.Sequence)
+		embl.WriteString("//\n")
+	}
+	return embl.Bytes(), nil
+}
+
+// writeLines word wraps text onto lines starting with code.
+func writeLines(embl *bytes.Buffer, code string, text string) {
+	for _, line := range wrap(text, lineWidth-5, false) {
+		embl.WriteString(strings.TrimRight(code+"   "+line, " ") + "\n")
+	}
+}
+
+// writeRawLines writes each line of text, which was parsed as is, back out with code.
+func writeRawLines(embl *bytes.Buffer, code string, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		writeLines(embl, code, line)
+	}
+}
+
+// wrap splits text into lines no wider than width, breaking on spaces where
+// possible. If hard is true, or a single word is wider than width, text is
+// broken mid word.
+func wrap(text string, width int, hard bool) []string {
+	var lines []string
+	if !hard {
+		text = wordwrap.WrapString(text, uint(width))
+	}
+	for _, line := range strings.Split(text, "\n") {
+		for len(line) > width {
+			lines = append(lines, line[:width])
+			line = line[width:]
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// BuildFeatureString builds the FT lines of a single feature.
+func BuildFeatureString(feature genbank.Feature) string {
+	var featureString strings.Builder
+	location := feature.Location.GbkLocationString
+	if location == "" {
+		location = genbank.BuildLocationString(feature.Location)
+	}
+	prefix := fmt.Sprintf("FT   %-16s", feature.Type)
+	for _, line := range genbank.WrapLocation(location, lineWidth-qualifierIndent) {
+		featureString.WriteString(prefix + line + "\n")
+		prefix = "FT" + strings.Repeat(" ", qualifierIndent-2)
+	}
+
+	for _, qualifier := range feature.OrderedQualifiers() {
+		value := qualifier.Value
+		text := "/" + qualifier.Key
+		switch {
+		case value == "":
+		case isNumber(value):
+			text += "=" + value
+		default:
+			text += "=\"" + strings.ReplaceAll(value, "\"", "\"\"") + "\""
+		}
+		for _, line := range wrap(text, lineWidth-qualifierIndent, qualifier.Key == "translation") {
+			featureString.WriteString(prefix + line + "\n")
+		}
+	}
+	return featureString.String()
+}
+
+// isNumber reports whether a qualifier value is a plain number, which EMBL leaves unquoted.
+func isNumber(value string) bool {
+	_, err := strconv.Atoi(value)
+	return err == nil
+}
+
+// writeSequence writes the SQ header and the sequence itself, 60 bases a line.
+func writeSequence(embl *bytes.Buffer, sequence string) {
+	counts := make(map[byte]int)
+	for index := 0; index < len(sequence); index++ {
+		counts[sequence[index]|0x20]++ // lowercase
+	}
+	other := len(sequence) - counts['a'] - counts['c'] - counts['g'] - counts['t']
+	fmt.Fprintf(embl, "SQ   Sequence %d BP; %d A; %d C; %d G; %d T; %d other;\n", len(sequence), counts['a'], counts['c'], counts['g'], counts['t'], other)
+
+	for lineStart := 0; lineStart < len(sequence); lineStart += 60 {
+		lineEnd := min(lineStart+60, len(sequence))
+		var blocks []string
+		for blockStart := lineStart; blockStart < lineEnd; blockStart += 10 {
+			blocks = append(blocks, sequence[blockStart:min(blockStart+10, lineEnd)])
+		}
+		fmt.Fprintf(embl, "     %-66s%9d\n", strings.Join(blocks, " "), lineEnd)
+	}
+}
@@ -0,0 +1,216 @@
+package embl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+var ignoreParents = cmpopts.IgnoreFields(genbank.Feature{}, "ParentSequence")
+
+func TestParse(t *testing.T) {
+	sequence, err := Read("data/X56734_trimmed.embl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedLocus := genbank.Locus{
+		Name:             "X56734",
+		SequenceLength:   "180",
+		MoleculeType:     "mRNA",
+		GenbankDivision:  "PLN",
+		ModificationDate: "25-NOV-2005",
+		SequenceCoding:   "bp",
+	}
+	if diff := cmp.Diff(expectedLocus, sequence.Meta.Locus); diff != "" {
+		t.Errorf("unexpected locus:\n%s", diff)
+	}
+	meta := sequence.Meta
+	if meta.Accession != "X56734 S46826" || meta.Version != "X56734.1" || meta.Date != "12-SEP-1991" {
+		t.Errorf("unexpected accession %q, version %q, or date %q", meta.Accession, meta.Version, meta.Date)
+	}
+	if meta.Definition != "Trifolium repens mRNA for non-cyanogenic beta-glucosidase, trimmed for poly's tests" {
+		t.Errorf("unexpected definition %q", meta.Definition)
+	}
+	if meta.Source != "Trifolium repens (white clover)" || meta.Organism != "Trifolium repens" {
+		t.Errorf("unexpected source %q or organism %q", meta.Source, meta.Organism)
+	}
+	if len(meta.Taxonomy) != 17 || meta.Taxonomy[0] != "Eukaryota" || meta.Taxonomy[16] != "Trifolium" {
+		t.Errorf("unexpected taxonomy %q", meta.Taxonomy)
+	}
+	if meta.Other["DR"] != "MD5; 1e51ca3a5450c43524b9185c236cc5cc." || !strings.HasPrefix(meta.Other["COMMENT"], "This record was trimmed") {
+		t.Errorf("unexpected other metadata %q", meta.Other)
+	}
+
+	expectedReferences := []genbank.Reference{
+		{
+			Authors: "Oxtoby E., Dunn M.A., Pancoro A., Hughes M.A.",
+			Title:   "Nucleotide and derived amino acid sequence of the cyanogenic beta-glucosidase (linamarase) from white clover (Trifolium repens L.)",
+			Journal: "Plant Mol. Biol. 17(2):209-219(1991).",
+			PubMed:  "1907511",
+			Range:   "(bases 1 to 180)",
+		},
+		{
+			Authors: "Hughes M.A.",
+			Journal: "Submitted (19-NOV-1990) to the INSDC. M.A. Hughes, UNIVERSITY OF NEWCASTLE UPON TYNE, MEDICAL SCHOOL, NEW CASTLE UPON TYNE, NE2 4HH, UK",
+			Range:   "(bases 1 to 90; 120 to 180)",
+		},
+	}
+	if diff := cmp.Diff(expectedReferences, meta.References); diff != "" {
+		t.Errorf("unexpected references:\n%s", diff)
+	}
+	if diff := cmp.Diff([]genbank.BaseCount{{Base: "a", Count: 60}, {Base: "c", Count: 29}, {Base: "g", Count: 35}, {Base: "t", Count: 56}, {Base: "other", Count: 0}}, meta.BaseCount); diff != "" {
+		t.Errorf("unexpected base count:\n%s", diff)
+	}
+	if len(sequence.Sequence) != 180 || !strings.HasPrefix(sequence.Sequence, "aaacaaacca") {
+		t.Errorf("unexpected sequence %q", sequence.Sequence)
+	}
+	if len(sequence.Warnings) != 0 {
+		t.Errorf("unexpected warnings %v", sequence.Warnings)
+	}
+
+	if len(sequence.Features) != 4 {
+		t.Fatalf("expected 4 features, got %d", len(sequence.Features))
+	}
+	mRNA := sequence.Features[1]
+	if mRNA.Location.GbkLocationString != "join(1..60,91..>180)" || !mRNA.Location.Join || len(mRNA.Location.SubLocations) != 2 {
+		t.Errorf("unexpected wrapped join location %+v", mRNA.Location)
+	}
+	if mRNA.Attributes["note"] != "a joined location that wraps onto a second line, and a note that wraps as well" {
+		t.Errorf("unexpected wrapped note %q", mRNA.Attributes["note"])
+	}
+	cds := sequence.Features[2]
+	expectedAttributes := map[string]string{
+		"codon_start": "1",
+		"product":     "beta-glucosidase",
+		"note":        `contains "quoted" text`,
+		"translation": "MDFIVAIFALFVISSFTITSTNAVEASTLLDIGNLSRSSFPRGFIFGAGSSAYQFEGAVNEGGRGPSIWDTFTHKYPEKIRDGSNADITVDQYHRYKEDVG",
+	}
+	if diff := cmp.Diff(expectedAttributes, cds.Attributes); diff != "" {
+		t.Errorf("unexpected CDS qualifiers:\n%s", diff)
+	}
+	if !cds.Location.ThreePrimePartial || cds.Location.Start != 13 || cds.Location.End != 180 {
+		t.Errorf("unexpected CDS location %+v", cds.Location)
+	}
+	misc := sequence.Features[3]
+	if value, ok := misc.Attributes["pseudo"]; !ok || value != "" {
+		t.Errorf("expected an empty /pseudo qualifier, got %q, %t", value, ok)
+	}
+	featureSequence, err := misc.GetSequence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if featureSequence != "tgacttctacgttaagtagtactatgctgat" {
+		t.Errorf("unexpected complement feature sequence %q", featureSequence)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	sequence, err := Read("data/X56734_trimmed.embl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	built, err := Build(sequence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for index, line := range strings.Split(string(built), "\n") {
+		if len(line) > lineWidth {
+			t.Errorf("line %d is %d characters long: %q", index+1, len(line), line)
+		}
+	}
+	reparsed, err := Parse(bytes.NewReader(built))
+	if err != nil {
+		t.Fatalf("failed to parse built EMBL: %s\n%s", err, built)
+	}
+	if diff := cmp.Diff(sequence, reparsed, ignoreParents); diff != "" {
+		t.Errorf("parsing the output of Build() does not match the original:\n%s", diff)
+	}
+}
+
+func TestGenbankConversion(t *testing.T) {
+	tmpDataDir, err := os.MkdirTemp("", "data-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDataDir)
+
+	for _, gbkPath := range []string{"../../data/puc19.gbk", "../../data/t4_intron.gb", "../../data/phix174.gb", "../../data/sample.gbk"} {
+		gbk, err := genbank.Read(gbkPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		emblPath := filepath.Join(tmpDataDir, filepath.Base(gbkPath)+".embl")
+		if err := Write(gbk, emblPath); err != nil {
+			t.Fatal(err)
+		}
+		converted, err := Read(emblPath)
+		if err != nil {
+			t.Fatalf("%s: %s", gbkPath, err)
+		}
+		if diff := cmp.Diff(gbk.Features, converted.Features, ignoreParents); diff != "" {
+			t.Errorf("%s: features changed converting to EMBL:\n%s", gbkPath, diff)
+		}
+		if converted.Sequence != gbk.Sequence || converted.Meta.Locus.Circular != gbk.Meta.Locus.Circular {
+			t.Errorf("%s: sequence or topology changed converting to EMBL", gbkPath)
+		}
+		if diff := cmp.Diff(gbk.Meta.Taxonomy, converted.Meta.Taxonomy); diff != "" {
+			t.Errorf("%s: taxonomy changed converting to EMBL:\n%s", gbkPath, diff)
+		}
+	}
+}
+
+func TestParseMulti(t *testing.T) {
+	file, err := os.ReadFile("data/X56734_trimmed.embl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	multi := append(append([]byte{}, file...), file...)
+	sequences, err := ParseMulti(bytes.NewReader(multi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sequences) != 2 {
+		t.Errorf("expected 2 records, got %d", len(sequences))
+	}
+	sequences, err = ParseMultiNth(bytes.NewReader(multi), 1)
+	if err != nil || len(sequences) != 1 {
+		t.Errorf("expected 1 record from ParseMultiNth, got %d, %v", len(sequences), err)
+	}
+}
+
+func TestParseOldID(t *testing.T) {
+	record := "ID   AA03518    standard; DNA; FUN; 12 BP.\nXX\nSQ   Sequence 12 BP;\n     atgcatgcat gc        12\n//\n"
+	sequence, err := Parse(strings.NewReader(record))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sequence.Meta.Locus.Name != "AA03518" || sequence.Meta.Locus.MoleculeType != "DNA" || sequence.Meta.Locus.GenbankDivision != "FUN" {
+		t.Errorf("unexpected locus %+v", sequence.Meta.Locus)
+	}
+	if len(sequence.Warnings) != 1 || !strings.Contains(sequence.Warnings[0].Message, "topology") {
+		t.Errorf("expected a topology warning, got %v", sequence.Warnings)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	records := map[string]string{
+		"no records":         "XX\n",
+		"no terminator":      "ID   X; SV 1; linear; DNA; STD; SYN; 4 BP.\nSQ   Sequence 4 BP;\n     atgc 4\n",
+		"bad location":       "ID   X; SV 1; linear; DNA; STD; SYN; 4 BP.\nFT   CDS             1..x\n//\n",
+		"orphan qualifier":   "ID   X; SV 1; linear; DNA; STD; SYN; 4 BP.\nFT                   /note=\"hi\"\n//\n",
+		"orphan reference":   "ID   X; SV 1; linear; DNA; STD; SYN; 4 BP.\nRA   Nobody;\n//\n",
+		"missing entry name": "ID   ; SV 1\n//\n",
+	}
+	for name, record := range records {
+		if _, err := Parse(strings.NewReader(record)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
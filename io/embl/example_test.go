@@ -0,0 +1,38 @@
+package embl_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bebop/poly/io/embl"
+	"github.com/bebop/poly/io/genbank"
+)
+
+func ExampleRead() {
+	sequence, _ := embl.Read("data/X56734_trimmed.embl")
+	fmt.Println(sequence.Meta.Locus.Name, sequence.Meta.Organism)
+	fmt.Println(sequence.Features[2].Type, sequence.Features[2].Attributes["product"])
+
+	// Output:
+	// X56734 Trifolium repens
+	// CDS beta-glucosidase
+}
+
+func ExampleWrite() {
+	tmpDataDir, err := os.MkdirTemp("", "data-*")
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+	defer os.RemoveAll(tmpDataDir)
+
+	// convert a GenBank file to EMBL and back again.
+	puc19, _ := genbank.Read("../../data/puc19.gbk")
+	path := filepath.Join(tmpDataDir, "puc19.embl")
+	_ = embl.Write(puc19, path)
+
+	converted, _ := embl.Read(path)
+	fmt.Println(converted.Sequence == puc19.Sequence, len(converted.Features) == len(puc19.Features))
+
+	// Output: true true
+}
@@ -1,6 +1,10 @@
 package io_test
 
 import (
+	"fmt"
+	"strings"
+
+	polyio "github.com/bebop/poly/io"
 	"github.com/bebop/poly/io/fasta"
 	"github.com/bebop/poly/io/genbank"
 	"github.com/bebop/poly/io/gff"
@@ -30,3 +34,18 @@ func Example() {
 	// 2. If you want to convert from one format to another (e.g. genbank to polyjson), you can easily do so with a for-loop and some field mapping.
 	// 3. Every file format is unique but they all share a common interface so you can use them with almost every native function in Poly.
 }
+
+func ExampleDetect() {
+	piped := strings.NewReader(">pUC19 fragment\nTCGCGCGTTTCGGTGATGACGG\n")
+
+	format, reader, err := polyio.Detect(piped)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if format == polyio.FASTA {
+		records, _ := fasta.Parse(reader)
+		fmt.Println(format, records[0].Name)
+	}
+	// Output: fasta pUC19 fragment
+}
@@ -112,3 +112,23 @@ func ExampleParser() {
 	// MCHU - Calmodulin - Human, rabbit, bovine, rat, and chicken
 	// EOF
 }
+
+func ExampleOpenIndexed() {
+	indexed, err := fasta.OpenIndexed("data/index_test.fasta")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer indexed.Close()
+
+	// bases 5 to 15 of seq1, which are split across its first two lines.
+	subsequence, _ := indexed.FetchSubsequence("seq1", 5, 15)
+	fmt.Println(subsequence)
+	fmt.Print(string(indexed.Index().Build()))
+	// Output:
+	// CGTACGTACG
+	// seq1	23	21	10	11
+	// seq2	15	53	10	11
+	// seq3	0	83	0	0
+	// seq4	5	89	5	6
+}
@@ -7,7 +7,8 @@ languages. However, this simplicity means that annotation of genetic objects
 is not supported.
 
 This package provides a parser and writer for working with Fasta formatted
-genetic sequences.
+genetic sequences, and samtools compatible .fai indexes, to read parts of
+sequences out of files too big to parse whole.
 */
 package fasta
 
@@ -22,6 +23,9 @@ import (
 	"os"
 	"strings"
 	"unsafe"
+
+	polyio "github.com/bebop/poly/io"
+	"github.com/bebop/poly/sequence"
 )
 
 /******************************************************************************
@@ -68,6 +72,17 @@ type Fasta struct {
 	Sequence string `json:"sequence"`
 }
 
+// ToSequence returns a Fasta's sequence. FASTA doesn't say what molecule a
+// sequence is, so it's guessed with sequence.New, and assumed linear.
+func (fasta Fasta) ToSequence() sequence.Sequence {
+	return sequence.New(fasta.Name, fasta.Sequence)
+}
+
+// FromSequence returns a Fasta of a sequence.
+func FromSequence(input sequence.Sequence) Fasta {
+	return Fasta{Name: input.Name, Sequence: input.Sequence}
+}
+
 // Parse parses a given Fasta file into an array of Fasta structs. Internally, it uses ParseFastaConcurrent.
 func Parse(r io.Reader) ([]Fasta, error) {
 	// 32kB is a magic number often used by the Go stdlib for parsing. We multiply it by two.
@@ -86,10 +101,11 @@ type Parser struct {
 }
 
 // NewParser returns a Parser that uses r as the source
-// from which to parse fasta formatted sequences.
+// from which to parse fasta formatted sequences. Gzipped and bgzipped
+// sources are decompressed.
 func NewParser(r io.Reader, maxLineSize int) *Parser {
 	return &Parser{
-		reader: *bufio.NewReaderSize(r, maxLineSize),
+		reader: *bufio.NewReaderSize(polyio.Decompress(r), maxLineSize),
 	}
 }
 
@@ -242,7 +258,7 @@ func (parser *Parser) ParseNext() (Fasta, int64, error) {
 
 // Reset discards all data in buffer and resets state.
 func (parser *Parser) Reset(r io.Reader) {
-	parser.reader.Reset(r)
+	parser.reader.Reset(polyio.Decompress(r))
 	parser.line = 0
 }
 
@@ -254,7 +270,7 @@ func ParseConcurrent(r io.Reader, sequences chan<- Fasta) {
 	start := true
 
 	// Start the scanner
-	scanner := bufio.NewScanner(r)
+	scanner := bufio.NewScanner(polyio.Decompress(r))
 	for scanner.Scan() {
 		line := scanner.Text()
 		switch {
@@ -351,8 +367,25 @@ Start of  Write functions
 
 ******************************************************************************/
 
+// WriteOptions changes how fastas are built and written. Zero values are
+// replaced with the defaults noted on each field.
+type WriteOptions struct {
+	// LineWidth is how many characters of sequence are written per line.
+	// Defaults to 80. A negative width writes every sequence on one line.
+	LineWidth int
+}
+
 // Build converts a Fastas array into a byte array to be written to a file.
 func Build(fastas []Fasta) ([]byte, error) {
+	return BuildWithOptions(fastas, WriteOptions{})
+}
+
+// BuildWithOptions converts a Fastas array into a byte array like Build,
+// changed by options.
+func BuildWithOptions(fastas []Fasta, options WriteOptions) ([]byte, error) {
+	if options.LineWidth == 0 {
+		options.LineWidth = 80
+	}
 	var fastaString bytes.Buffer
 	fastaLength := len(fastas)
 	for fastaIndex, fasta := range fastas {
@@ -361,11 +394,11 @@ func Build(fastas []Fasta) ([]byte, error) {
 		fastaString.WriteString("\n")
 
 		lineCount := 0
-		// write the fasta sequence 80 characters at a time
+		// write the fasta sequence LineWidth characters at a time
 		for _, character := range fasta.Sequence {
 			fastaString.WriteRune(character)
 			lineCount++
-			if lineCount == 80 {
+			if lineCount == options.LineWidth {
 				fastaString.WriteString("\n")
 				lineCount = 0
 			}
@@ -385,3 +418,13 @@ func Write(fastas []Fasta, path string) error {
 	}
 	return os.WriteFile(path, fastaBytes, 0644)
 }
+
+// WriteWithOptions writes a fasta array to a file like Write, changed by
+// options.
+func WriteWithOptions(fastas []Fasta, path string, options WriteOptions) error {
+	fastaBytes, err := BuildWithOptions(fastas, options)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, fastaBytes, 0644)
+}
@@ -239,3 +239,25 @@ func TestParseEOFAfterName(t *testing.T) {
 		t.Error("expected error, got nil")
 	}
 }
+
+func TestBuildWithOptions(t *testing.T) {
+	fastas := []Fasta{{Name: "first", Sequence: "ATGCATGCAT"}, {Name: "second", Sequence: "GGCC"}}
+	built, err := BuildWithOptions(fastas, WriteOptions{LineWidth: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, ">first\nATGC\nATGC\nAT\n\n>second\nGGCC\n", string(built))
+
+	built, err = BuildWithOptions(fastas, WriteOptions{LineWidth: -1})
+	assert.NoError(t, err)
+	assert.Equal(t, ">first\nATGCATGCAT\n\n>second\nGGCC", string(built))
+
+	// the default matches Build.
+	want, _ := Build(fastas)
+	built, _ = BuildWithOptions(fastas, WriteOptions{})
+	assert.Equal(t, want, built)
+
+	path := t.TempDir() + "/test.fasta"
+	assert.NoError(t, WriteWithOptions(fastas, path, WriteOptions{LineWidth: 4}))
+	written, err := Read(path)
+	assert.NoError(t, err)
+	assert.Equal(t, fastas, written)
+}
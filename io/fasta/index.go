@@ -0,0 +1,309 @@
+package fasta
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+/******************************************************************************
+
+FASTA indexing begins here.
+
+Genome FASTA files are gigabytes of sequence, and reading a whole one to get
+a few hundred bases out of one chromosome is slow. A .fai index, the one
+samtools faidx writes, records where each sequence starts in the file and how
+its lines are laid out, which is enough to work out exactly which bytes hold
+any range of it:
+
+	chr1	248956422	6	60	61
+
+is a sequence named chr1 that's 248956422 bases long, starting at byte 6,
+written 60 bases to a line with 61 bytes to a line, newline included.
+
+That only works if every line of a sequence but its last has the same number
+of bases, so files that don't can't be indexed. Names are the first word of
+each header, like samtools uses. Indexes are of plain FASTA files: gzipped
+files can't be read from the middle, and bgzipped files need a .gzi index
+too, which isn't supported, so decompress them first.
+
+******************************************************************************/
+
+// IndexRecord is a line of a .fai index, which locates a sequence in its
+// FASTA file.
+type IndexRecord struct {
+	Name   string `json:"name"`
+	Length int    `json:"length"`
+	// Offset is the byte of the file the sequence's first base is at.
+	Offset int64 `json:"offset"`
+	// LineBases is how many bases each line of the sequence has, and
+	// LineWidth how many bytes, newline included.
+	LineBases int `json:"line_bases"`
+	LineWidth int `json:"line_width"`
+}
+
+// Index is a .fai index of a FASTA file, with a record per sequence in the
+// order they're in the file.
+type Index []IndexRecord
+
+// BuildIndex indexes a FASTA file.
+func BuildIndex(r io.Reader) (Index, error) {
+	reader := bufio.NewReader(r)
+	var index Index
+	names := map[string]bool{}
+	var record *IndexRecord
+	var offset int64
+	// finished is whether a line shorter than the record's lines, or a blank
+	// line, has ended the record's sequence.
+	finished := false
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if len(line) == 0 {
+			break
+		}
+		lineWidth := len(line)
+		offset += int64(lineWidth)
+		bases := len(bytes.TrimRight(line, "\r\n"))
+		newline := lineWidth - bases
+
+		switch {
+		case line[0] == '>':
+			fields := strings.Fields(string(line[1:]))
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("line %d: sequence has no name", lineNumber)
+			}
+			if names[fields[0]] {
+				return nil, fmt.Errorf("line %d: there's already a sequence named %q", lineNumber, fields[0])
+			}
+			names[fields[0]] = true
+			index = append(index, IndexRecord{Name: fields[0], Offset: offset})
+			record = &index[len(index)-1]
+			finished = false
+		case record == nil:
+			if bases != 0 {
+				return nil, fmt.Errorf("line %d: sequence before the first header", lineNumber)
+			}
+		case bases == 0:
+			finished = true
+		case finished:
+			return nil, fmt.Errorf("line %d: sequence %q has lines of different lengths, so it can't be indexed", lineNumber, record.Name)
+		default:
+			if record.LineBases == 0 {
+				record.LineBases, record.LineWidth = bases, lineWidth
+			} else if bases > record.LineBases || (newline != 0 && newline != record.LineWidth-record.LineBases) {
+				return nil, fmt.Errorf("line %d: sequence %q has lines of different lengths, so it can't be indexed", lineNumber, record.Name)
+			}
+			record.Length += bases
+			// lines only end the sequence if they're short, or missing their newline.
+			finished = bases < record.LineBases || newline == 0
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+	return index, nil
+}
+
+// ParseIndex parses a .fai index.
+func ParseIndex(r io.Reader) (Index, error) {
+	var index Index
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("line %d: expected 5 tab separated fields, got %d", lineNumber, len(fields))
+		}
+		var numbers [4]int64
+		for position, field := range fields[1:] {
+			number, err := strconv.ParseInt(field, 10, 64)
+			if err != nil || number < 0 {
+				return nil, fmt.Errorf("line %d: invalid number %q", lineNumber, field)
+			}
+			numbers[position] = number
+		}
+		record := IndexRecord{Name: fields[0], Length: int(numbers[0]), Offset: numbers[1], LineBases: int(numbers[2]), LineWidth: int(numbers[3])}
+		if record.Length > 0 && (record.LineBases == 0 || record.LineWidth < record.LineBases) {
+			return nil, fmt.Errorf("line %d: invalid line layout for %q", lineNumber, record.Name)
+		}
+		index = append(index, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// ReadIndex reads a .fai index from path.
+func ReadIndex(path string) (Index, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseIndex(file)
+}
+
+// Build builds a .fai index into a byte slice.
+func (index Index) Build() []byte {
+	var buffer bytes.Buffer
+	for _, record := range index {
+		fmt.Fprintf(&buffer, "%s\t%d\t%d\t%d\t%d\n", record.Name, record.Length, record.Offset, record.LineBases, record.LineWidth)
+	}
+	return buffer.Bytes()
+}
+
+// Write writes a .fai index to path.
+func (index Index) Write(path string) error {
+	return os.WriteFile(path, index.Build(), 0644)
+}
+
+// IndexFile indexes the FASTA file at path, and writes the index next to it,
+// at path with .fai added, like samtools faidx.
+func IndexFile(path string) (Index, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if err := checkNotGzipped(file); err != nil {
+		return nil, err
+	}
+	index, err := BuildIndex(file)
+	if err != nil {
+		return nil, err
+	}
+	return index, index.Write(path + ".fai")
+}
+
+// checkNotGzipped makes sure a file isn't gzipped, which can't be indexed,
+// and leaves it at its start.
+func checkNotGzipped(file *os.File) error {
+	start := make([]byte, 2)
+	count, err := file.ReadAt(start, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	if count == 2 && start[0] == 0x1f && start[1] == 0x8b {
+		return fmt.Errorf("%s is gzipped, and gzipped FASTA can't be indexed", file.Name())
+	}
+	return nil
+}
+
+/******************************************************************************
+
+Random access begins here.
+
+******************************************************************************/
+
+// IndexedFile is a FASTA file opened with its index, to read ranges of its
+// sequences without reading the rest of it.
+type IndexedFile struct {
+	file    io.ReaderAt
+	index   Index
+	records map[string]IndexRecord
+}
+
+// OpenIndexed opens the FASTA file at path for random access. Its index is
+// read from path with .fai added if there's one, and built otherwise,
+// without writing it. Use IndexFile to write it for next time.
+func OpenIndexed(path string) (*IndexedFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNotGzipped(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	index, err := ReadIndex(path + ".fai")
+	if errors.Is(err, os.ErrNotExist) {
+		index, err = BuildIndex(file)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return NewIndexedFile(file, index), nil
+}
+
+// NewIndexedFile returns an IndexedFile that reads a FASTA file with an
+// index of it.
+func NewIndexedFile(file io.ReaderAt, index Index) *IndexedFile {
+	records := make(map[string]IndexRecord, len(index))
+	for _, record := range index {
+		records[record.Name] = record
+	}
+	return &IndexedFile{file: file, index: index, records: records}
+}
+
+// Index returns the index of the file.
+func (indexed *IndexedFile) Index() Index {
+	return indexed.index
+}
+
+// Close closes the file, if it can be closed.
+func (indexed *IndexedFile) Close() error {
+	if closer, ok := indexed.file.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// FetchSubsequence returns the half-open range [start, end) of the sequence
+// named name, zero-indexed, reading only the bytes of the file it's in.
+func (indexed *IndexedFile) FetchSubsequence(name string, start, end int) (string, error) {
+	record, ok := indexed.records[name]
+	if !ok {
+		return "", fmt.Errorf("no sequence named %q", name)
+	}
+	if start < 0 || end < start || end > record.Length {
+		return "", fmt.Errorf("range [%d, %d) is outside of %q, which is %d long", start, end, name, record.Length)
+	}
+	if start == end {
+		return "", nil
+	}
+	startOffset := record.byteOffset(start)
+	// the byte after the last base, rather than the position after it, which
+	// may be past a newline.
+	endOffset := record.byteOffset(end-1) + 1
+	data := make([]byte, endOffset-startOffset)
+	if _, err := indexed.file.ReadAt(data, startOffset); err != nil {
+		return "", err
+	}
+	sequence := make([]byte, 0, end-start)
+	for _, character := range data {
+		if character != '\n' && character != '\r' {
+			sequence = append(sequence, character)
+		}
+	}
+	if len(sequence) != end-start {
+		return "", fmt.Errorf("%q doesn't match the index, which may be out of date", name)
+	}
+	return string(sequence), nil
+}
+
+// FetchSequence returns the whole sequence named name.
+func (indexed *IndexedFile) FetchSequence(name string) (string, error) {
+	record, ok := indexed.records[name]
+	if !ok {
+		return "", fmt.Errorf("no sequence named %q", name)
+	}
+	return indexed.FetchSubsequence(name, 0, record.Length)
+}
+
+// byteOffset returns the byte of the file a position of the sequence is at.
+func (record IndexRecord) byteOffset(position int) int64 {
+	return record.Offset + int64(position/record.LineBases)*int64(record.LineWidth) + int64(position%record.LineBases)
+}
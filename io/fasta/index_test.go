@@ -0,0 +1,120 @@
+package fasta
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const indexTestFai = "seq1\t23\t21\t10\t11\nseq2\t15\t53\t10\t11\nseq3\t0\t83\t0\t0\nseq4\t5\t89\t5\t6\n"
+
+func TestBuildIndex(t *testing.T) {
+	file, err := os.Open("data/index_test.fasta")
+	require.NoError(t, err)
+	defer file.Close()
+	index, err := BuildIndex(file)
+	require.NoError(t, err)
+	assert.Equal(t, indexTestFai, string(index.Build()))
+
+	parsed, err := ParseIndex(strings.NewReader(indexTestFai))
+	require.NoError(t, err)
+	assert.Equal(t, index, parsed)
+
+	// Windows line endings are part of the line width.
+	index, err = BuildIndex(strings.NewReader(">a\r\nACGT\r\nAC\r\n"))
+	require.NoError(t, err)
+	assert.Equal(t, Index{{Name: "a", Length: 6, Offset: 4, LineBases: 4, LineWidth: 6}}, index)
+
+	for _, invalid := range []string{
+		">a\nACGT\nAC\nACGT\n",
+		">a\nACGT\nACGTA\n",
+		">a\nACGT\n\nACGT\n",
+		">a\nACGT\r\nACGT\nAC\n",
+		">a\nACGT\n>a\nACGT\n",
+		">\nACGT\n",
+		"ACGT\n>a\nACGT\n",
+	} {
+		_, err := BuildIndex(strings.NewReader(invalid))
+		assert.Error(t, err, invalid)
+	}
+
+	_, err = ParseIndex(strings.NewReader("seq1\t23\t21\t10\n"))
+	assert.Error(t, err)
+	_, err = ParseIndex(strings.NewReader("seq1\t23\t21\tten\t11\n"))
+	assert.Error(t, err)
+}
+
+func TestFetchSubsequence(t *testing.T) {
+	indexed, err := OpenIndexed("data/index_test.fasta")
+	require.NoError(t, err)
+	defer indexed.Close()
+
+	sequences := map[string]string{
+		"seq1": "ACGTACGTACGTACGTACGTACG",
+		"seq2": "TTTTTGGGGGCCCCC",
+		"seq3": "",
+		"seq4": "acgtn",
+	}
+	for name, want := range sequences {
+		for start := 0; start <= len(want); start++ {
+			for end := start; end <= len(want); end++ {
+				subsequence, err := indexed.FetchSubsequence(name, start, end)
+				require.NoError(t, err)
+				assert.Equal(t, want[start:end], subsequence, "%s [%d, %d)", name, start, end)
+			}
+		}
+		sequence, err := indexed.FetchSequence(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, sequence)
+	}
+
+	_, err = indexed.FetchSubsequence("seq5", 0, 1)
+	assert.Error(t, err)
+	_, err = indexed.FetchSubsequence("seq1", 20, 24)
+	assert.Error(t, err)
+	_, err = indexed.FetchSubsequence("seq1", 5, 4)
+	assert.Error(t, err)
+	_, err = indexed.FetchSubsequence("seq1", -1, 4)
+	assert.Error(t, err)
+}
+
+func TestIndexFile(t *testing.T) {
+	directory := t.TempDir()
+	contents, err := os.ReadFile("data/index_test.fasta")
+	require.NoError(t, err)
+	path := filepath.Join(directory, "index_test.fasta")
+	require.NoError(t, os.WriteFile(path, contents, 0644))
+
+	_, err = IndexFile(path)
+	require.NoError(t, err)
+	written, err := os.ReadFile(path + ".fai")
+	require.NoError(t, err)
+	assert.Equal(t, indexTestFai, string(written))
+
+	// the written index is used from then on, even if it's wrong.
+	require.NoError(t, os.WriteFile(path+".fai", []byte("seq1\t4\t21\t10\t11\n"), 0644))
+	indexed, err := OpenIndexed(path)
+	require.NoError(t, err)
+	defer indexed.Close()
+	assert.Len(t, indexed.Index(), 1)
+	_, err = indexed.FetchSubsequence("seq2", 0, 1)
+	assert.Error(t, err)
+
+	var gzipped bytes.Buffer
+	writer := gzip.NewWriter(&gzipped)
+	_, err = writer.Write(contents)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	gzippedPath := filepath.Join(directory, "index_test.fasta.gz")
+	require.NoError(t, os.WriteFile(gzippedPath, gzipped.Bytes(), 0644))
+	_, err = IndexFile(gzippedPath)
+	assert.Error(t, err)
+	_, err = OpenIndexed(gzippedPath)
+	assert.Error(t, err)
+}
@@ -21,6 +21,9 @@ import (
 	"math"
 	"os"
 	"strings"
+
+	polyio "github.com/bebop/poly/io"
+	"github.com/bebop/poly/sequence"
 )
 
 /******************************************************************************
@@ -50,6 +53,12 @@ type Fastq struct {
 	Quality    string            `json:"quality"`
 }
 
+// ToSequence returns a Fastq's sequence, without its quality scores. Reads
+// are guessed to be DNA or RNA with sequence.New, and assumed linear.
+func (fastq Fastq) ToSequence() sequence.Sequence {
+	return sequence.New(fastq.Identifier, fastq.Sequence)
+}
+
 // Parse parses a given Fastq file into an array of Fastq structs. Internally, it uses ParseFastqConcurrent.
 func Parse(r io.Reader) ([]Fastq, error) {
 	// 32kB is a magic number often used by the Go stdlib for parsing. We multiply it by two.
@@ -68,10 +77,11 @@ type Parser struct {
 }
 
 // NewParser returns a Parser that uses r as the source
-// from which to parse fastq formatted sequences.
+// from which to parse fastq formatted sequences. Gzipped and bgzipped
+// sources are decompressed.
 func NewParser(r io.Reader, maxLineSize int) *Parser {
 	return &Parser{
-		reader: *bufio.NewReaderSize(r, maxLineSize),
+		reader: *bufio.NewReaderSize(polyio.Decompress(r), maxLineSize),
 	}
 }
 
@@ -216,7 +226,7 @@ func (parser *Parser) ParseNext() (Fastq, int64, error) {
 
 // Reset discards all data in buffer and resets state.
 func (parser *Parser) Reset(r io.Reader) {
-	parser.reader.Reset(r)
+	parser.reader.Reset(polyio.Decompress(r))
 	parser.line = 0
 }
 
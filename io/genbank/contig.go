@@ -0,0 +1,158 @@
+package genbank
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bebop/poly/transform"
+)
+
+/******************************************************************************
+
+Contig assembly begins here.
+
+Big assemblies, like chromosomes built from whole genome shotgun contigs, are
+usually shared as CON records. Instead of a sequence, a CON record has a
+CONTIG line, which joins ranges of other records, and gaps between them, into
+its sequence:
+
+	CONTIG      join(AB000001.1:1..500,gap(100),complement(AB000002.1:1..400))
+
+ParseMulti reads CON records like any other, with their CONTIG in
+Meta.Contig and an empty sequence. ContigParts reads the CONTIG into the parts
+to assemble, and AssembleContig assembles them from the records they're from,
+which can be in the same file as the CON record or downloaded separately.
+
+******************************************************************************/
+
+// DefaultUnknownGapLength is how many Ns AssembleContig fills gaps without a
+// length, gap(), with. It's how many NCBI fills them with.
+const DefaultUnknownGapLength = 100
+
+// ContigPart is a part of a CON record's sequence: either a range of another
+// record, or a gap.
+type ContigPart struct {
+	// Accession is the accession of the record the part is from, usually
+	// with its version, like AB000001.1. It's empty for gaps.
+	Accession string `json:"accession"`
+	// Start and End are the zero-indexed, half-open range the part covers in
+	// that record.
+	Start      int  `json:"start"`
+	End        int  `json:"end"`
+	Complement bool `json:"complement"`
+	// Gap is the length of a gap, which is an estimate if UnknownLength,
+	// like gap(unk100), or DefaultUnknownGapLength if it isn't given, like
+	// gap().
+	Gap           int  `json:"gap"`
+	UnknownLength bool `json:"unknown_length"`
+}
+
+// IsGap returns whether a part is a gap.
+func (part ContigPart) IsGap() bool {
+	return part.Accession == ""
+}
+
+// ContigParts returns the parts of a CON record's sequence, in order, from
+// its CONTIG.
+func (genbank Genbank) ContigParts() ([]ContigPart, error) {
+	contig := strings.Join(strings.Fields(genbank.Meta.Contig), "")
+	if contig == "" {
+		return nil, fmt.Errorf("%s has no CONTIG", genbank.Meta.Locus.Name)
+	}
+	if strings.HasPrefix(contig, "join(") && strings.HasSuffix(contig, ")") {
+		contig = contig[len("join(") : len(contig)-1]
+	}
+	var parts []ContigPart
+	for _, partString := range strings.Split(contig, ",") {
+		part, err := parseContigPart(partString)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// parseContigPart parses a part of a CONTIG, like AB000001.1:1..500,
+// complement(AB000002.1:1..400), or gap(100).
+func parseContigPart(partString string) (ContigPart, error) {
+	var part ContigPart
+	if strings.HasPrefix(partString, "gap(") && strings.HasSuffix(partString, ")") {
+		length := partString[len("gap(") : len(partString)-1]
+		if length == "" {
+			return ContigPart{Gap: DefaultUnknownGapLength, UnknownLength: true}, nil
+		}
+		if strings.HasPrefix(length, "unk") {
+			length = strings.TrimPrefix(length, "unk")
+			part.UnknownLength = true
+		}
+		gap, err := strconv.Atoi(length)
+		if err != nil || gap < 0 {
+			return ContigPart{}, fmt.Errorf("invalid CONTIG gap %q", partString)
+		}
+		part.Gap = gap
+		return part, nil
+	}
+
+	rangeString := partString
+	if strings.HasPrefix(rangeString, "complement(") && strings.HasSuffix(rangeString, ")") {
+		rangeString = rangeString[len("complement(") : len(rangeString)-1]
+		part.Complement = true
+	}
+	accession, positions, found := strings.Cut(rangeString, ":")
+	if !found || accession == "" {
+		return ContigPart{}, fmt.Errorf("CONTIG part %q has no accession", partString)
+	}
+	startString, endString, found := strings.Cut(positions, "..")
+	if !found {
+		endString = startString
+	}
+	start, startErr := strconv.Atoi(startString)
+	end, endErr := strconv.Atoi(endString)
+	if startErr != nil || endErr != nil || start < 1 || end < start {
+		return ContigPart{}, fmt.Errorf("invalid CONTIG range %q", partString)
+	}
+	part.Accession, part.Start, part.End = accession, start-1, end
+	return part, nil
+}
+
+// AssembleContig assembles a CON record's sequence from the records its
+// CONTIG names, which are looked up by their VERSION, or by their ACCESSION
+// if the CONTIG names them without a version. Gaps are filled with Ns.
+func (genbank Genbank) AssembleContig(records []Genbank) (string, error) {
+	parts, err := genbank.ContigParts()
+	if err != nil {
+		return "", err
+	}
+	sequences := map[string]string{}
+	for _, record := range records {
+		if accession := strings.Fields(record.Meta.Accession); len(accession) > 0 {
+			sequences[accession[0]] = record.Sequence
+		}
+		if version := strings.Fields(record.Meta.Version); len(version) > 0 {
+			sequences[version[0]] = record.Sequence
+		}
+	}
+
+	var assembled strings.Builder
+	for _, part := range parts {
+		if part.IsGap() {
+			assembled.WriteString(strings.Repeat("n", part.Gap))
+			continue
+		}
+		sequence, ok := sequences[part.Accession]
+		if !ok {
+			return "", fmt.Errorf("no record for CONTIG part %s", part.Accession)
+		}
+		if part.End > len(sequence) {
+			return "", fmt.Errorf("CONTIG part %s:%d..%d is past the end of its %d bp sequence", part.Accession, part.Start+1, part.End, len(sequence))
+		}
+		bases := sequence[part.Start:part.End]
+		if part.Complement {
+			bases = transform.ReverseComplement(bases)
+		}
+		assembled.WriteString(bases)
+	}
+	return assembled.String(), nil
+}
@@ -0,0 +1,119 @@
+package genbank
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseMultiContig(t *testing.T) {
+	records, err := ReadMulti("../../data/contig_test.gbk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the CON record is between the records it's assembled from, which
+	// shouldn't be merged into it.
+	var names []string
+	for _, record := range records {
+		names = append(names, record.Meta.Locus.Name)
+	}
+	if diff := cmp.Diff([]string{"TEST0001", "TEST0003", "TEST0002"}, names); diff != "" {
+		t.Fatalf("record names mismatch (-want +got):\n%s", diff)
+	}
+
+	scaffold := records[1]
+	if scaffold.Meta.Contig != "join(TEST0001.1:1..30,gap(10),complement(TEST0002.1:5..20))" {
+		t.Errorf("got CONTIG %q", scaffold.Meta.Contig)
+	}
+	if scaffold.Sequence != "" || len(scaffold.Warnings) != 0 {
+		t.Errorf("CON record got sequence %q and warnings %v", scaffold.Sequence, scaffold.Warnings)
+	}
+	if len(scaffold.Features) != 2 || scaffold.Features[1].Attributes["gene"] != "test" {
+		t.Errorf("CON record features weren't parsed: %+v", scaffold.Features)
+	}
+	if records[2].Meta.Contig != "" || records[2].Sequence != "ttgacaggctagctaaatcg" {
+		t.Errorf("record after the CON record got CONTIG %q and sequence %q", records[2].Meta.Contig, records[2].Sequence)
+	}
+
+	parts, err := scaffold.ContigParts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantParts := []ContigPart{
+		{Accession: "TEST0001.1", Start: 0, End: 30},
+		{Gap: 10},
+		{Accession: "TEST0002.1", Start: 4, End: 20, Complement: true},
+	}
+	if diff := cmp.Diff(wantParts, parts); diff != "" {
+		t.Errorf("ContigParts mismatch (-want +got):\n%s", diff)
+	}
+
+	assembled, err := scaffold.AssembleContig(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "atggctagcatcgatcgatcgatcgtagct" + strings.Repeat("n", 10) + "cgatttagctagcctg"; assembled != want {
+		t.Errorf("AssembleContig got %q, want %q", assembled, want)
+	}
+	if _, err := scaffold.AssembleContig(records[:2]); err == nil {
+		t.Error("AssembleContig should fail without every record it's assembled from")
+	}
+}
+
+func TestContigParts(t *testing.T) {
+	for _, test := range []struct {
+		contig  string
+		want    []ContigPart
+		wantErr bool
+	}{
+		{contig: "join(A1.1:1..5,gap(unk100),gap(),B2:7)", want: []ContigPart{
+			{Accession: "A1.1", Start: 0, End: 5},
+			{Gap: 100, UnknownLength: true},
+			{Gap: DefaultUnknownGapLength, UnknownLength: true},
+			{Accession: "B2", Start: 6, End: 7},
+		}},
+		{contig: "A1.1:10..20", want: []ContigPart{{Accession: "A1.1", Start: 9, End: 20}}},
+		{contig: "", wantErr: true},
+		{contig: "join(1..20)", wantErr: true},
+		{contig: "join(A1.1:20..10)", wantErr: true},
+		{contig: "join(gap(many))", wantErr: true},
+	} {
+		got, err := Genbank{Meta: Meta{Contig: test.contig}}.ContigParts()
+		if (err != nil) != test.wantErr {
+			t.Errorf("ContigParts(%q) got error %v", test.contig, err)
+			continue
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("ContigParts(%q) mismatch (-want +got):\n%s", test.contig, diff)
+		}
+	}
+}
+
+func TestBuildContig(t *testing.T) {
+	var parts []string
+	for index := 0; index < 6; index++ {
+		parts = append(parts, "AADB02037551.1:1..37000")
+	}
+	contig := "join(" + strings.Join(parts, ",gap(100),") + ")"
+	sequence := Genbank{Meta: Meta{Locus: Locus{Name: "NW_000001", GenbankDivision: "CON"}, Contig: contig}}
+	built, err := Build(sequence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(built), "ORIGIN") {
+		t.Errorf("CON record without a sequence was built with an ORIGIN:\n%s", built)
+	}
+	for _, line := range strings.Split(string(built), "\n") {
+		if len(line) > 79 {
+			t.Errorf("line is %d characters long: %q", len(line), line)
+		}
+	}
+	parsed, err := Parse(strings.NewReader(string(built)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Meta.Contig != contig {
+		t.Errorf("got CONTIG %q, want %q", parsed.Meta.Contig, contig)
+	}
+}
@@ -0,0 +1,150 @@
+package genbank
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/******************************************************************************
+
+Dates begin here.
+
+GenBank writes dates like 21-JUN-1999, with the month in uppercase English
+whatever the locale of whoever wrote the file. Dates are calendar days with
+no time or time zone, so they're parsed as midnight UTC, and formatted from
+the calendar day of a time.Time in its own location. Converting a time to UTC
+first could move it to the day before or after, so it isn't.
+
+Locus.ModificationDate stays a string so records round-trip exactly as they
+were read, and ModificationTime and SetModificationDate convert it.
+
+******************************************************************************/
+
+// months are GenBank's month abbreviations, which don't change with locale.
+var months = [12]string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+
+var (
+	genbankDateRegex = regexp.MustCompile(`^(\d{1,2})-([A-Za-z]{3})-(\d{4})$`)
+	// NCBI's structured comments write dates and times like
+	// 08/28/2020 07:52:01, month first.
+	commentDateRegex = regexp.MustCompile(`^(\d{1,2})/(\d{1,2})/(\d{4})(?: (\d{2}):(\d{2}):(\d{2}))?$`)
+)
+
+// ParseDate parses a GenBank date like 21-JUN-1999 into midnight UTC of that
+// day. Months are matched whatever their case, and days can be one digit.
+// Dates from NCBI structured comments, like 08/28/2020 07:52:01, are parsed
+// too, keeping their time.
+func ParseDate(date string) (time.Time, error) {
+	date = strings.TrimSpace(date)
+	if match := genbankDateRegex.FindStringSubmatch(date); match != nil {
+		month := 0
+		for index, name := range months {
+			if strings.EqualFold(match[2], name) {
+				month = index + 1
+			}
+		}
+		if month == 0 {
+			return time.Time{}, fmt.Errorf("date %q has unknown month %q", date, match[2])
+		}
+		day, _ := strconv.Atoi(match[1])
+		year, _ := strconv.Atoi(match[3])
+		return validDate(date, year, month, day, 0, 0, 0)
+	}
+	if match := commentDateRegex.FindStringSubmatch(date); match != nil {
+		fields := make([]int, 6)
+		for index, field := range match[1:] {
+			fields[index], _ = strconv.Atoi(field)
+		}
+		return validDate(date, fields[2], fields[0], fields[1], fields[3], fields[4], fields[5])
+	}
+	return time.Time{}, fmt.Errorf("date %q isn't in a GenBank date format like 21-JUN-1999", date)
+}
+
+// validDate returns a time in UTC, or an error if it doesn't exist, like
+// 31-FEB-2020, which time.Date would quietly turn into 2-MAR-2020.
+func validDate(date string, year, month, day, hour, minute, second int) (time.Time, error) {
+	parsed := time.Date(year, time.Month(month), day, hour, minute, second, 0, time.UTC)
+	if parsed.Day() != day || int(parsed.Month()) != month || parsed.Hour() != hour || parsed.Minute() != minute || parsed.Second() != second {
+		return time.Time{}, fmt.Errorf("date %q doesn't exist", date)
+	}
+	return parsed, nil
+}
+
+// FormatDate formats the calendar day of a time, in its own location, as a
+// GenBank date like 21-JUN-1999.
+func FormatDate(date time.Time) string {
+	year, month, day := date.Date()
+	return fmt.Sprintf("%02d-%s-%04d", day, months[month-1], year)
+}
+
+// ModificationTime parses the modification date of a LOCUS line.
+func (locus Locus) ModificationTime() (time.Time, error) {
+	return ParseDate(locus.ModificationDate)
+}
+
+// SetModificationDate sets the modification date of a LOCUS line.
+func (locus *Locus) SetModificationDate(date time.Time) {
+	locus.ModificationDate = FormatDate(date)
+}
+
+// CommentDates returns the dates in a record's structured comment, like
+// "Annotation Date :: 08/28/2020 07:52:01", by their key. Values that aren't
+// dates are left out.
+func (meta Meta) CommentDates() map[string]time.Time {
+	dates := make(map[string]time.Time)
+	for _, line := range strings.Split(meta.Other["COMMENT"], "\n") {
+		key, value, found := strings.Cut(line, "::")
+		if !found {
+			continue
+		}
+		if date, err := ParseDate(value); err == nil {
+			dates[strings.TrimSpace(key)] = date
+		}
+	}
+	return dates
+}
+
+// WriteOptions changes how records are built and written.
+type WriteOptions struct {
+	// ModificationDate replaces the modification date of every record, if
+	// it isn't zero. The records passed in aren't changed.
+	ModificationDate time.Time
+}
+
+// WriteWithOptions writes a record to path like Write, changed by options.
+func WriteWithOptions(sequence Genbank, path string, options WriteOptions) error {
+	return WriteMultiWithOptions([]Genbank{sequence}, path, options)
+}
+
+// BuildWithOptions builds a GBK byte slice like Build, changed by options.
+func BuildWithOptions(gbk Genbank, options WriteOptions) ([]byte, error) {
+	return BuildMultiWithOptions([]Genbank{gbk}, options)
+}
+
+// BuildMultiWithOptions builds a multi GBK byte slice like BuildMulti,
+// changed by options.
+func BuildMultiWithOptions(sequences []Genbank, options WriteOptions) ([]byte, error) {
+	if !options.ModificationDate.IsZero() {
+		stamped := make([]Genbank, len(sequences))
+		copy(stamped, sequences)
+		for index := range stamped {
+			stamped[index].Meta.Locus.SetModificationDate(options.ModificationDate)
+		}
+		sequences = stamped
+	}
+	return BuildMulti(sequences)
+}
+
+// WriteMultiWithOptions writes records to path like WriteMulti, changed by
+// options.
+func WriteMultiWithOptions(sequences []Genbank, path string, options WriteOptions) error {
+	gbk, err := BuildMultiWithOptions(sequences, options)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, gbk, 0644)
+}
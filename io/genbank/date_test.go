@@ -0,0 +1,109 @@
+package genbank
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		date string
+		want time.Time
+	}{
+		{"21-JUN-1999", time.Date(1999, time.June, 21, 0, 0, 0, 0, time.UTC)},
+		{"21-jun-1999", time.Date(1999, time.June, 21, 0, 0, 0, 0, time.UTC)},
+		{"1-Feb-2020", time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"29-FEB-2020", time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"08/28/2020 07:52:01", time.Date(2020, time.August, 28, 7, 52, 1, 0, time.UTC)},
+		{"12/15/2018", time.Date(2018, time.December, 15, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		got, err := ParseDate(test.date)
+		if err != nil {
+			t.Errorf("ParseDate(%q) returned error %s", test.date, err)
+			continue
+		}
+		if !got.Equal(test.want) || got.Location() != time.UTC {
+			t.Errorf("ParseDate(%q) = %s, want %s", test.date, got, test.want)
+		}
+	}
+
+	for _, date := range []string{"31-FEB-2020", "29-FEB-2019", "21-JUNE-1999", "21-XYZ-1999", "1999-06-21", "13/01/2020", ""} {
+		if _, err := ParseDate(date); err == nil {
+			t.Errorf("ParseDate(%q) should have returned an error", date)
+		}
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	// a minute before midnight in Auckland is already the next day in UTC,
+	// and the date should stay the one on the clock.
+	auckland := time.FixedZone("NZDT", 13*60*60)
+	date := time.Date(2021, time.January, 1, 0, 1, 0, 0, auckland)
+	if got := FormatDate(date); got != "01-JAN-2021" {
+		t.Errorf("FormatDate(%s) = %s, want 01-JAN-2021", date, got)
+	}
+
+	// formatting a parsed date gives it back in the canonical form.
+	for date, want := range map[string]string{"21-JUN-1999": "21-JUN-1999", "1-feb-2020": "01-FEB-2020"} {
+		parsed, err := ParseDate(date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := FormatDate(parsed); got != want {
+			t.Errorf("FormatDate(ParseDate(%q)) = %s, want %s", date, got, want)
+		}
+	}
+}
+
+func TestModificationDate(t *testing.T) {
+	puc19, err := Read("../../data/puc19.gbk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	modified, err := puc19.Meta.Locus.ModificationTime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := FormatDate(modified); got != puc19.Meta.Locus.ModificationDate {
+		t.Errorf("expected %s to round trip, got %s", puc19.Meta.Locus.ModificationDate, got)
+	}
+
+	stamp := time.Date(2024, time.March, 5, 12, 0, 0, 0, time.UTC)
+	built, err := BuildMultiWithOptions([]Genbank{puc19}, WriteOptions{ModificationDate: stamp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	firstLine, _, _ := strings.Cut(string(built), "\n")
+	if !strings.HasSuffix(firstLine, "05-MAR-2024") {
+		t.Errorf("expected the LOCUS line to be stamped, got %q", firstLine)
+	}
+	if puc19.Meta.Locus.ModificationDate == "05-MAR-2024" {
+		t.Errorf("stamping a build shouldn't change the record")
+	}
+	reparsed, err := Parse(bytes.NewReader(built))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reparsed.Meta.Locus.ModificationDate != "05-MAR-2024" {
+		t.Errorf("expected the stamped date to be parsed back, got %s", reparsed.Meta.Locus.ModificationDate)
+	}
+}
+
+func TestCommentDates(t *testing.T) {
+	meta := Meta{Other: map[string]string{"COMMENT": "##Genome-Annotation-Data-START##\nAnnotation Provider :: NCBI\nAnnotation Date :: 08/28/2020 07:52:01\n##Genome-Annotation-Data-END##"}}
+	dates := meta.CommentDates()
+	if len(dates) != 1 || !dates["Annotation Date"].Equal(time.Date(2020, time.August, 28, 7, 52, 1, 0, time.UTC)) {
+		t.Errorf("unexpected comment dates %v", dates)
+	}
+}
+
+func TestLocusDateWarning(t *testing.T) {
+	locusString := "LOCUS       puc19.gbk               2686 bp    DNA     circular SYN 31-FEB-2019"
+	warnings := locusWarnings(locusString, parseLocus(locusString), 1)
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Message, "31-FEB-2019") {
+		t.Errorf("expected a warning about the date, got %v", warnings)
+	}
+}
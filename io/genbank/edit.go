@@ -0,0 +1,210 @@
+package genbank
+
+import (
+	"fmt"
+	"strconv"
+)
+
+/******************************************************************************
+
+Sequence editing begins here.
+
+Editing an annotated sequence by hand means fixing up every feature after the
+edit by hand too, which is easy to get wrong by one. Insert, Delete, Replace,
+and Rotate edit a Genbank's sequence and move its features with it:
+
+Features after an edit shift by however many bases it added or removed.
+Features an edit falls inside of grow or shrink to cover its replacement.
+Features an edit cuts into are truncated, and marked partial on that end.
+Features an edit covers entirely are removed.
+
+Circular sequences have no ends, so Replace and Delete take ranges that wrap
+around the origin, with a start after their end, and Rotate moves the origin,
+splitting features that cross the new one into joins and merging the parts of
+features that crossed the old one.
+
+Edits return an edited copy, leaving the original as it was.
+
+******************************************************************************/
+
+// Insert returns a sequence with bases inserted before position.
+func (sequence Genbank) Insert(position int, insertion string) (Genbank, error) {
+	return sequence.Replace(position, position, insertion)
+}
+
+// Delete returns a sequence with the half-open range [start, end) removed.
+func (sequence Genbank) Delete(start, end int) (Genbank, error) {
+	return sequence.Replace(start, end, "")
+}
+
+// Replace returns a sequence with the half-open range [start, end) replaced.
+// For circular sequences, a start after end replaces the range from start
+// to end across the origin, leaving the replacement at the end of the
+// sequence.
+func (sequence Genbank) Replace(start, end int, replacement string) (Genbank, error) {
+	length := len(sequence.Sequence)
+	if start > end && sequence.Meta.Locus.Circular && start <= length {
+		wrapped, err := sequence.Replace(start, length, replacement)
+		if err != nil {
+			return Genbank{}, err
+		}
+		return wrapped.Replace(0, end, "")
+	}
+	if start < 0 || end > length || start > end {
+		return Genbank{}, fmt.Errorf("can't replace %d..%d of a %d base sequence", start, end, length)
+	}
+
+	edited := sequence
+	edited.Sequence = sequence.Sequence[:start] + replacement + sequence.Sequence[end:]
+	edited.Features = nil
+	for _, feature := range sequence.Features {
+		location, kept := replaceInLocation(feature.Location, start, end, len(replacement))
+		if kept {
+			feature.Location = location
+			edited.Features = append(edited.Features, feature)
+		}
+	}
+	edited.finishEdit()
+	return edited, nil
+}
+
+// Rotate returns a circular sequence rotated to start at origin.
+func (sequence Genbank) Rotate(origin int) (Genbank, error) {
+	length := len(sequence.Sequence)
+	if !sequence.Meta.Locus.Circular {
+		return Genbank{}, fmt.Errorf("can't rotate linear sequence %s", sequence.Meta.Locus.Name)
+	}
+	if origin < 0 || origin >= max(length, 1) {
+		return Genbank{}, fmt.Errorf("origin %d is outside a %d base sequence", origin, length)
+	}
+
+	edited := sequence
+	edited.Sequence = sequence.Sequence[origin:] + sequence.Sequence[:origin]
+	edited.Features = make([]Feature, len(sequence.Features))
+	for index, feature := range sequence.Features {
+		if origin != 0 {
+			feature.Location = mergeAdjacent(rotateLocation(feature.Location, origin, length))
+		}
+		edited.Features[index] = feature
+	}
+	edited.finishEdit()
+	return edited, nil
+}
+
+// finishEdit points an edited sequence's features at it, and updates its
+// length.
+func (sequence *Genbank) finishEdit() {
+	for index := range sequence.Features {
+		sequence.Features[index].ParentSequence = sequence
+	}
+	if sequence.Meta.Locus.SequenceLength != "" {
+		sequence.Meta.Locus.SequenceLength = strconv.Itoa(len(sequence.Sequence))
+	}
+}
+
+// replaceInLocation moves a location to account for [start, end) being
+// replaced by replacementLength bases. It returns false if the replaced range
+// covers the whole location.
+func replaceInLocation(location Location, start, end, replacementLength int) (Location, bool) {
+	if span(location)[1] <= start {
+		return location, true
+	}
+	location.GbkLocationString = ""
+	if len(location.SubLocations) > 0 {
+		var subLocations []Location
+		for _, subLocation := range location.SubLocations {
+			if subLocation, kept := replaceInLocation(subLocation, start, end, replacementLength); kept {
+				subLocations = append(subLocations, subLocation)
+			}
+		}
+		return collapse(location, subLocations)
+	}
+
+	delta := replacementLength - (end - start)
+	first, last := location.Start, leafEnd(location)
+	switch {
+	case first >= end:
+		location.Start, location.End = first+delta, last+delta
+	case start <= first && end >= last:
+		return Location{}, false
+	case start <= first:
+		location.Start, location.End = start+replacementLength, last+delta
+		location.FivePrimePartial = true
+	case end >= last:
+		location.End = start
+		location.ThreePrimePartial = true
+	default:
+		location.End = last + delta
+	}
+	return location, true
+}
+
+// rotateLocation moves a location of a circular sequence of length bases to
+// account for it being rotated to start at origin. Leaves that cross the
+// origin are split into a join of their two halves.
+func rotateLocation(location Location, origin, length int) Location {
+	location.GbkLocationString = ""
+	if len(location.SubLocations) > 0 {
+		subLocations := make([]Location, len(location.SubLocations))
+		for index, subLocation := range location.SubLocations {
+			subLocations[index] = rotateLocation(subLocation, origin, length)
+		}
+		location.SubLocations = subLocations
+		return location
+	}
+	first, last := location.Start, leafEnd(location)
+	switch {
+	case first >= origin:
+		location.Start, location.End = first-origin, last-origin
+	case last <= origin:
+		location.Start, location.End = first+length-origin, last+length-origin
+	default:
+		before := Location{Start: first + length - origin, End: length, FivePrimePartial: location.FivePrimePartial}
+		after := Location{Start: 0, End: last - origin, ThreePrimePartial: location.ThreePrimePartial}
+		location = Location{Join: true, Complement: location.Complement, SubLocations: []Location{before, after}}
+	}
+	return location
+}
+
+// mergeAdjacent merges the parts of joins that follow on from each other,
+// like the two halves of a feature that crossed the origin before a
+// rotation.
+func mergeAdjacent(location Location) Location {
+	if len(location.SubLocations) == 0 {
+		return location
+	}
+	var subLocations []Location
+	for _, subLocation := range location.SubLocations {
+		subLocation = mergeAdjacent(subLocation)
+		last := len(subLocations) - 1
+		if last >= 0 && len(subLocation.SubLocations) == 0 && len(subLocations[last].SubLocations) == 0 && subLocation.Complement == subLocations[last].Complement {
+			previous := subLocations[last]
+			switch {
+			case !previous.Complement && previous.End == subLocation.Start:
+				subLocations[last].End, subLocations[last].ThreePrimePartial = subLocation.End, subLocation.ThreePrimePartial
+				continue
+			case previous.Complement && subLocation.End == previous.Start:
+				subLocations[last].Start, subLocations[last].FivePrimePartial = subLocation.Start, subLocation.FivePrimePartial
+				continue
+			}
+		}
+		subLocations = append(subLocations, subLocation)
+	}
+	merged, _ := collapse(location, subLocations)
+	return merged
+}
+
+// collapse replaces the sublocations of a location, returning false if there
+// are none left, and the one left if there's only one.
+func collapse(location Location, subLocations []Location) (Location, bool) {
+	switch len(subLocations) {
+	case 0:
+		return Location{}, false
+	case 1:
+		only := subLocations[0]
+		only.Complement = only.Complement != location.Complement
+		return only, true
+	}
+	location.SubLocations = subLocations
+	return location, true
+}
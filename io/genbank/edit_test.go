@@ -0,0 +1,157 @@
+package genbank
+
+import (
+	"strconv"
+	"testing"
+)
+
+// editTestSequence returns a small annotated sequence to edit.
+func editTestSequence(circular bool) Genbank {
+	sequence := Genbank{Sequence: "AAAACCCCGGGGTTTT"}
+	sequence.Meta.Locus = Locus{Name: "test", SequenceLength: "16", Circular: circular}
+	for _, locationString := range []string{"1..4", "complement(5..8)", "join(9..10,13..14)", "13..16"} {
+		location, _ := ParseLocation(locationString)
+		location.GbkLocationString = ""
+		_ = sequence.AddFeature(&Feature{Type: "misc_feature", Location: location})
+	}
+	return sequence
+}
+
+// locationStrings returns the locations of a sequence's features.
+func locationStrings(sequence Genbank) []string {
+	var locations []string
+	for _, feature := range sequence.Features {
+		locations = append(locations, BuildLocationString(feature.Location))
+	}
+	return locations
+}
+
+func TestReplace(t *testing.T) {
+	tests := []struct {
+		name      string
+		edit      func(Genbank) (Genbank, error)
+		circular  bool
+		sequence  string
+		locations []string
+	}{
+		{
+			name:      "insert inside a feature",
+			edit:      func(sequence Genbank) (Genbank, error) { return sequence.Insert(2, "NN") },
+			sequence:  "AANNAACCCCGGGGTTTT",
+			locations: []string{"1..6", "complement(7..10)", "join(11..12,15..16)", "15..18"},
+		},
+		{
+			name:      "insert between features",
+			edit:      func(sequence Genbank) (Genbank, error) { return sequence.Insert(4, "NN") },
+			sequence:  "AAAANNCCCCGGGGTTTT",
+			locations: []string{"1..4", "complement(7..10)", "join(11..12,15..16)", "15..18"},
+		},
+		{
+			name:      "delete across features",
+			edit:      func(sequence Genbank) (Genbank, error) { return sequence.Delete(2, 6) },
+			sequence:  "AACCGGGGTTTT",
+			locations: []string{"1..>2", "complement(<3..4)", "join(5..6,9..10)", "9..12"},
+		},
+		{
+			name:      "delete a whole feature",
+			edit:      func(sequence Genbank) (Genbank, error) { return sequence.Delete(0, 4) },
+			sequence:  "CCCCGGGGTTTT",
+			locations: []string{"complement(1..4)", "join(5..6,9..10)", "9..12"},
+		},
+		{
+			name:      "delete part of a join",
+			edit:      func(sequence Genbank) (Genbank, error) { return sequence.Delete(8, 10) },
+			sequence:  "AAAACCCCGGTTTT",
+			locations: []string{"1..4", "complement(5..8)", "11..12", "11..14"},
+		},
+		{
+			name:      "replace inside a feature",
+			edit:      func(sequence Genbank) (Genbank, error) { return sequence.Replace(5, 7, "TTT") },
+			sequence:  "AAAACTTTCGGGGTTTT",
+			locations: []string{"1..4", "complement(5..9)", "join(10..11,14..15)", "14..17"},
+		},
+		{
+			name:      "delete across the origin",
+			edit:      func(sequence Genbank) (Genbank, error) { return sequence.Delete(14, 2) },
+			circular:  true,
+			sequence:  "AACCCCGGGGTT",
+			locations: []string{"<1..2", "complement(3..6)", "join(7..8,11..12)", "11..>12"},
+		},
+	}
+	for _, test := range tests {
+		edited, err := test.edit(editTestSequence(test.circular))
+		if err != nil {
+			t.Errorf("%s: error = %v", test.name, err)
+			continue
+		}
+		if edited.Sequence != test.sequence {
+			t.Errorf("%s: sequence = %q, want %q", test.name, edited.Sequence, test.sequence)
+		}
+		got := locationStrings(edited)
+		if len(got) != len(test.locations) {
+			t.Errorf("%s: locations = %q, want %q", test.name, got, test.locations)
+			continue
+		}
+		for index := range got {
+			if got[index] != test.locations[index] {
+				t.Errorf("%s: locations = %q, want %q", test.name, got, test.locations)
+				break
+			}
+		}
+		if edited.Meta.Locus.SequenceLength != strconv.Itoa(len(test.sequence)) {
+			t.Errorf("%s: SequenceLength = %q", test.name, edited.Meta.Locus.SequenceLength)
+		}
+	}
+
+	original := editTestSequence(false)
+	if _, err := original.Delete(10, 20); err == nil {
+		t.Errorf("Delete() past the end should fail")
+	}
+	if _, err := original.Delete(10, 2); err == nil {
+		t.Errorf("Delete() across the origin of a linear sequence should fail")
+	}
+	if _, err := original.Rotate(2); err == nil {
+		t.Errorf("Rotate() of a linear sequence should fail")
+	}
+	if original.Sequence != "AAAACCCCGGGGTTTT" || BuildLocationString(original.Features[0].Location) != "1..4" {
+		t.Errorf("editing changed the original sequence")
+	}
+}
+
+func TestRotate(t *testing.T) {
+	rotated, err := editTestSequence(true).Rotate(6)
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if rotated.Sequence != "CCGGGGTTTTAAAACC" {
+		t.Errorf("Rotate() sequence = %q", rotated.Sequence)
+	}
+	if got := BuildLocationString(rotated.Features[1].Location); got != "complement(join(15..16,1..2))" {
+		t.Errorf("Rotate() split location = %q", got)
+	}
+	back, _ := rotated.Rotate(10)
+	if got := BuildLocationString(back.Features[1].Location); got != "complement(5..8)" {
+		t.Errorf("Rotate() back location = %q, want it merged", got)
+	}
+
+	// every feature of a real plasmid keeps its sequence at every origin.
+	puc19, err := Read("../../data/puc19.gbk")
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	want := make([]string, len(puc19.Features))
+	for index, feature := range puc19.Features {
+		want[index], _ = feature.GetSequence()
+	}
+	for origin := 0; origin < len(puc19.Sequence); origin += 97 {
+		rotated, err := puc19.Rotate(origin)
+		if err != nil {
+			t.Fatalf("Rotate(%d) error = %v", origin, err)
+		}
+		for index, feature := range rotated.Features {
+			if got, _ := feature.GetSequence(); got != want[index] {
+				t.Errorf("Rotate(%d) changed the sequence of feature %d", origin, index)
+			}
+		}
+	}
+}
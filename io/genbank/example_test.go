@@ -110,6 +110,21 @@ func ExampleParseMulti() {
 	// Output: 05-FEB-1999
 }
 
+func ExampleGenbank_AssembleContig() {
+	records, _ := genbank.ReadMulti("../../data/contig_test.gbk")
+	scaffold := records[1]
+	fmt.Println(scaffold.Meta.Contig)
+
+	sequence, err := scaffold.AssembleContig(records)
+	if err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println(sequence)
+	// Output:
+	// join(TEST0001.1:1..30,gap(10),complement(TEST0002.1:5..20))
+	// atggctagcatcgatcgatcgatcgtagctnnnnnnnnnncgatttagctagcctg
+}
+
 func ExampleGenbank_AddFeature() {
 	// Sequence for greenflourescent protein (GFP) that we're using as test data for this example.
 	gfpSequence := "ATGGCTAGCAAAGGAGAAGAACTTTTCACTGGAGTTGTCCCAATTCTTGTTGAATTAGATGGTGATGTTAATGGGCACAAATTTTCTGTCAGTGGAGAGGGTGAAGGTGATGCTACATACGGAAAGCTTACCCTTAAATTTATTTGCACTACTGGAAAACTACCTGTTCCATGGCCAACACTTGTCACTACTTTCTCTTATGGTGTTCAATGCTTTTCCCGTTATCCGGATCATATGAAACGGCATGACTTTTTCAAGAGTGCCATGCCCGAAGGTTATGTACAGGAACGCACTATATCTTTCAAAGATGACGGGAACTACAAGACGCGTGCTGAAGTCAAGTTTGAAGGTGATACCCTTGTTAATCGTATCGAGTTAAAAGGTATTGATTTTAAAGAAGATGGAAACATTCTCGGACACAAACTCGAGTACAACTATAACTCACACAATGTATACATCACGGCAGACAAACAAAAGAATGGAATCAAAGCTAACTTCAAAATTCGCCACAACATTGAAGATGGATCCGTTCAACTAGCAGACCATTATCAACAAAATACTCCAATTGGCGATGGCCCTGTCCTTTTACCAGACAACCATTACCTGTCGACACAATCTGCCCTTTCGAAAGATCCCAACGAAAAGCGTGACCACATGGTCCTTCTTGAGTTTGTAACTGCTGCTGGGATTACACATGGCATGGATGAGCTCTACAAATAA"
@@ -166,3 +181,50 @@ func ExampleFeature_GetSequence() {
 
 	// Output: true
 }
+
+func ExampleFeatureIndex_FeaturesAt() {
+	sequence, _ := genbank.Read("../../data/puc19.gbk")
+	index := sequence.Index()
+
+	// pUC19's ampicillin resistance gene covers position 2000.
+	for _, feature := range index.FeaturesAt(2000) {
+		fmt.Println(feature.Type)
+	}
+	// Output:
+	// source
+	// CDS
+}
+
+func ExampleLocus_ModificationTime() {
+	sequence, _ := genbank.Read("../../data/puc19.gbk")
+	modified, _ := sequence.Meta.Locus.ModificationTime()
+	fmt.Println(modified.Year(), modified.Month())
+
+	// stamp the record with a new date before writing it.
+	sequence.Meta.Locus.SetModificationDate(modified.AddDate(1, 0, 0))
+	fmt.Println(sequence.Meta.Locus.ModificationDate)
+	// Output:
+	// 2019 October
+	// 22-OCT-2020
+}
+
+func ExampleLocation_Extract() {
+	location, _ := genbank.ParseLocation("join(complement(5..8),13..16)")
+	extracted, _ := location.Extract("AAAACCCCGGGGTTTT")
+	fmt.Println(extracted, location.Length())
+	fmt.Println(genbank.BuildLocationString(location.Shift(100)))
+	// Output:
+	// GGGGTTTT 8
+	// join(complement(105..108),113..116)
+}
+
+func ExampleGenbank_Insert() {
+	sequence := genbank.Genbank{Sequence: "ATGAAATAAGGG"}
+	location, _ := genbank.ParseLocation("1..9")
+	_ = sequence.AddFeature(&genbank.Feature{Type: "CDS", Location: location})
+
+	edited, _ := sequence.Insert(3, "GGC")
+	cds, _ := edited.Features[0].GetSequence()
+	fmt.Println(genbank.BuildLocationString(edited.Features[0].Location), cds)
+	// Output: 1..12 ATGGGCAAATAA
+}
@@ -7,20 +7,30 @@ sequences.
 
 This package provides a parser and writer to convert between the GenBank file
 format and the more general Genbank struct.
+
+Files can have many records in them, which ParseMulti reads one by one. CON
+records, which are assembled from other records rather than having a sequence
+of their own, are read with the CONTIG line that says how in Meta.Contig, and
+can be assembled with AssembleContig.
 */
 package genbank
 
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
-	"github.com/bebop/poly/transform"
+	polyio "github.com/bebop/poly/io"
+	"github.com/bebop/poly/search/interval"
+	"github.com/bebop/poly/sequence"
+	"github.com/bebop/poly/warning"
 	"github.com/lunny/log"
 	"github.com/mitchellh/go-wordwrap"
 )
@@ -41,7 +51,8 @@ var (
 type Genbank struct {
 	Meta     Meta
 	Features []Feature
-	Sequence string // will be changed and include reader, writer, and byte slice.
+	Sequence string            // will be changed and include reader, writer, and byte slice.
+	Warnings []warning.Warning `json:"Warnings,omitempty"` // non-fatal issues found while parsing.
 }
 
 // Meta holds the meta data for Genbank and other annotated sequence files.
@@ -62,18 +73,33 @@ type Meta struct {
 	Name                 string            `json:"name"`
 	SequenceHash         string            `json:"sequence_hash"`
 	SequenceHashFunction string            `json:"hash_function"`
+	// Contig is the location on a CON record's CONTIG line, which joins
+	// ranges of other records, and gaps, into the record's sequence. See
+	// ContigParts and AssembleContig.
+	Contig string `json:"contig,omitempty"`
 }
 
 // Feature holds the information for a feature in a Genbank file and other annotated sequence files.
 type Feature struct {
-	Type                 string            `json:"type"`
-	Description          string            `json:"description"`
-	Attributes           map[string]string `json:"attributes"`
-	SequenceHash         string            `json:"sequence_hash"`
-	SequenceHashFunction string            `json:"hash_function"`
-	Sequence             string            `json:"sequence"`
-	Location             Location          `json:"location"`
-	ParentSequence       *Genbank          `json:"-"`
+	Type        string            `json:"type"`
+	Description string            `json:"description"`
+	Attributes  map[string]string `json:"attributes"`
+	// Qualifiers are the feature's qualifiers in the order they're written
+	// in, including repeated ones like /db_xref, of which Attributes only
+	// keeps the last. Build writes Attributes in this order, and writes
+	// every repeat of a qualifier whose last value Attributes still has.
+	Qualifiers           []Qualifier `json:"qualifiers"`
+	SequenceHash         string      `json:"sequence_hash"`
+	SequenceHashFunction string      `json:"hash_function"`
+	Sequence             string      `json:"sequence"`
+	Location             Location    `json:"location"`
+	ParentSequence       *Genbank    `json:"-"`
+}
+
+// Qualifier is a qualifier of a feature, like /gene="lacZ".
+type Qualifier struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
 }
 
 // Reference holds information for one reference in a Meta struct.
@@ -92,6 +118,7 @@ type Locus struct {
 	Name             string `json:"name"`
 	SequenceLength   string `json:"sequence_length"`
 	MoleculeType     string `json:"molecule_type"`
+	Strandedness     string `json:"strandedness"` // ss, ds, or ms, if the LOCUS line says.
 	GenbankDivision  string `json:"genbank_division"`
 	ModificationDate string `json:"modification_date"`
 	SequenceCoding   string `json:"sequence_coding"`
@@ -104,6 +131,7 @@ type Location struct {
 	End               int        `json:"end"`
 	Complement        bool       `json:"complement"`
 	Join              bool       `json:"join"`
+	Order             bool       `json:"order"` // parts listed in order, but not necessarily joined.
 	FivePrimePartial  bool       `json:"five_prime_partial"`
 	ThreePrimePartial bool       `json:"three_prime_partial"`
 	GbkLocationString string     `json:"gbk_location_string"`
@@ -118,10 +146,11 @@ type BaseCount struct {
 
 // Precompiled regular expressions:
 var (
-	basePairRegex         = regexp.MustCompile(` \d* \w{2} `)
+	basePairRegex         = regexp.MustCompile(` \d+ \w{2} `)
 	circularRegex         = regexp.MustCompile(` circular `)
-	modificationDateRegex = regexp.MustCompile(`\d{2}-[A-Z]{3}-\d{4}`)
+	modificationDateRegex = regexp.MustCompile(`\b\d{1,2}-[A-Za-z]{3}-\d{4}\b`)
 	partialRegex          = regexp.MustCompile("<|>")
+	strandednessRegex     = regexp.MustCompile(` (ss|ds|ms)-[A-Za-z]`)
 	sequenceRegex         = regexp.MustCompile("[^a-zA-Z]+")
 )
 
@@ -134,33 +163,119 @@ func (sequence *Genbank) AddFeature(feature *Feature) error {
 
 // GetSequence returns the sequence of a feature.
 func (feature Feature) GetSequence() (string, error) {
-	return getFeatureSequence(feature, feature.Location)
+	return feature.Location.Extract(feature.ParentSequence.Sequence)
 }
 
-// getFeatureSequence takes a feature and location object and returns a sequence string.
-func getFeatureSequence(feature Feature, location Location) (string, error) {
-	var sequenceBuffer bytes.Buffer
-	var sequenceString string
-	parentSequence := feature.ParentSequence.Sequence
+// ToSequence returns a Genbank's sequence with the molecule, topology, and
+// strandedness its LOCUS line describes. DNA is double stranded and RNA single
+// stranded unless the LOCUS line says otherwise, and the molecule is guessed
+// from the sequence if the LOCUS line doesn't say.
+func (genbank Genbank) ToSequence() sequence.Sequence {
+	name := genbank.Meta.Locus.Name
+	if name == "" {
+		name = genbank.Meta.Name
+	}
+	result := sequence.New(name, genbank.Sequence)
+	switch {
+	case strings.Contains(genbank.Meta.Locus.MoleculeType, "RNA"):
+		result.Molecule = sequence.RNA
+	case strings.Contains(genbank.Meta.Locus.MoleculeType, "DNA"):
+		result.Molecule = sequence.DNA
+	}
+	result.Circular = genbank.Meta.Locus.Circular
+	result.DoubleStranded = result.Molecule == sequence.DNA
+	switch genbank.Meta.Locus.Strandedness {
+	case "ss":
+		result.DoubleStranded = false
+	case "ds":
+		result.DoubleStranded = result.Molecule != sequence.Protein
+	}
+	return result
+}
 
-	if len(location.SubLocations) == 0 {
-		sequenceBuffer.WriteString(parentSequence[location.Start:location.End])
-	} else {
-		for _, subLocation := range location.SubLocations {
-			sequence, _ := getFeatureSequence(feature, subLocation)
+// FromSequence returns a Genbank of a sequence, without features.
+func FromSequence(input sequence.Sequence) Genbank {
+	locus := Locus{
+		Name:           input.Name,
+		SequenceLength: strconv.Itoa(input.Len()),
+		SequenceCoding: "bp",
+		Circular:       input.Circular,
+	}
+	switch input.Molecule {
+	case sequence.DNA, sequence.RNA:
+		locus.MoleculeType = string(input.Molecule)
+	case sequence.Protein:
+		locus.SequenceCoding = "aa"
+	}
+	return Genbank{Meta: Meta{Name: input.Name, Locus: locus}, Sequence: input.Sequence}
+}
+
+// FeatureIndex is an interval tree over the features of a Genbank record that
+// answers positional queries in O(log n + k) rather than scanning every feature.
+// The index is a snapshot of the record at the time Index was called.
+type FeatureIndex struct {
+	tree     *interval.Tree[int]
+	features []Feature
+}
 
-			sequenceBuffer.WriteString(sequence)
+// Index builds a FeatureIndex over the sequence's features. Every leaf of a
+// feature's location is indexed separately, so the introns of a joined CDS do
+// not count as overlapping the CDS.
+func (sequence *Genbank) Index() *FeatureIndex {
+	var intervals []interval.Interval[int]
+	for featureIndex, feature := range sequence.Features {
+		for _, leaf := range leafLocations(feature.Location) {
+			intervals = append(intervals, interval.Interval[int]{Start: leaf.Start, End: leafEnd(leaf), Value: featureIndex})
 		}
 	}
+	return &FeatureIndex{
+		tree:     interval.New(intervals),
+		features: sequence.Features,
+	}
+}
 
-	// reverse complements resulting string if needed.
-	if location.Complement {
-		sequenceString = transform.ReverseComplement(sequenceBuffer.String())
-	} else {
-		sequenceString = sequenceBuffer.String()
+// FeaturesOverlapping returns the features overlapping the 0-based, half-open
+// range [start, end) in the order they appear in the record.
+func (index *FeatureIndex) FeaturesOverlapping(start, end int) []Feature {
+	return index.collect(index.tree.Overlapping(start, end))
+}
+
+// FeaturesAt returns the features covering the 0-based position in the order
+// they appear in the record.
+func (index *FeatureIndex) FeaturesAt(position int) []Feature {
+	return index.collect(index.tree.At(position))
+}
+
+// collect deduplicates hits from features with multiple leaf locations and
+// restores record order.
+func (index *FeatureIndex) collect(hits []interval.Interval[int]) []Feature {
+	featureIndices := make([]int, 0, len(hits))
+	seen := make(map[int]bool, len(hits))
+	for _, hit := range hits {
+		if !seen[hit.Value] {
+			seen[hit.Value] = true
+			featureIndices = append(featureIndices, hit.Value)
+		}
+	}
+	sort.Ints(featureIndices)
+
+	features := make([]Feature, len(featureIndices))
+	for i, featureIndex := range featureIndices {
+		features[i] = index.features[featureIndex]
 	}
+	return features
+}
 
-	return sequenceString, nil
+// leafLocations flattens a location into the locations that actually carry coordinates.
+func leafLocations(location Location) []Location {
+	if len(location.SubLocations) == 0 {
+		return []Location{location}
+	}
+	var leaves []Location
+	for _, subLocation := range location.SubLocations {
+		leaves = append(leaves, leafLocations(subLocation)...)
+	}
+	return leaves
 }
 
 // Read reads a GBK file from path and returns a Genbank struct.
@@ -226,41 +341,22 @@ func Build(gbk Genbank) ([]byte, error) {
 func BuildMulti(sequences []Genbank) ([]byte, error) {
 	var gbkString bytes.Buffer
 	for _, sequence := range sequences {
-		locus := sequence.Meta.Locus
-		var shape string
-
-		if locus.Circular {
-			shape = "circular"
-		} else {
-			shape = "linear"
+		gbkString.WriteString(buildLocusString(sequence))
+
+		// building other standard meta features. Fields that are empty are
+		// left out, rather than written as a bare key.
+		gbkString.WriteString(buildOptionalMetaString("DEFINITION", sequence.Meta.Definition))
+		gbkString.WriteString(buildOptionalMetaString("ACCESSION", sequence.Meta.Accession))
+		gbkString.WriteString(buildOptionalMetaString("VERSION", sequence.Meta.Version))
+		gbkString.WriteString(buildOptionalMetaString("DBLINK", sequence.Meta.Other["DBLINK"]))
+		gbkString.WriteString(buildOptionalMetaString("KEYWORDS", sequence.Meta.Keywords))
+		gbkString.WriteString(buildOptionalMetaString("SEGMENT", sequence.Meta.Other["SEGMENT"]))
+
+		if sequence.Meta.Source != "" || sequence.Meta.Organism != "" || len(sequence.Meta.Taxonomy) > 0 {
+			gbkString.WriteString(buildMetaString("SOURCE", sequence.Meta.Source))
+			gbkString.WriteString(buildMetaString("  ORGANISM", sequence.Meta.Organism))
 		}
 
-		fivespace := generateWhiteSpace(subMetaIndex)
-
-		// building locus
-		locusData := locus.Name + fivespace + locus.SequenceLength + " bp" + fivespace + locus.MoleculeType + fivespace + shape + fivespace + locus.GenbankDivision + fivespace + locus.ModificationDate
-		locusString := "LOCUS       " + locusData + "\n"
-		gbkString.WriteString(locusString)
-
-		// building other standard meta features
-		definitionString := buildMetaString("DEFINITION", sequence.Meta.Definition)
-		gbkString.WriteString(definitionString)
-
-		accessionString := buildMetaString("ACCESSION", sequence.Meta.Accession)
-		gbkString.WriteString(accessionString)
-
-		versionString := buildMetaString("VERSION", sequence.Meta.Version)
-		gbkString.WriteString(versionString)
-
-		keywordsString := buildMetaString("KEYWORDS", sequence.Meta.Keywords)
-		gbkString.WriteString(keywordsString)
-
-		sourceString := buildMetaString("SOURCE", sequence.Meta.Source)
-		gbkString.WriteString(sourceString)
-
-		organismString := buildMetaString("  ORGANISM", sequence.Meta.Organism)
-		gbkString.WriteString(organismString)
-
 		if len(sequence.Meta.Taxonomy) > 0 {
 			var taxonomyString strings.Builder
 			for i, taxonomyData := range sequence.Meta.Taxonomy {
@@ -277,43 +373,37 @@ func BuildMulti(sequences []Genbank) ([]byte, error) {
 		// building references
 		// TODO: could use reflection to get keys and make more general.
 		for referenceIndex, reference := range sequence.Meta.References {
-			referenceString := buildMetaString("REFERENCE", fmt.Sprintf("%d  %s", referenceIndex+1, reference.Range))
-			gbkString.WriteString(referenceString)
-
-			if reference.Authors != "" {
-				authorsString := buildMetaString("  AUTHORS", reference.Authors)
-				gbkString.WriteString(authorsString)
-			}
-
-			if reference.Title != "" {
-				titleString := buildMetaString("  TITLE", reference.Title)
-				gbkString.WriteString(titleString)
-			}
-
-			if reference.Journal != "" {
-				journalString := buildMetaString("  JOURNAL", reference.Journal)
-				gbkString.WriteString(journalString)
-			}
-
-			if reference.PubMed != "" {
-				pubMedString := buildMetaString("  PUBMED", reference.PubMed)
-				gbkString.WriteString(pubMedString)
-			}
-			if reference.Consortium != "" {
-				consrtmString := buildMetaString("  CONSRTM", reference.Consortium)
-				gbkString.WriteString(consrtmString)
+			referenceNumber := strconv.Itoa(referenceIndex + 1)
+			if reference.Range != "" {
+				referenceNumber += "  " + reference.Range
 			}
+			gbkString.WriteString(buildMetaString("REFERENCE", referenceNumber))
+			gbkString.WriteString(buildOptionalMetaString("  AUTHORS", reference.Authors))
+			gbkString.WriteString(buildOptionalMetaString("  CONSRTM", reference.Consortium))
+			gbkString.WriteString(buildOptionalMetaString("  TITLE", reference.Title))
+			gbkString.WriteString(buildOptionalMetaString("  JOURNAL", reference.Journal))
+			gbkString.WriteString(buildOptionalMetaString("   PUBMED", reference.PubMed))
+			gbkString.WriteString(buildOptionalMetaString("  REMARK", reference.Remark))
 		}
 
-		// building other meta fields that are catch all
+		// building other meta fields that are catch all. COMMENT and PRIMARY
+		// come first, like NCBI writes them, and the rest in alphabetical
+		// order so records always build the same way.
 		otherKeys := make([]string, 0, len(sequence.Meta.Other))
 		for key := range sequence.Meta.Other {
+			switch key {
+			case "DBLINK", "SEGMENT", "COMMENT", "PRIMARY":
+				continue
+			}
 			otherKeys = append(otherKeys, key)
 		}
+		sort.Strings(otherKeys)
+		otherKeys = append([]string{"COMMENT", "PRIMARY"}, otherKeys...)
 
 		for _, otherKey := range otherKeys {
-			otherString := buildMetaString(otherKey, sequence.Meta.Other[otherKey])
-			gbkString.WriteString(otherString)
+			if otherData, ok := sequence.Meta.Other[otherKey]; ok {
+				gbkString.WriteString(buildMetaString(otherKey, otherData))
+			}
 		}
 
 		// start writing features section.
@@ -322,15 +412,28 @@ func BuildMulti(sequences []Genbank) ([]byte, error) {
 			gbkString.WriteString(BuildFeatureString(feature))
 		}
 
+		if sequence.Meta.Contig != "" {
+			for index, line := range WrapLocation(sequence.Meta.Contig, contigWidth) {
+				if index == 0 {
+					gbkString.WriteString("CONTIG      " + line + "\n")
+				} else {
+					gbkString.WriteString(generateWhiteSpace(12) + line + "\n")
+				}
+			}
+		}
+
 		if len(sequence.Meta.BaseCount) > 0 {
-			gbkString.WriteString("BASE COUNT    ")
+			gbkString.WriteString("BASE COUNT")
 			for _, baseCount := range sequence.Meta.BaseCount {
-				gbkString.WriteString(strconv.Itoa(baseCount.Count) + " " + baseCount.Base + "   ")
+				fmt.Fprintf(&gbkString, " %8d %s", baseCount.Count, baseCount.Base)
 			}
 			gbkString.WriteString("\n")
 		}
-		// start writing sequence section.
-		gbkString.WriteString("ORIGIN\n")
+		// start writing sequence section. CON records are written without
+		// one, unless they have a sequence.
+		if sequence.Meta.Contig == "" || len(sequence.Sequence) > 0 {
+			gbkString.WriteString("ORIGIN\n")
+		}
 
 		// iterate over every character in sequence range.
 		for index, base := range sequence.Sequence {
@@ -356,12 +459,40 @@ func BuildMulti(sequences []Genbank) ([]byte, error) {
 			}
 		}
 		// finish genbank file with "//" on newline (again a genbank convention)
-		gbkString.WriteString("\n//\n")
+		if len(sequence.Sequence) > 0 {
+			gbkString.WriteString("\n")
+		}
+		gbkString.WriteString("//\n")
 	}
 
 	return gbkString.Bytes(), nil
 }
 
+// buildLocusString builds the LOCUS line of a record, with its fields in the
+// columns NCBI writes them in. The length is the sequence's if the locus
+// doesn't have one.
+func buildLocusString(sequence Genbank) string {
+	locus := sequence.Meta.Locus
+	shape := "linear"
+	if locus.Circular {
+		shape = "circular"
+	}
+	length := locus.SequenceLength
+	if length == "" {
+		length = strconv.Itoa(len(sequence.Sequence))
+	}
+	coding := locus.SequenceCoding
+	if coding == "" {
+		coding = "bp"
+	}
+	strandedness := ""
+	if locus.Strandedness != "" {
+		strandedness = locus.Strandedness + "-"
+	}
+	locusData := fmt.Sprintf("%-16s %11s %s %3s%-6s  %-8s %s %s", locus.Name, length, coding, strandedness, locus.MoleculeType, shape, locus.GenbankDivision, locus.ModificationDate)
+	return "LOCUS       " + strings.TrimRight(locusData, " ") + "\n"
+}
+
 // Parse takes in a reader representing a single gbk/gb/genbank file and parses it into a Genbank struct.
 func Parse(r io.Reader) (Genbank, error) {
 	genbankSlice, err := parseMultiNthFn(r, 1)
@@ -389,7 +520,6 @@ type parseLoopParameters struct {
 	quoteActive      bool
 	attribute        string
 	attributeValue   string
-	emptyAttribute   bool
 	sequenceBuilder  strings.Builder
 	parseStep        string
 	genbank          Genbank // since we are scanning lines we need a Genbank struct to store the data outside the loop.
@@ -413,8 +543,9 @@ func (params *parseLoopParameters) init() {
 }
 
 // ParseMultiNth takes in a reader representing a multi gbk/gb/genbank file and parses the first n records into a slice of Genbank structs.
+// Gzipped files are decompressed.
 func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
-	scanner := bufio.NewScanner(r)
+	scanner := bufio.NewScanner(polyio.Decompress(r))
 	var genbanks []Genbank
 
 	// Sequence setup
@@ -441,6 +572,7 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 				parameters = parseLoopParameters{}
 				parameters.init()
 				parameters.genbank.Meta.Locus = parseLocus(line)
+				parameters.genbank.Warnings = locusWarnings(line, parameters.genbank.Meta.Locus, lineNum+1)
 				parameters.genbankStarted = true
 			}
 			continue
@@ -448,9 +580,14 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 
 		switch parameters.parseStep {
 		case "metadata":
-			// Handle empty lines
-			if len(line) == 0 {
-				return genbanks, fmt.Errorf("Empty metadata line on line %d", lineNum)
+			// Handle empty lines, which long comments can have between
+			// paragraphs, but nothing else can.
+			if len(strings.TrimSpace(line)) == 0 {
+				if parameters.metadataTag != "COMMENT" {
+					return genbanks, fmt.Errorf("Empty metadata line on line %d", lineNum)
+				}
+				parameters.metadataData = append(parameters.metadataData, "")
+				continue
 			}
 
 			// If we are currently reading a line, we need to figure out if it is a new meta line.
@@ -468,6 +605,8 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 					parameters.genbank.Meta.Keywords = parseMetadata(parameters.metadataData)
 				case "SOURCE":
 					parameters.genbank.Meta.Source, parameters.genbank.Meta.Organism, parameters.genbank.Meta.Taxonomy = getSourceOrganism(parameters.metadataData)
+				case "COMMENT":
+					parameters.genbank.Meta.Other["COMMENT"] = parseComment(parameters.metadataData)
 				case "REFERENCE":
 					reference, err := parseReferencesFn(parameters.metadataData)
 					if err != nil {
@@ -476,11 +615,19 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 					parameters.genbank.Meta.References = append(parameters.genbank.Meta.References, reference)
 
 				case "FEATURES":
+					// records without any features go straight to their sequence,
+					// or to their CONTIG.
+					if strings.HasPrefix(line, "ORIGIN") {
+						parameters.parseStep = "sequence"
+						continue
+					}
+					if strings.HasPrefix(line, "CONTIG") {
+						break
+					}
 					parameters.parseStep = "features"
 
 					// We know that we are now parsing features, so lets initialize our first feature
-					parameters.feature.Type = strings.TrimSpace(splitLine[0])
-					parameters.feature.Location.GbkLocationString = strings.TrimSpace(splitLine[len(splitLine)-1])
+					parameters.feature.Type, parameters.feature.Location.GbkLocationString = splitFeatureLine(line)
 					parameters.newLocation = true
 
 					continue
@@ -493,6 +640,9 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 
 				parameters.metadataTag = strings.TrimSpace(splitLine[0])
 				parameters.metadataData = []string{strings.TrimSpace(line[len(parameters.metadataTag):])}
+				if parameters.metadataTag == "CONTIG" {
+					parameters.parseStep = "contig"
+				}
 			} else {
 				parameters.metadataData = append(parameters.metadataData, line)
 			}
@@ -500,49 +650,26 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 
 			baseCountFlag := strings.Contains(line, "BASE COUNT") // example string for BASE COUNT: "BASE COUNT    67070277 a   48055043 c   48111528 g   67244164 t   18475410 n"
 			if baseCountFlag {
-				fields := strings.Fields(line)
-				for countIndex := 2; countIndex < len(fields)-1; countIndex += 2 { // starts at two because we don't want to include "BASE COUNT" in our fields
-					count, err := strconv.Atoi(fields[countIndex])
-					if err != nil {
-						return []Genbank{}, err
-					}
-
-					baseCount := BaseCount{
-						Base:  fields[countIndex+1],
-						Count: count,
-					}
-					parameters.genbank.Meta.BaseCount = append(parameters.genbank.Meta.BaseCount, baseCount)
+				if err := parameters.parseBaseCount(line); err != nil {
+					return []Genbank{}, err
 				}
 				break
 			}
+			// CON records end their features with a CONTIG instead of a sequence.
+			if strings.HasPrefix(line, "CONTIG") {
+				if err := parameters.finishFeatures(); err != nil {
+					return []Genbank{}, err
+				}
+				parameters.parseStep = "contig"
+				parameters.metadataData = []string{strings.TrimSpace(strings.TrimPrefix(line, "CONTIG"))}
+				continue
+			}
 			// Switch to sequence parsing
 			originFlag := strings.Contains(line, "ORIGIN") // we detect the beginning of the sequence with "ORIGIN"
 			if originFlag {
 				parameters.parseStep = "sequence"
-
-				// save our completed attribute / qualifier string to the current feature
-				if parameters.attributeValue != "" {
-					parameters.feature.Attributes[parameters.attribute] = parameters.attributeValue
-					parameters.features = append(parameters.features, parameters.feature)
-					parameters.attributeValue = ""
-					parameters.attribute = ""
-					parameters.feature = Feature{}
-					parameters.feature.Attributes = make(map[string]string)
-				} else {
-					parameters.features = append(parameters.features, parameters.feature)
-				}
-
-				// add our features to the genbank
-				for _, feature := range parameters.features {
-					location, err := parseLocation(feature.Location.GbkLocationString)
-					if err != nil {
-						return []Genbank{}, err
-					}
-					feature.Location = location
-					err = parameters.genbank.AddFeature(&feature)
-					if err != nil {
-						return []Genbank{}, err
-					}
+				if err := parameters.finishFeatures(); err != nil {
+					return []Genbank{}, err
 				}
 				continue
 			} // end sequence parsing flag logic
@@ -556,16 +683,8 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 			// determine if current line is a new top level feature
 			if countLeadingSpaces(parameters.currentLine) < countLeadingSpaces(parameters.prevline) || parameters.prevline == "FEATURES" {
 				// save our completed attribute / qualifier string to the current feature
-				if parameters.attributeValue != "" {
-					parameters.feature.Attributes[parameters.attribute] = parameters.attributeValue
-					parameters.features = append(parameters.features, parameters.feature)
-					parameters.attributeValue = ""
-					parameters.attribute = ""
-					parameters.feature = Feature{}
-					parameters.feature.Attributes = make(map[string]string)
-				}
+				parameters.saveQualifier()
 
-				// }
 				// checks for empty types
 				if parameters.feature.Type != "" {
 					parameters.features = append(parameters.features, parameters.feature)
@@ -578,8 +697,7 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 				if len(splitLine) < 2 {
 					return genbanks, fmt.Errorf("Feature line malformed on line %d. Got line: %s", lineNum, line)
 				}
-				parameters.feature.Type = strings.TrimSpace(splitLine[0])
-				parameters.feature.Location.GbkLocationString = strings.TrimSpace(splitLine[len(splitLine)-1])
+				parameters.feature.Type, parameters.feature.Location.GbkLocationString = splitFeatureLine(line)
 				parameters.multiLineFeature = false // without this we can't tell if something is a multiline feature or multiline qualifier
 			} else if !strings.Contains(parameters.currentLine, "/") { // current line is continuation of a feature or qualifier (sub-constituent of a feature)
 				// if it's a continuation of the current feature, add it to the location
@@ -589,46 +707,54 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 				} else { // it's a continued line of a qualifier
 					removeAttributeValueQuotes := strings.Replace(trimmedLine, "\"", "", -1)
 
-					parameters.attributeValue = parameters.attributeValue + removeAttributeValueQuotes
+					parameters.continueQualifier(removeAttributeValueQuotes)
 				}
 			} else if strings.Contains(parameters.currentLine, "/") { // current line is a new qualifier
 				trimmedCurrentLine := strings.TrimSpace(parameters.currentLine)
 				if trimmedCurrentLine[0] != '/' { // if we have an exception case, like (adenine(1518)-N(6)/adenine(1519)-N(6))-
-					parameters.attributeValue = parameters.attributeValue + trimmedCurrentLine
+					parameters.continueQualifier(strings.Replace(trimmedCurrentLine, "\"", "", -1))
 					continue
 				}
 				// save our completed attribute / qualifier string to the current feature
-				if parameters.attributeValue != "" || parameters.emptyAttribute {
-					parameters.feature.Attributes[parameters.attribute] = parameters.attributeValue
-					parameters.emptyAttribute = false
-				}
-				parameters.attributeValue = ""
-				splitAttribute := strings.Split(line, "=")
-				trimmedSpaceAttribute := strings.TrimSpace(splitAttribute[0])
+				parameters.saveQualifier()
+				// values can have = in them, so only the first splits the qualifier.
+				attribute, value, _ := strings.Cut(line, "=") // ` /pseudo ` has no value.
+				trimmedSpaceAttribute := strings.TrimSpace(attribute)
 				removedForwardSlashAttribute := strings.Replace(trimmedSpaceAttribute, "/", "", 1)
 
 				parameters.attribute = removedForwardSlashAttribute
-
-				var removeAttributeValueQuotes string
-				if len(splitAttribute) == 1 { // handle case of ` /pseudo `, which has no text
-					removeAttributeValueQuotes = ""
-					parameters.emptyAttribute = true
-				} else { // this is normally triggered
-					removeAttributeValueQuotes = strings.Replace(splitAttribute[1], "\"", "", -1)
-				}
-				parameters.attributeValue = removeAttributeValueQuotes
+				parameters.attributeValue = strings.Replace(strings.TrimSpace(value), "\"", "", -1)
 				parameters.multiLineFeature = false // without this we can't tell if something is a multiline feature or multiline qualifier
 			}
 
+		case "contig":
+			// the CONTIG location continues on indented lines, and can be
+			// followed by a sequence, though it usually isn't.
+			if len(strings.TrimSpace(line)) == 0 {
+				continue
+			}
+			if line[0] == ' ' {
+				parameters.metadataData = append(parameters.metadataData, strings.TrimSpace(line))
+				continue
+			}
+			parameters.genbank.Meta.Contig = strings.Join(parameters.metadataData, "")
+			switch {
+			case strings.HasPrefix(line, "BASE COUNT"):
+				if err := parameters.parseBaseCount(line); err != nil {
+					return []Genbank{}, err
+				}
+			case strings.HasPrefix(line, "ORIGIN"):
+				parameters.parseStep = "sequence"
+			case strings.HasPrefix(line, "//"):
+				genbanks = append(genbanks, parameters.finishRecord(lineNum))
+			default:
+				return genbanks, fmt.Errorf("Unexpected line after CONTIG on line %d. Got line: %s", lineNum, line)
+			}
 		case "sequence":
 			if len(line) < 2 { // throw error if line is malformed
 				return genbanks, fmt.Errorf("Too short line found while parsing genbank sequence on line %d. Got line: %s", lineNum, line)
 			} else if line[0:2] == "//" { // end of sequence
-				parameters.genbank.Sequence = parameters.sequenceBuilder.String()
-
-				genbanks = append(genbanks, parameters.genbank)
-				parameters.genbankStarted = false
-				parameters.sequenceBuilder.Reset()
+				genbanks = append(genbanks, parameters.finishRecord(lineNum))
 			} else { // add line to total sequence
 				parameters.sequenceBuilder.WriteString(sequenceRegex.ReplaceAllString(line, ""))
 			}
@@ -640,6 +766,88 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 	return genbanks, nil
 }
 
+// parseBaseCount adds the counts of a BASE COUNT line to the record.
+func (params *parseLoopParameters) parseBaseCount(line string) error {
+	fields := strings.Fields(line)
+	for countIndex := 2; countIndex < len(fields)-1; countIndex += 2 { // starts at two because we don't want to include "BASE COUNT" in our fields
+		count, err := strconv.Atoi(fields[countIndex])
+		if err != nil {
+			return err
+		}
+
+		baseCount := BaseCount{
+			Base:  fields[countIndex+1],
+			Count: count,
+		}
+		params.genbank.Meta.BaseCount = append(params.genbank.Meta.BaseCount, baseCount)
+	}
+	return nil
+}
+
+// finishFeatures saves the feature being read, and adds every feature read
+// to the record.
+func (params *parseLoopParameters) finishFeatures() error {
+	// save our completed attribute / qualifier string to the current feature
+	params.saveQualifier()
+	params.features = append(params.features, params.feature)
+	params.feature = Feature{}
+	params.feature.Attributes = make(map[string]string)
+
+	// add our features to the genbank
+	for _, feature := range params.features {
+		location, err := parseLocation(feature.Location.GbkLocationString)
+		if err != nil {
+			return err
+		}
+		feature.Location = location
+		err = params.genbank.AddFeature(&feature)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finishRecord returns the record read once its // line is reached. CON
+// records usually don't have a sequence, so theirs isn't checked against
+// their LOCUS length.
+func (params *parseLoopParameters) finishRecord(lineNum int) Genbank {
+	params.genbank.Sequence = params.sequenceBuilder.String()
+	hasSequence := params.genbank.Meta.Contig == "" || params.genbank.Sequence != ""
+	if length := params.genbank.Meta.Locus.SequenceLength; hasSequence && length != "" && length != strconv.Itoa(len(params.genbank.Sequence)) {
+		params.genbank.Warnings = append(params.genbank.Warnings, warning.AtLine(lineNum+1, "LOCUS declares %s bp but the sequence is %d bp", length, len(params.genbank.Sequence)))
+	}
+
+	params.genbankStarted = false
+	params.sequenceBuilder.Reset()
+	return params.genbank
+}
+
+// saveQualifier adds the qualifier being read, if there is one, to the
+// current feature.
+func (params *parseLoopParameters) saveQualifier() {
+	if params.attribute == "" {
+		return
+	}
+	params.feature.Attributes[params.attribute] = params.attributeValue
+	params.feature.Qualifiers = append(params.feature.Qualifiers, Qualifier{Key: params.attribute, Value: params.attributeValue})
+	params.attribute = ""
+	params.attributeValue = ""
+}
+
+// continueQualifier adds a continued line to the qualifier being read.
+func (params *parseLoopParameters) continueQualifier(text string) {
+	params.attributeValue += text
+}
+
+// splitFeatureLine splits the first line of a feature into its type and
+// location. Types can have spaces in them, like Benchling writes.
+func splitFeatureLine(line string) (string, string) {
+	trimmedLine := strings.TrimSpace(line)
+	locationStart := strings.LastIndex(trimmedLine, " ")
+	return strings.TrimSpace(trimmedLine[:locationStart+1]), trimmedLine[locationStart+1:]
+}
+
 func countLeadingSpaces(line string) int {
 	return len(line) - len(strings.TrimLeft(line, " "))
 }
@@ -656,6 +864,20 @@ func parseMetadata(metadataData []string) string {
 	return outputMetadata
 }
 
+// parseComment keeps the lines of a COMMENT as they are, since structured
+// comments, like "Annotation Date :: 08/28/2020", are a key and value per
+// line, lined up with spaces.
+func parseComment(metadataData []string) string {
+	lines := make([]string, len(metadataData))
+	for index, line := range metadataData {
+		if index > 0 {
+			line = line[min(countLeadingSpaces(line), 12):]
+		}
+		lines[index] = strings.TrimRight(line, " \r")
+	}
+	return strings.Join(lines, "\n")
+}
+
 func parseReferences(metadataData []string) (Reference, error) {
 	var reference Reference
 	var err error
@@ -670,8 +892,7 @@ func parseReferences(metadataData []string) (Reference, error) {
 		return Reference{}, fmt.Errorf("Got reference with no additional information")
 	}
 
-	referenceKey = strings.Split(strings.TrimSpace(metadataData[1]), " ")[0]
-	referenceValue = strings.TrimSpace(metadataData[1][len(referenceKey)+2:])
+	referenceKey, referenceValue = splitReferenceLine(metadataData[1])
 	for index := 2; index < len(metadataData); index++ {
 		if len(metadataData[index]) > 3 {
 			if metadataData[index][3] != ' ' {
@@ -679,8 +900,7 @@ func parseReferences(metadataData []string) (Reference, error) {
 				if err != nil {
 					return reference, err
 				}
-				referenceKey = strings.Split(strings.TrimSpace(metadataData[index]), " ")[0]
-				referenceValue = strings.TrimSpace(metadataData[index][len(referenceKey)+2:])
+				referenceKey, referenceValue = splitReferenceLine(metadataData[index])
 			} else {
 				// Otherwise, simply append the next metadata.
 				referenceValue = referenceValue + " " + strings.TrimSpace(metadataData[index])
@@ -695,6 +915,15 @@ func parseReferences(metadataData []string) (Reference, error) {
 	return reference, nil
 }
 
+// splitReferenceLine splits the first line of a reference field into its key
+// and value. Keys are indented by two spaces, except PUBMED, which NCBI
+// indents by three.
+func splitReferenceLine(line string) (string, string) {
+	trimmedLine := strings.TrimSpace(line)
+	referenceKey := strings.Split(trimmedLine, " ")[0]
+	return referenceKey, strings.TrimSpace(trimmedLine[len(referenceKey):])
+}
+
 func (reference *Reference) addKey(referenceKey string, referenceValue string) error {
 	switch referenceKey {
 	case "AUTHORS":
@@ -750,6 +979,8 @@ var genbankDivisions = []string{
 	"HTG", //HTG sequences (high-throughput genomic sequences)
 	"HTC", //unfinished high-throughput cDNA sequencing
 	"ENV", //environmental sampling sequences
+	"CON", //constructed sequences, assembled from the records their CONTIG joins
+	"TSA", //transcriptome shotgun assembly sequences
 }
 
 // TODO rewrite with proper error handling.
@@ -788,18 +1019,23 @@ func parseLocus(locusString string) Locus {
 		}
 	}
 
+	// strandedness, like the ds- of ds-DNA.
+	if match := strandednessRegex.FindStringSubmatch(locusString); match != nil {
+		locus.Strandedness = match[1]
+	}
+
 	// circularity flag
 	if circularRegex.Match([]byte(locusString)) {
 		locus.Circular = true
 	}
 
-	// genbank division
-	for _, genbankDivision := range genbankDivisions {
-		genbankDivisionRegex, _ := regexp.Compile(genbankDivision)
-		match := string(genbankDivisionRegex.Find([]byte(locusString)))
-		if match != "" {
-			locus.GenbankDivision = match
-			break
+	// genbank division, which is a field of its own, so names and dates
+	// that happen to have one in them aren't mistaken for it.
+	for _, field := range filteredLocusSplit[2:] {
+		for _, genbankDivision := range genbankDivisions {
+			if field == genbankDivision {
+				locus.GenbankDivision = field
+			}
 		}
 	}
 
@@ -809,6 +1045,26 @@ func parseLocus(locusString string) Locus {
 	return locus
 }
 
+// locusWarnings reports the guesses parseLocus had to make about a LOCUS line.
+func locusWarnings(locusString string, locus Locus, line int) []warning.Warning {
+	var warnings []warning.Warning
+	if !locus.Circular && !strings.Contains(locusString, " linear ") {
+		warnings = append(warnings, warning.AtLine(line, "LOCUS does not specify topology, assuming linear"))
+	}
+	if locus.MoleculeType == "" {
+		warnings = append(warnings, warning.AtLine(line, "LOCUS does not specify a known molecule type"))
+	}
+	if locus.SequenceLength == "" {
+		warnings = append(warnings, warning.AtLine(line, "LOCUS does not specify sequence length"))
+	}
+	if locus.ModificationDate != "" {
+		if _, err := locus.ModificationTime(); err != nil {
+			warnings = append(warnings, warning.AtLine(line, fmt.Sprintf("LOCUS modification date %s doesn't exist", locus.ModificationDate)))
+		}
+	}
+	return warnings
+}
+
 // indices for random points of interests on a gbk line.
 const subMetaIndex = 5
 const qualifierIndex = 21
@@ -839,19 +1095,28 @@ func getSourceOrganism(metadataData []string) (string, string, []string) {
 	return source, organism, taxonomy
 }
 
+// ParseLocation parses an INSDC feature table location string, like
+// "complement(join(1..10,20..30))". GenBank, EMBL, and DDBJ all share this
+// syntax, so other parsers can reuse it.
+func ParseLocation(locationString string) (Location, error) {
+	return parseLocation(locationString)
+}
+
 func parseLocation(locationString string) (Location, error) {
-	var location Location
-	location.GbkLocationString = locationString
-	if !strings.ContainsAny(locationString, "(") { // Case checks for simple expression of x..x
+	location := Location{GbkLocationString: locationString}
+	if !strings.ContainsAny(locationString, "()") { // Case checks for simple expression of x..x
 		if !strings.ContainsAny(locationString, ".") { //Case checks for simple expression x
 			position, err := strconv.Atoi(locationString)
 			if err != nil {
 				return Location{}, err
 			}
-			location = Location{Start: position, End: position}
+			location.Start, location.End = position-1, position
 		} else {
 			// to remove FivePrimePartial and ThreePrimePartial indicators from start and end before converting to int.
 			startEndSplit := strings.Split(locationString, "..")
+			if len(startEndSplit) != 2 {
+				return Location{}, fmt.Errorf("invalid location range %q", locationString)
+			}
 			start, err := strconv.Atoi(partialRegex.ReplaceAllString(startEndSplit[0], ""))
 			if err != nil {
 				return Location{}, err
@@ -860,65 +1125,46 @@ func parseLocation(locationString string) (Location, error) {
 			if err != nil {
 				return Location{}, err
 			}
-			location = Location{Start: start - 1, End: end}
+			location.Start, location.End = start-1, end
 		}
 	} else {
 		firstOuterParentheses := strings.Index(locationString, "(")
-		expression := locationString[firstOuterParentheses+1 : strings.LastIndex(locationString, ")")]
+		if firstOuterParentheses == -1 || !strings.HasSuffix(locationString, ")") {
+			return Location{}, fmt.Errorf("unbalanced parentheses in location %q", locationString)
+		}
+		expression := locationString[firstOuterParentheses+1 : len(locationString)-1]
+		subExpressions, err := splitLocationExpression(expression)
+		if err != nil {
+			return Location{}, fmt.Errorf("%w in location %q", err, locationString)
+		}
 		switch command := locationString[0:firstOuterParentheses]; command {
-		case "join":
-			location.Join = true
-			// This case checks for join(complement(x..x),complement(x..x)), or any more complicated derivatives
-			if strings.ContainsAny(expression, "(") {
-				firstInnerParentheses := strings.Index(expression, "(")
-				ParenthesesCount := 1
-				prevSubLocationStart := 0
-				for i := firstInnerParentheses + 1; i < len(expression); i++ { // "(" is at 0, so we start at 1
-					switch expression[i] {
-					case '(':
-						ParenthesesCount++
-					case ')':
-						ParenthesesCount--
-					case ',':
-						if ParenthesesCount == 0 {
-							parsedSubLocation, err := parseLocation(expression[prevSubLocationStart:i])
-							if err != nil {
-								return Location{}, err
-							}
-							parsedSubLocation.GbkLocationString = locationString
-							location.SubLocations = append(location.SubLocations, parsedSubLocation)
-							prevSubLocationStart = i + 1
-						}
-					}
-				}
-				if ParenthesesCount != 0 {
-					return Location{}, fmt.Errorf("Unbalanced parentheses")
-				}
-				parsedSubLocation, err := parseLocation(expression[prevSubLocationStart:])
+		case "join", "order":
+			location.Join = command == "join"
+			location.Order = command == "order"
+			for _, subExpression := range subExpressions {
+				subLocation, err := parseLocation(subExpression)
 				if err != nil {
 					return Location{}, err
 				}
-				parsedSubLocation.GbkLocationString = locationString
-				location.SubLocations = append(location.SubLocations, parsedSubLocation)
-			} else { // This is the default join(x..x,x..x)
-				for _, numberRange := range strings.Split(expression, ",") {
-					joinLocation, err := parseLocation(numberRange)
-					if err != nil {
-						return Location{}, err
-					}
-					location.SubLocations = append(location.SubLocations, joinLocation)
+				// This case checks for join(complement(x..x),complement(x..x)), or any more complicated derivatives
+				if strings.ContainsAny(expression, "(") {
+					subLocation.GbkLocationString = locationString
 				}
+				location.SubLocations = append(location.SubLocations, subLocation)
 			}
-
 		case "complement":
-			// location.Complement = true
+			if len(subExpressions) != 1 {
+				return Location{}, fmt.Errorf("complement takes one location, got %q", locationString)
+			}
 			subLocation, err := parseLocation(expression)
 			if err != nil {
 				return Location{}, err
 			}
 			subLocation.Complement = true
 			subLocation.GbkLocationString = locationString
-			location.SubLocations = append(location.SubLocations, subLocation)
+			return subLocation, nil
+		default:
+			return Location{}, fmt.Errorf("unknown location operator %q in %q", command, locationString)
 		}
 	}
 
@@ -930,14 +1176,37 @@ func parseLocation(locationString string) (Location, error) {
 		location.ThreePrimePartial = true
 	}
 
-	// if excess root node then trim node. Maybe should just be handled with second arg?
-	if location.Start == 0 && location.End == 0 && !location.Join && !location.Complement {
-		location = location.SubLocations[0]
-	}
-
 	return location, nil
 }
 
+// splitLocationExpression splits the comma separated locations inside an
+// operator like join, leaving the commas of nested operators alone. It
+// returns an error if the expression's parentheses aren't balanced.
+func splitLocationExpression(expression string) ([]string, error) {
+	var subExpressions []string
+	depth, subExpressionStart := 0, 0
+	for index := 0; index < len(expression); index++ {
+		switch expression[index] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, errors.New("unbalanced parentheses")
+			}
+		case ',':
+			if depth == 0 {
+				subExpressions = append(subExpressions, expression[subExpressionStart:index])
+				subExpressionStart = index + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, errors.New("unbalanced parentheses")
+	}
+	return append(subExpressions, expression[subExpressionStart:]), nil
+}
+
 // buildMetaString is a helper function to build the meta section of genbank files.
 func buildMetaString(name string, data string) string {
 	keyWhitespaceTrailLength := 12 - len(name) // I wish I was kidding.
@@ -946,20 +1215,36 @@ func buildMetaString(name string, data string) string {
 		keyWhitespaceTrail += " "
 	}
 	name += keyWhitespaceTrail
-	wrappedData := wordwrap.WrapString(data, 68)
-	splitData := strings.Split(wrappedData, "\n")
+	// lines of data, like those of a COMMENT, are kept, and wrapped if
+	// they're too long.
+	var splitData []string
+	for _, line := range strings.Split(data, "\n") {
+		if len(line) > 68 {
+			line = wordwrap.WrapString(line, 68)
+		}
+		splitData = append(splitData, strings.Split(line, "\n")...)
+	}
 	var returnData string
 	for index, datum := range splitData {
 		if index == 0 {
-			returnData = name + datum + "\n"
+			returnData = strings.TrimRight(name+datum, " ") + "\n"
 		} else {
-			returnData += generateWhiteSpace(12) + datum + "\n"
+			returnData += strings.TrimRight(generateWhiteSpace(12)+datum, " ") + "\n"
 		}
 	}
 
 	return returnData
 }
 
+// buildOptionalMetaString builds a meta field like buildMetaString, or
+// nothing if it's empty.
+func buildOptionalMetaString(name string, data string) string {
+	if data == "" {
+		return ""
+	}
+	return buildMetaString(name, data)
+}
+
 // BuildLocationString is a recursive function that takes a location object and creates a gbk location string for Build()
 func BuildLocationString(location Location) string {
 	var locationString string
@@ -967,28 +1252,58 @@ func BuildLocationString(location Location) string {
 	if location.Complement {
 		location.Complement = false
 		locationString = "complement(" + BuildLocationString(location) + ")"
-	} else if location.Join {
+	} else if location.Join || location.Order {
 		locationString = "join("
+		if location.Order {
+			locationString = "order("
+		}
 		for _, sublocation := range location.SubLocations {
 			locationString += BuildLocationString(sublocation) + ","
 		}
 		locationString = strings.TrimSuffix(locationString, ",") + ")"
 	} else {
-		locationString = strconv.Itoa(location.Start+1) + ".." + strconv.Itoa(location.End)
+		start := strconv.Itoa(location.Start + 1)
+		end := strconv.Itoa(location.End)
 		if location.FivePrimePartial {
-			locationString = "<" + locationString
+			start = "<" + start
 		}
-
 		if location.ThreePrimePartial {
-			locationString += ">"
+			end = ">" + end
+		}
+		locationString = start + ".." + end
+		// single bases are written without a range.
+		if location.End == location.Start+1 && !location.FivePrimePartial && !location.ThreePrimePartial {
+			locationString = end
 		}
 	}
 	return locationString
 }
 
+// unquotedQualifiers are the qualifiers whose values aren't written in
+// quotes, since they're numbers or other structured values.
+var unquotedQualifiers = map[string]bool{
+	"anticodon":        true,
+	"citation":         true,
+	"codon_start":      true,
+	"compare":          true,
+	"direction":        true,
+	"estimated_length": true,
+	"mod_base":         true,
+	"number":           true,
+	"rpt_type":         true,
+	"rpt_unit_range":   true,
+	"tag_peptide":      true,
+	"transl_except":    true,
+	"transl_table":     true,
+}
+
+// qualifierWidth is how many characters of a qualifier or location fit on a
+// line after the 21 it's indented by, in the 79 columns NCBI writes.
+const qualifierWidth = 79 - qualifierIndex
+
 // BuildFeatureString is a helper function to build gbk feature strings for Build()
 func BuildFeatureString(feature Feature) string {
-	whiteSpaceTrailLength := 16 - len(feature.Type) // I wish I was kidding.
+	whiteSpaceTrailLength := max(16-len(feature.Type), 1) // I wish I was kidding.
 	whiteSpaceTrail := generateWhiteSpace(whiteSpaceTrailLength)
 	var location string
 
@@ -997,18 +1312,105 @@ func BuildFeatureString(feature Feature) string {
 	} else {
 		location = BuildLocationString(feature.Location)
 	}
-	featureHeader := generateWhiteSpace(subMetaIndex) + feature.Type + whiteSpaceTrail + location + "\n"
+	locationLines := WrapLocation(location, qualifierWidth)
+	featureHeader := generateWhiteSpace(subMetaIndex) + feature.Type + whiteSpaceTrail + locationLines[0] + "\n"
 	returnString := featureHeader
+	for _, line := range locationLines[1:] {
+		returnString += generateWhiteSpace(qualifierIndex) + line + "\n"
+	}
 
-	qualifierKeys := make([]string, 0, len(feature.Attributes))
+	for _, qualifier := range feature.OrderedQualifiers() {
+		qualifierString := "/" + qualifier.Key
+		switch {
+		case qualifier.Value == "":
+			// qualifiers without values, like /pseudo, are written bare.
+		case unquotedQualifiers[qualifier.Key]:
+			qualifierString += "=" + qualifier.Value
+		default:
+			qualifierString += "=\"" + qualifier.Value + "\""
+		}
+		for _, line := range wrapQualifier(qualifierString) {
+			returnString += generateWhiteSpace(qualifierIndex) + line + "\n"
+		}
+	}
+	return returnString
+}
+
+// OrderedQualifiers returns the qualifiers of a feature as they're written:
+// those of Qualifiers that Attributes still has, every repeat included if
+// Attributes still has the last of them, and then the rest of Attributes in
+// alphabetical order.
+func (feature Feature) OrderedQualifiers() []Qualifier {
+	lastValues := make(map[string]string)
+	for _, qualifier := range feature.Qualifiers {
+		lastValues[qualifier.Key] = qualifier.Value
+	}
+	var qualifiers []Qualifier
+	written := make(map[string]bool)
+	for _, qualifier := range feature.Qualifiers {
+		value, ok := feature.Attributes[qualifier.Key]
+		if !ok {
+			continue
+		}
+		if value != lastValues[qualifier.Key] {
+			// the value was changed since it was parsed, so it's written once.
+			if written[qualifier.Key] {
+				continue
+			}
+			qualifier.Value = value
+		}
+		qualifiers = append(qualifiers, qualifier)
+		written[qualifier.Key] = true
+	}
+
+	keys := make([]string, 0, len(feature.Attributes))
 	for key := range feature.Attributes {
-		qualifierKeys = append(qualifierKeys, key)
+		if !written[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		qualifiers = append(qualifiers, Qualifier{Key: key, Value: feature.Attributes[key]})
+	}
+	return qualifiers
+}
+
+// contigWidth is how much of a CONTIG location fits on a line after the 12
+// columns it's indented by.
+const contigWidth = 79 - 12
+
+// WrapLocation splits a location string into lines of at most width,
+// breaking after commas where it can, for writers of flat files like GenBank
+// and EMBL whose readers join a location's lines back together.
+func WrapLocation(location string, width int) []string {
+	var lines []string
+	for len(location) > width {
+		split := strings.LastIndex(location[:width], ",")
+		if split < 0 {
+			split = width - 1
+		}
+		lines = append(lines, location[:split+1])
+		location = location[split+1:]
 	}
+	return append(lines, location)
+}
 
-	for _, qualifier := range qualifierKeys {
-		returnString += generateWhiteSpace(qualifierIndex) + "/" + qualifier + "=\"" + feature.Attributes[qualifier] + "\"\n"
+// wrapQualifier splits a qualifier into lines that fit after the qualifier
+// indent. Lines are joined back together as they are when they're parsed,
+// so they're never split next to a space, which would be trimmed off, or
+// before a /, which would start a new qualifier.
+func wrapQualifier(qualifier string) []string {
+	var lines []string
+	for len(qualifier) > qualifierWidth {
+		end := qualifierWidth
+		for end > 1 && (qualifier[end-1] == ' ' || qualifier[end] == ' ' || qualifier[end] == '/') {
+			end--
+		}
+		lines = append(lines, qualifier[:end])
+		qualifier = qualifier[end:]
 	}
-	return returnString
+	return append(lines, qualifier)
 }
 
 func generateWhiteSpace(length int) string {
@@ -1,6 +1,7 @@
 package genbank
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,6 +14,7 @@ import (
 	"reflect"
 
 	"github.com/bebop/poly/transform"
+	"github.com/bebop/poly/warning"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/stretchr/testify/assert"
@@ -49,7 +51,8 @@ func TestGbkIO(t *testing.T) {
 		_ = Write(gbk, tmpGbkFilePath)
 
 		writeTestGbk, _ := Read(tmpGbkFilePath)
-		if diff := cmp.Diff(gbk, writeTestGbk, []cmp.Option{cmpopts.IgnoreFields(Feature{}, "ParentSequence")}...); diff != "" {
+		// Build always writes a topology, so ambiguity warnings don't survive the round trip.
+		if diff := cmp.Diff(gbk, writeTestGbk, []cmp.Option{cmpopts.IgnoreFields(Feature{}, "ParentSequence"), cmpopts.IgnoreFields(Genbank{}, "Warnings")}...); diff != "" {
 			t.Errorf("Parsing the output of Build() does not produce the same output as parsing the original file, \"%s\", read with Read(). Got this diff:\n%s", filepath.Base(gbkPath), diff)
 		}
 	} // end test single gbk read, write, build, parse
@@ -110,7 +113,9 @@ func TestGbkLocationStringBuilder(t *testing.T) {
 	testInputGbk, _ := Read("../../data/sample.gbk")
 	testOutputGbk, _ := Read(tmpGbkFilePath)
 
-	if diff := cmp.Diff(testInputGbk, testOutputGbk, []cmp.Option{cmpopts.IgnoreFields(Feature{}, "ParentSequence")}...); diff != "" {
+	// the sample's nonstandard "687..3158>" is built as "687..>3158", which
+	// parses to the same location, so only the parsed locations are compared.
+	if diff := cmp.Diff(testInputGbk, testOutputGbk, []cmp.Option{cmpopts.IgnoreFields(Feature{}, "ParentSequence"), cmpopts.IgnoreFields(Genbank{}, "Warnings"), cmpopts.IgnoreFields(Location{}, "GbkLocationString")}...); diff != "" {
 		t.Errorf("Issue with partial location building. Parsing the output of Build() does not produce the same output as parsing the original file read with Read(). Got this diff:\n%s", diff)
 	}
 }
@@ -560,7 +565,11 @@ func Test_parseLocus(t *testing.T) {
 		args args
 		want Locus
 	}{
-		// TODO: Add test cases.
+		{
+			name: "strandedness and division",
+			args: args{locusString: "LOCUS       NC_000964            4215606 bp ds-DNA     circular CON 18-SEP-2018"},
+			want: Locus{Name: "NC_000964", SequenceLength: "4215606", SequenceCoding: "bp", MoleculeType: "DNA", Strandedness: "ds", Circular: true, GenbankDivision: "CON", ModificationDate: "18-SEP-2018"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -647,7 +656,15 @@ func Test_parseLocation(t *testing.T) {
 		want    Location
 		wantErr bool
 	}{
-		// TODO: Add test cases.
+		{"partial range", args{"<1..>500"}, Location{Start: 0, End: 500, FivePrimePartial: true, ThreePrimePartial: true, GbkLocationString: "<1..>500"}, false},
+		{"single base", args{"467"}, Location{Start: 466, End: 467, GbkLocationString: "467"}, false},
+		{"order", args{"order(1..5,7..9)"}, Location{Order: true, GbkLocationString: "order(1..5,7..9)", SubLocations: []Location{{Start: 0, End: 5, GbkLocationString: "1..5"}, {Start: 6, End: 9, GbkLocationString: "7..9"}}}, false},
+		{"truncated join", args{"join(1..5"}, Location{}, true},
+		{"extra parenthesis", args{"join(1..5))"}, Location{}, true},
+		{"unopened parenthesis", args{"1..5)"}, Location{}, true},
+		{"unknown operator", args{"bond(1,5)"}, Location{}, true},
+		{"complement of two locations", args{"complement(1..5,7..9)"}, Location{}, true},
+		{"too many dots", args{"1..5..9"}, Location{}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -659,6 +676,9 @@ func Test_parseLocation(t *testing.T) {
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("parseLocation() = %v, want %v", got, tt.want)
 			}
+			if built := BuildLocationString(got); !tt.wantErr && built != tt.args.locationString {
+				t.Errorf("BuildLocationString() = %q, want %q", built, tt.args.locationString)
+			}
 		})
 	}
 }
@@ -693,7 +713,21 @@ func TestBuildLocationString(t *testing.T) {
 		args args
 		want string
 	}{
-		// TODO: Add test cases.
+		{
+			name: "partial ends",
+			args: args{location: Location{Start: 5022, End: 6504, FivePrimePartial: true, ThreePrimePartial: true}},
+			want: "<5023..>6504",
+		},
+		{
+			name: "single base",
+			args: args{location: Location{Start: 9, End: 10}},
+			want: "10",
+		},
+		{
+			name: "complemented join",
+			args: args{location: Location{Complement: true, Join: true, SubLocations: []Location{{Start: 0, End: 10}, {Start: 19, End: 30}}}},
+			want: "complement(join(1..10,20..30))",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -802,3 +836,225 @@ func TestConsortiumRegression(t *testing.T) {
 		t.Errorf("Failed to read consrtm. Got err: %s", err)
 	}
 }
+
+func TestNoFeaturesRegression(t *testing.T) {
+	sequence, _ := Read("../../data/puc19.gbk")
+	sequence.Features = nil
+	built, err := Build(sequence)
+	if err != nil {
+		t.Fatalf("Failed to build record without features. Got err: %s", err)
+	}
+	parsed, err := Parse(bytes.NewReader(built))
+	if err != nil {
+		t.Fatalf("Failed to parse record without features. Got err: %s", err)
+	}
+	if len(parsed.Features) != 0 {
+		t.Errorf("Expected no features, got %d", len(parsed.Features))
+	}
+	if parsed.Sequence != sequence.Sequence {
+		t.Errorf("Sequence of record without features changed when it was parsed")
+	}
+}
+
+func TestFeatureIndex(t *testing.T) {
+	for _, gbkPath := range singleGbkPaths {
+		sequence, err := Read(gbkPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		index := sequence.Index()
+		for start := 0; start < len(sequence.Sequence); start += 97 {
+			end := start + 150
+			var expected []Feature
+			for _, feature := range sequence.Features {
+				for _, leaf := range leafLocations(feature.Location) {
+					leafEnd := leaf.End
+					if leafEnd <= leaf.Start {
+						leafEnd = leaf.Start + 1
+					}
+					if leaf.Start < end && leafEnd > start {
+						expected = append(expected, feature)
+						break
+					}
+				}
+			}
+			got := index.FeaturesOverlapping(start, end)
+			if diff := cmp.Diff(expected, got, cmpopts.IgnoreFields(Feature{}, "ParentSequence")); diff != "" {
+				t.Errorf("%s: FeaturesOverlapping(%d, %d) mismatch:\n%s", gbkPath, start, end, diff)
+			}
+		}
+	}
+}
+
+func TestFeatureIndex_joinedLocation(t *testing.T) {
+	var sequence Genbank
+	sequence.Sequence = strings.Repeat("A", 100)
+	location, err := parseLocation("join(1..10,91..100)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = sequence.AddFeature(&Feature{Type: "CDS", Location: location})
+	index := sequence.Index()
+
+	if got := index.FeaturesAt(50); len(got) != 0 {
+		t.Errorf("expected no features inside the joined gap, got %d", len(got))
+	}
+	if got := index.FeaturesAt(95); len(got) != 1 {
+		t.Errorf("expected 1 feature at position 95, got %d", len(got))
+	}
+	if got := index.FeaturesOverlapping(0, 100); len(got) != 1 {
+		t.Errorf("expected a joined feature to be reported once, got %d", len(got))
+	}
+}
+
+func TestParseWarnings(t *testing.T) {
+	sample, err := Read("../../data/sample.gbk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []warning.Warning{warning.AtLine(1, "LOCUS does not specify topology, assuming linear")}
+	if diff := cmp.Diff(expected, sample.Warnings); diff != "" {
+		t.Errorf("unexpected warnings for sample.gbk:\n%s", diff)
+	}
+	if err := warning.Strict(sample.Warnings); err == nil {
+		t.Errorf("expected Strict to promote sample.gbk warnings to an error")
+	}
+
+	puc19, err := Read("../../data/puc19.gbk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(puc19.Warnings) != 0 {
+		t.Errorf("expected no warnings for puc19.gbk, got %v", puc19.Warnings)
+	}
+
+	// the pichia test file is the head of a chromosome, so the sequence is shorter than LOCUS says.
+	pichia, err := Read("../../data/pichia_chr1_head.gb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pichia.Warnings) != 1 || !strings.Contains(pichia.Warnings[0].Message, "2891190 bp") {
+		t.Errorf("expected a sequence length warning for pichia_chr1_head.gb, got %v", pichia.Warnings)
+	}
+}
+
+func TestLocusWarnings(t *testing.T) {
+	locusString := "LOCUS       puc19.gbk               2686 bp    DNA     circular SYN 22-OCT-2019"
+	if warnings := locusWarnings(locusString, parseLocus(locusString), 1); len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+	locusString = "LOCUS       mystery"
+	if warnings := locusWarnings(locusString, parseLocus(locusString), 1); len(warnings) != 3 {
+		t.Errorf("expected topology, molecule type, and length warnings, got %v", warnings)
+	}
+}
+
+func TestToSequence(t *testing.T) {
+	puc19, err := Read("../../data/puc19.gbk")
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	sequence := puc19.ToSequence()
+	if sequence.Molecule != "DNA" || !sequence.Circular || !sequence.DoubleStranded || sequence.Len() != len(puc19.Sequence) {
+		t.Errorf("ToSequence() = %+v, want circular double stranded DNA", sequence)
+	}
+
+	roundTrip := FromSequence(sequence).ToSequence()
+	if roundTrip != sequence {
+		t.Errorf("FromSequence().ToSequence() = %+v, want %+v", roundTrip, sequence)
+	}
+	puc19.Meta.Locus.MoleculeType = "mRNA"
+	if sequence := puc19.ToSequence(); sequence.Molecule != "RNA" || sequence.DoubleStranded {
+		t.Errorf("ToSequence() of mRNA = %+v, want single stranded RNA", sequence)
+	}
+}
+
+// TestRoundTripCorpus parses every GenBank file in data, builds it, and
+// parses it again, which should give back the same records. Building those
+// should then give back the same file, with no line longer than NCBI writes.
+func TestRoundTripCorpus(t *testing.T) {
+	var paths []string
+	for _, pattern := range []string{"../../data/*.gb", "../../data/*.gbk", "../../data/*.seq"} {
+		matches, _ := filepath.Glob(pattern)
+		paths = append(paths, matches...)
+	}
+	for _, path := range paths {
+		if filepath.Base(path) == "malformed_read_test.gbk" {
+			continue
+		}
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			records, err := ReadMulti(path)
+			if err != nil {
+				t.Fatalf("ReadMulti() error = %v", err)
+			}
+			built, err := BuildMulti(records)
+			if err != nil {
+				t.Fatalf("BuildMulti() error = %v", err)
+			}
+			parsed, err := ParseMulti(bytes.NewReader(built))
+			if err != nil {
+				t.Fatalf("ParseMulti() of the built file error = %v", err)
+			}
+			if diff := cmp.Diff(records, parsed, cmpopts.IgnoreFields(Feature{}, "ParentSequence"), cmpopts.IgnoreFields(Genbank{}, "Warnings")); diff != "" {
+				t.Errorf("parsing the built file doesn't give back the records:\n%s", diff)
+			}
+			rebuilt, _ := BuildMulti(parsed)
+			if !bytes.Equal(built, rebuilt) {
+				t.Errorf("building the parsed records again gives a different file")
+			}
+			for lineNumber, line := range strings.Split(string(built), "\n") {
+				if len(line) > 80 && !strings.HasPrefix(line, "LOCUS") {
+					t.Errorf("line %d is %d characters long: %q", lineNumber+1, len(line), line)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestBuildFeatureStringQualifiers(t *testing.T) {
+	feature := Feature{
+		Type:     "CDS",
+		Location: Location{Start: 0, End: 9},
+		Attributes: map[string]string{
+			"db_xref":     "GeneID:2",
+			"codon_start": "1",
+			"pseudo":      "",
+			"gene":        "abcA",
+			"translation": strings.Repeat("M", 70),
+		},
+		Qualifiers: []Qualifier{
+			{Key: "gene", Value: "abcA"},
+			{Key: "db_xref", Value: "GeneID:1"},
+			{Key: "db_xref", Value: "GeneID:2"},
+			{Key: "codon_start", Value: "1"},
+			{Key: "pseudo", Value: ""},
+			{Key: "removed", Value: "x"},
+		},
+	}
+	want := `     CDS             1..9
+                     /gene="abcA"
+                     /db_xref="GeneID:1"
+                     /db_xref="GeneID:2"
+                     /codon_start=1
+                     /pseudo
+                     /translation="MMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMM
+                     MMMMMMMMMMMMMMMMMMMMMMMMMM"
+`
+	assert.Equal(t, want, BuildFeatureString(feature))
+
+	// changing a repeated qualifier replaces every repeat of it.
+	feature.Attributes["db_xref"] = "GeneID:3"
+	assert.Equal(t, []Qualifier{{"gene", "abcA"}, {"db_xref", "GeneID:3"}, {"codon_start", "1"}, {"pseudo", ""}, {"translation", strings.Repeat("M", 70)}}, feature.OrderedQualifiers())
+}
+
+func TestCommentLines(t *testing.T) {
+	gbk, err := Read("../../data/long_comment.seq")
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	dates := gbk.Meta.CommentDates()
+	if _, ok := dates["Annotation Date"]; !ok {
+		t.Errorf("CommentDates() = %v, want the structured comment's Annotation Date", dates)
+	}
+}
@@ -0,0 +1,97 @@
+package genbank
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bebop/poly/transform"
+)
+
+/******************************************************************************
+
+Location methods begin here.
+
+A Location is a tree. Its leaves carry coordinates, as zero-indexed half-open
+ranges, and the nodes above them join their children in order or complement
+them. "join(complement(123..456),789..1012)" is a join of a complemented
+leaf and a plain one, and fuzzy ends like "<1..>500" are leaves with
+FivePrimePartial or ThreePrimePartial set.
+
+GenBank, EMBL, and GFF records all use Location, so the methods here work on
+features from any of them.
+
+******************************************************************************/
+
+// Length returns the number of bases a location covers.
+func (location Location) Length() int {
+	return length(leafLocations(location))
+}
+
+// Extract returns the bases a location covers in a sequence, in the order
+// its joins list them, reverse complemented where it's complemented.
+func (location Location) Extract(sequence string) (string, error) {
+	if len(location.SubLocations) == 0 {
+		start, end := location.Start, leafEnd(location)
+		if start < 0 || end > len(sequence) {
+			return "", fmt.Errorf("location %d..%d is outside a %d base sequence", start+1, end, len(sequence))
+		}
+		if location.Complement {
+			return transform.ReverseComplement(sequence[start:end]), nil
+		}
+		return sequence[start:end], nil
+	}
+	var extracted strings.Builder
+	for _, subLocation := range location.SubLocations {
+		subSequence, err := subLocation.Extract(sequence)
+		if err != nil {
+			return "", err
+		}
+		extracted.WriteString(subSequence)
+	}
+	if location.Complement {
+		return transform.ReverseComplement(extracted.String()), nil
+	}
+	return extracted.String(), nil
+}
+
+// Overlaps returns whether two locations cover any of the same bases, on
+// either strand. The gaps between the parts of a join aren't covered.
+func (location Location) Overlaps(other Location) bool {
+	for _, leaf := range leafLocations(location) {
+		for _, otherLeaf := range leafLocations(other) {
+			if leaf.Start < leafEnd(otherLeaf) && otherLeaf.Start < leafEnd(leaf) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Shift returns a location moved by offset bases, like after bases are
+// inserted before it, or removed with a negative offset. Its
+// GbkLocationString is cleared, since it no longer describes it.
+func (location Location) Shift(offset int) Location {
+	location.GbkLocationString = ""
+	if len(location.SubLocations) == 0 {
+		location.Start += offset
+		location.End += offset
+	} else {
+		subLocations := make([]Location, len(location.SubLocations))
+		for index, subLocation := range location.SubLocations {
+			subLocations[index] = subLocation.Shift(offset)
+		}
+		location.SubLocations = subLocations
+	}
+	return location
+}
+
+// RangeLocation returns the location of the range [start, end) of a sequence
+// of length bases, complemented if complement is set. Ranges that run past
+// the end of a circular sequence are a join of the bases up to its origin and
+// the bases after it, like "join(2600..2686,1..20)".
+func RangeLocation(start, end, length int, complement bool) Location {
+	if end <= length {
+		return Location{Start: start, End: end, Complement: complement}
+	}
+	return Location{Join: true, Complement: complement, SubLocations: []Location{{Start: start, End: length}, {Start: 0, End: end - length}}}
+}
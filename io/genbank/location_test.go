@@ -0,0 +1,110 @@
+package genbank
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLocationMethods(t *testing.T) {
+	sequence := "AAAACCCCGGGGTTTT"
+	tests := []struct {
+		location string
+		length   int
+		want     string
+	}{
+		{"1..4", 4, "AAAA"},
+		{"5", 1, "C"},
+		{"<1..>4", 4, "AAAA"},
+		{"complement(5..8)", 4, "GGGG"},
+		{"join(1..2,13..14)", 4, "AATT"},
+		{"join(complement(5..6),9..10)", 4, "GGGG"},
+		{"complement(join(1..2,15..16))", 4, "AATT"},
+	}
+	for _, test := range tests {
+		location, err := ParseLocation(test.location)
+		if err != nil {
+			t.Fatalf("ParseLocation(%q) error = %v", test.location, err)
+		}
+		if got := location.Length(); got != test.length {
+			t.Errorf("Length() of %q = %d, want %d", test.location, got, test.length)
+		}
+		got, err := location.Extract(sequence)
+		if err != nil || got != test.want {
+			t.Errorf("Extract() of %q = %q, %v, want %q", test.location, got, err, test.want)
+		}
+	}
+
+	outside, _ := ParseLocation("join(1..4,15..20)")
+	if _, err := outside.Extract(sequence); err == nil {
+		t.Errorf("Extract() of a location past the end should fail")
+	}
+}
+
+func TestLocationOverlaps(t *testing.T) {
+	spliced, _ := ParseLocation("join(1..10,21..30)")
+	tests := []struct {
+		other string
+		want  bool
+	}{
+		{"complement(5..6)", true},
+		{"11..20", false},
+		{"20..21", true},
+		{"31", false},
+		{"30", true},
+	}
+	for _, test := range tests {
+		other, _ := ParseLocation(test.other)
+		if got := spliced.Overlaps(other); got != test.want {
+			t.Errorf("Overlaps(%q) = %v, want %v", test.other, got, test.want)
+		}
+		if got := other.Overlaps(spliced); got != test.want {
+			t.Errorf("Overlaps() of %q = %v, want %v", test.other, got, test.want)
+		}
+	}
+}
+
+func TestLocationShift(t *testing.T) {
+	location, _ := ParseLocation("join(complement(<1..10),21..30)")
+	shifted := location.Shift(5)
+	if got := BuildLocationString(shifted); got != "join(complement(<6..15),26..35)" {
+		t.Errorf("Shift() = %q", got)
+	}
+	if shifted.GbkLocationString != "" {
+		t.Errorf("Shift() should clear GbkLocationString")
+	}
+	if BuildLocationString(location) != "join(complement(<1..10),21..30)" {
+		t.Errorf("Shift() changed the original location")
+	}
+}
+
+func TestRangeLocation(t *testing.T) {
+	for _, test := range []struct {
+		start, end int
+		complement bool
+		want       string
+	}{
+		{9, 20, false, "10..20"},
+		{9, 20, true, "complement(10..20)"},
+		{95, 105, false, "join(96..100,1..5)"},
+		{95, 105, true, "complement(join(96..100,1..5))"},
+	} {
+		if got := BuildLocationString(RangeLocation(test.start, test.end, 100, test.complement)); got != test.want {
+			t.Errorf("RangeLocation(%d, %d, 100, %v) = %q, want %q", test.start, test.end, test.complement, got, test.want)
+		}
+	}
+}
+
+func TestWrapLocation(t *testing.T) {
+	for _, test := range []struct {
+		location string
+		want     string
+	}{
+		{"1..10", "1..10"},
+		{"join(1..10,20..30,40..50)", "join(1..10,|20..30,40..50)"},
+		{"join(100000..200000)", "join(100000..20|0000)"},
+	} {
+		if got := strings.Join(WrapLocation(test.location, 15), "|"); got != test.want {
+			t.Errorf("WrapLocation(%q, 15) = %q, want %q", test.location, got, test.want)
+		}
+	}
+}
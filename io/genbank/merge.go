@@ -0,0 +1,241 @@
+package genbank
+
+import (
+	"sort"
+	"strings"
+)
+
+/******************************************************************************
+
+Feature merging begins here.
+
+Running a few annotators over the same record, like an ORF finder, a part
+matcher, and a motif scanner, usually has them find some of the same things.
+Each of them adds its own feature for the same promoter or CDS, maybe under a
+slightly different name or a few bases off, and the record ends up with two
+or three copies of it stacked on top of each other.
+
+MergeFeatures reconciles them. Features are equivalent if they're the same
+type, on the same strand, and overlap each other by most of their length.
+Every set of equivalent features becomes a single feature: the one from the
+most trusted annotator, with any qualifiers only the others had added to it.
+Names the others gave it that differ from its own are kept in its note, so
+nothing an annotator found is lost.
+
+******************************************************************************/
+
+// MergeOptions changes how features are merged. Zero values are replaced
+// with the defaults noted on each field.
+type MergeOptions struct {
+	// MinOverlap is the fraction of each of two features' lengths that has
+	// to overlap the other for them to be equivalent. Defaults to 0.9.
+	MinOverlap float64
+	// SourceQualifier is the qualifier that names the annotator that made a
+	// feature. Defaults to "inference".
+	SourceQualifier string
+	// Priority lists annotators, by their SourceQualifier, from most to
+	// least trusted. Features from annotators that aren't listed come last,
+	// and ties go to the feature with the most qualifiers, then to the
+	// first in the record.
+	Priority []string
+}
+
+// namingQualifiers are the qualifiers that name a feature. If equivalent
+// features disagree on them, the names that lose are kept in the note.
+var namingQualifiers = []string{"label", "gene", "product"}
+
+// MergeFeatures merges equivalent features of a record, keeping the first of
+// every set in record order, and returns how many features were merged away.
+func (sequence *Genbank) MergeFeatures(options MergeOptions) int {
+	if options.MinOverlap == 0 {
+		options.MinOverlap = 0.9
+	}
+	if options.SourceQualifier == "" {
+		options.SourceQualifier = "inference"
+	}
+	rank := make(map[string]int)
+	for index, source := range options.Priority {
+		rank[source] = index + 1
+	}
+	rankOf := func(feature Feature) int {
+		if value, ok := rank[feature.Attributes[options.SourceQualifier]]; ok {
+			return value
+		}
+		return len(options.Priority) + 1
+	}
+
+	// equivalent features are joined into groups named by their first
+	// feature, sweeping the features by where they start so only features
+	// that could overlap are compared.
+	spans := make([][2]int, len(sequence.Features))
+	order := make([]int, len(sequence.Features))
+	for index, feature := range sequence.Features {
+		spans[index] = span(feature.Location)
+		order[index] = index
+	}
+	sort.SliceStable(order, func(i, j int) bool { return spans[order[i]][0] < spans[order[j]][0] })
+	groups := make([]int, len(sequence.Features))
+	for index := range groups {
+		groups[index] = index
+	}
+	find := func(index int) int {
+		for groups[index] != index {
+			groups[index] = groups[groups[index]]
+			index = groups[index]
+		}
+		return index
+	}
+	var active []int
+	for _, index := range order {
+		// features ending before this one starts can't overlap it or anything after it.
+		kept := active[:0]
+		for _, other := range active {
+			if spans[other][1] > spans[index][0] {
+				kept = append(kept, other)
+			}
+		}
+		active = kept
+		for _, other := range active {
+			if equivalent(sequence.Features[index], sequence.Features[other], options.MinOverlap) {
+				first, second := find(index), find(other)
+				groups[max(first, second)] = min(first, second)
+			}
+		}
+		active = append(active, index)
+	}
+	for index := range groups {
+		groups[index] = find(index)
+	}
+
+	members := make(map[int][]Feature)
+	for index, feature := range sequence.Features {
+		members[groups[index]] = append(members[groups[index]], feature)
+	}
+	var merged []Feature
+	for index := range sequence.Features {
+		if groups[index] != index {
+			continue
+		}
+		features := members[index]
+		sort.SliceStable(features, func(i, j int) bool {
+			if rankOf(features[i]) != rankOf(features[j]) {
+				return rankOf(features[i]) < rankOf(features[j])
+			}
+			return len(features[i].Attributes) > len(features[j].Attributes)
+		})
+		merged = append(merged, mergeFeatures(features, options.SourceQualifier))
+	}
+	removed := len(sequence.Features) - len(merged)
+	sequence.Features = merged
+	return removed
+}
+
+// mergeFeatures merges equivalent features into the first of them.
+func mergeFeatures(features []Feature, sourceQualifier string) Feature {
+	merged := features[0]
+	attributes := make(map[string]string, len(merged.Attributes))
+	for qualifier, value := range merged.Attributes {
+		attributes[qualifier] = value
+	}
+	isNaming := make(map[string]bool)
+	for _, qualifier := range namingQualifiers {
+		isNaming[qualifier] = true
+	}
+	var notes, sources, aliases []string
+	notes = appendDistinct(notes, attributes["note"])
+	sources = appendDistinct(sources, attributes[sourceQualifier])
+	names := make(map[string]bool)
+	for _, qualifier := range namingQualifiers {
+		names[attributes[qualifier]] = true
+	}
+	for _, feature := range features[1:] {
+		// qualifiers are merged in order so the result doesn't depend on map
+		// iteration order.
+		qualifiers := make([]string, 0, len(feature.Attributes))
+		for qualifier := range feature.Attributes {
+			qualifiers = append(qualifiers, qualifier)
+		}
+		sort.Strings(qualifiers)
+		for _, qualifier := range qualifiers {
+			value := feature.Attributes[qualifier]
+			switch {
+			case qualifier == "note":
+				notes = appendDistinct(notes, value)
+			case qualifier == sourceQualifier:
+				sources = appendDistinct(sources, value)
+			case attributes[qualifier] == "" && !(isNaming[qualifier] && names[value]):
+				attributes[qualifier] = value
+				if isNaming[qualifier] {
+					names[value] = true
+				}
+			case isNaming[qualifier] && !names[value]:
+				aliases = appendDistinct(aliases, value)
+			}
+		}
+	}
+	if len(aliases) > 0 {
+		notes = append(notes, "also annotated as "+strings.Join(aliases, ", "))
+	}
+	if len(notes) > 0 {
+		attributes["note"] = strings.Join(notes, "; ")
+	}
+	if len(sources) > 0 {
+		attributes[sourceQualifier] = strings.Join(sources, "; ")
+	}
+	merged.Attributes = attributes
+	return merged
+}
+
+// equivalent is whether two features are the same type, on the same strand,
+// and each overlap the other by at least minOverlap of their lengths.
+func equivalent(first, second Feature, minOverlap float64) bool {
+	if first.Type != second.Type || first.Location.Complement != second.Location.Complement {
+		return false
+	}
+	firstLeaves, secondLeaves := leafLocations(first.Location), leafLocations(second.Location)
+	overlap := 0
+	for _, firstLeaf := range firstLeaves {
+		for _, secondLeaf := range secondLeaves {
+			overlap += max(0, min(leafEnd(firstLeaf), leafEnd(secondLeaf))-max(firstLeaf.Start, secondLeaf.Start))
+		}
+	}
+	return float64(overlap) >= minOverlap*float64(length(firstLeaves)) && float64(overlap) >= minOverlap*float64(length(secondLeaves))
+}
+
+// span is the first and last base a location covers, as a half-open range.
+func span(location Location) [2]int {
+	leaves := leafLocations(location)
+	start, end := leaves[0].Start, leafEnd(leaves[0])
+	for _, leaf := range leaves[1:] {
+		start, end = min(start, leaf.Start), max(end, leafEnd(leaf))
+	}
+	return [2]int{start, end}
+}
+
+// length is the number of bases leaves cover.
+func length(leaves []Location) int {
+	total := 0
+	for _, leaf := range leaves {
+		total += leafEnd(leaf) - leaf.Start
+	}
+	return total
+}
+
+// leafEnd is the end of a leaf location, where single base locations like
+// "467" cover one base.
+func leafEnd(leaf Location) int {
+	return max(leaf.End, leaf.Start+1)
+}
+
+// appendDistinct appends a non-empty value that isn't in values already.
+func appendDistinct(values []string, value string) []string {
+	if value == "" {
+		return values
+	}
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,96 @@
+package genbank
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func mergeTestFeature(t *testing.T, featureType, location string, attributes map[string]string) Feature {
+	t.Helper()
+	parsed, err := parseLocation(location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Feature{Type: featureType, Location: parsed, Attributes: attributes}
+}
+
+func TestMergeFeatures(t *testing.T) {
+	var sequence Genbank
+	for _, feature := range []Feature{
+		mergeTestFeature(t, "CDS", "1284..2144", map[string]string{"label": "ORF1", "inference": "orf finder", "translation": "MSIQ"}),
+		mergeTestFeature(t, "promoter", "1180..1284", map[string]string{"label": "AmpR promoter", "inference": "part matcher"}),
+		// the part matcher found the same CDS a few bases shorter, and named it.
+		mergeTestFeature(t, "CDS", "1290..2144", map[string]string{"label": "AmpR", "gene": "bla", "note": "confers resistance to ampicillin", "inference": "part matcher"}),
+		// same place but the other strand, or a different type, isn't the same feature.
+		mergeTestFeature(t, "CDS", "complement(1284..2144)", map[string]string{"label": "ORF2", "inference": "orf finder"}),
+		mergeTestFeature(t, "misc_feature", "1284..2144", map[string]string{"label": "bla region"}),
+		// a third annotator agrees on the name.
+		mergeTestFeature(t, "CDS", "1284..2144", map[string]string{"gene": "bla", "inference": "motif scanner"}),
+		// a motif inside the CDS doesn't overlap enough of it.
+		mergeTestFeature(t, "CDS", "1284..1400", map[string]string{"label": "fragment"}),
+	} {
+		feature := feature
+		_ = sequence.AddFeature(&feature)
+	}
+
+	removed := sequence.MergeFeatures(MergeOptions{Priority: []string{"part matcher", "orf finder"}})
+	if removed != 2 {
+		t.Errorf("expected 2 features merged away, got %d", removed)
+	}
+	var labels []string
+	for _, feature := range sequence.Features {
+		labels = append(labels, feature.Attributes["label"])
+	}
+	if diff := cmp.Diff([]string{"AmpR", "AmpR promoter", "ORF2", "bla region", "fragment"}, labels); diff != "" {
+		t.Errorf("unexpected merged features:\n%s", diff)
+	}
+
+	merged := sequence.Features[0]
+	expected := map[string]string{
+		"label":       "AmpR",
+		"gene":        "bla",
+		"translation": "MSIQ",
+		"note":        "confers resistance to ampicillin; also annotated as ORF1",
+		"inference":   "part matcher; orf finder; motif scanner",
+	}
+	if diff := cmp.Diff(expected, merged.Attributes); diff != "" {
+		t.Errorf("unexpected merged attributes:\n%s", diff)
+	}
+	if merged.Location.Start != 1289 || merged.ParentSequence != &sequence {
+		t.Errorf("expected the merged CDS to keep the part matcher's location and its parent, got start %d", merged.Location.Start)
+	}
+
+	// merging again changes nothing.
+	before := append([]Feature{}, sequence.Features...)
+	if removed := sequence.MergeFeatures(MergeOptions{}); removed != 0 {
+		t.Errorf("expected merged features to stay merged, got %d more merged away", removed)
+	}
+	if diff := cmp.Diff(before, sequence.Features, cmpopts.IgnoreFields(Feature{}, "ParentSequence")); diff != "" {
+		t.Errorf("unexpected changes merging again:\n%s", diff)
+	}
+}
+
+func TestMergeFeatures_chained(t *testing.T) {
+	// without priorities the feature with the most qualifiers is kept, and
+	// features equivalent through another feature end up together.
+	var sequence Genbank
+	for _, feature := range []Feature{
+		mergeTestFeature(t, "rep_origin", "1..100", map[string]string{"label": "ori"}),
+		mergeTestFeature(t, "rep_origin", "5..104", map[string]string{"label": "ori", "direction": "RIGHT"}),
+		mergeTestFeature(t, "rep_origin", "9..108", nil),
+		mergeTestFeature(t, "rep_origin", "join(1..50,61..110)", map[string]string{"label": "split ori"}),
+		mergeTestFeature(t, "misc_feature", "500", map[string]string{"label": "snp"}),
+		mergeTestFeature(t, "misc_feature", "500", map[string]string{"label": "snp"}),
+	} {
+		feature := feature
+		_ = sequence.AddFeature(&feature)
+	}
+	if removed := sequence.MergeFeatures(MergeOptions{MinOverlap: 0.95}); removed != 3 {
+		t.Errorf("expected 3 features merged away, got %d", removed)
+	}
+	if len(sequence.Features) != 3 || sequence.Features[0].Attributes["direction"] != "RIGHT" || sequence.Features[0].Location.Start != 4 {
+		t.Errorf("expected the origins to merge into the one with a direction, got %+v", sequence.Features)
+	}
+}
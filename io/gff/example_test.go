@@ -93,6 +93,27 @@ func ExampleGff_AddFeature() {
 	// Output: true
 }
 
+func ExampleGff_AddChild() {
+	sequence := gff.Gff{Meta: gff.Meta{Name: "chr1"}}
+	_ = sequence.AddFeature(&gff.Feature{Type: "gene", Location: gff.Location{Start: 0, End: 30}, Attributes: map[string]string{"ID": "gene1"}})
+
+	// children name their parent's ID in their Parent attribute.
+	_ = sequence.AddChild(0, &gff.Feature{Type: "mRNA", Location: gff.Location{Start: 0, End: 30}, Attributes: map[string]string{"ID": "mRNA1"}, AttributeOrder: []string{"ID"}})
+	_ = sequence.AddChild(1, &gff.Feature{Type: "exon", Location: gff.Location{Start: 3, End: 27}})
+
+	output, _ := gff.Build(sequence)
+	fmt.Print(string(output))
+	fmt.Println(sequence.Features[0].Children, sequence.Features[2].Parents)
+	// Output:
+	// ##gff-version 3
+	// ##sequence-region chr1 1 30
+	// chr1	feature	gene	1	30	.	+	.	ID=gene1
+	// chr1	feature	mRNA	1	30	.	+	.	ID=mRNA1;Parent=gene1
+	// chr1	feature	exon	4	27	.	+	.	Parent=mRNA1
+	// ###
+	// [1] [1]
+}
+
 func ExampleFeature_GetSequence() {
 	// Sequence for greenflourescent protein (GFP) that we're using as test data for this example.
 	gfpSequence := "ATGGCTAGCAAAGGAGAAGAACTTTTCACTGGAGTTGTCCCAATTCTTGTTGAATTAGATGGTGATGTTAATGGGCACAAATTTTCTGTCAGTGGAGAGGGTGAAGGTGATGCTACATACGGAAAGCTTACCCTTAAATTTATTTGCACTACTGGAAAACTACCTGTTCCATGGCCAACACTTGTCACTACTTTCTCTTATGGTGTTCAATGCTTTTCCCGTTATCCGGATCATATGAAACGGCATGACTTTTTCAAGAGTGCCATGCCCGAAGGTTATGTACAGGAACGCACTATATCTTTCAAAGATGACGGGAACTACAAGACGCGTGCTGAAGTCAAGTTTGAAGGTGATACCCTTGTTAATCGTATCGAGTTAAAAGGTATTGATTTTAAAGAAGATGGAAACATTCTCGGACACAAACTCGAGTACAACTATAACTCACACAATGTATACATCACGGCAGACAAACAAAAGAATGGAATCAAAGCTAACTTCAAAATTCGCCACAACATTGAAGATGGATCCGTTCAACTAGCAGACCATTATCAACAAAATACTCCAATTGGCGATGGCCCTGTCCTTTTACCAGACAACCATTACCTGTCGACACAATCTGCCCTTTCGAAAGATCCCAACGAAAAGCGTGACCACATGGTCCTTCTTGAGTTTGTAACTGCTGCTGGGATTACACATGGCATGGATGAGCTCTACAAATAA"
@@ -8,13 +8,23 @@ genomic sequences.
 
 This package provides a parser and writer to convert between the gff file
 format and the more general poly.Sequence struct.
+
+GFF3 features are arranged in a hierarchy: a gene's ID attribute is named by
+the Parent attributes of its mRNAs, and theirs by their exons and CDSs. Parse
+links every feature to its parents and children, by their indexes in
+Features, and Build checks that every Parent it writes names a feature that's
+written too. Attributes are written in the order they were read in, and the
+sequence is written in a ##FASTA section after the features, so a parsed file
+builds back into the same file.
 */
 package gff
 
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"sort"
 	"strconv"
@@ -22,7 +32,9 @@ import (
 
 	"lukechampine.com/blake3"
 
-	"github.com/bebop/poly/transform"
+	polyio "github.com/bebop/poly/io"
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/sequence"
 )
 
 var (
@@ -53,69 +65,146 @@ type Meta struct {
 
 // Feature is a struct that represents a feature in a gff file.
 type Feature struct {
-	Name           string            `json:"name"`
-	Source         string            `json:"source"`
-	Type           string            `json:"type"`
-	Score          string            `json:"score"`
-	Strand         string            `json:"strand"`
-	Phase          string            `json:"phase"`
-	Attributes     map[string]string `json:"attributes"`
-	Location       Location          `json:"location"`
-	ParentSequence *Gff              `json:"-"`
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Type   string `json:"type"`
+	Score  string `json:"score"`
+	Strand string `json:"strand"`
+	Phase  string `json:"phase"`
+	// Attributes are the feature's attributes, with their values as they're
+	// written in the file, percent-encoding and all. AttributeValues decodes
+	// them.
+	Attributes map[string]string `json:"attributes"`
+	// AttributeOrder is the order attributes are written in. Attributes that
+	// aren't in it are written after, in alphabetical order.
+	AttributeOrder []string `json:"attribute_order"`
+	Location       Location `json:"location"`
+	// Parents and Children are the indexes in Features of the features this
+	// feature is part of, by its Parent attribute, and the features that are
+	// part of it. Features with the same ID are the parts of one
+	// discontinuous feature, like the exons of a CDS, and are all parents of
+	// their children.
+	Parents        []int `json:"parents"`
+	Children       []int `json:"children"`
+	ParentSequence *Gff  `json:"-"`
 }
 
-// Location is a struct that represents a location in a gff file.
-type Location struct {
-	Start             int        `json:"start"`
-	End               int        `json:"end"`
-	Complement        bool       `json:"complement"`
-	Join              bool       `json:"join"`
-	FivePrimePartial  bool       `json:"five_prime_partial"`
-	ThreePrimePartial bool       `json:"three_prime_partial"`
-	SubLocations      []Location `json:"sub_locations"`
-}
+// Location is where a feature is, shared with genbank so features from
+// either format can be extracted and compared the same way.
+type Location = genbank.Location
 
-// AddFeature takes a feature and adds it to the Gff struct.
+// AddFeature takes a feature and adds it to the Gff struct, linking it to
+// the features named by its Parent attribute and to the features that name
+// it.
 func (sequence *Gff) AddFeature(feature *Feature) error {
 	feature.ParentSequence = sequence
 	featureCopy := *feature
 	sequence.Features = append(sequence.Features, featureCopy)
+	added := len(sequence.Features) - 1
+	for index := range sequence.Features[:added] {
+		if isParent(sequence.Features[index], sequence.Features[added]) {
+			link(sequence.Features, index, added)
+		}
+		if isParent(sequence.Features[added], sequence.Features[index]) {
+			link(sequence.Features, added, index)
+		}
+	}
 	return nil
 }
 
-// GetSequence takes a feature and returns a sequence string for that feature.
-func (feature Feature) GetSequence() (string, error) {
-	return getFeatureSequence(feature, feature.Location)
+// AddChild adds a feature as a part of the feature at index parent, setting
+// its Parent attribute to the parent's ID.
+func (sequence *Gff) AddChild(parent int, child *Feature) error {
+	if parent < 0 || parent >= len(sequence.Features) {
+		return fmt.Errorf("there's no feature %d", parent)
+	}
+	id := sequence.Features[parent].Attributes["ID"]
+	if id == "" {
+		return fmt.Errorf("feature %d has no ID for children to name", parent)
+	}
+	if child.Attributes == nil {
+		child.Attributes = make(map[string]string)
+	}
+	if parents := child.Attributes["Parent"]; parents != "" {
+		child.Attributes["Parent"] = parents + "," + id
+	} else {
+		child.Attributes["Parent"] = id
+		child.AttributeOrder = append(child.AttributeOrder, "Parent")
+	}
+	return sequence.AddFeature(child)
 }
 
-// getFeatureSequence takes a feature and location object and returns a sequence string.
-func getFeatureSequence(feature Feature, location Location) (string, error) {
-	var sequenceBuffer bytes.Buffer
-	var sequenceString string
-	parentSequence := feature.ParentSequence.Sequence
+// LinkFeatures recomputes the Parents and Children of every feature from
+// their ID and Parent attributes, like after features are reordered or
+// removed. Parent attributes that don't name any feature are ignored.
+func (sequence *Gff) LinkFeatures() {
+	idIndexes := make(map[string][]int)
+	for index := range sequence.Features {
+		sequence.Features[index].Parents, sequence.Features[index].Children = nil, nil
+		if id := sequence.Features[index].Attributes["ID"]; id != "" {
+			idIndexes[id] = append(idIndexes[id], index)
+		}
+	}
+	for index, feature := range sequence.Features {
+		for _, parentID := range feature.AttributeValues("Parent") {
+			for _, parent := range idIndexes[parentID] {
+				link(sequence.Features, parent, index)
+			}
+		}
+	}
+}
 
-	if len(location.SubLocations) == 0 {
-		sequenceBuffer.WriteString(parentSequence[location.Start:location.End])
-	} else {
-		for _, subLocation := range location.SubLocations {
-			sequence, _ := getFeatureSequence(feature, subLocation)
-			sequenceBuffer.WriteString(sequence)
+// isParent checks whether child's Parent attribute names parent's ID.
+func isParent(parent, child Feature) bool {
+	id := parent.Attributes["ID"]
+	if id == "" {
+		return false
+	}
+	for _, parentID := range child.AttributeValues("Parent") {
+		if parentID == id {
+			return true
 		}
 	}
+	return false
+}
 
-	// reverse complements resulting string if needed.
-	if location.Complement {
-		sequenceString = transform.ReverseComplement(sequenceBuffer.String())
-	} else {
-		sequenceString = sequenceBuffer.String()
+// link makes the feature at child a child of the one at parent.
+func link(features []Feature, parent, child int) {
+	features[parent].Children = append(features[parent].Children, child)
+	features[child].Parents = append(features[child].Parents, parent)
+}
+
+// AttributeValues returns the values of an attribute, split at commas and
+// decoded, or nil if the feature doesn't have it.
+func (feature Feature) AttributeValues(key string) []string {
+	value, ok := feature.Attributes[key]
+	if !ok || value == "" {
+		return nil
+	}
+	values := strings.Split(value, ",")
+	for index, encoded := range values {
+		if decoded, err := url.PathUnescape(encoded); err == nil {
+			values[index] = decoded
+		}
 	}
+	return values
+}
 
-	return sequenceString, nil
+// ToSequence returns a Gff's sequence. GFF doesn't say what molecule a
+// sequence is, so it's guessed with sequence.New, and assumed linear.
+func (gff Gff) ToSequence() sequence.Sequence {
+	return sequence.New(gff.Meta.Name, gff.Sequence)
+}
+
+// GetSequence takes a feature and returns a sequence string for that feature.
+func (feature Feature) GetSequence() (string, error) {
+	return feature.Location.Extract(feature.ParentSequence.Sequence)
 }
 
 // Parse Takes in a string representing a gffv3 file and parses it into an Sequence object.
+// Gzipped files are decompressed.
 func Parse(file io.Reader) (Gff, error) {
-	fileBytes, err := readAllFn(file)
+	fileBytes, err := readAllFn(polyio.Decompress(file))
 	if err != nil {
 		return Gff{}, err
 	}
@@ -128,11 +217,11 @@ func Parse(file io.Reader) (Gff, error) {
 
 	lines := strings.Split(gffString, "\n")
 	regionStringArray, endOfMetaInfo, err := extractInfoFromField(lines, "##sequence-region")
-	metaString := lines[0:endOfMetaInfo]
-	versionString := metaString[0]
 	if err != nil {
 		return Gff{}, err
 	}
+	metaString := lines[0:endOfMetaInfo]
+	versionString := metaString[0]
 	// get name for general meta
 	meta := Meta{}
 	meta.Name = regionStringArray[1] // Formally region name, but changed to name here for generality/interoperability.
@@ -149,63 +238,96 @@ func Parse(file io.Reader) (Gff, error) {
 	}
 	meta.Size = meta.RegionEnd - meta.RegionStart
 
-	var sequenceBuffer bytes.Buffer
+	// the ##FASTA section can have more than one sequence. The one named
+	// after the sequence region is kept, or else the first.
+	var sequenceNames []string
+	sequences := make(map[string]*strings.Builder)
+	descriptions := make(map[string]string)
+	currentSequence := ""
 	fastaFlag := false
-	for _, line := range lines {
+	for lineIndex, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		lineNumber := lineIndex + 1
+		if fastaFlag {
+			if strings.HasPrefix(line, ">") {
+				name, description, _ := strings.Cut(strings.TrimSpace(line[1:]), " ")
+				currentSequence = name
+				if _, ok := sequences[name]; !ok {
+					sequenceNames = append(sequenceNames, name)
+					sequences[name] = &strings.Builder{}
+				}
+				descriptions[name] = strings.TrimSpace(description)
+			} else if builder, ok := sequences[currentSequence]; ok {
+				builder.WriteString(strings.TrimSpace(line))
+			}
+			continue
+		}
 		if line == "##FASTA" {
 			fastaFlag = true
-		} else if len(line) == 0 {
 			continue
-		} else if line[0:2] == "##" || line[0:2] == "#!" {
+		}
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
 			continue
-		} else if fastaFlag && line[0:1] != ">" {
-			// sequence.Sequence = sequence.Sequence + line
-			sequenceBuffer.WriteString(line)
-		} else if fastaFlag && line[0:1] == ">" {
-			gff.Meta.Description = line
-		} else {
-			record := Feature{}
-			fields := strings.Split(line, "\t")
-			record.Name = fields[0]
-			record.Source = fields[1]
-			record.Type = fields[2]
-
-			// Indexing starts at 1 for gff so we need to shift down for Sequence 0 index.
-			record.Location.Start, err = atoiFn(fields[3])
-			if err != nil {
-				return Gff{}, err
-			}
+		}
 
-			record.Location.Start--
-			record.Location.End, err = atoiFn(fields[4])
-			if err != nil {
-				return Gff{}, err
-			}
+		record := Feature{}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 9 {
+			return Gff{}, fmt.Errorf("line %d: expected 9 tab separated columns, got %d", lineNumber, len(fields))
+		}
+		record.Name = fields[0]
+		record.Source = fields[1]
+		record.Type = fields[2]
+
+		// Indexing starts at 1 for gff so we need to shift down for Sequence 0 index.
+		record.Location.Start, err = atoiFn(fields[3])
+		if err != nil {
+			return Gff{}, err
+		}
 
-			record.Score = fields[5]
-			record.Strand = fields[6]
-			record.Phase = fields[7]
-			record.Attributes = make(map[string]string)
-			attributes := fields[8]
-			// var eqIndex int
-			attributeSlice := strings.Split(attributes, ";")
-
-			for _, attribute := range attributeSlice {
-				attributeSplit := strings.Split(attribute, "=")
-				key := attributeSplit[0]
-				value := attributeSplit[1]
+		record.Location.Start--
+		record.Location.End, err = atoiFn(fields[4])
+		if err != nil {
+			return Gff{}, err
+		}
+
+		record.Score = fields[5]
+		record.Strand = fields[6]
+		record.Location.Complement = record.Strand == "-"
+		record.Phase = fields[7]
+		record.Attributes = make(map[string]string)
+		if attributes := fields[8]; attributes != "." {
+			for _, attribute := range strings.Split(attributes, ";") {
+				if strings.TrimSpace(attribute) == "" {
+					continue
+				}
+				key, value, ok := strings.Cut(attribute, "=")
+				if !ok {
+					return Gff{}, fmt.Errorf("line %d: attribute %q has no value", lineNumber, attribute)
+				}
+				if _, ok := record.Attributes[key]; !ok {
+					record.AttributeOrder = append(record.AttributeOrder, key)
+				}
 				record.Attributes[key] = value
 			}
-			err = gff.AddFeature(&record)
-			if err != nil {
-				return Gff{}, err
-			}
 		}
+		record.ParentSequence = &gff
+		gff.Features = append(gff.Features, record)
+	}
+	gff.LinkFeatures()
+
+	if len(sequenceNames) > 0 {
+		name := sequenceNames[0]
+		if _, ok := sequences[meta.Name]; ok {
+			name = meta.Name
+		}
+		gff.Sequence = sequences[name].String()
+		meta.Description = descriptions[name]
 	}
-	gff.Sequence = sequenceBuffer.String()
+	meta.CheckSum = gff.Meta.CheckSum
 	gff.Meta = meta
 
-	return gff, err
+	return gff, nil
 }
 
 // regionString takes in the lines array,fieldName that is needed in gff file, and
@@ -234,7 +356,7 @@ func extractInfoFromField(lines []string, fieldName string) ([]string, int, erro
 func Build(sequence Gff) ([]byte, error) {
 	var gffBuffer bytes.Buffer
 
-	versionString := "##gff-version 3 \n"
+	versionString := "##gff-version 3\n"
 	if sequence.Meta.Version != "" {
 		versionString = "##gff-version " + sequence.Meta.Version + "\n"
 	}
@@ -244,6 +366,15 @@ func Build(sequence Gff) ([]byte, error) {
 	name := "Sequence"
 	start := "1"
 	end := strconv.Itoa(sequence.Meta.RegionEnd)
+	if sequence.Meta.RegionEnd == 0 {
+		// without a region, it's as long as the sequence, or reaches to the
+		// end of the last feature.
+		regionEnd := len(sequence.Sequence)
+		for _, feature := range sequence.Features {
+			regionEnd = max(regionEnd, feature.Location.End)
+		}
+		end = strconv.Itoa(regionEnd)
+	}
 
 	if sequence.Meta.Name != "" {
 		name = sequence.Meta.Name
@@ -256,8 +387,24 @@ func Build(sequence Gff) ([]byte, error) {
 	regionString := "##sequence-region " + name + " " + start + " " + end + "\n"
 	gffBuffer.WriteString(regionString)
 
+	ids := make(map[string]bool)
 	for _, feature := range sequence.Features {
-		var featureString string
+		if id := feature.Attributes["ID"]; id != "" {
+			ids[id] = true
+		}
+	}
+
+	for index, feature := range sequence.Features {
+		for _, parentID := range feature.AttributeValues("Parent") {
+			if !ids[parentID] {
+				return nil, fmt.Errorf("feature %d has Parent %q, which no feature has as its ID", index, parentID)
+			}
+		}
+
+		featureName := name
+		if feature.Name != "" {
+			featureName = feature.Name
+		}
 
 		featureSource := "feature"
 		if feature.Source != "" {
@@ -269,49 +416,159 @@ func Build(sequence Gff) ([]byte, error) {
 			featureType = feature.Type
 		}
 
-		// Indexing starts at 1 for gff so we need to shift up from Sequence 0 index.
-		featureStart := strconv.Itoa(feature.Location.Start + 1)
-		featureEnd := strconv.Itoa(feature.Location.End)
-
-		featureScore := feature.Score
+		featureScore := orDot(feature.Score)
 		featureStrand := feature.Strand
-		featurePhase := feature.Phase
-		var featureAttributes string
-
-		keys := make([]string, 0, len(feature.Attributes))
-		for key := range feature.Attributes {
-			keys = append(keys, key)
+		if featureStrand == "" {
+			featureStrand = "+"
+			if feature.Location.Complement {
+				featureStrand = "-"
+			}
 		}
-		sort.Strings(keys)
+		featureAttributes := buildAttributes(feature)
 
-		for _, key := range keys {
-			attributeString := key + "=" + feature.Attributes[key] + ";"
-			featureAttributes += attributeString
+		// discontinuous features are written as one line per part, which
+		// share an ID.
+		parts := []Location{feature.Location}
+		if len(feature.Location.SubLocations) > 0 {
+			parts = feature.Location.SubLocations
+		}
+		if len(parts) > 1 && feature.Attributes["ID"] == "" {
+			return nil, fmt.Errorf("feature %d has %d parts, and needs an ID to share between them", index, len(parts))
+		}
+		phases, err := partPhases(feature, parts)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %w", index, err)
 		}
 
-		if len(featureAttributes) > 0 {
-			featureAttributes = featureAttributes[0 : len(featureAttributes)-1]
+		for partIndex, part := range parts {
+			// Indexing starts at 1 for gff so we need to shift up from Sequence 0 index.
+			featureStart := strconv.Itoa(part.Start + 1)
+			featureEnd := strconv.Itoa(part.End)
+			partStrand := featureStrand
+			if part.Complement && len(parts) > 1 {
+				partStrand = "-"
+			}
+			TAB := "\t"
+			featureString := featureName + TAB + featureSource + TAB + featureType + TAB + featureStart + TAB + featureEnd + TAB + featureScore + TAB + partStrand + TAB + phases[partIndex] + TAB + featureAttributes + "\n"
+			gffBuffer.WriteString(featureString)
 		}
-		TAB := "\t"
-		featureString = feature.Name + TAB + featureSource + TAB + featureType + TAB + featureStart + TAB + featureEnd + TAB + featureScore + TAB + featureStrand + TAB + featurePhase + TAB + featureAttributes + "\n"
-		gffBuffer.WriteString(featureString)
 	}
 
 	gffBuffer.WriteString("###\n")
+	if sequence.Sequence == "" {
+		return gffBuffer.Bytes(), nil
+	}
 	gffBuffer.WriteString("##FASTA\n")
-	gffBuffer.WriteString(">" + sequence.Meta.Name + "\n")
+	header := ">" + name
+	if sequence.Meta.Description != "" {
+		header += " " + sequence.Meta.Description
+	}
+	gffBuffer.WriteString(header + "\n")
+	for lineStart := 0; lineStart < len(sequence.Sequence); lineStart += 70 {
+		gffBuffer.WriteString(sequence.Sequence[lineStart:min(lineStart+70, len(sequence.Sequence))] + "\n")
+	}
+	return gffBuffer.Bytes(), nil
+}
+
+// orDot returns a column's value, or . for an empty one.
+func orDot(value string) string {
+	if value == "" {
+		return "."
+	}
+	return value
+}
+
+// buildAttributes builds the attribute column of a feature, with its
+// attributes in AttributeOrder and then alphabetical order.
+func buildAttributes(feature Feature) string {
+	keys := make([]string, 0, len(feature.Attributes))
+	written := make(map[string]bool)
+	for _, key := range feature.AttributeOrder {
+		if _, ok := feature.Attributes[key]; ok && !written[key] {
+			keys = append(keys, key)
+			written[key] = true
+		}
+	}
+	var rest []string
+	for key := range feature.Attributes {
+		if !written[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	keys = append(keys, rest...)
 
-	for letterIndex, letter := range sequence.Sequence {
-		letterIndex++
-		if letterIndex%70 == 0 && letterIndex != 0 && letterIndex != sequence.Meta.RegionEnd {
-			gffBuffer.WriteRune(letter)
-			gffBuffer.WriteString("\n")
-		} else {
-			gffBuffer.WriteRune(letter)
+	attributes := make([]string, len(keys))
+	for index, key := range keys {
+		attributes[index] = escapeAttribute(key) + "=" + escapeAttribute(feature.Attributes[key])
+	}
+	if len(attributes) == 0 {
+		return "."
+	}
+	return strings.Join(attributes, ";")
+}
+
+// escapeAttribute percent-encodes the characters of an attribute that would
+// break the line it's on, or the column. Values are kept as they are
+// otherwise, since they're already encoded, so commas still separate values
+// and existing escapes aren't escaped again.
+func escapeAttribute(value string) string {
+	var escaped strings.Builder
+	for index := 0; index < len(value); index++ {
+		character := value[index]
+		isEscape := character == '%' && index+2 < len(value) && isHex(value[index+1]) && isHex(value[index+2])
+		if character < ' ' || character == ';' || character == '&' || character == '=' || (character == '%' && !isEscape) {
+			fmt.Fprintf(&escaped, "%%%02X", character)
+			continue
 		}
+		escaped.WriteByte(character)
 	}
-	gffBuffer.WriteString("\n")
-	return gffBuffer.Bytes(), nil
+	return escaped.String()
+}
+
+// isHex checks whether a character is a hexadecimal digit.
+func isHex(character byte) bool {
+	return strings.IndexByte("0123456789ABCDEFabcdef", character) != -1
+}
+
+// partPhases returns the phase of every part of a feature. The phase of a
+// CDS is how many bases of its part come before its first whole codon, so
+// after the first part, in the order they're translated, it follows from
+// the lengths of the parts before. Other features, and single part ones,
+// keep the feature's phase.
+func partPhases(feature Feature, parts []Location) ([]string, error) {
+	phases := make([]string, len(parts))
+	if feature.Type != "CDS" || len(parts) == 1 {
+		for index := range phases {
+			phases[index] = orDot(feature.Phase)
+		}
+		return phases, nil
+	}
+	phase := 0
+	if feature.Phase != "" && feature.Phase != "." {
+		var err error
+		phase, err = strconv.Atoi(feature.Phase)
+		if err != nil || phase < 0 || phase > 2 {
+			return nil, fmt.Errorf("phase %q isn't 0, 1, or 2", feature.Phase)
+		}
+	}
+	order := make([]int, len(parts))
+	for index := range order {
+		order[index] = index
+		// minus strand parts are translated from the last one back.
+		if feature.Location.Complement {
+			order[index] = len(parts) - 1 - index
+		}
+	}
+	translated := 0
+	for _, index := range order {
+		phases[index] = strconv.Itoa((3 - (translated-phase)%3) % 3)
+		if translated == 0 {
+			phases[index] = strconv.Itoa(phase)
+		}
+		translated += parts[index].End - parts[index].Start
+	}
+	return phases, nil
 }
 
 // Read takes in a filepath for a .gffv3 file and parses it into an Annotated poly.Sequence struct.
@@ -327,6 +584,9 @@ func Read(path string) (Gff, error) {
 
 // Write takes an poly.Sequence struct and a path string and writes out a gff to that path.
 func Write(sequence Gff, path string) error {
-	gff, _ := Build(sequence)
+	gff, err := Build(sequence)
+	if err != nil {
+		return err
+	}
 	return os.WriteFile(path, gff, 0644)
 }
@@ -144,3 +144,162 @@ func BenchmarkReadGff10000(b *testing.B) { BenchmarkReadGff(b) }
 Gff related tests and benchmarks end here.
 
 ******************************************************************************/
+
+func TestMinusStrandFeature(t *testing.T) {
+	file := "##gff-version 3\n##sequence-region seq 1 16\nseq\ttest\tgene\t5\t8\t.\t-\t.\tID=gene\n##FASTA\n>seq\nAAAACCCCGGGGTTTT\n"
+	record, err := Parse(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	feature := record.Features[0]
+	if !feature.Location.Complement {
+		t.Errorf("minus strand feature should be complemented")
+	}
+	if got, err := feature.GetSequence(); err != nil || got != "GGGG" {
+		t.Errorf("GetSequence() = %q, %v, want GGGG", got, err)
+	}
+}
+
+// hierarchyGff is a gene with an mRNA, and its exons and a CDS in two parts,
+// with attributes out of alphabetical order.
+const hierarchyGff = `##gff-version 3
+##sequence-region chr1 1 40
+chr1	test	gene	1	40	.	+	.	ID=gene1;Name=abcA;Note=ABC transporter%3B putative
+chr1	test	mRNA	1	40	.	+	.	ID=mRNA1;Parent=gene1
+chr1	test	exon	1	12	.	+	.	ID=exon1;Parent=mRNA1
+chr1	test	exon	20	40	.	+	.	ID=exon2;Parent=mRNA1
+chr1	test	CDS	4	12	.	+	0	ID=cds1;Parent=mRNA1
+chr1	test	CDS	20	37	.	+	0	ID=cds1;Parent=mRNA1
+###
+##FASTA
+>chr1 test chromosome
+ATGATGAAATAGCCCCCCCGGCTGTAGTTTTTTTTTTTTT
+>plasmid
+GGGGGGGGGG
+`
+
+func TestParseHierarchy(t *testing.T) {
+	record, err := Parse(strings.NewReader(hierarchyGff))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := record.Features[1].Parents; !cmp.Equal(got, []int{0}) {
+		t.Errorf("mRNA parents = %v, want [0]", got)
+	}
+	if got := record.Features[1].Children; !cmp.Equal(got, []int{2, 3, 4, 5}) {
+		t.Errorf("mRNA children = %v, want [2 3 4 5]", got)
+	}
+	if got := record.Features[0].AttributeOrder; !cmp.Equal(got, []string{"ID", "Name", "Note"}) {
+		t.Errorf("gene attribute order = %v, want [ID Name Note]", got)
+	}
+	if got := record.Features[0].AttributeValues("Note"); !cmp.Equal(got, []string{"ABC transporter; putative"}) {
+		t.Errorf("gene Note = %q, want the decoded note", got)
+	}
+	if record.Sequence != "ATGATGAAATAGCCCCCCCGGCTGTAGTTTTTTTTTTTTT" {
+		t.Errorf("Sequence = %q, want only the chr1 record", record.Sequence)
+	}
+	if record.Meta.Description != "test chromosome" {
+		t.Errorf("Description = %q, want %q", record.Meta.Description, "test chromosome")
+	}
+}
+
+func TestBuildHierarchy(t *testing.T) {
+	record, err := Parse(strings.NewReader(hierarchyGff))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	built, err := Build(record)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	// only the sequence region's own sequence is kept.
+	want := strings.TrimSuffix(hierarchyGff, ">plasmid\nGGGGGGGGGG\n")
+	if string(built) != want {
+		t.Errorf("Build() =\n%s\nwant\n%s", built, want)
+	}
+}
+
+func TestBuildEscapesAttributes(t *testing.T) {
+	record := Gff{Meta: Meta{Name: "seq"}, Sequence: "ACGT"}
+	_ = record.AddFeature(&Feature{
+		Type:           "gene",
+		Location:       Location{Start: 0, End: 4},
+		Attributes:     map[string]string{"Note": "a=b; c&d\t100%", "Alias": "x,y", "ID": "g%3B1"},
+		AttributeOrder: []string{"Note"},
+	})
+	built, err := Build(record)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	line := strings.Split(string(built), "\n")[2]
+	want := "seq\tfeature\tgene\t1\t4\t.\t+\t.\tNote=a%3Db%3B c%26d%09100%25;Alias=x,y;ID=g%3B1"
+	if line != want {
+		t.Errorf("Build() feature line = %q, want %q", line, want)
+	}
+	parsed, err := Parse(bytes.NewReader(built))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := parsed.Features[0].AttributeValues("Note"); !cmp.Equal(got, []string{"a=b; c&d\t100%"}) {
+		t.Errorf("round tripped Note = %q", got)
+	}
+	if got := parsed.Features[0].AttributeValues("Alias"); !cmp.Equal(got, []string{"x", "y"}) {
+		t.Errorf("round tripped Alias = %q", got)
+	}
+}
+
+func TestBuildUnknownParent(t *testing.T) {
+	record := Gff{Meta: Meta{Name: "seq"}}
+	_ = record.AddFeature(&Feature{Type: "exon", Attributes: map[string]string{"Parent": "missing"}})
+	if _, err := Build(record); err == nil {
+		t.Errorf("Build() should fail on a Parent no feature has as its ID")
+	}
+}
+
+func TestBuildJoin(t *testing.T) {
+	record := Gff{Meta: Meta{Name: "seq"}}
+	cds := Feature{Type: "CDS", Phase: "0", Location: Location{Start: 0, End: 20, SubLocations: []Location{{Start: 0, End: 4}, {Start: 10, End: 20}}}}
+	_ = record.AddFeature(&cds)
+	if _, err := Build(record); err == nil {
+		t.Errorf("Build() should fail on a feature in parts without an ID")
+	}
+
+	record.Features[0].Attributes = map[string]string{"ID": "cds1"}
+	built, err := Build(record)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	want := "##gff-version 3\n##sequence-region seq 1 20\n" +
+		"seq\tfeature\tCDS\t1\t4\t.\t+\t0\tID=cds1\n" +
+		"seq\tfeature\tCDS\t11\t20\t.\t+\t2\tID=cds1\n" +
+		"###\n"
+	if string(built) != want {
+		t.Errorf("Build() =\n%s\nwant\n%s", built, want)
+	}
+}
+
+func TestGff_AddChild(t *testing.T) {
+	record := Gff{Meta: Meta{Name: "seq"}}
+	_ = record.AddFeature(&Feature{Type: "gene", Attributes: map[string]string{"ID": "gene1"}})
+	if err := record.AddChild(0, &Feature{Type: "mRNA"}); err != nil {
+		t.Fatalf("AddChild() error = %v", err)
+	}
+	if got := record.Features[1].Attributes["Parent"]; got != "gene1" {
+		t.Errorf("child Parent = %q, want gene1", got)
+	}
+	if !cmp.Equal(record.Features[0].Children, []int{1}) || !cmp.Equal(record.Features[1].Parents, []int{0}) {
+		t.Errorf("AddChild() didn't link the features: %v, %v", record.Features[0].Children, record.Features[1].Parents)
+	}
+	if err := record.AddChild(1, &Feature{Type: "exon"}); err == nil {
+		t.Errorf("AddChild() should fail on a parent without an ID")
+	}
+}
+
+func TestParseMalformedLines(t *testing.T) {
+	for _, line := range []string{"seq\ttest\tgene\t1\t4\t.\t+\t.", "seq\ttest\tgene\t1\t4\t.\t+\t.\tID"} {
+		file := "##gff-version 3\n##sequence-region seq 1 4\n" + line + "\n"
+		if _, err := Parse(strings.NewReader(file)); err == nil || !strings.Contains(err.Error(), "line 3") {
+			t.Errorf("Parse(%q) error = %v, want an error on line 3", line, err)
+		}
+	}
+}
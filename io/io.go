@@ -1,9 +1,148 @@
 /*
 Package io provides utilities for reading and writing sequence data.
+
+Every format poly reads has its own package, like io/genbank and io/fasta.
+This package only tells them apart: Detect reads the start of a file and says
+which format it's in, for when a file's extension is missing or wrong, or
+when data is piped in without a name at all.
+
+Reference datasets are usually shared gzipped, or bgzipped, which is gzip
+split into blocks that can be read on their own. Decompress sees through
+both, and every parser in poly uses it, so gzipped files can be read and
+parsed without decompressing them first.
 */
 package io
 
-/*
-This package is supposed to be empty and only exists to provide a doc string.
-Otherwise its namespace would collide with Go's native IO package.
-*/
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	stdio "io"
+)
+
+// Format is a file format poly can read.
+type Format string
+
+// The formats Detect recognizes, named after their packages.
+const (
+	GenBank   Format = "genbank"
+	EMBL      Format = "embl"
+	FASTA     Format = "fasta"
+	FASTQ     Format = "fastq"
+	GFF       Format = "gff"
+	PolyJSON  Format = "polyjson"
+	SLOW5     Format = "slow5"
+	AB1       Format = "ab1"
+	BedGraph  Format = "bedgraph"
+	Stockholm Format = "stockholm"
+	Clustal   Format = "clustal"
+	Newick    Format = "newick"
+	TwoBit    Format = "twobit"
+)
+
+// sniffLength is how many bytes Detect reads to decide on a format.
+const sniffLength = 512
+
+// signatures are what text formats start with, after any leading
+// whitespace, in the order they're checked.
+var signatures = []struct {
+	prefix string
+	format Format
+}{
+	{"LOCUS", GenBank},
+	{"ID   ", EMBL},
+	{"##gff-version", GFF},
+	{"#slow5_version", SLOW5},
+	{">", FASTA},
+	{"@", FASTQ},
+	{"{", PolyJSON},
+	{"browser", BedGraph},
+	{"track", BedGraph},
+	{"# STOCKHOLM", Stockholm},
+	{"CLUSTAL", Clustal},
+	{"MUSCLE", Clustal},
+	{"(", Newick},
+}
+
+// ErrUnknownFormat is returned by Detect for data it doesn't recognize.
+var ErrUnknownFormat = errors.New("couldn't detect the format")
+
+// Detect reads the start of r and returns the format it's in, along with a
+// reader that reads all of r again, from the start, to parse it with. Gzipped
+// data is decompressed, and the format detected is the format inside it.
+func Detect(r stdio.Reader) (Format, stdio.Reader, error) {
+	reader := bufio.NewReaderSize(Decompress(r), sniffLength)
+	start, err := reader.Peek(sniffLength)
+	if err != nil && !errors.Is(err, stdio.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return "", reader, err
+	}
+	// ABIF and .2bit are binary, so their magic numbers are the first thing
+	// in the file. .2bit's can be either endian.
+	if bytes.HasPrefix(start, []byte("ABIF")) {
+		return AB1, reader, nil
+	}
+	if bytes.HasPrefix(start, []byte{0x43, 0x27, 0x41, 0x1a}) || bytes.HasPrefix(start, []byte{0x1a, 0x41, 0x27, 0x43}) {
+		return TwoBit, reader, nil
+	}
+	start = bytes.TrimLeft(bytes.TrimPrefix(start, []byte("\xef\xbb\xbf")), " \t\r\n")
+	for _, signature := range signatures {
+		if bytes.HasPrefix(start, []byte(signature.prefix)) {
+			return signature.format, reader, nil
+		}
+	}
+	return "", reader, ErrUnknownFormat
+}
+
+// gzipMagic is what gzip data, and so bgzip data, starts with.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Decompress returns a reader that reads r decompressed if it's gzipped or
+// bgzipped, or as it is if it isn't. Nothing is read from r until the
+// reader is, and errors reading or decompressing r are returned by the
+// reader's Read, so parsers can call Decompress on whatever they're given.
+func Decompress(r stdio.Reader) stdio.Reader {
+	return &decompressor{source: r}
+}
+
+// decompressor checks whether its source is gzipped on its first Read.
+type decompressor struct {
+	source stdio.Reader
+	reader stdio.Reader
+}
+
+func (decompressor *decompressor) Read(buffer []byte) (int, error) {
+	if decompressor.reader == nil {
+		decompressor.reader = decompress(decompressor.source)
+	}
+	return decompressor.reader.Read(buffer)
+}
+
+// decompress returns a reader that reads source decompressed, if it's
+// gzipped.
+func decompress(source stdio.Reader) stdio.Reader {
+	reader := bufio.NewReader(source)
+	start, err := reader.Peek(len(gzipMagic))
+	if err != nil && !errors.Is(err, stdio.EOF) {
+		return errorReader{err}
+	}
+	if !bytes.Equal(start, gzipMagic) {
+		return reader
+	}
+	// gzip readers read every member of multistream data, which bgzip's
+	// blocks are, by default.
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return errorReader{err}
+	}
+	return gzipReader
+}
+
+// errorReader is a reader that fails with err.
+type errorReader struct {
+	err error
+}
+
+func (reader errorReader) Read([]byte) (int, error) {
+	return 0, reader.err
+}
@@ -0,0 +1,142 @@
+package io_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	stdio "io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	polyio "github.com/bebop/poly/io"
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/io/gff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	files := map[string]polyio.Format{
+		"../data/puc19.gbk":                          polyio.GenBank,
+		"embl/data/X56734_trimmed.embl":              polyio.EMBL,
+		"fasta/data/base.fasta":                      polyio.FASTA,
+		"fastq/data/nanosavseq.fastq":                polyio.FASTQ,
+		"../data/ecoli-mg1655-short.gff":             polyio.GFF,
+		"../data/cat.json":                           polyio.PolyJSON,
+		"slow5/data/example.slow5":                   polyio.SLOW5,
+		"ab1/data/synthetic_puc19.ab1":               polyio.AB1,
+		"bedgraph/data/puc19_accessibility.bedgraph": polyio.BedGraph,
+		"stockholm/data/trna.sto":                    polyio.Stockholm,
+		"clustal/data/rps2.aln":                      polyio.Clustal,
+		"newick/data/guide.nwk":                      polyio.Newick,
+		"twobit/data/example.2bit":                   polyio.TwoBit,
+	}
+	for path, want := range files {
+		file, err := os.Open(path)
+		require.NoError(t, err)
+		contents, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		format, reader, err := polyio.Detect(file)
+		require.NoError(t, err, path)
+		assert.Equal(t, want, format, path)
+		// the reader reads the whole file, sniffed bytes included.
+		reread, err := stdio.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, contents, reread, path)
+		file.Close()
+	}
+
+	format, _, err := polyio.Detect(strings.NewReader("\n\n  >leading blank lines\nACGT\n"))
+	require.NoError(t, err)
+	assert.Equal(t, polyio.FASTA, format)
+
+	_, _, err = polyio.Detect(strings.NewReader("not a sequence file"))
+	assert.ErrorIs(t, err, polyio.ErrUnknownFormat)
+	_, _, err = polyio.Detect(strings.NewReader(""))
+	assert.ErrorIs(t, err, polyio.ErrUnknownFormat)
+}
+
+// gzipped gzips data, in blocks of blockSize bytes written as separate gzip
+// members, like bgzip writes, if blockSize isn't 0.
+func gzipped(t *testing.T, data []byte, blockSize int) []byte {
+	t.Helper()
+	if blockSize == 0 {
+		blockSize = len(data) + 1
+	}
+	var buffer bytes.Buffer
+	for start := 0; start < len(data); start += blockSize {
+		writer := gzip.NewWriter(&buffer)
+		// bgzip marks its blocks with a BC extra field.
+		writer.Header.Extra = []byte{'B', 'C', 2, 0, 0, 0}
+		_, err := writer.Write(data[start:min(start+blockSize, len(data))])
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+	}
+	return buffer.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	contents, err := os.ReadFile("../data/puc19.gbk")
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		name string
+		data []byte
+	}{
+		{"plain", contents},
+		{"gzip", gzipped(t, contents, 0)},
+		{"bgzip", gzipped(t, contents, 1000)},
+	} {
+		read, err := stdio.ReadAll(polyio.Decompress(bytes.NewReader(test.data)))
+		require.NoError(t, err, test.name)
+		assert.Equal(t, contents, read, test.name)
+
+		format, reader, err := polyio.Detect(bytes.NewReader(test.data))
+		require.NoError(t, err, test.name)
+		assert.Equal(t, polyio.GenBank, format, test.name)
+		read, err = stdio.ReadAll(reader)
+		require.NoError(t, err, test.name)
+		assert.Equal(t, contents, read, test.name)
+	}
+
+	read, err := stdio.ReadAll(polyio.Decompress(strings.NewReader("")))
+	require.NoError(t, err)
+	assert.Empty(t, read)
+
+	_, err = stdio.ReadAll(polyio.Decompress(strings.NewReader("\x1f\x8bnot really gzip")))
+	assert.Error(t, err)
+}
+
+func TestReadGzipped(t *testing.T) {
+	directory := t.TempDir()
+	gzipFile := func(path string) string {
+		contents, err := os.ReadFile(path)
+		require.NoError(t, err)
+		gzippedPath := filepath.Join(directory, filepath.Base(path)+".gz")
+		require.NoError(t, os.WriteFile(gzippedPath, gzipped(t, contents, 4096), 0644))
+		return gzippedPath
+	}
+
+	want, err := genbank.Read("../data/puc19.gbk")
+	require.NoError(t, err)
+	got, err := genbank.Read(gzipFile("../data/puc19.gbk"))
+	require.NoError(t, err)
+	assert.Equal(t, want.Sequence, got.Sequence)
+	assert.Equal(t, len(want.Features), len(got.Features))
+
+	wantFastas, err := fasta.Read("fasta/data/base.fasta")
+	require.NoError(t, err)
+	gotFastas, err := fasta.Read(gzipFile("fasta/data/base.fasta"))
+	require.NoError(t, err)
+	assert.Equal(t, wantFastas, gotFastas)
+
+	wantGff, err := gff.Read("../data/ecoli-mg1655-short.gff")
+	require.NoError(t, err)
+	gotGff, err := gff.Read(gzipFile("../data/ecoli-mg1655-short.gff"))
+	require.NoError(t, err)
+	assert.Equal(t, wantGff.Sequence, gotGff.Sequence)
+	assert.Equal(t, len(wantGff.Features), len(gotGff.Features))
+}
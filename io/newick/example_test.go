@@ -0,0 +1,22 @@
+package newick_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bebop/poly/io/newick"
+)
+
+// This example roots a guide tree on an outgroup and measures how far apart
+// two of its leaves are.
+func ExampleReroot() {
+	trees, _ := newick.Parse(strings.NewReader("((E_coli:0.1,S_enterica:0.12):0.3,B_subtilis:0.5,M_tuberculosis:0.7);"))
+	root := newick.Reroot(trees[0].Find("M_tuberculosis"))
+	fmt.Println(root)
+
+	distance, _ := newick.Distance(root.Find("E_coli"), root.Find("B_subtilis"))
+	fmt.Println(distance)
+	// Output:
+	// (M_tuberculosis:0.35,((E_coli:0.1,S_enterica:0.12):0.3,B_subtilis:0.5):0.35);
+	// 0.9
+}
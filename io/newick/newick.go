@@ -0,0 +1,457 @@
+/*
+Package newick contains Newick tree parsers and writers, and a few things to
+do with trees once they're parsed.
+
+Newick is how nearly every phylogenetics program, and every multiple
+sequence aligner that builds a guide tree, writes trees. A tree is written
+as nested parentheses, with the children of each node inside its
+parentheses, each node's name after them, and the length of the branch to
+its parent after a colon:
+
+	((E_coli:0.1,S_enterica:0.12)Enterobacterales:0.3,B_subtilis:0.5);
+
+Names with spaces or any of ()[]':;, in them are quoted with single quotes,
+and text in square brackets is a comment. Underscores in unquoted names are
+kept as they are, rather than read as spaces like the original Newick
+standard says, since that's what most programs do now.
+
+Besides parsing and writing, nodes can be walked in pre-order or post-order,
+leaves renamed, say from accessions to species names, trees rerooted on an
+outgroup, and the patristic distances between leaves, the total length of
+the branches between them, measured.
+
+More information on Newick can be found here:
+https://phylipweb.github.io/phylip/newicktree.html
+*/
+package newick
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	polyio "github.com/bebop/poly/io"
+)
+
+// Node is a node of a tree. The root of a tree is the tree.
+type Node struct {
+	Name string `json:"name"`
+	// Length is the length of the branch to the node's parent, if HasLength.
+	Length    float64 `json:"length"`
+	HasLength bool    `json:"has_length"`
+	Children  []*Node `json:"children"`
+	Parent    *Node   `json:"-"`
+}
+
+// IsLeaf returns whether a node has no children.
+func (node *Node) IsLeaf() bool {
+	return len(node.Children) == 0
+}
+
+// Root returns the root of the tree a node is in.
+func (node *Node) Root() *Node {
+	for node.Parent != nil {
+		node = node.Parent
+	}
+	return node
+}
+
+// PreOrder returns a node and everything under it, with every node before
+// its children.
+func (node *Node) PreOrder() []*Node {
+	nodes := []*Node{node}
+	for _, child := range node.Children {
+		nodes = append(nodes, child.PreOrder()...)
+	}
+	return nodes
+}
+
+// PostOrder returns a node and everything under it, with every node after
+// its children.
+func (node *Node) PostOrder() []*Node {
+	var nodes []*Node
+	for _, child := range node.Children {
+		nodes = append(nodes, child.PostOrder()...)
+	}
+	return append(nodes, node)
+}
+
+// Leaves returns the leaves under a node, from left to right.
+func (node *Node) Leaves() []*Node {
+	var leaves []*Node
+	for _, descendant := range node.PreOrder() {
+		if descendant.IsLeaf() {
+			leaves = append(leaves, descendant)
+		}
+	}
+	return leaves
+}
+
+// Find returns the first node under a node, in pre-order, with a name, or
+// nil if there isn't one.
+func (node *Node) Find(name string) *Node {
+	for _, descendant := range node.PreOrder() {
+		if descendant.Name == name {
+			return descendant
+		}
+	}
+	return nil
+}
+
+// RenameLeaves renames the leaves under a node that are in names to what
+// they map to, and returns how many were renamed.
+func (node *Node) RenameLeaves(names map[string]string) int {
+	renamed := 0
+	for _, leaf := range node.Leaves() {
+		if name, ok := names[leaf.Name]; ok {
+			leaf.Name = name
+			renamed++
+		}
+	}
+	return renamed
+}
+
+// String returns the Newick of a node and everything under it, as a tree.
+func (node *Node) String() string {
+	var buffer bytes.Buffer
+	writeNode(&buffer, node)
+	buffer.WriteByte(';')
+	return buffer.String()
+}
+
+/******************************************************************************
+
+Tree operations begin here.
+
+******************************************************************************/
+
+// Reroot roots the tree an outgroup is in on the branch above the outgroup,
+// halfway along it, and returns the new root. The outgroup ends up as the
+// first child of the root, with the rest of the tree as the second. The old
+// root is removed if it's left with only one child, joining its branches.
+//
+// Reroot rearranges the tree in place rather than copying it, so the old root
+// is no longer the root, and may not be in the tree at all. Parse the tree
+// again, or keep its Newick from String, to hold on to the original.
+func Reroot(outgroup *Node) *Node {
+	parent := outgroup.Parent
+	if parent == nil {
+		return outgroup
+	}
+	oldRoot := outgroup.Root()
+	root := &Node{}
+	half, hasLength := outgroup.Length/2, outgroup.HasLength
+	parent.Children = without(parent.Children, outgroup)
+	reverse(parent, root, half, hasLength)
+	outgroup.Parent, outgroup.Length = root, half
+	root.Children = []*Node{outgroup, parent}
+
+	if len(oldRoot.Children) == 1 {
+		child := oldRoot.Children[0]
+		child.Parent = oldRoot.Parent
+		child.Length += oldRoot.Length
+		child.HasLength = child.HasLength || oldRoot.HasLength
+		oldRoot.Parent.Children[index(oldRoot.Parent.Children, oldRoot)] = child
+	}
+	return root
+}
+
+// reverse makes newParent the parent of node, and node's old parent one of
+// its children, all the way up to the old root.
+func reverse(node, newParent *Node, length float64, hasLength bool) {
+	oldParent, oldLength, oldHasLength := node.Parent, node.Length, node.HasLength
+	if oldParent != nil {
+		oldParent.Children = without(oldParent.Children, node)
+		reverse(oldParent, node, oldLength, oldHasLength)
+		node.Children = append(node.Children, oldParent)
+	}
+	node.Parent, node.Length, node.HasLength = newParent, length, hasLength
+}
+
+// without returns nodes without one of them.
+func without(nodes []*Node, node *Node) []*Node {
+	kept := make([]*Node, 0, len(nodes))
+	for _, other := range nodes {
+		if other != node {
+			kept = append(kept, other)
+		}
+	}
+	return kept
+}
+
+// index returns where a node is in nodes, or -1.
+func index(nodes []*Node, node *Node) int {
+	for position, other := range nodes {
+		if other == node {
+			return position
+		}
+	}
+	return -1
+}
+
+// Distance returns the patristic distance between two nodes of a tree, the
+// total length of the branches between them.
+func Distance(a, b *Node) (float64, error) {
+	// distances are the distances from a to each of its ancestors.
+	distances := map[*Node]float64{}
+	total := 0.0
+	for node := a; node != nil; node = node.Parent {
+		distances[node] = total
+		total += node.Length
+	}
+	total = 0
+	for node := b; node != nil; node = node.Parent {
+		if distance, ok := distances[node]; ok {
+			return distance + total, nil
+		}
+		total += node.Length
+	}
+	return 0, fmt.Errorf("%q and %q aren't in the same tree", a.Name, b.Name)
+}
+
+// PatristicDistances returns the names of the leaves of a tree, from left to
+// right, and the patristic distances between every pair of them.
+func PatristicDistances(root *Node) ([]string, [][]float64) {
+	leaves := root.Leaves()
+	names := make([]string, len(leaves))
+	distances := make([][]float64, len(leaves))
+	for i, leaf := range leaves {
+		names[i] = leaf.Name
+		distances[i] = make([]float64, len(leaves))
+		for j := 0; j < i; j++ {
+			// leaves are all under root, so they're in the same tree.
+			distances[i][j], _ = Distance(leaf, leaves[j])
+			distances[j][i] = distances[i][j]
+		}
+	}
+	return names, distances
+}
+
+/******************************************************************************
+
+Newick parser begins here.
+
+******************************************************************************/
+
+// Parse parses every tree of a Newick file, which can be gzipped. Trees end
+// with a semicolon.
+func Parse(r io.Reader) ([]*Node, error) {
+	data, err := io.ReadAll(polyio.Decompress(r))
+	if err != nil {
+		return nil, err
+	}
+	parser := parser{input: string(data)}
+	var trees []*Node
+	for {
+		if err := parser.skip(); err != nil {
+			return nil, err
+		}
+		if parser.position == len(parser.input) {
+			break
+		}
+		tree, err := parser.node()
+		if err != nil {
+			return nil, err
+		}
+		if err := parser.skip(); err != nil {
+			return nil, err
+		}
+		if parser.peek() != ';' {
+			return nil, parser.errorf("expected ; at the end of the tree")
+		}
+		parser.position++
+		trees = append(trees, tree)
+	}
+	if len(trees) == 0 {
+		return nil, fmt.Errorf("no trees found")
+	}
+	return trees, nil
+}
+
+// Read reads the trees of a Newick file from path.
+func Read(path string) ([]*Node, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+type parser struct {
+	input    string
+	position int
+}
+
+// peek returns the next byte of the input, or 0 at the end of it.
+func (parser *parser) peek() byte {
+	if parser.position >= len(parser.input) {
+		return 0
+	}
+	return parser.input[parser.position]
+}
+
+func (parser *parser) errorf(format string, arguments ...any) error {
+	return fmt.Errorf("position %d: %s", parser.position, fmt.Sprintf(format, arguments...))
+}
+
+// skip skips whitespace and comments.
+func (parser *parser) skip() error {
+	for parser.position < len(parser.input) {
+		switch parser.peek() {
+		case ' ', '\t', '\r', '\n':
+			parser.position++
+		case '[':
+			end := strings.IndexByte(parser.input[parser.position:], ']')
+			if end == -1 {
+				return parser.errorf("comment isn't closed")
+			}
+			parser.position += end + 1
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// node parses a node and everything under it.
+func (parser *parser) node() (*Node, error) {
+	node := &Node{}
+	if parser.peek() == '(' {
+		parser.position++
+		for {
+			if err := parser.skip(); err != nil {
+				return nil, err
+			}
+			child, err := parser.node()
+			if err != nil {
+				return nil, err
+			}
+			child.Parent = node
+			node.Children = append(node.Children, child)
+			if err := parser.skip(); err != nil {
+				return nil, err
+			}
+			if parser.peek() == ',' {
+				parser.position++
+				continue
+			}
+			if parser.peek() != ')' {
+				return nil, parser.errorf("expected , or ) after a child")
+			}
+			parser.position++
+			break
+		}
+		if err := parser.skip(); err != nil {
+			return nil, err
+		}
+	}
+
+	name, err := parser.name()
+	if err != nil {
+		return nil, err
+	}
+	node.Name = name
+	if err := parser.skip(); err != nil {
+		return nil, err
+	}
+	if parser.peek() == ':' {
+		parser.position++
+		if err := parser.skip(); err != nil {
+			return nil, err
+		}
+		start := parser.position
+		for parser.position < len(parser.input) && strings.IndexByte("0123456789.eE+-", parser.peek()) != -1 {
+			parser.position++
+		}
+		length, err := strconv.ParseFloat(parser.input[start:parser.position], 64)
+		if err != nil {
+			parser.position = start
+			return nil, parser.errorf("invalid branch length")
+		}
+		node.Length, node.HasLength = length, true
+	}
+	return node, nil
+}
+
+// name parses the name of a node, which may be quoted, or empty.
+func (parser *parser) name() (string, error) {
+	if parser.peek() == '\'' {
+		var name strings.Builder
+		for parser.position++; parser.position < len(parser.input); parser.position++ {
+			if parser.peek() != '\'' {
+				name.WriteByte(parser.peek())
+				continue
+			}
+			// quotes in quoted names are doubled.
+			if parser.position+1 < len(parser.input) && parser.input[parser.position+1] == '\'' {
+				name.WriteByte('\'')
+				parser.position++
+				continue
+			}
+			parser.position++
+			return name.String(), nil
+		}
+		return "", parser.errorf("quoted name isn't closed")
+	}
+	start := parser.position
+	for parser.position < len(parser.input) && strings.IndexByte(delimiters, parser.peek()) == -1 {
+		parser.position++
+	}
+	return parser.input[start:parser.position], nil
+}
+
+// delimiters end unquoted names.
+const delimiters = "()[]':;, \t\r\n"
+
+/******************************************************************************
+
+Newick writer begins here.
+
+******************************************************************************/
+
+// Build builds trees into a byte slice, one tree per line.
+func Build(trees []*Node) ([]byte, error) {
+	var buffer bytes.Buffer
+	for index, tree := range trees {
+		if tree == nil {
+			return nil, fmt.Errorf("tree %d is nil", index)
+		}
+		buffer.WriteString(tree.String())
+		buffer.WriteByte('\n')
+	}
+	return buffer.Bytes(), nil
+}
+
+// Write writes trees to path.
+func Write(trees []*Node, path string) error {
+	output, err := Build(trees)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, output, 0644)
+}
+
+func writeNode(buffer *bytes.Buffer, node *Node) {
+	if !node.IsLeaf() {
+		buffer.WriteByte('(')
+		for index, child := range node.Children {
+			if index > 0 {
+				buffer.WriteByte(',')
+			}
+			writeNode(buffer, child)
+		}
+		buffer.WriteByte(')')
+	}
+	if strings.ContainsAny(node.Name, delimiters) {
+		buffer.WriteString("'" + strings.ReplaceAll(node.Name, "'", "''") + "'")
+	} else {
+		buffer.WriteString(node.Name)
+	}
+	if node.HasLength {
+		buffer.WriteString(":" + strconv.FormatFloat(node.Length, 'g', -1, 64))
+	}
+}
@@ -0,0 +1,190 @@
+package newick
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parseOne(t *testing.T, input string) *Node {
+	t.Helper()
+	trees, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trees) != 1 {
+		t.Fatalf("expected 1 tree, got %d", len(trees))
+	}
+	return trees[0]
+}
+
+func names(nodes []*Node) []string {
+	result := make([]string, len(nodes))
+	for index, node := range nodes {
+		result[index] = node.Name
+	}
+	return result
+}
+
+func TestRead(t *testing.T) {
+	trees, err := Read("data/trees.nwk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trees) != 2 {
+		t.Fatalf("expected 2 trees, got %d", len(trees))
+	}
+	tree := trees[0]
+	if got := names(tree.Leaves()); !reflect.DeepEqual(got, []string{"E_coli", "S. enterica", "B_subtilis", "M_tuberculosis"}) {
+		t.Errorf("unexpected leaves %v", got)
+	}
+	enterobacterales := tree.Find("Enterobacterales")
+	if enterobacterales == nil || enterobacterales.Length != 0.3 || !enterobacterales.HasLength || enterobacterales.Parent != tree {
+		t.Errorf("unexpected internal node %+v", enterobacterales)
+	}
+	if tree.Find("S. enterica").Root() != tree {
+		t.Errorf("leaf isn't under the root")
+	}
+	if got := names(trees[1].PreOrder()); !reflect.DeepEqual(got, []string{"F", "A", "B", "E", "C", "D"}) {
+		t.Errorf("unexpected pre-order %v", got)
+	}
+	if got := names(trees[1].PostOrder()); !reflect.DeepEqual(got, []string{"A", "B", "C", "D", "E", "F"}) {
+		t.Errorf("unexpected post-order %v", got)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, input := range []string{
+		"((E_coli:0.1,'S. enterica':0.12)Enterobacterales:0.3,(B_subtilis:0.5,M_tuberculosis:0.7):0.05);",
+		"(A,B,(C,D)E)F;",
+		"('it''s',:1e-05,'a,b');",
+		"A;",
+	} {
+		tree := parseOne(t, input)
+		if tree.String() != input {
+			t.Errorf("round trip changed %s to %s", input, tree.String())
+		}
+	}
+
+	trees, err := Read("data/trees.nwk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "round_trip.nwk")
+	if err := Write(trees, path); err != nil {
+		t.Fatal(err)
+	}
+	written, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for index := range trees {
+		if written[index].String() != trees[index].String() {
+			t.Errorf("round trip changed %s to %s", trees[index], written[index])
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"[just a comment]",
+		"(A,B)",
+		"(A,B;",
+		"(A,B)C:x;",
+		"('A,B);",
+		"(A,B)[unclosed;",
+		"(A B);",
+	} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("expected an error parsing %q", input)
+		}
+	}
+}
+
+func TestRenameLeaves(t *testing.T) {
+	tree := parseOne(t, "((P0A7V0,Q9ZBR5)X,P66536);")
+	renamed := tree.RenameLeaves(map[string]string{"P0A7V0": "E. coli", "P66536": "M. tuberculosis", "X": "internal"})
+	if renamed != 2 {
+		t.Errorf("expected 2 leaves renamed, got %d", renamed)
+	}
+	if tree.String() != "(('E. coli',Q9ZBR5)X,'M. tuberculosis');" {
+		t.Errorf("unexpected renamed tree %s", tree)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tree := parseOne(t, "((A:1,B:2)AB:3,(C:4,D:5):6);")
+	for _, test := range []struct {
+		a, b     string
+		distance float64
+	}{
+		{"A", "B", 3},
+		{"A", "C", 14},
+		{"B", "D", 16},
+		{"A", "AB", 1},
+		{"C", "C", 0},
+	} {
+		distance, err := Distance(tree.Find(test.a), tree.Find(test.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if distance != test.distance {
+			t.Errorf("distance from %s to %s is %g, expected %g", test.a, test.b, distance, test.distance)
+		}
+	}
+	if _, err := Distance(tree.Find("A"), parseOne(t, "(A,B);").Find("A")); err == nil {
+		t.Errorf("expected an error for nodes of different trees")
+	}
+
+	leaves, distances := PatristicDistances(tree)
+	if !reflect.DeepEqual(leaves, []string{"A", "B", "C", "D"}) {
+		t.Errorf("unexpected leaves %v", leaves)
+	}
+	if distances[0][2] != 14 || distances[2][0] != 14 || distances[3][3] != 0 {
+		t.Errorf("unexpected distances %v", distances)
+	}
+}
+
+func TestReroot(t *testing.T) {
+	tree := parseOne(t, "((A:1,B:2)AB:3,(C:4,D:5)CD:6);")
+	before := map[[2]string]float64{}
+	leaves, distances := PatristicDistances(tree)
+	for i := range leaves {
+		for j := range leaves {
+			before[[2]string{leaves[i], leaves[j]}] = distances[i][j]
+		}
+	}
+
+	root := Reroot(tree.Find("D"))
+	if root.Parent != nil || len(root.Children) != 2 || root.Children[0].Name != "D" {
+		t.Fatalf("unexpected root %s", root)
+	}
+	if root.Children[0].Length != 2.5 {
+		t.Errorf("outgroup branch is %g, expected 2.5", root.Children[0].Length)
+	}
+	// the old root had two children, so it's left with one and removed.
+	if root.String() != "(D:2.5,(C:4,(A:1,B:2)AB:9)CD:2.5);" {
+		t.Errorf("unexpected rerooted tree %s", root)
+	}
+	// rerooting doesn't change how far apart leaves are.
+	leaves, distances = PatristicDistances(root)
+	for i := range leaves {
+		for j := range leaves {
+			if math.Abs(distances[i][j]-before[[2]string{leaves[i], leaves[j]}]) > 1e-9 {
+				t.Errorf("distance from %s to %s changed from %g to %g", leaves[i], leaves[j], before[[2]string{leaves[i], leaves[j]}], distances[i][j])
+			}
+		}
+	}
+	for _, node := range root.PreOrder()[1:] {
+		if index(node.Parent.Children, node) == -1 {
+			t.Errorf("%s isn't a child of its parent", node.Name)
+		}
+	}
+
+	if Reroot(root) != root {
+		t.Errorf("rerooting on the root changed it")
+	}
+}
@@ -44,6 +44,8 @@ import (
 	"strconv"
 	"strings"
 	"unicode"
+
+	polyio "github.com/bebop/poly/io"
 )
 
 // https://en.wikipedia.org/wiki/Pileup_format
@@ -74,10 +76,11 @@ type Parser struct {
 	line   uint
 }
 
-// NewParser creates a parser from an io.Reader for pileup data.
+// NewParser creates a parser from an io.Reader for pileup data, which can be
+// gzipped.
 func NewParser(r io.Reader, maxLineSize int) *Parser {
 	return &Parser{
-		reader: *bufio.NewReaderSize(r, maxLineSize),
+		reader: *bufio.NewReaderSize(polyio.Decompress(r), maxLineSize),
 	}
 }
 
@@ -195,7 +198,7 @@ func (parser *Parser) ParseNext() (Pileup, error) {
 
 // Reset discards all data in buffer and resets state.
 func (parser *Parser) Reset(r io.Reader) {
-	parser.reader.Reset(r)
+	parser.reader.Reset(polyio.Decompress(r))
 	parser.line = 0
 }
 
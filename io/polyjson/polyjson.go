@@ -13,6 +13,8 @@ import (
 	"os"
 	"time"
 
+	polyio "github.com/bebop/poly/io"
+	"github.com/bebop/poly/sequence"
 	"github.com/bebop/poly/transform"
 )
 
@@ -77,6 +79,12 @@ func (sequence *Poly) AddFeature(feature *Feature) error {
 	return nil
 }
 
+// ToSequence returns a Poly's sequence, guessing what molecule it is with
+// sequence.New. It's assumed linear.
+func (poly Poly) ToSequence() sequence.Sequence {
+	return sequence.New(poly.Meta.Name, poly.Sequence)
+}
+
 // GetSequence takes a feature and returns a sequence string for that feature.
 func (feature Feature) GetSequence() (string, error) {
 	return getFeatureSequence(feature, feature.Location)
@@ -110,11 +118,11 @@ func getFeatureSequence(feature Feature, location Location) (string, error) {
 	return sequenceString, nil
 }
 
-// Parse parses a Poly JSON file and adds appropriate pointers to struct.
+// Parse parses a Poly JSON file, gzipped or not, and adds appropriate pointers to struct.
 func Parse(file io.Reader) (Poly, error) {
 	var sequence Poly
 	buf := new(bytes.Buffer)
-	_, err := buf.ReadFrom(file) // todo: test error
+	_, err := buf.ReadFrom(polyio.Decompress(file)) // todo: test error
 	if err != nil {
 		return sequence, err
 	}
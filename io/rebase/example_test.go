@@ -26,3 +26,10 @@ func ExampleExport() {
 	fmt.Println(string(enzymeJSON)[:100])
 	// Output: {"AaaI":{"name":"AaaI","isoschizomers":["XmaIII","BseX3I","BsoDI","BstZI","EagI","EclXI","Eco52I","S
 }
+
+func ExampleEnzyme_CloneEnzyme() {
+	enzymeMap, _ := rebase.Read("data/rebase_test.txt")
+	enzyme, _ := enzymeMap["AarI"].CloneEnzyme()
+	fmt.Println(enzyme.RecognitionSite, enzyme.Skip, enzyme.OverheadLength)
+	// Output: CACCTGC 4 4
+}
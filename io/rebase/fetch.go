@@ -0,0 +1,147 @@
+package rebase
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bebop/poly/clone"
+	"github.com/bebop/poly/search/motif"
+	"github.com/bebop/poly/transform"
+)
+
+/******************************************************************************
+
+REBASE download begins here.
+
+REBASE is updated every month, so rather than bundling a copy that goes
+stale, Fetch downloads the current release and parses it. The release is a
+few megabytes, so with a cache directory the download is kept on disk and
+reused until it's older than MaxAge.
+
+Enzymes can be converted to clone.Enzyme to digest and clone with. REBASE
+doesn't say which enzymes Dam or Dcm methylation blocks, only what each
+enzyme's own methylase modifies, so for digests of methylated DNA have a look
+at clone.MethylationSensitivity.
+
+******************************************************************************/
+
+// DefaultURL is where the current REBASE release in format #31 is
+// downloaded from.
+const DefaultURL = "http://rebase.neb.com/rebase/link_withrefm"
+
+// cacheFile is the name of the cached release in a cache directory.
+const cacheFile = "withrefm.txt"
+
+// FetchOptions changes where REBASE is downloaded from and how it's cached.
+// Zero values are replaced with the defaults noted on each field.
+type FetchOptions struct {
+	URL    string       // defaults to DefaultURL.
+	Client *http.Client // defaults to http.DefaultClient.
+	// CacheDir is a directory the release is kept in. Without one, nothing
+	// is cached.
+	CacheDir string
+	// MaxAge is how old a cached release can be before it's downloaded
+	// again. Defaults to 30 days.
+	MaxAge time.Duration
+}
+
+// Fetch downloads and parses the current REBASE release, or reads it from
+// the cache if it was downloaded recently enough.
+func Fetch(options FetchOptions) (map[string]Enzyme, error) {
+	if options.URL == "" {
+		options.URL = DefaultURL
+	}
+	if options.Client == nil {
+		options.Client = http.DefaultClient
+	}
+	if options.MaxAge == 0 {
+		options.MaxAge = 30 * 24 * time.Hour
+	}
+
+	var cachePath string
+	if options.CacheDir != "" {
+		cachePath = filepath.Join(options.CacheDir, cacheFile)
+		if info, err := os.Stat(cachePath); err == nil && time.Since(info.ModTime()) < options.MaxAge {
+			return Read(cachePath)
+		}
+	}
+
+	response, err := options.Client.Get(options.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading REBASE from %s: %s", options.URL, response.Status)
+	}
+	release, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Contains(release, []byte("<1>")) {
+		return nil, fmt.Errorf("downloading REBASE from %s: response has no enzymes", options.URL)
+	}
+
+	if cachePath != "" {
+		if err := os.MkdirAll(options.CacheDir, 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(cachePath, release, 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return Parse(bytes.NewReader(release))
+}
+
+// CloneEnzyme converts an enzyme into a clone.Enzyme. clone only models
+// enzymes that cut once on the 3' side of, or within, their site, so enzymes
+// whose cleavage isn't known or that cut on both sides can't be converted.
+// clone treats every overhang as a 5' overhang, so enzymes that leave 3'
+// overhangs cut in the right place but their overhangs aren't right for
+// ligation.
+func (enzyme Enzyme) CloneEnzyme() (clone.Enzyme, error) {
+	if !enzyme.CutKnown {
+		return clone.Enzyme{}, fmt.Errorf("%s doesn't have a known cleavage site", enzyme.Name)
+	}
+	if enzyme.CutsBothSides {
+		return clone.Enzyme{}, fmt.Errorf("%s cuts on both sides of its site, which clone doesn't support", enzyme.Name)
+	}
+	site := strings.ToUpper(enzyme.Site)
+	forward, err := siteRegexp(site)
+	if err != nil {
+		return clone.Enzyme{}, fmt.Errorf("%s: %w", enzyme.Name, err)
+	}
+	reverse, err := siteRegexp(transform.ReverseComplement(site))
+	if err != nil {
+		return clone.Enzyme{}, fmt.Errorf("%s: %w", enzyme.Name, err)
+	}
+	overhangLength := enzyme.BottomCut - enzyme.TopCut
+	if overhangLength < 0 {
+		overhangLength = -overhangLength
+	}
+	return clone.Enzyme{
+		Name:            enzyme.Name,
+		RegexpFor:       forward,
+		RegexpRev:       reverse,
+		Skip:            enzyme.TopCut - len(site),
+		OverheadLength:  overhangLength,
+		RecognitionSite: site,
+	}, nil
+}
+
+// siteRegexp compiles a site with IUPAC ambiguity codes into a regular
+// expression matching every sequence it stands for.
+func siteRegexp(site string) (*regexp.Regexp, error) {
+	pattern, err := motif.CompileIUPAC(site)
+	if err != nil {
+		return nil, err
+	}
+	return pattern.Regexp()
+}
@@ -146,7 +146,10 @@ import (
 	"encoding/json"
 	"io"
 	"os"
+	"strconv"
 	"strings"
+
+	polyio "github.com/bebop/poly/io"
 )
 
 var (
@@ -165,11 +168,37 @@ type Enzyme struct {
 	Source                 string   `json:"source"`
 	CommercialAvailability []string `json:"commercialAvailability"`
 	References             string   `json:"references"`
+
+	// Site is the recognition sequence without its cleavage marks.
+	Site string `json:"site"`
+	// CutKnown is whether the recognition sequence gives where the enzyme
+	// cuts. Methylases and enzymes with unknown cleavage don't.
+	CutKnown bool `json:"cutKnown"`
+	// TopCut and BottomCut are where the top and bottom strands are cut, as
+	// the number of bases after the start of Site on the top strand. Cuts
+	// before Site are negative.
+	TopCut    int `json:"topCut"`
+	BottomCut int `json:"bottomCut"`
+	// CutsBothSides is whether the enzyme also cuts on the 5' side of its
+	// site, like BcgI. TopCut and BottomCut are the cuts on the 3' side.
+	CutsBothSides bool `json:"cutsBothSides"`
+	// Methylations are the bases the enzyme's cognate methylase modifies.
+	Methylations []Methylation `json:"methylations"`
+}
+
+// Methylation is a base of a recognition sequence that a methylase modifies.
+type Methylation struct {
+	// Position is the 1-based position of the base in the site, where
+	// negative positions count from the 5' end of the bottom strand.
+	Position int `json:"position"`
+	// Type is the kind of methylation: 6 for N6-methyladenosine, 5 for
+	// 5-methylcytosine, and 4 for N4-methylcytosine.
+	Type int `json:"type"`
 }
 
-// Parse parses the Rebase database into a map of enzymes
+// Parse parses the Rebase database, gzipped or not, into a map of enzymes
 func Parse(file io.Reader) (map[string]Enzyme, error) {
-	fileBytes, err := readAllFn(file)
+	fileBytes, err := readAllFn(polyio.Decompress(file))
 	if err != nil {
 		return make(map[string]Enzyme), err
 	}
@@ -239,8 +268,10 @@ func Parse(file io.Reader) (map[string]Enzyme, error) {
 			enzyme.Isoschizomers = strings.Split(line[3:], ",")
 		case strings.Contains(line, "<3>"):
 			enzyme.RecognitionSequence = line[3:]
+			enzyme.Site, enzyme.TopCut, enzyme.BottomCut, enzyme.CutKnown, enzyme.CutsBothSides = parseRecognitionSequence(enzyme.RecognitionSequence)
 		case strings.Contains(line, "<4>"):
 			enzyme.MethylationSite = line[3:]
+			enzyme.Methylations = parseMethylationSite(enzyme.MethylationSite)
 		case strings.Contains(line, "<5>"):
 			enzyme.MicroOrganism = line[3:]
 		case strings.Contains(line, "<6>"):
@@ -260,6 +291,77 @@ func Parse(file io.Reader) (map[string]Enzyme, error) {
 	return enzymeMap, err
 }
 
+// parseRecognitionSequence splits a recognition sequence like G^AATTC or
+// GGTCTC(1/5) into the site and where it's cut.
+func parseRecognitionSequence(recognitionSequence string) (site string, topCut, bottomCut int, cutKnown, cutsBothSides bool) {
+	site = recognitionSequence
+	var leading, trailing string
+	if strings.HasPrefix(site, "(") {
+		if end := strings.Index(site, ")"); end != -1 {
+			leading, site = site[1:end], site[end+1:]
+		}
+	}
+	if start := strings.Index(site, "("); start != -1 && strings.HasSuffix(site, ")") {
+		site, trailing = site[:start], site[start+1:len(site)-1]
+	}
+
+	switch {
+	case strings.Contains(site, "^"):
+		topCut = strings.Index(site, "^")
+		site = strings.Replace(site, "^", "", 1)
+		// sites cut within are written on the strand that makes them
+		// symmetric, so the bottom strand is cut at the mirrored position.
+		return site, topCut, len(site) - topCut, true, false
+	case trailing != "":
+		top, bottom, ok := parseCutOffsets(trailing)
+		if !ok || site == "?" {
+			return site, 0, 0, false, false
+		}
+		return site, len(site) + top, len(site) + bottom, true, leading != ""
+	case leading != "":
+		top, bottom, ok := parseCutOffsets(leading)
+		if !ok || site == "?" {
+			return site, 0, 0, false, false
+		}
+		return site, -top, -bottom, true, false
+	}
+	return site, 0, 0, false, false
+}
+
+// parseCutOffsets parses the offsets of a cut like 1/5.
+func parseCutOffsets(offsets string) (top, bottom int, ok bool) {
+	topString, bottomString, found := strings.Cut(offsets, "/")
+	if !found {
+		return 0, 0, false
+	}
+	top, topErr := strconv.Atoi(topString)
+	bottom, bottomErr := strconv.Atoi(bottomString)
+	return top, bottom, topErr == nil && bottomErr == nil
+}
+
+// parseMethylationSite parses a methylation site like 2(6) or 3,-3(5,5).
+func parseMethylationSite(methylationSite string) []Methylation {
+	positionsString, typesString, found := strings.Cut(strings.TrimSuffix(methylationSite, ")"), "(")
+	if !found {
+		return nil
+	}
+	positions, types := strings.Split(positionsString, ","), strings.Split(typesString, ",")
+	var methylations []Methylation
+	for index, positionString := range positions {
+		position, err := strconv.Atoi(strings.TrimSpace(positionString))
+		if err != nil {
+			return nil
+		}
+		// a single type applies to every position.
+		methylationType, err := strconv.Atoi(strings.TrimSpace(types[min(index, len(types)-1)]))
+		if err != nil {
+			return nil
+		}
+		methylations = append(methylations, Methylation{Position: position, Type: methylationType})
+	}
+	return methylations
+}
+
 // Read returns an enzymeMap from a Rebase data dump
 func Read(path string) (map[string]Enzyme, error) {
 	file, err := os.Open(path)
@@ -3,8 +3,13 @@ package rebase
 import (
 	"errors"
 	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -65,3 +70,105 @@ func TestExport_error(t *testing.T) {
 	_, err := Export(map[string]Enzyme{})
 	assert.EqualError(t, err, exportErr.Error())
 }
+
+func TestParse_cuts(t *testing.T) {
+	enzymeMap, err := Read("data/rebase_test.txt")
+	assert.NoError(t, err)
+
+	aarI := enzymeMap["AarI"]
+	assert.Equal(t, "CACCTGC", aarI.Site)
+	assert.True(t, aarI.CutKnown)
+	assert.Equal(t, 11, aarI.TopCut)
+	assert.Equal(t, 15, aarI.BottomCut)
+
+	aatII := enzymeMap["AatII"]
+	assert.Equal(t, "GACGTC", aatII.Site)
+	assert.Equal(t, 5, aatII.TopCut)
+	assert.Equal(t, 1, aatII.BottomCut)
+	assert.Equal(t, []Methylation{{Position: 2, Type: 6}}, aatII.Methylations)
+
+	assert.False(t, enzymeMap["AamI"].CutKnown)
+}
+
+func TestParseRecognitionSequence(t *testing.T) {
+	site, topCut, bottomCut, cutKnown, cutsBothSides := parseRecognitionSequence("(10/12)CGANNNNNNTGC(12/10)")
+	assert.Equal(t, "CGANNNNNNTGC", site)
+	assert.Equal(t, 24, topCut)
+	assert.Equal(t, 22, bottomCut)
+	assert.True(t, cutKnown)
+	assert.True(t, cutsBothSides)
+
+	site, topCut, bottomCut, _, cutsBothSides = parseRecognitionSequence("(8/13)GAGGAG")
+	assert.Equal(t, "GAGGAG", site)
+	assert.Equal(t, -8, topCut)
+	assert.Equal(t, -13, bottomCut)
+	assert.False(t, cutsBothSides)
+
+	assert.Equal(t, []Methylation{{Position: 3, Type: 5}, {Position: -3, Type: 5}}, parseMethylationSite("3,-3(5)"))
+}
+
+func TestFetch(t *testing.T) {
+	release, err := os.ReadFile("data/rebase_test.txt")
+	assert.NoError(t, err)
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		_, _ = w.Write(release)
+	}))
+	defer server.Close()
+
+	cacheDir := filepath.Join(t.TempDir(), "rebase")
+	options := FetchOptions{URL: server.URL, CacheDir: cacheDir}
+	enzymeMap, err := Fetch(options)
+	assert.NoError(t, err)
+	assert.Equal(t, "CACCTGC(4/8)", enzymeMap["AarI"].RecognitionSequence)
+	assert.FileExists(t, filepath.Join(cacheDir, cacheFile))
+
+	// a fresh cache isn't downloaded again.
+	_, err = Fetch(options)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, downloads)
+
+	// a stale one is.
+	stale := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(filepath.Join(cacheDir, cacheFile), stale, stale))
+	options.MaxAge = time.Minute
+	_, err = Fetch(options)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, downloads)
+}
+
+func TestFetch_error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusNotFound)
+	}))
+	defer server.Close()
+	_, err := Fetch(FetchOptions{URL: server.URL})
+	assert.ErrorContains(t, err, "404")
+
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html></html>"))
+	}))
+	defer empty.Close()
+	_, err = Fetch(FetchOptions{URL: empty.URL})
+	assert.ErrorContains(t, err, "no enzymes")
+}
+
+func TestCloneEnzyme(t *testing.T) {
+	enzymeMap, err := Read("data/rebase_test.txt")
+	assert.NoError(t, err)
+
+	aarI, err := enzymeMap["AarI"].CloneEnzyme()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, aarI.Skip)
+	assert.Equal(t, 4, aarI.OverheadLength)
+	assert.Equal(t, "GCAGGTG", aarI.RegexpRev.String())
+
+	_, err = enzymeMap["AamI"].CloneEnzyme()
+	assert.Error(t, err)
+
+	ambiguous, err := Enzyme{Name: "BsiHKAI", Site: "GWGCWC", CutKnown: true, TopCut: 5, BottomCut: 1}.CloneEnzyme()
+	assert.NoError(t, err)
+	assert.True(t, ambiguous.RegexpFor.MatchString("GAGCTC"))
+	assert.False(t, ambiguous.RegexpFor.MatchString("GCGCTC"))
+}
@@ -25,6 +25,8 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+
+	polyio "github.com/bebop/poly/io"
 )
 
 /******************************************************************************
@@ -105,10 +107,10 @@ type Parser struct {
 	endReasonMap map[int]string
 }
 
-// NewParser parsers a slow5 file.
+// NewParser parsers a slow5 file, or a gzipped one.
 func NewParser(r io.Reader, maxLineSize int) (*Parser, []Header, error) {
 	parser := &Parser{
-		reader: *bufio.NewReaderSize(r, maxLineSize),
+		reader: *bufio.NewReaderSize(polyio.Decompress(r), maxLineSize),
 		line:   0,
 	}
 	var headers []Header
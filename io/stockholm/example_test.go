@@ -0,0 +1,22 @@
+package stockholm_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/io/stockholm"
+	"github.com/bebop/poly/search/align/msa"
+)
+
+// This example reads a Stockholm alignment of tRNAs and finds their
+// consensus, with the consensus secondary structure it's annotated with.
+func ExampleRead() {
+	alignments, _ := stockholm.Read("data/trna.sto")
+	alignment := alignments[0]
+
+	consensus, _ := msa.Majority(alignment.Rows())
+	fmt.Println(consensus)
+	fmt.Println(alignment.ColumnAnnotations["SS_cons"])
+	// Output:
+	// GCGGAAUUAGCUCAGUUGGGAGAGCGCCAGACUGAAGAUCUGGAGGUCCUGUGUUCGAUCCACAGAAUUCGCACCA
+	// (((((((..((((........)))).(((((.......)))))).....(((((.......)))))))))))....
+}
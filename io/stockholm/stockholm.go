@@ -0,0 +1,311 @@
+/*
+Package stockholm contains Stockholm alignment parsers and writers.
+
+Stockholm is the multiple sequence alignment format of Pfam and Rfam, and of
+the tools that build them, HMMER and Infernal. Besides the aligned sequences,
+it carries annotation about the whole alignment, about each sequence, and
+about each column, like the consensus secondary structure an RNA family
+folds into:
+
+	# STOCKHOLM 1.0
+	#=GF ID    tRNA
+	tRNA1          GCGGAUUUAGCUCAGUUGGGAGAGC
+	tRNA2          GCCGAAAUAGCUCAGUUGGGAGAGC
+	#=GC SS_cons   (((((((..((((........))))
+	//
+
+Annotation lines start with #=GF for the file, #=GS for a sequence, #=GR for
+each residue of a sequence, and #=GC for each column. Long alignments are
+split into blocks, which are joined back together when they're parsed.
+
+The rows of a parsed alignment can be handed straight to the msa package to
+score how conserved each column is or to build a consensus.
+
+More information on Stockholm can be found here:
+https://sonnhammer.sbc.su.se/Stockholm.html
+*/
+package stockholm
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	polyio "github.com/bebop/poly/io"
+)
+
+// Alignment is a single Stockholm alignment.
+type Alignment struct {
+	// FileAnnotations are the #=GF lines, in the order they're in the file.
+	FileAnnotations []Annotation `json:"file_annotations"`
+	Sequences       []Sequence   `json:"sequences"`
+	// ColumnAnnotations are the #=GC lines, like SS_cons and RF, with one
+	// character per column of the alignment.
+	ColumnAnnotations map[string]string `json:"column_annotations"`
+}
+
+// Annotation is a feature of an alignment, like ID or AC, and its text.
+type Annotation struct {
+	Feature string `json:"feature"`
+	Text    string `json:"text"`
+}
+
+// Sequence is an aligned sequence.
+type Sequence struct {
+	Name     string `json:"name"`
+	Sequence string `json:"sequence"`
+	// Annotations are the #=GS lines of the sequence, like AC or DE.
+	Annotations map[string]string `json:"annotations"`
+	// ResidueAnnotations are the #=GR lines of the sequence, like SS or PP,
+	// with one character per column of the alignment.
+	ResidueAnnotations map[string]string `json:"residue_annotations"`
+}
+
+// Rows returns the aligned sequences of an alignment, in order, for the msa
+// package.
+func (alignment Alignment) Rows() []string {
+	rows := make([]string, len(alignment.Sequences))
+	for index, sequence := range alignment.Sequences {
+		rows[index] = sequence.Sequence
+	}
+	return rows
+}
+
+// Names returns the names of the sequences of an alignment, in order.
+func (alignment Alignment) Names() []string {
+	names := make([]string, len(alignment.Sequences))
+	for index, sequence := range alignment.Sequences {
+		names[index] = sequence.Name
+	}
+	return names
+}
+
+/******************************************************************************
+
+Stockholm parser begins here.
+
+******************************************************************************/
+
+// header is the first line of every Stockholm alignment.
+const header = "# STOCKHOLM 1.0"
+
+// Parse parses every alignment of a Stockholm file, which can be gzipped.
+func Parse(r io.Reader) ([]Alignment, error) {
+	var alignments []Alignment
+	var alignment *Alignment
+	// indexes finds sequences by name.
+	var indexes map[string]int
+	scanner := bufio.NewScanner(polyio.Decompress(r))
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1<<26)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if alignment == nil {
+			switch {
+			case strings.TrimSpace(line) == "":
+				continue
+			case strings.HasPrefix(line, header):
+				alignment = &Alignment{ColumnAnnotations: map[string]string{}}
+				indexes = map[string]int{}
+				continue
+			default:
+				return nil, fmt.Errorf("line %d: expected %q, got %q", lineNumber, header, line)
+			}
+		}
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			continue
+		case line == "//":
+			if err := check(*alignment); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			alignments = append(alignments, *alignment)
+			alignment = nil
+			continue
+		case strings.HasPrefix(line, "#=GF"):
+			feature, text, ok := cutField(line[len("#=GF"):])
+			if !ok {
+				return nil, fmt.Errorf("line %d: #=GF line has no feature", lineNumber)
+			}
+			alignment.FileAnnotations = append(alignment.FileAnnotations, Annotation{Feature: feature, Text: text})
+			continue
+		case strings.HasPrefix(line, "#=GC"):
+			feature, text, ok := cutField(line[len("#=GC"):])
+			if !ok || text == "" {
+				return nil, fmt.Errorf("line %d: #=GC line needs a feature and an annotation", lineNumber)
+			}
+			alignment.ColumnAnnotations[feature] += text
+			continue
+		case strings.HasPrefix(line, "#=GS"), strings.HasPrefix(line, "#=GR"):
+			name, rest, _ := cutField(line[len("#=GS"):])
+			feature, text, _ := cutField(rest)
+			if name == "" || feature == "" {
+				return nil, fmt.Errorf("line %d: %s line needs a sequence name and a feature", lineNumber, line[:4])
+			}
+			sequence := &alignment.Sequences[sequenceIndex(alignment, indexes, name)]
+			if strings.HasPrefix(line, "#=GS") {
+				if previous, ok := sequence.Annotations[feature]; ok {
+					// long descriptions are split over several lines.
+					text = previous + " " + text
+				}
+				sequence.Annotations[feature] = text
+			} else {
+				sequence.ResidueAnnotations[feature] += text
+			}
+			continue
+		case strings.HasPrefix(line, "#"):
+			// other comments aren't kept.
+			continue
+		}
+
+		name, residues, ok := cutField(line)
+		if !ok || residues == "" || strings.ContainsAny(residues, " \t") {
+			return nil, fmt.Errorf("line %d: expected a name and aligned sequence, got %q", lineNumber, line)
+		}
+		alignment.Sequences[sequenceIndex(alignment, indexes, name)].Sequence += residues
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if alignment != nil {
+		return nil, fmt.Errorf("alignment isn't ended by //")
+	}
+	return alignments, nil
+}
+
+// cutField cuts the first whitespace separated field off of a line, and
+// returns it and the rest of the line.
+func cutField(line string) (field, rest string, ok bool) {
+	line = strings.TrimLeft(line, " \t")
+	end := strings.IndexAny(line, " \t")
+	if end == -1 {
+		return line, "", line != ""
+	}
+	return line[:end], strings.TrimLeft(line[end:], " \t"), true
+}
+
+// sequenceIndex returns the index of the sequence with a name, adding it if
+// it's new.
+func sequenceIndex(alignment *Alignment, indexes map[string]int, name string) int {
+	index, ok := indexes[name]
+	if !ok {
+		index = len(alignment.Sequences)
+		indexes[name] = index
+		alignment.Sequences = append(alignment.Sequences, Sequence{Name: name, Annotations: map[string]string{}, ResidueAnnotations: map[string]string{}})
+	}
+	return index
+}
+
+// check makes sure every sequence and per column annotation of an alignment
+// is as long as the alignment.
+func check(alignment Alignment) error {
+	if len(alignment.Sequences) == 0 {
+		return fmt.Errorf("alignment has no sequences")
+	}
+	length := len(alignment.Sequences[0].Sequence)
+	for _, sequence := range alignment.Sequences {
+		if len(sequence.Sequence) != length {
+			return fmt.Errorf("sequence %q has length %d, expected %d", sequence.Name, len(sequence.Sequence), length)
+		}
+		for feature, annotation := range sequence.ResidueAnnotations {
+			if len(annotation) != length {
+				return fmt.Errorf("#=GR %s %s has length %d, expected %d", sequence.Name, feature, len(annotation), length)
+			}
+		}
+	}
+	for feature, annotation := range alignment.ColumnAnnotations {
+		if len(annotation) != length {
+			return fmt.Errorf("#=GC %s has length %d, expected %d", feature, len(annotation), length)
+		}
+	}
+	return nil
+}
+
+// Read reads a Stockholm file from path.
+func Read(path string) ([]Alignment, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+/******************************************************************************
+
+Stockholm writer begins here.
+
+Alignments are written with every sequence on one line, which every
+Stockholm reader accepts, and with names padded so the columns line up.
+Sequence annotations are written in alphabetical order.
+
+******************************************************************************/
+
+// Build builds Stockholm alignments into a byte slice.
+func Build(alignments []Alignment) ([]byte, error) {
+	var buffer bytes.Buffer
+	for _, alignment := range alignments {
+		if err := check(alignment); err != nil {
+			return nil, err
+		}
+		// width is how wide the names, and what comes before them on
+		// annotation lines, are padded to.
+		width := 0
+		for feature := range alignment.ColumnAnnotations {
+			width = max(width, len("#=GC ")+len(feature))
+		}
+		for _, sequence := range alignment.Sequences {
+			if sequence.Name == "" || strings.ContainsAny(sequence.Name, " \t") {
+				return nil, fmt.Errorf("invalid sequence name %q", sequence.Name)
+			}
+			width = max(width, len(sequence.Name))
+			for feature := range sequence.ResidueAnnotations {
+				width = max(width, len("#=GR ")+len(sequence.Name)+1+len(feature))
+			}
+		}
+
+		buffer.WriteString(header + "\n")
+		for _, annotation := range alignment.FileAnnotations {
+			fmt.Fprintf(&buffer, "#=GF %s %s\n", annotation.Feature, annotation.Text)
+		}
+		for _, sequence := range alignment.Sequences {
+			for _, feature := range sortedKeys(sequence.Annotations) {
+				fmt.Fprintf(&buffer, "#=GS %s %s %s\n", sequence.Name, feature, sequence.Annotations[feature])
+			}
+		}
+		for _, sequence := range alignment.Sequences {
+			fmt.Fprintf(&buffer, "%-*s %s\n", width, sequence.Name, sequence.Sequence)
+			for _, feature := range sortedKeys(sequence.ResidueAnnotations) {
+				fmt.Fprintf(&buffer, "%-*s %s\n", width, "#=GR "+sequence.Name+" "+feature, sequence.ResidueAnnotations[feature])
+			}
+		}
+		for _, feature := range sortedKeys(alignment.ColumnAnnotations) {
+			fmt.Fprintf(&buffer, "%-*s %s\n", width, "#=GC "+feature, alignment.ColumnAnnotations[feature])
+		}
+		buffer.WriteString("//\n")
+	}
+	return buffer.Bytes(), nil
+}
+
+// sortedKeys returns the keys of a map in alphabetical order.
+func sortedKeys(annotations map[string]string) []string {
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Write writes Stockholm alignments to path.
+func Write(alignments []Alignment, path string) error {
+	output, err := Build(alignments)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, output, 0644)
+}
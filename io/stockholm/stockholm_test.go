@@ -0,0 +1,91 @@
+package stockholm
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	alignments, err := Read("data/trna.sto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alignments) != 1 {
+		t.Fatalf("expected 1 alignment, got %d", len(alignments))
+	}
+	alignment := alignments[0]
+	if len(alignment.FileAnnotations) != 5 || alignment.FileAnnotations[0] != (Annotation{Feature: "ID", Text: "tRNA"}) {
+		t.Errorf("unexpected file annotations %v", alignment.FileAnnotations)
+	}
+	if !reflect.DeepEqual(alignment.Names(), []string{"tRNA1/1-72", "tRNA2/1-72", "tRNA3/1-71"}) {
+		t.Errorf("unexpected names %v", alignment.Names())
+	}
+	first := alignment.Sequences[0]
+	if first.Sequence != "GCGGAUUUAGCUCAGUUGGGAGAGCGCCAGACUGAAGAUCUGGAGGUCCUGUGUUCGAUCCACAGAAUUCGCACCA" {
+		t.Errorf("blocks weren't joined: %s", first.Sequence)
+	}
+	if !reflect.DeepEqual(first.Annotations, map[string]string{"AC": "X00001.1", "DE": "Phenylalanine tRNA"}) {
+		t.Errorf("unexpected sequence annotations %v", first.Annotations)
+	}
+	if len(first.ResidueAnnotations["SS"]) != 76 {
+		t.Errorf("unexpected residue annotations %v", first.ResidueAnnotations)
+	}
+	if alignment.ColumnAnnotations["SS_cons"] != first.ResidueAnnotations["SS"] {
+		t.Errorf("SS_cons %s differs from SS %s", alignment.ColumnAnnotations["SS_cons"], first.ResidueAnnotations["SS"])
+	}
+	if len(alignment.ColumnAnnotations["RF"]) != 76 {
+		t.Errorf("unexpected RF %q", alignment.ColumnAnnotations["RF"])
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	alignments, err := Read("data/trna.sto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alignments = append(alignments, Alignment{
+		Sequences:         []Sequence{{Name: "a", Sequence: "MKV-L", Annotations: map[string]string{}, ResidueAnnotations: map[string]string{}}, {Name: "b", Sequence: "MRVEL", Annotations: map[string]string{}, ResidueAnnotations: map[string]string{}}},
+		ColumnAnnotations: map[string]string{},
+	})
+	path := filepath.Join(t.TempDir(), "round_trip.sto")
+	if err := Write(alignments, path); err != nil {
+		t.Fatal(err)
+	}
+	written, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(written, alignments) {
+		t.Errorf("round trip changed alignments:\n%v\n%v", alignments, written)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		"CLUSTAL W\n",
+		"# STOCKHOLM 1.0\nseq1 ACGU\n",
+		"# STOCKHOLM 1.0\nseq1 ACGU\nseq2 ACG\n//\n",
+		"# STOCKHOLM 1.0\nseq1 ACGU\n#=GC SS_cons ((.\n//\n",
+		"# STOCKHOLM 1.0\nseq1 ACGU\n#=GR seq1 SS ....\n#=GR seq1\n//\n",
+		"# STOCKHOLM 1.0\nseq1\n//\n",
+		"# STOCKHOLM 1.0\n//\n",
+	} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("expected an error parsing %q", input)
+		}
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	for _, alignment := range []Alignment{
+		{},
+		{Sequences: []Sequence{{Name: "two words", Sequence: "ACGU"}}},
+		{Sequences: []Sequence{{Name: "a", Sequence: "ACGU"}, {Name: "b", Sequence: "ACG"}}},
+	} {
+		if _, err := Build([]Alignment{alignment}); err == nil {
+			t.Errorf("expected an error building %v", alignment)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package twobit_test
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/bebop/poly/io/twobit"
+	"github.com/bebop/poly/sequence/packed"
+)
+
+func ExampleOpen() {
+	file, err := twobit.Open("data/example.2bit")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer file.Close()
+
+	// only the bytes of bases 10 to 30 of chrA are read.
+	subsequence, _ := file.FetchSubsequence("chrA", 10, 30)
+	fmt.Println(file.Names())
+	fmt.Println(subsequence)
+	// Output:
+	// [chrA chrB empty]
+	// GACCAnnnnnNNNNNacgta
+}
+
+func ExampleRead() {
+	records, _ := twobit.Read("data/example.2bit")
+	for _, record := range records {
+		fmt.Printf("%s %d %q\n", record.Name, record.Sequence.Len(), record.Sequence)
+	}
+	// Output:
+	// chrA 42 "ACGTACGTTTGACCAnnnnnNNNNNacgtagctagGATTACA"
+	// chrB 12 "GGGCCCAAATTT"
+	// empty 0 ""
+}
+
+func ExampleBuild() {
+	built, _ := twobit.Build([]twobit.Record{{Name: "chr1", Sequence: packed.Pack("GATTACAnnnn")}})
+	records, _ := twobit.Parse(bytes.NewReader(built))
+	fmt.Println(records[0].Name, records[0].Sequence)
+	// Output: chr1 GATTACAnnnn
+}
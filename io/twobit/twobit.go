@@ -0,0 +1,461 @@
+/*
+Package twobit contains a UCSC .2bit parser and writer.
+
+.2bit is how UCSC distributes genome assemblies. It packs DNA two bits a
+base, a quarter of the size of FASTA, and has an index of where each sequence
+starts, so one chromosome, or a few hundred bases of it, can be read without
+reading the rest of the genome.
+
+Sequences are read into and written from packed.Sequence, which packs bases
+the same way, so they stay a quarter of the size in memory too. Like in
+packed.Sequence, every base that isn't A, C, G, or T is an N, and lowercase,
+soft masked, bases are kept.
+
+More information on .2bit can be found here:
+https://genome.ucsc.edu/FAQ/FAQformat.html#format7
+*/
+package twobit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	polyio "github.com/bebop/poly/io"
+	"github.com/bebop/poly/sequence/packed"
+)
+
+/******************************************************************************
+
+.2bit layout begins here.
+
+Every number is a 32 bit integer, little endian in every file UCSC writes,
+though readers are meant to read big endian files too, which they can tell
+apart by how the signature reads:
+
+	signature      0x1A412743
+	version        0, or 1 if the offsets in the index are 64 bit
+	sequenceCount
+	reserved       0
+
+Then an index with an entry for each sequence:
+
+	nameSize       a single byte
+	name           nameSize bytes
+	offset         where the sequence's record starts, 64 bit in version 1
+
+And each sequence's record:
+
+	dnaSize        how many bases it has
+	nBlockCount    and nBlockCount starts and nBlockCount sizes of its Ns
+	maskBlockCount and maskBlockCount starts and sizes of its masked bases
+	reserved       0
+	packedDna      (dnaSize+3)/4 bytes, T, C, A, and G as 0, 1, 2, and 3,
+	               four to a byte with the first in the highest bits
+
+Ns are packed as Ts.
+
+******************************************************************************/
+
+// signature is what .2bit files start with.
+const signature = 0x1A412743
+
+// Record is a named sequence of a .2bit file.
+type Record struct {
+	Name     string
+	Sequence packed.Sequence
+}
+
+// toPacked and fromPacked translate bytes of four .2bit codes to bytes of
+// four packed.Sequence codes, and back.
+var toPacked, fromPacked = func() ([256]byte, [256]byte) {
+	// twoBitCodes are the .2bit codes of A, C, G, and T, packed.Sequence's
+	// codes 0 to 3.
+	twoBitCodes := [4]byte{2, 1, 3, 0}
+	var packedCodes [4]byte
+	for code, twoBitCode := range twoBitCodes {
+		packedCodes[twoBitCode] = byte(code)
+	}
+	var to, from [256]byte
+	for value := 0; value < 256; value++ {
+		for shift := 0; shift < 8; shift += 2 {
+			to[value] |= packedCodes[value>>shift&3] << shift
+			from[value] |= twoBitCodes[value>>shift&3] << shift
+		}
+	}
+	return to, from
+}()
+
+/******************************************************************************
+
+.2bit parser begins here.
+
+******************************************************************************/
+
+// File is an open .2bit file, to read sequences out of without reading the
+// rest of it.
+type File struct {
+	reader    io.ReaderAt
+	byteOrder binary.ByteOrder
+	names     []string
+	offsets   map[string]int64
+}
+
+// Open opens the .2bit file at path, reading only its index.
+func Open(path string) (*File, error) {
+	osFile, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := NewFile(osFile)
+	if err != nil {
+		osFile.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// NewFile returns a File that reads a .2bit file from reader, reading its
+// index.
+func NewFile(reader io.ReaderAt) (*File, error) {
+	file := &File{reader: reader, offsets: map[string]int64{}}
+	header := make([]byte, 16)
+	if _, err := reader.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	switch {
+	case binary.LittleEndian.Uint32(header) == signature:
+		file.byteOrder = binary.LittleEndian
+	case binary.BigEndian.Uint32(header) == signature:
+		file.byteOrder = binary.BigEndian
+	default:
+		return nil, errors.New("not a .2bit file")
+	}
+	version := file.byteOrder.Uint32(header[4:])
+	if version > 1 {
+		return nil, fmt.Errorf("unsupported .2bit version %d", version)
+	}
+	offsetSize := 4 << version
+
+	position := int64(len(header))
+	for count := file.byteOrder.Uint32(header[8:]); count > 0; count-- {
+		nameSize := make([]byte, 1)
+		if _, err := reader.ReadAt(nameSize, position); err != nil {
+			return nil, fmt.Errorf("reading index: %w", err)
+		}
+		entry := make([]byte, int(nameSize[0])+offsetSize)
+		if _, err := reader.ReadAt(entry, position+1); err != nil {
+			return nil, fmt.Errorf("reading index: %w", err)
+		}
+		position += int64(1 + len(entry))
+		name := string(entry[:nameSize[0]])
+		if _, ok := file.offsets[name]; ok {
+			return nil, fmt.Errorf("there's already a sequence named %q", name)
+		}
+		offset := entry[nameSize[0]:]
+		if version == 0 {
+			file.offsets[name] = int64(file.byteOrder.Uint32(offset))
+		} else {
+			file.offsets[name] = int64(file.byteOrder.Uint64(offset))
+		}
+		file.names = append(file.names, name)
+	}
+	return file, nil
+}
+
+// Names returns the names of the sequences of a file, in order.
+func (file *File) Names() []string {
+	return file.names
+}
+
+// Close closes the file, if it can be closed.
+func (file *File) Close() error {
+	if closer, ok := file.reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// recordHeader is what comes before the packed bases of a record.
+type recordHeader struct {
+	length     int
+	nBlocks    []packed.Block
+	maskBlocks []packed.Block
+	// dataOffset is where the packed bases start.
+	dataOffset int64
+}
+
+// readUint32s reads count 32 bit integers at offset.
+func (file *File) readUint32s(offset int64, count int) ([]uint32, error) {
+	data := make([]byte, 4*count)
+	if _, err := readAt(file.reader, data, offset); err != nil {
+		return nil, err
+	}
+	numbers := make([]uint32, count)
+	for index := range numbers {
+		numbers[index] = file.byteOrder.Uint32(data[4*index:])
+	}
+	return numbers, nil
+}
+
+// readAt reads into data at offset. Some readers fail to read nothing at the
+// end of what they read, so nothing is never read.
+func readAt(reader io.ReaderAt, data []byte, offset int64) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	return reader.ReadAt(data, offset)
+}
+
+// readBlocks reads a count of blocks, their starts and their sizes at
+// offset, and returns them and where they end. A sequence of length bases
+// can't have more blocks than bases.
+func (file *File) readBlocks(offset int64, length int) ([]packed.Block, int64, error) {
+	count, err := file.readUint32s(offset, 1)
+	if err != nil {
+		return nil, 0, err
+	}
+	if int64(count[0]) > int64(length) {
+		return nil, 0, fmt.Errorf("%d blocks in %d bases", count[0], length)
+	}
+	numbers, err := file.readUint32s(offset+4, 2*int(count[0]))
+	if err != nil {
+		return nil, 0, err
+	}
+	blocks := make([]packed.Block, count[0])
+	for index := range blocks {
+		start, size := int(numbers[index]), int(numbers[int(count[0])+index])
+		blocks[index] = packed.Block{Start: start, End: start + size}
+	}
+	return blocks, offset + 4 + 4*int64(len(numbers)), nil
+}
+
+func (file *File) readRecordHeader(name string) (recordHeader, error) {
+	offset, ok := file.offsets[name]
+	if !ok {
+		return recordHeader{}, fmt.Errorf("no sequence named %q", name)
+	}
+	var header recordHeader
+	length, err := file.readUint32s(offset, 1)
+	if err != nil {
+		return recordHeader{}, fmt.Errorf("reading %q: %w", name, err)
+	}
+	header.length = int(length[0])
+	header.nBlocks, offset, err = file.readBlocks(offset+4, header.length)
+	if err != nil {
+		return recordHeader{}, fmt.Errorf("reading %q: %w", name, err)
+	}
+	header.maskBlocks, offset, err = file.readBlocks(offset, header.length)
+	if err != nil {
+		return recordHeader{}, fmt.Errorf("reading %q: %w", name, err)
+	}
+	// the reserved field comes before the packed bases.
+	header.dataOffset = offset + 4
+	return header, nil
+}
+
+// readPacked reads the packed bytes from start to end of a record.
+func (file *File) readPacked(header recordHeader, start, end int) ([]byte, error) {
+	data := make([]byte, end-start)
+	if _, err := readAt(file.reader, data, header.dataOffset+int64(start)); err != nil {
+		return nil, err
+	}
+	for index, value := range data {
+		data[index] = toPacked[value]
+	}
+	return data, nil
+}
+
+// Sequence reads the sequence named name.
+func (file *File) Sequence(name string) (packed.Sequence, error) {
+	header, err := file.readRecordHeader(name)
+	if err != nil {
+		return packed.Sequence{}, err
+	}
+	data, err := file.readPacked(header, 0, (header.length+3)/4)
+	if err != nil {
+		return packed.Sequence{}, fmt.Errorf("reading %q: %w", name, err)
+	}
+	sequence, err := packed.FromPacked(header.length, data, header.nBlocks, header.maskBlocks)
+	if err != nil {
+		return packed.Sequence{}, fmt.Errorf("reading %q: %w", name, err)
+	}
+	return sequence, nil
+}
+
+// FetchSubsequence returns the half-open range [start, end) of the sequence
+// named name, zero-indexed, reading only the bytes of the file it's in.
+func (file *File) FetchSubsequence(name string, start, end int) (string, error) {
+	header, err := file.readRecordHeader(name)
+	if err != nil {
+		return "", err
+	}
+	if start < 0 || end < start || end > header.length {
+		return "", fmt.Errorf("range [%d, %d) is outside of %q, which is %d long", start, end, name, header.length)
+	}
+	if start == end {
+		return "", nil
+	}
+	// the bytes the range is in start at the base firstBase.
+	firstByte, lastByte := start/4, (end+3)/4
+	firstBase := firstByte * 4
+	data, err := file.readPacked(header, firstByte, lastByte)
+	if err != nil {
+		return "", fmt.Errorf("reading %q: %w", name, err)
+	}
+	length := min(len(data)*4, header.length-firstBase)
+	window, err := packed.FromPacked(length, data, clip(header.nBlocks, firstBase, length), clip(header.maskBlocks, firstBase, length))
+	if err != nil {
+		return "", fmt.Errorf("reading %q: %w", name, err)
+	}
+	return window.Subsequence(start-firstBase, end-firstBase)
+}
+
+// clip returns the parts of blocks in the length bases from start, moved to
+// start from there.
+func clip(blocks []packed.Block, start, length int) []packed.Block {
+	var clipped []packed.Block
+	for _, block := range blocks {
+		block.Start, block.End = max(block.Start-start, 0), min(block.End-start, length)
+		if block.Start < block.End {
+			clipped = append(clipped, block)
+		}
+	}
+	return clipped
+}
+
+// Parse parses every sequence of a .2bit file, which can be gzipped, though
+// gzipped .2bit files are rare since it barely shrinks them.
+func Parse(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(polyio.Decompress(r))
+	if err != nil {
+		return nil, err
+	}
+	file, err := NewFile(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return file.Records()
+}
+
+// Records reads every sequence of a file.
+func (file *File) Records() ([]Record, error) {
+	records := make([]Record, len(file.names))
+	for index, name := range file.names {
+		sequence, err := file.Sequence(name)
+		if err != nil {
+			return nil, err
+		}
+		records[index] = Record{Name: name, Sequence: sequence}
+	}
+	return records, nil
+}
+
+// Read reads every sequence of the .2bit file at path.
+func Read(path string) ([]Record, error) {
+	file, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return file.Records()
+}
+
+/******************************************************************************
+
+.2bit writer begins here.
+
+******************************************************************************/
+
+// Build builds records into a .2bit file, little endian like UCSC writes
+// them. Files with more than 4 GB of sequence are written as version 1.
+func Build(records []Record) ([]byte, error) {
+	names := map[string]bool{}
+	indexSize := 0
+	recordsSize := int64(0)
+	for _, record := range records {
+		if len(record.Name) == 0 || len(record.Name) > math.MaxUint8 {
+			return nil, fmt.Errorf("sequence names must be 1 to 255 bytes long, got %q", record.Name)
+		}
+		if names[record.Name] {
+			return nil, fmt.Errorf("there's already a sequence named %q", record.Name)
+		}
+		names[record.Name] = true
+		if uint64(record.Sequence.Len()) > math.MaxUint32 {
+			return nil, fmt.Errorf("%q is too long for .2bit", record.Name)
+		}
+		indexSize += 1 + len(record.Name)
+		recordsSize += recordSize(record.Sequence)
+	}
+	version, offsetSize := uint32(0), 4
+	if 16+int64(indexSize+4*len(records))+recordsSize > math.MaxUint32 {
+		version, offsetSize = 1, 8
+	}
+
+	var buffer bytes.Buffer
+	write := func(numbers ...uint32) {
+		for _, number := range numbers {
+			buffer.Write(binary.LittleEndian.AppendUint32(nil, number))
+		}
+	}
+	write(signature, version, uint32(len(records)), 0)
+	offset := int64(16 + indexSize + offsetSize*len(records))
+	for _, record := range records {
+		buffer.WriteByte(byte(len(record.Name)))
+		buffer.WriteString(record.Name)
+		if version == 0 {
+			write(uint32(offset))
+		} else {
+			buffer.Write(binary.LittleEndian.AppendUint64(nil, uint64(offset)))
+		}
+		offset += recordSize(record.Sequence)
+	}
+
+	for _, record := range records {
+		sequence := record.Sequence
+		write(uint32(sequence.Len()))
+		for _, blocks := range [][]packed.Block{sequence.NBlocks(), sequence.MaskBlocks()} {
+			write(uint32(len(blocks)))
+			for _, block := range blocks {
+				write(uint32(block.Start))
+			}
+			for _, block := range blocks {
+				write(uint32(block.End - block.Start))
+			}
+		}
+		write(0)
+		data := make([]byte, len(sequence.Packed()))
+		for index, value := range sequence.Packed() {
+			data[index] = fromPacked[value]
+		}
+		// Ns, and the padding after the last base, are packed as Ts, which
+		// are 0.
+		padding := packed.Block{Start: sequence.Len(), End: 4 * len(data)}
+		for _, block := range append(append([]packed.Block{}, sequence.NBlocks()...), padding) {
+			for position := block.Start; position < block.End; position++ {
+				data[position/4] &^= 3 << (6 - 2*(position%4))
+			}
+		}
+		buffer.Write(data)
+	}
+	return buffer.Bytes(), nil
+}
+
+// recordSize returns how many bytes a sequence's record takes.
+func recordSize(sequence packed.Sequence) int64 {
+	blocks := len(sequence.NBlocks()) + len(sequence.MaskBlocks())
+	return int64(16+8*blocks) + int64(len(sequence.Packed()))
+}
+
+// Write writes records to a .2bit file at path.
+func Write(records []Record, path string) error {
+	output, err := Build(records)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, output, 0644)
+}
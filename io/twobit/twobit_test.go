@@ -0,0 +1,114 @@
+package twobit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bebop/poly/sequence/packed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var exampleSequences = map[string]string{
+	"chrA":  "ACGTACGTTTGACCAnnnnnNNNNNacgtagctagGATTACA",
+	"chrB":  "GGGCCCAAATTT",
+	"empty": "",
+}
+
+func TestRead(t *testing.T) {
+	records, err := Read("data/example.2bit")
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	for index, name := range []string{"chrA", "chrB", "empty"} {
+		assert.Equal(t, name, records[index].Name)
+		assert.Equal(t, exampleSequences[name], records[index].Sequence.String(), name)
+	}
+
+	// building what was read gives back the same file.
+	contents, err := os.ReadFile("data/example.2bit")
+	require.NoError(t, err)
+	built, err := Build(records)
+	require.NoError(t, err)
+	assert.Equal(t, contents, built)
+}
+
+func TestBuild(t *testing.T) {
+	built, err := Build([]Record{{Name: "chr1", Sequence: packed.Pack("ACGTNNacgt")}})
+	require.NoError(t, err)
+	expected := []byte{
+		0x43, 0x27, 0x41, 0x1a, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0,
+		4, 'c', 'h', 'r', '1', 25, 0, 0, 0,
+		10, 0, 0, 0,
+		1, 0, 0, 0, 4, 0, 0, 0, 2, 0, 0, 0,
+		1, 0, 0, 0, 6, 0, 0, 0, 4, 0, 0, 0,
+		0, 0, 0, 0,
+		// TCAG are 0123, and Ns are Ts.
+		0x9c, 0x09, 0xc0,
+	}
+	assert.Equal(t, expected, built)
+
+	// big endian files are read too.
+	bigEndian := append([]byte{}, expected...)
+	for _, offset := range []int{0, 4, 8, 12, 21, 25, 29, 33, 37, 41, 45, 49, 53} {
+		binary.BigEndian.PutUint32(bigEndian[offset:], binary.LittleEndian.Uint32(expected[offset:]))
+	}
+	records, err := Parse(bytes.NewReader(bigEndian))
+	require.NoError(t, err)
+	assert.Equal(t, "ACGTNNacgt", records[0].Sequence.String())
+
+	for _, invalid := range [][]Record{
+		{{Name: "", Sequence: packed.Pack("ACGT")}},
+		{{Name: "chr1"}, {Name: "chr1"}},
+		{{Name: string(make([]byte, 256))}},
+	} {
+		_, err := Build(invalid)
+		assert.Error(t, err)
+	}
+	_, err = Parse(bytes.NewReader([]byte("not a .2bit file at all")))
+	assert.Error(t, err)
+	_, err = Parse(bytes.NewReader(expected[:40]))
+	assert.Error(t, err)
+}
+
+func TestFetchSubsequence(t *testing.T) {
+	file, err := Open("data/example.2bit")
+	require.NoError(t, err)
+	defer file.Close()
+	assert.Equal(t, []string{"chrA", "chrB", "empty"}, file.Names())
+
+	for name, want := range exampleSequences {
+		for start := 0; start <= len(want); start++ {
+			for end := start; end <= len(want); end++ {
+				subsequence, err := file.FetchSubsequence(name, start, end)
+				require.NoError(t, err)
+				assert.Equal(t, want[start:end], subsequence, "%s [%d, %d)", name, start, end)
+			}
+		}
+	}
+
+	_, err = file.FetchSubsequence("chrC", 0, 1)
+	assert.Error(t, err)
+	_, err = file.FetchSubsequence("chrB", 10, 13)
+	assert.Error(t, err)
+	_, err = file.Sequence("chrC")
+	assert.Error(t, err)
+}
+
+func TestWrite(t *testing.T) {
+	records := []Record{
+		{Name: "masked", Sequence: packed.Pack("acgtACGTNNNNnnnnRYKMacgt")},
+		{Name: "plain", Sequence: packed.Pack("GATTACA")},
+	}
+	path := filepath.Join(t.TempDir(), "written.2bit")
+	require.NoError(t, Write(records, path))
+	read, err := Read(path)
+	require.NoError(t, err)
+	require.Len(t, read, len(records))
+	for index, record := range records {
+		assert.Equal(t, record.Name, read[index].Name)
+		assert.True(t, record.Sequence.Equal(read[index].Sequence), record.Name)
+	}
+}
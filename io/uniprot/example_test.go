@@ -19,3 +19,15 @@ func Example_basic() {
 	fmt.Println(entry.Accession[0])
 	// Output: O55723
 }
+
+// This example shows how to turn the features of an entry, like one
+// downloaded with uniprot.Fetch, into GenBank features.
+func ExampleEntry_Features() {
+	entries, _, _ := uniprot.Read("data/uniprot_sprot_mini.xml.gz")
+	entry := <-entries
+
+	for _, feature := range entry.Features() {
+		fmt.Println(feature.Type, feature.Attributes["label"], feature.Location.Start, feature.Location.End)
+	}
+	// Output: mat_peptide Protein MGF 100-1R 0 122
+}
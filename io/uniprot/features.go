@@ -0,0 +1,143 @@
+package uniprot
+
+import (
+	"strings"
+
+	"github.com/bebop/poly/io/genbank"
+)
+
+/******************************************************************************
+
+Feature conversion begins here.
+
+UniProt annotates proteins with features much like GenBank annotates DNA:
+domains, active and binding sites, modified residues, disulfide bonds, and
+so on, each with a location on the sequence. Converting them to
+genbank.Feature lets proteins be worked with by the same code as every other
+annotated sequence in poly.
+
+UniProt feature types are mapped to the protein feature keys of GenPept,
+NCBI's GenBank format for proteins: sites of one or a few residues become
+Site, bonds become Bond, secondary structure becomes SecStr, peptides that
+are cut out of the protein keep their own keys, and everything else becomes
+Region. The UniProt type is kept in the note, with the feature's
+description, and the description is its label.
+
+UniProt positions are 1-based and inclusive, and are converted to poly's
+0-based, half-open locations. Features whose ends UniProt doesn't know are
+left out, and ends that are only known to be before or after a position are
+marked partial.
+
+******************************************************************************/
+
+// featureKeys are the GenPept keys of UniProt feature types that aren't
+// regions.
+var featureKeys = map[Type]string{
+	"active site":                 "Site",
+	"binding site":                "Site",
+	"site":                        "Site",
+	"metal ion-binding site":      "Site",
+	"modified residue":            "Site",
+	"glycosylation site":          "Site",
+	"lipid moiety-binding region": "Site",
+	"non-standard amino acid":     "Site",
+	"mutagenesis site":            "Site",
+	"sequence variant":            "Site",
+	"disulfide bond":              "Bond",
+	"cross-link":                  "Bond",
+	"helix":                       "SecStr",
+	"strand":                      "SecStr",
+	"turn":                        "SecStr",
+	"signal peptide":              "sig_peptide",
+	"transit peptide":             "transit_peptide",
+	"propeptide":                  "propeptide",
+	"chain":                       "mat_peptide",
+	"peptide":                     "mat_peptide",
+}
+
+// Features returns the features of an entry as GenBank features, in the
+// order UniProt lists them.
+func (entry Entry) Features() []genbank.Feature {
+	var features []genbank.Feature
+	for _, feature := range entry.Feature {
+		location, ok := featureLocation(feature)
+		if !ok {
+			continue
+		}
+		key, ok := featureKeys[feature.Type]
+		if !ok {
+			key = "Region"
+		}
+		label := feature.Description
+		if label == "" {
+			label = string(feature.Type)
+		}
+		note := string(feature.Type)
+		if feature.Description != "" {
+			note += ": " + feature.Description
+		}
+		features = append(features, genbank.Feature{
+			Type:       key,
+			Attributes: map[string]string{"label": label, "note": note},
+			Location:   location,
+		})
+	}
+	return features
+}
+
+// featureLocation converts the location of a feature, if both of its ends
+// are known.
+func featureLocation(feature FeatureType) (genbank.Location, bool) {
+	location := feature.Location
+	if location.Position.Position != 0 {
+		position := int(location.Position.Position)
+		return genbank.Location{Start: position - 1, End: position}, true
+	}
+	if location.Begin.Position == 0 || location.End.Position == 0 ||
+		location.Begin.Status == "unknown" || location.End.Status == "unknown" {
+		return genbank.Location{}, false
+	}
+	begin, end := int(location.Begin.Position), int(location.End.Position)
+	if feature.Type == "disulfide bond" || feature.Type == "cross-link" {
+		// bonds join two residues, not the residues between them.
+		return genbank.Location{Join: true, SubLocations: []genbank.Location{{Start: begin - 1, End: begin}, {Start: end - 1, End: end}}}, true
+	}
+	return genbank.Location{
+		Start:             begin - 1,
+		End:               end,
+		FivePrimePartial:  location.Begin.Status == "less than",
+		ThreePrimePartial: location.End.Status == "greater than",
+	}, true
+}
+
+// Genbank returns an entry as a GenBank record of its protein sequence and
+// features.
+func (entry Entry) Genbank() genbank.Genbank {
+	var record genbank.Genbank
+	if len(entry.Accession) > 0 {
+		record.Meta.Accession = entry.Accession[0]
+	}
+	if len(entry.Name) > 0 {
+		record.Meta.Name = entry.Name[0]
+		record.Meta.Locus.Name = entry.Name[0]
+	}
+	record.Meta.Definition = entry.Protein.RecommendedName.FullName.Value
+	if record.Meta.Definition == "" && len(entry.Protein.SubmittedName) > 0 {
+		record.Meta.Definition = entry.Protein.SubmittedName[0].FullName.Value
+	}
+	for _, name := range entry.Organism.Name {
+		if name.Type == "scientific" {
+			record.Meta.Organism = name.Value
+			record.Meta.Source = name.Value
+		}
+	}
+	record.Meta.Taxonomy = entry.Organism.Lineage.Taxon
+	record.Meta.Locus.MoleculeType = "AA"
+	// sequences in data dumps are wrapped over several lines.
+	record.Sequence = strings.Join(strings.Fields(entry.Sequence.Value), "")
+	for _, feature := range entry.Features() {
+		feature := feature
+		_ = record.AddFeature(&feature)
+	}
+	return record
+}
@@ -0,0 +1,150 @@
+package uniprot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testEntry = `<?xml version="1.0" encoding="UTF-8"?>
+<uniprot xmlns="http://uniprot.org/uniprot">
+<entry dataset="Swiss-Prot" created="1986-07-21" modified="2024-01-24" version="10" xmlns="http://uniprot.org/uniprot">
+  <accession>P00000</accession>
+  <name>TEST_ECOLI</name>
+  <protein>
+    <recommendedName>
+      <fullName>Test protein</fullName>
+    </recommendedName>
+  </protein>
+  <organism>
+    <name type="scientific">Escherichia coli</name>
+    <lineage>
+      <taxon>Bacteria</taxon>
+      <taxon>Pseudomonadota</taxon>
+    </lineage>
+  </organism>
+  <feature type="signal peptide">
+    <location>
+      <begin position="1"/>
+      <end position="5"/>
+    </location>
+  </feature>
+  <feature type="domain" description="Test domain">
+    <location>
+      <begin position="6"/>
+      <end position="20"/>
+    </location>
+  </feature>
+  <feature type="active site" description="Nucleophile">
+    <location>
+      <position position="8"/>
+    </location>
+  </feature>
+  <feature type="disulfide bond">
+    <location>
+      <begin position="4"/>
+      <end position="19"/>
+    </location>
+  </feature>
+  <feature type="region of interest" description="Disordered">
+    <location>
+      <begin status="less than" position="15"/>
+      <end status="unknown"/>
+    </location>
+  </feature>
+  <feature type="region of interest" description="Tail">
+    <location>
+      <begin position="19"/>
+      <end status="greater than" position="22"/>
+    </location>
+  </feature>
+  <sequence length="22" mass="2500" checksum="0" modified="1986-07-21" version="1">MKTCAYSAKQRQISFVKSCFSR
+</sequence>
+</entry>
+</uniprot>`
+
+func TestFetch(t *testing.T) {
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requested = request.URL.Path
+		if request.URL.Path != "/P00000.xml" {
+			http.NotFound(writer, request)
+			return
+		}
+		_, _ = writer.Write([]byte(testEntry))
+	}))
+	defer server.Close()
+
+	entry, err := Fetch(" P00000 ", FetchOptions{URL: server.URL + "/", Client: server.Client()})
+	require.NoError(t, err)
+	assert.Equal(t, "/P00000.xml", requested)
+	assert.Equal(t, []string{"P00000"}, entry.Accession)
+	assert.Equal(t, "Test protein", entry.Protein.RecommendedName.FullName.Value)
+
+	_, err = Fetch("Q99999", FetchOptions{URL: server.URL, Client: server.Client()})
+	assert.ErrorContains(t, err, "404")
+
+	_, err = Fetch("", FetchOptions{URL: server.URL})
+	assert.Error(t, err)
+}
+
+func TestDecodeEntryEmpty(t *testing.T) {
+	_, err := decodeEntry(strings.NewReader(`<uniprot xmlns="http://uniprot.org/uniprot"></uniprot>`))
+	assert.ErrorContains(t, err, "no entry")
+}
+
+func TestFeatures(t *testing.T) {
+	entry, err := decodeEntry(strings.NewReader(testEntry))
+	require.NoError(t, err)
+
+	features := entry.Features()
+	// the disordered region's end is unknown, so it's left out.
+	require.Len(t, features, 5)
+
+	assert.Equal(t, "sig_peptide", features[0].Type)
+	assert.Equal(t, "signal peptide", features[0].Attributes["label"])
+	assert.Equal(t, 0, features[0].Location.Start)
+	assert.Equal(t, 5, features[0].Location.End)
+
+	assert.Equal(t, "Region", features[1].Type)
+	assert.Equal(t, "Test domain", features[1].Attributes["label"])
+	assert.Equal(t, "domain: Test domain", features[1].Attributes["note"])
+
+	assert.Equal(t, "Site", features[2].Type)
+	assert.Equal(t, 7, features[2].Location.Start)
+	assert.Equal(t, 8, features[2].Location.End)
+
+	assert.Equal(t, "Bond", features[3].Type)
+	assert.True(t, features[3].Location.Join)
+	require.Len(t, features[3].Location.SubLocations, 2)
+	assert.Equal(t, 3, features[3].Location.SubLocations[0].Start)
+	assert.Equal(t, 18, features[3].Location.SubLocations[1].Start)
+
+	assert.True(t, features[4].Location.ThreePrimePartial)
+	assert.False(t, features[4].Location.FivePrimePartial)
+}
+
+func TestGenbank(t *testing.T) {
+	entry, err := decodeEntry(strings.NewReader(testEntry))
+	require.NoError(t, err)
+
+	record := entry.Genbank()
+	assert.Equal(t, "P00000", record.Meta.Accession)
+	assert.Equal(t, "TEST_ECOLI", record.Meta.Name)
+	assert.Equal(t, "Test protein", record.Meta.Definition)
+	assert.Equal(t, "Escherichia coli", record.Meta.Organism)
+	assert.Equal(t, []string{"Bacteria", "Pseudomonadota"}, record.Meta.Taxonomy)
+	assert.Equal(t, "MKTCAYSAKQRQISFVKSCFSR", record.Sequence)
+	require.Len(t, record.Features, 5)
+
+	domain, err := record.Features[1].GetSequence()
+	require.NoError(t, err)
+	assert.Equal(t, "YSAKQRQISFVKSCF", domain)
+	bond, err := record.Features[3].GetSequence()
+	require.NoError(t, err)
+	assert.Equal(t, "CC", bond)
+}
@@ -16,14 +16,17 @@ Each protein in Uniprot is known as an "Entry" (as defined in xml.go).
 
 The function Parse stream-reads Uniprot into an Entry channel, from which you
 can use the entries however you want. Read simplifies reading gzipped files
-from a disk into an Entry channel.
+from a disk into an Entry channel, and Fetch downloads a single entry from
+UniProt's REST API. Features and Genbank convert an entry's annotations into
+GenBank features, to be used like the features of any other sequence.
 */
 package uniprot
 
 import (
-	"compress/gzip"
 	"encoding/xml"
 	"os"
+
+	polyio "github.com/bebop/poly/io"
 )
 
 /******************************************************************************
@@ -62,7 +65,7 @@ type Decoder interface {
 	Token() (xml.Token, error)
 }
 
-// Read reads a gzipped Uniprot XML dump. Failing to open the XML dump
+// Read reads a Uniprot XML dump, which is usually gzipped. Failing to open the XML dump
 // gives a single error, while errors encountered while decoding the XML dump
 // are added to the errors channel.
 func Read(path string) (chan Entry, chan error, error) {
@@ -72,11 +75,7 @@ func Read(path string) (chan Entry, chan error, error) {
 	if err != nil {
 		return entries, decoderErrors, err
 	}
-	unzippedBytes, err := gzip.NewReader(xmlFile)
-	if err != nil {
-		return entries, decoderErrors, err
-	}
-	decoder := xml.NewDecoder(unzippedBytes)
+	decoder := xml.NewDecoder(polyio.Decompress(xmlFile))
 	go Parse(decoder, entries, decoderErrors)
 	return entries, decoderErrors, nil
 }
@@ -0,0 +1,362 @@
+package primers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/bebop/poly/checks"
+	"github.com/bebop/poly/fold"
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/stats"
+	"github.com/bebop/poly/transform"
+	"github.com/bebop/poly/warning"
+)
+
+/******************************************************************************
+
+Primer design begins here.
+
+pcr.DesignPrimers picks the shortest primers at each end of a sequence that
+reach a target melting temperature, which is all you need to amplify a whole
+part. Design is for when you want a specific region out of a larger template
+and would like primers that are actually good: it tries every primer that
+binds within a flank on either side of the region, throws out the ones whose
+melting temperature, GC content, homopolymers, self-dimer, or hairpin are
+out of bounds, and pairs up the rest, best first.
+
+Melting temperatures come from Tm, with its salt corrections. Self-dimers are
+scored with fold.Duplex of a primer against itself, and hairpins with
+fold.Zuker, both as free energies at 37 °C, the usual reference temperature
+for oligo secondary structure.
+
+A primer's penalty is how far its melting temperature is from the optimum,
+plus a degree for not ending in a G or C (a "GC clamp", which helps the 3' end
+stay bound), plus a degree for every kcal/mol of dimer or hairpin beyond -3.
+A pair's penalty adds up its primers' and the difference between their
+melting temperatures, and pairs whose primers dimerize with each other are
+skipped.
+
+******************************************************************************/
+
+// DesignOptions changes how primers are designed. Zero values are replaced
+// with the defaults noted on each field.
+type DesignOptions struct {
+	MinLength, MaxLength int     // default to 18 and 25.
+	MinTm, MaxTm         float64 // default to 57 and 63 °C.
+	OptimalTm            float64 // defaults to 60 °C.
+	MaxTmDifference      float64 // most the Tm of a pair's primers can differ by. Defaults to 3 °C.
+	MinGC, MaxGC         float64 // default to 0.4 and 0.6.
+	// MaxHomopolymer is the longest run of a single base a primer can have.
+	// Defaults to 5.
+	MaxHomopolymer int
+	// MinDimerEnergy is the most stable a primer's self-dimer, or a pair's
+	// heterodimer, can be, in kcal/mol. Defaults to -9.
+	MinDimerEnergy float64
+	// MinHairpinEnergy is the most stable a primer's hairpin can be, in
+	// kcal/mol. Defaults to -3.
+	MinHairpinEnergy float64
+	// Flank is how far outside the target region primers can bind. Defaults
+	// to 100.
+	Flank      int
+	Conditions Conditions // defaults to DefaultConditions.
+	Pairs      int        // most pairs to return. Defaults to 5.
+}
+
+// Primer is a designed primer.
+type Primer struct {
+	Sequence string
+	// Start and End are the 0-based, half open span of the template the
+	// primer binds, on the top strand even for reverse primers.
+	Start, End int
+	Forward    bool
+	Tm         float64
+	GC         float64 // fraction of G and C.
+	SelfDimer  float64 // free energy of the most stable self-dimer, in kcal/mol.
+	Hairpin    float64 // free energy of the most stable hairpin, in kcal/mol.
+	Penalty    float64
+}
+
+// PrimerPair is a forward and reverse primer that amplify a target region.
+type PrimerPair struct {
+	Forward, Reverse Primer
+	ProductLength    int
+	HeteroDimer      float64 // free energy of the most stable dimer between the primers, in kcal/mol.
+	Penalty          float64
+}
+
+// dimerWarning and hairpinWarning are the free energies, in kcal/mol, below
+// which a primer's dimers and hairpins are worth a warning even if they're
+// within a design's constraints.
+const (
+	dimerWarning   = -6
+	hairpinWarning = -2
+)
+
+// structureTemp is the temperature in °C dimers and hairpins are scored at.
+const structureTemp = 37
+
+// maxCandidates is how many of the best primers on each side are tried in
+// pairs.
+const maxCandidates = 50
+
+// Design designs primer pairs that amplify the 0-based, half open target
+// region [start, end) of template, ranked from best to worst. The forward
+// primer binds before the region and the reverse primer after it.
+func Design(template string, start, end int, options DesignOptions) ([]PrimerPair, error) {
+	options = designDefaults(options)
+
+	template = strings.ToUpper(template)
+	if start < 0 || end > len(template) || start >= end {
+		return nil, fmt.Errorf("target region [%d, %d) isn't within the template of length %d", start, end, len(template))
+	}
+	if options.MinLength > options.MaxLength || options.MinLength < 2 {
+		return nil, fmt.Errorf("primer lengths from %d to %d aren't a valid range", options.MinLength, options.MaxLength)
+	}
+
+	var forwards, reverses []Primer
+	for primerStart := max(0, start-options.Flank); primerStart < start; primerStart++ {
+		for length := options.MinLength; length <= options.MaxLength && primerStart+length <= start; length++ {
+			if primer, ok := candidate(template[primerStart:primerStart+length], primerStart, true, options); ok {
+				forwards = append(forwards, primer)
+			}
+		}
+	}
+	for primerEnd := min(len(template), end+options.Flank); primerEnd > end; primerEnd-- {
+		for length := options.MinLength; length <= options.MaxLength && primerEnd-length >= end; length++ {
+			sequence := transform.ReverseComplement(template[primerEnd-length : primerEnd])
+			if primer, ok := candidate(sequence, primerEnd-length, false, options); ok {
+				reverses = append(reverses, primer)
+			}
+		}
+	}
+	if len(forwards) == 0 || len(reverses) == 0 {
+		return nil, fmt.Errorf("found %d forward and %d reverse primers meeting the constraints, try a larger flank or looser constraints", len(forwards), len(reverses))
+	}
+	byPenalty := func(primers []Primer) {
+		sort.SliceStable(primers, func(i, j int) bool { return primers[i].Penalty < primers[j].Penalty })
+	}
+	byPenalty(forwards)
+	byPenalty(reverses)
+
+	var pairs []PrimerPair
+	for _, forward := range forwards[:min(len(forwards), maxCandidates)] {
+		for _, reverse := range reverses[:min(len(reverses), maxCandidates)] {
+			difference := math.Abs(forward.Tm - reverse.Tm)
+			if difference > options.MaxTmDifference {
+				continue
+			}
+			pairs = append(pairs, PrimerPair{
+				Forward:       forward,
+				Reverse:       reverse,
+				ProductLength: reverse.End - forward.Start,
+				Penalty:       forward.Penalty + reverse.Penalty + difference,
+			})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		if pairs[i].Penalty != pairs[j].Penalty {
+			return pairs[i].Penalty < pairs[j].Penalty
+		}
+		return pairs[i].ProductLength < pairs[j].ProductLength
+	})
+
+	// heterodimers are only checked for as many pairs as it takes, best first.
+	var designed []PrimerPair
+	for _, pair := range pairs {
+		pair.HeteroDimer = duplexEnergy(pair.Forward.Sequence, pair.Reverse.Sequence)
+		if pair.HeteroDimer < options.MinDimerEnergy {
+			continue
+		}
+		designed = append(designed, pair)
+		if len(designed) == options.Pairs {
+			break
+		}
+	}
+	if len(designed) == 0 {
+		return nil, fmt.Errorf("no forward and reverse primers pair up within %g °C of each other without dimerizing", options.MaxTmDifference)
+	}
+	return designed, nil
+}
+
+// designDefaults replaces the zero values of options with their defaults.
+func designDefaults(options DesignOptions) DesignOptions {
+	if options.MinLength == 0 && options.MaxLength == 0 {
+		options.MinLength, options.MaxLength = 18, 25
+	}
+	if options.MinTm == 0 && options.MaxTm == 0 {
+		options.MinTm, options.MaxTm = 57, 63
+	}
+	if options.OptimalTm == 0 {
+		options.OptimalTm = (options.MinTm + options.MaxTm) / 2
+	}
+	if options.MaxTmDifference == 0 {
+		options.MaxTmDifference = 3
+	}
+	if options.MinGC == 0 && options.MaxGC == 0 {
+		options.MinGC, options.MaxGC = 0.4, 0.6
+	}
+	if options.MaxHomopolymer == 0 {
+		options.MaxHomopolymer = 5
+	}
+	if options.MinDimerEnergy == 0 {
+		options.MinDimerEnergy = -9
+	}
+	if options.MinHairpinEnergy == 0 {
+		options.MinHairpinEnergy = -3
+	}
+	if options.Flank == 0 {
+		options.Flank = 100
+	}
+	if options.Conditions == (Conditions{}) {
+		options.Conditions = DefaultConditions
+	}
+	if options.Pairs == 0 {
+		options.Pairs = 5
+	}
+	return options
+}
+
+// candidate scores a primer, and checks it against every constraint.
+func candidate(sequence string, start int, forward bool, options DesignOptions) (Primer, bool) {
+	primer := Primer{Sequence: sequence, Start: start, End: start + len(sequence), Forward: forward}
+	primer.GC = checks.GcContent(sequence)
+	if primer.GC < options.MinGC || primer.GC > options.MaxGC {
+		return primer, false
+	}
+	if options.MaxHomopolymer > 0 && len(stats.Homopolymers(sequence, options.MaxHomopolymer+1)) > 0 {
+		return primer, false
+	}
+	tm, err := Tm(sequence, options.Conditions)
+	if err != nil || tm < options.MinTm || tm > options.MaxTm {
+		return primer, false
+	}
+	primer.Tm = tm
+
+	// the folding is the slow part, so it goes last.
+	primer.SelfDimer = duplexEnergy(sequence, sequence)
+	if primer.SelfDimer < options.MinDimerEnergy {
+		return primer, false
+	}
+	primer.Hairpin = hairpinEnergy(sequence)
+	if primer.Hairpin < options.MinHairpinEnergy {
+		return primer, false
+	}
+
+	primer.Penalty = math.Abs(tm-options.OptimalTm) + structurePenalty(primer.SelfDimer) + structurePenalty(primer.Hairpin)
+	if last := sequence[len(sequence)-1]; last != 'G' && last != 'C' {
+		primer.Penalty++
+	}
+	return primer, true
+}
+
+// structurePenalty penalizes structures more stable than -3 kcal/mol.
+func structurePenalty(energy float64) float64 {
+	return max(0, -3-energy)
+}
+
+// duplexEnergy is the free energy of the most stable duplex of two primers,
+// or 0 if they don't pair.
+func duplexEnergy(first, second string) float64 {
+	duplex, err := fold.Duplex(first, second, structureTemp)
+	if err != nil {
+		return 0
+	}
+	return min(duplex.Energy, 0)
+}
+
+// hairpinEnergy is the free energy of the most stable hairpin of a primer,
+// or 0 if it doesn't fold.
+func hairpinEnergy(sequence string) float64 {
+	result, err := fold.Zuker(sequence, structureTemp)
+	if err != nil {
+		return 0
+	}
+	energy := result.MinimumFreeEnergy()
+	if math.IsInf(energy, 0) {
+		return 0
+	}
+	return min(energy, 0)
+}
+
+// DesignFeature designs primer pairs that amplify a feature of a record, found
+// by its label, gene, locus_tag, or product qualifier.
+func DesignFeature(record genbank.Genbank, name string, options DesignOptions) ([]PrimerPair, error) {
+	start, end, err := FeatureRegion(record, name)
+	if err != nil {
+		return nil, err
+	}
+	return Design(record.Sequence, start, end, options)
+}
+
+// FeatureRegion returns the 0-based, half open region of a record spanned by
+// the first feature with a label, gene, locus_tag, or product qualifier of
+// name.
+func FeatureRegion(record genbank.Genbank, name string) (start, end int, err error) {
+	for _, feature := range record.Features {
+		named := false
+		for _, attribute := range []string{"label", "gene", "locus_tag", "product"} {
+			if feature.Attributes[attribute] == name {
+				named = true
+				break
+			}
+		}
+		if !named {
+			continue
+		}
+		start, end, crossesOrigin := regionOf(feature.Location)
+		if crossesOrigin {
+			return 0, 0, fmt.Errorf("feature %s crosses the origin, which primers can't be designed around", name)
+		}
+		return start, end, nil
+	}
+	return 0, 0, fmt.Errorf("no feature is named %s", name)
+}
+
+// regionOf returns the span of a location, from the lowest start of its parts
+// to the highest end, and whether it crosses the origin. Joins list parts on
+// the top strand in ascending order, and parts on the bottom strand, like
+// join(complement(500..600),complement(100..200)), in descending order, so a
+// part listed the other way has started again from the origin.
+func regionOf(location genbank.Location) (start, end int, crossesOrigin bool) {
+	if len(location.SubLocations) == 0 {
+		return location.Start, location.End, false
+	}
+	start, end, crossesOrigin = regionOf(location.SubLocations[0])
+	previousStart := start
+	for _, subLocation := range location.SubLocations[1:] {
+		subStart, subEnd, subCrossesOrigin := regionOf(subLocation)
+		if subCrossesOrigin || (subLocation.Complement && subStart > previousStart) || (!subLocation.Complement && subStart < previousStart) {
+			crossesOrigin = true
+		}
+		start, end, previousStart = min(start, subStart), max(end, subEnd), subStart
+	}
+	return start, end, crossesOrigin
+}
+
+// Warnings lists what might go wrong with a primer: a stable self-dimer or
+// hairpin, or a 3' end without a GC clamp.
+func (primer Primer) Warnings() []warning.Warning {
+	var warnings []warning.Warning
+	if primer.SelfDimer < dimerWarning {
+		warnings = append(warnings, warning.New("%s forms a self-dimer of %.1f kcal/mol", primer.Sequence, primer.SelfDimer))
+	}
+	if primer.Hairpin < hairpinWarning {
+		warnings = append(warnings, warning.New("%s forms a hairpin of %.1f kcal/mol", primer.Sequence, primer.Hairpin))
+	}
+	if length := len(primer.Sequence); length > 0 && primer.Sequence[length-1] != 'G' && primer.Sequence[length-1] != 'C' {
+		warnings = append(warnings, warning.New("%s doesn't end in a G or C", primer.Sequence))
+	}
+	return warnings
+}
+
+// Warnings lists what might go wrong with a primer pair: its primers'
+// warnings, and a stable heterodimer.
+func (pair PrimerPair) Warnings() []warning.Warning {
+	warnings := append(pair.Forward.Warnings(), pair.Reverse.Warnings()...)
+	if pair.HeteroDimer < dimerWarning {
+		warnings = append(warnings, warning.New("%s and %s form a heterodimer of %.1f kcal/mol", pair.Forward.Sequence, pair.Reverse.Sequence, pair.HeteroDimer))
+	}
+	return warnings
+}
@@ -0,0 +1,176 @@
+package primers
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/transform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDesign(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	template := make([]byte, 1000)
+	for position := range template {
+		template[position] = "ACGT"[random.Intn(4)]
+	}
+	sequence := string(template)
+
+	pairs, err := Design(sequence, 400, 600, DesignOptions{})
+	require.NoError(t, err)
+	require.Len(t, pairs, 5)
+	for index, pair := range pairs {
+		forward, reverse := pair.Forward, pair.Reverse
+		assert.True(t, forward.Forward)
+		assert.False(t, reverse.Forward)
+		assert.Equal(t, sequence[forward.Start:forward.End], forward.Sequence)
+		assert.Equal(t, transform.ReverseComplement(sequence[reverse.Start:reverse.End]), reverse.Sequence)
+		assert.LessOrEqual(t, forward.End, 400)
+		assert.GreaterOrEqual(t, forward.Start, 300)
+		assert.GreaterOrEqual(t, reverse.Start, 600)
+		assert.LessOrEqual(t, reverse.End, 700)
+		assert.Equal(t, reverse.End-forward.Start, pair.ProductLength)
+		for _, primer := range []Primer{forward, reverse} {
+			assert.True(t, primer.Tm >= 57 && primer.Tm <= 63, primer.Tm)
+			assert.True(t, primer.GC >= 0.4 && primer.GC <= 0.6, primer.GC)
+			assert.True(t, len(primer.Sequence) >= 18 && len(primer.Sequence) <= 25)
+			assert.GreaterOrEqual(t, primer.SelfDimer, -9.0)
+			assert.GreaterOrEqual(t, primer.Hairpin, -3.0)
+		}
+		assert.LessOrEqual(t, forward.Tm-reverse.Tm, 3.0)
+		assert.GreaterOrEqual(t, forward.Tm-reverse.Tm, -3.0)
+		if index > 0 {
+			assert.GreaterOrEqual(t, pair.Penalty, pairs[index-1].Penalty)
+		}
+	}
+
+	_, err = Design(sequence, 600, 400, DesignOptions{})
+	assert.Error(t, err)
+	_, err = Design(sequence, 400, 1200, DesignOptions{})
+	assert.Error(t, err)
+	_, err = Design(sequence, 400, 600, DesignOptions{MinGC: 0.9, MaxGC: 1})
+	assert.Error(t, err)
+	_, err = Design(sequence, 400, 600, DesignOptions{MinLength: 30, MaxLength: 20})
+	assert.Error(t, err)
+}
+
+func TestCandidate(t *testing.T) {
+	options := DesignOptions{MinTm: 40, MaxTm: 80, OptimalTm: 60, MinGC: 0, MaxGC: 1, MinDimerEnergy: -9, MinHairpinEnergy: -3, Conditions: DefaultConditions}
+
+	primer, ok := candidate("ATGACCATGATTACGCCAAGC", 0, true, options)
+	assert.True(t, ok)
+	assert.Equal(t, 21, primer.End)
+
+	// a strong hairpin.
+	_, ok = candidate("GGGGCCCCTTTTGGGGCCCC", 0, true, options)
+	assert.False(t, ok)
+
+	// a long homopolymer.
+	options.MaxHomopolymer = 4
+	_, ok = candidate("ATGACCATGAAAAATACGCCAAGC", 0, true, options)
+	assert.False(t, ok)
+	_, ok = candidate("ATGACCATGAAAATACGCCAAGC", 0, true, options)
+	assert.True(t, ok)
+	options.MaxHomopolymer = 0
+
+	// without a GC clamp.
+	clamped, _ := candidate("ATGACCATGATTACGCCAAGC", 0, true, options)
+	unclamped, _ := candidate("TGACCATGATTACGCCAAGCA", 0, true, options)
+	assert.Greater(t, unclamped.Penalty, clamped.Penalty-1)
+
+	assert.Equal(t, 0.0, hairpinEnergy(strings.Repeat("A", 20)))
+}
+
+func TestDesignFeature(t *testing.T) {
+	record, err := genbank.Read("../data/puc19.gbk")
+	require.NoError(t, err)
+
+	start, end, err := FeatureRegion(record, "MCS")
+	require.NoError(t, err)
+	pairs, err := DesignFeature(record, "MCS", DesignOptions{})
+	require.NoError(t, err)
+	assert.LessOrEqual(t, pairs[0].Forward.End, start)
+	assert.GreaterOrEqual(t, pairs[0].Reverse.Start, end)
+
+	// found by its gene qualifier instead of its label.
+	_, _, err = FeatureRegion(record, "bla")
+	assert.NoError(t, err)
+
+	_, err = DesignFeature(record, "not a feature", DesignOptions{})
+	assert.Error(t, err)
+
+	// joins span all of their parts, whichever order they're listed in.
+	for _, test := range []struct {
+		location      string
+		start, end    int
+		crossesOrigin bool
+	}{
+		{"join(100..200,500..600)", 99, 600, false},
+		{"complement(join(100..200,500..600))", 99, 600, false},
+		{"join(complement(500..600),complement(100..200))", 99, 600, false},
+		{"join(2000..2686,1..100)", 0, 2686, true},
+		{"join(complement(1..100),complement(2000..2686))", 0, 2686, true},
+	} {
+		location, err := genbank.ParseLocation(test.location)
+		require.NoError(t, err)
+		start, end, crossesOrigin := regionOf(location)
+		assert.Equal(t, []int{test.start, test.end}, []int{start, end}, test.location)
+		assert.Equal(t, test.crossesOrigin, crossesOrigin, test.location)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	primer := Primer{Sequence: "GGGGCCCCTTTTGGGGCCCA", SelfDimer: -12, Hairpin: -4}
+	assert.Len(t, primer.Warnings(), 3)
+	assert.Empty(t, Primer{Sequence: "ATGACCATGATTACGCCAAGC", SelfDimer: -3, Hairpin: -1}.Warnings())
+	assert.Empty(t, Primer{}.Warnings())
+
+	pair := PrimerPair{Forward: Primer{Sequence: "ATGACCATGATTACGCCAAGC"}, Reverse: primer, HeteroDimer: -10}
+	warnings := pair.Warnings()
+	require.Len(t, warnings, 4)
+	assert.Contains(t, warnings[3].Message, "heterodimer")
+}
+
+func TestDesignSequencing(t *testing.T) {
+	random := rand.New(rand.NewSource(2))
+	template := make([]byte, 3000)
+	for position := range template {
+		template[position] = "ACGT"[random.Intn(4)]
+	}
+	sequence := string(template)
+
+	for _, reverse := range []bool{false, true} {
+		primers, err := DesignSequencing(sequence, 500, 2500, SequencingOptions{Reverse: reverse})
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, len(primers), 3)
+		// every base of the region is read by some primer.
+		read := make([]bool, len(sequence))
+		for _, primer := range primers {
+			assert.Equal(t, !reverse, primer.Forward)
+			if reverse {
+				assert.Equal(t, transform.ReverseComplement(sequence[primer.Start:primer.End]), primer.Sequence)
+				for position := max(primer.Start-800, 0); position < primer.Start-50; position++ {
+					read[position] = true
+				}
+			} else {
+				assert.Equal(t, sequence[primer.Start:primer.End], primer.Sequence)
+				for position := primer.End + 50; position < min(primer.End+800, len(sequence)); position++ {
+					read[position] = true
+				}
+			}
+		}
+		for position := 500; position < 2500; position++ {
+			require.True(t, read[position], position)
+		}
+	}
+
+	_, err := DesignSequencing(sequence, 10, 500, SequencingOptions{})
+	assert.Error(t, err)
+	_, err = DesignSequencing(sequence, 500, 400, SequencingOptions{})
+	assert.Error(t, err)
+	_, err = DesignSequencing(sequence, 500, 2500, SequencingOptions{ReadLength: 40})
+	assert.Error(t, err)
+}
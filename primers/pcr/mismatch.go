@@ -0,0 +1,229 @@
+package pcr
+
+import (
+	"fmt"
+	"index/suffixarray"
+	"sort"
+	"strings"
+
+	"github.com/bebop/poly/primers"
+	"github.com/bebop/poly/transform"
+)
+
+/******************************************************************************
+
+Mismatch tolerant PCR begins here.
+
+Simulate only finds primers that bind their template perfectly, but primers
+don't need to. A primer with a mismatch or two in its 5' half will usually
+still amplify, which is how off-target products show up in a gel. What a
+polymerase won't tolerate is a mismatch near the 3' end, since the end of the
+primer has to be paired for it to extend. So Amplify finds every place a
+primer binds with up to a few mismatches, as long as its last few bases are a
+perfect match, and predicts the products of every pair of sites that point
+towards each other.
+
+Like Simulate, only the 3' end of a primer that's needed to reach the target
+melting temperature has to bind. Anything 5' of that is a tail, like a
+restriction site or an assembly overhang, and ends up in the product.
+
+******************************************************************************/
+
+// Options changes how Amplify finds binding sites and amplicons. Zero values
+// are replaced with the defaults noted on each field.
+type Options struct {
+	TargetTm float64 // melting temperature the binding part of a primer needs. Defaults to 55 °C.
+	// MaxMismatches is the most mismatches a primer can have with a binding
+	// site.
+	MaxMismatches int
+	// AnchorLength is how many bases at the 3' end of a primer have to match
+	// perfectly. Defaults to 5.
+	AnchorLength int
+	// MaxLength is the longest amplicon predicted, where 0 means no limit.
+	MaxLength int
+	Circular  bool
+}
+
+// BindingSite is a place a primer binds its template.
+type BindingSite struct {
+	Primer int // index of the primer.
+	// Start and End are the 0-based, half open span of the template the
+	// binding part of the primer pairs with, on the top strand. For circular
+	// templates a site can span the origin, in which case End is less than
+	// Start.
+	Start, End int
+	Forward    bool // whether the primer's sequence is on the top strand.
+	Mismatches int
+}
+
+// Amplicon is a predicted product of a pair of binding sites.
+type Amplicon struct {
+	Forward, Reverse BindingSite
+	// Start and End are the 0-based, half open span of the template that's
+	// amplified, from the start of the forward site to the end of the
+	// reverse. For circular templates an amplicon can span the origin, in
+	// which case End is less than Start.
+	Start, End int
+	Sequence   string // product sequence, including primer tails.
+	Mismatches int    // total mismatches of both primers.
+}
+
+// FindBindingSites finds every site on both strands of template where a
+// primer binds with at most options.MaxMismatches mismatches and a perfectly
+// matching 3' anchor, ordered by position.
+func FindBindingSites(template string, primerList []string, options Options) ([]BindingSite, error) {
+	options = withDefaults(options)
+	template = strings.ToUpper(template)
+	searched := template
+	if options.Circular {
+		// sites spanning the origin are found in the wrapped around copy.
+		longest := 0
+		for _, primer := range primerList {
+			longest = max(longest, len(primer))
+		}
+		searched += template[:min(len(template), longest)]
+	}
+	index := suffixarray.New([]byte(searched))
+
+	var sites []BindingSite
+	for primerIndex, primer := range primerList {
+		binding := bindingRegion(strings.ToUpper(primer), options.TargetTm)
+		if len(binding) < options.AnchorLength {
+			return nil, fmt.Errorf("primer %d is shorter than the %d base anchor", primerIndex, options.AnchorLength)
+		}
+		for _, forward := range []bool{true, false} {
+			site := binding
+			if !forward {
+				site = transform.ReverseComplement(binding)
+			}
+			// the anchor is the 3' end of the primer, which is the start of
+			// its reverse complement.
+			anchorOffset := len(site) - options.AnchorLength
+			if !forward {
+				anchorOffset = 0
+			}
+			for _, anchorPosition := range index.Lookup([]byte(site[anchorOffset:anchorOffset+options.AnchorLength]), -1) {
+				start := anchorPosition - anchorOffset
+				if start < 0 || start+len(site) > len(searched) || start >= len(template) {
+					continue
+				}
+				mismatches := 0
+				for offset := 0; offset < len(site) && mismatches <= options.MaxMismatches; offset++ {
+					if site[offset] != searched[start+offset] {
+						mismatches++
+					}
+				}
+				if mismatches > options.MaxMismatches {
+					continue
+				}
+				end := start + len(site)
+				if end > len(template) {
+					end -= len(template)
+				}
+				sites = append(sites, BindingSite{Primer: primerIndex, Start: start, End: end, Forward: forward, Mismatches: mismatches})
+			}
+		}
+	}
+	sort.Slice(sites, func(i, j int) bool {
+		if sites[i].Start != sites[j].Start {
+			return sites[i].Start < sites[j].Start
+		}
+		if sites[i].Forward != sites[j].Forward {
+			return sites[i].Forward
+		}
+		return sites[i].Primer < sites[j].Primer
+	})
+	return sites, nil
+}
+
+// Amplify predicts the amplicons of a PCR with mismatch tolerant primers.
+// Every forward binding site is paired with the closest reverse sites
+// downstream of it, since the polymerase never gets further than those.
+func Amplify(template string, primerList []string, options Options) ([]Amplicon, error) {
+	options = withDefaults(options)
+	template = strings.ToUpper(template)
+	sites, err := FindBindingSites(template, primerList, options)
+	if err != nil {
+		return nil, err
+	}
+	var forwards, reverses []BindingSite
+	for _, site := range sites {
+		if site.Forward {
+			forwards = append(forwards, site)
+		} else {
+			reverses = append(reverses, site)
+		}
+	}
+
+	doubled := template + template
+	var amplicons []Amplicon
+	for _, forward := range forwards {
+		// length is the length of the product of a reverse site, or -1 if
+		// the reverse site doesn't face the forward one.
+		length := func(reverse BindingSite) int {
+			distance := reverse.Start - forward.Start
+			if options.Circular && distance < 0 {
+				distance += len(template)
+			}
+			length := distance + siteLength(reverse, len(template))
+			if distance < siteLength(forward, len(template)) || length > len(template) || (options.MaxLength > 0 && length > options.MaxLength) {
+				return -1
+			}
+			return length
+		}
+		closest := -1
+		for _, reverse := range reverses {
+			if productLength := length(reverse); productLength != -1 && (closest == -1 || productLength < closest) {
+				closest = productLength
+			}
+		}
+		if closest == -1 {
+			continue
+		}
+		for _, reverse := range reverses {
+			if length(reverse) != closest {
+				continue
+			}
+			insert := doubled[forward.Start+siteLength(forward, len(template)) : forward.Start+closest-siteLength(reverse, len(template))]
+			amplicons = append(amplicons, Amplicon{
+				Forward:    forward,
+				Reverse:    reverse,
+				Start:      forward.Start,
+				End:        reverse.End,
+				Sequence:   strings.ToUpper(primerList[forward.Primer]) + insert + transform.ReverseComplement(strings.ToUpper(primerList[reverse.Primer])),
+				Mismatches: forward.Mismatches + reverse.Mismatches,
+			})
+		}
+	}
+	return amplicons, nil
+}
+
+// withDefaults fills in the zero values of options.
+func withDefaults(options Options) Options {
+	if options.TargetTm == 0 {
+		options.TargetTm = 55
+	}
+	if options.AnchorLength == 0 {
+		options.AnchorLength = 5
+	}
+	return options
+}
+
+// bindingRegion is the shortest 3' end of a primer that reaches a target
+// melting temperature, or the whole primer if none does.
+func bindingRegion(primer string, targetTm float64) string {
+	for length := min(minimalPrimerLength, len(primer)); length < len(primer); length++ {
+		if primers.MeltingTemp(primer[len(primer)-length:]) >= targetTm {
+			return primer[len(primer)-length:]
+		}
+	}
+	return primer
+}
+
+// siteLength is the length of a binding site on a template of length.
+func siteLength(site BindingSite, length int) int {
+	if site.End <= site.Start {
+		return site.End + length - site.Start
+	}
+	return site.End - site.Start
+}
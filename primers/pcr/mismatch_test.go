@@ -0,0 +1,94 @@
+package pcr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAmplify(t *testing.T) {
+	forwardPrimer := "TTATAGGTCTCATACTAATAATTACACCGAGATAACACATCATGG"
+	reversePrimer := "TATATGGTCTCTTCATTTAAGAAAGCGCATTTTCCAGC"
+	fragments, _ := Simulate([]string{gene}, 55.0, false, []string{forwardPrimer, reversePrimer})
+
+	amplicons, err := Amplify(gene, []string{forwardPrimer, reversePrimer}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(amplicons) != 1 {
+		t.Fatalf("Expected one amplicon, got %d", len(amplicons))
+	}
+	amplicon := amplicons[0]
+	if amplicon.Sequence != fragments[0] {
+		t.Errorf("Amplicon doesn't match Simulate. Expected: %s, got: %s", fragments[0], amplicon.Sequence)
+	}
+	// only the 3' ends of the primers needed to reach 55 °C count as binding.
+	if amplicon.Start != 7 || amplicon.End != 614 || amplicon.Mismatches != 0 {
+		t.Errorf("Expected an amplicon from 7 to 614 without mismatches, got %+v", amplicon)
+	}
+	if amplicon.Forward.Primer != 0 || amplicon.Reverse.Primer != 1 {
+		t.Errorf("Primers are mixed up: %+v", amplicon)
+	}
+
+	amplicons, _ = Amplify(gene, []string{forwardPrimer, reversePrimer}, Options{MaxLength: 500})
+	if len(amplicons) != 0 {
+		t.Errorf("Amplicon is longer than the maximum length")
+	}
+}
+
+func TestAmplifyMismatches(t *testing.T) {
+	reversePrimer := "TTAAGAAAGCGCATTTTCCAGC"
+	// two mismatches in the middle of the forward primer.
+	mismatched := "AATAATTACAGCGAGTTAACACATCATGG"
+	amplicons, _ := Amplify(gene, []string{mismatched, reversePrimer}, Options{})
+	if len(amplicons) != 0 {
+		t.Errorf("Primer with mismatches shouldn't bind without mismatches allowed")
+	}
+	amplicons, _ = Amplify(gene, []string{mismatched, reversePrimer}, Options{MaxMismatches: 2})
+	if len(amplicons) != 1 {
+		t.Fatalf("Expected one amplicon, got %d", len(amplicons))
+	}
+	if amplicons[0].Mismatches != 2 || !strings.HasPrefix(amplicons[0].Sequence, mismatched) {
+		t.Errorf("Expected an amplicon with two mismatches starting with the primer, got %+v", amplicons[0])
+	}
+
+	// a single mismatch in the 3' anchor stops extension.
+	anchorMismatch := "AATAATTACACCGAGATAACACATCTTGG"
+	amplicons, _ = Amplify(gene, []string{anchorMismatch, reversePrimer}, Options{MaxMismatches: 3})
+	if len(amplicons) != 0 {
+		t.Errorf("Primer with a mismatched anchor shouldn't amplify")
+	}
+
+	_, err := Amplify(gene, []string{"ACG", reversePrimer}, Options{})
+	if err == nil {
+		t.Errorf("Primers shorter than their anchor should be rejected")
+	}
+}
+
+func TestAmplifyCircular(t *testing.T) {
+	forwardPrimer := "actctgggctttagaaggagcgataaacggc"
+	reversePrimer := "aagtgcctcaaatagcttcatagaatctttgagtatcgg"
+	targetFragment := "ACTCTGGGCTTTAGAAGGAGCGATAAACGGCACGCACTGGAGCGTCGTTCCTCAATCTCGCAGAGAAGAGCTGGAAAATGCGCTTTCTTAAAATAATTACACCGAGATAACACATCATGGATAAACCGATACTCAAAGATTCTATGAAGCTATTTGAGGCACTT"
+
+	amplicons, _ := Amplify(gene, []string{forwardPrimer, reversePrimer}, Options{})
+	if len(amplicons) != 0 {
+		t.Errorf("Linear template shouldn't amplify across its ends")
+	}
+	amplicons, _ = Amplify(gene, []string{forwardPrimer, reversePrimer}, Options{Circular: true})
+	if len(amplicons) != 1 {
+		t.Fatalf("Expected one amplicon, got %d", len(amplicons))
+	}
+	if amplicons[0].Sequence != targetFragment {
+		t.Errorf("Didn't get target fragment from circular amplification. Expected: %s, got: %s", targetFragment, amplicons[0].Sequence)
+	}
+	if amplicons[0].End >= amplicons[0].Start {
+		t.Errorf("Amplicon should span the origin, got %d to %d", amplicons[0].Start, amplicons[0].End)
+	}
+
+	// a primer binding across the origin.
+	upper := strings.ToUpper(gene)
+	spanning := upper[len(upper)-10:] + upper[:15]
+	sites, _ := FindBindingSites(gene, []string{spanning}, Options{Circular: true})
+	if len(sites) != 1 || sites[0].Start != len(gene)-10 || sites[0].End != 15 {
+		t.Errorf("Expected a site spanning the origin, got %+v", sites)
+	}
+}
@@ -17,6 +17,13 @@ oligo pool, use the `Simulate` rather than `SimulateSimple` function to detect
 if there is concatemerization happening in your multiplex reaction. In most
 other cases, use `SimulateSimple`.
 
+If you'd like to know where primers might bind without binding perfectly,
+like when checking a primer set for off-target products before ordering it,
+use `Amplify`, which tolerates mismatches outside of a primer's 3' end and
+reports where on the template every amplicon comes from. Once you have a
+primer pair you trust, `Touchdown` plans a touchdown thermocycler program for
+it.
+
 IMPORTANT! The targetTm in all functions is specifically for Taq polymerase.
 */
 package pcr
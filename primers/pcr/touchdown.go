@@ -0,0 +1,173 @@
+package pcr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/bebop/poly/checks"
+	"github.com/bebop/poly/primers"
+)
+
+/******************************************************************************
+
+Touchdown PCR planning begins here.
+
+Touchdown PCR starts annealing well above the primers' melting temperature
+and lowers it a degree or so every cycle. The first cycles are so stringent
+that only the intended product gets made at all, and by the time annealing is
+permissive enough for primers to bind elsewhere, the intended product has a
+head start of several doublings it never loses. It's the usual fix for
+primers that give extra bands, and a good default when the Tm estimates
+aren't trusted.
+
+Touchdown plans one from a primer pair and its template. The final annealing
+temperature is a few degrees below the lower primer Tm, and the touchdown
+starts Span degrees above that. The amplicon comes from Amplify, and sets the
+extension time and, if it's GC rich, a hotter and longer denaturation.
+
+******************************************************************************/
+
+// TouchdownOptions changes how a touchdown program is planned. Zero values
+// are replaced with the defaults noted on each field.
+type TouchdownOptions struct {
+	Conditions primers.Conditions // defaults to primers.DefaultConditions.
+	// Span is how far above the final annealing temperature the touchdown
+	// starts. Defaults to 10 °C.
+	Span float64
+	// Decrement is how much the annealing temperature drops every touchdown
+	// cycle. Defaults to 1 °C.
+	Decrement float64
+	// Cycles is the number of cycles at the final annealing temperature.
+	// Defaults to 25.
+	Cycles int
+	// ExtensionRate is how many bases the polymerase extends a minute.
+	// Defaults to 1000, about right for Taq.
+	ExtensionRate int
+	Circular      bool
+}
+
+// Step is a single temperature hold of a thermocycler program.
+type Step struct {
+	Name        string  `json:"name"`
+	Temperature float64 `json:"temperature"` // in °C.
+	Seconds     int     `json:"seconds"`     // 0 holds forever.
+	// Increment changes the temperature every cycle after the first, in °C.
+	Increment float64 `json:"increment,omitempty"`
+}
+
+// Stage is a set of steps repeated for a number of cycles.
+type Stage struct {
+	Name   string `json:"name"`
+	Cycles int    `json:"cycles"`
+	Steps  []Step `json:"steps"`
+}
+
+// Program is a thermocycler program.
+type Program struct {
+	ForwardTm      float64  `json:"forward_tm"`
+	ReverseTm      float64  `json:"reverse_tm"`
+	AnnealingTm    float64  `json:"annealing_tm"` // final annealing temperature.
+	AmpliconLength int      `json:"amplicon_length"`
+	AmpliconGC     float64  `json:"amplicon_gc"`
+	Stages         []Stage  `json:"stages"`
+	Notes          []string `json:"notes"`
+}
+
+// gcRich is the amplicon GC content above which denaturation is hotter and
+// longer.
+const gcRich = 0.65
+
+// Touchdown plans a touchdown PCR program for a primer pair and template.
+func Touchdown(forward, reverse, template string, options TouchdownOptions) (Program, error) {
+	if options.Conditions == (primers.Conditions{}) {
+		options.Conditions = primers.DefaultConditions
+	}
+	if options.Span == 0 {
+		options.Span = 10
+	}
+	if options.Decrement == 0 {
+		options.Decrement = 1
+	}
+	if options.Cycles == 0 {
+		options.Cycles = 25
+	}
+	if options.ExtensionRate == 0 {
+		options.ExtensionRate = 1000
+	}
+
+	var program Program
+	amplicons, err := Amplify(template, []string{forward, reverse}, Options{Circular: options.Circular})
+	if err != nil {
+		return Program{}, err
+	}
+	var amplicon Amplicon
+	found := false
+	for _, candidate := range amplicons {
+		// the pair has to make the product, rather than either primer on its own.
+		if candidate.Forward.Primer != candidate.Reverse.Primer {
+			amplicon, found = candidate, true
+			break
+		}
+	}
+	if !found {
+		return Program{}, fmt.Errorf("primers don't amplify anything from the template")
+	}
+	if len(amplicons) > 1 {
+		program.Notes = append(program.Notes, fmt.Sprintf("primers make %d products, touchdown may not be enough to suppress the extra ones", len(amplicons)))
+	}
+
+	// only the part of each primer that binds the template melts off it.
+	forwardBinding := bindingRegion(strings.ToUpper(forward), 55)
+	reverseBinding := bindingRegion(strings.ToUpper(reverse), 55)
+	if amplicon.Forward.Primer == 1 {
+		forwardBinding, reverseBinding = reverseBinding, forwardBinding
+	}
+	if program.ForwardTm, err = primers.Tm(forwardBinding, options.Conditions); err != nil {
+		return Program{}, err
+	}
+	if program.ReverseTm, err = primers.Tm(reverseBinding, options.Conditions); err != nil {
+		return Program{}, err
+	}
+	if difference := math.Abs(program.ForwardTm - program.ReverseTm); difference > 5 {
+		program.Notes = append(program.Notes, fmt.Sprintf("primer Tms differ by %.1f °C, consider redesigning one of them", difference))
+	}
+	program.AnnealingTm = math.Round(min(program.ForwardTm, program.ReverseTm)) - 3
+	program.AmpliconLength = len(amplicon.Sequence)
+	program.AmpliconGC = checks.GcContent(amplicon.Sequence)
+
+	denaturation, initialSeconds := 95.0, 180
+	if program.AmpliconGC > gcRich {
+		denaturation, initialSeconds = 98, 300
+		program.Notes = append(program.Notes, fmt.Sprintf("amplicon is %.0f%% GC, consider adding 3 to 5%% DMSO", 100*program.AmpliconGC))
+	}
+	extensionSeconds := max(15, int(math.Ceil(float64(program.AmpliconLength)*60/float64(options.ExtensionRate))))
+	startTm := min(program.AnnealingTm+options.Span, 72)
+	touchdownCycles := max(1, int(math.Round((startTm-program.AnnealingTm)/options.Decrement)))
+
+	program.Stages = []Stage{
+		{Name: "initial denaturation", Cycles: 1, Steps: []Step{{Name: "denature", Temperature: denaturation, Seconds: initialSeconds}}},
+		{Name: "touchdown", Cycles: touchdownCycles, Steps: []Step{
+			{Name: "denature", Temperature: denaturation, Seconds: 30},
+			{Name: "anneal", Temperature: startTm, Seconds: 30, Increment: -options.Decrement},
+			{Name: "extend", Temperature: 72, Seconds: extensionSeconds},
+		}},
+		{Name: "amplification", Cycles: options.Cycles, Steps: []Step{
+			{Name: "denature", Temperature: denaturation, Seconds: 30},
+			{Name: "anneal", Temperature: program.AnnealingTm, Seconds: 30},
+			{Name: "extend", Temperature: 72, Seconds: extensionSeconds},
+		}},
+		{Name: "final extension", Cycles: 1, Steps: []Step{{Name: "extend", Temperature: 72, Seconds: 300}}},
+		{Name: "hold", Cycles: 1, Steps: []Step{{Name: "hold", Temperature: 4}}},
+	}
+	return program, nil
+}
+
+// WriteJSON writes a program as indented JSON.
+func (program Program) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(program)
+}
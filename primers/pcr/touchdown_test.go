@@ -0,0 +1,73 @@
+package pcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/transform"
+)
+
+func TestTouchdown(t *testing.T) {
+	forward := "TTATAGGTCTCATACTAATAATTACACCGAGATAACACATCATGG"
+	reverse := "TATATGGTCTCTTCATTTAAGAAAGCGCATTTTCCAGC"
+	program, err := Touchdown(forward, reverse, gene, TouchdownOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if program.AmpliconLength != 648 {
+		t.Errorf("Expected a 648 bp amplicon, got %d", program.AmpliconLength)
+	}
+	if len(program.Stages) != 5 {
+		t.Fatalf("Expected 5 stages, got %d", len(program.Stages))
+	}
+	touchdown, amplification := program.Stages[1], program.Stages[2]
+	if touchdown.Cycles != 10 || touchdown.Steps[1].Temperature != program.AnnealingTm+10 || touchdown.Steps[1].Increment != -1 {
+		t.Errorf("Touchdown should drop 10 °C to the annealing temperature, got %+v", touchdown)
+	}
+	if amplification.Cycles != 25 || amplification.Steps[1].Temperature != program.AnnealingTm {
+		t.Errorf("Amplification should anneal at %.0f °C, got %+v", program.AnnealingTm, amplification)
+	}
+	if lowest := min(program.ForwardTm, program.ReverseTm); program.AnnealingTm > lowest || program.AnnealingTm < lowest-5 {
+		t.Errorf("Annealing temperature %.0f °C should be a few degrees under the lower Tm %.1f °C", program.AnnealingTm, lowest)
+	}
+	if amplification.Steps[2].Seconds != 39 || amplification.Steps[0].Temperature != 95 {
+		t.Errorf("Unexpected amplification steps %+v", amplification.Steps)
+	}
+
+	var output bytes.Buffer
+	if err := program.WriteJSON(&output); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Program
+	if err := json.Unmarshal(output.Bytes(), &decoded); err != nil || decoded.Stages[1].Steps[1].Increment != -1 {
+		t.Errorf("Program didn't survive JSON: %v", err)
+	}
+
+	if _, err := Touchdown(forward, reverse, strings.Repeat("A", 1000), TouchdownOptions{}); err == nil {
+		t.Errorf("Primers that don't bind should be an error")
+	}
+}
+
+func TestTouchdownGCRich(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	sequence := make([]byte, 300)
+	for position := range sequence {
+		sequence[position] = "GGGCCCAT"[random.Intn(8)]
+	}
+	template := string(sequence)
+	forward := template[:20]
+	reverse := transform.ReverseComplement(template[280:])
+	program, err := Touchdown(forward, reverse, template, TouchdownOptions{Span: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if program.Stages[0].Steps[0].Temperature != 98 || len(program.Notes) == 0 {
+		t.Errorf("GC rich amplicons should denature at 98 °C with a note, got %+v", program)
+	}
+	if program.Stages[1].Cycles > 5 {
+		t.Errorf("Expected at most 5 touchdown cycles, got %d", program.Stages[1].Cycles)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/bebop/poly/io/genbank"
 	"github.com/bebop/poly/primers"
 	"github.com/bebop/poly/transform"
 )
@@ -133,3 +134,44 @@ func TestCreateBarcode(t *testing.T) {
 		t.Errorf("TestUniqueSequence string should return CTCTCGGTCGCTCCGTCCCG. Got:\n%s", output)
 	}
 }
+
+func ExampleTm() {
+	// M13 forward in a typical PCR buffer.
+	meltingTemp, _ := primers.Tm("GTAAAACGACGGCCAGT", primers.DefaultConditions)
+	fmt.Printf("%.1f\n", meltingTemp)
+	// output: 58.0
+}
+
+func ExampleDesign() {
+	lacZ := "ATGACCATGATTACGCCAAGCTTGCATGCCTGCAGGTCGACTCTAGAGGATCCCCGGGTACCGAGCTCGAATTCACTGGCCGTCGTTTTACAACGTCGTGACTGGGAAAACCCTGGCGTTACCCAACTTAATCGCCTTGCAGCACATCCCCCTTTCGCCAGCTGGCGTAATAGCGAAGAGGCCCGCACCGATCGCCCTTCCCAACAGTTGCGCAGCCTGAATGGCGAATGG"
+
+	// primers around the multiple cloning site.
+	pairs, err := primers.Design(lacZ, 50, 130, primers.DesignOptions{Flank: 50})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	best := pairs[0]
+	fmt.Println(best.Forward.Sequence, best.Reverse.Sequence, best.ProductLength)
+	// Output: GACCATGATTACGCCAAGC CTTCGCTATTACGCCAGC 176
+}
+
+func ExampleDesignFeature() {
+	puc19, _ := genbank.Read("../data/puc19.gbk")
+
+	pairs, err := primers.DesignFeature(puc19, "MCS", primers.DesignOptions{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	best := pairs[0]
+	for _, primer := range []primers.Primer{best.Forward, best.Reverse} {
+		fmt.Printf("%s Tm %.1f GC %.0f%%\n", primer.Sequence, primer.Tm, 100*primer.GC)
+	}
+	for _, warning := range best.Warnings() {
+		fmt.Println(warning)
+	}
+	// Output:
+	// GAAACAGCTATGACCATGATTACG Tm 60.0 GC 42%
+	// GCAAGGCGATTAAGTTGGG Tm 60.1 GC 53%
+}
@@ -0,0 +1,98 @@
+package primers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bebop/poly/transform"
+)
+
+/******************************************************************************
+
+Sequencing primer design begins here.
+
+A Sanger read starts a little after its primer, since the first few dozen
+bases come off the sequencer as a mess, and gets unreliable somewhere around
+800 to 1000 bases in. Checking a region longer than that takes a walk of
+primers, each binding near the end of the last one's read.
+
+DesignSequencing picks the best primer, by the same constraints and penalty
+as Design, whose read starts at or before each stretch of the region that
+hasn't been read yet, until the whole region has been. Primers read the top
+strand by default, or the bottom strand, binding after the region, with
+Reverse.
+
+******************************************************************************/
+
+// SequencingOptions changes how sequencing primers are designed. Zero values
+// are replaced with the defaults noted on each field.
+type SequencingOptions struct {
+	// ReadLength is how many bases after the end of a primer are read well.
+	// Defaults to 800.
+	ReadLength int
+	// Lead is how many bases after the end of a primer aren't read well.
+	// Defaults to 50.
+	Lead int
+	// Reverse designs primers that read the bottom strand.
+	Reverse bool
+	// Design constrains the primers. Its Flank is how much further before
+	// where a read has to start its primer can bind, and its Pairs is
+	// ignored.
+	Design DesignOptions
+}
+
+// DesignSequencing designs primers whose reads cover the 0-based, half open
+// region [start, end) of template, in the order they read it.
+func DesignSequencing(template string, start, end int, options SequencingOptions) ([]Primer, error) {
+	if options.ReadLength == 0 {
+		options.ReadLength = 800
+	}
+	if options.Lead == 0 {
+		options.Lead = 50
+	}
+	options.Design = designDefaults(options.Design)
+
+	template = strings.ToUpper(template)
+	if start < 0 || end > len(template) || start >= end {
+		return nil, fmt.Errorf("region [%d, %d) isn't within the template of length %d", start, end, len(template))
+	}
+	if options.Design.MinLength > options.Design.MaxLength || options.Design.MinLength < 2 {
+		return nil, fmt.Errorf("primer lengths from %d to %d aren't a valid range", options.Design.MinLength, options.Design.MaxLength)
+	}
+	if options.ReadLength <= options.Lead {
+		return nil, fmt.Errorf("read length %d has to be longer than the lead of %d", options.ReadLength, options.Lead)
+	}
+	if options.Reverse {
+		// reverse primers are forward primers of the reverse complement.
+		primers, err := DesignSequencing(transform.ReverseComplement(template), len(template)-end, len(template)-start, SequencingOptions{ReadLength: options.ReadLength, Lead: options.Lead, Design: options.Design})
+		if err != nil {
+			return nil, err
+		}
+		for index := range primers {
+			primers[index].Start, primers[index].End = len(template)-primers[index].End, len(template)-primers[index].Start
+			primers[index].Forward = false
+		}
+		return primers, nil
+	}
+
+	var primers []Primer
+	for read := start; read < end; {
+		// the primer has to end where its read starts at or before read.
+		var candidates []Primer
+		for primerEnd := read - options.Lead; primerEnd >= max(read-options.Lead-options.Design.Flank, options.Design.MinLength); primerEnd-- {
+			for length := options.Design.MinLength; length <= options.Design.MaxLength && primerEnd-length >= 0; length++ {
+				if primer, ok := candidate(template[primerEnd-length:primerEnd], primerEnd-length, true, options.Design); ok {
+					candidates = append(candidates, primer)
+				}
+			}
+		}
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("found no primer meeting the constraints to read from %d, try a larger flank or looser constraints", read)
+		}
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Penalty < candidates[j].Penalty })
+		primers = append(primers, candidates[0])
+		read = candidates[0].End + options.ReadLength
+	}
+	return primers, nil
+}
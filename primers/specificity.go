@@ -0,0 +1,141 @@
+package primers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/bebop/poly/fold"
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/transform"
+)
+
+/******************************************************************************
+
+Panel specificity begins here.
+
+A multiplexed diagnostic panel puts a dozen probes or primer pairs into the
+same reaction with a dozen targets, and every probe has to stick to its own
+target and nothing else. Checking for exact matches isn't enough, since a
+probe can bind a target it only partly matches well enough to give a false
+positive. So Specificity hybridizes every probe with every target, on both
+strands, using fold.Duplex, and fills in a matrix of the free energies. Any
+probe that binds a target other than its own more stably than a threshold is
+flagged as cross-reactive.
+
+Duplex time grows with the product of the lengths of the two strands, so
+targets should be the amplicons or regions the probes are meant for rather
+than whole genomes. To search whole genomes for near matches, have a look at
+pcr.FindBindingSites.
+
+******************************************************************************/
+
+// SpecificityOptions changes how probes are checked against a panel. Zero
+// values are replaced with the defaults noted on each field.
+type SpecificityOptions struct {
+	Temp float64 // hybridization temperature. Defaults to 37 °C.
+	// Threshold is the free energy in kcal/mol below which a probe is
+	// considered to bind a target. Defaults to -10.
+	Threshold float64
+	// Intended maps every probe's name to the names of the targets it's
+	// meant to bind. A probe that isn't in Intended isn't meant to bind
+	// anything.
+	Intended map[string][]string
+}
+
+// Hybridization is the most stable duplex of a probe and a target.
+type Hybridization struct {
+	Probe, Target string
+	Energy        float64 // free energy in kcal/mol, or 0 if they don't pair.
+	Structure     string  // duplex of the probe and target strand, as from fold.Duplex.
+	// Forward is whether the probe binds the target as given, rather than
+	// its reverse complement.
+	Forward       bool
+	Binds         bool // whether Energy is below the threshold.
+	CrossReactive bool // whether it binds a target it isn't meant to.
+}
+
+// SpecificityMatrix is the hybridization of every probe of a panel with
+// every target. Hybridizations[probe][target] is in the order probes and
+// targets were given.
+type SpecificityMatrix struct {
+	Probes, Targets []string
+	Hybridizations  [][]Hybridization
+}
+
+// Specificity hybridizes every probe with both strands of every target.
+func Specificity(probes, targets []fasta.Fasta, options SpecificityOptions) (SpecificityMatrix, error) {
+	if options.Temp == 0 {
+		options.Temp = 37
+	}
+	if options.Threshold == 0 {
+		options.Threshold = -10
+	}
+
+	matrix := SpecificityMatrix{Hybridizations: make([][]Hybridization, len(probes))}
+	for _, target := range targets {
+		matrix.Targets = append(matrix.Targets, target.Name)
+	}
+	for probeIndex, probe := range probes {
+		matrix.Probes = append(matrix.Probes, probe.Name)
+		intended := make(map[string]bool)
+		for _, name := range options.Intended[probe.Name] {
+			intended[name] = true
+		}
+		for _, target := range targets {
+			hybridization := Hybridization{Probe: probe.Name, Target: target.Name}
+			for _, forward := range []bool{true, false} {
+				strand := target.Sequence
+				if !forward {
+					strand = transform.ReverseComplement(strand)
+				}
+				duplex, err := fold.Duplex(probe.Sequence, strand, options.Temp)
+				if err != nil {
+					return SpecificityMatrix{}, fmt.Errorf("couldn't hybridize probe %s with target %s: %w", probe.Name, target.Name, err)
+				}
+				if hybridization.Structure == "" || duplex.Energy < hybridization.Energy {
+					hybridization.Energy, hybridization.Structure, hybridization.Forward = duplex.Energy, duplex.Structure, forward
+				}
+			}
+			hybridization.Binds = hybridization.Energy < options.Threshold
+			hybridization.CrossReactive = hybridization.Binds && !intended[target.Name]
+			matrix.Hybridizations[probeIndex] = append(matrix.Hybridizations[probeIndex], hybridization)
+		}
+	}
+	return matrix, nil
+}
+
+// CrossReactions returns every cross-reactive hybridization of a matrix, by
+// probe and then target.
+func (matrix SpecificityMatrix) CrossReactions() []Hybridization {
+	var crossReactions []Hybridization
+	for _, row := range matrix.Hybridizations {
+		for _, hybridization := range row {
+			if hybridization.CrossReactive {
+				crossReactions = append(crossReactions, hybridization)
+			}
+		}
+	}
+	return crossReactions
+}
+
+// WriteSpecificityMatrix writes the free energies of a matrix as CSV, with a
+// row for every probe and a column for every target.
+func WriteSpecificityMatrix(w io.Writer, matrix SpecificityMatrix) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(append([]string{"probe"}, matrix.Targets...)); err != nil {
+		return err
+	}
+	for probeIndex, probe := range matrix.Probes {
+		record := []string{probe}
+		for _, hybridization := range matrix.Hybridizations[probeIndex] {
+			record = append(record, strconv.FormatFloat(hybridization.Energy, 'f', 2, 64))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,72 @@
+package primers
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/transform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpecificity(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	var targets []fasta.Fasta
+	for _, name := range []string{"flu A", "flu B", "RSV"} {
+		sequence := make([]byte, 120)
+		for position := range sequence {
+			sequence[position] = "ACGT"[random.Intn(4)]
+		}
+		targets = append(targets, fasta.Fasta{Name: name, Sequence: string(sequence)})
+	}
+	probes := []fasta.Fasta{
+		{Name: "flu A probe", Sequence: transform.ReverseComplement(targets[0].Sequence[40:65])},
+		// binds the other strand of its target.
+		{Name: "flu B probe", Sequence: targets[1].Sequence[30:55]},
+		// meant for RSV, but taken from flu A by mistake.
+		{Name: "RSV probe", Sequence: transform.ReverseComplement(targets[0].Sequence[70:92])},
+	}
+	intended := map[string][]string{"flu A probe": {"flu A"}, "flu B probe": {"flu B"}, "RSV probe": {"RSV"}}
+
+	matrix, err := Specificity(probes, targets, SpecificityOptions{Intended: intended})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"flu A probe", "flu B probe", "RSV probe"}, matrix.Probes)
+	assert.Equal(t, []string{"flu A", "flu B", "RSV"}, matrix.Targets)
+	require.Len(t, matrix.Hybridizations, 3)
+
+	fluA := matrix.Hybridizations[0][0]
+	assert.True(t, fluA.Binds)
+	assert.True(t, fluA.Forward)
+	assert.False(t, fluA.CrossReactive)
+	assert.Less(t, fluA.Energy, -20.0)
+	assert.GreaterOrEqual(t, strings.Count(fluA.Structure, "("), 20)
+
+	fluB := matrix.Hybridizations[1][1]
+	assert.True(t, fluB.Binds)
+	assert.False(t, fluB.Forward)
+	for probe := range probes {
+		for target := range targets {
+			if probe != target && probe != 2 {
+				assert.False(t, matrix.Hybridizations[probe][target].Binds, "%d %d", probe, target)
+			}
+		}
+	}
+
+	crossReactions := matrix.CrossReactions()
+	require.Len(t, crossReactions, 1)
+	assert.Equal(t, "RSV probe", crossReactions[0].Probe)
+	assert.Equal(t, "flu A", crossReactions[0].Target)
+
+	var output bytes.Buffer
+	require.NoError(t, WriteSpecificityMatrix(&output, matrix))
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, "probe,flu A,flu B,RSV", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "flu A probe,-"))
+
+	_, err = Specificity([]fasta.Fasta{{Name: "rna", Sequence: "ACGUACGU"}}, targets, SpecificityOptions{})
+	assert.Error(t, err)
+}
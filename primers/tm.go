@@ -0,0 +1,128 @@
+package primers
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/bebop/poly/transform"
+)
+
+/******************************************************************************
+
+Salt corrected melting temperature begins here.
+
+SantaLucia above corrects for salt the way SantaLucia, 1998 did, which is
+good for sodium but treats magnesium as if it were just more sodium. Real PCR
+buffers have 1.5 to 4 mM of magnesium and comparatively little sodium, and
+magnesium stabilizes DNA much more than its concentration suggests, so that
+approximation can be off by several degrees right where it matters.
+
+Tm computes the melting temperature at 1 M NaCl from the same unified nearest
+neighbor parameters, and then corrects it using Owczarzy et al.: the 2004
+correction for monovalent ions when there's little or no magnesium, and the
+2008 correction for magnesium otherwise, which also accounts for magnesium
+tied up by dNTPs and for monovalent ions competing with it.
+
+For more on the salt corrections:
+Owczarzy et al., 2004
+https://doi.org/10.1021/bi034621r
+Owczarzy et al., 2008
+https://doi.org/10.1021/bi702363u
+
+******************************************************************************/
+
+// Conditions are the concentrations an oligo melts in, in molar.
+type Conditions struct {
+	// Oligo is the total concentration of both strands, the oligo and its
+	// complement at equal concentrations, as SantaLucia's formula assumes. A
+	// self-complementary oligo is its own complement, so it's just the
+	// oligo's concentration. For an oligo in large excess of its target, like
+	// a primer on its template, pass four times the oligo's concentration.
+	Oligo      float64
+	Monovalent float64 // total concentration of monovalent cations, like Na+ and K+.
+	Magnesium  float64 // total concentration of Mg2+.
+	DNTP       float64 // total concentration of dNTPs, which bind Mg2+ one to one.
+}
+
+// DefaultConditions are typical PCR conditions: 250 nM primer, 50 mM
+// monovalent cations, 1.5 mM magnesium, and 0.8 mM dNTPs (0.2 mM each). The
+// primer is in large excess of its template, so Oligo is four times 250 nM.
+var DefaultConditions = Conditions{
+	Oligo:      1e-6,
+	Monovalent: 50e-3,
+	Magnesium:  1.5e-3,
+	DNTP:       0.8e-3,
+}
+
+// Tm returns the melting temperature in degrees Celsius of a DNA oligo
+// binding its perfect complement under conditions.
+func Tm(sequence string, conditions Conditions) (float64, error) {
+	sequence = strings.ToUpper(sequence)
+	if len(sequence) < 2 {
+		return 0, fmt.Errorf("sequence %q is too short to have a melting temperature", sequence)
+	}
+	if strings.Trim(sequence, "ACGT") != "" {
+		return 0, fmt.Errorf("sequence %q has bases other than A, C, G, and T", sequence)
+	}
+	if conditions.Oligo <= 0 {
+		return 0, fmt.Errorf("oligo concentration is %g, expected a positive number", conditions.Oligo)
+	}
+	if conditions.Monovalent < 0 || conditions.Magnesium < 0 || conditions.DNTP < 0 {
+		return 0, fmt.Errorf("ion concentrations can't be negative")
+	}
+	freeMagnesium := max(conditions.Magnesium-conditions.DNTP, 0)
+	if conditions.Monovalent == 0 && freeMagnesium == 0 {
+		return 0, fmt.Errorf("there are no free cations for the DNA to melt in")
+	}
+
+	// melting temperature at 1 M NaCl, from SantaLucia and Hicks, 2004.
+	enthalpy, entropy := initialThermodynamicPenalty.H, initialThermodynamicPenalty.S
+	for index := 0; index+1 < len(sequence); index++ {
+		stack := nearestNeighborsThermodynamics[sequence[index:index+2]]
+		enthalpy += stack.H
+		entropy += stack.S
+	}
+	for _, end := range []byte{sequence[0], sequence[len(sequence)-1]} {
+		if end == 'A' || end == 'T' {
+			enthalpy += terminalATThermodynamicPenalty.H
+			entropy += terminalATThermodynamicPenalty.S
+		}
+	}
+	// at the melting temperature half of each strand is in the duplex, which
+	// for two strands making up half of Oligo each puts the equilibrium
+	// constant at 4/Oligo, and for a self-complementary one at 1/Oligo.
+	symmetryFactor := 4.0
+	if sequence == transform.ReverseComplement(sequence) {
+		entropy += symmetryThermodynamicPenalty.S
+		symmetryFactor = 1
+	}
+	const gasConstant = 1.9872 // cal / mol K
+	inverseTm := (entropy + gasConstant*math.Log(conditions.Oligo/symmetryFactor)) / (enthalpy * 1000)
+
+	gcFraction := float64(strings.Count(sequence, "G")+strings.Count(sequence, "C")) / float64(len(sequence))
+	monovalent := conditions.Monovalent
+	ratio := math.Inf(1)
+	if monovalent > 0 {
+		ratio = math.Sqrt(freeMagnesium) / monovalent
+	}
+
+	if ratio < 0.22 {
+		// monovalent ions dominate.
+		logMonovalent := math.Log(monovalent)
+		inverseTm += (4.29*gcFraction-3.95)*1e-5*logMonovalent + 9.40e-6*logMonovalent*logMonovalent
+		return 1/inverseTm - 273.15, nil
+	}
+
+	a, b, c, d, e, f, g := 3.92e-5, -9.11e-6, 6.26e-5, 1.42e-5, -4.82e-4, 5.25e-4, 8.31e-5
+	if ratio < 6 {
+		// monovalent ions compete with magnesium.
+		logMonovalent := math.Log(monovalent)
+		a *= 0.843 - 0.352*math.Sqrt(monovalent)*logMonovalent
+		d *= 1.279 - 4.03e-3*logMonovalent - 8.03e-3*logMonovalent*logMonovalent
+		g *= 0.486 - 0.258*logMonovalent + 5.25e-3*logMonovalent*logMonovalent*logMonovalent
+	}
+	logMagnesium := math.Log(freeMagnesium)
+	inverseTm += a + b*logMagnesium + gcFraction*(c+d*logMagnesium) + (e+f*logMagnesium+g*logMagnesium*logMagnesium)/(2*float64(len(sequence)-1))
+	return 1/inverseTm - 273.15, nil
+}
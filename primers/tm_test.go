@@ -0,0 +1,91 @@
+package primers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTm(t *testing.T) {
+	m13 := "GTAAAACGACGGCCAGT"
+
+	// at 1 M sodium the salt correction vanishes, leaving the nearest
+	// neighbor sum: 16 stacks, initiation, and one terminal AT.
+	enthalpy := -8.4 - 7.2 - 7.6 - 7.6 - 7.6 - 8.4 - 10.6 - 8.2 - 8.4 - 10.6 - 8.0 - 9.8 - 8.0 - 8.5 - 7.8 - 8.4 + 0.2 + 2.2
+	entropy := -22.4 - 21.3 - 21.3 - 21.3 - 21.3 - 22.4 - 27.2 - 22.2 - 22.4 - 27.2 - 19.9 - 24.4 - 19.9 - 22.7 - 21.0 - 22.4 - 5.7 + 6.9
+	expected := enthalpy*1000/(entropy+1.9872*math.Log(250e-9/4)) - 273.15
+	tm, err := Tm(m13, Conditions{Oligo: 250e-9, Monovalent: 1})
+	require.NoError(t, err)
+	assert.InDelta(t, expected, tm, 1e-9)
+
+	// a primer in excess of its template is a quarter of the equal strand
+	// concentration SantaLucia's formula takes.
+	expected = enthalpy*1000/(entropy+1.9872*math.Log(250e-9)) - 273.15
+	excess, err := Tm(m13, Conditions{Oligo: 4 * 250e-9, Monovalent: 1})
+	require.NoError(t, err)
+	assert.InDelta(t, expected, excess, 1e-9)
+
+	// self-complementary sequences pay a symmetry penalty, and melt at their
+	// own concentration rather than a quarter of it.
+	dickerson := "CGCGAATTCGCG"
+	enthalpy = -10.6 - 9.8 - 10.6 - 8.2 - 7.6 - 7.2 - 7.6 - 8.2 - 10.6 - 9.8 - 10.6 + 0.2
+	entropy = -27.2 - 24.4 - 27.2 - 22.2 - 21.3 - 20.4 - 21.3 - 22.2 - 27.2 - 24.4 - 27.2 - 5.7 - 1.4
+	expected = enthalpy*1000/(entropy+1.9872*math.Log(250e-9)) - 273.15
+	selfComplementary, err := Tm(dickerson, Conditions{Oligo: 250e-9, Monovalent: 1})
+	require.NoError(t, err)
+	assert.InDelta(t, expected, selfComplementary, 1e-9)
+
+	// less salt, lower melting temperature.
+	lowSalt, err := Tm(m13, Conditions{Oligo: 250e-9, Monovalent: 50e-3})
+	require.NoError(t, err)
+	assert.Less(t, lowSalt, tm)
+	// close to the older correction in plain sodium.
+	santaLucia, _, _ := SantaLucia(m13, 250e-9, 50e-3, 0)
+	assert.InDelta(t, santaLucia, lowSalt, 3)
+
+	// magnesium stabilizes, unless the dNTPs soak it all up.
+	pcr, err := Tm(m13, DefaultConditions)
+	require.NoError(t, err)
+	assert.Greater(t, pcr, lowSalt)
+	moreMagnesium, err := Tm(m13, Conditions{Oligo: 250e-9, Monovalent: 50e-3, Magnesium: 4e-3, DNTP: 0.8e-3})
+	require.NoError(t, err)
+	assert.Greater(t, moreMagnesium, pcr)
+	chelated, err := Tm(m13, Conditions{Oligo: 250e-9, Monovalent: 50e-3, Magnesium: 0.8e-3, DNTP: 0.8e-3})
+	require.NoError(t, err)
+	assert.Equal(t, lowSalt, chelated)
+
+	// magnesium alone is enough.
+	magnesiumOnly, err := Tm(m13, Conditions{Oligo: 250e-9, Magnesium: 2e-3})
+	require.NoError(t, err)
+	assert.Greater(t, magnesiumOnly, 40.0)
+	assert.Less(t, magnesiumOnly, 70.0)
+
+	// more oligo, higher melting temperature.
+	concentrated, err := Tm(m13, Conditions{Oligo: 1e-6, Monovalent: 50e-3})
+	require.NoError(t, err)
+	assert.Greater(t, concentrated, lowSalt)
+
+	// self-complementary sequences only need one strand.
+	palindrome, err := Tm("acgtagatctacgt", DefaultConditions)
+	require.NoError(t, err)
+	assert.Greater(t, palindrome, 0.0)
+}
+
+func TestTmErrors(t *testing.T) {
+	tests := []struct {
+		sequence   string
+		conditions Conditions
+	}{
+		{"A", DefaultConditions},
+		{"ACGTN", DefaultConditions},
+		{"ACGT", Conditions{Monovalent: 50e-3}},
+		{"ACGT", Conditions{Oligo: 250e-9, Monovalent: -1}},
+		{"ACGT", Conditions{Oligo: 250e-9, Magnesium: 1e-3, DNTP: 1e-3}},
+	}
+	for _, test := range tests {
+		_, err := Tm(test.sequence, test.conditions)
+		assert.Error(t, err, test.sequence)
+	}
+}
@@ -0,0 +1,19 @@
+package proteins_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/proteins"
+)
+
+func ExampleAnalyze() {
+	ubiquitin := "MQIFVKTLTGKTITLEVEPSDTIENVKAKIQDKEGIPPDQQRLIFAGKQLEDGRTLSDYNIQKESTLHLVLRLRGG"
+
+	properties, err := proteins.Analyze(ubiquitin)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%.0f Da, pI %.2f, GRAVY %.3f, stable: %t\n", properties.MolecularWeight, properties.IsoelectricPoint, properties.GRAVY, properties.Stable())
+	// Output: 8565 Da, pI 6.56, GRAVY -0.489, stable: true
+}
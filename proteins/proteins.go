@@ -0,0 +1,265 @@
+/*
+Package proteins computes the physicochemical properties of proteins from
+their sequences.
+
+Before expressing and purifying a protein it helps to know roughly how it will
+behave: how big it is, for the gel it'll run on; its isoelectric point, for
+picking an ion exchange column and buffer; how strongly it absorbs at 280 nm,
+for measuring how much there is; and whether it's likely to be hydrophobic or
+unstable. Expasy's ProtParam (https://web.expasy.org/protparam/) is where
+most people look these up, and Analyze computes the same properties the same
+way, so the numbers match it:
+
+  - MolecularWeight sums the average masses of the residues.
+  - IsoelectricPoint is the pH where the protein has no net charge, with the
+    pK values of Bjellqvist et al. 1993, which depend on the residues at the
+    termini.
+  - ExtinctionCoefficient is the absorbance at 280 nm of a 1 M solution in
+    water, from its tryptophans, tyrosines, and cystines (Pace et al. 1995).
+  - GRAVY is the average Kyte-Doolittle hydropathy of the residues. Positive
+    means hydrophobic.
+  - InstabilityIndex is from the dipeptides of the sequence (Guruprasad et al.
+    1990). Proteins scoring over 40 are likely unstable in the test tube.
+  - Aromaticity is the fraction of the residues that are aromatic.
+
+Proteins are written with the one letter codes of the 20 standard amino acids.
+A trailing stop, *, is ignored.
+*/
+package proteins
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Properties are the physicochemical properties of a protein.
+type Properties struct {
+	Length int
+	// MolecularWeight is in daltons.
+	MolecularWeight  float64
+	IsoelectricPoint float64
+	// ExtinctionCoefficient is in M⁻¹ cm⁻¹ at 280 nm, assuming every pair of
+	// cysteines forms a cystine, and ExtinctionCoefficientReduced assumes
+	// none do.
+	ExtinctionCoefficient        int
+	ExtinctionCoefficientReduced int
+	GRAVY                        float64
+	InstabilityIndex             float64
+	Aromaticity                  float64
+}
+
+// Stable returns whether a protein is predicted to be stable, with an
+// instability index under 40.
+func (properties Properties) Stable() bool {
+	return properties.InstabilityIndex < 40
+}
+
+// Analyze computes the properties of a protein.
+func Analyze(protein string) (Properties, error) {
+	protein, err := clean(protein)
+	if err != nil {
+		return Properties{}, err
+	}
+	oxidized, reduced := extinctionCoefficients(protein)
+	return Properties{
+		Length:                       len(protein),
+		MolecularWeight:              molecularWeight(protein),
+		IsoelectricPoint:             isoelectricPoint(protein),
+		ExtinctionCoefficient:        oxidized,
+		ExtinctionCoefficientReduced: reduced,
+		GRAVY:                        gravy(protein),
+		InstabilityIndex:             instabilityIndex(protein),
+		Aromaticity:                  aromaticity(protein),
+	}, nil
+}
+
+// MolecularWeight returns the average molecular weight of a protein, in
+// daltons.
+func MolecularWeight(protein string) (float64, error) {
+	protein, err := clean(protein)
+	if err != nil {
+		return 0, err
+	}
+	return molecularWeight(protein), nil
+}
+
+// IsoelectricPoint returns the pH at which a protein has no net charge.
+func IsoelectricPoint(protein string) (float64, error) {
+	protein, err := clean(protein)
+	if err != nil {
+		return 0, err
+	}
+	return isoelectricPoint(protein), nil
+}
+
+// Charge returns the net charge of a protein at a pH.
+func Charge(protein string, pH float64) (float64, error) {
+	protein, err := clean(protein)
+	if err != nil {
+		return 0, err
+	}
+	return charge(protein, pH), nil
+}
+
+// ExtinctionCoefficient returns the extinction coefficients of a protein at
+// 280 nm, in M⁻¹ cm⁻¹, assuming every pair of cysteines forms a cystine and
+// assuming none do.
+func ExtinctionCoefficient(protein string) (oxidized, reduced int, err error) {
+	protein, err = clean(protein)
+	if err != nil {
+		return 0, 0, err
+	}
+	oxidized, reduced = extinctionCoefficients(protein)
+	return oxidized, reduced, nil
+}
+
+// GRAVY returns the grand average of hydropathy of a protein.
+func GRAVY(protein string) (float64, error) {
+	protein, err := clean(protein)
+	if err != nil {
+		return 0, err
+	}
+	return gravy(protein), nil
+}
+
+// InstabilityIndex returns the instability index of a protein.
+func InstabilityIndex(protein string) (float64, error) {
+	protein, err := clean(protein)
+	if err != nil {
+		return 0, err
+	}
+	return instabilityIndex(protein), nil
+}
+
+// Aromaticity returns the fraction of a protein's residues that are
+// phenylalanine, tryptophan, or tyrosine.
+func Aromaticity(protein string) (float64, error) {
+	protein, err := clean(protein)
+	if err != nil {
+		return 0, err
+	}
+	return aromaticity(protein), nil
+}
+
+// clean uppercases a protein and drops its trailing stop, and checks that
+// it's only standard amino acids.
+func clean(protein string) (string, error) {
+	protein = strings.TrimSuffix(strings.ToUpper(protein), "*")
+	if protein == "" {
+		return "", fmt.Errorf("protein is empty")
+	}
+	for index := 0; index < len(protein); index++ {
+		if _, ok := residueMasses[protein[index]]; !ok {
+			return "", fmt.Errorf("residue %d, %q, isn't one of the 20 standard amino acids", index+1, protein[index])
+		}
+	}
+	return protein, nil
+}
+
+// waterMass is the average mass of water, lost from every peptide bond.
+const waterMass = 18.01528
+
+// residueMasses are the average masses of the free amino acids, in daltons.
+var residueMasses = map[byte]float64{
+	'A': 89.0932, 'R': 174.201, 'N': 132.1179, 'D': 133.1027, 'C': 121.1582,
+	'Q': 146.1445, 'E': 147.1293, 'G': 75.0666, 'H': 155.1546, 'I': 131.1729,
+	'L': 131.1729, 'K': 146.1876, 'M': 149.2113, 'F': 165.1891, 'P': 115.1305,
+	'S': 105.0926, 'T': 119.1192, 'W': 204.2252, 'Y': 181.1885, 'V': 117.1463,
+}
+
+func molecularWeight(protein string) float64 {
+	weight := 0.0
+	for index := 0; index < len(protein); index++ {
+		weight += residueMasses[protein[index]]
+	}
+	return weight - float64(len(protein)-1)*waterMass
+}
+
+// pK values of Bjellqvist et al. 1993. The termini's depend on the residue
+// at them, where it's one of the residues listed.
+var (
+	positivePKs           = map[byte]float64{'K': 10, 'R': 12, 'H': 5.98}
+	negativePKs           = map[byte]float64{'D': 4.05, 'E': 4.45, 'C': 9, 'Y': 10}
+	nTerminalPK           = 7.5
+	cTerminalPK           = 3.55
+	nTerminalPKsByResidue = map[byte]float64{'A': 7.59, 'M': 7, 'S': 6.93, 'P': 8.36, 'T': 6.82, 'V': 7.44, 'E': 7.7}
+	cTerminalPKsByResidue = map[byte]float64{'D': 4.55, 'E': 4.75}
+)
+
+func charge(protein string, pH float64) float64 {
+	nTerminal, ok := nTerminalPKsByResidue[protein[0]]
+	if !ok {
+		nTerminal = nTerminalPK
+	}
+	cTerminal, ok := cTerminalPKsByResidue[protein[len(protein)-1]]
+	if !ok {
+		cTerminal = cTerminalPK
+	}
+	total := 1/(math.Pow(10, pH-nTerminal)+1) - 1/(math.Pow(10, cTerminal-pH)+1)
+	for index := 0; index < len(protein); index++ {
+		if pK, ok := positivePKs[protein[index]]; ok {
+			total += 1 / (math.Pow(10, pH-pK) + 1)
+		}
+		if pK, ok := negativePKs[protein[index]]; ok {
+			total -= 1 / (math.Pow(10, pK-pH) + 1)
+		}
+	}
+	return total
+}
+
+// isoelectricPoint bisects for the pH where charge crosses 0, which it does
+// once since charge only falls as the pH rises.
+func isoelectricPoint(protein string) float64 {
+	low, high := 0.0, 14.0
+	for high-low > 0.0001 {
+		middle := (low + high) / 2
+		if charge(protein, middle) > 0 {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	return (low + high) / 2
+}
+
+// molar extinction coefficients at 280 nm of Pace et al. 1995.
+const (
+	tryptophanExtinction = 5500
+	tyrosineExtinction   = 1490
+	cystineExtinction    = 125
+)
+
+func extinctionCoefficients(protein string) (oxidized, reduced int) {
+	tryptophans, tyrosines := strings.Count(protein, "W"), strings.Count(protein, "Y")
+	reduced = tryptophans*tryptophanExtinction + tyrosines*tyrosineExtinction
+	return reduced + strings.Count(protein, "C")/2*cystineExtinction, reduced
+}
+
+// kyteDoolittle is the hydropathy scale of Kyte and Doolittle 1982.
+var kyteDoolittle = map[byte]float64{
+	'A': 1.8, 'R': -4.5, 'N': -3.5, 'D': -3.5, 'C': 2.5, 'Q': -3.5, 'E': -3.5,
+	'G': -0.4, 'H': -3.2, 'I': 4.5, 'L': 3.8, 'K': -3.9, 'M': 1.9, 'F': 2.8,
+	'P': -1.6, 'S': -0.8, 'T': -0.7, 'W': -0.9, 'Y': -1.3, 'V': 4.2,
+}
+
+func gravy(protein string) float64 {
+	total := 0.0
+	for index := 0; index < len(protein); index++ {
+		total += kyteDoolittle[protein[index]]
+	}
+	return total / float64(len(protein))
+}
+
+func instabilityIndex(protein string) float64 {
+	total := 0.0
+	for index := 0; index+1 < len(protein); index++ {
+		total += dipeptideInstability[protein[index]][protein[index+1]]
+	}
+	return 10 / float64(len(protein)) * total
+}
+
+func aromaticity(protein string) float64 {
+	aromatic := strings.Count(protein, "F") + strings.Count(protein, "W") + strings.Count(protein, "Y")
+	return float64(aromatic) / float64(len(protein))
+}
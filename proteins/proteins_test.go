@@ -0,0 +1,103 @@
+package proteins_test
+
+import (
+	"testing"
+
+	"github.com/bebop/poly/proteins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ubiquitin = "MQIFVKTLTGKTITLEVEPSDTIENVKAKIQDKEGIPPDQQRLIFAGKQLEDGRTLSDYNIQKESTLHLVLRLRGG"
+
+const gfp = "MASKGEELFTGVVPILVELDGDVNGHKFSVSGEGEGDATYGKLTLKFICTTGKLPVPWPTLVTTFSYGVQCFSRYPDHMKRHDFFKSAMPEGYVQERTISFKDDGNYKTRAEVKFEGDTLVNRIELKGIDFKEDGNILGHKLEYNYNSHNVYITADKQKNGIKANFKIRHNIEDGSVQLADHYQQNTPIGDGPVLLPDNHYLSTQSALSKDPNEKRDHMVLLEFVTAAGITHGMDELYK"
+
+func TestAnalyze(t *testing.T) {
+	// ProtParam gives ubiquitin a molecular weight of 8564.84, a pI of 6.56,
+	// and a GRAVY of -0.489.
+	properties, err := proteins.Analyze(ubiquitin)
+	require.NoError(t, err)
+	assert.Equal(t, 76, properties.Length)
+	assert.InDelta(t, 8564.84, properties.MolecularWeight, 0.5)
+	assert.InDelta(t, 6.56, properties.IsoelectricPoint, 0.01)
+	assert.Equal(t, 1490, properties.ExtinctionCoefficient)
+	assert.Equal(t, 1490, properties.ExtinctionCoefficientReduced)
+	assert.InDelta(t, -0.489, properties.GRAVY, 0.001)
+	assert.InDelta(t, 3.0/76, properties.Aromaticity, 1e-9)
+	assert.True(t, properties.Stable())
+
+	// GFP has a tryptophan, 11 tyrosines, and two cysteines.
+	properties, err = proteins.Analyze(gfp + "*")
+	require.NoError(t, err)
+	assert.Equal(t, 239, properties.Length)
+	assert.Equal(t, 21890+125, properties.ExtinctionCoefficient)
+	assert.Equal(t, 21890, properties.ExtinctionCoefficientReduced)
+
+	_, err = proteins.Analyze("MAXK")
+	assert.EqualError(t, err, "residue 3, 'X', isn't one of the 20 standard amino acids")
+	_, err = proteins.Analyze("*")
+	assert.Error(t, err)
+}
+
+func TestCharge(t *testing.T) {
+	// a protein is positive below its pI and negative above it.
+	pI, err := proteins.IsoelectricPoint(ubiquitin)
+	require.NoError(t, err)
+	below, err := proteins.Charge(ubiquitin, pI-1)
+	require.NoError(t, err)
+	above, err := proteins.Charge(ubiquitin, pI+1)
+	require.NoError(t, err)
+	assert.Greater(t, below, 0.0)
+	assert.Less(t, above, 0.0)
+	at, err := proteins.Charge(ubiquitin, pI)
+	require.NoError(t, err)
+	assert.InDelta(t, 0, at, 0.001)
+
+	// polylysine is basic and polyglutamate acidic.
+	basic, err := proteins.IsoelectricPoint("KKKKKKKKKK")
+	require.NoError(t, err)
+	acidic, err := proteins.IsoelectricPoint("EEEEEEEEEE")
+	require.NoError(t, err)
+	assert.Greater(t, basic, 10.0)
+	assert.Less(t, acidic, 4.0)
+}
+
+func TestInstabilityIndex(t *testing.T) {
+	// one AC dipeptide, weighted 44.94, over two residues.
+	index, err := proteins.InstabilityIndex("ac")
+	require.NoError(t, err)
+	assert.InDelta(t, 10.0/2*44.94, index, 1e-9)
+
+	// a single residue has no dipeptides.
+	index, err = proteins.InstabilityIndex("M")
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, index)
+}
+
+func TestSingleProperties(t *testing.T) {
+	properties, err := proteins.Analyze(gfp)
+	require.NoError(t, err)
+
+	weight, err := proteins.MolecularWeight(gfp)
+	require.NoError(t, err)
+	assert.Equal(t, properties.MolecularWeight, weight)
+	oxidized, reduced, err := proteins.ExtinctionCoefficient(gfp)
+	require.NoError(t, err)
+	assert.Equal(t, properties.ExtinctionCoefficient, oxidized)
+	assert.Equal(t, properties.ExtinctionCoefficientReduced, reduced)
+	gravy, err := proteins.GRAVY(gfp)
+	require.NoError(t, err)
+	assert.Equal(t, properties.GRAVY, gravy)
+	aromaticity, err := proteins.Aromaticity(gfp)
+	require.NoError(t, err)
+	assert.Equal(t, properties.Aromaticity, aromaticity)
+
+	for _, property := range []func(string) (float64, error){proteins.MolecularWeight, proteins.IsoelectricPoint, proteins.GRAVY, proteins.InstabilityIndex, proteins.Aromaticity} {
+		_, err := property("")
+		assert.Error(t, err)
+	}
+	_, _, err = proteins.ExtinctionCoefficient("B")
+	assert.Error(t, err)
+	_, err = proteins.Charge("B", 7)
+	assert.Error(t, err)
+}
@@ -0,0 +1,433 @@
+package align
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bebop/poly/search/align/matrix"
+	"github.com/bebop/poly/sequence/packed"
+)
+
+/******************************************************************************
+
+Affine gap alignment begins here.
+
+NeedlemanWunsch and SmithWaterman charge the same for every base of a gap, so
+one gap of ten bases costs as much as ten gaps of one. Real insertions and
+deletions are usually one event of several bases, and sequencing errors are
+usually a single base, so aligners charge more to open a gap than to extend
+one. Those are affine gap penalties, and aligning with them takes three
+matrices instead of one (Gotoh 1982): one for alignments ending in a match or
+mismatch, and one for each kind of gap they can end in.
+
+When you already know roughly where two sequences line up, like a primer and
+the site it was designed against or a Sanger trace and the stretch of a
+plasmid it was meant to read, only a narrow band of diagonals around that
+line can hold the alignment. Restricting the matrices to the band makes
+alignment take time and memory in proportion to the band's width instead of
+the product of the sequences' lengths.
+
+Alignments come with CIGAR strings, the run length encoded alignments SAM
+files use, with stringA as the query and stringB as the reference.
+
+******************************************************************************/
+
+// AffineScoring holds a substitution matrix and affine gap penalties. A gap of
+// n bases scores GapOpen + (n-1)*GapExtend, so a GapOpen equal to GapExtend
+// scores gaps like Scoring's GapPenalty.
+type AffineScoring struct {
+	SubstitutionMatrix *matrix.SubstitutionMatrix
+	// GapOpen is the score of the first base of a gap, and isn't positive.
+	GapOpen int
+	// GapExtend is the score of every further base of a gap, and isn't
+	// positive.
+	GapExtend int
+}
+
+// NewAffineScoring returns a new AffineScoring, using matrix.Default if
+// substitutionMatrix is nil.
+func NewAffineScoring(substitutionMatrix *matrix.SubstitutionMatrix, gapOpen, gapExtend int) (AffineScoring, error) {
+	if substitutionMatrix == nil {
+		substitutionMatrix = matrix.Default
+	}
+	if gapOpen > 0 || gapExtend > 0 {
+		return AffineScoring{}, errors.New("gap penalties are added to alignment scores and can't be positive")
+	}
+	return AffineScoring{SubstitutionMatrix: substitutionMatrix, GapOpen: gapOpen, GapExtend: gapExtend}, nil
+}
+
+// BandOptions restricts an alignment to a band of diagonals. The zero value
+// aligns without a band.
+type BandOptions struct {
+	// Band is how many diagonals either side of Diagonal the alignment can
+	// use. Zero means the whole matrix is used.
+	Band int
+	// Diagonal is where the band is centered, as the position in stringB
+	// minus the position in stringA that are expected to line up.
+	Diagonal int
+}
+
+// GlobalBand returns a band for globally aligning strings of lengthA and
+// lengthB, wide enough to reach both of their ends with slack diagonals to
+// spare on either side.
+func GlobalBand(lengthA, lengthB, slack int) BandOptions {
+	difference := lengthB - lengthA
+	if difference < 0 {
+		difference = -difference
+	}
+	return BandOptions{Band: difference + slack}
+}
+
+// Alignment is an alignment of two sequences.
+type Alignment struct {
+	Score int `json:"score"`
+	// StartA and EndA are the half-open range of stringA that's aligned,
+	// and StartB and EndB the range of stringB.
+	StartA int `json:"start_a"`
+	EndA   int `json:"end_a"`
+	StartB int `json:"start_b"`
+	EndB   int `json:"end_b"`
+	// AlignedA and AlignedB are the aligned ranges with gaps written as "-".
+	AlignedA string `json:"aligned_a"`
+	AlignedB string `json:"aligned_b"`
+	// Cigar describes the alignment of stringA to stringB using = for
+	// matches, X for mismatches, I for bases only in stringA, D for bases
+	// only in stringB, and S for the bases of stringA outside the alignment.
+	Cigar string `json:"cigar"`
+}
+
+// negativeInfinity is the score of cells no alignment can reach. It's far
+// enough from the smallest int that adding penalties to it can't overflow.
+const negativeInfinity = -1 << 40
+
+// bandedMatrix is a dynamic programming matrix that only stores the cells in
+// its band.
+type bandedMatrix struct {
+	// starts[row] is the first column stored for row.
+	starts []int
+	rows   [][]int
+}
+
+func newBandedMatrix(rows, columns int, options BandOptions) bandedMatrix {
+	banded := bandedMatrix{starts: make([]int, rows+1), rows: make([][]int, rows+1)}
+	for row := 0; row <= rows; row++ {
+		start, end := 0, columns
+		if options.Band > 0 {
+			start = max(0, row+options.Diagonal-options.Band)
+			end = min(columns, row+options.Diagonal+options.Band)
+		}
+		banded.starts[row] = start
+		if end >= start {
+			banded.rows[row] = make([]int, end-start+1)
+			for index := range banded.rows[row] {
+				banded.rows[row][index] = negativeInfinity
+			}
+		}
+	}
+	return banded
+}
+
+// columns returns the range of columns stored for row.
+func (banded bandedMatrix) columns(row int) (start, end int) {
+	return banded.starts[row], banded.starts[row] + len(banded.rows[row]) - 1
+}
+
+func (banded bandedMatrix) get(row, column int) int {
+	if row < 0 {
+		return negativeInfinity
+	}
+	index := column - banded.starts[row]
+	if index < 0 || index >= len(banded.rows[row]) {
+		return negativeInfinity
+	}
+	return banded.rows[row][index]
+}
+
+func (banded bandedMatrix) set(row, column, value int) {
+	banded.rows[row][column-banded.starts[row]] = value
+}
+
+// alignmentMode is which parts of two sequences an alignment has to cover.
+type alignmentMode int
+
+const (
+	// local alignments can start and end anywhere in either sequence.
+	local alignmentMode = iota
+	// global alignments cover both sequences from end to end.
+	global
+	// semiGlobal alignments cover both sequences, but gaps before or after
+	// either of them are free, so they can overhang each other.
+	semiGlobal
+)
+
+// NeedlemanWunschAffine performs global alignment between two strings using
+// the Needleman-Wunsch algorithm with affine gap penalties. With a band, the
+// band has to reach the ends of both strings.
+func NeedlemanWunschAffine(stringA, stringB string, scoring AffineScoring, options BandOptions) (Alignment, error) {
+	return affineAlign(stringA, stringB, scoring, options, global)
+}
+
+// SmithWatermanAffine performs local alignment between two strings using the
+// Smith-Waterman algorithm with affine gap penalties. Without a band it takes
+// O(nm) time and space, and with one O(n*band).
+func SmithWatermanAffine(stringA, stringB string, scoring AffineScoring, options BandOptions) (Alignment, error) {
+	return affineAlign(stringA, stringB, scoring, options, local)
+}
+
+// SemiGlobalAffine performs semi-global alignment between two strings with
+// affine gap penalties. Like global alignment it doesn't stop at mismatches
+// and gaps in the middle, but gaps at either end of either string are free,
+// which suits finding a short sequence in a longer one or the overlap of two
+// sequences. The Alignment covers only where the strings overlap.
+func SemiGlobalAffine(stringA, stringB string, scoring AffineScoring, options BandOptions) (Alignment, error) {
+	return affineAlign(stringA, stringB, scoring, options, semiGlobal)
+}
+
+// SemiGlobalPacked aligns stringA to the range [start, end) of a packed
+// sequence with SemiGlobalAffine, unpacking only that range, so a read can be
+// aligned to a stretch of a packed genome without unpacking all of it. The
+// band's Diagonal is relative to start, and StartB and EndB are positions on
+// the whole packed sequence.
+func SemiGlobalPacked(stringA string, sequenceB packed.Sequence, start, end int, scoring AffineScoring, options BandOptions) (Alignment, error) {
+	region, err := sequenceB.Subsequence(start, end)
+	if err != nil {
+		return Alignment{}, err
+	}
+	alignment, err := SemiGlobalAffine(stringA, strings.ToUpper(region), scoring, options)
+	if err != nil {
+		return Alignment{}, err
+	}
+	alignment.StartB += start
+	alignment.EndB += start
+	return alignment, nil
+}
+
+func affineAlign(stringA, stringB string, scoring AffineScoring, options BandOptions, mode alignmentMode) (Alignment, error) {
+	if scoring.SubstitutionMatrix == nil {
+		scoring.SubstitutionMatrix = matrix.Default
+	}
+	lengthA, lengthB := len(stringA), len(stringB)
+	// best holds the best score of alignments ending at every cell, and
+	// gapInA and gapInB those ending in a gap in stringA or stringB.
+	best := newBandedMatrix(lengthA, lengthB, options)
+	gapInA := newBandedMatrix(lengthA, lengthB, options)
+	gapInB := newBandedMatrix(lengthA, lengthB, options)
+
+	maxScore, maxRow, maxColumn := 0, 0, 0
+	for row := 0; row <= lengthA; row++ {
+		start, end := best.columns(row)
+		for column := start; column <= end; column++ {
+			switch {
+			case row == 0 && column == 0:
+				best.set(row, column, 0)
+				continue
+			case (row == 0 || column == 0) && mode != global:
+				best.set(row, column, 0)
+				continue
+			case row == 0:
+				gapInA.set(row, column, scoring.GapOpen+(column-1)*scoring.GapExtend)
+				best.set(row, column, gapInA.get(row, column))
+				continue
+			case column == 0:
+				gapInB.set(row, column, scoring.GapOpen+(row-1)*scoring.GapExtend)
+				best.set(row, column, gapInB.get(row, column))
+				continue
+			}
+			matchScore, err := scoring.SubstitutionMatrix.Score(string(stringA[row-1]), string(stringB[column-1]))
+			if err != nil {
+				return Alignment{}, err
+			}
+			gapInA.set(row, column, max(best.get(row, column-1)+scoring.GapOpen, gapInA.get(row, column-1)+scoring.GapExtend))
+			gapInB.set(row, column, max(best.get(row-1, column)+scoring.GapOpen, gapInB.get(row-1, column)+scoring.GapExtend))
+			score := max(best.get(row-1, column-1)+matchScore, max(gapInA.get(row, column), gapInB.get(row, column)))
+			if mode == local {
+				score = max(0, score)
+				if score > maxScore {
+					maxScore, maxRow, maxColumn = score, row, column
+				}
+			}
+			best.set(row, column, score)
+		}
+	}
+
+	switch mode {
+	case global:
+		maxScore, maxRow, maxColumn = best.get(lengthA, lengthB), lengthA, lengthB
+		if maxScore <= negativeInfinity/2 {
+			return Alignment{}, errors.New("the band doesn't reach the ends of both strings")
+		}
+	case semiGlobal:
+		// the alignment ends at the end of one string or the other.
+		maxScore = negativeInfinity
+		start, end := best.columns(lengthA)
+		for column := start; column <= end; column++ {
+			if best.get(lengthA, column) > maxScore {
+				maxScore, maxRow, maxColumn = best.get(lengthA, column), lengthA, column
+			}
+		}
+		for row := 0; row <= lengthA; row++ {
+			if best.get(row, lengthB) > maxScore {
+				maxScore, maxRow, maxColumn = best.get(row, lengthB), row, lengthB
+			}
+		}
+		if maxScore <= negativeInfinity/2 {
+			return Alignment{}, errors.New("the band doesn't reach the ends of the strings")
+		}
+	}
+
+	// Trace back from the best cell, keeping track of which matrix the
+	// alignment is in.
+	var operations []byte
+	row, column := maxRow, maxColumn
+	state := byte('M')
+	for row > 0 || column > 0 {
+		if (row == 0 || column == 0) && mode != global {
+			break
+		}
+		if mode == local && state == 'M' && best.get(row, column) == 0 {
+			break
+		}
+		// global alignments finish with whatever is left of either string
+		// aligned against gaps.
+		if row == 0 {
+			operations = append(operations, 'D')
+			column--
+			continue
+		}
+		if column == 0 {
+			operations = append(operations, 'I')
+			row--
+			continue
+		}
+		switch state {
+		case 'M':
+			matchScore, err := scoring.SubstitutionMatrix.Score(string(stringA[row-1]), string(stringB[column-1]))
+			if err != nil {
+				return Alignment{}, err
+			}
+			switch best.get(row, column) {
+			case best.get(row-1, column-1) + matchScore:
+				if strings.EqualFold(stringA[row-1:row], stringB[column-1:column]) {
+					operations = append(operations, '=')
+				} else {
+					operations = append(operations, 'X')
+				}
+				row, column = row-1, column-1
+			case gapInA.get(row, column):
+				state = 'D'
+			default:
+				state = 'I'
+			}
+		case 'D':
+			if gapInA.get(row, column) == best.get(row, column-1)+scoring.GapOpen {
+				state = 'M'
+			}
+			operations = append(operations, 'D')
+			column--
+		case 'I':
+			if gapInB.get(row, column) == best.get(row-1, column)+scoring.GapOpen {
+				state = 'M'
+			}
+			operations = append(operations, 'I')
+			row--
+		}
+	}
+
+	alignment := Alignment{Score: maxScore, StartA: row, EndA: maxRow, StartB: column, EndB: maxColumn}
+	alignment.AlignedA, alignment.AlignedB = alignedStrings(stringA[row:maxRow], stringB[column:maxColumn], operations)
+	alignment.Cigar = cigar(row, operations, lengthA-maxRow)
+	return alignment, nil
+}
+
+// alignedStrings writes two aligned ranges with gaps, from backwards
+// alignment operations.
+func alignedStrings(rangeA, rangeB string, operations []byte) (string, string) {
+	var alignedA, alignedB strings.Builder
+	indexA, indexB := 0, 0
+	for index := len(operations) - 1; index >= 0; index-- {
+		switch operations[index] {
+		case 'I':
+			alignedA.WriteByte(rangeA[indexA])
+			alignedB.WriteByte('-')
+			indexA++
+		case 'D':
+			alignedA.WriteByte('-')
+			alignedB.WriteByte(rangeB[indexB])
+			indexB++
+		default:
+			alignedA.WriteByte(rangeA[indexA])
+			alignedB.WriteByte(rangeB[indexB])
+			indexA++
+			indexB++
+		}
+	}
+	return alignedA.String(), alignedB.String()
+}
+
+// cigar run length encodes backwards alignment operations, soft clipping the
+// unaligned bases of stringA before and after them.
+func cigar(clippedStart int, operations []byte, clippedEnd int) string {
+	var cigar strings.Builder
+	if clippedStart > 0 {
+		cigar.WriteString(strconv.Itoa(clippedStart) + "S")
+	}
+	for end := len(operations); end > 0; {
+		start := end - 1
+		for start > 0 && operations[start-1] == operations[end-1] {
+			start--
+		}
+		cigar.WriteString(strconv.Itoa(end - start))
+		cigar.WriteByte(operations[end-1])
+		end = start
+	}
+	if clippedEnd > 0 {
+		cigar.WriteString(strconv.Itoa(clippedEnd) + "S")
+	}
+	return cigar.String()
+}
+
+// Pretty writes an alignment for reading, in blocks of width columns. Each
+// block has a line of stringA, labeled nameA, and a line of stringB, labeled
+// nameB, each between the 1-based positions of its first and last bases, with
+// a line between them marking matches with | and mismatches with a dot.
+// Bases match whatever their case, as they do when aligning. A width of 0
+// writes blocks of 60 columns.
+func (alignment Alignment) Pretty(nameA, nameB string, width int) string {
+	if width <= 0 {
+		width = 60
+	}
+	nameWidth := max(len(nameA), len(nameB))
+	positionWidth := len(strconv.Itoa(max(alignment.EndA, alignment.EndB)))
+	label := func(name string, position int) string {
+		return fmt.Sprintf("%-*s %*d ", nameWidth, name, positionWidth, position)
+	}
+
+	var pretty strings.Builder
+	positionA, positionB := alignment.StartA, alignment.StartB
+	for start := 0; start < len(alignment.AlignedA); start += width {
+		if start > 0 {
+			pretty.WriteString("\n")
+		}
+		end := min(start+width, len(alignment.AlignedA))
+		blockA, blockB := alignment.AlignedA[start:end], alignment.AlignedB[start:end]
+		var matches strings.Builder
+		for column := 0; column < len(blockA); column++ {
+			switch {
+			case blockA[column] == '-' || blockB[column] == '-':
+				matches.WriteByte(' ')
+			case strings.EqualFold(blockA[column:column+1], blockB[column:column+1]):
+				matches.WriteByte('|')
+			default:
+				matches.WriteByte('.')
+			}
+		}
+		basesA, basesB := len(blockA)-strings.Count(blockA, "-"), len(blockB)-strings.Count(blockB, "-")
+		fmt.Fprintf(&pretty, "%s%s %d\n", label(nameA, positionA+1), blockA, positionA+basesA)
+		fmt.Fprintf(&pretty, "%s%s\n", strings.Repeat(" ", nameWidth+positionWidth+2), strings.TrimRight(matches.String(), " "))
+		fmt.Fprintf(&pretty, "%s%s %d\n", label(nameB, positionB+1), blockB, positionB+basesB)
+		positionA, positionB = positionA+basesA, positionB+basesB
+	}
+	return pretty.String()
+}
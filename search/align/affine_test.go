@@ -0,0 +1,253 @@
+package align_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/search/align"
+	"github.com/bebop/poly/search/align/matrix"
+	"github.com/bebop/poly/sequence/packed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSmithWatermanAffine(t *testing.T) {
+	scoring, err := align.NewAffineScoring(nil, -5, -1)
+	require.NoError(t, err)
+
+	// a gap of eight bases scores -5 - 7.
+	as, cs, gs := strings.Repeat("A", 20), strings.Repeat("C", 8), strings.Repeat("G", 20)
+	alignment, err := align.SmithWatermanAffine("TT"+as+cs+gs, as+gs+"TT", scoring, align.BandOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, align.Alignment{
+		Score:  28,
+		StartA: 2, EndA: 50,
+		StartB: 0, EndB: 40,
+		AlignedA: as + cs + gs,
+		AlignedB: as + "--------" + gs,
+		Cigar:    "2S20=8I20=",
+	}, alignment)
+
+	// and the other way round, it's a deletion.
+	alignment, err = align.SmithWatermanAffine(as+gs, as+cs+gs, scoring, align.BandOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "20=8D20=", alignment.Cigar)
+
+	alignment, err = align.SmithWatermanAffine("ACGTAGGTACGTTTTTACGTACGTACGT", "ACGTACGTACGTTACGTACGTCCGT", scoring, align.BandOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 14, alignment.Score)
+	assert.Equal(t, "5=1X5=3I10=1X3=", alignment.Cigar)
+	assert.Equal(t, "ACGTACGTACG---TTACGTACGTCCGT", alignment.AlignedB)
+}
+
+func TestSmithWatermanAffineLinearGaps(t *testing.T) {
+	// with the same penalty to open and extend a gap, scores are the same
+	// as SmithWaterman's.
+	linear, err := align.NewScoring(nil, -2)
+	require.NoError(t, err)
+	affine, err := align.NewAffineScoring(nil, -2, -2)
+	require.NoError(t, err)
+	for _, pair := range [][2]string{
+		{"GATTACA", "GCATGCT"},
+		{"ACGTAGGTACGTTTTTACGTACGTACGT", "ACGTACGTACGTTACGTACGTCCGT"},
+		{"TTAAAAAAAAAACCCCGGGGGGGGGG", "AAAAAAAAAAGGGGGGGGGGTT"},
+	} {
+		score, alignA, alignB, err := align.SmithWaterman(pair[0], pair[1], linear)
+		require.NoError(t, err)
+		alignment, err := align.SmithWatermanAffine(pair[0], pair[1], affine, align.BandOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, score, alignment.Score, pair)
+		assert.Equal(t, alignA, alignment.AlignedA, pair)
+		assert.Equal(t, alignB, alignment.AlignedB, pair)
+	}
+}
+
+func TestSmithWatermanAffineBanded(t *testing.T) {
+	scoring, err := align.NewAffineScoring(nil, -5, -1)
+	require.NoError(t, err)
+	query, reference := "GATTACAGATTACA", "CCCCCCCCCCGATTACAGATTACACCCCC"
+
+	full, err := align.SmithWatermanAffine(query, reference, scoring, align.BandOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 14, full.Score)
+	assert.Equal(t, 10, full.StartB)
+
+	// a band around where the query really is finds the same alignment.
+	banded, err := align.SmithWatermanAffine(query, reference, scoring, align.BandOptions{Band: 2, Diagonal: 10})
+	require.NoError(t, err)
+	assert.Equal(t, full, banded)
+
+	// and one somewhere else doesn't.
+	banded, err = align.SmithWatermanAffine(query, reference, scoring, align.BandOptions{Band: 2})
+	require.NoError(t, err)
+	assert.Less(t, banded.Score, full.Score)
+
+	// a band entirely outside the matrix finds nothing.
+	banded, err = align.SmithWatermanAffine(query, reference, scoring, align.BandOptions{Band: 2, Diagonal: 100})
+	require.NoError(t, err)
+	assert.Equal(t, 0, banded.Score)
+	assert.Equal(t, "14S", banded.Cigar)
+}
+
+func TestSmithWatermanAffineErrors(t *testing.T) {
+	_, err := align.NewAffineScoring(nil, 5, -1)
+	assert.Error(t, err)
+
+	scoring, err := align.NewAffineScoring(nil, -5, -1)
+	require.NoError(t, err)
+	_, err = align.SmithWatermanAffine("GATTACA", "GAT-ACA", scoring, align.BandOptions{})
+	assert.Error(t, err)
+}
+
+func TestNeedlemanWunschAffine(t *testing.T) {
+	scoring, err := align.NewAffineScoring(nil, -5, -1)
+	require.NoError(t, err)
+
+	as, cs, gs := strings.Repeat("A", 20), strings.Repeat("C", 8), strings.Repeat("G", 20)
+	alignment, err := align.NeedlemanWunschAffine(as+cs+gs, as+gs, scoring, align.BandOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 28, alignment.Score)
+	assert.Equal(t, "20=8I20=", alignment.Cigar)
+
+	// global alignments cover both strings, even where they don't match.
+	alignment, err = align.NeedlemanWunschAffine("GATTACA", "CCCCCGATTACACCCC", scoring, align.BandOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, align.Alignment{
+		Score:  -10,
+		StartA: 0, EndA: 7,
+		StartB: 0, EndB: 16,
+		AlignedA: "-----GATTACA----",
+		AlignedB: "CCCCCGATTACACCCC",
+		Cigar:    "5D7=4D",
+	}, alignment)
+
+	// with the same penalty to open and extend a gap, scores are the same
+	// as NeedlemanWunsch's.
+	linear, err := align.NewScoring(nil, -2)
+	require.NoError(t, err)
+	affine, err := align.NewAffineScoring(nil, -2, -2)
+	require.NoError(t, err)
+	for _, pair := range [][2]string{
+		{"GATTACA", "GCATGCT"},
+		{"ACGTAGGTACGTTTTTACGTACGTACGT", "ACGTACGTACGTTACGTACGTCCGT"},
+		{"GATTACA", "GAT"},
+	} {
+		score, alignA, alignB, err := align.NeedlemanWunsch(pair[0], pair[1], linear)
+		require.NoError(t, err)
+		alignment, err := align.NeedlemanWunschAffine(pair[0], pair[1], affine, align.BandOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, score, alignment.Score, pair)
+		assert.Equal(t, alignA, alignment.AlignedA, pair)
+		assert.Equal(t, alignB, alignment.AlignedB, pair)
+	}
+
+	// a band that doesn't reach the end of both strings can't hold a
+	// global alignment.
+	_, err = align.NeedlemanWunschAffine("GATTACA", "CCCCCGATTACACCCC", scoring, align.BandOptions{Band: 2})
+	assert.Error(t, err)
+	alignment, err = align.NeedlemanWunschAffine("GATTACA", "GATTTACA", scoring, align.BandOptions{Band: 2})
+	require.NoError(t, err)
+	assert.Equal(t, "2=1D5=", alignment.Cigar)
+}
+
+func TestSemiGlobalAffine(t *testing.T) {
+	scoring, err := align.NewAffineScoring(nil, -5, -1)
+	require.NoError(t, err)
+
+	// a short string inside a long one.
+	alignment, err := align.SemiGlobalAffine("GATTACA", "CCCCCGATTACACCCC", scoring, align.BandOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 7, alignment.Score)
+	assert.Equal(t, 5, alignment.StartB)
+	assert.Equal(t, "7=", alignment.Cigar)
+
+	// two strings that overlap.
+	alignment, err = align.SemiGlobalAffine("TTTTTGATTACA", "GATTACACCCC", scoring, align.BandOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, align.Alignment{
+		Score:  7,
+		StartA: 5, EndA: 12,
+		StartB: 0, EndB: 7,
+		AlignedA: "GATTACA",
+		AlignedB: "GATTACA",
+		Cigar:    "5S7=",
+	}, alignment)
+
+	// unlike a local alignment, it doesn't stop at mismatches near the end.
+	alignment, err = align.SemiGlobalAffine("GATTACATT", "GATTACAGG", scoring, align.BandOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "7=2X", alignment.Cigar)
+	alignment, err = align.SmithWatermanAffine("GATTACATT", "GATTACAGG", scoring, align.BandOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "7=2S", alignment.Cigar)
+}
+
+func TestSemiGlobalPacked(t *testing.T) {
+	scoring, err := align.NewAffineScoring(nil, -5, -1)
+	require.NoError(t, err)
+	genome := packed.Pack("NNNNCCCCCgattacaCCCCATTTTT")
+
+	// the read is found in the unpacked range, masked or not, at its place
+	// on the whole genome.
+	alignment, err := align.SemiGlobalPacked("GATTACA", genome, 4, 20, scoring, align.BandOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 9, alignment.StartB)
+	assert.Equal(t, 16, alignment.EndB)
+	assert.Equal(t, "GATTACA", alignment.AlignedB)
+	assert.Equal(t, "7=", alignment.Cigar)
+
+	_, err = align.SemiGlobalPacked("GATTACA", genome, 20, 40, scoring, align.BandOptions{})
+	assert.Error(t, err)
+}
+
+func TestProteinAlignment(t *testing.T) {
+	scoring, err := align.NewAffineScoring(matrix.Blosum62, -11, -1)
+	require.NoError(t, err)
+	// GFP and a variant with an extra valine after its start, a deleted
+	// loop, and a few substitutions.
+	gfp := "MSKGEELFTGVVPILVELDGDVNGHKFSVSGEGEGDATYGKLTLKFICTTGKLPVPWPTLVTTFSYGVQCFSRYPDHMKQHDFFKSAMPEGYVQERTIFFKDDGNYKTRAEVKFEGDTLVNRIELKGIDFKEDGNILGHKLEYNYNSHNVYIMADKQKNGIKVNFKIRHNIEDGSVQLADHYQQNTPIGDGPVLLPDNHYLSTQSALSKDPNEKRDHMVLLEFVTAAGITHGMDELYK"
+	variant := "MVSKGEELFTGVVPILVELDGDVNGHKFSVRGEGEGDATNGKLTLKFICTTGKLPVPWPTLVTTLTYGVQCFSRYPDHMKRHDFFKSAMPEGYVQERTISFKDDGTYKTRAEVKFEGDTLVNRIELKGIDFKEDGNILGHKLEYNFNSHNVYITADKQKNGIKANFKIRHNVEDGSVQLADHYQQNTPIGDGPVLLPDNHYLSTQSVLSKDPNEKRDHMVLLEFVTAAGITLGMDELYK"
+	variant = strings.Replace(variant, "GIDFKEDGNILG", "GIDFLG", 1)
+
+	alignment, err := align.NeedlemanWunschAffine(gfp, variant, scoring, align.BandOptions{})
+	require.NoError(t, err)
+	// the deletion is one gap, not several.
+	assert.Equal(t, 1, strings.Count(alignment.Cigar, "I"))
+	assert.Contains(t, alignment.Cigar, "6I")
+	assert.Equal(t, 1, strings.Count(alignment.Cigar, "D"))
+
+	local, err := align.SmithWatermanAffine(gfp, variant, scoring, align.BandOptions{})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, local.Score, alignment.Score)
+	banded, err := align.SmithWatermanAffine(gfp, variant, scoring, align.BandOptions{Band: 10})
+	require.NoError(t, err)
+	assert.Equal(t, local, banded)
+}
+
+func TestPretty(t *testing.T) {
+	scoring, err := align.NewAffineScoring(nil, -5, -1)
+	require.NoError(t, err)
+
+	// a local alignment numbers its lines from where it starts.
+	alignment, err := align.SmithWatermanAffine("GGGGACGTACGTAAAACGTACGT", "TTACGTACGTACGTACGTTT", scoring, align.BandOptions{})
+	require.NoError(t, err)
+	pretty := alignment.Pretty("a", "b", 0)
+	lines := strings.Split(strings.TrimSuffix(pretty, "\n"), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "a  5 "), lines[0])
+	assert.True(t, strings.HasPrefix(lines[2], "b  3 "), lines[2])
+	assert.Equal(t, len(alignment.AlignedA), strings.Count(lines[1], "|"))
+
+	// bases match whatever their case, like they do when aligning.
+	alignment = align.Alignment{EndA: 8, EndB: 8, AlignedA: "acgtACGT", AlignedB: "ACGTacgt", Cigar: "8="}
+	lines = strings.Split(alignment.Pretty("a", "b", 0), "\n")
+	assert.Equal(t, 8, strings.Count(lines[1], "|"), lines[1])
+
+	assert.Empty(t, align.Alignment{}.Pretty("a", "b", 10))
+}
+
+func TestGlobalBand(t *testing.T) {
+	assert.Equal(t, align.BandOptions{Band: 13}, align.GlobalBand(100, 90, 3))
+	assert.Equal(t, align.BandOptions{Band: 13}, align.GlobalBand(90, 100, 3))
+	assert.Equal(t, align.BandOptions{Band: 3}, align.GlobalBand(90, 90, 3))
+}
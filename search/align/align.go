@@ -43,6 +43,15 @@ takes advantage of more heuristic techniques to speed up alignment, and is bette
 at finding similar sequences in large database, sacrificing precision for faster
 results.
 
+NeedlemanWunschAffine, SmithWatermanAffine, and SemiGlobalAffine align with
+affine gap penalties, which charge more to open a gap than to extend one, and
+can be limited to a band of diagonals when you know roughly where two
+sequences line up. They also return where the alignment is and its CIGAR
+string. See affine.go.
+
+Proteins are aligned the same way as DNA, with a substitution matrix for amino
+acids like matrix.Blosum62 or matrix.Pam250 instead of one for bases.
+
 Both are "dynamic programming algorithms" which is a fancy 1980's term for they use
 matrices. If you're familiar with kernel operations, linear filters, or whatever term
 ML researchers are using nowadays for, "slide a window over a matrix and determine that
@@ -159,6 +168,16 @@ func NeedlemanWunsch(stringA string, stringB string, scoring Scoring) (int, stri
 		}
 	}
 
+	// Whatever is left of either string at the start is aligned against gaps.
+	for ; columnM > 0; columnM-- {
+		alignA = append(alignA, rune(stringA[columnM-1]))
+		alignB = append(alignB, '-')
+	}
+	for ; rowN > 0; rowN-- {
+		alignA = append(alignA, '-')
+		alignB = append(alignB, rune(stringB[rowN-1]))
+	}
+
 	// Reverse the alignments to get the optimal alignment.
 	alignA = reverseRuneArray(alignA)
 	alignB = reverseRuneArray(alignB)
@@ -231,6 +250,22 @@ func SmithWaterman(stringA string, stringB string, scoring Scoring) (int, string
 	return maxScore, alignA, alignB, nil
 }
 
+// Hamming counts the positions two sequences differ at, the distance between
+// sequences that can't shift against each other, like barcodes or overhangs.
+// Positions past the end of the shorter sequence all count as differences.
+func Hamming(stringA, stringB string) int {
+	distance := 0
+	for index := 0; index < len(stringA) && index < len(stringB); index++ {
+		if stringA[index] != stringB[index] {
+			distance++
+		}
+	}
+	if len(stringA) > len(stringB) {
+		return distance + len(stringA) - len(stringB)
+	}
+	return distance + len(stringB) - len(stringA)
+}
+
 func reverseRuneArray(runes []rune) []rune { // wasn't able to find a built-in reverse function for runes
 	length := len(runes)
 	for index := 0; index < length/2; index++ {
@@ -134,6 +134,20 @@ func TestNeedlemanWunsch(t *testing.T) {
 	if score != -5 {
 		t.Errorf("score: %d, A: %s, B: %s", score, alignO, alignP)
 	}
+
+	// check that overhanging starts are kept
+	q := "TTGATTACA"
+	r := "GATTACA"
+
+	score, alignQ, alignR, err := align.NeedlemanWunsch(q, r, scoring)
+
+	if err != nil {
+		t.Errorf("error: %s", err)
+	}
+
+	if score != 5 || alignQ != "TTGATTACA" || alignR != "--GATTACA" {
+		t.Errorf("score: %d, A: %s, B: %s", score, alignQ, alignR)
+	}
 }
 
 func TestSmithWaterman(t *testing.T) {
@@ -290,3 +304,20 @@ func TestSmithWaterman(t *testing.T) {
 		t.Errorf("Alignment is %s, expected G", alignN)
 	}
 }
+
+func TestHamming(t *testing.T) {
+	tests := []struct {
+		stringA, stringB string
+		distance         int
+	}{
+		{"GATTACA", "GATTACA", 0},
+		{"GATTACA", "GACTATA", 2},
+		{"GATTACA", "GATT", 3},
+		{"", "ACGT", 4},
+	}
+	for _, test := range tests {
+		if distance := align.Hamming(test.stringA, test.stringB); distance != test.distance {
+			t.Errorf("Hamming(%q, %q) = %d, expected %d", test.stringA, test.stringB, distance, test.distance)
+		}
+	}
+}
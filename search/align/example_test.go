@@ -109,3 +109,67 @@ func ExampleSmithWaterman_matrix_nuc_4() {
 
 	// Output: score: 15, A: GATTAC, B: GCATGC
 }
+
+func ExampleSmithWatermanAffine() {
+	// a primer missing a base, against the template it came from.
+	primer := "GTAAAACGACGCCAGT"
+	template := "CCCAGTCACGACGTTGTAAAACGACGGCCAGTGAATTCGAG"
+
+	scoring, err := align.NewAffineScoring(nil, -3, -1)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	alignment, err := align.SmithWatermanAffine(primer, template, scoring, align.BandOptions{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Printf("score: %d, position: %d, cigar: %s", alignment.Score, alignment.StartB, alignment.Cigar)
+
+	// Output: score: 13, position: 15, cigar: 10=1D6=
+}
+
+func ExampleSemiGlobalAffine_protein() {
+	// find a thrombin cleavage site in a protein, allowing for substitutions.
+	protein := "MGSSHHHHHHSSGLVPRGSHMASMTGGQQMGRGS"
+	site := "LIPRGS"
+
+	scoring, err := align.NewAffineScoring(matrix.Blosum62, -11, -1)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	alignment, err := align.SemiGlobalAffine(site, protein, scoring, align.BandOptions{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Printf("%s at %d, cigar: %s", alignment.AlignedB, alignment.StartB, alignment.Cigar)
+
+	// Output: LVPRGS at 13, cigar: 1=1X4=
+}
+
+func ExampleAlignment_Pretty() {
+	scoring, err := align.NewAffineScoring(nil, -5, -1)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	alignment, err := align.NeedlemanWunschAffine("ATGACCATGATTACGCCAAGCTTGCATGCC", "ATGACCATGTTTACGCCAAGCTTCATGCC", scoring, align.BandOptions{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(alignment.Pretty("query", "subject", 20))
+	// Output:
+	// query    1 ATGACCATGATTACGCCAAG 20
+	//            |||||||||.||||||||||
+	// subject  1 ATGACCATGTTTACGCCAAG 20
+	//
+	// query   21 CTTGCATGCC 30
+	//            ||| ||||||
+	// subject 21 CTT-CATGCC 29
+}
@@ -47,3 +47,44 @@ func TestSubstitutionMatrix(t *testing.T) {
 		}
 	}
 }
+
+func TestProteinMatrices(t *testing.T) {
+	testCases := []struct {
+		matrix  *matrix.SubstitutionMatrix
+		symbol1 string
+		symbol2 string
+		score   int
+	}{
+		{matrix.Blosum62, "W", "W", 11},
+		{matrix.Blosum62, "A", "R", -1},
+		{matrix.Blosum62, "R", "A", -1},
+		{matrix.Blosum62, "C", "*", -4},
+		{matrix.Blosum80, "A", "A", 7},
+		{matrix.Blosum80, "C", "C", 13},
+		{matrix.Blosum45, "W", "W", 15},
+		{matrix.Pam250, "W", "W", 17},
+		{matrix.Pam250, "C", "C", 12},
+		{matrix.Pam30, "W", "W", 13},
+	}
+	for _, tc := range testCases {
+		score, err := tc.matrix.Score(tc.symbol1, tc.symbol2)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.score, score, "%s %s", tc.symbol1, tc.symbol2)
+	}
+
+	// every protein matrix is symmetric.
+	symbols := matrix.ProteinAlphabet.Symbols()
+	for _, substitutionMatrix := range []*matrix.SubstitutionMatrix{matrix.Blosum45, matrix.Blosum50, matrix.Blosum62, matrix.Blosum80, matrix.Blosum90, matrix.Pam30, matrix.Pam70, matrix.Pam250} {
+		assert.NotNil(t, substitutionMatrix)
+		for _, first := range symbols {
+			for _, second := range symbols {
+				forward, _ := substitutionMatrix.Score(first, second)
+				backward, _ := substitutionMatrix.Score(second, first)
+				assert.Equal(t, forward, backward)
+			}
+		}
+	}
+
+	_, err := matrix.NewProteinMatrix(matrix.NUC_4)
+	assert.Error(t, err)
+}
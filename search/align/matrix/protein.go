@@ -0,0 +1,32 @@
+package matrix
+
+import "github.com/bebop/poly/alphabet"
+
+// ProteinAlphabet is the alphabet the protein scoring matrices in this
+// package are defined over: the twenty amino acids, the ambiguity codes B
+// (D or N), J (I or L), Z (E or Q), and X (any), stops as *, and gaps as -.
+var ProteinAlphabet = alphabet.NewAlphabet([]string{"-", "A", "B", "C", "D", "E", "F", "G", "H", "I", "J", "K", "L", "M", "N", "P", "Q", "R", "S", "T", "V", "W", "X", "Y", "Z", "*"})
+
+// NewProteinMatrix returns a substitution matrix for scores laid out like the
+// protein scoring matrices in this package, like BLOSUM62 or PAM250, over
+// ProteinAlphabet. Use it for any of them not already defined below, or for
+// your own matrix in the same layout.
+func NewProteinMatrix(scores [][]int) (*SubstitutionMatrix, error) {
+	return NewSubstitutionMatrix(ProteinAlphabet, ProteinAlphabet, scores)
+}
+
+// Substitution matrices for aligning proteins. BLOSUM matrices with higher
+// numbers and PAM matrices with lower numbers suit more closely related
+// proteins: BLOSUM62 is the usual default, BLOSUM80 and PAM30 are better for
+// close homologs and short peptides, and BLOSUM45 and PAM250 for distant
+// ones.
+var (
+	Blosum45, _ = NewProteinMatrix(BLOSUM45)
+	Blosum50, _ = NewProteinMatrix(BLOSUM50)
+	Blosum62, _ = NewProteinMatrix(BLOSUM62)
+	Blosum80, _ = NewProteinMatrix(BLOSUM80)
+	Blosum90, _ = NewProteinMatrix(BLOSUM90)
+	Pam30, _    = NewProteinMatrix(PAM30)
+	Pam70, _    = NewProteinMatrix(PAM70)
+	Pam250, _   = NewProteinMatrix(PAM250)
+)
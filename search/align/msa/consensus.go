@@ -0,0 +1,296 @@
+package msa
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/bebop/poly/alphabet"
+	"github.com/bebop/poly/fold"
+	"github.com/bebop/poly/search/align"
+)
+
+/******************************************************************************
+
+Consensus parts begin here.
+
+Parts that have to work in more than one context, like terminators or
+ribosome binding sites, are often best borrowed from nature. If a terminator
+works in a dozen related species, the bases they all agree on are probably
+the ones that matter, and the rest can vary.
+
+Star aligns a set of homologous sequences, Consensus writes down what they
+agree on with IUPAC ambiguity codes for where they don't, and Candidates
+picks a concrete sequence to actually order. For parts like terminators
+that work by folding, a consensus that doesn't fold like any of the real
+sequences is a bad bet, so every candidate is folded and the consensus is
+only preferred if its folding energy falls within the range of the
+homologs'.
+
+******************************************************************************/
+
+// Star makes a multiple sequence alignment of sequences using the center star
+// method: the sequence most similar to all the others is picked as the
+// center, every other sequence is aligned to it with NeedlemanWunsch, and the
+// pairwise alignments are merged, adding gaps wherever any sequence had an
+// insertion. It's quick and simple, though not as good as progressive
+// aligners for very divergent sequences.
+func Star(sequences []string, scoring align.Scoring) ([]string, error) {
+	if len(sequences) == 0 {
+		return nil, fmt.Errorf("no sequences to align")
+	}
+	upperSequences := make([]string, len(sequences))
+	for index, sequence := range sequences {
+		upperSequences[index] = strings.ToUpper(sequence)
+	}
+
+	// pick the center by its total score against every other sequence.
+	center, bestTotal := 0, math.MinInt
+	for first := range upperSequences {
+		total := 0
+		for second := range upperSequences {
+			if first == second {
+				continue
+			}
+			score, _, _, err := align.NeedlemanWunsch(upperSequences[first], upperSequences[second], scoring)
+			if err != nil {
+				return nil, err
+			}
+			total += score
+		}
+		if total > bestTotal {
+			center, bestTotal = first, total
+		}
+	}
+	centerSequence := upperSequences[center]
+
+	// insertions[position] is the most residues any sequence inserts before
+	// center position position, with the last slot after the end.
+	insertions := make([]int, len(centerSequence)+1)
+	pairwise := make([][2]string, len(upperSequences))
+	for index, sequence := range upperSequences {
+		if index == center {
+			continue
+		}
+		_, alignedCenter, alignedSequence, err := align.NeedlemanWunsch(centerSequence, sequence, scoring)
+		if err != nil {
+			return nil, err
+		}
+		pairwise[index] = [2]string{alignedCenter, alignedSequence}
+		position, run := 0, 0
+		for column := 0; column < len(alignedCenter); column++ {
+			if alignedCenter[column] == '-' {
+				run++
+				continue
+			}
+			insertions[position] = max(insertions[position], run)
+			position, run = position+1, 0
+		}
+		insertions[position] = max(insertions[position], run)
+	}
+
+	alignment := make([]string, len(upperSequences))
+	for index := range upperSequences {
+		alignedCenter, alignedSequence := centerSequence, centerSequence
+		if index != center {
+			alignedCenter, alignedSequence = pairwise[index][0], pairwise[index][1]
+		}
+		var row strings.Builder
+		position, inserted := 0, []byte{}
+		flush := func() {
+			row.Write(inserted)
+			row.WriteString(strings.Repeat("-", insertions[position]-len(inserted)))
+			inserted = inserted[:0]
+		}
+		for column := 0; column < len(alignedCenter); column++ {
+			if alignedCenter[column] == '-' {
+				inserted = append(inserted, alignedSequence[column])
+				continue
+			}
+			flush()
+			row.WriteByte(alignedSequence[column])
+			position++
+		}
+		flush()
+		alignment[index] = row.String()
+	}
+	return alignment, nil
+}
+
+// Consensus returns the IUPAC consensus of a nucleotide alignment. In every
+// column, the most common bases are added until they make up at least
+// threshold (from 0 to 1) of the column's bases, and the column becomes the
+// ambiguity code covering them. A threshold of 1 covers every base. Columns
+// that are gaps in most sequences are left out. Like alphabet.AmbiguityCode,
+// the consensus is written as DNA, so RNA alignments get T instead of U.
+func Consensus(alignment []string, threshold float64) (string, error) {
+	counts, err := Counts(alignment)
+	if err != nil {
+		return "", err
+	}
+	if threshold <= 0 || threshold > 1 {
+		return "", fmt.Errorf("consensus threshold %f isn't between 0 and 1", threshold)
+	}
+	var consensus strings.Builder
+	for index, column := range counts {
+		bases, total := byFrequency(column)
+		if 2*total <= len(alignment) {
+			continue
+		}
+		covered := 0
+		var chosen []byte
+		for _, base := range bases {
+			chosen = append(chosen, base)
+			covered += column[base]
+			if float64(covered) >= threshold*float64(total) {
+				break
+			}
+		}
+		code := alphabet.AmbiguityCode(string(chosen))
+		if code == 0 {
+			return "", fmt.Errorf("column %d has residues %q, which aren't nucleotides", index, chosen)
+		}
+		consensus.WriteByte(code)
+	}
+	return consensus.String(), nil
+}
+
+// Majority returns the majority rule consensus of an alignment: the most
+// common residue of every column, breaking ties alphabetically. Columns that
+// are gaps in most sequences are left out.
+func Majority(alignment []string) (string, error) {
+	counts, err := Counts(alignment)
+	if err != nil {
+		return "", err
+	}
+	var majority strings.Builder
+	for _, column := range counts {
+		residues, total := byFrequency(column)
+		if 2*total > len(alignment) {
+			majority.WriteByte(residues[0])
+		}
+	}
+	return majority.String(), nil
+}
+
+// byFrequency sorts the residues of a column from most to least common,
+// breaking ties alphabetically, and counts them.
+func byFrequency(column map[byte]int) ([]byte, int) {
+	residues := make([]byte, 0, len(column))
+	total := 0
+	for residue, count := range column {
+		residues = append(residues, residue)
+		total += count
+	}
+	sort.Slice(residues, func(i, j int) bool {
+		if column[residues[i]] != column[residues[j]] {
+			return column[residues[i]] > column[residues[j]]
+		}
+		return residues[i] < residues[j]
+	})
+	return residues, total
+}
+
+// Candidate is a concrete sequence that could stand in for an alignment.
+type Candidate struct {
+	Sequence    string
+	Row         int     // row of the alignment the candidate came from, or -1 for the majority consensus.
+	Agreement   float64 // fraction of the degenerate consensus the candidate's bases fit, from 0 to 1.
+	Energy      float64 // minimum free energy in kcal/mol, or 0 if nothing folds.
+	TypicalFold bool    // whether Energy falls within the range of the homologs' energies.
+}
+
+// Candidates folds every sequence of a nucleotide alignment and its majority
+// consensus at temp degrees Celsius, and ranks them as representatives of the
+// alignment, best first. Candidates that fold like the homologs come first,
+// then those that agree most with the degenerate consensus at threshold,
+// then those with an energy closest to the homologs' average. The majority
+// consensus agrees perfectly with the degenerate consensus, so it comes first
+// unless it folds unlike any homolog.
+func Candidates(alignment []string, threshold, temp float64) ([]Candidate, error) {
+	consensus, err := Consensus(alignment, threshold)
+	if err != nil {
+		return nil, err
+	}
+	majority, err := Majority(alignment)
+	if err != nil {
+		return nil, err
+	}
+	// the columns consensus and majority were written from.
+	var kept []int
+	counts, _ := Counts(alignment)
+	for column := range counts {
+		if _, total := byFrequency(counts[column]); 2*total > len(alignment) {
+			kept = append(kept, column)
+		}
+	}
+
+	var candidates []Candidate
+	lowest, highest, totalEnergy := math.Inf(1), math.Inf(-1), 0.0
+	for row, aligned := range alignment {
+		var sequence strings.Builder
+		for index := 0; index < len(aligned); index++ {
+			if !isGap(aligned[index]) {
+				sequence.WriteByte(upper(aligned[index]))
+			}
+		}
+		energy, err := foldingEnergy(sequence.String(), temp)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row, err)
+		}
+		lowest, highest, totalEnergy = min(lowest, energy), max(highest, energy), totalEnergy+energy
+
+		agreement := 0
+		for index, column := range kept {
+			if residue := upper(aligned[column]); !isGap(residue) && alphabet.AmbiguityCode(string([]byte{consensus[index], residue})) == consensus[index] {
+				agreement++
+			}
+		}
+		candidates = append(candidates, Candidate{
+			Sequence:  sequence.String(),
+			Row:       row,
+			Agreement: float64(agreement) / float64(max(len(kept), 1)),
+			Energy:    energy,
+		})
+	}
+
+	majorityEnergy, err := foldingEnergy(majority, temp)
+	if err != nil {
+		return nil, fmt.Errorf("majority consensus: %w", err)
+	}
+	// the majority consensus goes first so that it wins ties.
+	candidates = append([]Candidate{{Sequence: majority, Row: -1, Agreement: 1, Energy: majorityEnergy}}, candidates...)
+
+	meanEnergy := totalEnergy / float64(len(alignment))
+	for index := range candidates {
+		candidates[index].TypicalFold = candidates[index].Energy >= lowest && candidates[index].Energy <= highest
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		first, second := candidates[i], candidates[j]
+		if first.TypicalFold != second.TypicalFold {
+			return first.TypicalFold
+		}
+		if first.Agreement != second.Agreement {
+			return first.Agreement > second.Agreement
+		}
+		return math.Abs(first.Energy-meanEnergy) < math.Abs(second.Energy-meanEnergy)
+	})
+	return candidates, nil
+}
+
+// foldingEnergy folds a sequence, counting sequences that can't fold as 0 kcal/mol.
+func foldingEnergy(sequence string, temp float64) (float64, error) {
+	if sequence == "" {
+		return 0, nil
+	}
+	result, err := fold.Zuker(sequence, temp)
+	if err != nil {
+		return 0, err
+	}
+	energy := result.MinimumFreeEnergy()
+	if math.IsInf(energy, 0) || math.IsNaN(energy) {
+		return 0, nil
+	}
+	return energy, nil
+}
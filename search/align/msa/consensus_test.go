@@ -0,0 +1,88 @@
+package msa
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/search/align"
+	"github.com/bebop/poly/search/align/matrix"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStar(t *testing.T) {
+	scoring, err := align.NewScoring(matrix.Default, -1)
+	require.NoError(t, err)
+
+	sequences := []string{"GATTACA", "gattaca", "GATTTACA", "GATACA", "CCGATTACA"}
+	alignment, err := Star(sequences, scoring)
+	require.NoError(t, err)
+	require.Len(t, alignment, len(sequences))
+	for index, row := range alignment {
+		assert.Len(t, row, len(alignment[0]))
+		assert.Equal(t, strings.ToUpper(sequences[index]), strings.ReplaceAll(row, "-", ""))
+	}
+	assert.Equal(t, "--GATTACA", alignment[0][:2]+strings.ReplaceAll(alignment[0][2:], "-", ""))
+	assert.Equal(t, "CCGATTACA", strings.ReplaceAll(alignment[4], "-", ""))
+
+	alignment, err = Star([]string{"ACGT"}, scoring)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ACGT"}, alignment)
+
+	_, err = Star(nil, scoring)
+	assert.Error(t, err)
+}
+
+func TestConsensus(t *testing.T) {
+	alignment := []string{
+		"ACGTA-",
+		"ACGTA-",
+		"ACGCG-",
+		"ATGCAT",
+	}
+	consensus, err := Consensus(alignment, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "AYGYR", consensus)
+
+	consensus, err = Consensus(alignment, 0.7)
+	require.NoError(t, err)
+	assert.Equal(t, "ACGYA", consensus)
+
+	majority, err := Majority(alignment)
+	require.NoError(t, err)
+	assert.Equal(t, "ACGCA", majority)
+
+	_, err = Consensus(alignment, 0)
+	assert.Error(t, err)
+	_, err = Consensus([]string{"EFQ", "EFQ"}, 1)
+	assert.Error(t, err)
+	_, err = Majority(nil)
+	assert.Error(t, err)
+}
+
+func TestCandidates(t *testing.T) {
+	// rho independent terminator hairpins with a few differences.
+	alignment := []string{
+		"AAAAGCCCGCUCAUUAGGCGGGCUUUUUUU",
+		"AAAAGCCCGCUCAUUAGGCGGGCUUUUUUU",
+		"AAAAGCCCGCUCAUCAGGCGGGCUUUUUUU",
+		"AAAAGCCCGCUAAUUAGGCGGGCUUUUUUA",
+	}
+	candidates, err := Candidates(alignment, 1, 37)
+	require.NoError(t, err)
+	require.Len(t, candidates, 5)
+	assert.Equal(t, -1, candidates[0].Row)
+	assert.Equal(t, alignment[0], candidates[0].Sequence)
+	assert.True(t, candidates[0].TypicalFold)
+	assert.Less(t, candidates[0].Energy, 0.0)
+	for _, candidate := range candidates {
+		assert.Equal(t, 1.0, candidate.Agreement)
+	}
+
+	candidates, err = Candidates(alignment, 0.6, 37)
+	require.NoError(t, err)
+	assert.Less(t, candidates[len(candidates)-1].Agreement, 1.0)
+
+	_, err = Candidates([]string{"EFQ", "EFQ"}, 1, 37)
+	assert.Error(t, err)
+}
@@ -0,0 +1,55 @@
+package msa_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/search/align"
+	"github.com/bebop/poly/search/align/matrix"
+	"github.com/bebop/poly/search/align/msa"
+)
+
+func ExampleReference() {
+	alignment := []string{
+		"MKV-LLAG",
+		"MKVELLSG",
+		"MRV-LLAG",
+		"MKI-LLTG",
+	}
+	columns, _ := msa.Reference(alignment, 0, msa.Options{})
+	for _, column := range columns {
+		fmt.Printf("%c%d %.2f\n", column.Residue, column.Position+1, column.Conservation)
+	}
+	// Output:
+	// M1 1.00
+	// K2 0.77
+	// V3 0.77
+	// L4 1.00
+	// L5 1.00
+	// A6 0.57
+	// G7 1.00
+}
+
+func ExampleVariable() {
+	alignment := []string{
+		"ATGAAAGTTCTG",
+		"ATGAAGGTGCTG",
+		"ATGAAAGTACTG",
+	}
+	columns, _ := msa.Reference(alignment, 0, msa.Options{Background: msa.UniformBackground("ACGT")})
+	fmt.Println(msa.Variable(columns, 0.6))
+	// Output: [5 8]
+}
+
+func ExampleConsensus() {
+	terminators := []string{
+		"GCCCGCUCAUUAGGCGGGC",
+		"GCCCGCUCAUCAGGCGGGC",
+		"GCCCGCUAAUUAGGCGGGC",
+		"GCCUGCUCAUUAGCAGGC",
+	}
+	scoring, _ := align.NewScoring(matrix.Default, -1)
+	alignment, _ := msa.Star(terminators, scoring)
+	consensus, _ := msa.Consensus(alignment, 1)
+	fmt.Println(consensus)
+	// Output: GCCYGCTMATYAGGCRGGC
+}
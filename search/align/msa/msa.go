@@ -0,0 +1,265 @@
+/*
+Package msa contains utilities for working with multiple sequence alignments.
+
+A multiple sequence alignment lines up a set of related sequences, like the
+same enzyme from a few dozen species, so that every column holds residues
+that descend from a common ancestor. Gaps are written as "-" (or "."):
+
+	MKV-LLAG
+	MKVELLSG
+	MRV-LLAG
+
+Looking down a column tells you how much evolution has put up with at that
+position. A column that's the same in every species is usually holding
+something important up, while a column that's all over the place is probably
+safe to mess with. That's useful to know when you're designing a library of
+mutants and would rather not waste most of it on dead enzymes.
+
+This package scores every column by its Shannon entropy, its relative entropy
+against a background distribution, and a conservation score from 0 to 1, and
+maps the columns back to the coordinates of a reference sequence in the
+alignment so you can line them up with your own construct. It can also build
+a quick alignment out of a set of homologs, and turn one into a degenerate
+IUPAC consensus part.
+
+For more on conservation scores:
+Capra and Singh, 2007
+https://doi.org/10.1093/bioinformatics/btm270
+*/
+package msa
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// isGap checks if a symbol of an alignment is a gap.
+func isGap(symbol byte) bool {
+	return symbol == '-' || symbol == '.'
+}
+
+// check makes sure an alignment has at least one row and that every row is
+// the same length.
+func check(alignment []string) error {
+	if len(alignment) == 0 {
+		return fmt.Errorf("alignment is empty")
+	}
+	for index, row := range alignment {
+		if len(row) != len(alignment[0]) {
+			return fmt.Errorf("row %d of alignment has length %d, expected %d", index, len(row), len(alignment[0]))
+		}
+	}
+	return nil
+}
+
+// Counts returns how many times every uppercase residue shows up in each
+// column of an alignment. Gaps aren't counted.
+func Counts(alignment []string) ([]map[byte]int, error) {
+	if err := check(alignment); err != nil {
+		return nil, err
+	}
+	counts := make([]map[byte]int, len(alignment[0]))
+	for column := range counts {
+		counts[column] = make(map[byte]int)
+		for _, row := range alignment {
+			if symbol := row[column]; !isGap(symbol) {
+				counts[column][upper(symbol)]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+// upper uppercases an ASCII letter.
+func upper(symbol byte) byte {
+	if symbol >= 'a' && symbol <= 'z' {
+		return symbol - 'a' + 'A'
+	}
+	return symbol
+}
+
+// Background returns the frequency of every residue across a whole
+// alignment, ignoring gaps. It's the default background for relative
+// entropy.
+func Background(alignment []string) (map[byte]float64, error) {
+	counts, err := Counts(alignment)
+	if err != nil {
+		return nil, err
+	}
+	total := 0
+	totals := make(map[byte]int)
+	for _, column := range counts {
+		for symbol, count := range column {
+			totals[symbol] += count
+			total += count
+		}
+	}
+	background := make(map[byte]float64)
+	for symbol, count := range totals {
+		background[symbol] = float64(count) / float64(total)
+	}
+	return background, nil
+}
+
+// UniformBackground returns a background where every symbol of alphabet is
+// equally likely, like UniformBackground("ACGT") for DNA.
+func UniformBackground(alphabet string) map[byte]float64 {
+	background := make(map[byte]float64)
+	alphabet = strings.ToUpper(alphabet)
+	for index := 0; index < len(alphabet); index++ {
+		background[alphabet[index]] = 0
+	}
+	for symbol := range background {
+		background[symbol] = 1 / float64(len(background))
+	}
+	return background
+}
+
+/******************************************************************************
+
+Column scoring begins here.
+
+Shannon entropy measures how spread out a column is, in bits. A column with a
+single residue has an entropy of 0, and a DNA column with all four bases in
+equal amounts has an entropy of 2.
+
+Relative entropy (the Kullback-Leibler divergence) measures how different a
+column is from the background, also in bits. It's a better signal than plain
+entropy when the background is skewed: a column of all A's in a very AT rich
+genome is less surprising than it looks.
+
+Conservation rescales entropy to run from 0 (as spread out as the alphabet
+allows) to 1 (identical in every sequence), and then scales that down by the
+fraction of sequences that aren't gapped in the column, so that a column
+that's conserved in the two sequences that have it isn't mistaken for a
+column conserved in all of them.
+
+******************************************************************************/
+
+// Column is the conservation of a single alignment column.
+type Column struct {
+	Column          int          // 0-based column of the alignment.
+	Position        int          // 0-based position in the reference sequence, or -1 if the reference is gapped here.
+	Residue         byte         // residue of the reference sequence, or the gap.
+	Counts          map[byte]int // count of every residue in the column, gaps excluded.
+	GapFraction     float64      // fraction of sequences gapped in the column.
+	Entropy         float64      // Shannon entropy of the column's residues, in bits.
+	RelativeEntropy float64      // relative entropy of the column's residues against the background, in bits.
+	Conservation    float64      // from 0 (variable) to 1 (conserved), penalized by gaps.
+}
+
+// Options changes how columns are scored.
+type Options struct {
+	// Background is the residue distribution relative entropy is measured
+	// against, and its size sets the most entropy a column can have. Defaults
+	// to the residue frequencies of the whole alignment.
+	Background map[byte]float64
+	// Pseudocount is added to the count of every background residue in each
+	// column, which keeps small alignments from looking more conserved than
+	// they are. Defaults to 0.
+	Pseudocount float64
+}
+
+// Score scores every column of an alignment. reference is the index of the
+// row whose coordinates go into each Column's Position.
+func Score(alignment []string, reference int, options Options) ([]Column, error) {
+	counts, err := Counts(alignment)
+	if err != nil {
+		return nil, err
+	}
+	if reference < 0 || reference >= len(alignment) {
+		return nil, fmt.Errorf("reference %d isn't a row of an alignment of %d sequences", reference, len(alignment))
+	}
+	background := options.Background
+	if background == nil {
+		background, err = Background(alignment)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(background) == 0 {
+		return nil, fmt.Errorf("background is empty")
+	}
+	// sorted so that sums come out the same every time.
+	symbols := make([]byte, 0, len(background))
+	for symbol, frequency := range background {
+		if frequency <= 0 || math.IsNaN(frequency) {
+			return nil, fmt.Errorf("background frequency of %q is %f, expected a positive number", symbol, frequency)
+		}
+		symbols = append(symbols, symbol)
+	}
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i] < symbols[j] })
+	backgroundTotal := 0.0
+	for _, symbol := range symbols {
+		backgroundTotal += background[symbol]
+	}
+	maxEntropy := math.Log2(float64(len(symbols)))
+
+	columns := make([]Column, len(counts))
+	position := 0
+	for index, columnCounts := range counts {
+		residue := alignment[reference][index]
+		column := Column{Column: index, Position: -1, Residue: residue, Counts: columnCounts}
+		if !isGap(residue) {
+			column.Position = position
+			position++
+		}
+
+		residues := 0
+		for symbol, count := range columnCounts {
+			if _, ok := background[symbol]; !ok {
+				return nil, fmt.Errorf("column %d has %q, which isn't in the background", index, symbol)
+			}
+			residues += count
+		}
+		column.GapFraction = 1 - float64(residues)/float64(len(alignment))
+
+		total := float64(residues) + options.Pseudocount*float64(len(symbols))
+		if total > 0 {
+			for _, symbol := range symbols {
+				frequency := (float64(columnCounts[symbol]) + options.Pseudocount) / total
+				if frequency == 0 {
+					continue
+				}
+				column.Entropy -= frequency * math.Log2(frequency)
+				column.RelativeEntropy += frequency * math.Log2(frequency/(background[symbol]/backgroundTotal))
+			}
+			column.Conservation = 1 - column.GapFraction
+			if maxEntropy > 0 {
+				column.Conservation *= 1 - column.Entropy/maxEntropy
+			}
+		}
+		columns[index] = column
+	}
+	return columns, nil
+}
+
+// Reference is like Score, but only returns the columns where the reference
+// isn't gapped, so the score of reference position i is at index i.
+func Reference(alignment []string, reference int, options Options) ([]Column, error) {
+	columns, err := Score(alignment, reference, options)
+	if err != nil {
+		return nil, err
+	}
+	var mapped []Column
+	for _, column := range columns {
+		if column.Position != -1 {
+			mapped = append(mapped, column)
+		}
+	}
+	return mapped, nil
+}
+
+// Variable returns the 0-based reference positions whose conservation is at
+// most maxConservation, which are the best places to start mutating.
+func Variable(columns []Column, maxConservation float64) []int {
+	var positions []int
+	for _, column := range columns {
+		if column.Position != -1 && column.Conservation <= maxConservation {
+			positions = append(positions, column.Position)
+		}
+	}
+	return positions
+}
@@ -0,0 +1,96 @@
+package msa
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var alignment = []string{
+	"ACGT-A",
+	"ACGTTA",
+	"AGGA-A",
+	"acCT-G",
+}
+
+func TestCounts(t *testing.T) {
+	counts, err := Counts(alignment)
+	require.NoError(t, err)
+	assert.Equal(t, map[byte]int{'A': 4}, counts[0])
+	assert.Equal(t, map[byte]int{'C': 3, 'G': 1}, counts[1])
+	assert.Equal(t, map[byte]int{'T': 1}, counts[4])
+
+	_, err = Counts(nil)
+	assert.Error(t, err)
+	_, err = Counts([]string{"ACGT", "ACG"})
+	assert.Error(t, err)
+}
+
+func TestBackground(t *testing.T) {
+	background, err := Background(alignment)
+	require.NoError(t, err)
+	total := 0.0
+	for _, frequency := range background {
+		total += frequency
+	}
+	assert.InDelta(t, 1, total, 1e-12)
+	assert.InDelta(t, 8.0/21, background['A'], 1e-12)
+
+	assert.Equal(t, map[byte]float64{'A': 0.25, 'C': 0.25, 'G': 0.25, 'T': 0.25}, UniformBackground("acgtACGT"))
+}
+
+func TestScore(t *testing.T) {
+	columns, err := Score(alignment, 0, Options{Background: UniformBackground("ACGT")})
+	require.NoError(t, err)
+	require.Len(t, columns, 6)
+
+	// a fully conserved column.
+	assert.Equal(t, 0.0, columns[0].Entropy)
+	assert.InDelta(t, 2, columns[0].RelativeEntropy, 1e-12)
+	assert.Equal(t, 1.0, columns[0].Conservation)
+
+	// three to one.
+	want := -(0.75*math.Log2(0.75) + 0.25*math.Log2(0.25))
+	assert.InDelta(t, want, columns[1].Entropy, 1e-12)
+	assert.InDelta(t, 1-want/2, columns[1].Conservation, 1e-12)
+
+	// a gap in the reference, and in most of the column.
+	assert.Equal(t, -1, columns[4].Position)
+	assert.Equal(t, byte('-'), columns[4].Residue)
+	assert.Equal(t, 0.75, columns[4].GapFraction)
+	assert.Equal(t, 0.25, columns[4].Conservation)
+	assert.Equal(t, 4, columns[5].Position)
+
+	// pseudocounts pull conserved columns towards the background.
+	smoothed, err := Score(alignment, 0, Options{Background: UniformBackground("ACGT"), Pseudocount: 1})
+	require.NoError(t, err)
+	assert.Greater(t, smoothed[0].Entropy, 0.0)
+	assert.Less(t, smoothed[0].Conservation, 1.0)
+
+	_, err = Score(alignment, 4, Options{})
+	assert.Error(t, err)
+	_, err = Score(alignment, 0, Options{Background: UniformBackground("AC")})
+	assert.Error(t, err)
+	_, err = Score(alignment, 0, Options{Background: map[byte]float64{'A': 0}})
+	assert.Error(t, err)
+	_, err = Score(alignment, 0, Options{Background: map[byte]float64{}})
+	assert.Error(t, err)
+}
+
+func TestReference(t *testing.T) {
+	columns, err := Reference(alignment, 1, Options{})
+	require.NoError(t, err)
+	assert.Len(t, columns, 6)
+
+	columns, err = Reference(alignment, 0, Options{})
+	require.NoError(t, err)
+	require.Len(t, columns, 5)
+	for position, column := range columns {
+		assert.Equal(t, position, column.Position)
+		assert.Equal(t, alignment[0][column.Column], column.Residue)
+	}
+	assert.Equal(t, []int{1, 2, 3, 4}, Variable(columns, 0.9))
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, Variable(columns, 1))
+}
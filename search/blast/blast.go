@@ -0,0 +1,450 @@
+/*
+Package blast searches NCBI's databases with BLAST, through the BLAST URL API.
+
+Checking a guide RNA or primer for off-target hits, or confirming what a
+sequence is, usually means pasting it into the BLAST web page and reading
+the results by eye. NCBI also runs BLAST for programs, through its URL API
+(https://blast.ncbi.nlm.nih.gov/doc/blast-help/urlapi.html): a search is
+submitted, which returns a request ID, the request is polled until the
+search finishes, and then its results are downloaded.
+
+Submit, RequestStatus, and Results each do one of those steps, and Search
+does all three, waiting between polls. Results come back as Hits, one per
+high scoring pair, with the same columns as BLAST's tabular output.
+ParseTabular and ParseXML read hits from BLAST's tabular (-outfmt 6 or 7) and
+XML (-outfmt 5) output, so results from a local BLAST+ can be read the same
+way.
+
+NCBI asks that programs poll a request no more than once a minute, send an
+email address to be contacted at, and don't run more than one search at a
+time, so the defaults here are slow on purpose. BLAST searches against nt
+often take a few minutes. For many searches, a local BLAST+ and database
+are the better choice.
+*/
+package blast
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultURL is NCBI's BLAST URL API.
+const DefaultURL = "https://blast.ncbi.nlm.nih.gov/Blast.cgi"
+
+// Options changes how searches are run. Zero values are replaced with the
+// defaults noted on each field.
+type Options struct {
+	// Program is the BLAST program, like blastn, blastp, blastx, tblastn, or
+	// tblastx. Defaults to blastn.
+	Program string
+	// Database is the database searched, like nt, refseq_rna, or nr.
+	// Defaults to nt.
+	Database string
+	// Megablast searches with megablast, which is faster for finding nearly
+	// identical sequences. Only for blastn.
+	Megablast bool
+	// Expect is the E-value threshold of hits. NCBI's default is 10 for
+	// blastn and 0.05 for the other programs.
+	Expect float64
+	// HitListSize is the most subjects returned. NCBI's default is 100.
+	HitListSize int
+	// EntrezQuery limits the subjects searched, like "Escherichia coli[organism]".
+	EntrezQuery string
+
+	// Email is the address NCBI contacts about problems with your searches,
+	// and Tool is the name of the program running them. Tool defaults to
+	// poly.
+	Email, Tool string
+
+	URL    string       // defaults to DefaultURL.
+	Client *http.Client // defaults to http.DefaultClient.
+	// PollInterval is how long Search waits between polls. Defaults to a
+	// minute, the shortest NCBI allows.
+	PollInterval time.Duration
+	// Timeout is how long Search waits for a search to finish before giving
+	// up. Defaults to an hour.
+	Timeout time.Duration
+}
+
+func defaults(options Options) Options {
+	if options.Program == "" {
+		options.Program = "blastn"
+	}
+	if options.Database == "" {
+		options.Database = "nt"
+	}
+	if options.Tool == "" {
+		options.Tool = "poly"
+	}
+	if options.URL == "" {
+		options.URL = DefaultURL
+	}
+	if options.Client == nil {
+		options.Client = http.DefaultClient
+	}
+	if options.PollInterval == 0 {
+		options.PollInterval = time.Minute
+	}
+	if options.Timeout == 0 {
+		options.Timeout = time.Hour
+	}
+	return options
+}
+
+// Hit is a high scoring pair of a query and a subject, a local alignment
+// between them. A subject that aligns to a query in more than one place has
+// a hit for each.
+type Hit struct {
+	QueryID   string
+	SubjectID string
+	// SubjectTitle is the description of the subject. It's only read from
+	// XML.
+	SubjectTitle string
+	// PercentIdentity is the percentage of the aligned columns that match.
+	PercentIdentity float64
+	AlignmentLength int
+	Mismatches      int
+	GapOpens        int
+	// QueryStart, QueryEnd, SubjectStart, and SubjectEnd are where the hit
+	// is, as BLAST reports them: 1-based and inclusive, with SubjectStart
+	// after SubjectEnd for hits on the subject's minus strand.
+	QueryStart, QueryEnd     int
+	SubjectStart, SubjectEnd int
+	EValue                   float64
+	BitScore                 float64
+	// AlignedQuery and AlignedSubject are the aligned sequences, with gaps
+	// as -. They're only read from XML.
+	AlignedQuery, AlignedSubject string
+}
+
+// Status is the status of a submitted search.
+type Status string
+
+// The statuses of a search.
+const (
+	Waiting Status = "WAITING"
+	Ready   Status = "READY"
+	Failed  Status = "FAILED"
+	// Unknown is the status of requests NCBI doesn't have, because they
+	// expired or never existed.
+	Unknown Status = "UNKNOWN"
+)
+
+/******************************************************************************
+
+BLAST URL API begins here.
+
+The URL API answers with web pages, and hides the parts meant for programs
+in QBlastInfo comments:
+
+	<!--QBlastInfoBegin
+		RID = 1ABCDEFG013
+		RTOE = 26
+	QBlastInfoEnd
+	-->
+
+Submit reads the request ID (RID) and estimated time to completion (RTOE)
+from them, and RequestStatus reads the status of the search and whether it found
+anything. Results are downloaded as XML, since it has the aligned sequences
+as well as the columns of the tabular output.
+
+******************************************************************************/
+
+// Search submits a query, waits for it to finish, and returns its hits. The
+// query is a sequence, a FASTA record, or an accession.
+func Search(query string, options Options) ([]Hit, error) {
+	options = defaults(options)
+	requestID, estimate, err := Submit(query, options)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(options.Timeout)
+	// nothing is ready before NCBI's estimate.
+	wait := max(estimate, options.PollInterval)
+	for {
+		if time.Now().Add(wait).After(deadline) {
+			return nil, fmt.Errorf("BLAST request %s didn't finish within %s", requestID, options.Timeout)
+		}
+		time.Sleep(wait)
+		wait = options.PollInterval
+
+		status, hasHits, err := RequestStatus(requestID, options)
+		if err != nil {
+			return nil, err
+		}
+		switch status {
+		case Waiting:
+			continue
+		case Ready:
+			if !hasHits {
+				return nil, nil
+			}
+			return Results(requestID, options)
+		case Failed:
+			return nil, fmt.Errorf("BLAST request %s failed", requestID)
+		default:
+			return nil, fmt.Errorf("BLAST request %s is unknown to NCBI, and may have expired", requestID)
+		}
+	}
+}
+
+// Submit submits a query, and returns its request ID and NCBI's estimate of
+// how long it'll take to finish.
+func Submit(query string, options Options) (requestID string, estimate time.Duration, err error) {
+	options = defaults(options)
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", 0, fmt.Errorf("query is empty")
+	}
+	form := url.Values{
+		"CMD":      {"Put"},
+		"PROGRAM":  {options.Program},
+		"DATABASE": {options.Database},
+		"QUERY":    {query},
+		"TOOL":     {options.Tool},
+	}
+	if options.Megablast {
+		form.Set("MEGABLAST", "on")
+	}
+	if options.Expect != 0 {
+		form.Set("EXPECT", strconv.FormatFloat(options.Expect, 'g', -1, 64))
+	}
+	if options.HitListSize != 0 {
+		form.Set("HITLIST_SIZE", strconv.Itoa(options.HitListSize))
+	}
+	if options.EntrezQuery != "" {
+		form.Set("ENTREZ_QUERY", options.EntrezQuery)
+	}
+	if options.Email != "" {
+		form.Set("EMAIL", options.Email)
+	}
+
+	response, err := options.Client.PostForm(options.URL, form)
+	if err != nil {
+		return "", 0, err
+	}
+	body, err := readResponse(response, "submitting BLAST search")
+	if err != nil {
+		return "", 0, err
+	}
+	info := qblastInfo(body)
+	requestID = info["RID"]
+	if requestID == "" {
+		return "", 0, fmt.Errorf("submitting BLAST search to %s: response has no request ID", options.URL)
+	}
+	seconds, _ := strconv.Atoi(info["RTOE"])
+	return requestID, time.Duration(seconds) * time.Second, nil
+}
+
+// RequestStatus returns the status of a submitted search, and whether it
+// found any hits once it's Ready.
+func RequestStatus(requestID string, options Options) (status Status, hasHits bool, err error) {
+	options = defaults(options)
+	response, err := options.Client.Get(options.URL + "?" + url.Values{
+		"CMD":           {"Get"},
+		"FORMAT_OBJECT": {"SearchInfo"},
+		"RID":           {requestID},
+		"TOOL":          {options.Tool},
+	}.Encode())
+	if err != nil {
+		return "", false, err
+	}
+	body, err := readResponse(response, "checking BLAST request "+requestID)
+	if err != nil {
+		return "", false, err
+	}
+	info := qblastInfo(body)
+	if info["Status"] == "" {
+		return "", false, fmt.Errorf("checking BLAST request %s: response has no status", requestID)
+	}
+	return Status(info["Status"]), info["ThereAreHits"] == "yes", nil
+}
+
+// Results downloads the hits of a finished search.
+func Results(requestID string, options Options) ([]Hit, error) {
+	options = defaults(options)
+	response, err := options.Client.Get(options.URL + "?" + url.Values{
+		"CMD":         {"Get"},
+		"FORMAT_TYPE": {"XML"},
+		"RID":         {requestID},
+		"TOOL":        {options.Tool},
+	}.Encode())
+	if err != nil {
+		return nil, err
+	}
+	body, err := readResponse(response, "downloading BLAST results "+requestID)
+	if err != nil {
+		return nil, err
+	}
+	return ParseXML(strings.NewReader(body))
+}
+
+// readResponse reads the body of a response, and errors for anything but
+// 200 OK.
+func readResponse(response *http.Response, doing string) (string, error) {
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", doing, response.Status)
+	}
+	body, err := io.ReadAll(response.Body)
+	return string(body), err
+}
+
+var (
+	qblastInfoRegex = regexp.MustCompile(`(?s)QBlastInfoBegin(.*?)QBlastInfoEnd`)
+	keyValueRegex   = regexp.MustCompile(`(\w+)\s*=\s*(\S+)`)
+)
+
+// qblastInfo reads the keys and values of the QBlastInfo comments of a
+// page.
+func qblastInfo(page string) map[string]string {
+	info := make(map[string]string)
+	for _, block := range qblastInfoRegex.FindAllStringSubmatch(page, -1) {
+		for _, pair := range keyValueRegex.FindAllStringSubmatch(block[1], -1) {
+			info[pair[1]] = pair[2]
+		}
+	}
+	return info
+}
+
+/******************************************************************************
+
+Result parsing begins here.
+
+******************************************************************************/
+
+// ParseTabular reads hits from BLAST's tabular output, with or without
+// comment lines, in its 12 standard columns: query ID, subject ID, percent
+// identity, alignment length, mismatches, gap opens, query start and end,
+// subject start and end, E-value, and bit score.
+func ParseTabular(reader io.Reader) ([]Hit, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	var hits []Hit
+	for lineNumber, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 12 {
+			return nil, fmt.Errorf("line %d: has %d columns instead of 12", lineNumber+1, len(fields))
+		}
+		hit := Hit{QueryID: fields[0], SubjectID: fields[1]}
+		var parseErr error
+		parseFloat := func(field string) float64 {
+			value, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil && parseErr == nil {
+				parseErr = err
+			}
+			return value
+		}
+		parseInt := func(field string) int {
+			value, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil && parseErr == nil {
+				parseErr = err
+			}
+			return value
+		}
+		hit.PercentIdentity = parseFloat(fields[2])
+		hit.AlignmentLength = parseInt(fields[3])
+		hit.Mismatches = parseInt(fields[4])
+		hit.GapOpens = parseInt(fields[5])
+		hit.QueryStart, hit.QueryEnd = parseInt(fields[6]), parseInt(fields[7])
+		hit.SubjectStart, hit.SubjectEnd = parseInt(fields[8]), parseInt(fields[9])
+		hit.EValue = parseFloat(fields[10])
+		hit.BitScore = parseFloat(fields[11])
+		if parseErr != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber+1, parseErr)
+		}
+		hits = append(hits, hit)
+	}
+	return hits, nil
+}
+
+// blastOutput is the part of BLAST's XML output that hits are read from.
+type blastOutput struct {
+	Iterations []struct {
+		QueryID  string `xml:"Iteration_query-ID"`
+		QueryDef string `xml:"Iteration_query-def"`
+		Hits     []struct {
+			ID        string `xml:"Hit_id"`
+			Def       string `xml:"Hit_def"`
+			Accession string `xml:"Hit_accession"`
+			HSPs      []struct {
+				BitScore    float64 `xml:"Hsp_bit-score"`
+				EValue      float64 `xml:"Hsp_evalue"`
+				QueryFrom   int     `xml:"Hsp_query-from"`
+				QueryTo     int     `xml:"Hsp_query-to"`
+				HitFrom     int     `xml:"Hsp_hit-from"`
+				HitTo       int     `xml:"Hsp_hit-to"`
+				Identity    int     `xml:"Hsp_identity"`
+				Gaps        int     `xml:"Hsp_gaps"`
+				AlignLength int     `xml:"Hsp_align-len"`
+				QuerySeq    string  `xml:"Hsp_qseq"`
+				HitSeq      string  `xml:"Hsp_hseq"`
+			} `xml:"Hit_hsps>Hsp"`
+		} `xml:"Iteration_hits>Hit"`
+	} `xml:"BlastOutput_iterations>Iteration"`
+}
+
+// ParseXML reads hits from BLAST's XML output.
+func ParseXML(reader io.Reader) ([]Hit, error) {
+	var output blastOutput
+	if err := xml.NewDecoder(reader).Decode(&output); err != nil {
+		return nil, fmt.Errorf("parsing BLAST XML: %w", err)
+	}
+	var hits []Hit
+	for _, iteration := range output.Iterations {
+		queryID := iteration.QueryID
+		// the definition line starts with the query's own ID, if it has one.
+		if fields := strings.Fields(iteration.QueryDef); len(fields) > 0 && iteration.QueryDef != "No definition line" {
+			queryID = fields[0]
+		}
+		for _, subject := range iteration.Hits {
+			for _, hsp := range subject.HSPs {
+				hit := Hit{
+					QueryID:         queryID,
+					SubjectID:       subject.ID,
+					SubjectTitle:    subject.Def,
+					AlignmentLength: hsp.AlignLength,
+					Mismatches:      hsp.AlignLength - hsp.Identity - hsp.Gaps,
+					GapOpens:        gapOpens(hsp.QuerySeq) + gapOpens(hsp.HitSeq),
+					QueryStart:      hsp.QueryFrom,
+					QueryEnd:        hsp.QueryTo,
+					SubjectStart:    hsp.HitFrom,
+					SubjectEnd:      hsp.HitTo,
+					EValue:          hsp.EValue,
+					BitScore:        hsp.BitScore,
+					AlignedQuery:    hsp.QuerySeq,
+					AlignedSubject:  hsp.HitSeq,
+				}
+				if hsp.AlignLength > 0 {
+					hit.PercentIdentity = 100 * float64(hsp.Identity) / float64(hsp.AlignLength)
+				}
+				hits = append(hits, hit)
+			}
+		}
+	}
+	return hits, nil
+}
+
+// gapOpens counts the runs of gaps in an aligned sequence.
+func gapOpens(aligned string) int {
+	opens := 0
+	for index := 0; index < len(aligned); index++ {
+		if aligned[index] == '-' && (index == 0 || aligned[index-1] != '-') {
+			opens++
+		}
+	}
+	return opens
+}
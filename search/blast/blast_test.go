@@ -0,0 +1,194 @@
+package blast
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBLAST serves the URL API, answering polls with WAITING until it's been
+// polled waits times.
+func fakeBLAST(t *testing.T, waits int, hits bool) (*httptest.Server, *requests) {
+	t.Helper()
+	results, err := os.ReadFile("data/results.xml")
+	require.NoError(t, err)
+	submitted := &requests{}
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.NoError(t, request.ParseForm())
+		switch {
+		case request.Form.Get("CMD") == "Put":
+			submitted.form = request.PostForm
+			_, _ = writer.Write([]byte("<html><!--QBlastInfoBegin\n    RID = TESTRID01\n    RTOE = 0\nQBlastInfoEnd\n--></html>"))
+		case request.Form.Get("RID") != "TESTRID01":
+			_, _ = writer.Write([]byte("<!--QBlastInfoBegin\n\tStatus=UNKNOWN\nQBlastInfoEnd\n-->"))
+		case request.Form.Get("FORMAT_OBJECT") == "SearchInfo":
+			submitted.polls++
+			status := "READY"
+			if submitted.polls <= waits {
+				status = "WAITING"
+			}
+			thereAreHits := "no"
+			if hits {
+				thereAreHits = "yes"
+			}
+			_, _ = writer.Write([]byte("<!--QBlastInfoBegin\n\tStatus=" + status + "\nQBlastInfoEnd\n-->\n<!--QBlastInfoBegin\n\tThereAreHits=" + thereAreHits + "\nQBlastInfoEnd\n-->"))
+		case request.Form.Get("FORMAT_TYPE") == "XML":
+			_, _ = writer.Write(results)
+		default:
+			http.Error(writer, "bad request", http.StatusBadRequest)
+		}
+	}))
+	return server, submitted
+}
+
+// requests records what was sent to fakeBLAST.
+type requests struct {
+	form  map[string][]string
+	polls int
+}
+
+func TestSearch(t *testing.T) {
+	server, submitted := fakeBLAST(t, 2, true)
+	defer server.Close()
+
+	hits, err := Search("ATGGTGAGCAAGGGCGAGGAGCTGTTCACCGGGGTGGTGC", Options{
+		URL:          server.URL,
+		Client:       server.Client(),
+		Megablast:    true,
+		EntrezQuery:  "Escherichia coli[organism]",
+		Email:        "someone@example.com",
+		PollInterval: time.Millisecond,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, submitted.polls)
+	assert.Equal(t, []string{"blastn"}, submitted.form["PROGRAM"])
+	assert.Equal(t, []string{"nt"}, submitted.form["DATABASE"])
+	assert.Equal(t, []string{"on"}, submitted.form["MEGABLAST"])
+	assert.Equal(t, []string{"Escherichia coli[organism]"}, submitted.form["ENTREZ_QUERY"])
+	assert.Equal(t, []string{"poly"}, submitted.form["TOOL"])
+	require.Len(t, hits, 2)
+	assert.Equal(t, "gb|U55762.1|", hits[0].SubjectID)
+}
+
+func TestSearchNoHits(t *testing.T) {
+	server, _ := fakeBLAST(t, 0, false)
+	defer server.Close()
+
+	hits, err := Search("ATGC", Options{URL: server.URL, PollInterval: time.Millisecond})
+	assert.NoError(t, err)
+	assert.Empty(t, hits)
+}
+
+func TestSearchTimeout(t *testing.T) {
+	server, _ := fakeBLAST(t, 1000, true)
+	defer server.Close()
+
+	_, err := Search("ATGC", Options{URL: server.URL, PollInterval: 10 * time.Millisecond, Timeout: 50 * time.Millisecond})
+	assert.ErrorContains(t, err, "didn't finish")
+}
+
+func TestRequestStatus(t *testing.T) {
+	server, _ := fakeBLAST(t, 1, true)
+	defer server.Close()
+	options := Options{URL: server.URL}
+
+	status, _, err := RequestStatus("TESTRID01", options)
+	require.NoError(t, err)
+	assert.Equal(t, Waiting, status)
+	status, hasHits, err := RequestStatus("TESTRID01", options)
+	require.NoError(t, err)
+	assert.Equal(t, Ready, status)
+	assert.True(t, hasHits)
+
+	status, _, err = RequestStatus("EXPIRED", options)
+	require.NoError(t, err)
+	assert.Equal(t, Unknown, status)
+}
+
+func TestSubmitErrors(t *testing.T) {
+	_, _, err := Submit(" \n", Options{})
+	assert.Error(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Query().Get("down") != "" {
+			http.Error(writer, "down", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = writer.Write([]byte("<html>Error: no query</html>"))
+	}))
+	defer server.Close()
+
+	_, _, err = Submit("ATGC", Options{URL: server.URL})
+	assert.ErrorContains(t, err, "no request ID")
+	_, _, err = Submit("ATGC", Options{URL: server.URL + "?down=yes"})
+	assert.ErrorContains(t, err, "503")
+}
+
+func TestParseXML(t *testing.T) {
+	file, err := os.Open("data/results.xml")
+	require.NoError(t, err)
+	defer file.Close()
+	hits, err := ParseXML(file)
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+
+	assert.Equal(t, "gfp_fragment", hits[0].QueryID)
+	assert.Equal(t, 100.0, hits[0].PercentIdentity)
+	assert.Equal(t, 0, hits[0].Mismatches)
+	assert.Equal(t, 0, hits[0].GapOpens)
+	assert.Equal(t, 679, hits[0].SubjectStart)
+	assert.Equal(t, 2.1e-10, hits[0].EValue)
+	assert.True(t, strings.HasPrefix(hits[0].SubjectTitle, "Cloning vector pEGFP-N1"))
+
+	// the second hit is on the minus strand, with a gap in the query.
+	assert.Equal(t, 3, hits[1].Mismatches)
+	assert.Equal(t, 1, hits[1].GapOpens)
+	assert.Equal(t, 1500, hits[1].SubjectStart)
+	assert.Equal(t, 1462, hits[1].SubjectEnd)
+	assert.InDelta(t, 89.744, hits[1].PercentIdentity, 0.001)
+	assert.Equal(t, "GGTGAGCAAGGGCGAGGAG-CTGTTCACCGGGGTGGTGC", hits[1].AlignedQuery)
+
+	_, err = ParseXML(strings.NewReader("<BlastOutput>"))
+	assert.Error(t, err)
+}
+
+func TestParseTabular(t *testing.T) {
+	file, err := os.Open("data/results.tsv")
+	require.NoError(t, err)
+	defer file.Close()
+	tabular, err := ParseTabular(file)
+	require.NoError(t, err)
+	require.Len(t, tabular, 2)
+
+	file, err = os.Open("data/results.xml")
+	require.NoError(t, err)
+	defer file.Close()
+	fromXML, err := ParseXML(file)
+	require.NoError(t, err)
+
+	// the tabular and XML outputs of a search agree, but for how they name
+	// subjects and how much they round.
+	for index := range tabular {
+		assert.Equal(t, fromXML[index].QueryID, tabular[index].QueryID)
+		assert.InDelta(t, fromXML[index].PercentIdentity, tabular[index].PercentIdentity, 0.001)
+		assert.Equal(t, fromXML[index].AlignmentLength, tabular[index].AlignmentLength)
+		assert.Equal(t, fromXML[index].Mismatches, tabular[index].Mismatches)
+		assert.Equal(t, fromXML[index].GapOpens, tabular[index].GapOpens)
+		assert.Equal(t, fromXML[index].QueryStart, tabular[index].QueryStart)
+		assert.Equal(t, fromXML[index].QueryEnd, tabular[index].QueryEnd)
+		assert.Equal(t, fromXML[index].SubjectStart, tabular[index].SubjectStart)
+		assert.Equal(t, fromXML[index].SubjectEnd, tabular[index].SubjectEnd)
+		assert.InDelta(t, fromXML[index].BitScore, tabular[index].BitScore, 0.1)
+	}
+
+	_, err = ParseTabular(strings.NewReader("query\tsubject\t100\n"))
+	assert.ErrorContains(t, err, "line 1")
+	_, err = ParseTabular(strings.NewReader("query\tsubject\t100\tforty\t0\t0\t1\t40\t1\t40\t1e-10\t74.8\n"))
+	assert.ErrorContains(t, err, "line 1")
+}
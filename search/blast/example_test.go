@@ -0,0 +1,21 @@
+package blast_test
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bebop/poly/search/blast"
+)
+
+func ExampleParseXML() {
+	file, _ := os.Open("data/results.xml")
+	defer file.Close()
+	hits, _ := blast.ParseXML(file)
+
+	for _, hit := range hits {
+		fmt.Printf("%s %.1f%% identity, E-value %.2g\n", hit.SubjectID, hit.PercentIdentity, hit.EValue)
+	}
+	// Output:
+	// gb|U55762.1| 100.0% identity, E-value 2.1e-10
+	// gb|MN000001.1| 89.7% identity, E-value 0.00083
+}
@@ -0,0 +1,150 @@
+package fmindex
+
+import (
+	"sort"
+	"strings"
+)
+
+/******************************************************************************
+
+Approximate search begins here.
+
+LocateApproximate finds the places a pattern occurs with a few substitutions,
+insertions, or deletions, by backtracking through backward search: at every
+position of the pattern it tries every symbol of the index, paying an edit
+for each one that isn't the pattern's, as well as skipping a base of the
+pattern or of the text. Branches stop as soon as they've run out of edits or
+out of rows, so a search with a couple of edits only visits a small part of
+the index, but every edit allowed multiplies the work, so this is meant for
+short patterns and small edit distances.
+
+An occurrence with an edit can usually be shifted over by a base for one more
+edit, so matches next to a closer one are dropped, leaving only the best
+match of each place a pattern occurs.
+
+******************************************************************************/
+
+// ApproximateMatch is a match of a pattern with up to a few edits.
+type ApproximateMatch struct {
+	Match
+	Sequence string // the indexed text that matched, which may differ from the pattern in length.
+	Edits    int    // substitutions, insertions, and deletions between the pattern and Sequence.
+}
+
+// approximateSearch holds the state of a single pattern's approximate search.
+type approximateSearch struct {
+	index    *Index
+	symbols  []byte
+	pattern  string
+	maxEdits int
+	found    map[int]ApproximateMatch // keyed by position in the joined text.
+	text     []byte                   // matched text, built backwards.
+}
+
+// edit operations, so a walk never follows an insertion with a deletion or
+// the other way around, which a substitution always does better.
+const (
+	substitution = iota
+	insertion
+	deletion
+)
+
+// LocateApproximate returns every occurrence of pattern in the indexed
+// sequences within maxEdits substitutions, insertions, and deletions, ordered
+// by sequence and then by position. Each place the pattern occurs is only
+// reported once, with the fewest edits it can be matched with.
+func (index *Index) LocateApproximate(pattern string, maxEdits int) []ApproximateMatch {
+	if pattern == "" || maxEdits < 0 {
+		return nil
+	}
+	search := approximateSearch{
+		index:    index,
+		symbols:  index.Symbols(),
+		pattern:  strings.ToUpper(pattern),
+		maxEdits: maxEdits,
+		found:    make(map[int]ApproximateMatch),
+	}
+	search.walk(index.Full(), len(pattern)-1, 0, substitution)
+
+	positions := make([]int, 0, len(search.found))
+	for position, match := range search.found {
+		if !search.shadowed(position, match) {
+			positions = append(positions, position)
+		}
+	}
+	sort.Ints(positions)
+	matches := make([]ApproximateMatch, len(positions))
+	for match, position := range positions {
+		matches[match] = search.found[position]
+	}
+	return matches
+}
+
+// walk extends rows with the pattern from position down to its start. last
+// is the edit that got it here.
+func (search *approximateSearch) walk(rows Range, position, edits, last int) {
+	if position < 0 {
+		search.record(rows, edits)
+		return
+	}
+	canEdit := edits < search.maxEdits
+	// a deletion at either end of the pattern would only add a base to the
+	// match for nothing, so the text's extra bases have to be inside it.
+	canDelete := canEdit && last != insertion && position < len(search.pattern)-1
+
+	for _, symbol := range search.symbols {
+		next := search.index.Extend(rows, symbol)
+		if next.Empty() {
+			continue
+		}
+		search.text = append(search.text, symbol)
+		switch {
+		case symbol == search.pattern[position]:
+			search.walk(next, position-1, edits, substitution)
+		case canEdit:
+			search.walk(next, position-1, edits+1, substitution)
+		}
+		if canDelete {
+			// the text has a base the pattern doesn't.
+			search.walk(next, position, edits+1, deletion)
+		}
+		search.text = search.text[:len(search.text)-1]
+	}
+	if canEdit && last != deletion {
+		// the pattern has a base the text doesn't.
+		search.walk(rows, position-1, edits+1, insertion)
+	}
+}
+
+// record adds every occurrence of rows to the search's results, keeping the
+// closest match of places found more than once.
+func (search *approximateSearch) record(rows Range, edits int) {
+	if len(search.text) == 0 {
+		// the whole pattern was inserted, which matches nothing.
+		return
+	}
+	sequence := make([]byte, len(search.text))
+	for index, symbol := range search.text {
+		sequence[len(sequence)-1-index] = symbol
+	}
+	for row := rows.Start; row < rows.End; row++ {
+		position := search.index.locate(row)
+		existing, ok := search.found[position]
+		if ok && (existing.Edits < edits || (existing.Edits == edits && len(existing.Sequence) <= len(sequence))) {
+			continue
+		}
+		search.found[position] = ApproximateMatch{Match: search.index.match(position), Sequence: string(sequence), Edits: edits}
+	}
+}
+
+// shadowed checks if a match is just a closer match next to it, shifted over
+// by a base or two and paying for it in edits.
+func (search *approximateSearch) shadowed(position int, match ApproximateMatch) bool {
+	for shift := -search.maxEdits; shift <= search.maxEdits; shift++ {
+		neighbor, ok := search.found[position+shift]
+		if shift != 0 && ok && neighbor.Name == match.Name && neighbor.Edits < match.Edits {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package fmindex_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/search/fmindex"
+)
+
+func ExampleIndex_Locate() {
+	index, _ := fmindex.New([]fasta.Fasta{
+		{Name: "chromosome", Sequence: "ATGCGTACGTTAGCATGCGTACCGATTACA"},
+		{Name: "plasmid", Sequence: "GGGATTACACCCATGCGTAC"},
+	})
+	fmt.Println(index.Count("ATGCGTAC"))
+	for _, match := range index.Locate("GATTACA") {
+		fmt.Println(match.Name, match.Position)
+	}
+	// Output:
+	// 3
+	// chromosome 23
+	// plasmid 2
+}
+
+func ExampleIndex_LocateApproximate() {
+	index, _ := fmindex.New([]fasta.Fasta{{Name: "chromosome", Sequence: "TTTTGATTACATTTTGATCACATTTTGATACATTTT"}})
+	for _, match := range index.LocateApproximate("GATTACA", 1) {
+		fmt.Println(match.Position, match.Sequence, match.Edits)
+	}
+	// Output:
+	// 4 GATTACA 0
+	// 15 GATCACA 1
+	// 26 GATACA 1
+}
@@ -0,0 +1,484 @@
+/*
+Package fmindex is a package for fast exact search of genome sized sequences.
+
+Checking that a guide RNA, primer, or homology arm is unique in a genome
+means searching the whole genome for it. strings.Index does that by reading
+all of the genome for every query, which is fine once but painfully slow for
+a library of thousands of guides.
+
+An FM-index does the reading once, up front. It stores the Burrows-Wheeler
+transform of the genome along with a few small tables, and can then count
+the occurrences of any pattern in time proportional to the length of the
+pattern, no matter how big the genome is. Finding where those occurrences
+are takes a few more steps per hit. The index can be written to disk and read
+back, so an index of your favorite genome only ever needs to be built once.
+
+Patterns that don't quite match, like primers against a related strain or
+reads with sequencing errors, can be found with a few substitutions,
+insertions, or deletions by LocateApproximate, which backtracks through the
+index instead of reading the whole genome.
+
+The bwt package implements a run-length compressed BWT that's well suited for
+exploring how the transform works and for small, repetitive sequences. This
+package trades some of that compression for a linear time suffix array
+construction, a flat layout that's quick to save and load, and support for
+indexing many named sequences (like the chromosomes of a genome) at once.
+
+For more on FM-indexes:
+Ferragina and Manzini, 2000
+https://doi.org/10.1109/SFCS.2000.892127
+*/
+package fmindex
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bebop/poly/io/fasta"
+)
+
+const (
+	// separator joins the indexed sequences, so no match can span two of them.
+	separator = '$'
+	// occurrenceInterval is how many BWT rows apart the occurrence checkpoints
+	// are. Smaller is faster to search and bigger is smaller to store.
+	occurrenceInterval = 128
+	// suffixInterval is how many text positions apart the sampled suffix array
+	// entries are. Locating a match takes at most this many steps.
+	suffixInterval = 32
+	// formatVersion is bumped whenever the serialized format changes.
+	formatVersion = 1
+)
+
+// Match is an exact match of a pattern.
+type Match struct {
+	Name     string // name of the indexed sequence the match is in.
+	Position int    // 0-based position of the start of the match within that sequence.
+}
+
+// Index is an FM-index of a set of sequences. Sequences and patterns are
+// uppercased, so searches are case insensitive. An Index is safe to search
+// from several goroutines at once.
+type Index struct {
+	names  []string
+	starts []int // start of every sequence in the joined text.
+
+	transform   []byte // BWT of the joined text, with 0 at the sentinel row.
+	sentinelRow int
+	// symbols lists the symbols of the text in sorted order, and symbolCodes
+	// maps each byte back to its index in symbols, or -1.
+	symbols     []byte
+	symbolCodes [256]int
+	// firstRows[code] is the first row starting with symbols[code], the C
+	// array of the FM-index literature.
+	firstRows []int
+	// occurrences[checkpoint*len(symbols)+code] counts symbols[code] in
+	// transform[:checkpoint*occurrenceInterval].
+	occurrences []uint32
+
+	// sampled marks the rows whose suffix starts at a multiple of
+	// suffixInterval, and samples holds those suffixes in row order.
+	sampled rankVector
+	samples []uint32
+}
+
+// New builds an FM-index of sequences, which are looked up by their names in
+// matches. Sequences may not contain "$" or null bytes.
+func New(sequences []fasta.Fasta) (*Index, error) {
+	if len(sequences) == 0 {
+		return nil, errors.New("no sequences to index")
+	}
+	total := 0
+	for _, sequence := range sequences {
+		total += len(sequence.Sequence) + 1
+	}
+	if total >= math.MaxInt32 {
+		return nil, fmt.Errorf("sequences are %d bases long, an index can hold at most %d", total, math.MaxInt32-1)
+	}
+
+	index := &Index{}
+	text := make([]byte, 0, total)
+	for _, sequence := range sequences {
+		if strings.IndexByte(sequence.Sequence, separator) != -1 || strings.IndexByte(sequence.Sequence, 0) != -1 {
+			return nil, fmt.Errorf("sequence %q contains %q or a null byte, which can't be indexed", sequence.Name, separator)
+		}
+		index.names = append(index.names, sequence.Name)
+		index.starts = append(index.starts, len(text))
+		text = append(text, strings.ToUpper(sequence.Sequence)...)
+		text = append(text, separator)
+	}
+
+	// the text's last separator doubles as the sentinel that ends it, and
+	// every other byte is shifted up by one to make room for it.
+	symbolsPresent := [256]bool{}
+	encoded := make([]int32, len(text))
+	for position, symbol := range text[:len(text)-1] {
+		encoded[position] = int32(symbol) + 1
+		symbolsPresent[symbol] = true
+	}
+	suffixes := suffixArray(encoded, 257)
+
+	for code := range index.symbolCodes {
+		index.symbolCodes[code] = -1
+	}
+	for symbol, present := range symbolsPresent {
+		if present {
+			index.symbolCodes[symbol] = len(index.symbols)
+			index.symbols = append(index.symbols, byte(symbol))
+		}
+	}
+
+	index.transform = make([]byte, len(text))
+	index.sampled = newRankVector(len(text))
+	counts := make([]int, len(index.symbols))
+	for row, suffix := range suffixes {
+		if row%occurrenceInterval == 0 {
+			for _, count := range counts {
+				index.occurrences = append(index.occurrences, uint32(count))
+			}
+		}
+		if suffix%suffixInterval == 0 {
+			index.sampled.set(row)
+			index.samples = append(index.samples, uint32(suffix))
+		}
+		if suffix == 0 {
+			index.sentinelRow = row
+			continue
+		}
+		symbol := text[suffix-1]
+		index.transform[row] = symbol
+		counts[index.symbolCodes[symbol]]++
+	}
+	if len(text)%occurrenceInterval == 0 {
+		// searches can end on the row just past the end.
+		for _, count := range counts {
+			index.occurrences = append(index.occurrences, uint32(count))
+		}
+	}
+	index.sampled.finish()
+
+	index.firstRows = make([]int, len(index.symbols))
+	row := 1 // the sentinel sorts first.
+	for code, count := range counts {
+		index.firstRows[code] = row
+		row += count
+	}
+	return index, nil
+}
+
+// Len returns the total length of the indexed sequences.
+func (index *Index) Len() int {
+	return len(index.transform) - len(index.names)
+}
+
+// Names returns the names of the indexed sequences, in the order they were
+// indexed.
+func (index *Index) Names() []string {
+	return append([]string{}, index.names...)
+}
+
+// Count returns the number of times pattern occurs in the indexed sequences.
+func (index *Index) Count(pattern string) int {
+	return index.search(pattern).Len()
+}
+
+// Locate returns every occurrence of pattern in the indexed sequences,
+// ordered by sequence and then by position.
+func (index *Index) Locate(pattern string) []Match {
+	return index.LocateRange(index.search(pattern))
+}
+
+// search returns the range of rows that start with pattern, using backward
+// search: extend the match one symbol at a time from the end of the pattern,
+// narrowing the range of rows at every step.
+func (index *Index) search(pattern string) Range {
+	if pattern == "" {
+		return Range{}
+	}
+	rows := index.Full()
+	for position := len(pattern) - 1; position >= 0 && !rows.Empty(); position-- {
+		rows = index.Extend(rows, pattern[position])
+	}
+	return rows
+}
+
+/******************************************************************************
+
+Backward search begins here.
+
+Count and Locate look for exact matches, but plenty of searches aren't exact:
+guides with a few mismatches, patterns with ambiguous bases, reads with
+sequencing errors. All of them can be built out of the same step backward
+search takes, which is exposed here as Range and Extend.
+
+A Range is a set of rows of the index whose suffixes all start with the same
+text, which starts out empty and matches everywhere. Extend prepends a symbol
+to that text. Trying every symbol at a position instead of just one, and
+keeping track of how many of them didn't match the pattern, is how
+approximate searches backtrack through the index.
+
+******************************************************************************/
+
+// Range is a range of rows of an index, from Start inclusive to End
+// exclusive, whose suffixes all start with the same text.
+type Range struct {
+	Start, End int
+}
+
+// Len returns the number of rows, which is the number of occurrences of the
+// range's text.
+func (rows Range) Len() int {
+	return max(rows.End-rows.Start, 0)
+}
+
+// Empty checks if a range has no rows.
+func (rows Range) Empty() bool {
+	return rows.End <= rows.Start
+}
+
+// Full returns the range of every row, which matches the empty text.
+func (index *Index) Full() Range {
+	return Range{Start: 0, End: len(index.transform)}
+}
+
+// Extend returns the rows whose suffixes start with symbol followed by the
+// text of rows. Symbols are uppercased, and the range is empty if the symbol
+// isn't in the index.
+func (index *Index) Extend(rows Range, symbol byte) Range {
+	if symbol >= 'a' && symbol <= 'z' {
+		symbol -= 'a' - 'A'
+	}
+	code := index.symbolCodes[symbol]
+	if code == -1 || symbol == separator || rows.Empty() {
+		return Range{}
+	}
+	return Range{
+		Start: index.firstRows[code] + index.occurrence(code, rows.Start),
+		End:   index.firstRows[code] + index.occurrence(code, rows.End),
+	}
+}
+
+// Symbols returns every symbol of the indexed sequences, in sorted order.
+func (index *Index) Symbols() []byte {
+	var symbols []byte
+	for _, symbol := range index.symbols {
+		if symbol != separator {
+			symbols = append(symbols, symbol)
+		}
+	}
+	return symbols
+}
+
+// LocateRange returns the occurrence of every row of a range, ordered by
+// sequence and then by position.
+func (index *Index) LocateRange(rows Range) []Match {
+	if rows.Empty() {
+		return nil
+	}
+	// positions in the joined text are already ordered by sequence.
+	positions := make([]int, 0, rows.Len())
+	for row := rows.Start; row < rows.End; row++ {
+		positions = append(positions, index.locate(row))
+	}
+	sort.Ints(positions)
+	matches := make([]Match, len(positions))
+	for match, position := range positions {
+		matches[match] = index.match(position)
+	}
+	return matches
+}
+
+// occurrence counts symbols[code] in transform[:row].
+func (index *Index) occurrence(code, row int) int {
+	checkpoint := row / occurrenceInterval
+	count := int(index.occurrences[checkpoint*len(index.symbols)+code])
+	symbol := index.symbols[code]
+	for _, current := range index.transform[checkpoint*occurrenceInterval : row] {
+		if current == symbol {
+			count++
+		}
+	}
+	return count
+}
+
+// locate returns the position in the joined text of the suffix at row, by
+// stepping backwards through the text until it reaches a sampled suffix.
+func (index *Index) locate(row int) int {
+	steps := 0
+	for !index.sampled.get(row) {
+		code := index.symbolCodes[index.transform[row]]
+		row = index.firstRows[code] + index.occurrence(code, row)
+		steps++
+	}
+	return int(index.samples[index.sampled.rank(row)]) + steps
+}
+
+// match converts a position in the joined text to a Match.
+func (index *Index) match(position int) Match {
+	sequence := sort.Search(len(index.starts), func(i int) bool { return index.starts[i] > position }) - 1
+	return Match{Name: index.names[sequence], Position: position - index.starts[sequence]}
+}
+
+/******************************************************************************
+
+Index serialization begins here.
+
+******************************************************************************/
+
+// serializedIndex is the on-disk form of an Index.
+type serializedIndex struct {
+	Version     int
+	Names       []string
+	Starts      []int
+	Transform   []byte
+	SentinelRow int
+	Symbols     []byte
+	FirstRows   []int
+	Occurrences []uint32
+	Sampled     []uint64
+	Samples     []uint32
+}
+
+// WriteTo writes the index to w.
+func (index *Index) WriteTo(w io.Writer) (int64, error) {
+	counter := &countingWriter{writer: w}
+	err := gob.NewEncoder(counter).Encode(serializedIndex{
+		Version:     formatVersion,
+		Names:       index.names,
+		Starts:      index.starts,
+		Transform:   index.transform,
+		SentinelRow: index.sentinelRow,
+		Symbols:     index.symbols,
+		FirstRows:   index.firstRows,
+		Occurrences: index.occurrences,
+		Sampled:     index.sampled.words,
+		Samples:     index.samples,
+	})
+	return counter.count, err
+}
+
+// Parse reads an index written by WriteTo.
+func Parse(r io.Reader) (*Index, error) {
+	var serialized serializedIndex
+	if err := gob.NewDecoder(r).Decode(&serialized); err != nil {
+		return nil, fmt.Errorf("error decoding index: %w", err)
+	}
+	if serialized.Version != formatVersion {
+		return nil, fmt.Errorf("index has format version %d, expected %d", serialized.Version, formatVersion)
+	}
+	index := &Index{
+		names:       serialized.Names,
+		starts:      serialized.Starts,
+		transform:   serialized.Transform,
+		sentinelRow: serialized.SentinelRow,
+		symbols:     serialized.Symbols,
+		firstRows:   serialized.FirstRows,
+		occurrences: serialized.Occurrences,
+		sampled:     rankVector{words: serialized.Sampled, length: len(serialized.Transform)},
+		samples:     serialized.Samples,
+	}
+	for code := range index.symbolCodes {
+		index.symbolCodes[code] = -1
+	}
+	for code, symbol := range index.symbols {
+		index.symbolCodes[symbol] = code
+	}
+	checkpoints := len(index.transform)/occurrenceInterval + 1
+	switch {
+	case len(index.names) == 0 || len(index.names) != len(index.starts):
+		return nil, errors.New("index has no sequences")
+	case len(index.firstRows) != len(index.symbols) || len(index.occurrences) != checkpoints*len(index.symbols):
+		return nil, errors.New("index tables don't match its alphabet")
+	case len(index.sampled.words) != (len(index.transform)+63)/64:
+		return nil, errors.New("index suffix samples don't match its length")
+	}
+	index.sampled.finish()
+	if len(index.samples) != index.sampled.rank(len(index.transform)) {
+		return nil, errors.New("index suffix samples don't match its length")
+	}
+	return index, nil
+}
+
+// Read reads an index from path.
+func Read(path string) (*Index, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+// Write writes an index to path.
+func Write(index *Index, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := index.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (counter *countingWriter) Write(p []byte) (int, error) {
+	written, err := counter.writer.Write(p)
+	counter.count += int64(written)
+	return written, err
+}
+
+/******************************************************************************
+
+Rank bit vector begins here.
+
+******************************************************************************/
+
+// rankVector is a bit vector that can count the set bits before any position.
+type rankVector struct {
+	words  []uint64
+	ranks  []int // set bits before each word.
+	length int
+}
+
+func newRankVector(length int) rankVector {
+	return rankVector{words: make([]uint64, (length+63)/64), length: length}
+}
+
+func (vector *rankVector) set(position int) {
+	vector.words[position/64] |= 1 << (position % 64)
+}
+
+func (vector rankVector) get(position int) bool {
+	return vector.words[position/64]&(1<<(position%64)) != 0
+}
+
+// finish builds the rank table once every bit is set.
+func (vector *rankVector) finish() {
+	vector.ranks = make([]int, len(vector.words)+1)
+	for word, bitsOfWord := range vector.words {
+		vector.ranks[word+1] = vector.ranks[word] + bits.OnesCount64(bitsOfWord)
+	}
+}
+
+// rank counts the set bits before position.
+func (vector rankVector) rank(position int) int {
+	word := position / 64
+	if position%64 == 0 {
+		return vector.ranks[word]
+	}
+	return vector.ranks[word] + bits.OnesCount64(vector.words[word]&(1<<(position%64)-1))
+}
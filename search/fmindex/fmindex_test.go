@@ -0,0 +1,235 @@
+package fmindex
+
+import (
+	"bytes"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuffixArray(t *testing.T) {
+	source := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 200; trial++ {
+		// small alphabets make lots of repeats, which is where SA-IS recurses.
+		alphabetSize := 2 + source.Intn(4)
+		text := make([]int32, 1+source.Intn(300))
+		for position := range text[:len(text)-1] {
+			text[position] = 1 + int32(source.Intn(alphabetSize))
+		}
+		naive := make([]int32, len(text))
+		for position := range naive {
+			naive[position] = int32(position)
+		}
+		sort.Slice(naive, func(i, j int) bool {
+			first, second := text[naive[i]:], text[naive[j]:]
+			for offset := 0; offset < len(first) && offset < len(second); offset++ {
+				if first[offset] != second[offset] {
+					return first[offset] < second[offset]
+				}
+			}
+			return len(first) < len(second)
+		})
+		require.Equal(t, naive, suffixArray(text, alphabetSize+1), "trial %d", trial)
+	}
+}
+
+// naiveLocate finds every, possibly overlapping, occurrence of pattern.
+func naiveLocate(sequences []fasta.Fasta, pattern string) []Match {
+	var matches []Match
+	for _, sequence := range sequences {
+		upper := strings.ToUpper(sequence.Sequence)
+		for position := 0; position+len(pattern) <= len(upper); position++ {
+			if upper[position:position+len(pattern)] == strings.ToUpper(pattern) {
+				matches = append(matches, Match{Name: sequence.Name, Position: position})
+			}
+		}
+	}
+	return matches
+}
+
+func TestIndex(t *testing.T) {
+	repeat, _ := random.DNASequence(40, 2)
+	chromosomeStart, _ := random.DNASequence(3000, 3)
+	chromosomeMiddle, _ := random.DNASequence(2000, 4)
+	plasmid, _ := random.DNASequence(500, 5)
+	sequences := []fasta.Fasta{
+		{Name: "chromosome", Sequence: chromosomeStart + repeat + chromosomeMiddle + repeat},
+		{Name: "plasmid", Sequence: strings.ToLower(plasmid + repeat)},
+		{Name: "empty", Sequence: ""},
+		{Name: "poly-A", Sequence: strings.Repeat("A", 300)},
+	}
+	index, err := New(sequences)
+	require.NoError(t, err)
+	assert.Equal(t, 3000+40+2000+40+540+300, index.Len())
+	assert.Equal(t, []string{"chromosome", "plasmid", "empty", "poly-A"}, index.Names())
+
+	patterns := []string{repeat, repeat[:12], "ACGT", "A", "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA", "gattaca", sequences[0].Sequence[2990:3010], "N", "A$A", ""}
+	for trial := 0; trial < 50; trial++ {
+		pattern, _ := random.DNASequence(3+trial%10, int64(trial))
+		patterns = append(patterns, pattern)
+	}
+	for _, pattern := range patterns {
+		want := []Match(nil)
+		if pattern != "" && !strings.Contains(pattern, "$") {
+			want = naiveLocate(sequences, pattern)
+		}
+		assert.Equal(t, want, index.Locate(pattern), pattern)
+		assert.Equal(t, len(want), index.Count(pattern), pattern)
+	}
+	// the last occurrence checkpoint lands right at the end of this index.
+	exactSequence, _ := random.DNASequence(occurrenceInterval-1, 6)
+	exact := []fasta.Fasta{{Name: "exact", Sequence: exactSequence}}
+	exactIndex, err := New(exact)
+	require.NoError(t, err)
+	for _, symbol := range []string{"A", "C", "G", "T"} {
+		assert.Equal(t, naiveLocate(exact, symbol), exactIndex.Locate(symbol))
+	}
+
+	// matches can't span two sequences.
+	assert.Equal(t, 0, index.Count(sequences[0].Sequence[5070:]+sequences[1].Sequence[:10]))
+
+	_, err = New(nil)
+	assert.Error(t, err)
+	_, err = New([]fasta.Fasta{{Name: "bad", Sequence: "ACG$T"}})
+	assert.Error(t, err)
+}
+
+func TestSerialization(t *testing.T) {
+	first, _ := random.DNASequence(1000, 7)
+	second, _ := random.DNASequence(1000, 8)
+	sequences := []fasta.Fasta{{Name: "first", Sequence: first}, {Name: "second", Sequence: second}}
+	index, err := New(sequences)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "index.fm")
+	require.NoError(t, Write(index, path))
+	read, err := Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, index, read)
+	pattern := sequences[1].Sequence[100:120]
+	assert.Equal(t, []Match{{Name: "second", Position: 100}}, read.Locate(pattern))
+
+	var buffer bytes.Buffer
+	written, err := index.WriteTo(&buffer)
+	require.NoError(t, err)
+	assert.Equal(t, int64(buffer.Len()), written)
+
+	_, err = Parse(strings.NewReader("not an index"))
+	assert.Error(t, err)
+	_, err = Read(filepath.Join(t.TempDir(), "missing.fm"))
+	assert.Error(t, err)
+}
+
+func TestExtend(t *testing.T) {
+	index, err := New([]fasta.Fasta{{Name: "first", Sequence: "GATTACAgattacaNNN"}, {Name: "second", Sequence: "TTACA"}})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("ACGNT"), index.Symbols())
+
+	rows := index.Full()
+	assert.Equal(t, index.Len()+2, rows.Len())
+	for position := len("TTACA") - 1; position >= 0; position-- {
+		rows = index.Extend(rows, "ttaca"[position])
+	}
+	assert.Equal(t, 3, rows.Len())
+	assert.Equal(t, []Match{{"first", 2}, {"first", 9}, {"second", 0}}, index.LocateRange(rows))
+	assert.Equal(t, 2, index.Extend(rows, 'A').Len())
+	assert.True(t, index.Extend(rows, 'C').Empty())
+	assert.True(t, index.Extend(rows, '$').Empty())
+	assert.True(t, index.Extend(rows, 'X').Empty())
+	assert.Nil(t, index.LocateRange(Range{}))
+}
+
+// naiveEdits is the fewest edits to match pattern to text starting at its
+// first base, with the text's end left free.
+func naiveEdits(pattern, text string) int {
+	const infinity = 1 << 20
+	previous := make([]int, len(text)+1)
+	for column := 1; column <= len(text); column++ {
+		previous[column] = infinity // the match has to start at the first base.
+	}
+	for row := 1; row <= len(pattern); row++ {
+		current := make([]int, len(text)+1)
+		current[0] = row
+		for column := 1; column <= len(text); column++ {
+			substitution := previous[column-1]
+			if pattern[row-1] != text[column-1] {
+				substitution++
+			}
+			current[column] = min(substitution, previous[column]+1, current[column-1]+1)
+		}
+		previous = current
+	}
+	best := infinity
+	for _, edits := range previous[1:] {
+		best = min(best, edits)
+	}
+	return best
+}
+
+func TestLocateApproximate(t *testing.T) {
+	first, _ := random.DNASequence(400, 9)
+	second, _ := random.DNASequence(300, 10)
+	sequences := []fasta.Fasta{{Name: "first", Sequence: first}, {Name: "second", Sequence: second}}
+	source := rand.New(rand.NewSource(4))
+	index, err := New(sequences)
+	require.NoError(t, err)
+
+	for trial := 0; trial < 30; trial++ {
+		sequence := sequences[source.Intn(len(sequences))].Sequence
+		start := source.Intn(len(sequence) - 12)
+		pattern := []byte(sequence[start : start+8+source.Intn(4)])
+		pattern[source.Intn(len(pattern))] = "ACGT"[source.Intn(4)]
+		maxEdits := source.Intn(3)
+
+		matches := index.LocateApproximate(string(pattern), maxEdits)
+		reported := make(map[Match]ApproximateMatch)
+		for _, match := range matches {
+			reported[match.Match] = match
+		}
+		for _, sequence := range sequences {
+			edits := make([]int, len(sequence.Sequence))
+			for position := range edits {
+				edits[position] = naiveEdits(string(pattern), sequence.Sequence[position:])
+			}
+			for position, fewest := range edits {
+				match, ok := reported[Match{Name: sequence.Name, Position: position}]
+				if ok {
+					assert.Equal(t, fewest, match.Edits, "trial %d", trial)
+					assert.True(t, strings.HasPrefix(sequence.Sequence[position:], match.Sequence), "trial %d", trial)
+					assert.Equal(t, match.Edits, naiveEdits(string(pattern), match.Sequence), "trial %d", trial)
+					continue
+				}
+				if fewest > maxEdits {
+					continue
+				}
+				// unreported matches must be next to a closer one.
+				shadowed := false
+				for shift := -maxEdits; shift <= maxEdits; shift++ {
+					neighbor := position + shift
+					if neighbor >= 0 && neighbor < len(edits) && edits[neighbor] < fewest {
+						shadowed = true
+					}
+				}
+				assert.True(t, shadowed, "trial %d: %s at %d with %d edits", trial, sequence.Name, position, fewest)
+			}
+		}
+	}
+
+	index, err = New([]fasta.Fasta{{Name: "chromosome", Sequence: "TTTTGATTACATTTTGATCACATTTTGATACATTTT"}})
+	require.NoError(t, err)
+	assert.Equal(t, []ApproximateMatch{{Match: Match{"chromosome", 4}, Sequence: "GATTACA"}}, index.LocateApproximate("gattaca", 0))
+	assert.Equal(t, []ApproximateMatch{
+		{Match: Match{"chromosome", 4}, Sequence: "GATTACA"},
+		{Match: Match{"chromosome", 15}, Sequence: "GATCACA", Edits: 1},
+		{Match: Match{"chromosome", 26}, Sequence: "GATACA", Edits: 1},
+	}, index.LocateApproximate("GATTACA", 1))
+	assert.Nil(t, index.LocateApproximate("", 1))
+	assert.Nil(t, index.LocateApproximate("GATTACA", -1))
+}
@@ -0,0 +1,164 @@
+package fmindex
+
+/******************************************************************************
+
+Suffix array construction begins here.
+
+An FM-index is built from the suffix array of its text: the starting position
+of every suffix, in sorted order. Sorting the suffixes directly is fine for a
+plasmid, but gets very slow on a genome, since comparing two suffixes of a
+repetitive sequence can take as long as the repeat.
+
+SA-IS builds the suffix array in linear time. It classifies every suffix as
+S-type (smaller than the suffix after it) or L-type (larger), sorts only the
+leftmost S-type suffixes of each run (the "LMS" suffixes), and then induces
+the order of every other suffix from them in two passes over the buckets of
+each symbol. Sorting the LMS suffixes is itself a suffix array problem on a
+text at most half as long, so it recurses.
+
+For more on SA-IS:
+Nong, Zhang, and Chan, 2009
+https://doi.org/10.1109/DCC.2009.42
+
+******************************************************************************/
+
+// suffixArray returns the suffix array of text. Every symbol of text must be
+// less than alphabetSize, and the last symbol must be a unique 0 sentinel.
+func suffixArray(text []int32, alphabetSize int) []int32 {
+	length := len(text)
+	suffixes := make([]int32, length)
+	if length == 1 {
+		return suffixes
+	}
+
+	// sTypes[i] is true if the suffix at i is S-type.
+	sTypes := make([]bool, length)
+	sTypes[length-1] = true
+	for index := length - 2; index >= 0; index-- {
+		sTypes[index] = text[index] < text[index+1] || (text[index] == text[index+1] && sTypes[index+1])
+	}
+	isLMS := func(index int) bool {
+		return index > 0 && sTypes[index] && !sTypes[index-1]
+	}
+
+	bucketSizes := make([]int32, alphabetSize)
+	for _, symbol := range text {
+		bucketSizes[symbol]++
+	}
+	bucketHeads := func() []int32 {
+		heads := make([]int32, alphabetSize)
+		var sum int32
+		for symbol, size := range bucketSizes {
+			heads[symbol] = sum
+			sum += size
+		}
+		return heads
+	}
+	bucketTails := func() []int32 {
+		tails := make([]int32, alphabetSize)
+		var sum int32
+		for symbol, size := range bucketSizes {
+			sum += size
+			tails[symbol] = sum
+		}
+		return tails
+	}
+	// induce sorts the L-type suffixes from the LMS suffixes already in
+	// place, and then the S-type suffixes from the L-type ones.
+	induce := func() {
+		heads := bucketHeads()
+		for index := 0; index < length; index++ {
+			if previous := suffixes[index] - 1; suffixes[index] > 0 && !sTypes[previous] {
+				suffixes[heads[text[previous]]] = previous
+				heads[text[previous]]++
+			}
+		}
+		tails := bucketTails()
+		for index := length - 1; index >= 0; index-- {
+			if previous := suffixes[index] - 1; suffixes[index] > 0 && sTypes[previous] {
+				tails[text[previous]]--
+				suffixes[tails[text[previous]]] = previous
+			}
+		}
+	}
+
+	// roughly sort the LMS suffixes by their LMS substrings.
+	for index := range suffixes {
+		suffixes[index] = -1
+	}
+	tails := bucketTails()
+	for index := length - 1; index > 0; index-- {
+		if isLMS(index) {
+			tails[text[index]]--
+			suffixes[tails[text[index]]] = int32(index)
+		}
+	}
+	induce()
+
+	// gather the LMS suffixes in their sorted order, and name their
+	// substrings so that equal substrings share a name.
+	lmsCount := 0
+	for _, suffix := range suffixes {
+		if isLMS(int(suffix)) {
+			suffixes[lmsCount] = suffix
+			lmsCount++
+		}
+	}
+	names := make([]int32, length)
+	for index := range names {
+		names[index] = -1
+	}
+	substringsEqual := func(first, second int) bool {
+		for offset := 0; ; offset++ {
+			if text[first+offset] != text[second+offset] || sTypes[first+offset] != sTypes[second+offset] {
+				return false
+			}
+			if offset > 0 && (isLMS(first+offset) || isLMS(second+offset)) {
+				return isLMS(first+offset) && isLMS(second+offset)
+			}
+		}
+	}
+	var name int32
+	previous := -1
+	for _, suffix := range suffixes[:lmsCount] {
+		if previous == -1 || !substringsEqual(previous, int(suffix)) {
+			name++
+		}
+		previous = int(suffix)
+		names[suffix] = name - 1
+	}
+
+	// sort the LMS suffixes exactly, recursing if any of their substrings
+	// share a name.
+	lmsPositions := make([]int32, 0, lmsCount)
+	reduced := make([]int32, 0, lmsCount)
+	for index, name := range names {
+		if name >= 0 {
+			lmsPositions = append(lmsPositions, int32(index))
+			reduced = append(reduced, name)
+		}
+	}
+	var reducedSuffixes []int32
+	if int(name) < lmsCount {
+		reducedSuffixes = suffixArray(reduced, int(name))
+	} else {
+		reducedSuffixes = make([]int32, lmsCount)
+		for index, name := range reduced {
+			reducedSuffixes[name] = int32(index)
+		}
+	}
+
+	// put the sorted LMS suffixes at the ends of their buckets and induce
+	// everything else from them.
+	for index := range suffixes {
+		suffixes[index] = -1
+	}
+	tails = bucketTails()
+	for index := lmsCount - 1; index >= 0; index-- {
+		position := lmsPositions[reducedSuffixes[index]]
+		tails[text[position]]--
+		suffixes[tails[text[position]]] = position
+	}
+	induce()
+	return suffixes
+}
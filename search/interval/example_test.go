@@ -0,0 +1,32 @@
+package interval_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/search/interval"
+)
+
+func ExampleTree_Overlapping() {
+	tree := interval.New([]interval.Interval[string]{
+		{Start: 0, End: 1000, Value: "promoter"},
+		{Start: 1000, End: 2500, Value: "gene"},
+		{Start: 2500, End: 2600, Value: "terminator"},
+	})
+
+	for _, hit := range tree.Overlapping(900, 1100) {
+		fmt.Println(hit.Value)
+	}
+	// Output:
+	// promoter
+	// gene
+}
+
+func ExampleTree_At() {
+	tree := interval.New([]interval.Interval[string]{
+		{Start: 0, End: 1000, Value: "promoter"},
+		{Start: 1000, End: 2500, Value: "gene"},
+	})
+
+	fmt.Println(tree.At(1000)[0].Value)
+	// Output: gene
+}
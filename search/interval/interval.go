@@ -0,0 +1,124 @@
+/*
+Package interval provides an interval tree for fast overlap queries.
+
+Annotated sequences tend to carry a lot of features. A bacterial genome from
+RefSeq can easily have tens of thousands of genes, CDSs, and misc features, and
+a eukaryotic chromosome can have hundreds of thousands. Asking "which features
+cover position 1,234,567?" by scanning every feature gets slow very quickly,
+especially when you have to ask the question over and over again (for example
+when walking along a genome to annotate variants).
+
+An interval tree answers those questions in O(log n + k) time, where k is the
+number of intervals returned.
+
+The tree in this package is static: it is built once from a slice of intervals
+and then queried. Internally the intervals are sorted by start position and
+viewed as an implicit balanced binary search tree, where every node is
+augmented with the maximum end position of its subtree. This keeps the
+memory footprint to a single extra int per interval and avoids pointer chasing.
+
+All intervals are half-open, [Start, End), which matches the 0-based
+coordinates used by poly's parsers (for example genbank.Location).
+*/
+package interval
+
+import (
+	"math"
+	"sort"
+)
+
+// Interval is a half-open [Start, End) range carrying an arbitrary value.
+type Interval[T any] struct {
+	Start int
+	End   int
+	Value T
+}
+
+// Tree is a static interval tree. Tree is safe for concurrent reads.
+type Tree[T any] struct {
+	intervals []Interval[T]
+	maxEnd    []int // maxEnd[i] is the largest End within the subtree rooted at i.
+}
+
+// New builds a Tree from a slice of intervals. The input slice is copied and
+// is not modified.
+func New[T any](intervals []Interval[T]) *Tree[T] {
+	sorted := make([]Interval[T], len(intervals))
+	copy(sorted, intervals)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Start == sorted[j].Start {
+			return sorted[i].End < sorted[j].End
+		}
+		return sorted[i].Start < sorted[j].Start
+	})
+
+	tree := &Tree[T]{
+		intervals: sorted,
+		maxEnd:    make([]int, len(sorted)),
+	}
+	tree.build(0, len(sorted))
+	return tree
+}
+
+// build fills the maxEnd augmentation for the implicit subtree covering [low, high).
+func (tree *Tree[T]) build(low, high int) int {
+	if low >= high {
+		return math.MinInt
+	}
+	middle := int(uint(low+high) >> 1)
+	maxEnd := tree.intervals[middle].End
+	if leftMax := tree.build(low, middle); leftMax > maxEnd {
+		maxEnd = leftMax
+	}
+	if rightMax := tree.build(middle+1, high); rightMax > maxEnd {
+		maxEnd = rightMax
+	}
+	tree.maxEnd[middle] = maxEnd
+	return maxEnd
+}
+
+// Len returns the number of intervals stored in the tree.
+func (tree *Tree[T]) Len() int {
+	return len(tree.intervals)
+}
+
+// Overlapping returns every interval that overlaps the half-open range
+// [start, end), ordered by start position.
+func (tree *Tree[T]) Overlapping(start, end int) []Interval[T] {
+	var overlapping []Interval[T]
+	if start >= end {
+		return overlapping
+	}
+	tree.query(0, len(tree.intervals), start, end, &overlapping)
+	return overlapping
+}
+
+// At returns every interval that contains position, ordered by start position.
+func (tree *Tree[T]) At(position int) []Interval[T] {
+	return tree.Overlapping(position, position+1)
+}
+
+// query collects the intervals of the implicit subtree covering [low, high)
+// that overlap [start, end).
+func (tree *Tree[T]) query(low, high, start, end int, overlapping *[]Interval[T]) {
+	if low >= high {
+		return
+	}
+	middle := int(uint(low+high) >> 1)
+	// nothing in this subtree reaches far enough to overlap the query.
+	if tree.maxEnd[middle] <= start {
+		return
+	}
+	tree.query(low, middle, start, end, overlapping)
+
+	node := tree.intervals[middle]
+	// everything to the right starts at or after this node, so if this node
+	// starts after the query ends we are done.
+	if node.Start >= end {
+		return
+	}
+	if node.End > start {
+		*overlapping = append(*overlapping, node)
+	}
+	tree.query(middle+1, high, start, end, overlapping)
+}
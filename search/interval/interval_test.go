@@ -0,0 +1,126 @@
+package interval
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func randomIntervals(count, span, maxLength int, seed int64) []Interval[int] {
+	random := rand.New(rand.NewSource(seed))
+	intervals := make([]Interval[int], count)
+	for i := range intervals {
+		start := random.Intn(span)
+		intervals[i] = Interval[int]{Start: start, End: start + 1 + random.Intn(maxLength), Value: i}
+	}
+	return intervals
+}
+
+func bruteForceOverlapping(intervals []Interval[int], start, end int) map[int]bool {
+	overlapping := make(map[int]bool)
+	for _, interval := range intervals {
+		if interval.Start < end && interval.End > start {
+			overlapping[interval.Value] = true
+		}
+	}
+	return overlapping
+}
+
+func TestOverlapping(t *testing.T) {
+	intervals := randomIntervals(2000, 100000, 5000, 1)
+	tree := New(intervals)
+	if tree.Len() != len(intervals) {
+		t.Fatalf("expected %d intervals, got %d", len(intervals), tree.Len())
+	}
+
+	random := rand.New(rand.NewSource(2))
+	for query := 0; query < 500; query++ {
+		start := random.Intn(110000) - 5000
+		end := start + random.Intn(3000)
+		expected := bruteForceOverlapping(intervals, start, end)
+		got := tree.Overlapping(start, end)
+		if len(got) != len(expected) {
+			t.Fatalf("query [%d, %d): expected %d intervals, got %d", start, end, len(expected), len(got))
+		}
+		for i, interval := range got {
+			if !expected[interval.Value] {
+				t.Errorf("query [%d, %d): unexpected interval %v", start, end, interval)
+			}
+			if i > 0 && got[i-1].Start > interval.Start {
+				t.Errorf("query [%d, %d): results not ordered by start", start, end)
+			}
+		}
+	}
+}
+
+func TestAt(t *testing.T) {
+	tree := New([]Interval[string]{
+		{Start: 0, End: 10, Value: "a"},
+		{Start: 5, End: 15, Value: "b"},
+		{Start: 10, End: 20, Value: "c"},
+	})
+	tests := []struct {
+		position int
+		want     []string
+	}{
+		{-1, nil},
+		{0, []string{"a"}},
+		{9, []string{"a", "b"}},
+		{10, []string{"b", "c"}},
+		{19, []string{"c"}},
+		{20, nil},
+	}
+	for _, test := range tests {
+		got := tree.At(test.position)
+		if len(got) != len(test.want) {
+			t.Errorf("At(%d): expected %v, got %v", test.position, test.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i].Value != test.want[i] {
+				t.Errorf("At(%d): expected %v, got %v", test.position, test.want, got)
+			}
+		}
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	tree := New[int](nil)
+	if got := tree.Overlapping(0, 100); len(got) != 0 {
+		t.Errorf("expected no intervals from an empty tree, got %v", got)
+	}
+	tree = New(randomIntervals(10, 100, 10, 1))
+	if got := tree.Overlapping(50, 50); len(got) != 0 {
+		t.Errorf("expected no intervals for an empty query, got %v", got)
+	}
+}
+
+// RefSeq bacterial genomes carry ~10k features per 5Mb and large eukaryotic
+// chromosomes ~100k+, so benchmark at that scale.
+func BenchmarkOverlapping(b *testing.B) {
+	intervals := randomIntervals(100000, 50000000, 10000, 1)
+	tree := New(intervals)
+	random := rand.New(rand.NewSource(2))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		start := random.Intn(50000000)
+		tree.Overlapping(start, start+1000)
+	}
+}
+
+func BenchmarkLinearScan(b *testing.B) {
+	intervals := randomIntervals(100000, 50000000, 10000, 1)
+	random := rand.New(rand.NewSource(2))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		start := random.Intn(50000000)
+		bruteForceOverlapping(intervals, start, start+1000)
+	}
+}
+
+func BenchmarkNew(b *testing.B) {
+	intervals := randomIntervals(100000, 50000000, 10000, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		New(intervals)
+	}
+}
@@ -0,0 +1,28 @@
+package kmers_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/search/kmers"
+)
+
+func ExampleCounter() {
+	counter, _ := kmers.NewCounter(3, kmers.Options{})
+	// CGT is the reverse complement of ACG, so they're counted together.
+	counter.Add("ACGTTACG")
+	fmt.Println(counter.Count("ACG"), counter.Count("CGT"), counter.Distinct(), counter.Total())
+	// Output: 3 3 4 6
+}
+
+func ExampleSketch_Jaccard() {
+	sequence := "ATGGCTAGCAAAGGAGAAGAACTTTTCACTGGAGTTGTCCCAATTCTTGTTGAATTAGATGGTGATGTTAATGGGCACAAATTTTCTGTCAGTGGAGAGGGTGAAGGTGATGCTACATACGGAAAGCTTACCCTTAAATTTATTTGCACTACTGGAAAACTACCTGTTCC"
+	first, _ := kmers.NewSketch(15, 100, kmers.Options{})
+	first.Add(sequence)
+	// the same sequence read from the other strand.
+	second, _ := kmers.NewSketch(15, 100, kmers.Options{})
+	second.Add("GGAACAGGTAGTTTTCCAGTAGTGCAAATAAATTTAAGGGTAAGCTTTCCGTATGTAGCATCACCTTCACCCTCTCCACTGACAGAAAATTTGTGCCCATTAACATCACCATCTAATTCAACAAGAATTGGGACAACTCCAGTGAAAAGTTCTTCTCCTTTGCTAGCCAT")
+
+	jaccard, _ := first.Jaccard(second)
+	fmt.Println(jaccard)
+	// Output: 1
+}
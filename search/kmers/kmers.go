@@ -0,0 +1,259 @@
+/*
+Package kmers counts and sketches the k-mers of sequences.
+
+A k-mer is a substring of length k, and which k-mers a sequence has, and how
+many of each, says a lot about it. Two sequences that share most of their
+k-mers are probably related, a read full of k-mers the genome doesn't have is
+probably contamination, and k-mers that turn up far more often than the rest
+are repeats.
+
+DNA has two strands, and a k-mer read off one of them is the reverse
+complement of the same k-mer read off the other. Unless told otherwise, this
+package counts every k-mer together with its reverse complement as one
+canonical k-mer, whichever of the two sorts first, so it doesn't matter which
+strand a sequence was written from.
+
+Counting every k-mer of a genome as strings would take gigabytes, so k-mers of
+up to 32 bases are packed two bits to a base into a uint64 instead. Moving
+from one k-mer to the next only shifts in one base, on both strands, so
+counting is a single pass over the sequence. K-mers with bases other than A,
+C, G, T, or U, like Ns, are skipped. Sequences packed by sequence/packed,
+like those read from .2bit files, are already two bits a base, and AddPacked
+and PackedMinimizers read them without unpacking them.
+
+Comparing big collections of sequences by all of their k-mers is still slow,
+so sequences can be sketched as well. A Sketch keeps only a small sample of a
+sequence's k-mers, chosen by hash so that similar sequences keep similar
+samples, and estimates the Jaccard similarity and containment of sequences
+from their sketches alone. There are two ways to choose the sample. MinHash
+bottom sketches (as in Mash, Ondov et al. 2016) keep the k-mers with the
+smallest hashes, up to a fixed size, which makes sketches of genomes of any
+size equally quick to compare. Minimizers (Roberts et al. 2004) keep the k-mer
+with the smallest hash in every window of w k-mers, which samples every part
+of a sequence and says where each sampled k-mer is.
+*/
+package kmers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bebop/poly/sequence/packed"
+	"github.com/bebop/poly/transform"
+)
+
+// MaxK is the longest k-mer that can be counted or sketched.
+const MaxK = 32
+
+// Options changes how k-mers are read from sequences. The zero value reads
+// canonical k-mers from linear sequences.
+type Options struct {
+	// Stranded reads k-mers as they're written instead of as canonical
+	// k-mers, for sequences like RNAs where the strand matters.
+	Stranded bool
+	// Circular reads k-mers across the end of every sequence into its start.
+	Circular bool
+}
+
+// baseCodes are the two bit codes of bases, or 4 for anything else.
+var baseCodes = func() [256]uint64 {
+	var codes [256]uint64
+	for index := range codes {
+		codes[index] = 4
+	}
+	for code, bases := range []string{"Aa", "Cc", "Gg", "TtUu"} {
+		for _, base := range bases {
+			codes[base] = uint64(code)
+		}
+	}
+	return codes
+}()
+
+// validateK checks k is a length k-mers can be packed into a uint64 at.
+func validateK(k int) error {
+	if k < 1 || k > MaxK {
+		return fmt.Errorf("k must be between 1 and %d, got %d", MaxK, k)
+	}
+	return nil
+}
+
+// scan calls found with the position and packed code of every k-mer of a
+// sequence with only unambiguous bases.
+func scan(sequence string, k int, options Options, found func(position int, code uint64)) {
+	scanCodes(len(sequence), func(index int) uint64 { return baseCodes[sequence[index]] }, k, options, found)
+}
+
+// scanPacked scans a packed sequence like scan, without unpacking it.
+func scanPacked(sequence packed.Sequence, k int, options Options, found func(position int, code uint64)) {
+	scanCodes(sequence.Len(), func(index int) uint64 {
+		code, ok := sequence.Code(index)
+		if !ok {
+			return 4
+		}
+		return uint64(code)
+	}, k, options, found)
+}
+
+// scanCodes scans a sequence of length bases, whose codes codeAt returns.
+func scanCodes(length int, codeAt func(index int) uint64, k int, options Options, found func(position int, code uint64)) {
+	if length < k {
+		return
+	}
+	end := length
+	if options.Circular {
+		end += k - 1
+	}
+	mask := ^uint64(0) >> (64 - 2*k)
+	shift := uint(2 * (k - 1))
+	var forward, reverse uint64
+	valid := 0 // how many unambiguous bases in a row end at the current one.
+	for index := 0; index < end; index++ {
+		code := codeAt(index % length)
+		if code == 4 {
+			valid = 0
+			continue
+		}
+		forward = (forward<<2 | code) & mask
+		reverse = reverse>>2 | (3-code)<<shift
+		valid++
+		if valid < k {
+			continue
+		}
+		start := index - k + 1
+		if start >= length {
+			break
+		}
+		if options.Stranded || forward <= reverse {
+			found(start, forward)
+		} else {
+			found(start, reverse)
+		}
+	}
+}
+
+// Encode packs a k-mer into the code k-mers are counted by, canonical unless
+// options say it's stranded. It returns false if the k-mer is too long or has
+// ambiguous bases.
+func Encode(kmer string, options Options) (uint64, bool) {
+	if validateK(len(kmer)) != nil {
+		return 0, false
+	}
+	encoded, ok := uint64(0), false
+	scan(kmer, len(kmer), Options{Stranded: options.Stranded}, func(_ int, code uint64) {
+		encoded, ok = code, true
+	})
+	return encoded, ok
+}
+
+// Decode unpacks a k-mer of length k from its code.
+func Decode(code uint64, k int) string {
+	kmer := make([]byte, k)
+	for index := k - 1; index >= 0; index-- {
+		kmer[index] = "ACGT"[code&3]
+		code >>= 2
+	}
+	return string(kmer)
+}
+
+/******************************************************************************
+
+Counting begins here.
+
+******************************************************************************/
+
+// Counter counts k-mers. Every distinct k-mer takes a map entry of 12 bytes
+// plus overhead, however long it is.
+type Counter struct {
+	k       int
+	options Options
+	counts  map[uint64]uint32
+	total   int
+}
+
+// NewCounter returns a Counter of k-mers of length k, which can be at most
+// MaxK.
+func NewCounter(k int, options Options) (*Counter, error) {
+	if err := validateK(k); err != nil {
+		return nil, err
+	}
+	return &Counter{k: k, options: options, counts: make(map[uint64]uint32)}, nil
+}
+
+// K returns the length of the k-mers being counted.
+func (counter *Counter) K() int {
+	return counter.k
+}
+
+// Add counts the k-mers of a sequence.
+func (counter *Counter) Add(sequence string) {
+	scan(sequence, counter.k, counter.options, func(_ int, code uint64) {
+		counter.counts[code]++
+		counter.total++
+	})
+}
+
+// AddPacked counts the k-mers of a packed sequence, the same as Add counts
+// them unpacked.
+func (counter *Counter) AddPacked(sequence packed.Sequence) {
+	scanPacked(sequence, counter.k, counter.options, func(_ int, code uint64) {
+		counter.counts[code]++
+		counter.total++
+	})
+}
+
+// Count returns how many times a k-mer, or its reverse complement if k-mers
+// are canonical, has been counted.
+func (counter *Counter) Count(kmer string) int {
+	if len(kmer) != counter.k {
+		return 0
+	}
+	code, ok := Encode(kmer, counter.options)
+	if !ok {
+		return 0
+	}
+	return int(counter.counts[code])
+}
+
+// Distinct returns how many different k-mers have been counted.
+func (counter *Counter) Distinct() int {
+	return len(counter.counts)
+}
+
+// Total returns how many k-mers have been counted, including repeats.
+func (counter *Counter) Total() int {
+	return counter.total
+}
+
+// Each calls found with every k-mer counted and its count, in no particular
+// order, until found returns false. Canonical k-mers are given as whichever
+// of the k-mer and its reverse complement sorts first.
+func (counter *Counter) Each(found func(kmer string, count int) bool) {
+	for code, count := range counter.counts {
+		if !found(Decode(code, counter.k), int(count)) {
+			return
+		}
+	}
+}
+
+// Merge adds the counts of another Counter of k-mers read the same way.
+func (counter *Counter) Merge(other *Counter) error {
+	if other.k != counter.k || other.options.Stranded != counter.options.Stranded {
+		return fmt.Errorf("can't merge counts of different k-mers: k %d and %d, stranded %t and %t", counter.k, other.k, counter.options.Stranded, other.options.Stranded)
+	}
+	for code, count := range other.counts {
+		counter.counts[code] += count
+	}
+	counter.total += other.total
+	return nil
+}
+
+// Canonical returns whichever of a k-mer and its reverse complement sorts
+// first, in uppercase.
+func Canonical(kmer string) string {
+	kmer = strings.ToUpper(kmer)
+	reverse := transform.ReverseComplement(kmer)
+	if reverse < kmer {
+		return reverse
+	}
+	return kmer
+}
@@ -0,0 +1,260 @@
+package kmers
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/random"
+	"github.com/bebop/poly/sequence/packed"
+	"github.com/bebop/poly/transform"
+	"github.com/bebop/poly/window"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	for _, kmer := range []string{"A", "ACG", "TTTTGGGGCCCCAAAATTTTGGGGCCCCAAAA", "GATTACA"} {
+		code, ok := Encode(kmer, Options{Stranded: true})
+		require.True(t, ok)
+		assert.Equal(t, kmer, Decode(code, len(kmer)))
+
+		// canonical k-mers are the same for both strands.
+		code, ok = Encode(kmer, Options{})
+		require.True(t, ok)
+		reverseCode, ok := Encode(transform.ReverseComplement(kmer), Options{})
+		require.True(t, ok)
+		assert.Equal(t, code, reverseCode)
+		assert.Equal(t, Canonical(kmer), Decode(code, len(kmer)))
+	}
+
+	code, _ := Encode("acgu", Options{Stranded: true})
+	assert.Equal(t, "ACGT", Decode(code, 4))
+	_, ok := Encode("ACNGT", Options{})
+	assert.False(t, ok)
+	_, ok = Encode(strings.Repeat("A", 33), Options{})
+	assert.False(t, ok)
+	_, ok = Encode("", Options{})
+	assert.False(t, ok)
+}
+
+func TestCounter(t *testing.T) {
+	counter, err := NewCounter(3, Options{})
+	require.NoError(t, err)
+	counter.Add("ACGTNACGT")
+	assert.Equal(t, 4, counter.Count("ACG"))
+	assert.Equal(t, 4, counter.Count("cgt"))
+	assert.Equal(t, 0, counter.Count("AAA"))
+	assert.Equal(t, 0, counter.Count("ACGT"))
+	assert.Equal(t, 1, counter.Distinct())
+	assert.Equal(t, 4, counter.Total())
+
+	stranded, err := NewCounter(3, Options{Stranded: true})
+	require.NoError(t, err)
+	stranded.Add("ACGTNACGT")
+	assert.Equal(t, 2, stranded.Count("ACG"))
+	assert.Equal(t, 2, stranded.Count("CGT"))
+	counts := make(map[string]int)
+	stranded.Each(func(kmer string, count int) bool {
+		counts[kmer] = count
+		return true
+	})
+	assert.Equal(t, map[string]int{"ACG": 2, "CGT": 2}, counts)
+
+	circular, err := NewCounter(3, Options{Circular: true, Stranded: true})
+	require.NoError(t, err)
+	circular.Add("ACGT")
+	assert.Equal(t, 4, circular.Total())
+	assert.Equal(t, 1, circular.Count("TAC"))
+
+	assert.Error(t, counter.Merge(stranded))
+	other, err := NewCounter(3, Options{})
+	require.NoError(t, err)
+	other.Add("ACGAAA")
+	require.NoError(t, counter.Merge(other))
+	assert.Equal(t, 5, counter.Count("ACG"))
+	assert.Equal(t, 8, counter.Total())
+
+	for _, k := range []int{0, 33} {
+		_, err := NewCounter(k, Options{})
+		assert.Error(t, err)
+	}
+}
+
+func TestCounterMatchesStrings(t *testing.T) {
+	sequence, err := random.DNASequence(5000, 1)
+	require.NoError(t, err)
+	for _, k := range []int{1, 5, 21, 32} {
+		counter, err := NewCounter(k, Options{})
+		require.NoError(t, err)
+		counter.Add(sequence)
+
+		expected := make(map[string]int)
+		kmers := window.Kmers(sequence, k)
+		for kmers.Next() {
+			expected[Canonical(kmers.Window())]++
+		}
+		assert.Equal(t, len(expected), counter.Distinct(), k)
+		for kmer, count := range expected {
+			assert.Equal(t, count, counter.Count(kmer), kmer)
+		}
+	}
+}
+
+// mutate changes a fraction of the bases of a sequence.
+func mutate(sequence string, fraction float64, seed int64) string {
+	random := rand.New(rand.NewSource(seed))
+	mutated := []byte(sequence)
+	for index := range mutated {
+		if random.Float64() < fraction {
+			mutated[index] = "ACGT"[(strings.IndexByte("ACGT", mutated[index])+1+random.Intn(3))%4]
+		}
+	}
+	return string(mutated)
+}
+
+// exactJaccard is the Jaccard similarity of the canonical k-mers of two
+// sequences.
+func exactJaccard(first, second string, k int) float64 {
+	kmers := func(sequence string) map[string]bool {
+		set := make(map[string]bool)
+		iterator := window.Kmers(sequence, k)
+		for iterator.Next() {
+			set[Canonical(iterator.Window())] = true
+		}
+		return set
+	}
+	firstKmers, secondKmers := kmers(first), kmers(second)
+	shared := 0
+	for kmer := range firstKmers {
+		if secondKmers[kmer] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(firstKmers)+len(secondKmers)-shared)
+}
+
+func TestSketch(t *testing.T) {
+	genome, err := random.DNASequence(50000, 2)
+	require.NoError(t, err)
+	mutated := mutate(genome, 0.01, 3)
+	unrelated, err := random.DNASequence(50000, 4)
+	require.NoError(t, err)
+
+	sketch := func(sequence string) *Sketch {
+		sketch, err := NewSketch(21, 2000, Options{})
+		require.NoError(t, err)
+		sketch.Add(sequence)
+		assert.Len(t, sketch.Hashes, 2000)
+		return sketch
+	}
+	genomeSketch := sketch(genome)
+
+	jaccard, err := genomeSketch.Jaccard(sketch(transform.ReverseComplement(genome)))
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, jaccard)
+
+	jaccard, err = genomeSketch.Jaccard(sketch(mutated))
+	require.NoError(t, err)
+	assert.InDelta(t, exactJaccard(genome, mutated, 21), jaccard, 0.05)
+	distance, err := genomeSketch.MashDistance(sketch(mutated))
+	require.NoError(t, err)
+	assert.InDelta(t, 0.01, distance, 0.003)
+
+	jaccard, err = genomeSketch.Jaccard(sketch(unrelated))
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, jaccard)
+	distance, err = genomeSketch.MashDistance(sketch(unrelated))
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, distance)
+
+	// a plasmid sized piece of the genome is contained in it, but the
+	// genome isn't contained in the piece.
+	piece, err := NewSketch(21, 2000, Options{})
+	require.NoError(t, err)
+	piece.Add(genome[10000:15000])
+	containment, err := piece.Containment(genomeSketch)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, containment)
+	containment, err = genomeSketch.Containment(piece)
+	require.NoError(t, err)
+	assert.Less(t, containment, 0.2)
+
+	other, err := NewSketch(17, 2000, Options{})
+	require.NoError(t, err)
+	_, err = genomeSketch.Jaccard(other)
+	assert.Error(t, err)
+	_, err = NewSketch(21, 0, Options{})
+	assert.Error(t, err)
+}
+
+func TestMinimizers(t *testing.T) {
+	sequence, err := random.DNASequence(2000, 5)
+	require.NoError(t, err)
+	k, size := 15, 10
+	minimizers := Minimizers(sequence, k, size, Options{})
+
+	// every window's minimizer is the smallest hash in it, found the slow way.
+	var hashes []Minimizer
+	scan(sequence, k, Options{}, func(position int, code uint64) {
+		hashes = append(hashes, Minimizer{position, hash(code)})
+	})
+	var expected []Minimizer
+	for start := 0; start+size <= len(hashes); start++ {
+		smallest := hashes[start]
+		for _, candidate := range hashes[start : start+size] {
+			if candidate.Hash < smallest.Hash {
+				smallest = candidate
+			}
+		}
+		if len(expected) == 0 || expected[len(expected)-1] != smallest {
+			expected = append(expected, smallest)
+		}
+	}
+	assert.Equal(t, expected, minimizers)
+	// roughly two minimizers are picked for every window of k-mers.
+	assert.InDelta(t, 2.0/float64(size+1), float64(len(minimizers))/float64(len(hashes)), 0.05)
+
+	first, err := NewMinimizerSketch(k, size, Options{})
+	require.NoError(t, err)
+	first.Add(sequence)
+	second, err := NewMinimizerSketch(k, size, Options{})
+	require.NoError(t, err)
+	second.Add(transform.ReverseComplement(sequence))
+	jaccard, err := first.Jaccard(second)
+	require.NoError(t, err)
+	assert.Greater(t, jaccard, 0.9)
+
+	minHash, err := NewSketch(k, 100, Options{})
+	require.NoError(t, err)
+	_, err = first.Jaccard(minHash)
+	assert.Error(t, err)
+	assert.Empty(t, Minimizers("ACGT", 15, 10, Options{}))
+}
+
+func TestPacked(t *testing.T) {
+	sequence, err := random.DNASequence(3000, 7)
+	require.NoError(t, err)
+	// Ns, soft masking, and a k-mer across the origin of circular sequences.
+	sequence = sequence[:1000] + "NNNN" + strings.ToLower(sequence[1000:1500]) + sequence[1500:]
+	for _, options := range []Options{{}, {Stranded: true}, {Circular: true}} {
+		counter, err := NewCounter(21, options)
+		require.NoError(t, err)
+		counter.Add(sequence)
+		packedCounter, err := NewCounter(21, options)
+		require.NoError(t, err)
+		packedCounter.AddPacked(packed.Pack(sequence))
+		assert.Equal(t, counter.counts, packedCounter.counts, options)
+		assert.Equal(t, counter.Total(), packedCounter.Total(), options)
+
+		assert.Equal(t, Minimizers(sequence, 15, 10, options), PackedMinimizers(packed.Pack(sequence), 15, 10, options), options)
+
+		sketch, err := NewSketch(21, 100, options)
+		require.NoError(t, err)
+		sketch.Add(sequence)
+		packedSketch, err := NewSketch(21, 100, options)
+		require.NoError(t, err)
+		packedSketch.AddPacked(packed.Pack(sequence))
+		assert.Equal(t, sketch.Hashes, packedSketch.Hashes, options)
+	}
+}
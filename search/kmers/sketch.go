@@ -0,0 +1,264 @@
+package kmers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/bebop/poly/sequence/packed"
+)
+
+/******************************************************************************
+
+Sketching begins here.
+
+Sketches hash packed k-mers with a 64 bit mixing function, so the k-mers they
+keep are a pseudorandom sample that's the same for every sequence. Two
+sketches can only be compared if they were made with the same k, the same
+strandedness, and the same way of sampling.
+
+******************************************************************************/
+
+// Sketch is a sample of the k-mers of one or more sequences, kept as the
+// sorted, distinct hashes of the k-mers.
+type Sketch struct {
+	K int
+	// Size is the most hashes a MinHash sketch keeps, or 0 for a minimizer
+	// sketch, which keeps a hash for every window.
+	Size int
+	// Window is how many k-mers a minimizer sketch picks one from, or 0 for
+	// a MinHash sketch.
+	Window  int
+	Options Options
+	Hashes  []uint64
+}
+
+// Minimizer is a k-mer picked by a minimizer sketch, and where it is.
+type Minimizer struct {
+	Position int
+	Hash     uint64
+}
+
+// hash mixes a packed k-mer into a hash, with the finalizer of splitmix64.
+func hash(code uint64) uint64 {
+	code ^= code >> 30
+	code *= 0xbf58476d1ce4e5b9
+	code ^= code >> 27
+	code *= 0x94d049bb133111eb
+	code ^= code >> 31
+	return code
+}
+
+// NewSketch returns an empty MinHash sketch that keeps the size smallest
+// hashes of the k-mers of length k it's given.
+func NewSketch(k, size int, options Options) (*Sketch, error) {
+	if err := validateK(k); err != nil {
+		return nil, err
+	}
+	if size < 1 {
+		return nil, fmt.Errorf("sketch size must be at least 1, got %d", size)
+	}
+	return &Sketch{K: k, Size: size, Options: options}, nil
+}
+
+// NewMinimizerSketch returns an empty minimizer sketch that keeps the
+// smallest hash of every window of window k-mers of length k it's given.
+func NewMinimizerSketch(k, window int, options Options) (*Sketch, error) {
+	if err := validateK(k); err != nil {
+		return nil, err
+	}
+	if window < 1 {
+		return nil, fmt.Errorf("minimizer window must be at least 1 k-mer, got %d", window)
+	}
+	return &Sketch{K: k, Window: window, Options: options}, nil
+}
+
+// Add sketches the k-mers of a sequence along with those already sketched.
+func (sketch *Sketch) Add(sequence string) {
+	if sketch.Window > 0 {
+		for _, minimizer := range Minimizers(sequence, sketch.K, sketch.Window, sketch.Options) {
+			sketch.insert(minimizer.Hash)
+		}
+		return
+	}
+	scan(sequence, sketch.K, sketch.Options, func(_ int, code uint64) {
+		sketch.insert(hash(code))
+	})
+}
+
+// AddPacked sketches the k-mers of a packed sequence, the same as Add
+// sketches them unpacked.
+func (sketch *Sketch) AddPacked(sequence packed.Sequence) {
+	if sketch.Window > 0 {
+		for _, minimizer := range PackedMinimizers(sequence, sketch.K, sketch.Window, sketch.Options) {
+			sketch.insert(minimizer.Hash)
+		}
+		return
+	}
+	scanPacked(sequence, sketch.K, sketch.Options, func(_ int, code uint64) {
+		sketch.insert(hash(code))
+	})
+}
+
+// insert adds a hash to the sorted hashes, if it's small enough to keep and
+// isn't already there.
+func (sketch *Sketch) insert(hashed uint64) {
+	full := sketch.Size > 0 && len(sketch.Hashes) >= sketch.Size
+	if full && hashed >= sketch.Hashes[len(sketch.Hashes)-1] {
+		return
+	}
+	index := sort.Search(len(sketch.Hashes), func(index int) bool { return sketch.Hashes[index] >= hashed })
+	if index < len(sketch.Hashes) && sketch.Hashes[index] == hashed {
+		return
+	}
+	if full {
+		sketch.Hashes = sketch.Hashes[:len(sketch.Hashes)-1]
+	}
+	sketch.Hashes = append(sketch.Hashes, 0)
+	copy(sketch.Hashes[index+1:], sketch.Hashes[index:])
+	sketch.Hashes[index] = hashed
+}
+
+// compatible returns an error if two sketches can't be compared.
+func (sketch *Sketch) compatible(other *Sketch) error {
+	if sketch.K != other.K || sketch.Options.Stranded != other.Options.Stranded || sketch.Window != other.Window || (sketch.Size == 0) != (other.Size == 0) {
+		return errors.New("sketches made with different k, strandedness, or sampling can't be compared")
+	}
+	return nil
+}
+
+// Jaccard estimates the Jaccard similarity of the k-mers of two sketched
+// sequences, the fraction of the k-mers either of them has that both have.
+// MinHash sketches estimate it from the smallest hashes of both combined,
+// as many as the smaller sketch keeps, and minimizer sketches from all of
+// their hashes.
+func (sketch *Sketch) Jaccard(other *Sketch) (float64, error) {
+	if err := sketch.compatible(other); err != nil {
+		return 0, err
+	}
+	limit := math.MaxInt
+	if sketch.Size > 0 {
+		limit = min(sketch.Size, other.Size)
+	}
+	union, shared := 0, 0
+	index, otherIndex := 0, 0
+	for union < limit && (index < len(sketch.Hashes) || otherIndex < len(other.Hashes)) {
+		switch {
+		case otherIndex == len(other.Hashes) || (index < len(sketch.Hashes) && sketch.Hashes[index] < other.Hashes[otherIndex]):
+			index++
+		case index == len(sketch.Hashes) || other.Hashes[otherIndex] < sketch.Hashes[index]:
+			otherIndex++
+		default:
+			shared++
+			index++
+			otherIndex++
+		}
+		union++
+	}
+	if union == 0 {
+		return 0, nil
+	}
+	return float64(shared) / float64(union), nil
+}
+
+// Containment estimates the fraction of the k-mers of the sketch's sequences
+// that are also in the other's, which is what to screen with when one is
+// much bigger than the other, like a plasmid and a genome it might be in.
+// Only hashes small enough that both sketches would have kept them are
+// compared.
+func (sketch *Sketch) Containment(other *Sketch) (float64, error) {
+	if err := sketch.compatible(other); err != nil {
+		return 0, err
+	}
+	threshold := uint64(math.MaxUint64)
+	for _, full := range []*Sketch{sketch, other} {
+		if full.Size > 0 && len(full.Hashes) >= full.Size {
+			threshold = min(threshold, full.Hashes[len(full.Hashes)-1])
+		}
+	}
+	counted, shared := 0, 0
+	for _, hashed := range sketch.Hashes {
+		if hashed > threshold {
+			break
+		}
+		counted++
+		if other.sortedContains(hashed) {
+			shared++
+		}
+	}
+	if counted == 0 {
+		return 0, nil
+	}
+	return float64(shared) / float64(counted), nil
+}
+
+// sortedContains is whether the sketch has a hash.
+func (sketch *Sketch) sortedContains(hashed uint64) bool {
+	index := sort.Search(len(sketch.Hashes), func(index int) bool { return sketch.Hashes[index] >= hashed })
+	return index < len(sketch.Hashes) && sketch.Hashes[index] == hashed
+}
+
+// MashDistance estimates the mutation distance between two sketched
+// sequences from their Jaccard similarity, as the fraction of bases that
+// differ (Ondov et al. 2016). Sequences that share no k-mers are 1 apart.
+func (sketch *Sketch) MashDistance(other *Sketch) (float64, error) {
+	jaccard, err := sketch.Jaccard(other)
+	if err != nil || jaccard == 0 {
+		return 1, err
+	}
+	return math.Min(1, -math.Log(2*jaccard/(1+jaccard))/float64(sketch.K)), nil
+}
+
+// Minimizers returns the (window, k) minimizers of a sequence: the k-mer with
+// the smallest hash of every window of window consecutive k-mers, leftmost
+// if there's a tie. Windows that share their minimizer only report it once.
+// Windows are made of k-mers without ambiguous bases, so Ns don't split them.
+func Minimizers(sequence string, k, window int, options Options) []Minimizer {
+	return minimizers(func(found func(position int, code uint64)) {
+		scan(sequence, k, options, found)
+	}, k, window)
+}
+
+// PackedMinimizers returns the minimizers of a packed sequence, the same as
+// Minimizers returns them unpacked.
+func PackedMinimizers(sequence packed.Sequence, k, window int, options Options) []Minimizer {
+	return minimizers(func(found func(position int, code uint64)) {
+		scanPacked(sequence, k, options, found)
+	}, k, window)
+}
+
+// minimizers returns the minimizers of the k-mers scanKmers finds.
+func minimizers(scanKmers func(found func(position int, code uint64)), k, window int) []Minimizer {
+	if validateK(k) != nil || window < 1 {
+		return nil
+	}
+	var minimizers []Minimizer
+	// candidates are the k-mers of the current window that could still be
+	// the minimizer of some window, with increasing hashes, and ordinals
+	// counts k-mers so windows are window k-mers long whatever is skipped.
+	type candidate struct {
+		Minimizer
+		ordinal int
+	}
+	var candidates []candidate
+	ordinal := 0
+	scanKmers(func(position int, code uint64) {
+		current := candidate{Minimizer{Position: position, Hash: hash(code)}, ordinal}
+		ordinal++
+		for len(candidates) > 0 && candidates[len(candidates)-1].Hash > current.Hash {
+			candidates = candidates[:len(candidates)-1]
+		}
+		candidates = append(candidates, current)
+		if candidates[0].ordinal <= current.ordinal-window {
+			candidates = candidates[1:]
+		}
+		if ordinal < window {
+			return
+		}
+		if len(minimizers) == 0 || minimizers[len(minimizers)-1] != candidates[0].Minimizer {
+			minimizers = append(minimizers, candidates[0].Minimizer)
+		}
+	})
+	return minimizers
+}
@@ -0,0 +1,178 @@
+package mapper
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+/******************************************************************************
+
+Coverage begins here.
+
+Once reads are mapped, the first question is whether there are enough of
+them everywhere. A construct with a mean depth of 200 can still have a stretch
+with no reads at all, and a mutation hiding in it. So besides the depth at
+every base, Coverage reports:
+
+Breadth, the fraction of bases covered by at least one read.
+
+Evenness, from Oexle et al., 2016 (https://doi.org/10.1038/jhg.2016.35). It's
+1 when every base has the same depth, and drops as more of the bases fall
+below the mean.
+
+The Gini coefficient of the depths, which is 0 when every base has the same
+depth and approaches 1 when all the reads pile up in one place.
+
+And every interval whose depth is below a minimum, which is where to look
+first when a verification comes back inconclusive.
+
+******************************************************************************/
+
+// Coverage is the read coverage of a single reference.
+type Coverage struct {
+	Reference   string
+	Depths      []int   // number of reads covering each base. Deletions don't count.
+	MeanDepth   float64 // mean of Depths.
+	Breadth     float64 // fraction of bases with a depth of at least 1.
+	Evenness    float64 // from 0 to 1, where 1 is perfectly even.
+	Gini        float64 // Gini coefficient of Depths, from 0 (perfectly even) to 1.
+	LowCoverage []LowCoverage
+}
+
+// LowCoverage is a run of bases whose depth is below a minimum.
+type LowCoverage struct {
+	Start, End int     // 0-based, half open.
+	MeanDepth  float64 // mean depth of the run.
+}
+
+// Coverage computes the coverage of every reference from the alignments of
+// its reads, in the order the references were given to New. Runs of bases
+// with a depth below minDepth are reported as LowCoverage.
+func (mapper *Mapper) Coverage(alignments []Alignment, minDepth int) []Coverage {
+	depths := make(map[string][]int)
+	for name, length := range mapper.lengths {
+		depths[name] = make([]int, length)
+	}
+	for _, alignment := range alignments {
+		referenceDepths, ok := depths[alignment.Reference]
+		if !ok || len(referenceDepths) == 0 {
+			continue
+		}
+		position := alignment.Position
+		for _, operation := range alignment.Operations() {
+			switch operation.Kind {
+			case 'M', '=', 'X':
+				for step := 0; step < operation.Length; step++ {
+					// wraps around the origin of circular references.
+					referenceDepths[(position+step)%len(referenceDepths)]++
+				}
+				position += operation.Length
+			case 'D':
+				position += operation.Length
+			}
+		}
+	}
+
+	names := make([]string, 0, len(depths))
+	for name := range depths {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return mapper.order[names[i]] < mapper.order[names[j]] })
+	coverages := make([]Coverage, len(names))
+	for index, name := range names {
+		coverages[index] = Summarize(name, depths[name], minDepth)
+	}
+	return coverages
+}
+
+// Summarize computes coverage statistics from the depth at every base of a
+// reference, however they were counted.
+func Summarize(reference string, depths []int, minDepth int) Coverage {
+	coverage := Coverage{Reference: reference, Depths: depths}
+	if len(depths) == 0 {
+		return coverage
+	}
+	total, covered := 0, 0
+	for _, depth := range depths {
+		total += depth
+		if depth > 0 {
+			covered++
+		}
+	}
+	coverage.MeanDepth = float64(total) / float64(len(depths))
+	coverage.Breadth = float64(covered) / float64(len(depths))
+	coverage.Evenness = evenness(depths, coverage.MeanDepth)
+	coverage.Gini = gini(depths, total)
+
+	for start := 0; start < len(depths); {
+		if depths[start] >= minDepth {
+			start++
+			continue
+		}
+		end, sum := start, 0
+		for end < len(depths) && depths[end] < minDepth {
+			sum += depths[end]
+			end++
+		}
+		coverage.LowCoverage = append(coverage.LowCoverage, LowCoverage{Start: start, End: end, MeanDepth: float64(sum) / float64(end-start)})
+		start = end
+	}
+	return coverage
+}
+
+// evenness is the evenness score E of Oexle et al., 2016.
+func evenness(depths []int, meanDepth float64) float64 {
+	roundedMean := math.Round(meanDepth)
+	if roundedMean == 0 {
+		return 0
+	}
+	// only the bases at or below the mean pull the score down.
+	below, belowTotal := 0, 0
+	for _, depth := range depths {
+		if float64(depth) <= roundedMean {
+			below++
+			belowTotal += depth
+		}
+	}
+	return 1 - (float64(below)-float64(belowTotal)/roundedMean)/float64(len(depths))
+}
+
+// gini is the Gini coefficient of depths, which add up to total.
+func gini(depths []int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	sorted := append([]int{}, depths...)
+	sort.Ints(sorted)
+	weighted := 0.0
+	for rank, depth := range sorted {
+		weighted += float64(rank+1) * float64(depth)
+	}
+	length := float64(len(sorted))
+	return 2*weighted/(length*float64(total)) - (length+1)/length
+}
+
+// Operation is a run of a single CIGAR operation.
+type Operation struct {
+	Length int
+	Kind   byte // one of =, X, I, D, or M for alignments from elsewhere.
+}
+
+// Operations splits an alignment's CIGAR string into its operations,
+// skipping anything malformed.
+func (alignment Alignment) Operations() []Operation {
+	var operations []Operation
+	start := 0
+	for index := 0; index < len(alignment.Cigar); index++ {
+		if alignment.Cigar[index] >= '0' && alignment.Cigar[index] <= '9' {
+			continue
+		}
+		length, err := strconv.Atoi(alignment.Cigar[start:index])
+		if err == nil {
+			operations = append(operations, Operation{length, alignment.Cigar[index]})
+		}
+		start = index + 1
+	}
+	return operations
+}
@@ -0,0 +1,82 @@
+package mapper
+
+import (
+	"testing"
+
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	even := Summarize("even", []int{5, 5, 5, 5}, 1)
+	assert.Equal(t, 5.0, even.MeanDepth)
+	assert.Equal(t, 1.0, even.Breadth)
+	assert.Equal(t, 1.0, even.Evenness)
+	assert.InDelta(t, 0, even.Gini, 1e-9)
+	assert.Empty(t, even.LowCoverage)
+
+	// everything piled up on one base.
+	piled := Summarize("piled", []int{0, 0, 0, 8}, 1)
+	assert.Equal(t, 2.0, piled.MeanDepth)
+	assert.Equal(t, 0.25, piled.Breadth)
+	assert.InDelta(t, 0.25, piled.Evenness, 1e-9)
+	assert.InDelta(t, 0.75, piled.Gini, 1e-9)
+	assert.Equal(t, []LowCoverage{{Start: 0, End: 3, MeanDepth: 0}}, piled.LowCoverage)
+
+	gappy := Summarize("gappy", []int{10, 2, 3, 10, 10, 0}, 5)
+	assert.Equal(t, []LowCoverage{{Start: 1, End: 3, MeanDepth: 2.5}, {Start: 5, End: 6, MeanDepth: 0}}, gappy.LowCoverage)
+
+	empty := Summarize("empty", nil, 5)
+	assert.Equal(t, Coverage{Reference: "empty"}, empty)
+	none := Summarize("none", []int{0, 0}, 1)
+	assert.Equal(t, 0.0, none.Evenness)
+	assert.Equal(t, 0.0, none.Gini)
+}
+
+func TestCoverage(t *testing.T) {
+	plasmid, _ := random.DNASequence(300, 9)
+	other, _ := random.DNASequence(100, 10)
+	mapper, err := New([]fasta.Fasta{{Name: "plasmid", Sequence: plasmid}, {Name: "other", Sequence: other}}, Options{Circular: true})
+	require.NoError(t, err)
+
+	alignments := []Alignment{
+		{Reference: "plasmid", Position: 0, Cigar: "100="},
+		{Reference: "plasmid", Position: 50, Cigar: "10=2D10=1I9="},
+		// across the origin.
+		{Reference: "plasmid", Position: 290, Cigar: "20="},
+		{Reference: "missing", Position: 0, Cigar: "20="},
+	}
+	coverages := mapper.Coverage(alignments, 1)
+	require.Len(t, coverages, 2)
+	assert.Equal(t, "plasmid", coverages[0].Reference)
+	assert.Equal(t, "other", coverages[1].Reference)
+
+	depths := coverages[0].Depths
+	assert.Equal(t, 2, depths[0])
+	assert.Equal(t, 2, depths[9])
+	assert.Equal(t, 1, depths[10])
+	assert.Equal(t, 2, depths[55])
+	assert.Equal(t, 1, depths[60])
+	assert.Equal(t, 1, depths[61])
+	assert.Equal(t, 2, depths[62])
+	assert.Equal(t, 2, depths[80])
+	assert.Equal(t, 1, depths[81])
+	assert.Equal(t, 0, depths[100])
+	assert.Equal(t, 1, depths[295])
+	assert.Equal(t, []LowCoverage{{Start: 100, End: 290, MeanDepth: 0}}, coverages[0].LowCoverage)
+	assert.Equal(t, 0.0, coverages[1].Breadth)
+
+	// reads mapped for real.
+	alignment, ok := mapper.Map(plasmid[100:200])
+	require.True(t, ok)
+	coverages = mapper.Coverage([]Alignment{alignment}, 1)
+	assert.InDelta(t, 1.0/3, coverages[0].Breadth, 1e-9)
+}
+
+func TestOperations(t *testing.T) {
+	assert.Equal(t, []Operation{{10, '='}, {2, 'D'}, {3, 'M'}}, Alignment{Cigar: "10=2D3M"}.Operations())
+	assert.Empty(t, Alignment{}.Operations())
+	assert.Equal(t, []Operation{{4, 'X'}}, Alignment{Cigar: "=4X"}.Operations())
+}
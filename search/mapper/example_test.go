@@ -0,0 +1,19 @@
+package mapper_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/search/mapper"
+)
+
+func ExampleMapper_Map() {
+	plasmid := "ATGACCATGATTACGCCAAGCTTGCATGCCTGCAGGTCGACTCTAGAGGATCCCCGGGTACCGAGCTCGAATTCACTGGCCGTCGTTTTACAACGTCGTGACTGGGAAAACCCTGGCG"
+	readMapper, _ := mapper.New([]fasta.Fasta{{Name: "pUC19 lacZ", Sequence: plasmid}}, mapper.Options{Circular: true})
+
+	// a read with one mismatch, spanning the origin.
+	read := "CAACGTCGTGACTGGGAAAACCCTGGCGATGACCATGATTACGCCTAGCTTGCATG"
+	alignment, _ := readMapper.Map(read)
+	fmt.Println(alignment.Reference, alignment.Position, alignment.Forward, alignment.Cigar)
+	// Output: pUC19 lacZ 90 true 45=1X10=
+}
@@ -0,0 +1,391 @@
+/*
+Package mapper aligns short reads to reference sequences.
+
+Checking a plasmid prep or an amplicon by sequencing means lining every read
+up against the construct it should have come from, and then looking for the
+places where they disagree. Usually that means shelling out to bowtie or bwa,
+which is fine on a cluster and a pain everywhere else. For references the
+size of a plasmid, or a handful of them, this package does the job without
+leaving Go.
+
+It works the same way those tools do, by seed and extend. Short exact pieces
+of a read (the seeds) are looked up in an FM-index of the references, and
+every place a seed lands suggests where the whole read might go. Each of
+those places is then checked with a proper alignment that allows mismatches,
+insertions, and deletions, and the closest one wins. If a read has at least
+one seed without a sequencing error in it, which is nearly always the case
+for short reads off a good sequencer, it will be found.
+
+Reads are tried on both strands, and circular references, like plasmids, can
+have reads mapped across their origin. Once they're mapped, Coverage tells
+you how deep and how evenly the reads cover each reference, and where they
+don't.
+
+For more on seed and extend:
+Langmead and Salzberg, 2012
+https://doi.org/10.1038/nmeth.1923
+*/
+package mapper
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/io/fastq"
+	"github.com/bebop/poly/search/fmindex"
+	"github.com/bebop/poly/transform"
+)
+
+// Options changes how reads are mapped. Zero values are replaced with the
+// defaults noted on each field.
+type Options struct {
+	SeedLength   int // length of each seed. Defaults to 20, or the read length if shorter.
+	SeedInterval int // distance between the starts of seeds. Defaults to 10.
+	// MaxSeedHits skips seeds that land in more places than this, which
+	// only happens in repeats. Defaults to 100.
+	MaxSeedHits int
+	// MaxEdits is the most mismatches, insertions, and deletions a read can
+	// have against its reference. Defaults to a tenth of the read length.
+	MaxEdits int
+	Circular bool // treats every reference as circular.
+}
+
+// Alignment is where a read maps.
+type Alignment struct {
+	Read      string // identifier of the read, if it had one.
+	Reference string // name of the reference the read maps to.
+	// Position is the 0-based start of the alignment on the forward strand of
+	// the reference. Alignments across the origin of a circular reference
+	// run off its end and continue from 0.
+	Position int
+	Forward  bool // whether the read maps to the forward strand.
+	Edits    int  // mismatches, insertions, and deletions against the reference.
+	// Cigar describes the alignment of the read, reverse complemented if it
+	// maps to the reverse strand, against the reference, with = for a match,
+	// X for a mismatch, I for a base missing from the reference, and D for a
+	// base missing from the read, like "50=1X20=2D29=".
+	Cigar string
+	// MappingQuality is how sure the mapper is that the read belongs here
+	// rather than somewhere else, from 0 (just as good elsewhere) to 60
+	// (nowhere else comes close), like a SAM MAPQ.
+	MappingQuality int
+}
+
+// Mapper maps reads to a fixed set of references.
+type Mapper struct {
+	index      *fmindex.Index
+	references map[string]string // indexed text of each reference, doubled if circular.
+	lengths    map[string]int    // length of each reference.
+	order      map[string]int    // position of each reference in the input.
+	options    Options
+}
+
+// New indexes references for mapping.
+func New(references []fasta.Fasta, options Options) (*Mapper, error) {
+	if options.SeedLength == 0 {
+		options.SeedLength = 20
+	}
+	if options.SeedInterval == 0 {
+		options.SeedInterval = 10
+	}
+	if options.MaxSeedHits == 0 {
+		options.MaxSeedHits = 100
+	}
+	mapper := &Mapper{
+		references: make(map[string]string),
+		lengths:    make(map[string]int),
+		order:      make(map[string]int),
+		options:    options,
+	}
+	indexed := make([]fasta.Fasta, len(references))
+	for position, reference := range references {
+		sequence := strings.ToUpper(reference.Sequence)
+		if _, ok := mapper.references[reference.Name]; ok {
+			return nil, fmt.Errorf("there's more than one reference named %s", reference.Name)
+		}
+		mapper.lengths[reference.Name] = len(sequence)
+		mapper.order[reference.Name] = position
+		if options.Circular {
+			// a read across the origin is a plain substring of the doubled
+			// sequence.
+			sequence += sequence
+		}
+		mapper.references[reference.Name] = sequence
+		indexed[position] = fasta.Fasta{Name: reference.Name, Sequence: sequence}
+	}
+	index, err := fmindex.New(indexed)
+	if err != nil {
+		return nil, err
+	}
+	mapper.index = index
+	return mapper, nil
+}
+
+// Map finds the best alignment of a read. It returns false if the read
+// doesn't map anywhere within MaxEdits.
+func (mapper *Mapper) Map(read string) (Alignment, bool) {
+	alignments := mapper.candidates(strings.ToUpper(read))
+	if len(alignments) == 0 {
+		return Alignment{}, false
+	}
+	best := alignments[0]
+	best.MappingQuality = 60
+	if len(alignments) > 1 {
+		// every edit between the best and the runner up is worth 10.
+		best.MappingQuality = min(60, 10*(alignments[1].Edits-best.Edits))
+	}
+	return best, true
+}
+
+// MapReads maps every read, and returns the alignments of the ones that map,
+// in order.
+func (mapper *Mapper) MapReads(reads []fastq.Fastq) []Alignment {
+	var alignments []Alignment
+	for _, read := range reads {
+		if alignment, ok := mapper.Map(read.Sequence); ok {
+			alignment.Read = read.Identifier
+			alignments = append(alignments, alignment)
+		}
+	}
+	return alignments
+}
+
+/******************************************************************************
+
+Seed and extend begins here.
+
+A seed that lands at reference position p, and starts at position s of the
+read, puts the read on the diagonal p - s. Every seed of an error free read
+lands on the same diagonal; insertions and deletions shift it by a base or
+two. So seed hits are gathered by diagonal, and each diagonal is extended
+once, by aligning the whole read against the reference from MaxEdits bases
+before the diagonal to MaxEdits bases after the read's end. The alignment is
+global on the read and local on the reference, so the read can start and end
+anywhere in that window but all of it has to be aligned.
+
+******************************************************************************/
+
+// candidate is a place a read might map.
+type candidate struct {
+	reference string
+	diagonal  int
+	forward   bool
+}
+
+// candidates returns every distinct alignment of a read within MaxEdits,
+// closest first.
+func (mapper *Mapper) candidates(read string) []Alignment {
+	maxEdits := mapper.options.MaxEdits
+	if maxEdits == 0 {
+		maxEdits = len(read) / 10
+	}
+	seedLength := min(mapper.options.SeedLength, len(read))
+	if seedLength == 0 {
+		return nil
+	}
+
+	seen := make(map[candidate]bool)
+	var candidates []candidate
+	for _, forward := range []bool{true, false} {
+		oriented := read
+		if !forward {
+			oriented = transform.ReverseComplement(read)
+		}
+		starts := []int{}
+		for start := 0; start+seedLength <= len(oriented); start += mapper.options.SeedInterval {
+			starts = append(starts, start)
+		}
+		// always seed the end of the read too.
+		if last := len(oriented) - seedLength; starts[len(starts)-1] != last {
+			starts = append(starts, last)
+		}
+		for _, start := range starts {
+			seed := oriented[start : start+seedLength]
+			if strings.ContainsRune(seed, 'N') || mapper.index.Count(seed) > mapper.options.MaxSeedHits {
+				continue
+			}
+			for _, match := range mapper.index.Locate(seed) {
+				diagonal := match.Position - start
+				if mapper.options.Circular {
+					// the same place shows up in both copies of a doubled
+					// reference, so diagonals are kept in the first copy.
+					length := mapper.lengths[match.Name]
+					diagonal = ((diagonal % length) + length) % length
+				}
+				key := candidate{match.Name, diagonal, forward}
+				if !seen[key] {
+					seen[key] = true
+					candidates = append(candidates, key)
+				}
+			}
+		}
+	}
+
+	found := make(map[[2]int]Alignment)
+	for _, candidate := range candidates {
+		oriented := read
+		if !candidate.forward {
+			oriented = transform.ReverseComplement(read)
+		}
+		alignment, ok := mapper.extend(oriented, candidate, maxEdits)
+		if !ok {
+			continue
+		}
+		key := [2]int{mapper.order[candidate.reference]*2 + boolToInt(candidate.forward), alignment.Position}
+		if existing, ok := found[key]; ok && existing.Edits <= alignment.Edits {
+			continue
+		}
+		found[key] = alignment
+	}
+
+	alignments := make([]Alignment, 0, len(found))
+	for _, alignment := range found {
+		alignments = append(alignments, alignment)
+	}
+	sort.Slice(alignments, func(i, j int) bool {
+		first, second := alignments[i], alignments[j]
+		switch {
+		case first.Edits != second.Edits:
+			return first.Edits < second.Edits
+		case first.Reference != second.Reference:
+			return mapper.order[first.Reference] < mapper.order[second.Reference]
+		case first.Position != second.Position:
+			return first.Position < second.Position
+		}
+		return first.Forward && !second.Forward
+	})
+	// alignments a base or two apart are usually the same alignment with
+	// an indel moved around, so only the best of each neighborhood counts
+	// against it.
+	var distinct []Alignment
+	for _, alignment := range alignments {
+		duplicate := false
+		for _, kept := range distinct {
+			if kept.Reference == alignment.Reference && kept.Forward == alignment.Forward && kept.Position-alignment.Position <= maxEdits && alignment.Position-kept.Position <= maxEdits {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			distinct = append(distinct, alignment)
+		}
+	}
+	return distinct
+}
+
+// extend aligns a read, already oriented to the forward strand, to the
+// reference around a diagonal.
+func (mapper *Mapper) extend(read string, candidate candidate, maxEdits int) (Alignment, bool) {
+	reference := mapper.references[candidate.reference]
+	windowStart := max(0, candidate.diagonal-maxEdits)
+	windowEnd := min(len(reference), candidate.diagonal+len(read)+maxEdits)
+	if windowStart >= windowEnd {
+		return Alignment{}, false
+	}
+	start, edits, cigar := fitAlignment(read, reference[windowStart:windowEnd])
+	if edits > maxEdits {
+		return Alignment{}, false
+	}
+	position := windowStart + start
+	if mapper.options.Circular {
+		position %= mapper.lengths[candidate.reference]
+	}
+	return Alignment{
+		Reference: candidate.reference,
+		Position:  position,
+		Forward:   candidate.forward,
+		Edits:     edits,
+		Cigar:     cigar,
+	}, true
+}
+
+// fitAlignment aligns all of read to the best matching part of window, by
+// edit distance, and returns where in window the alignment starts, its edit
+// distance, and its CIGAR string.
+func fitAlignment(read, window string) (start, edits int, cigar string) {
+	rows, columns := len(read)+1, len(window)+1
+	// distances[row*columns+column] is the edit distance of read[:row]
+	// against window[?:column], starting anywhere.
+	distances := make([]int, rows*columns)
+	for row := 1; row < rows; row++ {
+		distances[row*columns] = row
+		for column := 1; column < columns; column++ {
+			cost := 1
+			if read[row-1] == window[column-1] && read[row-1] != 'N' {
+				cost = 0
+			}
+			distances[row*columns+column] = min(
+				distances[(row-1)*columns+column-1]+cost,
+				distances[(row-1)*columns+column]+1, // read base missing from the window.
+				distances[row*columns+column-1]+1,   // window base missing from the read.
+			)
+		}
+	}
+	end := 0
+	for column := 1; column < columns; column++ {
+		if distances[(rows-1)*columns+column] < distances[(rows-1)*columns+end] {
+			end = column
+		}
+	}
+	edits = distances[(rows-1)*columns+end]
+
+	// trace back, preferring matches and mismatches so indels are placed
+	// as far left as they can go, but carrying on with an indel once it's
+	// started so it isn't split into several smaller ones.
+	var operations []byte
+	row, column := rows-1, end
+	for row > 0 {
+		current := distances[row*columns+column]
+		previous := byte(0)
+		if len(operations) > 0 {
+			previous = operations[len(operations)-1]
+		}
+		switch {
+		case previous == 'I' && current == distances[(row-1)*columns+column]+1:
+			operations = append(operations, 'I')
+			row--
+		case previous == 'D' && column > 0 && current == distances[row*columns+column-1]+1:
+			operations = append(operations, 'D')
+			column--
+		case column > 0 && current == distances[(row-1)*columns+column-1]+boolToInt(read[row-1] != window[column-1] || read[row-1] == 'N'):
+			if read[row-1] == window[column-1] && read[row-1] != 'N' {
+				operations = append(operations, '=')
+			} else {
+				operations = append(operations, 'X')
+			}
+			row, column = row-1, column-1
+		case current == distances[(row-1)*columns+column]+1:
+			operations = append(operations, 'I')
+			row--
+		default:
+			operations = append(operations, 'D')
+			column--
+		}
+	}
+	return column, edits, buildCigar(operations)
+}
+
+// buildCigar run length encodes backwards alignment operations.
+func buildCigar(operations []byte) string {
+	var cigar strings.Builder
+	for end := len(operations); end > 0; {
+		start := end - 1
+		for start > 0 && operations[start-1] == operations[end-1] {
+			start--
+		}
+		cigar.WriteString(strconv.Itoa(end - start))
+		cigar.WriteByte(operations[end-1])
+		end = start
+	}
+	return cigar.String()
+}
+
+func boolToInt(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,126 @@
+package mapper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/io/fastq"
+	"github.com/bebop/poly/random"
+	"github.com/bebop/poly/transform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFitAlignment(t *testing.T) {
+	tests := []struct {
+		read, window string
+		start, edits int
+		cigar        string
+	}{
+		{"ACGTACGT", "TTACGTACGTTT", 2, 0, "8="},
+		{"ACGTTCGT", "TTACGTACGTTT", 2, 1, "4=1X3="},
+		{"ACGTAACGT", "TTACGTACGTTT", 2, 1, "4=1I4="},
+		{"ACGACGT", "TTACGTACGTTT", 2, 1, "3=1D4="},
+		{"ACNTACGT", "TTACGTACGTTT", 2, 1, "2=1X5="},
+		{"ACGT", "", 0, 4, "4I"},
+		// a 2 base deletion is one run, not two split by a match.
+		{"CACCGGACCG", "CACCGGGAACCG", 0, 2, "6=2D4="},
+	}
+	for _, test := range tests {
+		start, edits, cigar := fitAlignment(test.read, test.window)
+		assert.Equal(t, test.start, start, test.read)
+		assert.Equal(t, test.edits, edits, test.read)
+		assert.Equal(t, test.cigar, cigar, test.read)
+	}
+}
+
+func TestMap(t *testing.T) {
+	repeat, _ := random.DNASequence(200, 1)
+	plasmid, _ := random.DNASequence(3000, 2)
+	genomeStart, _ := random.DNASequence(2000, 3)
+	genomeMiddle, _ := random.DNASequence(1000, 4)
+	genomeEnd, _ := random.DNASequence(1000, 5)
+	genome := genomeStart + repeat + genomeMiddle + repeat + genomeEnd
+	mapper, err := New([]fasta.Fasta{{Name: "plasmid", Sequence: plasmid}, {Name: "genome", Sequence: strings.ToLower(genome)}}, Options{})
+	require.NoError(t, err)
+
+	// an exact read.
+	alignment, ok := mapper.Map(plasmid[100:250])
+	require.True(t, ok)
+	assert.Equal(t, Alignment{Reference: "plasmid", Position: 100, Forward: true, Cigar: "150=", MappingQuality: 60}, alignment)
+
+	// a read with a substitution, an insertion, and a deletion.
+	substitution := string("CGTA"[strings.IndexByte("ACGT", plasmid[580])])
+	read := plasmid[500:540] + "A" + plasmid[540:580] + substitution + plasmid[581:600] + plasmid[601:650]
+	alignment, ok = mapper.Map(read)
+	require.True(t, ok)
+	assert.Equal(t, "plasmid", alignment.Reference)
+	assert.Equal(t, 500, alignment.Position)
+	assert.Equal(t, 3, alignment.Edits)
+
+	// the reverse strand, from the genome.
+	alignment, ok = mapper.Map(transform.ReverseComplement(genome[3500:3600]))
+	require.True(t, ok)
+	assert.Equal(t, Alignment{Reference: "genome", Position: 3500, Forward: false, Cigar: "100=", MappingQuality: 60}, alignment)
+
+	// a read in a repeat maps equally well to both copies.
+	alignment, ok = mapper.Map(repeat[50:150])
+	require.True(t, ok)
+	assert.Equal(t, 2050, alignment.Position)
+	assert.Equal(t, 0, alignment.MappingQuality)
+
+	// nothing close.
+	unrelated, _ := random.DNASequence(150, 6)
+	_, ok = mapper.Map(unrelated)
+	assert.False(t, ok)
+	_, ok = mapper.Map("")
+	assert.False(t, ok)
+
+	// too many errors for the default of a tenth of the read length.
+	mutated := []byte(plasmid[1000:1100])
+	for position := 5; position < 100; position += 8 {
+		mutated[position] = "CGTA"[strings.IndexByte("ACGT", mutated[position])]
+	}
+	_, ok = mapper.Map(string(mutated))
+	assert.False(t, ok)
+
+	// a read across the origin only maps if the plasmid is circular.
+	across := plasmid[2950:] + plasmid[:50]
+	_, ok = mapper.Map(across)
+	assert.False(t, ok)
+	circular, err := New([]fasta.Fasta{{Name: "plasmid", Sequence: plasmid}}, Options{Circular: true})
+	require.NoError(t, err)
+	alignment, ok = circular.Map(across)
+	require.True(t, ok)
+	assert.Equal(t, Alignment{Reference: "plasmid", Position: 2950, Forward: true, Cigar: "100=", MappingQuality: 60}, alignment)
+	alignment, ok = circular.Map(plasmid[10:110])
+	require.True(t, ok)
+	assert.Equal(t, 10, alignment.Position)
+	assert.Equal(t, 60, alignment.MappingQuality)
+}
+
+func TestMapReads(t *testing.T) {
+	plasmid, _ := random.DNASequence(2000, 7)
+	unmapped, _ := random.DNASequence(50, 8)
+	mapper, err := New([]fasta.Fasta{{Name: "plasmid", Sequence: plasmid}}, Options{SeedLength: 16, SeedInterval: 8, MaxEdits: 2})
+	require.NoError(t, err)
+	reads := []fastq.Fastq{
+		{Identifier: "read1", Sequence: plasmid[10:60]},
+		{Identifier: "unmapped", Sequence: unmapped},
+		{Identifier: "read2", Sequence: transform.ReverseComplement(plasmid[300:350])},
+	}
+	alignments := mapper.MapReads(reads)
+	require.Len(t, alignments, 2)
+	assert.Equal(t, "read1", alignments[0].Read)
+	assert.Equal(t, 10, alignments[0].Position)
+	assert.Equal(t, "read2", alignments[1].Read)
+	assert.False(t, alignments[1].Forward)
+}
+
+func TestNewErrors(t *testing.T) {
+	_, err := New([]fasta.Fasta{{Name: "a", Sequence: "ACGT"}, {Name: "a", Sequence: "ACGT"}}, Options{})
+	assert.Error(t, err)
+	_, err = New([]fasta.Fasta{{Name: "a", Sequence: "AC$GT"}}, Options{})
+	assert.Error(t, err)
+}
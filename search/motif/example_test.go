@@ -0,0 +1,27 @@
+package motif_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/search/motif"
+)
+
+func ExamplePattern_Find() {
+	bsaI, _ := motif.CompileIUPAC("GGTCTCN")
+	plasmid := "CTCAGATTACAAAGAGACCGATTACAGGT"
+	for _, match := range bsaI.Find(plasmid, motif.Options{Circular: true}) {
+		fmt.Println(genbank.BuildLocationString(match.Location), match.Sequence)
+	}
+	// Output:
+	// complement(13..19) GGTCTCT
+	// join(27..29,1..4) GGTCTCA
+}
+
+func ExampleCompilePROSITE() {
+	zincFinger, _ := motif.CompilePROSITE("C-x(2,4)-C-x(3)-[LIVMFYWC]-x(8)-H-x(3,5)-H")
+	for _, match := range zincFinger.Find("MSPKKRYPCPECGKSFSQSSNLQKHQRTHTGEKP", motif.Options{}) {
+		fmt.Println(match.Start, match.Sequence)
+	}
+	// Output: 8 CPECGKSFSQSSNLQKHQRTH
+}
@@ -0,0 +1,413 @@
+/*
+Package motif finds degenerate DNA motifs and PROSITE protein patterns in
+sequences.
+
+Plenty of the sites worth finding in a sequence aren't one exact string.
+BsaI cuts wherever it finds GGTCTC and then a base or so downstream, a
+ribosome binding site is some purine rich stretch a few bases before a start
+codon, and a zinc finger is two cysteines and two histidines at roughly the
+right spacing. Motifs like these are written with IUPAC ambiguity codes for
+DNA, like GGTCTCN, or as PROSITE patterns for proteins, like
+C-x(2,4)-C-x(3)-[LIVMFYWC]-x(8)-H-x(3,5)-H.
+
+CompileIUPAC and CompilePROSITE turn either kind of motif into a Pattern, and
+Find returns every place it matches. DNA patterns are looked for on both
+strands unless told otherwise, and either kind can be looked for across the
+origin of a circular sequence, like a plasmid. A few mismatches can be
+allowed too, for sites that are only nearly there.
+
+Matches know where they are as feature locations, so Features turns them into
+features that can be added straight to a record.
+
+PROSITE patterns are described here:
+https://prosite.expasy.org/scanprosite/scanprosite_doc.html#mo_motifs
+*/
+package motif
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bebop/poly/alphabet"
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/transform"
+)
+
+// Pattern is a compiled motif. It's safe to use from several goroutines at
+// once.
+type Pattern struct {
+	source   string
+	elements []element
+	// protein patterns aren't looked for on the reverse strand.
+	protein bool
+	// palindromic patterns are their own reverse complement, so matches on
+	// the reverse strand are the same sites as those on the forward one.
+	palindromic bool
+	// anchorStart and anchorEnd hold a PROSITE pattern to the start or end of
+	// the sequence.
+	anchorStart, anchorEnd bool
+}
+
+// element is a position of a pattern that matches between min and max
+// residues, each of which has to be allowed.
+type element struct {
+	allowed  [256]bool
+	min, max int
+	// orEnd lets the element match the end of the sequence instead, like
+	// [G>] in PROSITE.
+	orEnd bool
+}
+
+// Options changes how a pattern is looked for. The zero value looks for DNA
+// patterns on both strands of a linear sequence, without mismatches.
+type Options struct {
+	// Circular finds matches across the end of the sequence into its start.
+	Circular bool
+	// Stranded only looks for matches on the sequence as written. Protein
+	// patterns are only ever looked for as written.
+	Stranded bool
+	// MaxMismatches is how many residues of a match can be ones the pattern
+	// doesn't allow.
+	MaxMismatches int
+}
+
+// Match is a place a pattern matches.
+type Match struct {
+	// Start and End are where the match is on the sequence as written, as a
+	// half-open range. End is past the end of a circular sequence for
+	// matches that cross its origin.
+	Start, End int
+	// Reverse is whether the match is on the reverse complement strand.
+	Reverse bool
+	// Sequence is what matched, read 5' to 3' on its own strand.
+	Sequence   string
+	Mismatches int
+	// Pattern is the pattern that matched, as it was written.
+	Pattern string
+	// Location is where the match is, as a feature location.
+	Location genbank.Location
+}
+
+// String returns the pattern as it was written.
+func (pattern *Pattern) String() string {
+	return pattern.source
+}
+
+/******************************************************************************
+
+Pattern compilation begins here.
+
+******************************************************************************/
+
+// CompileIUPAC compiles a DNA motif written with IUPAC ambiguity codes, like
+// GGTCTCN. Ambiguous bases in searched sequences only match positions of the
+// pattern that allow every base they stand for, so an N in a sequence only
+// matches an N in the pattern.
+func CompileIUPAC(motif string) (*Pattern, error) {
+	if motif == "" {
+		return nil, fmt.Errorf("empty motif")
+	}
+	upper := strings.ToUpper(motif)
+	pattern := &Pattern{source: motif}
+	for position := 0; position < len(upper); position++ {
+		bases := alphabet.Ambiguities(upper[position])
+		if bases == "" {
+			return nil, fmt.Errorf("motif %q has %q at position %d, which isn't an IUPAC nucleotide code", motif, upper[position], position+1)
+		}
+		current := element{min: 1, max: 1}
+		for code := range current.allowed {
+			covered := alphabet.Ambiguities(byte(code))
+			current.allowed[code] = covered != "" && strings.Trim(covered, bases) == ""
+		}
+		pattern.elements = append(pattern.elements, current)
+	}
+	pattern.palindromic = transform.ReverseComplement(upper) == upper
+	return pattern, nil
+}
+
+// CompilePROSITE compiles a PROSITE protein pattern, like
+// C-x(2,4)-C-x(3)-[LIVMFYWC]-x(8)-H-x(3,5)-H. Elements are separated by
+// dashes and may be an amino acid, x for any amino acid, [ABC] for any of
+// those listed, or {ABC} for any but those listed, each followed by how many
+// times it repeats, like x(3) or x(2,4). A pattern starting with < only
+// matches at the start of a sequence and one ending with > only matches at
+// the end, and [G>] matches either a G or the end of the sequence. A final
+// period is ignored.
+func CompilePROSITE(motif string) (*Pattern, error) {
+	pattern := &Pattern{source: motif, protein: true}
+	trimmed := strings.TrimSuffix(strings.TrimSpace(motif), ".")
+	if strings.HasPrefix(trimmed, "<") {
+		pattern.anchorStart = true
+		trimmed = trimmed[1:]
+	}
+	if strings.HasSuffix(trimmed, ">") {
+		pattern.anchorEnd = true
+		trimmed = trimmed[:len(trimmed)-1]
+	}
+	if trimmed == "" {
+		return nil, fmt.Errorf("empty PROSITE pattern %q", motif)
+	}
+	parts := strings.Split(trimmed, "-")
+	shortest := 0
+	for index, part := range parts {
+		current, err := parsePROSITEElement(part)
+		if err != nil {
+			return nil, fmt.Errorf("PROSITE pattern %q element %d: %w", motif, index+1, err)
+		}
+		if current.orEnd && index != len(parts)-1 {
+			return nil, fmt.Errorf("PROSITE pattern %q element %d: only the last element can match the end of the sequence", motif, index+1)
+		}
+		if !current.orEnd {
+			shortest += current.min
+		}
+		pattern.elements = append(pattern.elements, current)
+	}
+	if shortest == 0 {
+		return nil, fmt.Errorf("PROSITE pattern %q can match nothing at all", motif)
+	}
+	return pattern, nil
+}
+
+// parsePROSITEElement parses one dash separated element of a PROSITE
+// pattern.
+func parsePROSITEElement(part string) (element, error) {
+	current := element{min: 1, max: 1}
+	if open := strings.IndexByte(part, '('); open != -1 {
+		if !strings.HasSuffix(part, ")") {
+			return element{}, fmt.Errorf("unclosed repeat in %q", part)
+		}
+		counts := strings.Split(part[open+1:len(part)-1], ",")
+		if len(counts) > 2 {
+			return element{}, fmt.Errorf("bad repeat in %q", part)
+		}
+		var err error
+		if current.min, err = strconv.Atoi(strings.TrimSpace(counts[0])); err != nil {
+			return element{}, fmt.Errorf("bad repeat in %q", part)
+		}
+		current.max = current.min
+		if len(counts) == 2 {
+			if current.max, err = strconv.Atoi(strings.TrimSpace(counts[1])); err != nil {
+				return element{}, fmt.Errorf("bad repeat in %q", part)
+			}
+		}
+		if current.min < 0 || current.max < current.min || current.max == 0 {
+			return element{}, fmt.Errorf("bad repeat in %q", part)
+		}
+		part = part[:open]
+	}
+
+	residues, negated := "", false
+	switch {
+	case part == "x" || part == "X":
+		negated = true
+	case len(part) >= 2 && part[0] == '[' && part[len(part)-1] == ']':
+		residues = part[1 : len(part)-1]
+		if strings.HasSuffix(residues, ">") {
+			current.orEnd = true
+			residues = residues[:len(residues)-1]
+		}
+	case len(part) >= 2 && part[0] == '{' && part[len(part)-1] == '}':
+		residues, negated = part[1:len(part)-1], true
+	case len(part) == 1:
+		residues = part
+	default:
+		return element{}, fmt.Errorf("can't read %q", part)
+	}
+	for index := 0; index < len(residues); index++ {
+		if residues[index] < 'A' || residues[index] > 'Z' {
+			return element{}, fmt.Errorf("%q isn't an amino acid", residues[index])
+		}
+	}
+	if residues == "" && !negated && !current.orEnd {
+		return element{}, fmt.Errorf("no amino acids in %q", part)
+	}
+	for residue := 'A'; residue <= 'Z'; residue++ {
+		current.allowed[residue] = strings.ContainsRune(residues, residue) != negated
+	}
+	return current, nil
+}
+
+/******************************************************************************
+
+Matching begins here.
+
+Patterns are matched by trying every start position in turn and backtracking
+over how many residues each repeated element takes, longest first, so every
+start position a pattern matches at gives one match, the longest one with the
+fewest mismatches.
+
+******************************************************************************/
+
+// Find returns every match of a pattern in a sequence, sorted by where they
+// start, with forward strand matches before reverse ones at the same place.
+// Matches can overlap. A palindromic DNA pattern's matches are only reported
+// on the forward strand.
+func (pattern *Pattern) Find(sequence string, options Options) []Match {
+	sequence = strings.ToUpper(sequence)
+	length := len(sequence)
+	var matches []Match
+	strands := []bool{false}
+	if !pattern.protein && !pattern.palindromic && !options.Stranded {
+		strands = append(strands, true)
+	}
+	for _, reverse := range strands {
+		strand := sequence
+		if reverse {
+			strand = transform.ReverseComplement(sequence)
+		}
+		for _, found := range pattern.scan(strand, options) {
+			match := found
+			match.Reverse = reverse
+			if reverse {
+				// positions on the reverse strand are mirrored onto the forward.
+				match.Start, match.End = length-found.End, length-found.Start
+				if match.Start < 0 {
+					match.Start, match.End = match.Start+length, match.End+length
+				}
+			}
+			match.Pattern = pattern.source
+			match.Location = genbank.RangeLocation(match.Start, match.End, length, match.Reverse)
+			matches = append(matches, match)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Start != matches[j].Start {
+			return matches[i].Start < matches[j].Start
+		}
+		return !matches[i].Reverse && matches[j].Reverse
+	})
+	return matches
+}
+
+// MatchString returns whether a pattern matches the whole of sequence, as
+// written and without mismatches, like a recognition site against the bases
+// at one position of a sequence.
+func (pattern *Pattern) MatchString(sequence string) bool {
+	anchored := *pattern
+	anchored.anchorEnd = true
+	_, ok := anchored.match(strings.ToUpper(sequence), 0, len(sequence), 0, 0, false)
+	return ok
+}
+
+// Regexp returns a regular expression matching what a DNA pattern matches on
+// the strand it's written on, for APIs that take one, like clone.Enzyme. It
+// only matches uppercase DNA, so sequences should be uppercased first.
+func (pattern *Pattern) Regexp() (*regexp.Regexp, error) {
+	if pattern.protein {
+		return nil, fmt.Errorf("%s is a protein pattern, which has no regular expression", pattern.source)
+	}
+	var expression strings.Builder
+	for _, current := range pattern.elements {
+		var allowed []byte
+		for code, ok := range current.allowed {
+			if ok && code >= 'A' && code <= 'Z' && code != 'U' {
+				allowed = append(allowed, byte(code))
+			}
+		}
+		if len(allowed) == 1 {
+			expression.Write(allowed)
+		} else {
+			expression.WriteString("[" + string(allowed) + "]")
+		}
+	}
+	return regexp.Compile(expression.String())
+}
+
+// scan finds the matches of one strand, with positions on that strand.
+func (pattern *Pattern) scan(strand string, options Options) []Match {
+	length := len(strand)
+	extended := strand
+	if options.Circular {
+		// a match can be at most as long as the sequence, so one copy of it
+		// is enough to read every match across the origin.
+		extended = strand + strand
+	}
+	var matches []Match
+	for start := 0; start < length; start++ {
+		if pattern.anchorStart && (options.Circular || start > 0) {
+			break
+		}
+		limit := length
+		if options.Circular {
+			limit = start + length
+		}
+		for budget := 0; budget <= options.MaxMismatches; budget++ {
+			end, ok := pattern.match(extended, start, limit, 0, budget, options.Circular)
+			if ok {
+				matches = append(matches, Match{Start: start, End: end, Sequence: extended[start:end], Mismatches: budget})
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// match matches the elements of the pattern from element onwards to text
+// from position, using exactly budget mismatches, and returns where the
+// match ends. limit is the furthest a match can go, and text only ends there
+// if it isn't circular.
+func (pattern *Pattern) match(text string, position, limit, element, budget int, circular bool) (int, bool) {
+	if element == len(pattern.elements) {
+		if budget > 0 || (pattern.anchorEnd && (circular || position != limit)) {
+			return 0, false
+		}
+		return position, true
+	}
+	current := pattern.elements[element]
+	// count how far the element can reach, and the mismatches along the way.
+	mismatches := []int{0}
+	for count := 1; count <= current.max && position+count <= limit; count++ {
+		previous := mismatches[count-1]
+		if !current.allowed[text[position+count-1]] {
+			previous++
+		}
+		if previous > budget {
+			break
+		}
+		mismatches = append(mismatches, previous)
+	}
+	for count := len(mismatches) - 1; count >= current.min; count-- {
+		end, ok := pattern.match(text, position+count, limit, element+1, budget-mismatches[count], circular)
+		if ok {
+			return end, true
+		}
+	}
+	if current.orEnd && !circular && position == limit {
+		return pattern.match(text, position, limit, element+1, budget, circular)
+	}
+	return 0, false
+}
+
+/******************************************************************************
+
+Features begin here.
+
+******************************************************************************/
+
+// Feature returns a match as a misc_feature labeled with its pattern.
+func (match Match) Feature() genbank.Feature {
+	note := fmt.Sprintf("matches %s", match.Pattern)
+	if match.Mismatches > 0 {
+		note += fmt.Sprintf(" with %d mismatches", match.Mismatches)
+	}
+	return genbank.Feature{
+		Type: "misc_feature",
+		Attributes: map[string]string{
+			"label": match.Pattern,
+			"note":  note,
+		},
+		Location: match.Location,
+	}
+}
+
+// Features returns matches as misc_features.
+func Features(matches []Match) []genbank.Feature {
+	features := make([]genbank.Feature, len(matches))
+	for index, match := range matches {
+		features[index] = match.Feature()
+	}
+	return features
+}
@@ -0,0 +1,165 @@
+package motif
+
+import (
+	"testing"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindIUPAC(t *testing.T) {
+	bsaI, err := CompileIUPAC("GGTCTCN")
+	require.NoError(t, err)
+
+	// one site on each strand: GGTCTC at 3 and GAGACC at 16.
+	sequence := "AAAGGTCTCAAAAAAAGAGACCAAA"
+	matches := bsaI.Find(sequence, Options{})
+	require.Len(t, matches, 2)
+	assert.Equal(t, Match{Start: 3, End: 10, Sequence: "GGTCTCA", Pattern: "GGTCTCN", Location: matches[0].Location}, matches[0])
+	assert.Equal(t, "4..10", genbank.BuildLocationString(matches[0].Location))
+	assert.Equal(t, 15, matches[1].Start)
+	assert.Equal(t, 22, matches[1].End)
+	assert.True(t, matches[1].Reverse)
+	assert.Equal(t, "GGTCTCT", matches[1].Sequence)
+	assert.Equal(t, "complement(16..22)", genbank.BuildLocationString(matches[1].Location))
+	assert.True(t, matches[1].Location.Complement)
+
+	stranded := bsaI.Find(sequence, Options{Stranded: true})
+	assert.Len(t, stranded, 1)
+
+	// ambiguous bases only match positions that allow all of them.
+	assert.Len(t, bsaI.Find("GGTCTCN", Options{Stranded: true}), 1)
+	assert.Empty(t, bsaI.Find("GGTCNCA", Options{Stranded: true}))
+	degenerate, err := CompileIUPAC("ryn")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AYC", "GTN"}, sequences(degenerate.Find("AYCTGTN", Options{Stranded: true})))
+}
+
+func TestFindPalindromic(t *testing.T) {
+	ecoRI, err := CompileIUPAC("GAATTC")
+	require.NoError(t, err)
+	matches := ecoRI.Find("AAGAATTCAA", Options{})
+	require.Len(t, matches, 1)
+	assert.False(t, matches[0].Reverse)
+}
+
+func TestFindCircular(t *testing.T) {
+	bsaI, err := CompileIUPAC("GGTCTC")
+	require.NoError(t, err)
+	plasmid := "TCTCAAAAAAAAAAAAGG"
+	assert.Empty(t, bsaI.Find(plasmid, Options{}))
+
+	matches := bsaI.Find(plasmid, Options{Circular: true})
+	require.Len(t, matches, 1)
+	assert.Equal(t, 16, matches[0].Start)
+	assert.Equal(t, 22, matches[0].End)
+	assert.Equal(t, "GGTCTC", matches[0].Sequence)
+	assert.Equal(t, "join(17..18,1..4)", genbank.BuildLocationString(matches[0].Location))
+
+	// the reverse strand crosses the origin too.
+	flipped := "AGACCAAAAAAAAAAAAG"
+	matches = bsaI.Find(flipped, Options{Circular: true})
+	require.Len(t, matches, 1)
+	assert.True(t, matches[0].Reverse)
+	assert.Equal(t, 17, matches[0].Start)
+	assert.Equal(t, 23, matches[0].End)
+	assert.Equal(t, "complement(join(18,1..5))", genbank.BuildLocationString(matches[0].Location))
+}
+
+func TestFindMismatches(t *testing.T) {
+	pattern, err := CompileIUPAC("GATTACA")
+	require.NoError(t, err)
+	sequence := "TTGATTACATTGATCACATTGCTCACATT"
+	assert.Len(t, pattern.Find(sequence, Options{Stranded: true}), 1)
+	matches := pattern.Find(sequence, Options{Stranded: true, MaxMismatches: 1})
+	require.Len(t, matches, 2)
+	assert.Equal(t, 0, matches[0].Mismatches)
+	assert.Equal(t, "GATCACA", matches[1].Sequence)
+	assert.Equal(t, 1, matches[1].Mismatches)
+	assert.Len(t, pattern.Find(sequence, Options{Stranded: true, MaxMismatches: 2}), 3)
+}
+
+func TestFindPROSITE(t *testing.T) {
+	zincFinger, err := CompilePROSITE("C-x(2,4)-C-x(3)-[LIVMFYWC]-x(8)-H-x(3,5)-H.")
+	require.NoError(t, err)
+	protein := "MSPKKRYPCPECGKSFSQSSNLQKHQRTHTGEKP"
+	matches := zincFinger.Find(protein, Options{})
+	require.Len(t, matches, 1)
+	assert.Equal(t, 8, matches[0].Start)
+	assert.Equal(t, "CPECGKSFSQSSNLQKHQRTH", matches[0].Sequence)
+	assert.False(t, matches[0].Reverse)
+
+	anchored, err := CompilePROSITE("<M-x-[ST]")
+	require.NoError(t, err)
+	assert.Len(t, anchored.Find("MKSMKS", Options{}), 1)
+	assert.Empty(t, anchored.Find("AMKS", Options{}))
+
+	ending, err := CompilePROSITE("K-{P}-[G>]")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"KAG", "KA"}, sequences(ending.Find("KAGPPKPGKA", Options{})))
+
+	terminal, err := CompilePROSITE("K-D-E-L>")
+	require.NoError(t, err)
+	assert.Len(t, terminal.Find("MKDELAAKDEL", Options{}), 1)
+	assert.Equal(t, 7, terminal.Find("MKDELAAKDEL", Options{})[0].Start)
+}
+
+func TestMatchString(t *testing.T) {
+	pam, err := CompileIUPAC("NGG")
+	require.NoError(t, err)
+	assert.True(t, pam.MatchString("TGG"))
+	assert.True(t, pam.MatchString("agg"))
+	assert.False(t, pam.MatchString("TGA"))
+	assert.False(t, pam.MatchString("TGGA"))
+	assert.False(t, pam.MatchString("GG"))
+	// like Find, an N only fits a position that allows every base.
+	assert.True(t, pam.MatchString("NGG"))
+	assert.False(t, pam.MatchString("TNG"))
+}
+
+func TestRegexp(t *testing.T) {
+	sacI, err := CompileIUPAC("GRGCYC")
+	require.NoError(t, err)
+	expression, err := sacI.Regexp()
+	require.NoError(t, err)
+	assert.Equal(t, "G[AGR]GC[CTY]C", expression.String())
+	assert.True(t, expression.MatchString("GAGCTC"))
+	assert.False(t, expression.MatchString("GCGCTC"))
+
+	zincFinger, err := CompilePROSITE("C-x(2,4)-C")
+	require.NoError(t, err)
+	_, err = zincFinger.Regexp()
+	assert.Error(t, err)
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, motif := range []string{"", "GGTCTCJ", "GG-TC"} {
+		_, err := CompileIUPAC(motif)
+		assert.Error(t, err, motif)
+	}
+	for _, motif := range []string{"", ".", "C-x(2", "C-x(3,2)", "C-x(a)", "C-[a]", "[G>]-C", "x(0,2)", "C-Z1"} {
+		_, err := CompilePROSITE(motif)
+		assert.Error(t, err, motif)
+	}
+}
+
+func TestFeatures(t *testing.T) {
+	pattern, err := CompileIUPAC("GATTACA")
+	require.NoError(t, err)
+	features := Features(pattern.Find("TTGATCACATT", Options{MaxMismatches: 1}))
+	require.Len(t, features, 1)
+	assert.Equal(t, "misc_feature", features[0].Type)
+	assert.Equal(t, "GATTACA", features[0].Attributes["label"])
+	assert.Equal(t, "matches GATTACA with 1 mismatches", features[0].Attributes["note"])
+	assert.Equal(t, 2, features[0].Location.Start)
+	assert.Equal(t, 9, features[0].Location.End)
+}
+
+func sequences(matches []Match) []string {
+	var found []string
+	for _, match := range matches {
+		found = append(found, match.Sequence)
+	}
+	return found
+}
@@ -38,3 +38,46 @@ func ExampleRotateSequence() {
 	fmt.Println(seqhash.RotateSequence(sequence.Sequence) == seqhash.RotateSequence(testSequence))
 	// output: true
 }
+
+func ExampleIsRotation() {
+	// the same plasmid, opened at different places.
+	fmt.Println(seqhash.LeastRotation("GGCTAAATG"))
+	fmt.Println(seqhash.IsRotation("ATGGGCTAA", "GGCTAAATG"))
+	// Output:
+	// 4
+	// true
+}
+
+func ExampleHashWithOptions() {
+	sequenceSeqhash, _ := seqhash.HashWithOptions("ATGC", seqhash.Options{Type: seqhash.DNA, Strandedness: seqhash.DoubleStranded})
+	fmt.Println(sequenceSeqhash)
+	// Output: v1_DLD_f4028f93e08c5c23cbb8daa189b0a9802b378f1a1c919dcbcf1608a615f46350
+}
+
+func ExampleHashV2() {
+	sequenceSeqhash, _ := seqhash.HashV2("ATGC", seqhash.Options{Type: seqhash.DNA, Strandedness: seqhash.DoubleStranded})
+	fmt.Println(sequenceSeqhash)
+
+	migrated, _ := seqhash.MigrateV1("v1_DLD_f4028f93e08c5c23cbb8daa189b0a9802b378f1a1c919dcbcf1608a615f46350")
+	fmt.Println(migrated == sequenceSeqhash)
+
+	metadata, _ := seqhash.ParseV2(sequenceSeqhash)
+	fmt.Println(metadata.Type, metadata.Circular, metadata.DoubleStranded)
+	// Output:
+	// baieb4ihuakhzhyemlqr4xog2uge3bkmafm3y6gq4sgo4xtywbctbl5ddka
+	// true
+	// DNA false true
+}
+
+func ExampleHashFragment() {
+	// a part with AATG and AAGC overhangs, read from each strand.
+	top, _ := seqhash.HashFragment("AATGGCTAGCTAAGCTT", 4, 4)
+	bottom, _ := seqhash.HashFragment("AAGCTTAGCTAGCCATT", 4, 4)
+	fmt.Println(top == bottom)
+
+	metadata, _ := seqhash.ParseV2(top)
+	fmt.Println(metadata.Type)
+	// Output:
+	// true
+	// FRAGMENT
+}
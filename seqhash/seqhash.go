@@ -50,6 +50,10 @@ not the sequence is circular (C for Circular, L for Linear). The final letter co
 sequence is double stranded (D for Double stranded, S for Single stranded). The final element is the blake3
 hash of the sequence (once rotated and complemented, as stated above).
 
+Version 2 Seqhashes, from HashV2, hash sequences the same way but encode the
+version, metadata, and hash as self-describing multiformats bytes instead.
+MigrateV1 converts version 1 Seqhashes to version 2 without the sequence.
+
 Seqhash is a simple algorithm that allows for much better indexing of genetic sequences than what is
 currently available.
 */
@@ -61,6 +65,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/bebop/poly/alphabet"
 	"github.com/bebop/poly/transform"
 	"lukechampine.com/blake3"
 )
@@ -72,79 +77,160 @@ const (
 	DNA     SequenceType = "DNA"
 	RNA     SequenceType = "RNA"
 	PROTEIN SequenceType = "PROTEIN"
+	// FRAGMENT is a double stranded DNA fragment with overhangs, which only
+	// version 2 Seqhashes hash, with HashFragment.
+	FRAGMENT SequenceType = "FRAGMENT"
 )
 
-// boothLeastRotation gets the least rotation of a circular string.
-func boothLeastRotation(sequence string) int {
+// proteinLettersV1 are the amino acids version 1 Seqhashes allow.
+const proteinLettersV1 = "ACDEFGHIKLMNPQRSTVWYUO*BXZ"
+
+// LeastRotation returns where the lexicographically least rotation of a
+// circular sequence starts, in linear time and constant memory. Two circular
+// sequences are the same if their least rotations are, no matter where each
+// was opened.
+func LeastRotation(sequence string) int {
 	// https://en.wikipedia.org/wiki/Lexicographically_minimal_string_rotation
-	// this is generally over commented but I'm keeping it this way for now. - Tim
+	// Two candidate rotations are compared character by character. When they
+	// differ after matching for offset characters, none of the rotations
+	// starting within those characters of the larger candidate can be least
+	// either, so it skips past them. The sequence is indexed modulo its length
+	// rather than concatenated to itself, so large plasmids aren't copied, and
+	// nothing else is allocated.
+	length := len(sequence)
+	candidate, other, offset := 0, 1, 0
+	for candidate < length && other < length && offset < length {
+		character := sequence[(candidate+offset)%length]
+		otherCharacter := sequence[(other+offset)%length]
+		if character == otherCharacter {
+			offset++
+			continue
+		}
+		if character > otherCharacter {
+			candidate += offset + 1
+		} else {
+			other += offset + 1
+		}
+		if candidate == other {
+			other++
+		}
+		offset = 0
+	}
+	return min(candidate, other) % max(length, 1)
+}
 
-	// first concatenate the sequence to itself to avoid modular arithmetic
-	sequence += sequence // maybe do this as a buffer just for speed? May get annoying with larger sequences.
-	leastRotationIndex := 0
+// RotateSequence rotates circular sequences to deterministic point.
+func RotateSequence(sequence string) string {
+	rotationIndex := LeastRotation(sequence)
+	return sequence[rotationIndex:] + sequence[:rotationIndex]
+}
 
-	//initializing failure slice.
-	failureSlice := make([]int, len(sequence))
-	for i := range failureSlice {
-		failureSlice[i] = -1
+// IsRotation returns whether two circular sequences are the same sequence,
+// opened at different places. It doesn't consider reverse complements.
+func IsRotation(sequence, other string) bool {
+	length := len(sequence)
+	if len(other) != length {
+		return false
 	}
-	// iterate through each character in the doubled over sequence
-	for characterIndex := 1; characterIndex < len(sequence); characterIndex++ {
-		// get character
-		character := sequence[characterIndex]
-		// get failure
-		failure := failureSlice[characterIndex-leastRotationIndex-1]
-		// while failure does not equal -1 and character does not equal the character found at the least rotation + failure + 1 <- why this?
-		for failure != -1 && character != sequence[leastRotationIndex+failure+1] {
-			// if character is lexically less than whatever is at the leastRotationIndex index update leastRotation index
-			if character < sequence[leastRotationIndex+failure+1] {
-				leastRotationIndex = characterIndex - failure - 1
-			}
-			// update failure using previous failure as index?
-			failure = failureSlice[failure]
+	start, otherStart := LeastRotation(sequence), LeastRotation(other)
+	for index := 0; index < length; index++ {
+		if sequence[(start+index)%length] != other[(otherStart+index)%length] {
+			return false
 		}
+	}
+	return true
+}
 
-		// if character does not equal whatever character is at leastRotationIndex plus failure.
-		if character != sequence[leastRotationIndex+failure+1] {
-			// if character is lexically less then what is rotated least leastRotationIndex gets value of character index.
-			if character < sequence[leastRotationIndex] {
-				leastRotationIndex = characterIndex
-			}
-			// assign -1 to whatever is at the index of difference between character and rotation indices.
-			failureSlice[characterIndex-leastRotationIndex] = -1
+// Strandedness is whether a sequence being hashed is single or double
+// stranded.
+type Strandedness int
 
-			// if character does equal whatever character is at leastRotationIndex plus failure.
-		} else {
-			// assign failure + 1 at the index of difference between character and rotation indices.
-			failureSlice[characterIndex-leastRotationIndex] = failure + 1
-		}
-	} // end loop
+const (
+	// DefaultStrandedness is double stranded for DNA, and single stranded
+	// for everything else.
+	DefaultStrandedness Strandedness = iota
+	SingleStranded
+	DoubleStranded
+)
 
-	return leastRotationIndex
+// Options describe the sequence being hashed, for HashWithOptions and HashV2.
+// The zero value describes linear, double stranded DNA.
+type Options struct {
+	Type         SequenceType // defaults to DNA.
+	Circular     bool
+	Strandedness Strandedness
 }
 
-// RotateSequence rotates circular sequences to deterministic point.
-func RotateSequence(sequence string) string {
-	rotationIndex := boothLeastRotation(sequence)
-	var sequenceBuilder strings.Builder
-
-	// writing the same sequence twice. using build incase of very long circular genome.
-	sequenceBuilder.WriteString(sequence)
-	sequenceBuilder.WriteString(sequence)
+// withDefaults fills in the type and strandedness options leave unset.
+func (options Options) withDefaults() Options {
+	if options.Type == "" {
+		options.Type = DNA
+	}
+	if options.Strandedness == DefaultStrandedness {
+		options.Strandedness = SingleStranded
+		if options.Type == DNA {
+			options.Strandedness = DoubleStranded
+		}
+	}
+	return options
+}
 
-	concatenatedSequence := sequenceBuilder.String()
-	sequence = concatenatedSequence[rotationIndex : rotationIndex+len(sequence)]
-	return sequence
+// HashWithOptions creates a Seqhash like Hash, with the sequence described by
+// options rather than by positional arguments.
+func HashWithOptions(sequence string, options Options) (string, error) {
+	options = options.withDefaults()
+	return Hash(sequence, options.Type, options.Circular, options.Strandedness == DoubleStranded)
 }
 
 // Hash is a function to create Seqhashes, a specific kind of identifier.
 func Hash(sequence string, sequenceType SequenceType, circular bool, doubleStranded bool) (string, error) {
+	deterministic, err := deterministicSequence(sequence, sequenceType, circular, doubleStranded, proteinLettersV1)
+	if err != nil {
+		return "", err
+	}
+
+	// Build 3 letter metadata
+	var sequenceTypeLetter string
+	var circularLetter string
+	var doubleStrandedLetter string
+	// Get first letter. D for DNA, R for RNA, and P for Protein
+	switch sequenceType {
+	case DNA:
+		sequenceTypeLetter = "D"
+	case RNA:
+		sequenceTypeLetter = "R"
+	case PROTEIN:
+		sequenceTypeLetter = "P"
+	}
+	// Get 2nd letter. C for circular, L for Linear
+	if circular {
+		circularLetter = "C"
+	} else {
+		circularLetter = "L"
+	}
+	// Get 3rd letter. D for Double stranded, S for Single stranded
+	if doubleStranded {
+		doubleStrandedLetter = "D"
+	} else {
+		doubleStrandedLetter = "S"
+	}
+
+	newhash := blake3.Sum256([]byte(deterministic))
+	seqhash := "v1" + "_" + sequenceTypeLetter + circularLetter + doubleStrandedLetter + "_" + hex.EncodeToString(newhash[:])
+	return seqhash, nil
+}
+
+// deterministicSequence checks a sequence, and rotates and complements it to
+// the one sequence all of its equivalent sequences hash as.
+// Proteins may only have proteinLetters in them, which differ between Seqhash
+// versions.
+func deterministicSequence(sequence string, sequenceType SequenceType, circular bool, doubleStranded bool, proteinLetters string) (string, error) {
 	// By definition, Seqhashes are of uppercase sequences
 	sequence = strings.ToUpper(sequence)
 	// If RNA, convert to a DNA sequence. The hash itself between a DNA and RNA sequence will not
 	// be different, but their Seqhash will have a different metadata string (R vs D)
 	if sequenceType == SequenceType("RNA") {
-		sequence = strings.ReplaceAll(sequence, "U", "T")
+		sequence = alphabet.RNAToDNA(sequence)
 	}
 
 	// Run checks on the input
@@ -165,8 +251,8 @@ func Hash(sequence string, sequenceType SequenceType, circular bool, doubleStran
 			// The release notes https://web.expasy.org/docs/relnotes/relstat.html
 			// also state there are Asx (B), Glx (Z), and Xaa (X) amino acids, so
 			// these are added in as well.
-			if !strings.Contains("ACDEFGHIKLMNPQRSTVWYUO*BXZ", string(char)) {
-				return "", errors.New("Only letters ACDEFGHIKLMNPQRSTVWYUO*BXZ are allowed for Proteins. Got letter: " + string(char))
+			if !strings.Contains(proteinLetters, string(char)) {
+				return "", errors.New("Only letters " + proteinLetters + " are allowed for Proteins. Got letter: " + string(char))
 			}
 		}
 	}
@@ -176,49 +262,20 @@ func Hash(sequence string, sequenceType SequenceType, circular bool, doubleStran
 	}
 
 	// Gets Deterministic sequence based off of metadata + sequence
-	var deterministicSequence string
+	var deterministic string
 	switch {
 	case circular && doubleStranded:
 		potentialSequences := []string{RotateSequence(sequence), RotateSequence(transform.ReverseComplement(sequence))}
 		sort.Strings(potentialSequences)
-		deterministicSequence = potentialSequences[0]
+		deterministic = potentialSequences[0]
 	case circular && !doubleStranded:
-		deterministicSequence = RotateSequence(sequence)
+		deterministic = RotateSequence(sequence)
 	case !circular && doubleStranded:
 		potentialSequences := []string{sequence, transform.ReverseComplement(sequence)}
 		sort.Strings(potentialSequences)
-		deterministicSequence = potentialSequences[0]
+		deterministic = potentialSequences[0]
 	case !circular && !doubleStranded:
-		deterministicSequence = sequence
+		deterministic = sequence
 	}
-
-	// Build 3 letter metadata
-	var sequenceTypeLetter string
-	var circularLetter string
-	var doubleStrandedLetter string
-	// Get first letter. D for DNA, R for RNA, and P for Protein
-	switch sequenceType {
-	case DNA:
-		sequenceTypeLetter = "D"
-	case RNA:
-		sequenceTypeLetter = "R"
-	case PROTEIN:
-		sequenceTypeLetter = "P"
-	}
-	// Get 2nd letter. C for circular, L for Linear
-	if circular {
-		circularLetter = "C"
-	} else {
-		circularLetter = "L"
-	}
-	// Get 3rd letter. D for Double stranded, S for Single stranded
-	if doubleStranded {
-		doubleStrandedLetter = "D"
-	} else {
-		doubleStrandedLetter = "S"
-	}
-
-	newhash := blake3.Sum256([]byte(deterministicSequence))
-	seqhash := "v1" + "_" + sequenceTypeLetter + circularLetter + doubleStrandedLetter + "_" + hex.EncodeToString(newhash[:])
-	return seqhash, nil
+	return deterministic, nil
 }
@@ -3,6 +3,8 @@ package seqhash
 import (
 	"bytes"
 	"fmt"
+	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/bebop/poly/io/genbank"
@@ -89,3 +91,83 @@ func TestLeastRotation(t *testing.T) {
 		}
 	}
 }
+
+func TestLeastRotationBruteForce(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 500; trial++ {
+		sequence := make([]byte, random.Intn(30))
+		for index := range sequence {
+			// two letters make lots of ties between rotations.
+			sequence[index] = "AB"[random.Intn(2)]
+		}
+		want, wantIndex := string(sequence), 0
+		for start := range sequence {
+			if rotation := string(sequence[start:]) + string(sequence[:start]); rotation < want {
+				want, wantIndex = rotation, start
+			}
+		}
+		if got := RotateSequence(string(sequence)); got != want {
+			t.Errorf("RotateSequence(%q) = %q, want %q", sequence, got, want)
+		}
+		// ties go to the first rotation.
+		if got := LeastRotation(string(sequence)); got != wantIndex {
+			t.Errorf("LeastRotation(%q) = %d, want %d", sequence, got, wantIndex)
+		}
+	}
+}
+
+func TestLeastRotationLarge(t *testing.T) {
+	// a megabase of repeats, the worst case for naive rotation.
+	sequence := strings.Repeat("ATGC", 250000) + "A"
+	if got := LeastRotation(sequence); got != len(sequence)-1 {
+		t.Errorf("LeastRotation() = %d, want %d", got, len(sequence)-1)
+	}
+	if !IsRotation(sequence, sequence[1000:]+sequence[:1000]) {
+		t.Errorf("IsRotation() should be true for a rotated sequence")
+	}
+	if IsRotation("ATGC", "ACGT") || IsRotation("ATGC", "ATGCA") {
+		t.Errorf("IsRotation() should be false for different sequences")
+	}
+	if allocations := testing.AllocsPerRun(10, func() { LeastRotation(sequence) }); allocations != 0 {
+		t.Errorf("LeastRotation() allocated %v times, want none", allocations)
+	}
+}
+
+func BenchmarkLeastRotation(b *testing.B) {
+	sequence := strings.Repeat("ATGC", 250000)
+	for i := 0; i < b.N; i++ {
+		LeastRotation(sequence)
+	}
+}
+
+func TestHashWithOptions(t *testing.T) {
+	want, _ := Hash("ATGGGCTAA", DNA, true, true)
+	got, err := HashWithOptions("ATGGGCTAA", Options{Circular: true, Strandedness: DoubleStranded})
+	if err != nil || got != want {
+		t.Errorf("HashWithOptions() = %q, %v, want %q", got, err, want)
+	}
+	want, _ = Hash("MGCS*", PROTEIN, false, false)
+	got, err = HashWithOptions("MGCS*", Options{Type: PROTEIN})
+	if err != nil || got != want {
+		t.Errorf("HashWithOptions() = %q, %v, want %q", got, err, want)
+	}
+	if _, err := HashWithOptions("MGCS*", Options{Type: PROTEIN, Strandedness: DoubleStranded}); err == nil {
+		t.Errorf("HashWithOptions() should fail for double stranded proteins")
+	}
+
+	// DNA is double stranded unless said otherwise, and RNA is single stranded.
+	for _, test := range []struct {
+		options Options
+		want    string
+	}{
+		{Options{}, "v1_DLD_"},
+		{Options{Strandedness: SingleStranded}, "v1_DLS_"},
+		{Options{Type: RNA}, "v1_RLS_"},
+		{Options{Type: RNA, Strandedness: DoubleStranded}, "v1_RLD_"},
+	} {
+		got, err := HashWithOptions("ATGGGCTAA", test.options)
+		if err != nil || !strings.HasPrefix(got, test.want) {
+			t.Errorf("HashWithOptions(%+v) = %q, %v, want a %q prefix", test.options, got, err, test.want)
+		}
+	}
+}
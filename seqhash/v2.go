@@ -0,0 +1,257 @@
+package seqhash
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bebop/poly/sequence"
+	"github.com/bebop/poly/transform"
+	"lukechampine.com/blake3"
+)
+
+/******************************************************************************
+
+Seqhash version 2 begins here.
+
+Version 1 Seqhashes are easy to read, but only to people who already know
+what they look like. Nothing in "v1_DCD_4b06..." says which hash function
+made it or how it's encoded, so a program that finds one in the wild has to
+be told it's a Seqhash.
+
+Version 2 Seqhashes describe themselves, the way multiformats
+(https://multiformats.io) identifiers do. A V2 Seqhash is these bytes:
+
+	0x02                 version
+	metadata             sequence type, circularity, and strandedness
+	0x1e 0x20            multihash header: blake3, 32 bytes long
+	digest               the blake3 hash of the deterministic sequence
+
+encoded with multibase's lowercase, unpadded base32, which starts with "b".
+
+The metadata byte keeps the sequence type in its lowest two bits (0 for DNA,
+1 for RNA, 2 for protein, 3 for fragment), whether the sequence is circular
+in the next bit, and whether it's double stranded in the one after that.
+
+Since the version and metadata come first, every Seqhash with the same
+metadata starts with the same five characters, which makes them easy to tell
+apart at a glance. The first three, "bai", are the multibase prefix and the
+version, so every V2 Seqhash starts with them. The fourth holds whether the
+sequence is double stranded, whether it's circular, and the high bit of its
+type, and the fifth holds the low bit of its type along with the start of
+the multihash header:
+
+	baiab    linear single stranded DNA
+	baieb    linear double stranded DNA
+	baicb    circular single stranded DNA
+	baigb    circular double stranded DNA
+	baiar    linear single stranded RNA (and baier, baicr, baigr)
+	baibb    linear protein
+	baidb    circular protein
+	baifr    fragment
+
+The digest is the same blake3 hash of the same deterministic sequence as a V1
+Seqhash, so MigrateV1 can turn any V1 Seqhash into its V2 Seqhash without the
+sequence. V2 proteins may also have Xle (J) in them, which V1 proteins may not.
+
+Fragment Seqhashes identify parts rather than whole constructs. A part cut out
+of a plasmid for Golden Gate or BioBrick assembly is a linear, double stranded
+fragment with single stranded overhangs on its ends, and two parts with the
+same bases but different overhangs assemble differently, so they're different
+parts. HashFragment hashes the overhang lengths along with the sequence,
+whichever strand it's read from, so a registry can hash every part it has and
+find the duplicates the same way it does for whole constructs.
+
+******************************************************************************/
+
+// V2 identifies version 2 Seqhashes, as their first byte.
+const V2 = 0x02
+
+const (
+	// multihashBlake3 is the multicodec code of blake3, and blake3Length is
+	// how many bytes of it Seqhashes keep.
+	multihashBlake3 = 0x1e
+	blake3Length    = 32
+	// multibaseBase32 prefixes lowercase, unpadded base32.
+	multibaseBase32 = "b"
+	// proteinLettersV2 adds Xle (J), which mass spectrometry can't tell apart
+	// from leucine or isoleucine, to the amino acids V1 Seqhashes allow.
+	proteinLettersV2 = proteinLettersV1 + "J"
+	// metadata bits.
+	circularBit       = 1 << 2
+	doubleStrandedBit = 1 << 3
+)
+
+var (
+	base32Encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+	sequenceTypes  = []SequenceType{DNA, RNA, PROTEIN, FRAGMENT}
+	errNotV2       = errors.New("not a version 2 Seqhash")
+)
+
+// Metadata is what a Seqhash says about the sequence it hashes.
+type Metadata struct {
+	Version        int
+	Type           SequenceType
+	Circular       bool
+	DoubleStranded bool
+	Digest         []byte // blake3 hash of the deterministic sequence.
+}
+
+// HashV2 creates a version 2 Seqhash of a sequence.
+func HashV2(sequence string, options Options) (string, error) {
+	options = options.withDefaults()
+	doubleStranded := options.Strandedness == DoubleStranded
+	deterministic, err := deterministicSequence(sequence, options.Type, options.Circular, doubleStranded, proteinLettersV2)
+	if err != nil {
+		return "", err
+	}
+	digest := blake3.Sum256([]byte(deterministic))
+	return encodeV2(Metadata{Version: V2, Type: options.Type, Circular: options.Circular, DoubleStranded: doubleStranded, Digest: digest[:]})
+}
+
+// HashSequence creates a version 2 Seqhash of a sequence, with its molecule,
+// topology, and strandedness.
+func HashSequence(input sequence.Sequence) (string, error) {
+	strandedness := SingleStranded
+	if input.DoubleStranded {
+		strandedness = DoubleStranded
+	}
+	return HashV2(input.Sequence, Options{Type: SequenceType(input.Molecule), Circular: input.Circular, Strandedness: strandedness})
+}
+
+// encodeV2 encodes metadata as a version 2 Seqhash.
+func encodeV2(metadata Metadata) (string, error) {
+	metadataByte := byte(0)
+	found := false
+	for code, sequenceType := range sequenceTypes {
+		if sequenceType == metadata.Type {
+			metadataByte, found = byte(code), true
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("unknown sequence type %q", metadata.Type)
+	}
+	if metadata.Circular {
+		metadataByte |= circularBit
+	}
+	if metadata.DoubleStranded {
+		metadataByte |= doubleStrandedBit
+	}
+	if len(metadata.Digest) != blake3Length {
+		return "", fmt.Errorf("digest is %d bytes, not %d", len(metadata.Digest), blake3Length)
+	}
+	encoded := append([]byte{V2, metadataByte, multihashBlake3, blake3Length}, metadata.Digest...)
+	return multibaseBase32 + strings.ToLower(base32Encoding.EncodeToString(encoded)), nil
+}
+
+// ParseV2 reads the metadata of a version 2 Seqhash.
+func ParseV2(seqhash string) (Metadata, error) {
+	if !strings.HasPrefix(seqhash, multibaseBase32) {
+		return Metadata{}, fmt.Errorf("%w: %q isn't multibase base32", errNotV2, seqhash)
+	}
+	decoded, err := base32Encoding.DecodeString(strings.ToUpper(seqhash[len(multibaseBase32):]))
+	if err != nil {
+		return Metadata{}, fmt.Errorf("%w: %v", errNotV2, err)
+	}
+	if len(decoded) != 4+blake3Length || decoded[0] != V2 {
+		return Metadata{}, errNotV2
+	}
+	if decoded[2] != multihashBlake3 || decoded[3] != blake3Length {
+		return Metadata{}, fmt.Errorf("%w: hash isn't a 32 byte blake3 multihash", errNotV2)
+	}
+	metadataByte := decoded[1]
+	if int(metadataByte&0b11) >= len(sequenceTypes) || metadataByte>>4 != 0 {
+		return Metadata{}, fmt.Errorf("%w: unknown metadata %08b", errNotV2, metadataByte)
+	}
+	metadata := Metadata{
+		Version:        V2,
+		Type:           sequenceTypes[metadataByte&0b11],
+		Circular:       metadataByte&circularBit != 0,
+		DoubleStranded: metadataByte&doubleStrandedBit != 0,
+		Digest:         decoded[4:],
+	}
+	if metadata.Type == PROTEIN && metadata.DoubleStranded {
+		return Metadata{}, fmt.Errorf("%w: proteins can't be double stranded", errNotV2)
+	}
+	if metadata.Type == FRAGMENT && (metadata.Circular || !metadata.DoubleStranded) {
+		return Metadata{}, fmt.Errorf("%w: fragments are linear and double stranded", errNotV2)
+	}
+	return metadata, nil
+}
+
+// HashFragment creates a version 2 Seqhash of a double stranded DNA fragment
+// with 5' overhangs. The sequence is the fragment's top strand with both
+// overhangs filled in: its first forwardOverhang bases are the top strand's
+// overhang, and its last reverseOverhang bases pair with the bottom strand's.
+// Either strand of a fragment hashes the same, with its overhangs swapped.
+func HashFragment(sequence string, forwardOverhang, reverseOverhang int) (string, error) {
+	if forwardOverhang < 0 || reverseOverhang < 0 || forwardOverhang > 255 || reverseOverhang > 255 {
+		return "", fmt.Errorf("overhangs have to be 0 to 255 bases long, got %d and %d", forwardOverhang, reverseOverhang)
+	}
+	if forwardOverhang+reverseOverhang > len(sequence) {
+		return "", fmt.Errorf("%d and %d base overhangs don't fit on a %d base fragment", forwardOverhang, reverseOverhang, len(sequence))
+	}
+	forward, err := deterministicSequence(sequence, DNA, false, false, proteinLettersV2)
+	if err != nil {
+		return "", err
+	}
+	// reading the bottom strand swaps which overhang is at the start.
+	deterministic := append([]byte{byte(forwardOverhang), byte(reverseOverhang)}, forward...)
+	if reverse := transform.ReverseComplement(forward); reverse < forward {
+		deterministic = append([]byte{byte(reverseOverhang), byte(forwardOverhang)}, reverse...)
+	}
+	digest := blake3.Sum256(deterministic)
+	return encodeV2(Metadata{Version: V2, Type: FRAGMENT, DoubleStranded: true, Digest: digest[:]})
+}
+
+// ParseV1 reads the metadata of a version 1 Seqhash.
+func ParseV1(seqhash string) (Metadata, error) {
+	parts := strings.Split(seqhash, "_")
+	if len(parts) != 3 || parts[0] != "v1" || len(parts[1]) != 3 {
+		return Metadata{}, fmt.Errorf("%q isn't a version 1 Seqhash", seqhash)
+	}
+	metadata := Metadata{Version: 1}
+	switch parts[1][0] {
+	case 'D':
+		metadata.Type = DNA
+	case 'R':
+		metadata.Type = RNA
+	case 'P':
+		metadata.Type = PROTEIN
+	default:
+		return Metadata{}, fmt.Errorf("unknown sequence type %q in %q", parts[1][0], seqhash)
+	}
+	switch parts[1][1] {
+	case 'C':
+		metadata.Circular = true
+	case 'L':
+	default:
+		return Metadata{}, fmt.Errorf("unknown circularity %q in %q", parts[1][1], seqhash)
+	}
+	switch parts[1][2] {
+	case 'D':
+		metadata.DoubleStranded = true
+	case 'S':
+	default:
+		return Metadata{}, fmt.Errorf("unknown strandedness %q in %q", parts[1][2], seqhash)
+	}
+	digest, err := hex.DecodeString(parts[2])
+	if err != nil || len(digest) != blake3Length {
+		return Metadata{}, fmt.Errorf("%q doesn't end in a 32 byte hex hash", seqhash)
+	}
+	metadata.Digest = digest
+	return metadata, nil
+}
+
+// MigrateV1 converts a version 1 Seqhash to the version 2 Seqhash of the
+// same sequence.
+func MigrateV1(seqhash string) (string, error) {
+	metadata, err := ParseV1(seqhash)
+	if err != nil {
+		return "", err
+	}
+	metadata.Version = V2
+	return encodeV2(metadata)
+}
@@ -0,0 +1,175 @@
+package seqhash
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/sequence"
+)
+
+func TestHashV2(t *testing.T) {
+	for _, options := range []Options{
+		{Type: DNA, Strandedness: SingleStranded},
+		{Type: DNA, Circular: true, Strandedness: DoubleStranded},
+		{Type: RNA, Strandedness: DoubleStranded},
+		{Type: PROTEIN, Circular: true},
+	} {
+		sequence := "ATGGGCTAA"
+		if options.Type == PROTEIN {
+			sequence = "MGCS*"
+		}
+		v2, err := HashV2(sequence, options)
+		if err != nil {
+			t.Fatalf("HashV2() failed with %v", err)
+		}
+		metadata, err := ParseV2(v2)
+		if err != nil {
+			t.Fatalf("ParseV2(%q) failed with %v", v2, err)
+		}
+		if metadata.Version != V2 || metadata.Type != options.Type || metadata.Circular != options.Circular || metadata.DoubleStranded != (options.Strandedness == DoubleStranded) {
+			t.Errorf("ParseV2(%q) = %+v, doesn't match %+v", v2, metadata, options)
+		}
+
+		// V1 and V2 Seqhashes of the same sequence hash it the same way.
+		v1, _ := HashWithOptions(sequence, options)
+		v1Metadata, err := ParseV1(v1)
+		if err != nil || !bytes.Equal(v1Metadata.Digest, metadata.Digest) {
+			t.Errorf("ParseV1(%q) = %+v, %v, doesn't share its digest with %q", v1, v1Metadata, err, v2)
+		}
+		migrated, err := MigrateV1(v1)
+		if err != nil || migrated != v2 {
+			t.Errorf("MigrateV1(%q) = %q, %v, want %q", v1, migrated, err, v2)
+		}
+	}
+
+	// rotations and reverse complements hash the same, like V1.
+	first, _ := HashV2("ATGGGCTAA", Options{Circular: true, Strandedness: DoubleStranded})
+	second, _ := HashV2("TTAGCCCAT", Options{Circular: true, Strandedness: DoubleStranded})
+	third, _ := HashV2("GGCTAAATG", Options{Circular: true, Strandedness: DoubleStranded})
+	if first != second || first != third {
+		t.Errorf("HashV2() of equivalent sequences differ: %q, %q, %q", first, second, third)
+	}
+
+	// the prefix is the same for the same metadata.
+	other, _ := HashV2("GATTACA", Options{Circular: true, Strandedness: DoubleStranded})
+	if first[:4] != other[:4] || !strings.HasPrefix(first, "b") {
+		t.Errorf("HashV2() prefixes %q and %q should match", first[:4], other[:4])
+	}
+
+	if _, err := HashV2("MGCS*", Options{Type: PROTEIN, Strandedness: DoubleStranded}); err == nil {
+		t.Errorf("HashV2() should fail for double stranded proteins")
+	}
+	if _, err := HashV2("ATG", Options{Type: "TNA"}); err == nil {
+		t.Errorf("HashV2() should fail for TNA")
+	}
+}
+
+func TestV2Prefixes(t *testing.T) {
+	for _, test := range []struct {
+		sequence string
+		options  Options
+		want     string
+	}{
+		{"ATGGGCTAA", Options{Strandedness: SingleStranded}, "baiab"},
+		{"ATGGGCTAA", Options{Strandedness: DoubleStranded}, "baieb"},
+		{"ATGGGCTAA", Options{Circular: true, Strandedness: SingleStranded}, "baicb"},
+		{"ATGGGCTAA", Options{Circular: true, Strandedness: DoubleStranded}, "baigb"},
+		{"AUGGGCUAA", Options{Type: RNA, Strandedness: SingleStranded}, "baiar"},
+		{"AUGGGCUAA", Options{Type: RNA, Strandedness: DoubleStranded}, "baier"},
+		{"AUGGGCUAA", Options{Type: RNA, Circular: true, Strandedness: SingleStranded}, "baicr"},
+		{"AUGGGCUAA", Options{Type: RNA, Circular: true, Strandedness: DoubleStranded}, "baigr"},
+		{"MGCS*", Options{Type: PROTEIN}, "baibb"},
+		{"MGCS*", Options{Type: PROTEIN, Circular: true}, "baidb"},
+	} {
+		got, err := HashV2(test.sequence, test.options)
+		if err != nil || !strings.HasPrefix(got, test.want) {
+			t.Errorf("HashV2(%q, %+v) = %q, %v, want a %q prefix", test.sequence, test.options, got, err, test.want)
+		}
+	}
+	fragment, err := HashFragment("ATGGGCTAA", 0, 0)
+	if err != nil || !strings.HasPrefix(fragment, "baifr") {
+		t.Errorf("HashFragment() = %q, %v, want a %q prefix", fragment, err, "baifr")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	v1, _ := Hash("ATGGGCTAA", DNA, true, true)
+	if _, err := ParseV2(v1); !errors.Is(err, errNotV2) {
+		t.Errorf("ParseV2() of a V1 Seqhash should fail with errNotV2, got %v", err)
+	}
+	for _, seqhash := range []string{"bzzzz", "b", "baeaqcaaa", ""} {
+		if _, err := ParseV2(seqhash); err == nil {
+			t.Errorf("ParseV2(%q) should fail", seqhash)
+		}
+	}
+	for _, seqhash := range []string{"v2_DLD_00", "v1_XLD_00", "v1_DXD_00", "v1_DLX_00", "v1_DLD_zz", "v1_DLD_0011", "v1_DLD"} {
+		if _, err := MigrateV1(seqhash); err == nil {
+			t.Errorf("MigrateV1(%q) should fail", seqhash)
+		}
+	}
+}
+
+func TestHashFragment(t *testing.T) {
+	// a part with an AATG overhang on one end and a blunt other end, read
+	// from either strand.
+	top := "AATGGCTAGCTAA"
+	bottom := "AAGCTTAGCTAGCCATT"
+	first, err := HashFragment(top+"GCTT", 4, 0)
+	if err != nil {
+		t.Fatalf("HashFragment() error = %v", err)
+	}
+	second, _ := HashFragment(bottom, 0, 4)
+	if first != second {
+		t.Errorf("HashFragment() of both strands differ: %q, %q", first, second)
+	}
+	blunt, _ := HashFragment(top+"GCTT", 0, 0)
+	if blunt == first {
+		t.Errorf("HashFragment() should depend on overhangs")
+	}
+	linear, _ := HashV2(top+"GCTT", Options{Strandedness: DoubleStranded})
+	if linear == blunt {
+		t.Errorf("HashFragment() should differ from HashV2()")
+	}
+	metadata, err := ParseV2(first)
+	if err != nil || metadata.Type != FRAGMENT || !metadata.DoubleStranded || metadata.Circular {
+		t.Errorf("ParseV2() = %+v, %v, want a double stranded linear fragment", metadata, err)
+	}
+
+	for _, test := range []struct {
+		sequence         string
+		forward, reverse int
+	}{
+		{"AATG", 3, 2},
+		{"AATG", -1, 0},
+		{"AATG", 0, 256},
+		{"AAMG*", 0, 0},
+	} {
+		if _, err := HashFragment(test.sequence, test.forward, test.reverse); err == nil {
+			t.Errorf("HashFragment(%q, %d, %d) should fail", test.sequence, test.forward, test.reverse)
+		}
+	}
+	if _, err := HashV2("ATG", Options{Type: FRAGMENT}); err == nil {
+		t.Errorf("HashV2() should fail for fragments")
+	}
+}
+
+func TestProteinAlphabet(t *testing.T) {
+	if _, err := HashV2("MJKLBZX*", Options{Type: PROTEIN}); err != nil {
+		t.Errorf("HashV2() of a protein with ambiguous amino acids error = %v", err)
+	}
+	if _, err := HashV2("MGCJ1", Options{Type: PROTEIN}); err == nil {
+		t.Errorf("HashV2() should fail for a protein with a digit in it")
+	}
+}
+
+func TestHashSequence(t *testing.T) {
+	plasmid := sequence.New("plasmid", "ATGGGCTAA")
+	plasmid.Circular = true
+	got, err := HashSequence(plasmid)
+	want, _ := HashV2("ATGGGCTAA", Options{Circular: true, Strandedness: DoubleStranded})
+	if err != nil || got != want {
+		t.Errorf("HashSequence() = %q, %v, want %q", got, err, want)
+	}
+}
@@ -0,0 +1,19 @@
+package sequence_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/sequence"
+)
+
+func ExampleNew() {
+	plasmid := sequence.New("pUC19", "TCGCGCGTTTCGGTGATGACGG")
+	plasmid.Circular = true
+	fmt.Println(plasmid.Molecule, plasmid.Circular, plasmid.DoubleStranded, plasmid.Len())
+
+	protein := sequence.New("GFP", "MSKGEELFTG")
+	fmt.Println(protein.Molecule, protein.DoubleStranded)
+	// Output:
+	// DNA true true 22
+	// PROTEIN false
+}
@@ -0,0 +1,19 @@
+package packed_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/sequence/packed"
+)
+
+func ExamplePack() {
+	sequence := packed.Pack("ACGTRYacgt")
+	subsequence, _ := sequence.Subsequence(2, 8)
+	fmt.Println(sequence.Len(), len(sequence.Packed()))
+	fmt.Println(sequence.NBlocks(), sequence.MaskBlocks())
+	fmt.Println(subsequence)
+	// Output:
+	// 10 3
+	// [{4 6}] [{6 10}]
+	// GTNNac
+}
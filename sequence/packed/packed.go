@@ -0,0 +1,242 @@
+/*
+Package packed stores DNA sequences in two bits a base.
+
+Strings take a byte a base, which is four times what the four bases of DNA
+need. A human genome is 3 GB as a string, but 750 MB packed, and what's
+packed can be compared and hashed a byte, four bases, at a time.
+
+A Sequence packs A, C, G, and T as 0, 1, 2, and 3, the same codes k-mers are
+packed with in search/kmers, four to a byte with the first base in the
+highest two bits. Two bits can't say N, so every base that isn't A, C, G, or
+T is kept as a run of Ns instead, and lowercase bases, which genome
+assemblies use to soft mask repeats, are kept as runs of masked bases. That's
+what the UCSC .2bit format keeps too, and io/twobit reads and writes packed
+sequences. U is packed as T, so RNA unpacks as DNA.
+
+search/kmers counts and sketches packed sequences without unpacking them, and
+align.SemiGlobalPacked aligns reads to a range of one, unpacking only that
+range. search/fmindex and search/mapper index strings, so unpack what they
+index with String or Subsequence.
+*/
+package packed
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Block is a run of bases, the half-open range [Start, End) of a sequence,
+// zero-indexed.
+type Block struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Sequence is a DNA sequence packed two bits a base. The zero value is an
+// empty sequence.
+type Sequence struct {
+	length int
+	bases  []byte
+	// nBlocks and maskBlocks are each sorted, with no two blocks of either
+	// overlapping.
+	nBlocks    []Block
+	maskBlocks []Block
+}
+
+// codes are the two bit codes of bases, or 4 for anything else.
+var codes = func() [256]byte {
+	var codes [256]byte
+	for index := range codes {
+		codes[index] = 4
+	}
+	for code, bases := range []string{"Aa", "Cc", "Gg", "TtUu"} {
+		for _, base := range bases {
+			codes[base] = byte(code)
+		}
+	}
+	return codes
+}()
+
+// Pack packs a sequence.
+func Pack(sequence string) Sequence {
+	packed := Sequence{length: len(sequence), bases: make([]byte, (len(sequence)+3)/4)}
+	for position := 0; position < len(sequence); position++ {
+		base := sequence[position]
+		if code := codes[base]; code == 4 {
+			packed.nBlocks = extend(packed.nBlocks, position)
+		} else {
+			packed.bases[position/4] |= code << shift(position)
+		}
+		if 'a' <= base && base <= 'z' {
+			packed.maskBlocks = extend(packed.maskBlocks, position)
+		}
+	}
+	return packed
+}
+
+// extend adds a position to the last of some blocks if it's just after it,
+// or as a new block if it isn't.
+func extend(blocks []Block, position int) []Block {
+	if last := len(blocks) - 1; last >= 0 && blocks[last].End == position {
+		blocks[last].End++
+		return blocks
+	}
+	return append(blocks, Block{Start: position, End: position + 1})
+}
+
+// shift is how far a position's code is shifted in its byte.
+func shift(position int) uint {
+	return uint(6 - 2*(position%4))
+}
+
+// FromPacked returns a sequence of length bases from its packed bases, four
+// to a byte like Packed returns them, and its runs of Ns and masked bases.
+func FromPacked(length int, bases []byte, nBlocks, maskBlocks []Block) (Sequence, error) {
+	if length < 0 || len(bases) != (length+3)/4 {
+		return Sequence{}, fmt.Errorf("%d bases pack into %d bytes, got %d", length, (length+3)/4, len(bases))
+	}
+	nBlocks, err := checkBlocks(nBlocks, length)
+	if err != nil {
+		return Sequence{}, fmt.Errorf("N blocks: %w", err)
+	}
+	maskBlocks, err = checkBlocks(maskBlocks, length)
+	if err != nil {
+		return Sequence{}, fmt.Errorf("mask blocks: %w", err)
+	}
+	return Sequence{length: length, bases: bases, nBlocks: nBlocks, maskBlocks: maskBlocks}, nil
+}
+
+// checkBlocks returns blocks sorted, with empty ones removed, and checks
+// they're in a sequence of length bases and don't overlap.
+func checkBlocks(blocks []Block, length int) ([]Block, error) {
+	sorted := make([]Block, 0, len(blocks))
+	for _, block := range blocks {
+		if block.Start < 0 || block.End < block.Start || block.End > length {
+			return nil, fmt.Errorf("block [%d, %d) isn't in a sequence of %d bases", block.Start, block.End, length)
+		}
+		if block.Start < block.End {
+			sorted = append(sorted, block)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+	for index := 1; index < len(sorted); index++ {
+		if sorted[index].Start < sorted[index-1].End {
+			return nil, fmt.Errorf("blocks [%d, %d) and [%d, %d) overlap", sorted[index-1].Start, sorted[index-1].End, sorted[index].Start, sorted[index].End)
+		}
+	}
+	return sorted, nil
+}
+
+// Len returns the number of bases in a sequence.
+func (sequence Sequence) Len() int {
+	return sequence.length
+}
+
+// Packed returns the packed bases of a sequence, four to a byte. What's
+// packed where the Ns are doesn't mean anything.
+func (sequence Sequence) Packed() []byte {
+	return sequence.bases
+}
+
+// NBlocks returns the runs of Ns in a sequence, in order.
+func (sequence Sequence) NBlocks() []Block {
+	return sequence.nBlocks
+}
+
+// MaskBlocks returns the runs of masked, lowercase, bases in a sequence, in
+// order.
+func (sequence Sequence) MaskBlocks() []Block {
+	return sequence.maskBlocks
+}
+
+// Code returns the two bit code of the base at position, and false if it's
+// an N.
+func (sequence Sequence) Code(position int) (byte, bool) {
+	if inBlocks(sequence.nBlocks, position) {
+		return 0, false
+	}
+	return sequence.bases[position/4] >> shift(position) & 3, true
+}
+
+// Base returns the base at position, lowercase if it's masked.
+func (sequence Sequence) Base(position int) byte {
+	base := byte('N')
+	if code, ok := sequence.Code(position); ok {
+		base = "ACGT"[code]
+	}
+	if inBlocks(sequence.maskBlocks, position) {
+		base += 'a' - 'A'
+	}
+	return base
+}
+
+// inBlocks checks whether a position is in one of some sorted blocks.
+func inBlocks(blocks []Block, position int) bool {
+	index := sort.Search(len(blocks), func(index int) bool { return blocks[index].End > position })
+	return index < len(blocks) && blocks[index].Start <= position
+}
+
+// Subsequence returns the half-open range [start, end) of a sequence,
+// unpacked.
+func (sequence Sequence) Subsequence(start, end int) (string, error) {
+	if start < 0 || end < start || end > sequence.length {
+		return "", fmt.Errorf("range [%d, %d) isn't in a sequence of %d bases", start, end, sequence.length)
+	}
+	bases := make([]byte, end-start)
+	for position := start; position < end; position++ {
+		bases[position-start] = "ACGT"[sequence.bases[position/4]>>shift(position)&3]
+	}
+	overlay(bases, start, sequence.nBlocks, func(byte) byte { return 'N' })
+	overlay(bases, start, sequence.maskBlocks, func(base byte) byte { return base + 'a' - 'A' })
+	return string(bases), nil
+}
+
+// overlay changes the bases of an unpacked range that starts at start which
+// are in some sorted blocks.
+func overlay(bases []byte, start int, blocks []Block, change func(byte) byte) {
+	end := start + len(bases)
+	first := sort.Search(len(blocks), func(index int) bool { return blocks[index].End > start })
+	for _, block := range blocks[first:] {
+		if block.Start >= end {
+			break
+		}
+		for position := max(block.Start, start); position < min(block.End, end); position++ {
+			bases[position-start] = change(bases[position-start])
+		}
+	}
+}
+
+// String returns a sequence unpacked.
+func (sequence Sequence) String() string {
+	// the whole sequence is always in range.
+	unpacked, _ := sequence.Subsequence(0, sequence.length)
+	return unpacked
+}
+
+// Equal checks whether two sequences have the same bases, masked the same.
+func (sequence Sequence) Equal(other Sequence) bool {
+	if sequence.length != other.length || !equalBlocks(sequence.nBlocks, other.nBlocks) || !equalBlocks(sequence.maskBlocks, other.maskBlocks) {
+		return false
+	}
+	// Ns can be packed as anything, so bases are compared outside of them.
+	for position := 0; position < sequence.length; position++ {
+		code, ok := sequence.Code(position)
+		otherCode, _ := other.Code(position)
+		if ok && code != otherCode {
+			return false
+		}
+	}
+	return true
+}
+
+func equalBlocks(blocks, others []Block) bool {
+	if len(blocks) != len(others) {
+		return false
+	}
+	for index := range blocks {
+		if blocks[index] != others[index] {
+			return false
+		}
+	}
+	return true
+}
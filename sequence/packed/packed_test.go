@@ -0,0 +1,64 @@
+package packed
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPack(t *testing.T) {
+	sequence := Pack("ACGTNNacgtnRYACGTA")
+	assert.Equal(t, 18, sequence.Len())
+	assert.Equal(t, "ACGTNNacgtnNNACGTA", sequence.String())
+	assert.Len(t, sequence.Packed(), 5)
+	assert.Equal(t, []Block{{4, 6}, {10, 13}}, sequence.NBlocks())
+	assert.Equal(t, []Block{{6, 11}}, sequence.MaskBlocks())
+
+	code, ok := sequence.Code(2)
+	assert.True(t, ok)
+	assert.Equal(t, byte(2), code)
+	_, ok = sequence.Code(11)
+	assert.False(t, ok)
+	assert.Equal(t, byte('g'), sequence.Base(8))
+	assert.Equal(t, byte('n'), sequence.Base(10))
+	assert.Equal(t, byte('N'), sequence.Base(11))
+
+	for start := 0; start <= sequence.Len(); start++ {
+		for end := start; end <= sequence.Len(); end++ {
+			subsequence, err := sequence.Subsequence(start, end)
+			require.NoError(t, err)
+			assert.Equal(t, "ACGTNNacgtnNNACGTA"[start:end], subsequence)
+		}
+	}
+	_, err := sequence.Subsequence(3, 19)
+	assert.Error(t, err)
+
+	// U packs as T.
+	assert.Equal(t, "ACGT", Pack("ACGU").String())
+	assert.Equal(t, "", Sequence{}.String())
+}
+
+func TestFromPacked(t *testing.T) {
+	sequence := Pack("ACGTNNacgtnRYACGTA")
+	rebuilt, err := FromPacked(sequence.Len(), sequence.Packed(), sequence.NBlocks(), sequence.MaskBlocks())
+	require.NoError(t, err)
+	assert.True(t, sequence.Equal(rebuilt))
+
+	// what's packed under Ns doesn't matter, and blocks can come in any order.
+	bases := append([]byte{}, sequence.Packed()...)
+	bases[1] |= 0xf0
+	rebuilt, err = FromPacked(sequence.Len(), bases, []Block{{10, 13}, {4, 6}, {7, 7}}, sequence.MaskBlocks())
+	require.NoError(t, err)
+	assert.True(t, sequence.Equal(rebuilt))
+	assert.Equal(t, sequence.String(), rebuilt.String())
+	assert.False(t, sequence.Equal(Pack("ACGTNNacgtnRYACGTT")))
+	assert.False(t, sequence.Equal(Pack("ACGTNNACGTnRYACGTA")))
+
+	_, err = FromPacked(18, bases[:4], nil, nil)
+	assert.Error(t, err)
+	_, err = FromPacked(18, bases, []Block{{4, 6}, {5, 8}}, nil)
+	assert.Error(t, err)
+	_, err = FromPacked(18, bases, nil, []Block{{17, 19}})
+	assert.Error(t, err)
+}
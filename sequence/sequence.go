@@ -0,0 +1,109 @@
+/*
+Package sequence provides Sequence, the one way to describe a sequence that
+every other package in poly agrees on.
+
+A string of bases doesn't say much on its own. "ATGC" could be DNA or RNA
+written with Ts, single stranded or a double stranded duplex, a linear
+fragment or a plasmid. Which one it is changes what it means: a circular
+sequence has no ends to cut off, a double stranded one hashes the same as its
+reverse complement, and DNA and RNA fold with different energies.
+
+Before Sequence, those properties lived in the structs of whichever parser
+read them, like the LOCUS line of a Genbank, or weren't kept at all, like in
+FASTA, so every package that needed them had to work them out again. Now io
+packages turn what they read into a Sequence with ToSequence, and packages
+that need a sequence's topology or molecule take a Sequence.
+*/
+package sequence
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bebop/poly/alphabet"
+)
+
+// Molecule is what kind of molecule a sequence is. The values match the
+// sequence types of seqhash.
+type Molecule string
+
+// Molecules.
+const (
+	DNA     Molecule = "DNA"
+	RNA     Molecule = "RNA"
+	Protein Molecule = "PROTEIN"
+)
+
+// proteinLetters are the amino acids, stop, and ambiguity codes proteins may
+// contain, the same as seqhash allows.
+const proteinLetters = "ACDEFGHIKLMNPQRSTVWYUO*BXZJ"
+
+// Sequence is a sequence with its molecule, topology, and strandedness.
+type Sequence struct {
+	Name           string
+	Sequence       string
+	Molecule       Molecule
+	Circular       bool
+	DoubleStranded bool
+}
+
+// New returns a linear Sequence, guessing what molecule it is with
+// GuessMolecule. DNA is assumed to be double stranded, and RNA single
+// stranded.
+func New(name, sequence string) Sequence {
+	molecule := GuessMolecule(sequence)
+	return Sequence{Name: name, Sequence: sequence, Molecule: molecule, DoubleStranded: molecule == DNA}
+}
+
+// GuessMolecule guesses what molecule a sequence is from its letters. A
+// sequence of only nucleotide codes is DNA, unless it has Us and no Ts, which
+// makes it RNA. Anything else is a protein.
+func GuessMolecule(sequence string) Molecule {
+	hasT, hasU := false, false
+	for index := 0; index < len(sequence); index++ {
+		switch sequence[index] {
+		case 'T', 't':
+			hasT = true
+		case 'U', 'u':
+			hasU = true
+		}
+		if alphabet.Ambiguities(sequence[index]) == "" {
+			return Protein
+		}
+	}
+	if hasU && !hasT {
+		return RNA
+	}
+	return DNA
+}
+
+// Len returns the length of a sequence.
+func (sequence Sequence) Len() int {
+	return len(sequence.Sequence)
+}
+
+// Validate checks that a sequence only has letters its molecule can have,
+// and that proteins aren't double stranded.
+func (sequence Sequence) Validate() error {
+	switch sequence.Molecule {
+	case DNA, RNA:
+		for index := 0; index < len(sequence.Sequence); index++ {
+			if alphabet.Ambiguities(sequence.Sequence[index]) == "" {
+				return fmt.Errorf("%q at position %d of %s isn't a nucleotide", sequence.Sequence[index], index, sequence.Name)
+			}
+		}
+	case Protein:
+		if sequence.DoubleStranded {
+			return fmt.Errorf("protein %s can't be double stranded", sequence.Name)
+		}
+		upper := strings.ToUpper(sequence.Sequence)
+		for index := 0; index < len(upper); index++ {
+			if strings.IndexByte(proteinLetters, upper[index]) == -1 {
+				return fmt.Errorf("%q at position %d of %s isn't an amino acid", sequence.Sequence[index], index, sequence.Name)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown molecule %q", sequence.Molecule)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package sequence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuessMolecule(t *testing.T) {
+	tests := []struct {
+		sequence string
+		want     Molecule
+	}{
+		{"ATGC", DNA},
+		{"atgcNNRY", DNA},
+		{"AUGC", RNA},
+		{"ATUG", DNA},
+		{"", DNA},
+		{"MKLV*", Protein},
+		{"ATG-C", Protein},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, GuessMolecule(test.sequence), test.sequence)
+	}
+}
+
+func TestNew(t *testing.T) {
+	dna := New("dna", "ATGC")
+	assert.Equal(t, Sequence{Name: "dna", Sequence: "ATGC", Molecule: DNA, DoubleStranded: true}, dna)
+	assert.Equal(t, 4, dna.Len())
+	assert.False(t, New("rna", "AUGC").DoubleStranded)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, New("dna", "ATGCNWS").Validate())
+	assert.NoError(t, Sequence{Molecule: Protein, Sequence: "mklv*"}.Validate())
+	assert.Error(t, Sequence{Molecule: DNA, Sequence: "ATGE"}.Validate())
+	assert.Error(t, Sequence{Molecule: Protein, Sequence: "MK1"}.Validate())
+	assert.Error(t, Sequence{Molecule: Protein, Sequence: "MK", DoubleStranded: true}.Validate())
+	assert.Error(t, Sequence{Molecule: "TNA", Sequence: "ATG"}.Validate())
+}
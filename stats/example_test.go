@@ -0,0 +1,38 @@
+package stats_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bebop/poly/stats"
+)
+
+func ExampleProfile() {
+	sequence := strings.Repeat("G", 50) + strings.Repeat("AT", 25) + strings.Repeat("GC", 25)
+	for _, window := range stats.Profile(sequence, stats.Options{Window: 50}) {
+		fmt.Printf("%d %.2f %.2f %.2f\n", window.Start, window.GC, window.GCSkew, window.Entropy)
+	}
+	// Output:
+	// 0 1.00 1.00 0.00
+	// 50 0.00 0.00 1.00
+	// 100 1.00 0.00 1.00
+}
+
+func ExampleMask() {
+	sequence := "GATCGGACTTAGCCATGCAACGTTAGC" + strings.Repeat("A", 40) + "CGATTGCAGTCCGATAGCTTGACGAT"
+	masked := stats.Mask(sequence, stats.DustOptions{})
+	fmt.Println(masked[0].Start, masked[0].End)
+	fmt.Println(stats.SoftMask(sequence, masked))
+	// Output:
+	// 27 67
+	// GATCGGACTTAGCCATGCAACGTTAGCaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaCGATTGCAGTCCGATAGCTTGACGAT
+}
+
+func ExampleHomopolymers() {
+	for _, homopolymer := range stats.Homopolymers("GATTTTTCAAAAAAGCG", 5) {
+		fmt.Println(homopolymer.Start, homopolymer.End, homopolymer.Feature().Attributes["label"])
+	}
+	// Output:
+	// 2 7 poly(T)
+	// 8 14 poly(A)
+}
@@ -0,0 +1,162 @@
+package stats
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bebop/poly/io/genbank"
+)
+
+/******************************************************************************
+
+CpG island and homopolymer detection begins here.
+
+CpG dinucleotides are rare in vertebrate genomes, because methylated Cs in
+them mutate to Ts, except in CpG islands, which are mostly unmethylated and
+sit at the promoters of about half of all genes. They matter for design too:
+CpGs in a transgene get it methylated and silenced in mammalian cells, and
+they set off the innate immune system when delivered as plasmid DNA.
+
+CpGIslands uses the criteria of Gardiner-Garden and Frommer, 1987
+(https://doi.org/10.1016/0022-2836(87)90689-9): a stretch of at least 200
+bases, more than half of them G or C, with at least 60% as many CpGs as its
+Cs and Gs would make by chance. Every window of that length that meets them
+is found, and windows that overlap are merged into one island.
+
+Homopolymers, long runs of a single base, are hard to synthesize, make
+polymerases slip, and throw off sequencing, so synthesis fixing and primer
+design both avoid them.
+
+******************************************************************************/
+
+// CpGOptions changes how CpG islands are found. Zero values are replaced
+// with the defaults noted on each field.
+type CpGOptions struct {
+	// MinLength is the shortest an island can be. Defaults to 200.
+	MinLength int
+	// MinGC is the fraction of an island that has to be G or C, which it has
+	// to be more than. Defaults to 0.5.
+	MinGC float64
+	// MinObservedExpected is the fewest CpGs an island can have, as a
+	// fraction of the Cs times the Gs over its length. Defaults to 0.6.
+	MinObservedExpected float64
+}
+
+// CpGIsland is a CpG island.
+type CpGIsland struct {
+	Start, End       int // half-open range of the island.
+	GC               float64
+	ObservedExpected float64 // CpGs over the Cs times the Gs over the length.
+}
+
+// Homopolymer is a run of a single base.
+type Homopolymer struct {
+	Start, End int  // half-open range of the run.
+	Base       byte // uppercase.
+}
+
+// Len returns the length of a homopolymer.
+func (homopolymer Homopolymer) Len() int {
+	return homopolymer.End - homopolymer.Start
+}
+
+// CpGIslands returns the CpG islands of a sequence, sorted by where they
+// start.
+func CpGIslands(sequence string, options CpGOptions) []CpGIsland {
+	if options.MinLength == 0 {
+		options.MinLength = 200
+	}
+	if options.MinGC == 0 {
+		options.MinGC = 0.5
+	}
+	if options.MinObservedExpected == 0 {
+		options.MinObservedExpected = 0.6
+	}
+	sequence = strings.ToUpper(sequence)
+	if len(sequence) < options.MinLength || options.MinLength < 2 {
+		return nil
+	}
+
+	// cumulative counts of Cs, Gs, and CpGs before every position, where a
+	// CpG is counted at its C.
+	cs, gs, cpgs := make([]int, len(sequence)+1), make([]int, len(sequence)+1), make([]int, len(sequence)+1)
+	for position := 0; position < len(sequence); position++ {
+		cs[position+1], gs[position+1], cpgs[position+1] = cs[position], gs[position], cpgs[position]
+		switch sequence[position] {
+		case 'C':
+			cs[position+1]++
+			if position+1 < len(sequence) && sequence[position+1] == 'G' {
+				cpgs[position+1]++
+			}
+		case 'G':
+			gs[position+1]++
+		}
+	}
+	island := func(start, end int) CpGIsland {
+		c, g := cs[end]-cs[start], gs[end]-gs[start]
+		// a CpG whose C is the last base of the range isn't in it.
+		cpg := cpgs[end-1] - cpgs[start]
+		current := CpGIsland{Start: start, End: end, GC: float64(c+g) / float64(end-start)}
+		if c > 0 && g > 0 {
+			current.ObservedExpected = float64(cpg) * float64(end-start) / (float64(c) * float64(g))
+		}
+		return current
+	}
+
+	var islands []CpGIsland
+	for start := 0; start+options.MinLength <= len(sequence); start++ {
+		window := island(start, start+options.MinLength)
+		if window.GC <= options.MinGC || window.ObservedExpected < options.MinObservedExpected {
+			continue
+		}
+		if last := len(islands) - 1; last >= 0 && start <= islands[last].End {
+			islands[last] = island(islands[last].Start, window.End)
+			continue
+		}
+		islands = append(islands, window)
+	}
+	return islands
+}
+
+// Homopolymers returns the runs of a single unambiguous base in a sequence
+// that are at least minLength long, sorted by where they start.
+func Homopolymers(sequence string, minLength int) []Homopolymer {
+	sequence = strings.ToUpper(sequence)
+	var homopolymers []Homopolymer
+	for start := 0; start < len(sequence); {
+		end := start + 1
+		for end < len(sequence) && sequence[end] == sequence[start] {
+			end++
+		}
+		if end-start >= minLength && strings.IndexByte("ACGTU", sequence[start]) != -1 {
+			homopolymers = append(homopolymers, Homopolymer{Start: start, End: end, Base: sequence[start]})
+		}
+		start = end
+	}
+	return homopolymers
+}
+
+// Feature returns a CpG island as a misc_feature.
+func (island CpGIsland) Feature() genbank.Feature {
+	return genbank.Feature{
+		Type: "misc_feature",
+		Attributes: map[string]string{
+			"label": "CpG island",
+			"note":  fmt.Sprintf("%.0f%% GC, CpG observed/expected %.2f", island.GC*100, island.ObservedExpected),
+		},
+		Location: genbank.Location{Start: island.Start, End: island.End},
+	}
+}
+
+// Feature returns a homopolymer as a repeat_region.
+func (homopolymer Homopolymer) Feature() genbank.Feature {
+	return genbank.Feature{
+		Type: "repeat_region",
+		Attributes: map[string]string{
+			"label":        fmt.Sprintf("poly(%c)", homopolymer.Base),
+			"rpt_type":     "tandem",
+			"rpt_unit_seq": strings.ToLower(string(homopolymer.Base)),
+		},
+		Location: genbank.Location{Start: homopolymer.Start, End: homopolymer.End},
+	}
+}
@@ -0,0 +1,57 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCpGIslands(t *testing.T) {
+	flank := strings.Repeat("AT", 150)
+	island := strings.Repeat("CGGA", 75)
+	sequence := flank + island + flank
+
+	islands := CpGIslands(sequence, CpGOptions{})
+	require.Len(t, islands, 1)
+	// windows reaching partway into either flank still pass.
+	assert.True(t, islands[0].Start > 100 && islands[0].Start <= 300)
+	assert.True(t, islands[0].End >= 600 && islands[0].End < 800)
+	assert.Greater(t, islands[0].GC, 0.5)
+	assert.Greater(t, islands[0].ObservedExpected, 0.6)
+
+	// GC rich, but without CpGs.
+	assert.Empty(t, CpGIslands(flank+strings.Repeat("GCAG", 75)+flank, CpGOptions{}))
+	assert.Empty(t, CpGIslands(strings.Repeat("GGCA", 75), CpGOptions{}))
+	// too short.
+	assert.Empty(t, CpGIslands(strings.Repeat("CG", 50), CpGOptions{}))
+	assert.Len(t, CpGIslands(strings.Repeat("CG", 50), CpGOptions{MinLength: 50}), 1)
+	// lowercase works too.
+	assert.Len(t, CpGIslands(strings.ToLower(sequence), CpGOptions{}), 1)
+}
+
+func TestHomopolymers(t *testing.T) {
+	homopolymers := Homopolymers("GATTTTTCAAAAAAGNNNNNNNNc", 5)
+	assert.Equal(t, []Homopolymer{{Start: 2, End: 7, Base: 'T'}, {Start: 8, End: 14, Base: 'A'}}, homopolymers)
+	assert.Equal(t, 6, homopolymers[1].Len())
+	assert.Len(t, Homopolymers("gggggg", 6), 1)
+	assert.Empty(t, Homopolymers("ACGT", 2))
+	assert.Empty(t, Homopolymers("", 1))
+}
+
+func TestFeatures(t *testing.T) {
+	feature := Homopolymer{Start: 2, End: 7, Base: 'T'}.Feature()
+	assert.Equal(t, "repeat_region", feature.Type)
+	assert.Equal(t, "poly(T)", feature.Attributes["label"])
+	assert.Equal(t, "t", feature.Attributes["rpt_unit_seq"])
+	assert.Equal(t, 2, feature.Location.Start)
+	assert.Equal(t, 7, feature.Location.End)
+
+	feature = CpGIsland{Start: 10, End: 300, GC: 0.65, ObservedExpected: 0.8}.Feature()
+	assert.Equal(t, "misc_feature", feature.Type)
+	assert.Equal(t, "CpG island", feature.Attributes["label"])
+	assert.Equal(t, "65% GC, CpG observed/expected 0.80", feature.Attributes["note"])
+	assert.Equal(t, 10, feature.Location.Start)
+	assert.Equal(t, 300, feature.Location.End)
+}
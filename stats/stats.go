@@ -0,0 +1,338 @@
+/*
+Package stats computes composition statistics of DNA sequences, in windows
+and as a whole.
+
+The first thing anyone does with a new sequence is look at its composition.
+GC content says a lot about where a sequence came from and how hard it will
+be to synthesize and amplify. GC skew, (G-C)/(G+C), flips sign at the origin
+and terminus of replication of most bacterial chromosomes, so a plot of it
+points right at them. Shannon entropy measures how evenly a window uses the
+four bases, from 2 bits for random sequence down to 0 for a homopolymer.
+
+Low complexity sequence, like long runs of a single base or short tandem
+repeats, is worth knowing about too. It trips up synthesis and sequencing,
+and makes spurious hits in any kind of similarity search, which is why
+aligners mask it first. Mask finds it with the DUST algorithm, which scores
+how often the triplets of a window repeat each other.
+
+For DUST:
+Morgulis et al., 2006
+https://doi.org/10.1089/cmb.2006.13.1028
+*/
+package stats
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/bebop/poly/search/interval"
+	"github.com/bebop/poly/window"
+)
+
+// Options changes the windows Profile computes statistics over. Zero values
+// are replaced with the defaults noted on each field.
+type Options struct {
+	// Window is how many bases each window is. Defaults to 100.
+	Window int
+	// Step is how many bases apart windows start. Defaults to Window, so
+	// windows don't overlap.
+	Step int
+	// Circular wraps windows around the end of the sequence into its start,
+	// so every step of a plasmid starts a window.
+	Circular bool
+}
+
+// Window is the statistics of one window of a sequence.
+type Window struct {
+	// Start and End are where the window is, as a half-open range. End is
+	// past the end of a circular sequence for windows that cross its origin.
+	Start, End int
+	GC         float64 // fraction of unambiguous bases that are G or C.
+	GCSkew     float64 // (G-C)/(G+C).
+	Entropy    float64 // Shannon entropy of the bases, in bits.
+}
+
+// Summary is the statistics of a whole sequence.
+type Summary struct {
+	Length    int
+	Ambiguous int // bases other than A, C, G, T, and U, like N.
+	GC        float64
+	GCSkew    float64
+	Entropy   float64
+	// LowComplexity is how many bases Mask masks with its default options.
+	LowComplexity int
+}
+
+// baseCounts counts the As, Cs, Gs, and Ts (or Us) of a sequence, in that
+// order, and everything else.
+func baseCounts(sequence string) (counts [4]int, other int) {
+	for index := 0; index < len(sequence); index++ {
+		switch sequence[index] {
+		case 'A', 'a':
+			counts[0]++
+		case 'C', 'c':
+			counts[1]++
+		case 'G', 'g':
+			counts[2]++
+		case 'T', 't', 'U', 'u':
+			counts[3]++
+		default:
+			other++
+		}
+	}
+	return counts, other
+}
+
+// GCContent returns the fraction of the unambiguous bases of a sequence that
+// are G or C, or 0 if it has none. Unlike checks.GcContent, Ns and other
+// ambiguous bases don't count towards the total.
+func GCContent(sequence string) float64 {
+	counts, _ := baseCounts(sequence)
+	total := counts[0] + counts[1] + counts[2] + counts[3]
+	if total == 0 {
+		return 0
+	}
+	return float64(counts[1]+counts[2]) / float64(total)
+}
+
+// GCSkew returns (G-C)/(G+C) of a sequence, from -1 to 1, or 0 if it has no
+// Gs or Cs.
+func GCSkew(sequence string) float64 {
+	counts, _ := baseCounts(sequence)
+	if counts[1]+counts[2] == 0 {
+		return 0
+	}
+	return float64(counts[2]-counts[1]) / float64(counts[2]+counts[1])
+}
+
+// Entropy returns the Shannon entropy of the unambiguous bases of a
+// sequence, in bits, from 0 for a single repeated base to 2 for all four in
+// equal amounts.
+func Entropy(sequence string) float64 {
+	counts, _ := baseCounts(sequence)
+	total := float64(counts[0] + counts[1] + counts[2] + counts[3])
+	entropy := 0.0
+	for _, count := range counts {
+		if count > 0 {
+			frequency := float64(count) / total
+			entropy -= frequency * math.Log2(frequency)
+		}
+	}
+	return entropy
+}
+
+// Profile returns the statistics of every window of a sequence.
+func Profile(sequence string, options Options) []Window {
+	if options.Window == 0 {
+		options.Window = 100
+	}
+	if options.Step == 0 {
+		options.Step = options.Window
+	}
+	var windows []Window
+	iterator := window.New(sequence, options.Window, options.Step, options.Circular)
+	for iterator.Next() {
+		current := iterator.Window()
+		windows = append(windows, Window{
+			Start:   iterator.Start(),
+			End:     iterator.End(),
+			GC:      GCContent(current),
+			GCSkew:  GCSkew(current),
+			Entropy: Entropy(current),
+		})
+	}
+	return windows
+}
+
+// Summarize returns the statistics of a whole sequence.
+func Summarize(sequence string) Summary {
+	_, ambiguous := baseCounts(sequence)
+	summary := Summary{
+		Length:    len(sequence),
+		Ambiguous: ambiguous,
+		GC:        GCContent(sequence),
+		GCSkew:    GCSkew(sequence),
+		Entropy:   Entropy(sequence),
+	}
+	for _, masked := range Mask(sequence, DustOptions{}) {
+		summary.LowComplexity += masked.End - masked.Start
+	}
+	return summary
+}
+
+/******************************************************************************
+
+Low complexity masking begins here.
+
+DUST scores a stretch of sequence by counting each of the 64 triplets in it
+and adding up c(c-1)/2 over the counts, which is how many pairs of the same
+triplet it has, divided by one less than the number of triplets. Random
+sequence scores about 0.5, a dinucleotide repeat a quarter of its length, and
+a homopolymer half of it.
+
+Mask slides a window along the sequence, and in every window that scores over
+the threshold, masks the stretch of it that scores highest. Triplets with
+ambiguous bases aren't counted, so runs of Ns aren't low complexity, just
+unknown.
+
+******************************************************************************/
+
+// DustOptions changes how Mask finds low complexity sequence. Zero values
+// are replaced with the defaults noted on each field, which are those of
+// NCBI's dustmasker.
+type DustOptions struct {
+	// Window is how many bases each window scored is. Defaults to 64.
+	Window int
+	// Threshold is the score over which a window is low complexity. Defaults
+	// to 2, which dustmasker calls level 20 since it counts in tenths.
+	Threshold float64
+}
+
+// tripletCodes packs the unambiguous bases into two bits, or -1.
+var tripletCodes = func() [256]int {
+	var codes [256]int
+	for index := range codes {
+		codes[index] = -1
+	}
+	for code, bases := range []string{"Aa", "Cc", "Gg", "TtUu"} {
+		for _, base := range bases {
+			codes[base] = code
+		}
+	}
+	return codes
+}()
+
+// triplets returns the code of the triplet starting at every position of a
+// sequence that has one, or -1 where it has ambiguous bases.
+func triplets(sequence string) []int {
+	if len(sequence) < 3 {
+		return nil
+	}
+	codes := make([]int, len(sequence)-2)
+	for position := range codes {
+		first, second, third := tripletCodes[sequence[position]], tripletCodes[sequence[position+1]], tripletCodes[sequence[position+2]]
+		if first < 0 || second < 0 || third < 0 {
+			codes[position] = -1
+			continue
+		}
+		codes[position] = first<<4 | second<<2 | third
+	}
+	return codes
+}
+
+// DustScore returns the DUST score of a sequence.
+func DustScore(sequence string) float64 {
+	codes := triplets(sequence)
+	var counts [64]int
+	pairs := 0
+	for _, code := range codes {
+		if code >= 0 {
+			pairs += counts[code]
+			counts[code]++
+		}
+	}
+	if len(codes) < 2 {
+		return 0
+	}
+	return float64(pairs) / float64(len(codes)-1)
+}
+
+// Mask returns the low complexity stretches of a sequence, as half-open
+// intervals carrying their DUST scores, sorted and merged where they overlap.
+func Mask(sequence string, options DustOptions) []interval.Interval[float64] {
+	if options.Window == 0 {
+		options.Window = 64
+	}
+	if options.Threshold == 0 {
+		options.Threshold = 2
+	}
+	codes := triplets(sequence)
+	tripletWindow := options.Window - 2
+	if tripletWindow < 2 {
+		return nil
+	}
+
+	var masked []interval.Interval[float64]
+	var counts [64]int
+	pairs := 0
+	for end := range codes {
+		if code := codes[end]; code >= 0 {
+			pairs += counts[code]
+			counts[code]++
+		}
+		start := end + 1 - tripletWindow
+		if start > 0 {
+			if code := codes[start-1]; code >= 0 {
+				counts[code]--
+				pairs -= counts[code]
+			}
+		}
+		// windows at the start of the sequence are shorter.
+		length := end + 1 - max(start, 0)
+		if length < 2 || float64(pairs)/float64(length-1) <= options.Threshold {
+			continue
+		}
+		best := bestStretch(codes[max(start, 0) : end+1])
+		best.Start += max(start, 0)
+		best.End += max(start, 0) + 2 // the last triplet's bases.
+		masked = append(masked, best)
+	}
+	return merge(masked)
+}
+
+// bestStretch returns the stretch of triplets with the highest DUST score,
+// as bases relative to the start of codes, not counting the last triplet's
+// last two bases.
+func bestStretch(codes []int) interval.Interval[float64] {
+	best := interval.Interval[float64]{Value: -1}
+	for start := range codes {
+		var counts [64]int
+		pairs := 0
+		for end := start; end < len(codes); end++ {
+			if code := codes[end]; code >= 0 {
+				pairs += counts[code]
+				counts[code]++
+			}
+			if end == start {
+				continue
+			}
+			score := float64(pairs) / float64(end-start)
+			if score > best.Value {
+				best = interval.Interval[float64]{Start: start, End: end + 1, Value: score}
+			}
+		}
+	}
+	return best
+}
+
+// merge sorts intervals and merges the ones that overlap, keeping the highest
+// score of those merged.
+func merge(intervals []interval.Interval[float64]) []interval.Interval[float64] {
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].Start < intervals[j].Start })
+	var merged []interval.Interval[float64]
+	for _, current := range intervals {
+		last := len(merged) - 1
+		if last >= 0 && current.Start <= merged[last].End {
+			merged[last].End = max(merged[last].End, current.End)
+			merged[last].Value = math.Max(merged[last].Value, current.Value)
+			continue
+		}
+		merged = append(merged, current)
+	}
+	return merged
+}
+
+// SoftMask lowercases the intervals of a sequence, like those from Mask, and
+// uppercases the rest.
+func SoftMask(sequence string, intervals []interval.Interval[float64]) string {
+	masked := []byte(strings.ToUpper(sequence))
+	for _, current := range intervals {
+		for position := max(current.Start, 0); position < min(current.End, len(masked)); position++ {
+			if masked[position] >= 'A' && masked[position] <= 'Z' {
+				masked[position] += 'a' - 'A'
+			}
+		}
+	}
+	return string(masked)
+}
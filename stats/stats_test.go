@@ -0,0 +1,77 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComposition(t *testing.T) {
+	assert.Equal(t, 0.5, GCContent("ATGCNNNN"))
+	assert.Equal(t, 0.5, GCContent("augc"))
+	assert.Equal(t, 0.0, GCContent("NNN"))
+	assert.Equal(t, 0.5, GCSkew("GGGC"))
+	assert.Equal(t, -1.0, GCSkew("ATCC"))
+	assert.Equal(t, 0.0, GCSkew("ATAT"))
+	assert.Equal(t, 2.0, Entropy("ACGT"))
+	assert.Equal(t, 1.0, Entropy("AATT"))
+	assert.Equal(t, 0.0, Entropy("AAAA"))
+	assert.Equal(t, 0.0, Entropy(""))
+}
+
+func TestProfile(t *testing.T) {
+	sequence := strings.Repeat("G", 50) + strings.Repeat("AT", 25) + strings.Repeat("C", 50)
+	windows := Profile(sequence, Options{Window: 50})
+	require.Len(t, windows, 3)
+	assert.Equal(t, Window{Start: 0, End: 50, GC: 1, GCSkew: 1}, windows[0])
+	assert.Equal(t, Window{Start: 50, End: 100, GC: 0, GCSkew: 0, Entropy: 1}, windows[1])
+	assert.Equal(t, Window{Start: 100, End: 150, GC: 1, GCSkew: -1}, windows[2])
+
+	assert.Len(t, Profile(sequence, Options{}), 1)
+	assert.Len(t, Profile(sequence, Options{Window: 50, Step: 10}), 11)
+	circular := Profile(sequence, Options{Window: 50, Step: 10, Circular: true})
+	require.Len(t, circular, 15)
+	assert.Equal(t, 190, circular[14].End)
+	assert.InDelta(t, 0.6, circular[14].GCSkew, 1e-9) // 10 Cs and 40 Gs.
+}
+
+func TestDustScore(t *testing.T) {
+	// a homopolymer of n bases has n-2 identical triplets.
+	assert.InDelta(t, 31.0, DustScore(strings.Repeat("A", 64)), 1e-9)
+	unique, _ := random.DNASequence(64, 1)
+	assert.Less(t, DustScore(unique), 2.0)
+	assert.Equal(t, 0.0, DustScore("AC"))
+	assert.Equal(t, 0.0, DustScore(strings.Repeat("N", 64)))
+}
+
+func TestMask(t *testing.T) {
+	left, _ := random.DNASequence(300, 2)
+	middle, _ := random.DNASequence(300, 3)
+	right, _ := random.DNASequence(300, 4)
+	repeat := strings.Repeat("CA", 40)
+	sequence := left + repeat + middle + strings.Repeat("N", 100) + right
+
+	masked := Mask(sequence, DustOptions{})
+	require.Len(t, masked, 1)
+	// the repeat's edges can run a base or two into the random sequence.
+	assert.InDelta(t, 300, masked[0].Start, 3)
+	assert.InDelta(t, 380, masked[0].End, 3)
+	assert.Greater(t, masked[0].Value, 2.0)
+
+	softMasked := SoftMask(sequence, masked)
+	assert.Contains(t, softMasked, strings.ToLower(repeat[4:76]))
+	assert.Equal(t, strings.ToUpper(sequence[:290]), softMasked[:290])
+
+	unique, _ := random.DNASequence(2000, 5)
+	assert.Empty(t, Mask(unique, DustOptions{}))
+	assert.Empty(t, Mask(sequence, DustOptions{Threshold: 100}))
+	assert.Empty(t, Mask("AAAA", DustOptions{}))
+
+	summary := Summarize(sequence)
+	assert.Equal(t, len(sequence), summary.Length)
+	assert.Equal(t, 100, summary.Ambiguous)
+	assert.Equal(t, masked[0].End-masked[0].Start, summary.LowComplexity)
+}
@@ -0,0 +1,326 @@
+/*
+Package barcode designs barcoded construct libraries for pooled screens.
+
+In a pooled screen every variant of a construct goes into the same tube, so
+the only way to tell afterwards which cells got which variant is to sequence
+something short that identifies it: a barcode. Barcodes are cheap to read,
+but a sequencer still makes mistakes, so a good barcode set keeps every two
+barcodes a few substitutions apart. That way a misread barcode is either
+correctable or at least doesn't turn into a different variant.
+
+This package draws barcodes at random, keeps the ones that are far enough from
+every barcode already kept, have a reasonable GC content, and don't have long
+homopolymers, and puts them into each variant. A barcode can also create a
+restriction site at its junctions with the construct, which would wreck any
+cloning downstream, so barcodes that add new sites of the given enzymes are
+thrown out too. The result comes with a barcode to variant table, which is
+what you need to deconvolute the sequencing reads.
+
+If you'd rather have barcodes that never share a substring, have a look at
+primers.CreateBarcodes instead.
+*/
+package barcode
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/bebop/poly/checks"
+	"github.com/bebop/poly/clone"
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/search/align"
+)
+
+// Placement is where a barcode goes in its variant.
+type Placement int
+
+// Places a barcode can go.
+const (
+	Placeholder Placement = iota // replaces the first run of N's in the variant, which must be the barcode's length.
+	Prepend                      // at the 5' end of the variant.
+	Append                       // at the 3' end of the variant.
+)
+
+// Options changes how barcodes are designed. Zero values are replaced with
+// the defaults noted on each field.
+type Options struct {
+	Placement          Placement
+	Length             int // barcode length. Defaults to 12, or the length of the placeholder.
+	BarcodesPerVariant int // defaults to 1.
+	// MinDistance is the fewest substitutions between any two barcodes.
+	// Barcodes at distance d can have (d-1)/2 errors corrected. Defaults to 3.
+	MinDistance int
+	// MinGC and MaxGC bound the GC content of every barcode. Default to 0.25
+	// and 0.75.
+	MinGC, MaxGC float64
+	// MaxHomopolymer is the longest run of a single base allowed in a
+	// barcode. Defaults to 3.
+	MaxHomopolymer int
+	// Enzymes whose sites a barcode can't add to its construct, on either
+	// strand, including across the barcode's junctions.
+	Enzymes []clone.Enzyme
+	Seed    int64
+}
+
+// Construct is a single barcoded variant.
+type Construct struct {
+	Name     string // variant name, then the barcode's number for the variant.
+	Variant  string // name of the variant.
+	Barcode  string
+	Position int // 0-based start of the barcode in Sequence.
+	Sequence string
+}
+
+// maxAttempts is how many random barcodes are drawn per barcode needed
+// before giving up.
+const maxAttempts = 1000
+
+// Generate makes a barcoded construct for every barcode of every variant.
+// Every barcode is unique across the whole library.
+func Generate(variants []fasta.Fasta, options Options) ([]Construct, error) {
+	if options.BarcodesPerVariant == 0 {
+		options.BarcodesPerVariant = 1
+	}
+	if options.MinDistance == 0 {
+		options.MinDistance = 3
+	}
+	if options.MinGC == 0 && options.MaxGC == 0 {
+		options.MinGC, options.MaxGC = 0.25, 0.75
+	}
+	if options.MaxHomopolymer == 0 {
+		options.MaxHomopolymer = 3
+	}
+
+	// work out where every barcode goes before drawing any of them.
+	type slot struct {
+		before, after string
+	}
+	slots := make([]slot, len(variants))
+	for index, variant := range variants {
+		sequence := strings.ToUpper(variant.Sequence)
+		switch options.Placement {
+		case Placeholder:
+			start := strings.IndexByte(sequence, 'N')
+			if start == -1 {
+				return nil, fmt.Errorf("variant %s has no run of N's to put a barcode in", variant.Name)
+			}
+			end := start
+			for end < len(sequence) && sequence[end] == 'N' {
+				end++
+			}
+			if options.Length == 0 {
+				options.Length = end - start
+			}
+			if end-start != options.Length {
+				return nil, fmt.Errorf("variant %s has a run of %d N's, expected %d", variant.Name, end-start, options.Length)
+			}
+			slots[index] = slot{sequence[:start], sequence[end:]}
+		case Prepend:
+			slots[index] = slot{"", sequence}
+		case Append:
+			slots[index] = slot{sequence, ""}
+		default:
+			return nil, fmt.Errorf("unknown placement %d", options.Placement)
+		}
+	}
+	if options.Length == 0 {
+		options.Length = 12
+	}
+	if options.MinDistance > options.Length {
+		return nil, fmt.Errorf("barcodes of length %d can't be %d substitutions apart", options.Length, options.MinDistance)
+	}
+
+	random := rand.New(rand.NewSource(options.Seed))
+	set := newBarcodeSet(options.Length, options.MinDistance)
+	var constructs []Construct
+	for index, variant := range variants {
+		before, after := slots[index].before, slots[index].after
+		for number := 1; number <= options.BarcodesPerVariant; number++ {
+			found := false
+			for attempt := 0; attempt < maxAttempts && !found; attempt++ {
+				barcode := randomBarcode(random, options.Length)
+				if !goodBarcode(barcode, options) || !set.fits(barcode) {
+					continue
+				}
+				if addsSites(before, barcode, after, options.Enzymes) {
+					continue
+				}
+				set.add(barcode)
+				constructs = append(constructs, Construct{
+					Name:     variant.Name + "_" + strconv.Itoa(number),
+					Variant:  variant.Name,
+					Barcode:  barcode,
+					Position: len(before),
+					Sequence: before + barcode + after,
+				})
+				found = true
+			}
+			if !found {
+				return nil, fmt.Errorf("couldn't find barcode %d of variant %s in %d tries, try longer barcodes or looser constraints", number, variant.Name, maxAttempts)
+			}
+		}
+	}
+	return constructs, nil
+}
+
+// randomBarcode draws a uniformly random DNA sequence.
+func randomBarcode(random *rand.Rand, length int) string {
+	barcode := make([]byte, length)
+	for index := range barcode {
+		barcode[index] = "ACGT"[random.Intn(4)]
+	}
+	return string(barcode)
+}
+
+// goodBarcode checks a barcode's GC content and homopolymers.
+func goodBarcode(barcode string, options Options) bool {
+	gc := checks.GcContent(barcode)
+	if gc < options.MinGC || gc > options.MaxGC {
+		return false
+	}
+	run := 1
+	for index := 1; index < len(barcode); index++ {
+		if barcode[index] == barcode[index-1] {
+			run++
+		} else {
+			run = 1
+		}
+		if run > options.MaxHomopolymer {
+			return false
+		}
+	}
+	return true
+}
+
+// addsSites checks if putting a barcode between before and after makes a
+// site of any of enzymes, on either strand. The barcode is new sequence, so
+// every site overlapping it is new.
+func addsSites(before, barcode, after string, enzymes []clone.Enzyme) bool {
+	sequence := before + barcode + after
+	for _, enzyme := range enzymes {
+		siteLength := len(enzyme.RecognitionSite)
+		for position := max(0, len(before)-siteLength+1); position < len(before)+len(barcode) && position+siteLength <= len(sequence); position++ {
+			window := sequence[position : position+siteLength]
+			if enzyme.RegexpFor.MatchString(window) || enzyme.RegexpRev.MatchString(window) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/******************************************************************************
+
+Barcode distance checking begins here.
+
+Checking every new barcode against every barcode already picked is fine for a
+few hundred, but a pooled library can have a hundred thousand. The trick is
+the pigeonhole principle: split a barcode into d blocks, and any barcode
+fewer than d substitutions away has to match it exactly in at least one
+block. So every barcode is filed under each of its blocks, and a new barcode
+only has to be compared against the barcodes that share a block with it.
+
+******************************************************************************/
+
+// barcodeSet is a set of barcodes that are all at least a minimum Hamming
+// distance apart.
+type barcodeSet struct {
+	minDistance int
+	blocks      [][2]int              // start and end of every block.
+	index       []map[string][]string // barcodes by the sequence of each block.
+	barcodes    map[string]struct{}
+}
+
+func newBarcodeSet(length, minDistance int) *barcodeSet {
+	set := &barcodeSet{minDistance: minDistance, barcodes: make(map[string]struct{})}
+	for block := 0; block < minDistance; block++ {
+		set.blocks = append(set.blocks, [2]int{block * length / minDistance, (block + 1) * length / minDistance})
+		set.index = append(set.index, make(map[string][]string))
+	}
+	return set
+}
+
+// fits checks if a barcode is far enough from every barcode in the set.
+func (set *barcodeSet) fits(barcode string) bool {
+	if _, ok := set.barcodes[barcode]; ok {
+		return false
+	}
+	for block, bounds := range set.blocks {
+		for _, other := range set.index[block][barcode[bounds[0]:bounds[1]]] {
+			if align.Hamming(barcode, other) < set.minDistance {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (set *barcodeSet) add(barcode string) {
+	set.barcodes[barcode] = struct{}{}
+	for block, bounds := range set.blocks {
+		key := barcode[bounds[0]:bounds[1]]
+		set.index[block][key] = append(set.index[block][key], barcode)
+	}
+}
+
+/******************************************************************************
+
+Barcode tables begin here.
+
+******************************************************************************/
+
+// Table maps every barcode of a library to the name of its variant.
+func Table(constructs []Construct) map[string]string {
+	table := make(map[string]string, len(constructs))
+	for _, construct := range constructs {
+		table[construct.Barcode] = construct.Variant
+	}
+	return table
+}
+
+// WriteTable writes a library's barcode to variant table as CSV, with a
+// header, in library order.
+func WriteTable(w io.Writer, constructs []Construct) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"barcode", "variant", "construct", "position"}); err != nil {
+		return err
+	}
+	for _, construct := range constructs {
+		if err := writer.Write([]string{construct.Barcode, construct.Variant, construct.Name, strconv.Itoa(construct.Position)}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Decode finds the barcode closest to an observed one, correcting up to
+// maxErrors substitutions. It fails if there's no barcode that close, or if
+// there's a tie.
+func Decode(table map[string]string, observed string, maxErrors int) (barcode string, variant string, ok bool) {
+	observed = strings.ToUpper(observed)
+	if variant, ok := table[observed]; ok {
+		return observed, variant, true
+	}
+	best, bestDistance, tied := "", maxErrors+1, false
+	for candidate := range table {
+		if len(candidate) != len(observed) {
+			continue
+		}
+		distance := align.Hamming(candidate, observed)
+		switch {
+		case distance < bestDistance:
+			best, bestDistance, tied = candidate, distance, false
+		case distance == bestDistance:
+			tied = true
+		}
+	}
+	if best == "" || tied {
+		return "", "", false
+	}
+	return best, table[best], true
+}
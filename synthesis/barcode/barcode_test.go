@@ -0,0 +1,132 @@
+package barcode
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/checks"
+	"github.com/bebop/poly/clone"
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/search/align"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	// a BsaI site is one base away on the left of the barcode.
+	var variants []fasta.Fasta
+	for index := 0; index < 200; index++ {
+		variants = append(variants, fasta.Fasta{Name: fmt.Sprintf("variant%d", index), Sequence: "atgGGTCTnnnnnnnnnnnnGAGACCtaa"})
+	}
+	enzymes := clone.GetBaseRestrictionEnzymes()
+	constructs, err := Generate(variants, Options{BarcodesPerVariant: 2, Enzymes: enzymes, Seed: 1})
+	require.NoError(t, err)
+	require.Len(t, constructs, 400)
+
+	assert.Equal(t, "variant0_1", constructs[0].Name)
+	assert.Equal(t, "variant0_2", constructs[1].Name)
+	assert.Equal(t, "variant199", constructs[399].Variant)
+	for _, construct := range constructs {
+		assert.Equal(t, 8, construct.Position)
+		assert.Equal(t, construct.Barcode, construct.Sequence[8:20])
+		assert.Equal(t, "ATGGGTCT"+construct.Barcode+"GAGACCTAA", construct.Sequence)
+		gc := checks.GcContent(construct.Barcode)
+		assert.True(t, gc >= 0.25 && gc <= 0.75, construct.Barcode)
+		for _, base := range "ACGT" {
+			assert.NotContains(t, construct.Barcode, strings.Repeat(string(base), 4))
+		}
+		// the only BsaI site is the one that was already there.
+		assert.Equal(t, 1, strings.Count(construct.Sequence, "GAGACC"))
+		assert.Equal(t, 0, strings.Count(construct.Sequence, "GGTCTC"))
+	}
+	for first := range constructs {
+		for second := first + 1; second < len(constructs); second++ {
+			assert.GreaterOrEqual(t, align.Hamming(constructs[first].Barcode, constructs[second].Barcode), 3)
+		}
+	}
+
+	// the same seed gives the same library.
+	again, err := Generate(variants, Options{BarcodesPerVariant: 2, Enzymes: enzymes, Seed: 1})
+	require.NoError(t, err)
+	assert.Equal(t, constructs, again)
+}
+
+func TestGeneratePlacement(t *testing.T) {
+	variants := []fasta.Fasta{{Name: "gfp", Sequence: "ATGGTGAGCAAG"}}
+	constructs, err := Generate(variants, Options{Placement: Prepend, Length: 8})
+	require.NoError(t, err)
+	assert.Equal(t, 0, constructs[0].Position)
+	assert.Equal(t, constructs[0].Barcode+"ATGGTGAGCAAG", constructs[0].Sequence)
+	assert.Len(t, constructs[0].Barcode, 8)
+
+	constructs, err = Generate(variants, Options{Placement: Append})
+	require.NoError(t, err)
+	assert.Equal(t, 12, constructs[0].Position)
+	assert.Equal(t, "ATGGTGAGCAAG"+constructs[0].Barcode, constructs[0].Sequence)
+	assert.Len(t, constructs[0].Barcode, 12)
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		variants []fasta.Fasta
+		options  Options
+	}{
+		{"no placeholder", []fasta.Fasta{{Name: "a", Sequence: "ACGT"}}, Options{}},
+		{"placeholder length", []fasta.Fasta{{Name: "a", Sequence: "ANNNNT"}}, Options{Length: 6}},
+		{"uneven placeholders", []fasta.Fasta{{Name: "a", Sequence: "ANNNNNT"}, {Name: "b", Sequence: "ANNNNNNT"}}, Options{}},
+		{"unknown placement", []fasta.Fasta{{Name: "a", Sequence: "ACGT"}}, Options{Placement: 5}},
+		{"distance longer than barcode", []fasta.Fasta{{Name: "a", Sequence: "ACGT"}}, Options{Placement: Append, Length: 4, MinDistance: 5}},
+		// there aren't 100 4 base barcodes 3 substitutions apart.
+		{"too many barcodes", []fasta.Fasta{{Name: "a", Sequence: "ACGT"}}, Options{Placement: Append, Length: 4, BarcodesPerVariant: 100}},
+	}
+	for _, test := range tests {
+		_, err := Generate(test.variants, test.options)
+		assert.Error(t, err, test.name)
+	}
+}
+
+func TestBarcodeSet(t *testing.T) {
+	set := newBarcodeSet(8, 3)
+	set.add("ACGTACGT")
+	assert.False(t, set.fits("ACGTACGT"))
+	assert.False(t, set.fits("ACGTACGA"))
+	assert.False(t, set.fits("TCGTACGA"))
+	assert.True(t, set.fits("TCGTACAA"))
+	// two substitutions away.
+	assert.False(t, set.fits("ACGTACGT"[:2]+"CC"+"ACGT"[:2]+"GT"))
+}
+
+func TestTables(t *testing.T) {
+	constructs := []Construct{
+		{Name: "gfp_1", Variant: "gfp", Barcode: "ACGTACGTACGT", Position: 3},
+		{Name: "rfp_1", Variant: "rfp", Barcode: "TTGGCCAATTGG", Position: 3},
+	}
+	table := Table(constructs)
+	assert.Equal(t, map[string]string{"ACGTACGTACGT": "gfp", "TTGGCCAATTGG": "rfp"}, table)
+
+	var buffer bytes.Buffer
+	require.NoError(t, WriteTable(&buffer, constructs))
+	assert.Equal(t, "barcode,variant,construct,position\nACGTACGTACGT,gfp,gfp_1,3\nTTGGCCAATTGG,rfp,rfp_1,3\n", buffer.String())
+
+	barcode, variant, ok := Decode(table, "acgtacgtacgt", 1)
+	assert.True(t, ok)
+	assert.Equal(t, "ACGTACGTACGT", barcode)
+	assert.Equal(t, "gfp", variant)
+
+	barcode, variant, ok = Decode(table, "TTGGCCAATTGC", 1)
+	assert.True(t, ok)
+	assert.Equal(t, "TTGGCCAATTGG", barcode)
+	assert.Equal(t, "rfp", variant)
+
+	_, _, ok = Decode(table, "TTGGCCAATTCC", 1)
+	assert.False(t, ok)
+	_, _, ok = Decode(table, "ACGT", 1)
+	assert.False(t, ok)
+
+	// equally close to two barcodes.
+	_, _, ok = Decode(map[string]string{"AAAA": "a", "AATT": "b"}, "AAAT", 1)
+	assert.False(t, ok)
+}
@@ -0,0 +1,26 @@
+package barcode_test
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bebop/poly/clone"
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/synthesis/barcode"
+)
+
+func ExampleGenerate() {
+	variants := []fasta.Fasta{
+		{Name: "promoter1", Sequence: "TTGACAATTAATCATCGGCTCGTATAATGTGTGGNNNNNNNNNNNN"},
+		{Name: "promoter2", Sequence: "TTTACAGCTAGCTCAGTCCTAGGTATAATGCTAGCNNNNNNNNNNNN"},
+	}
+	constructs, _ := barcode.Generate(variants, barcode.Options{Enzymes: clone.GetBaseRestrictionEnzymes(), Seed: 7})
+	for _, construct := range constructs {
+		fmt.Println(construct.Name, len(construct.Barcode), construct.Position)
+	}
+	_ = barcode.WriteTable(os.Stdout, constructs[:0])
+	// Output:
+	// promoter1_1 12 34
+	// promoter2_1 12 35
+	// barcode,variant,construct,position
+}
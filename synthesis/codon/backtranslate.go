@@ -0,0 +1,76 @@
+package codon
+
+import (
+	"math/rand"
+	"strings"
+
+	"github.com/bebop/poly/geneticcode"
+)
+
+/******************************************************************************
+
+Back-translation begins here.
+
+BackTranslate is the general form of Optimize, for when the DNA is a means to
+an end rather than a gene to express, like a library or a degenerate primer.
+It samples one codon per amino acid by the table's weights, always from the
+same seed so the same protein gives the same DNA, or writes every codon the
+host uses at once with IUPAC codes. Codons the table weighs at 0, like the
+rare codons CompromiseCodonTable cuts off, are left out of both.
+
+geneticcode's Code.BackTranslate does the same degenerate back-translation
+with every codon of a code, weighted or not.
+
+******************************************************************************/
+
+// BackTranslateOptions changes how BackTranslate picks codons.
+type BackTranslateOptions struct {
+	// Degenerate writes every codon an amino acid has weight on with IUPAC
+	// codes, rather than sampling one.
+	Degenerate bool
+	// Seed seeds the sampling of codons.
+	Seed int64
+}
+
+// BackTranslate returns DNA encoding a protein, with codons sampled by the
+// table's weights or, with options.Degenerate, all of them written with IUPAC
+// codes.
+func (table *TranslationTable) BackTranslate(protein string, options BackTranslateOptions) (string, error) {
+	protein = strings.ToUpper(protein)
+	if len(protein) == 0 {
+		return "", errEmptyAminoAcidString
+	}
+	var dna strings.Builder
+	if options.Degenerate {
+		degenerateCodons := make(map[string]string)
+		for _, aminoAcid := range table.AminoAcids {
+			var weighted []string
+			for _, codon := range aminoAcid.Codons {
+				if codon.Weight > 0 {
+					weighted = append(weighted, strings.ToUpper(codon.Triplet))
+				}
+			}
+			if len(weighted) > 0 {
+				degenerateCodons[aminoAcid.Letter] = geneticcode.Degenerate(weighted)
+			}
+		}
+		for _, aminoAcid := range protein {
+			degenerate, ok := degenerateCodons[string(aminoAcid)]
+			if !ok {
+				return "", invalidAminoAcidError{aminoAcid}
+			}
+			dna.WriteString(degenerate)
+		}
+		return dna.String(), nil
+	}
+
+	random := rand.New(rand.NewSource(options.Seed))
+	for _, aminoAcid := range protein {
+		chooser, ok := table.Choosers[string(aminoAcid)]
+		if !ok {
+			return "", invalidAminoAcidError{aminoAcid}
+		}
+		dna.WriteString(chooser.PickSource(random).(string))
+	}
+	return dna.String(), nil
+}
@@ -0,0 +1,54 @@
+package codon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackTranslate(t *testing.T) {
+	table, err := NewTranslationTable(11)
+	require.NoError(t, err)
+	protein := "MASKGEELFTGVVPILVELDGDVNGHKFSV*"
+
+	dna, err := table.BackTranslate(protein, BackTranslateOptions{Seed: 4})
+	require.NoError(t, err)
+	translated, err := table.Translate(dna)
+	require.NoError(t, err)
+	assert.Equal(t, protein, translated)
+	// the same seed samples the same codons.
+	again, err := table.BackTranslate(protein, BackTranslateOptions{Seed: 4})
+	require.NoError(t, err)
+	assert.Equal(t, dna, again)
+
+	degenerate, err := table.BackTranslate("MAW*", BackTranslateOptions{Degenerate: true})
+	require.NoError(t, err)
+	assert.Equal(t, "ATGGCNTGGTRR", degenerate)
+
+	// codons without weight are left out.
+	for aminoAcidIndex, aminoAcid := range table.AminoAcids {
+		if aminoAcid.Letter != "A" {
+			continue
+		}
+		for codonIndex, codon := range aminoAcid.Codons {
+			if codon.Triplet != "GCC" {
+				table.AminoAcids[aminoAcidIndex].Codons[codonIndex].Weight = 0
+			}
+		}
+	}
+	require.NoError(t, table.UpdateWeights(table.AminoAcids))
+	degenerate, err = table.BackTranslate("MA", BackTranslateOptions{Degenerate: true})
+	require.NoError(t, err)
+	assert.Equal(t, "ATGGCC", degenerate)
+	sampled, err := table.BackTranslate("AAAA", BackTranslateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "GCCGCCGCCGCC", sampled)
+
+	_, err = table.BackTranslate("MAB", BackTranslateOptions{})
+	assert.Error(t, err)
+	_, err = table.BackTranslate("MAB", BackTranslateOptions{Degenerate: true})
+	assert.Error(t, err)
+	_, err = table.BackTranslate("", BackTranslateOptions{})
+	assert.Error(t, err)
+}
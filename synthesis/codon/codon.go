@@ -26,7 +26,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/geneticcode"
+	"github.com/bebop/poly/window"
 	weightedRand "github.com/mroth/weightedrand"
 )
 
@@ -48,7 +49,11 @@ File is structured as so:
 
     TranslationTable.Optimize - will return a set of codons which can be used to encode the given amino acid sequence. The codons picked are weighted according to the computed translation table's weights
 
-    TranslationTable.UpdateWeightsWithSequence - will look at the coding regions in the given genbank data, and use those to generate new weights for the codons in the translation table. The next time a sequence is optimised, it will use those updated weights.
+    TranslationTable.OptimizeConstrained - like Optimize, but searches for the best codons that keep forbidden motifs, long homopolymers, extreme GC content, and 5' mRNA structure out of the sequence. See constrained.go.
+
+    TranslationTable.CodonAdaptationIndex, TRNAAdaptationIndex, and EffectiveNumberOfCodons - score how well a coding sequence fits a host. See metrics.go.
+
+    TranslationTable.UpdateWeightsWithSequence - will look at the coding regions in the given genbank data, and use those to generate new weights for the codons in the translation table. The next time a sequence is optimised, it will use those updated weights. See genbank.go.
 
 		TranslationTable.Stats - a set of statistics we maintain throughout the translation table's lifetime. For example we track the start codons observed when we update the codon table's weights with other DNA sequences
 ******************************************************************************/
@@ -225,33 +230,6 @@ func (table *TranslationTable) UpdateWeights(aminoAcids []AminoAcid) error {
 	return nil
 }
 
-// UpdateWeightsWithSequence will look at the coding regions in the given genbank data, and use those to generate new
-// weights for the codons in the translation table. The next time a sequence is optimised, it will use those updated
-// weights.
-//
-// This can be used to, for example, figure out which DNA sequence is needed to give the best yield of protein when
-// trying to express a protein across different species
-func (table *TranslationTable) UpdateWeightsWithSequence(data genbank.Genbank) error {
-	codingRegions, err := extractCodingRegion(data)
-	if err != nil {
-		return err
-	}
-
-	table.Stats.GeneCount = len(codingRegions)
-	for _, sequence := range codingRegions {
-		table.Stats.StartCodonCount[sequence[:3]]++
-	}
-
-	if len(codingRegions) == 0 {
-		return errNoCodingRegions
-	}
-
-	// weight our codon optimization table using the regions we collected from the genbank file above
-	newWeights := weightAminoAcids(strings.Join(codingRegions, ""), table.AminoAcids)
-
-	return table.UpdateWeights(newWeights)
-}
-
 // Translate will return an amino acid sequence which the given DNA will yield
 func (table *TranslationTable) Translate(dnaSeq string) (string, error) {
 	if dnaSeq == "" {
@@ -294,55 +272,13 @@ func weightAminoAcids(sequence string, aminoAcids []AminoAcid) []AminoAcid {
 	return aminoAcids
 }
 
-// extractCodingRegion loops through genbank data to find all CDS (coding sequences)
-func extractCodingRegion(data genbank.Genbank) ([]string, error) {
-	codingRegions := []string{}
-
-	// iterate through the features of the genbank file and if the feature is a coding region, append the sequence to the string builder
-	for _, feature := range data.Features {
-		if feature.Type == "CDS" {
-			sequence, err := feature.GetSequence()
-			if err != nil {
-				return nil, err
-			}
-
-			// Note: sometimes, genbank files will have annotated CDSs that are pseudo genes (not having triplet codons).
-			// This will shift the entire codon table, messing up the end results. To fix this, make sure to do a modulo
-			// check.
-			if len(sequence)%3 != 0 {
-				continue
-			}
-
-			codingRegions = append(codingRegions, sequence)
-		}
-	}
-
-	return codingRegions, nil
-}
-
 // getCodonFrequency takes a DNA sequence and returns a hashmap of its codons and their frequencies.
 func getCodonFrequency(sequence string) map[string]int {
 	codonFrequencyHashMap := map[string]int{}
-	var currentCodon strings.Builder
-
-	for _, letter := range sequence {
-		// add current nucleotide to currentCodon
-		currentCodon.WriteRune(letter)
 
-		// if current nucleotide is the third in a codon add to hashmap
-		if currentCodon.Len() == 3 {
-			// if codon is already initialized in map increment
-			if _, ok := codonFrequencyHashMap[currentCodon.String()]; ok {
-				codonString := currentCodon.String()
-				codonFrequencyHashMap[codonString]++
-				// if codon is not already initialized in map initialize with value at 1
-			} else {
-				codonString := currentCodon.String()
-				codonFrequencyHashMap[codonString] = 1
-			}
-			// reset codon string builder for next codon.
-			currentCodon.Reset()
-		}
+	codons := window.Codons(sequence, 0)
+	for codons.Next() {
+		codonFrequencyHashMap[codons.Window()]++
 	}
 	return codonFrequencyHashMap
 }
@@ -475,36 +411,20 @@ func generateCodonTable(aminoAcids, starts string) (*TranslationTable, error) {
 
 // NewTranslationTable takes the index of desired NCBI codon table and returns it.
 func NewTranslationTable(index int) (*TranslationTable, error) {
-	return generateCodonTable(translationTablesByNumber[index][0], translationTablesByNumber[index][1])
+	code, err := geneticcode.Get(index)
+	if err != nil {
+		return nil, err
+	}
+	return NewTranslationTableFromCode(code)
 }
 
-// translationTablesByNumber stores all data necessary to generate codon tables from sequences published by NCBI https://www.ncbi.nlm.nih.gov/Taxonomy/Utils/wprintgc.cgi using numbered indices.
-var translationTablesByNumber = map[int][]string{
-	1:  {"FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "---M------**--*----M---------------M----------------------------"},
-	2:  {"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSS**VVVVAAAADDEEGGGG", "----------**--------------------MMMM----------**---M------------"},
-	3:  {"FFLLSSSSYY**CCWWTTTTPPPPHHQQRRRRIIMMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------**----------------------MM---------------M------------"},
-	4:  {"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--MM------**-------M------------MMMM---------------M------------"},
-	5:  {"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSSSVVVVAAAADDEEGGGG", "---M------**--------------------MMMM---------------M------------"},
-	6:  {"FFLLSSSSYYQQCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--------------*--------------------M----------------------------"},
-	9:  {"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG", "----------**-----------------------M---------------M------------"},
-	10: {"FFLLSSSSYY**CCCWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------**-----------------------M----------------------------"},
-	11: {"FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "---M------**--*----M------------MMMM---------------M------------"},
-	12: {"FFLLSSSSYY**CC*WLLLSPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------**--*----M---------------M----------------------------"},
-	13: {"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSGGVVVVAAAADDEEGGGG", "---M------**----------------------MM---------------M------------"},
-	14: {"FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG", "-----------*-----------------------M----------------------------"},
-	16: {"FFLLSSSSYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------*---*--------------------M----------------------------"},
-	21: {"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNNKSSSSVVVVAAAADDEEGGGG", "----------**-----------------------M---------------M------------"},
-	22: {"FFLLSS*SYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "------*---*---*--------------------M----------------------------"},
-	23: {"FF*LSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--*-------**--*-----------------M--M---------------M------------"},
-	24: {"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG", "---M------**-------M---------------M---------------M------------"},
-	25: {"FFLLSSSSYY**CCGWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "---M------**-----------------------M---------------M------------"},
-	26: {"FFLLSSSSYY**CC*WLLLAPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------**--*----M---------------M----------------------------"},
-	27: {"FFLLSSSSYYQQCCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--------------*--------------------M----------------------------"},
-	28: {"FFLLSSSSYYQQCCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------**--*--------------------M----------------------------"},
-	29: {"FFLLSSSSYYYYCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--------------*--------------------M----------------------------"},
-	30: {"FFLLSSSSYYEECC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--------------*--------------------M----------------------------"},
-	31: {"FFLLSSSSYYEECCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------**-----------------------M----------------------------"},
-	33: {"FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG", "---M-------*-------M---------------M---------------M------------"},
+// NewTranslationTableFromCode returns a translation table that reads codons
+// with a genetic code, like a recoded one made with geneticcode.Reassign.
+func NewTranslationTableFromCode(code geneticcode.Code) (*TranslationTable, error) {
+	if len(code.AminoAcids) != 64 || len(code.Starts) != 64 {
+		return nil, fmt.Errorf("genetic code %q doesn't have 64 codons", code.Name)
+	}
+	return generateCodonTable(code.AminoAcids, code.Starts)
 }
 
 /******************************************************************************
@@ -0,0 +1,266 @@
+package codon
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/bebop/poly/alphabet"
+	"github.com/bebop/poly/fold"
+	"github.com/bebop/poly/transform"
+)
+
+/******************************************************************************
+
+Constrained optimization begins here.
+
+Optimize samples codons by how often the host uses them, which is usually what
+you want, but it doesn't look at the sequence it makes. Now and then it puts a
+BsaI site in the middle of a part meant for Golden Gate, a run of eight As, or
+a stretch of 80% GC the synthesis company won't touch, and the fix package has
+to go back and patch it.
+
+OptimizeConstrained builds the sequence so it never has those problems in the
+first place. It's a beam search: codons are added one amino acid at a time,
+every partial sequence that breaks a hard constraint is dropped, and of the
+rest only the BeamWidth with the best codon usage are kept going. Codon usage
+is scored like the codon adaptation index, by the log of each codon's weight
+relative to the most used codon for its amino acid.
+
+Hard constraints are forbidden motifs on either strand, like restriction
+sites or splice donors (SpliceDonor), the longest homopolymer allowed, and
+limits on GC content in every window. Structure near the 5' end of an mRNA
+slows translation initiation, so the start of the sequence can be folded too,
+with its free energy taken off the score as a penalty.
+
+******************************************************************************/
+
+// SpliceDonor is the core of the consensus 5' splice site, GT followed by
+// RAGT, which is worth keeping out of genes expressed in eukaryotes.
+const SpliceDonor = "GTRAGT"
+
+// Constraints are limits on the sequences OptimizeConstrained makes. Zero
+// values turn a constraint off unless a default is noted.
+type Constraints struct {
+	// Forbidden are motifs, which can have IUPAC ambiguity codes, that can't
+	// appear on either strand.
+	Forbidden []string
+	// MaxHomopolymer is the longest run of a single base allowed.
+	MaxHomopolymer int
+	// MinGC and MaxGC are limits on the GC content of every GCWindow bases,
+	// as fractions. Sequences shorter than GCWindow are held to them as a
+	// whole.
+	MinGC, MaxGC float64
+	// GCWindow defaults to 50 bases if MinGC or MaxGC is set.
+	GCWindow int
+	// FivePrimeWindow is how many bases at the start of the sequence are
+	// folded as mRNA to penalize structure there.
+	FivePrimeWindow int
+	// StructurePenalty is how much score a kcal/mol of 5' structure costs.
+	// Defaults to 1 if FivePrimeWindow is set.
+	StructurePenalty float64
+	// Temperature is what the 5' end is folded at, in Celsius. Defaults
+	// to 37.
+	Temperature float64
+	// BeamWidth is how many partial sequences are kept at every step. Wider
+	// beams find better sequences more slowly. Defaults to 32.
+	BeamWidth int
+}
+
+var errConstraintsUnsatisfiable = errors.New("no sequence satisfies the constraints")
+
+// candidate is a partial sequence in the beam.
+type candidate struct {
+	sequence string
+	score    float64
+}
+
+// OptimizeConstrained returns a sequence encoding aminoAcids that satisfies
+// constraints, with the best codon usage the search finds.
+func (table *TranslationTable) OptimizeConstrained(aminoAcids string, constraints Constraints) (string, error) {
+	aminoAcids = strings.ToUpper(aminoAcids)
+	if len(aminoAcids) == 0 {
+		return "", errEmptyAminoAcidString
+	}
+	constraints = constraints.withDefaults()
+	forbidden := constraints.forbiddenMotifs()
+
+	codonScores := make(map[string]map[string]float64)
+	for _, aminoAcid := range table.AminoAcids {
+		maxWeight := 0
+		for _, codon := range aminoAcid.Codons {
+			maxWeight = max(maxWeight, codon.Weight)
+		}
+		scores := make(map[string]float64)
+		for _, codon := range aminoAcid.Codons {
+			// codons that are never used aren't used here either.
+			if codon.Weight > 0 {
+				scores[codon.Triplet] = math.Log(float64(codon.Weight) / float64(maxWeight))
+			}
+		}
+		codonScores[aminoAcid.Letter] = scores
+	}
+
+	beam := []candidate{{}}
+	for index, aminoAcid := range aminoAcids {
+		scores, ok := codonScores[string(aminoAcid)]
+		if !ok || len(scores) == 0 {
+			return "", invalidAminoAcidError{aminoAcid}
+		}
+		var next []candidate
+		for _, partial := range beam {
+			for triplet, score := range scores {
+				extended := candidate{sequence: partial.sequence + triplet, score: partial.score + score}
+				if len(constraints.violations(extended.sequence, len(partial.sequence), forbidden, true)) > 0 {
+					continue
+				}
+				// the 5' end is folded once, as soon as it's complete.
+				window := constraints.FivePrimeWindow
+				last := index == len(aminoAcids)-1
+				if window > 0 && ((len(partial.sequence) < window && len(extended.sequence) >= window) || (last && len(extended.sequence) < window)) {
+					energy, err := fivePrimeEnergy(extended.sequence[:min(window, len(extended.sequence))], constraints.Temperature)
+					if err != nil {
+						return "", err
+					}
+					extended.score += constraints.StructurePenalty * energy
+				}
+				next = append(next, extended)
+			}
+		}
+		if len(next) == 0 {
+			return "", fmt.Errorf("%w at amino acid %d (%c)", errConstraintsUnsatisfiable, index+1, aminoAcid)
+		}
+		sort.Slice(next, func(i, j int) bool {
+			if next[i].score != next[j].score {
+				return next[i].score > next[j].score
+			}
+			return next[i].sequence < next[j].sequence
+		})
+		beam = next[:min(len(next), constraints.BeamWidth)]
+	}
+
+	// sequences shorter than a GC window are only checked once they're done.
+	for _, finished := range beam {
+		if len(constraints.violations(finished.sequence, 0, forbidden, false)) == 0 {
+			return finished.sequence, nil
+		}
+	}
+	return "", errConstraintsUnsatisfiable
+}
+
+// Violations returns every way a sequence breaks the constraints, which is
+// useful for checking sequences that weren't made by OptimizeConstrained.
+// 5' structure is a penalty rather than a constraint, so it's never a
+// violation.
+func (constraints Constraints) Violations(sequence string) []string {
+	constraints = constraints.withDefaults()
+	return constraints.violations(strings.ToUpper(sequence), 0, constraints.forbiddenMotifs(), false)
+}
+
+func (constraints Constraints) withDefaults() Constraints {
+	if constraints.GCWindow == 0 && (constraints.MinGC > 0 || constraints.MaxGC > 0) {
+		constraints.GCWindow = 50
+	}
+	if constraints.FivePrimeWindow > 0 && constraints.StructurePenalty == 0 {
+		constraints.StructurePenalty = 1
+	}
+	if constraints.Temperature == 0 {
+		constraints.Temperature = 37
+	}
+	if constraints.BeamWidth == 0 {
+		constraints.BeamWidth = 32
+	}
+	return constraints
+}
+
+// forbiddenMotifs returns the forbidden motifs and their reverse
+// complements.
+func (constraints Constraints) forbiddenMotifs() []string {
+	var motifs []string
+	for _, motif := range constraints.Forbidden {
+		motif = strings.ToUpper(motif)
+		motifs = append(motifs, motif)
+		if reverse := transform.ReverseComplement(motif); reverse != motif {
+			motifs = append(motifs, reverse)
+		}
+	}
+	return motifs
+}
+
+// violations finds the ways a sequence breaks the constraints that end at or
+// after from, since everything before it was already checked. Windows
+// shorter than GCWindow are only checked if the sequence is complete.
+func (constraints Constraints) violations(sequence string, from int, forbidden []string, stopAtFirst bool) []string {
+	var found []string
+	add := func(violation string) bool {
+		found = append(found, violation)
+		return stopAtFirst
+	}
+	for end := max(from+1, 1); end <= len(sequence); end++ {
+		for _, motif := range forbidden {
+			if end >= len(motif) && matchesMotif(sequence[end-len(motif):end], motif) {
+				if add(fmt.Sprintf("forbidden motif %s at %d", motif, end-len(motif))) {
+					return found
+				}
+			}
+		}
+		if constraints.MaxHomopolymer > 0 && end > constraints.MaxHomopolymer {
+			run := sequence[end-constraints.MaxHomopolymer-1 : end]
+			if strings.Count(run, run[:1]) == len(run) {
+				if add(fmt.Sprintf("homopolymer longer than %d at %d", constraints.MaxHomopolymer, end-len(run))) {
+					return found
+				}
+			}
+		}
+		if constraints.GCWindow > 0 && end >= constraints.GCWindow {
+			start := end - constraints.GCWindow
+			if violation := constraints.gcViolation(sequence[start:end], start); violation != "" && add(violation) {
+				return found
+			}
+		}
+	}
+	if !stopAtFirst && constraints.GCWindow > len(sequence) {
+		if violation := constraints.gcViolation(sequence, 0); violation != "" {
+			found = append(found, violation)
+		}
+	}
+	return found
+}
+
+// gcViolation describes how a window's GC content is out of bounds, if it is.
+func (constraints Constraints) gcViolation(window string, start int) string {
+	gc := float64(strings.Count(window, "G")+strings.Count(window, "C")) / float64(len(window))
+	switch {
+	case constraints.MinGC > 0 && gc < constraints.MinGC:
+		return fmt.Sprintf("GC content %.2f below %.2f at %d", gc, constraints.MinGC, start)
+	case constraints.MaxGC > 0 && gc > constraints.MaxGC:
+		return fmt.Sprintf("GC content %.2f above %.2f at %d", gc, constraints.MaxGC, start)
+	}
+	return ""
+}
+
+// matchesMotif is whether every base of sequence is one a motif allows.
+func matchesMotif(sequence, motif string) bool {
+	for index := 0; index < len(motif); index++ {
+		if !strings.ContainsRune(alphabet.Ambiguities(motif[index]), rune(sequence[index])) {
+			return false
+		}
+	}
+	return true
+}
+
+// fivePrimeEnergy is the minimum free energy of a sequence folded as mRNA, or
+// 0 if it doesn't fold.
+func fivePrimeEnergy(sequence string, temperature float64) (float64, error) {
+	result, err := fold.Zuker(alphabet.DNAToRNA(sequence), temperature)
+	if err != nil {
+		return 0, err
+	}
+	energy := result.MinimumFreeEnergy()
+	if math.IsInf(energy, 0) || energy > 0 {
+		return 0, nil
+	}
+	return energy, nil
+}
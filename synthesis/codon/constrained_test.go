@@ -0,0 +1,118 @@
+package codon
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gfpProtein = "MASKGEELFTGVVPILVELDGDVNGHKFSVSGEGEGDATYGKLTLKFICTTGKLPVPWPTLVTTFSYGVQCFSRYPDHMKRHDFFKSAMPEGYVQERTISFKDDGNYKTRAEVKFEGDTLVNRIELKGIDFKEDGNILGHKLEYNYNSHNVYITADKQKNGIKANFKIRHNIEDGSVQLADHYQQNTPIGDGPVLLPDNHYLSTQSALSKDPNEKRDHMVLLEFVTAAGITHGMDELYK*"
+
+// setWeight sets the weight of a codon in a table.
+func setWeight(table *TranslationTable, triplet string, weight int) {
+	for aminoAcidIndex, aminoAcid := range table.AminoAcids {
+		for codonIndex, codon := range aminoAcid.Codons {
+			if codon.Triplet == triplet {
+				table.AminoAcids[aminoAcidIndex].Codons[codonIndex].Weight = weight
+			}
+		}
+	}
+}
+
+func TestOptimizeConstrained(t *testing.T) {
+	table, err := NewTranslationTable(11)
+	require.NoError(t, err)
+	constraints := Constraints{
+		Forbidden:      []string{"GGTCTC", "CGTCTC", "GAATTC", SpliceDonor},
+		MaxHomopolymer: 4,
+		MinGC:          0.4,
+		MaxGC:          0.6,
+	}
+	sequence, err := table.OptimizeConstrained(gfpProtein, constraints)
+	require.NoError(t, err)
+	translation, err := table.Translate(sequence)
+	require.NoError(t, err)
+	assert.Equal(t, gfpProtein, translation)
+	assert.Empty(t, constraints.Violations(sequence))
+}
+
+func TestOptimizeConstrainedCodonUsage(t *testing.T) {
+	table, err := NewTranslationTable(11)
+	require.NoError(t, err)
+	setWeight(table, "GCT", 10)
+
+	// without constraints, the most used codon is always picked.
+	sequence, err := table.OptimizeConstrained("AAAAAA", Constraints{})
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("GCT", 6), sequence)
+
+	// and with them, as often as they allow.
+	sequence, err = table.OptimizeConstrained("AAAAAA", Constraints{Forbidden: []string{"GCTGCTGCT"}})
+	require.NoError(t, err)
+	assert.Equal(t, 4, strings.Count(sequence, "GCT"))
+	assert.NotContains(t, sequence, "GCTGCTGCT")
+
+	// codons that are never used aren't.
+	setWeight(table, "GCC", 0)
+	sequence, err = table.OptimizeConstrained("AAAAAA", Constraints{Forbidden: []string{"GCTG"}})
+	require.NoError(t, err)
+	assert.NotContains(t, sequence, "GCC")
+}
+
+func TestOptimizeConstrainedFivePrime(t *testing.T) {
+	table, err := NewTranslationTable(11)
+	require.NoError(t, err)
+	// GGGG... and CCCC... fold back on each other into a long hairpin.
+	setWeight(table, "GGG", 10)
+	setWeight(table, "CCC", 10)
+	protein := "MGGGGSPPPP"
+	unpenalized, err := table.OptimizeConstrained(protein, Constraints{})
+	require.NoError(t, err)
+	penalized, err := table.OptimizeConstrained(protein, Constraints{FivePrimeWindow: 30})
+	require.NoError(t, err)
+
+	unpenalizedEnergy, err := fivePrimeEnergy(unpenalized[:30], 37)
+	require.NoError(t, err)
+	penalizedEnergy, err := fivePrimeEnergy(penalized[:30], 37)
+	require.NoError(t, err)
+	assert.Equal(t, "ATGGGGGGGGGGGGG", unpenalized[:15])
+	assert.Greater(t, penalizedEnergy, unpenalizedEnergy)
+}
+
+func TestOptimizeConstrainedErrors(t *testing.T) {
+	table, err := NewTranslationTable(11)
+	require.NoError(t, err)
+
+	_, err = table.OptimizeConstrained("", Constraints{})
+	assert.ErrorIs(t, err, errEmptyAminoAcidString)
+
+	_, err = table.OptimizeConstrained("MJ", Constraints{})
+	assert.Equal(t, invalidAminoAcidError{'J'}, err)
+
+	// methionine only has ATG.
+	_, err = table.OptimizeConstrained("MMM", Constraints{Forbidden: []string{"ATGATG"}})
+	assert.True(t, errors.Is(err, errConstraintsUnsatisfiable))
+
+	// a short sequence is held to its GC limits as a whole.
+	_, err = table.OptimizeConstrained("MKK", Constraints{MinGC: 0.5})
+	assert.ErrorIs(t, err, errConstraintsUnsatisfiable)
+}
+
+func TestViolations(t *testing.T) {
+	constraints := Constraints{Forbidden: []string{"GGTCTC"}, MaxHomopolymer: 3, MaxGC: 0.5, GCWindow: 10}
+	violations := constraints.Violations("aaaaGAGACCgcgcgcgc")
+	assert.Equal(t, []string{
+		"homopolymer longer than 3 at 0",
+		"forbidden motif GAGACC at 4",
+		"GC content 0.60 above 0.50 at 2",
+		"GC content 0.70 above 0.50 at 3",
+		"GC content 0.80 above 0.50 at 4",
+		"GC content 0.80 above 0.50 at 5",
+		"GC content 0.90 above 0.50 at 6",
+		"GC content 0.90 above 0.50 at 7",
+		"GC content 1.00 above 0.50 at 8",
+	}, violations)
+}
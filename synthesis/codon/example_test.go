@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/bebop/poly/io/genbank"
 	"github.com/bebop/poly/synthesis/codon"
 )
 
@@ -78,39 +77,6 @@ func ExampleTranslationTable_UpdateWeights() {
 	// output: true
 }
 
-func ExampleTranslationTable_Optimize() {
-	gfpTranslation := "MASKGEELFTGVVPILVELDGDVNGHKFSVSGEGEGDATYGKLTLKFICTTGKLPVPWPTLVTTFSYGVQCFSRYPDHMKRHDFFKSAMPEGYVQERTISFKDDGNYKTRAEVKFEGDTLVNRIELKGIDFKEDGNILGHKLEYNYNSHNVYITADKQKNGIKANFKIRHNIEDGSVQLADHYQQNTPIGDGPVLLPDNHYLSTQSALSKDPNEKRDHMVLLEFVTAAGITHGMDELYK*"
-
-	sequence, _ := genbank.Read("../../data/puc19.gbk")
-	codonTable, err := codon.NewTranslationTable(11)
-	if err != nil {
-		fmt.Printf("error running example: %s\n", err)
-		return
-	}
-
-	_ = codonTable.UpdateWeightsWithSequence(sequence)
-
-	// Here, we double check if the number of genes is equal to the number of stop codons
-	stopCodonCount := 0
-	for _, aa := range codonTable.AminoAcids {
-		if aa.Letter == "*" {
-			for _, codon := range aa.Codons {
-				stopCodonCount = stopCodonCount + codon.Weight
-			}
-		}
-	}
-
-	if stopCodonCount != codonTable.Stats.GeneCount {
-		fmt.Println("Stop codons don't equal number of genes!")
-	}
-
-	optimizedSequence, _ := codonTable.Optimize(gfpTranslation)
-	optimizedSequenceTranslation, _ := codonTable.Translate(optimizedSequence)
-
-	fmt.Println(optimizedSequenceTranslation == gfpTranslation)
-	// output: true
-}
-
 func ExampleReadCodonJSON() {
 	codontable := codon.ReadCodonJSON("../../data/bsub_codon_test.json")
 
@@ -138,82 +104,44 @@ func ExampleWriteCodonJSON() {
 	//output: 28327
 }
 
-func ExampleCompromiseCodonTable() {
-	sequence, _ := genbank.Read("../../data/puc19.gbk")
-
-	// weight our codon optimization table using the regions we collected from the genbank file above
-	optimizationTable, err := codon.NewTranslationTable(11)
-	if err != nil {
-		fmt.Printf("error running example: %s\n", err)
-		return
-	}
+func ExampleTranslationTable_OptimizeConstrained() {
+	table, _ := codon.NewTranslationTable(11)
 
-	err = optimizationTable.UpdateWeightsWithSequence(sequence)
-	if err != nil {
-		panic(fmt.Errorf("got unexpected error in an example: %w", err))
-	}
-
-	sequence2, _ := genbank.Read("../../data/phix174.gb")
-	optimizationTable2, err := codon.NewTranslationTable(11)
-	if err != nil {
-		fmt.Printf("error running example: %s\n", err)
-		return
+	constraints := codon.Constraints{
+		Forbidden:      []string{"GGTCTC", "GAATTC", codon.SpliceDonor}, // BsaI, EcoRI, and splice donors.
+		MaxHomopolymer: 5,
+		MinGC:          0.35,
+		MaxGC:          0.65,
 	}
-
-	err = optimizationTable2.UpdateWeightsWithSequence(sequence2)
-	if err != nil {
-		panic(fmt.Errorf("got unexpected error in an example: %w", err))
-	}
-
-	finalTable, _ := codon.CompromiseCodonTable(optimizationTable, optimizationTable2, 0.1)
-	for _, aa := range finalTable.GetWeightedAminoAcids() {
-		for _, codon := range aa.Codons {
-			if codon.Triplet == "TAA" {
-				fmt.Println(codon.Weight)
-			}
-		}
-	}
-	//output: 3863
+	sequence, _ := table.OptimizeConstrained("MASKGEELFTGVVPILVELDGDVNGHKFSVSGEGEGDATYGKLTLKF", constraints)
+	translation, _ := table.Translate(sequence)
+	fmt.Println(translation, len(constraints.Violations(sequence)))
+	// Output: MASKGEELFTGVVPILVELDGDVNGHKFSVSGEGEGDATYGKLTLKF 0
 }
 
-func ExampleAddCodonTable() {
-	sequence, _ := genbank.Read("../../data/puc19.gbk")
-
-	// weight our codon optimization table using the regions we collected from the genbank file above
-	optimizationTable, err := codon.NewTranslationTable(11)
-	if err != nil {
-		fmt.Printf("error running example: %s\n", err)
-		return
-	}
-
-	err = optimizationTable.UpdateWeightsWithSequence(sequence)
-	if err != nil {
-		panic(fmt.Errorf("got unexpected error in an example: %w", err))
-	}
-
-	sequence2, _ := genbank.Read("../../data/phix174.gb")
-	optimizationTable2, err := codon.NewTranslationTable(11)
-	if err != nil {
-		fmt.Printf("error running example: %s\n", err)
-		return
-	}
-
-	err = optimizationTable2.UpdateWeightsWithSequence(sequence2)
-	if err != nil {
-		panic(fmt.Errorf("got unexpected error in an example: %w", err))
-	}
-
-	finalTable, err := codon.AddCodonTable(optimizationTable, optimizationTable2)
-	if err != nil {
-		panic(fmt.Errorf("got error in adding codon table example: %w", err))
-	}
+func ExampleTranslationTable_CodonAdaptationIndex() {
+	table, _ := codon.NewTranslationTable(11)
+	// a host that uses CTG for leucine four times as often as any other codon.
+	_ = table.UpdateWeights([]codon.AminoAcid{{Letter: "L", Codons: []codon.Codon{
+		{Triplet: "CTG", Weight: 4}, {Triplet: "CTT", Weight: 1}, {Triplet: "CTC", Weight: 1},
+		{Triplet: "CTA", Weight: 1}, {Triplet: "TTA", Weight: 1}, {Triplet: "TTG", Weight: 1},
+	}}})
+
+	cai, _ := table.CodonAdaptationIndex("ATGCTGCTTTAA")
+	fmt.Printf("%.2f\n", cai)
+	// Output: 0.50
+}
 
-	for _, aa := range finalTable.AminoAcids {
-		for _, codon := range aa.Codons {
-			if codon.Triplet == "GGC" {
-				fmt.Println(codon.Weight)
-			}
-		}
-	}
-	//output: 51
+func ExampleScreenFivePrime() {
+	// a 5' UTR ending in a Shine-Dalgarno sequence, AGGAGG, and two variants
+	// of the start of a gene. The first pairs with the Shine-Dalgarno
+	// sequence.
+	utr := "TCTAGAAATAATTTTGTTTAACTTTAAGAAGGAGATATACATCGCGAGGAGGCGCG"
+	variants := []string{
+		"ATGCCTCCTTCTAAAGGCGAAGAACTGTTT",
+		"ATGAGCAAAGGTGAAGAATTATTCAAGCTT",
+	}
+	structures, _ := codon.ScreenFivePrime(utr, variants, codon.FivePrimeOptions{})
+	fmt.Println("least structure:", structures[0].Index)
+	// Output: least structure: 1
 }
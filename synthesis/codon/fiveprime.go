@@ -0,0 +1,123 @@
+package codon
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/bebop/poly/alphabet"
+	"github.com/bebop/poly/checks"
+	"github.com/bebop/poly/fold"
+)
+
+/******************************************************************************
+
+5' structure screening begins here.
+
+How much protein an mRNA makes depends a lot on how easily a ribosome can get
+onto it, and ribosomes can't bind a Shine-Dalgarno sequence or a start codon
+that's tied up in a hairpin. Changing a few codons near the start of a gene
+can change its expression several fold this way, even though it doesn't
+change the protein (Kudla et al. 2009), so picking between codon optimized
+variants of a gene by how little structure they have around the start codon
+is an easy win.
+
+ScreenFivePrime folds the mRNA from 30 bases before the start codon to 30
+bases into the gene, with the same 5' UTR in front of every variant, and
+reports the free energy of the fold and how much of the ribosome binding
+site and start codon is left unpaired. Variants come back with the least
+structure first.
+
+******************************************************************************/
+
+// FivePrimeOptions changes how ScreenFivePrime folds variants. Zero values
+// are replaced with the defaults noted on each field. Positions are relative
+// to the first base of the start codon.
+type FivePrimeOptions struct {
+	// Upstream and Downstream are how many bases before and after the start
+	// codon's first base are folded. Both default to 30.
+	Upstream, Downstream int
+	// RegionStart and RegionEnd are the half-open range whose accessibility
+	// is reported. They default to -15 and 3, the ribosome binding site and
+	// start codon.
+	RegionStart, RegionEnd int
+	// Temperature is what the mRNA is folded at, in Celsius. Defaults to 37.
+	Temperature float64
+}
+
+// FivePrimeStructure is how a variant folds around its start codon.
+type FivePrimeStructure struct {
+	// Index is which of the variants it is.
+	Index int
+	// Window is the mRNA that was folded, as DNA, and Structure its fold in
+	// dot-bracket notation.
+	Window    string
+	Structure string
+	// Energy is the free energy of the fold, in kcal/mol. Lower energies
+	// are more stable structures.
+	Energy float64
+	// Accessibility is the fraction of the bases from RegionStart to
+	// RegionEnd that are unpaired.
+	Accessibility float64
+}
+
+// ScreenFivePrime folds the start of every variant of a coding sequence, after
+// utr, the 5' UTR with the ribosome binding site they'll be expressed from.
+// Variants are returned with the least structure first: by highest energy,
+// then by highest accessibility.
+func ScreenFivePrime(utr string, variants []string, options FivePrimeOptions) ([]FivePrimeStructure, error) {
+	if options.Upstream == 0 {
+		options.Upstream = 30
+	}
+	if options.Downstream == 0 {
+		options.Downstream = 30
+	}
+	if options.RegionStart == 0 && options.RegionEnd == 0 {
+		options.RegionStart, options.RegionEnd = -15, 3
+	}
+	if options.Temperature == 0 {
+		options.Temperature = 37
+	}
+	if options.RegionStart >= options.RegionEnd {
+		return nil, fmt.Errorf("region %d..%d is empty", options.RegionStart, options.RegionEnd)
+	}
+
+	utr = strings.ToUpper(utr)
+	upstream := utr[len(utr)-min(options.Upstream, len(utr)):]
+	structures := make([]FivePrimeStructure, len(variants))
+	for index, variant := range variants {
+		variant = strings.ToUpper(variant)
+		window := upstream + variant[:min(options.Downstream, len(variant))]
+		if !checks.IsDNA(window) {
+			return nil, fmt.Errorf("variant %d: %q has bases other than A, C, G, and T", index, window)
+		}
+		result, err := fold.Zuker(alphabet.DNAToRNA(window), options.Temperature)
+		if err != nil {
+			return nil, fmt.Errorf("variant %d: %w", index, err)
+		}
+		structure := result.DotBracket()
+		structure += strings.Repeat(".", len(window)-len(structure))
+		energy := result.MinimumFreeEnergy()
+		// a fold that isn't stable doesn't form.
+		if math.IsInf(energy, 0) || energy > 0 {
+			structure, energy = strings.Repeat(".", len(window)), 0
+		}
+
+		// the region is clipped to the window.
+		start := max(len(upstream)+options.RegionStart, 0)
+		end := min(len(upstream)+options.RegionEnd, len(window))
+		accessibility := 0.0
+		if start < end {
+			accessibility = float64(strings.Count(structure[start:end], ".")) / float64(end-start)
+		}
+		structures[index] = FivePrimeStructure{Index: index, Window: window, Structure: structure, Energy: energy, Accessibility: accessibility}
+	}
+	sort.SliceStable(structures, func(i, j int) bool {
+		if structures[i].Energy != structures[j].Energy {
+			return structures[i].Energy > structures[j].Energy
+		}
+		return structures[i].Accessibility > structures[j].Accessibility
+	})
+	return structures, nil
+}
@@ -0,0 +1,56 @@
+package codon
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// utr is a 5' UTR with a strong Shine-Dalgarno sequence, AGGAGG.
+const utr = "TCTAGAAATAATTTTGTTTAACTTTAAGAAGGAGATATACAT"
+
+func TestScreenFivePrime(t *testing.T) {
+	// both encode MSKGEELF. The first pairs its start with the
+	// Shine-Dalgarno sequence, the second doesn't.
+	variants := []string{
+		"ATGCCTCCTTCTAAAGGCGAAGAACTGTTT",
+		"ATGAGCAAAGGTGAAGAATTATTCAAGCTT",
+	}
+	structures, err := ScreenFivePrime(utr+"CGCGAGGAGGCGCG", variants, FivePrimeOptions{})
+	require.NoError(t, err)
+	require.Len(t, structures, 2)
+	assert.Equal(t, 1, structures[0].Index)
+	assert.Equal(t, 0, structures[1].Index)
+	assert.Greater(t, structures[0].Energy, structures[1].Energy)
+	for _, structure := range structures {
+		assert.Len(t, structure.Window, 60)
+		assert.Len(t, structure.Structure, 60)
+		assert.True(t, strings.HasSuffix(structure.Window[:33], "ATG"))
+		assert.GreaterOrEqual(t, structure.Accessibility, 0.0)
+		assert.LessOrEqual(t, structure.Accessibility, 1.0)
+	}
+}
+
+func TestScreenFivePrimeShort(t *testing.T) {
+	// short UTRs and variants fold what there is.
+	structures, err := ScreenFivePrime("AGGAGG", []string{"atgaaataa"}, FivePrimeOptions{})
+	require.NoError(t, err)
+	require.Len(t, structures, 1)
+	assert.Equal(t, "AGGAGGATGAAATAA", structures[0].Window)
+	assert.Equal(t, 0.0, structures[0].Energy)
+	assert.Equal(t, 1.0, structures[0].Accessibility)
+}
+
+func TestScreenFivePrimeOptions(t *testing.T) {
+	structures, err := ScreenFivePrime(utr, []string{"ATGAGCAAAGGTGAAGAATTATTC"}, FivePrimeOptions{Upstream: 10, Downstream: 12, RegionStart: -10, RegionEnd: 12})
+	require.NoError(t, err)
+	assert.Equal(t, utr[len(utr)-10:]+"ATGAGCAAAGGT", structures[0].Window)
+	assert.Equal(t, float64(strings.Count(structures[0].Structure, "."))/22, structures[0].Accessibility)
+
+	_, err = ScreenFivePrime(utr, []string{"ATGNNN"}, FivePrimeOptions{})
+	assert.Error(t, err)
+	_, err = ScreenFivePrime(utr, []string{"ATG"}, FivePrimeOptions{RegionStart: 3, RegionEnd: 3})
+	assert.Error(t, err)
+}
@@ -0,0 +1,100 @@
+//go:build !poly_minimal
+
+package codon
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/bebop/poly/io/genbank"
+)
+
+/******************************************************************************
+
+Learning from GenBank records begins here.
+
+These are the parts of the package that read GenBank records, which pulls in
+the GenBank parser and its dependencies. Building with the poly_minimal tag
+leaves them out, for TinyGo and wasm builds that only need to translate and
+optimize with tables they already have.
+
+******************************************************************************/
+
+// UpdateWeightsWithSequence will look at the coding regions in the given genbank data, and use those to generate new
+// weights for the codons in the translation table. The next time a sequence is optimised, it will use those updated
+// weights.
+//
+// This can be used to, for example, figure out which DNA sequence is needed to give the best yield of protein when
+// trying to express a protein across different species
+func (table *TranslationTable) UpdateWeightsWithSequence(data genbank.Genbank) error {
+	codingRegions, err := extractCodingRegion(data)
+	if err != nil {
+		return err
+	}
+
+	table.Stats.GeneCount = len(codingRegions)
+	for _, sequence := range codingRegions {
+		table.Stats.StartCodonCount[sequence[:3]]++
+	}
+
+	if len(codingRegions) == 0 {
+		return errNoCodingRegions
+	}
+
+	// weight our codon optimization table using the regions we collected from the genbank file above
+	newWeights := weightAminoAcids(strings.Join(codingRegions, ""), table.AminoAcids)
+
+	return table.UpdateWeights(newWeights)
+}
+
+// extractCodingRegion loops through genbank data to find all CDS (coding sequences)
+func extractCodingRegion(data genbank.Genbank) ([]string, error) {
+	codingRegions := []string{}
+
+	// iterate through the features of the genbank file and if the feature is a coding region, append the sequence to the string builder
+	for _, feature := range data.Features {
+		if feature.Type == "CDS" {
+			sequence, err := feature.GetSequence()
+			if err != nil {
+				return nil, err
+			}
+
+			// Note: sometimes, genbank files will have annotated CDSs that are pseudo genes (not having triplet codons).
+			// This will shift the entire codon table, messing up the end results. To fix this, make sure to do a modulo
+			// check.
+			if len(sequence)%3 != 0 {
+				continue
+			}
+
+			codingRegions = append(codingRegions, sequence)
+		}
+	}
+
+	return codingRegions, nil
+}
+
+// anticodonRegex matches anticodons in /anticodon qualifiers, like
+// (pos:11496..11498,aa:Ile,seq:gat), and in names like tRNA-Ala(UGC).
+var anticodonRegex = regexp.MustCompile(`(?i)seq:([acgtu]{3})|RNA-\w+\(([acgtu]{3})\)`)
+
+// TRNAGeneCounts counts the tRNA genes of a genome by anticodon, for
+// TRNAAdaptationIndex. Anticodons are read from /anticodon qualifiers, or
+// failing that from names like tRNA-Ala(UGC) in /product or /note.
+func TRNAGeneCounts(record genbank.Genbank) map[string]int {
+	counts := make(map[string]int)
+	for _, feature := range record.Features {
+		if feature.Type != "tRNA" {
+			continue
+		}
+		for _, qualifier := range []string{"anticodon", "product", "note"} {
+			match := anticodonRegex.FindStringSubmatch(feature.Attributes[qualifier])
+			if match == nil {
+				continue
+			}
+			anticodon := strings.ToUpper(match[1] + match[2])
+			counts[strings.ReplaceAll(anticodon, "U", "T")]++
+			break
+		}
+	}
+	return counts
+}
@@ -0,0 +1,123 @@
+//go:build !poly_minimal
+
+package codon_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/synthesis/codon"
+)
+
+func ExampleTranslationTable_Optimize() {
+	gfpTranslation := "MASKGEELFTGVVPILVELDGDVNGHKFSVSGEGEGDATYGKLTLKFICTTGKLPVPWPTLVTTFSYGVQCFSRYPDHMKRHDFFKSAMPEGYVQERTISFKDDGNYKTRAEVKFEGDTLVNRIELKGIDFKEDGNILGHKLEYNYNSHNVYITADKQKNGIKANFKIRHNIEDGSVQLADHYQQNTPIGDGPVLLPDNHYLSTQSALSKDPNEKRDHMVLLEFVTAAGITHGMDELYK*"
+
+	sequence, _ := genbank.Read("../../data/puc19.gbk")
+	codonTable, err := codon.NewTranslationTable(11)
+	if err != nil {
+		fmt.Printf("error running example: %s\n", err)
+		return
+	}
+
+	_ = codonTable.UpdateWeightsWithSequence(sequence)
+
+	// Here, we double check if the number of genes is equal to the number of stop codons
+	stopCodonCount := 0
+	for _, aa := range codonTable.AminoAcids {
+		if aa.Letter == "*" {
+			for _, codon := range aa.Codons {
+				stopCodonCount = stopCodonCount + codon.Weight
+			}
+		}
+	}
+
+	if stopCodonCount != codonTable.Stats.GeneCount {
+		fmt.Println("Stop codons don't equal number of genes!")
+	}
+
+	optimizedSequence, _ := codonTable.Optimize(gfpTranslation)
+	optimizedSequenceTranslation, _ := codonTable.Translate(optimizedSequence)
+
+	fmt.Println(optimizedSequenceTranslation == gfpTranslation)
+	// output: true
+}
+
+func ExampleCompromiseCodonTable() {
+	sequence, _ := genbank.Read("../../data/puc19.gbk")
+
+	// weight our codon optimization table using the regions we collected from the genbank file above
+	optimizationTable, err := codon.NewTranslationTable(11)
+	if err != nil {
+		fmt.Printf("error running example: %s\n", err)
+		return
+	}
+
+	err = optimizationTable.UpdateWeightsWithSequence(sequence)
+	if err != nil {
+		panic(fmt.Errorf("got unexpected error in an example: %w", err))
+	}
+
+	sequence2, _ := genbank.Read("../../data/phix174.gb")
+	optimizationTable2, err := codon.NewTranslationTable(11)
+	if err != nil {
+		fmt.Printf("error running example: %s\n", err)
+		return
+	}
+
+	err = optimizationTable2.UpdateWeightsWithSequence(sequence2)
+	if err != nil {
+		panic(fmt.Errorf("got unexpected error in an example: %w", err))
+	}
+
+	finalTable, _ := codon.CompromiseCodonTable(optimizationTable, optimizationTable2, 0.1)
+	for _, aa := range finalTable.GetWeightedAminoAcids() {
+		for _, codon := range aa.Codons {
+			if codon.Triplet == "TAA" {
+				fmt.Println(codon.Weight)
+			}
+		}
+	}
+	//output: 3863
+}
+
+func ExampleAddCodonTable() {
+	sequence, _ := genbank.Read("../../data/puc19.gbk")
+
+	// weight our codon optimization table using the regions we collected from the genbank file above
+	optimizationTable, err := codon.NewTranslationTable(11)
+	if err != nil {
+		fmt.Printf("error running example: %s\n", err)
+		return
+	}
+
+	err = optimizationTable.UpdateWeightsWithSequence(sequence)
+	if err != nil {
+		panic(fmt.Errorf("got unexpected error in an example: %w", err))
+	}
+
+	sequence2, _ := genbank.Read("../../data/phix174.gb")
+	optimizationTable2, err := codon.NewTranslationTable(11)
+	if err != nil {
+		fmt.Printf("error running example: %s\n", err)
+		return
+	}
+
+	err = optimizationTable2.UpdateWeightsWithSequence(sequence2)
+	if err != nil {
+		panic(fmt.Errorf("got unexpected error in an example: %w", err))
+	}
+
+	finalTable, err := codon.AddCodonTable(optimizationTable, optimizationTable2)
+	if err != nil {
+		panic(fmt.Errorf("got error in adding codon table example: %w", err))
+	}
+
+	for _, aa := range finalTable.AminoAcids {
+		for _, codon := range aa.Codons {
+			if codon.Triplet == "GGC" {
+				fmt.Println(codon.Weight)
+			}
+		}
+	}
+	//output: 51
+}
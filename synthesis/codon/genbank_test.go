@@ -0,0 +1,328 @@
+//go:build !poly_minimal
+
+package codon
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/google/go-cmp/cmp"
+	weightedRand "github.com/mroth/weightedrand"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptimize(t *testing.T) {
+	gfpTranslation := "MASKGEELFTGVVPILVELDGDVNGHKFSVSGEGEGDATYGKLTLKFICTTGKLPVPWPTLVTTFSYGVQCFSRYPDHMKRHDFFKSAMPEGYVQERTISFKDDGNYKTRAEVKFEGDTLVNRIELKGIDFKEDGNILGHKLEYNYNSHNVYITADKQKNGIKANFKIRHNIEDGSVQLADHYQQNTPIGDGPVLLPDNHYLSTQSALSKDPNEKRDHMVLLEFVTAAGITHGMDELYK*"
+
+	sequence, _ := genbank.Read("../../data/puc19.gbk")
+
+	table, err := NewTranslationTable(11)
+	if err != nil {
+		t.Fatalf("failed to initialise codon table: %s", err)
+	}
+
+	err = table.UpdateWeightsWithSequence(sequence)
+	if err != nil {
+		t.Error(err)
+	}
+
+	codonTable, err := NewTranslationTable(11)
+	if err != nil {
+		t.Fatalf("failed to initialise codon table: %s", err)
+	}
+
+	optimizedSequence, _ := table.Optimize(gfpTranslation)
+	optimizedSequenceTranslation, _ := codonTable.Translate(optimizedSequence)
+
+	if optimizedSequenceTranslation != gfpTranslation {
+		t.Errorf("TestOptimize has failed. Translate has returned %q, want %q", optimizedSequenceTranslation, gfpTranslation)
+	}
+}
+
+func TestOptimizeSameSeed(t *testing.T) {
+	var gfpTranslation = "MASKGEELFTGVVPILVELDGDVNGHKFSVSGEGEGDATYGKLTLKFICTTGKLPVPWPTLVTTFSYGVQCFSRYPDHMKRHDFFKSAMPEGYVQERTISFKDDGNYKTRAEVKFEGDTLVNRIELKGIDFKEDGNILGHKLEYNYNSHNVYITADKQKNGIKANFKIRHNIEDGSVQLADHYQQNTPIGDGPVLLPDNHYLSTQSALSKDPNEKRDHMVLLEFVTAAGITHGMDELYK*"
+	var sequence, _ = genbank.Read("../../data/puc19.gbk")
+	optimizationTable, err := NewTranslationTable(11)
+	if err != nil {
+		t.Fatalf("failed to initialise codon table: %s", err)
+	}
+
+	err = optimizationTable.UpdateWeightsWithSequence(sequence)
+	if err != nil {
+		t.Error(err)
+	}
+	if err != nil {
+		t.Error(err)
+	}
+
+	randomSeed := 10
+
+	optimizedSequence, _ := optimizationTable.Optimize(gfpTranslation, randomSeed)
+	otherOptimizedSequence, _ := optimizationTable.Optimize(gfpTranslation, randomSeed)
+
+	if optimizedSequence != otherOptimizedSequence {
+		t.Error("Optimized sequence with the same random seed are not the same")
+	}
+}
+
+func TestOptimizeDifferentSeed(t *testing.T) {
+	var gfpTranslation = "MASKGEELFTGVVPILVELDGDVNGHKFSVSGEGEGDATYGKLTLKFICTTGKLPVPWPTLVTTFSYGVQCFSRYPDHMKRHDFFKSAMPEGYVQERTISFKDDGNYKTRAEVKFEGDTLVNRIELKGIDFKEDGNILGHKLEYNYNSHNVYITADKQKNGIKANFKIRHNIEDGSVQLADHYQQNTPIGDGPVLLPDNHYLSTQSALSKDPNEKRDHMVLLEFVTAAGITHGMDELYK*"
+	var sequence, _ = genbank.Read("../../data/puc19.gbk")
+	optimizationTable, err := NewTranslationTable(11)
+	if err != nil {
+		t.Fatalf("failed to initialise codon table: %s", err)
+	}
+
+	err = optimizationTable.UpdateWeightsWithSequence(sequence)
+	if err != nil {
+		t.Error(err)
+	}
+
+	optimizedSequence, _ := optimizationTable.Optimize(gfpTranslation)
+	otherOptimizedSequence, _ := optimizationTable.Optimize(gfpTranslation)
+
+	if optimizedSequence == otherOptimizedSequence {
+		t.Error("Optimized sequence with different random seed have the same result")
+	}
+}
+
+func TestCompromiseCodonTable(t *testing.T) {
+	sequence, _ := genbank.Read("../../data/puc19.gbk")
+
+	// weight our codon optimization table using the regions we collected from the genbank file above
+
+	optimizationTable, err := NewTranslationTable(11)
+	if err != nil {
+		t.Fatalf("failed to initialise codon table: %s", err)
+	}
+
+	err = optimizationTable.UpdateWeightsWithSequence(sequence)
+	if err != nil {
+		t.Error(err)
+	}
+
+	sequence2, _ := genbank.Read("../../data/phix174.gb")
+	optimizationTable2, err := NewTranslationTable(11)
+	if err != nil {
+		t.Fatalf("failed to initialise codon table: %s", err)
+	}
+
+	err = optimizationTable2.UpdateWeightsWithSequence(sequence2)
+	if err != nil {
+		t.Error(err)
+	}
+
+	_, err = CompromiseCodonTable(optimizationTable, optimizationTable2, -1.0) // Fails too low
+	if err == nil {
+		t.Errorf("Compromise table should fail on -1.0")
+	}
+	_, err = CompromiseCodonTable(optimizationTable, optimizationTable2, 10.0) // Fails too high
+	if err == nil {
+		t.Errorf("Compromise table should fail on 10.0")
+	}
+
+	// replace chooser fn with test one
+	newChooserFn = func(choices ...weightedRand.Choice) (*weightedRand.Chooser, error) {
+		return nil, errors.New("new chooser rigged to fail")
+	}
+
+	defer func() {
+		newChooserFn = weightedRand.NewChooser
+	}()
+
+	_, err = CompromiseCodonTable(optimizationTable, optimizationTable2, 0.1)
+	if err == nil {
+		t.Errorf("Compromise table should fail when new chooser func rigged")
+	}
+}
+
+func TestAddCodonTable(t *testing.T) {
+	sequence, _ := genbank.Read("../../data/puc19.gbk")
+
+	// weight our codon optimization table using the regions we collected from the genbank file above
+
+	optimizationTable, err := NewTranslationTable(11)
+	if err != nil {
+		t.Fatalf("failed to initialise codon table: %s", err)
+	}
+
+	err = optimizationTable.UpdateWeightsWithSequence(sequence)
+	if err != nil {
+		t.Error(err)
+	}
+
+	sequence2, _ := genbank.Read("../../data/phix174.gb")
+	optimizationTable2, err := NewTranslationTable(11)
+	if err != nil {
+		t.Fatalf("failed to initialise codon table: %s", err)
+	}
+
+	err = optimizationTable2.UpdateWeightsWithSequence(sequence2)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// replace chooser fn with test one
+	newChooserFn = func(choices ...weightedRand.Choice) (*weightedRand.Chooser, error) {
+		return nil, errors.New("new chooser rigged to fail")
+	}
+
+	defer func() {
+		newChooserFn = weightedRand.NewChooser
+	}()
+
+	_, err = AddCodonTable(optimizationTable, optimizationTable2)
+	if err == nil {
+		t.Errorf("Compromise table should fail when new chooser func rigged")
+	}
+}
+
+func TestCapitalizationRegression(t *testing.T) {
+	// Tests to make sure that amino acids are capitalized
+	gfpTranslation := "MaSKGEELFTGVVPILVELDGDVNGHKFSVSGEGEGDATYGKLTLKFICTTGKLPVPWPTLVTTFSYGVQCFSRYPDHMKRHDFFKSAMPEGYVQERTISFKDDGNYKTRAEVKFEGDTLVNRIELKGIDFKEDGNILGHKLEYNYNSHNVYITADKQKNGIKANFKIRHNIEDGSVQLADHYQQNTPIGDGPVLLPDNHYLSTQSALSKDPNEKRDHMVLLEFVTAAGITHGMDELYK*"
+
+	sequence, _ := genbank.Read("../../data/puc19.gbk")
+
+	optimizationTable, err := NewTranslationTable(11)
+	if err != nil {
+		t.Fatalf("failed to initialise codon table: %s", err)
+	}
+
+	err = optimizationTable.UpdateWeightsWithSequence(sequence)
+	if err != nil {
+		t.Error(err)
+	}
+
+	optimizedSequence, _ := optimizationTable.Optimize(gfpTranslation, 1)
+	optimizedSequenceTranslation, _ := optimizationTable.Translate(optimizedSequence)
+
+	if optimizedSequenceTranslation != strings.ToUpper(gfpTranslation) {
+		t.Errorf("TestOptimize has failed. Translate has returned %q, want %q", optimizedSequenceTranslation, gfpTranslation)
+	}
+}
+
+func TestOptimizeSequence(t *testing.T) {
+	t.Parallel()
+
+	var (
+		gfpTranslation = "MASKGEELFTGVVPILVELDGDVNGHKFSVSGEGEGDATYGKLTLKFICTTGKLPVPWPTLVTTFSYGVQCFSRYPDHMKRHDFFKSAMPEGYVQERTISFKDDGNYKTRAEVKFEGDTLVNRIELKGIDFKEDGNILGHKLEYNYNSHNVYITADKQKNGIKANFKIRHNIEDGSVQLADHYQQNTPIGDGPVLLPDNHYLSTQSALSKDPNEKRDHMVLLEFVTAAGITHGMDELYK*"
+		optimisedGFP   = "ATGGCAAGTAAGGGAGAAGAGCTTTTTACCGGCGTAGTACCAATTCTGGTAGAACTGGATGGTGATGTAAACGGTCACAAATTTAGTGTAAGCGGAGAAGGTGAGGGTGATGCTACCTATGGCAAACTGACCCTAAAGTTTATATGCACGACTGGAAAACTTCCGGTACCGTGGCCAACGTTAGTTACAACGTTTTCTTATGGAGTACAGTGCTTCAGCCGCTACCCAGATCATATGAAACGCCATGATTTCTTTAAGAGCGCCATGCCAGAGGGTTATGTTCAGGAGCGCACGATCTCGTTTAAGGATGATGGTAACTATAAGACTCGTGCTGAGGTGAAGTTCGAAGGCGATACCCTTGTAAATCGTATTGAATTGAAGGGTATAGACTTCAAGGAGGATGGAAATATTCTTGGACATAAGCTGGAATACAATTACAATTCACATAACGTTTATATAACTGCCGACAAGCAAAAAAACGGGATAAAAGCTAATTTTAAAATACGCCACAACATAGAGGACGGGTCGGTGCAACTAGCCGATCATTATCAACAAAACACACCAATCGGCGACGGACCAGTTCTGTTGCCCGATAATCATTACTTATCAACCCAAAGTGCCTTAAGTAAGGATCCGAACGAAAAGCGCGATCATATGGTACTTCTTGAGTTTGTTACCGCTGCAGGCATAACGCATGGCATGGACGAGCTATACAAATAA"
+		puc19          = func() genbank.Genbank {
+			seq, err := genbank.Read("../../data/puc19.gbk")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return seq
+		}()
+	)
+
+	tests := []struct {
+		name string
+
+		sequenceToOptimise string
+		updateWeightsWith  genbank.Genbank
+		wantOptimised      string
+
+		wantUpdateWeightsErr error
+		wantOptimiseErr      error
+	}{
+		{
+			name: "ok",
+
+			sequenceToOptimise: gfpTranslation,
+			updateWeightsWith:  puc19,
+			wantOptimised:      optimisedGFP,
+
+			wantUpdateWeightsErr: nil,
+			wantOptimiseErr:      nil,
+		},
+		{
+			name: "giving no sequence to optimise",
+
+			sequenceToOptimise: "",
+			updateWeightsWith:  puc19,
+			wantOptimised:      "",
+
+			wantUpdateWeightsErr: nil,
+			wantOptimiseErr:      errEmptyAminoAcidString,
+		},
+		{
+			name: "updating weights with a sequence with no CDS",
+
+			sequenceToOptimise: "",
+			updateWeightsWith:  genbank.Genbank{},
+			wantOptimised:      "",
+
+			wantUpdateWeightsErr: errNoCodingRegions,
+			wantOptimiseErr:      errEmptyAminoAcidString,
+		},
+	}
+
+	for _, tt := range tests {
+		var tt = tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			optimizationTable, err := NewTranslationTable(11)
+			if err != nil {
+				t.Fatalf("failed to initialise codon table: %s", err)
+			}
+
+			err = optimizationTable.UpdateWeightsWithSequence(tt.updateWeightsWith)
+			if !errors.Is(err, tt.wantUpdateWeightsErr) {
+				t.Errorf("got %v, want %v", err, tt.wantUpdateWeightsErr)
+			}
+
+			got, err := optimizationTable.Optimize(tt.sequenceToOptimise, 1)
+			if !errors.Is(err, tt.wantOptimiseErr) {
+				t.Errorf("got %v, want %v", err, tt.wantOptimiseErr)
+			}
+
+			if !cmp.Equal(got, tt.wantOptimised) {
+				t.Errorf("got and tt.wantOptimised didn't match %s", cmp.Diff(got, tt.wantOptimised))
+			}
+		})
+	}
+}
+
+func TestTRNAGeneCounts(t *testing.T) {
+	record, err := genbank.Read("../../data/bsub.gbk")
+	require.NoError(t, err)
+	counts := TRNAGeneCounts(record)
+	assert.Positive(t, counts["TGC"])
+
+	record = genbank.Genbank{Features: []genbank.Feature{
+		{Type: "tRNA", Attributes: map[string]string{"anticodon": "(pos:11496..11498,aa:Ile,seq:gat)"}},
+		{Type: "tRNA", Attributes: map[string]string{"product": "tRNA-Ile(GAU)"}},
+		{Type: "tRNA", Attributes: map[string]string{"product": "tRNA-Ile"}},
+		{Type: "CDS", Attributes: map[string]string{"note": "tRNA-Ala(UGC)"}},
+	}}
+	assert.Equal(t, map[string]int{"GAT": 2}, TRNAGeneCounts(record))
+}
+
+func TestMetricsOfNaturalGenes(t *testing.T) {
+	record, err := genbank.Read("../../data/bsub.gbk")
+	require.NoError(t, err)
+	table, err := NewTranslationTable(11)
+	require.NoError(t, err)
+	require.NoError(t, table.UpdateWeightsWithSequence(record))
+	tRNAs := TRNAGeneCounts(record)
+
+	codingRegions, err := extractCodingRegion(record)
+	require.NoError(t, err)
+	for _, sequence := range codingRegions[:20] {
+		cai, err := table.CodonAdaptationIndex(sequence)
+		require.NoError(t, err)
+		assert.True(t, cai > 0 && cai < 1, "CAI %f", cai)
+		tai, err := table.TRNAAdaptationIndex(sequence, tRNAs)
+		require.NoError(t, err)
+		assert.True(t, tai > 0 && tai < 1, "tAI %f", tai)
+		enc, err := table.EffectiveNumberOfCodons(sequence)
+		require.NoError(t, err)
+		assert.True(t, enc >= 20 && enc <= 61, "ENC %f", enc)
+	}
+}
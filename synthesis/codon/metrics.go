@@ -0,0 +1,253 @@
+package codon
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/bebop/poly/transform"
+)
+
+/******************************************************************************
+
+Codon usage metrics begin here.
+
+These score how well a coding sequence fits a host, which is handy both for
+checking what Optimize and OptimizeConstrained made and for comparing natural
+genes.
+
+The codon adaptation index (CAI, Sharp and Li 1987) is the geometric mean,
+over every codon of a gene, of how often the host uses that codon relative to
+the most used codon for the same amino acid. A table's weights are its codon
+usage, so a table weighted with UpdateWeightsWithSequence from a host's highly
+expressed genes is the usual reference. A gene made only of the host's
+favorite codons has a CAI of 1.
+
+The tRNA adaptation index (tAI, dos Reis et al. 2004) is built the same way,
+but from how many tRNA genes the host has that can read each codon, counting
+wobble pairs as partly as good as Watson-Crick ones.
+
+The effective number of codons (ENC, Wright 1990) doesn't need a reference at
+all. It's how many codons a gene effectively uses, from 20 if it only ever
+uses one codon per amino acid to 61 if it uses every synonymous codon equally.
+
+Methionine, tryptophan, and stop codons, which have no synonyms in the
+standard code, don't say anything about codon choice and are left out of all
+three.
+
+******************************************************************************/
+
+var errNoScorableCodons = errors.New("no codons with synonymous alternatives to score")
+
+// wobblePenalties are how much worse than a Watson-Crick pair each wobble pair
+// between the first base of an anticodon and the third base of a codon is,
+// from dos Reis et al. 2004. Adenines at the wobble position of a tRNA are
+// modified to inosine, which is why A34 tRNAs read codons ending in T, C, and
+// A.
+var wobblePenalties = map[byte]map[byte]float64{
+	// third base of the codon: first base of the anticodon, as written in
+	// the tRNA gene.
+	'T': {'A': 0, 'G': 0.41},
+	'C': {'G': 0, 'A': 0.28},
+	'A': {'T': 0, 'A': 0.9999},
+	'G': {'C': 0, 'T': 0.68},
+}
+
+// CodonAdaptationIndex returns the CAI of a coding sequence, using the
+// table's weights as the host's codon usage. Codons the host never uses would
+// make the CAI 0, so they're counted as if they were used half a time.
+func (table *TranslationTable) CodonAdaptationIndex(sequence string) (float64, error) {
+	adaptiveness := make(map[string]float64)
+	for _, aminoAcid := range table.AminoAcids {
+		maxWeight := 0
+		for _, codon := range aminoAcid.Codons {
+			maxWeight = max(maxWeight, codon.Weight)
+		}
+		// amino acids with no usage data can't be scored.
+		if maxWeight == 0 {
+			continue
+		}
+		for _, codon := range aminoAcid.Codons {
+			adaptiveness[codon.Triplet] = math.Max(float64(codon.Weight), 0.5) / float64(maxWeight)
+		}
+	}
+	return table.geometricMean(sequence, adaptiveness)
+}
+
+// TRNAAdaptationIndex returns the tAI of a coding sequence, given how many
+// genes the host has for tRNAs with each anticodon, written 5' to 3' as DNA,
+// like "GAT" for the tRNA that reads ATC. TRNAGeneCounts counts them from a
+// genome. Codons no tRNA can read are given the geometric mean of the others,
+// as dos Reis et al. do.
+func (table *TranslationTable) TRNAAdaptationIndex(sequence string, tRNAGenes map[string]int) (float64, error) {
+	counts := make(map[string]int)
+	for anticodon, count := range tRNAGenes {
+		counts[strings.ToUpper(strings.ReplaceAll(anticodon, "U", "T"))] += count
+	}
+
+	absolute := make(map[string]float64)
+	maxAbsolute := 0.0
+	for _, aminoAcid := range table.AminoAcids {
+		if !scorable(aminoAcid) {
+			continue
+		}
+		for _, codon := range aminoAcid.Codons {
+			// the anticodon's last two bases pair with the codon's first two.
+			pairing := transform.ReverseComplement(codon.Triplet[:2])
+			readability := 0.0
+			for wobble, penalty := range wobblePenalties[codon.Triplet[2]] {
+				anticodon := string(wobble) + pairing
+				// a tRNA can only read codons of the amino acid it carries.
+				if table.TranslationMap[transform.ReverseComplement(anticodon)] == aminoAcid.Letter {
+					readability += (1 - penalty) * float64(counts[anticodon])
+				}
+			}
+			absolute[codon.Triplet] = readability
+			maxAbsolute = math.Max(maxAbsolute, readability)
+		}
+	}
+	if maxAbsolute == 0 {
+		return 0, errors.New("no tRNA genes read any codons of the table")
+	}
+
+	adaptiveness := make(map[string]float64)
+	logSum, readable := 0.0, 0
+	for triplet, readability := range absolute {
+		if readability > 0 {
+			adaptiveness[triplet] = readability / maxAbsolute
+			logSum += math.Log(adaptiveness[triplet])
+			readable++
+		}
+	}
+	for triplet, readability := range absolute {
+		if readability == 0 {
+			adaptiveness[triplet] = math.Exp(logSum / float64(readable))
+		}
+	}
+	return table.geometricMean(sequence, adaptiveness)
+}
+
+// EffectiveNumberOfCodons returns the ENC of a coding sequence, from Wright's
+// homozygosity of codon usage for every amino acid. Amino acids are grouped by
+// how many codons they have in the table, and a group with no amino acids
+// seen at least twice in the sequence is assumed to use its codons equally,
+// except isoleucine's three codons in the standard code, which are given the
+// average of the two and four codon groups, as Wright does. The ENC is never
+// more than the number of sense codons in the table.
+func (table *TranslationTable) EffectiveNumberOfCodons(sequence string) (float64, error) {
+	codons, err := splitCodons(sequence)
+	if err != nil {
+		return 0, err
+	}
+	usage := make(map[string]int)
+	for _, triplet := range codons {
+		usage[triplet]++
+	}
+
+	homozygositySums := make(map[int]float64)
+	homozygosityCounts := make(map[int]int)
+	aminoAcidCounts := make(map[int]int)
+	encoding := 0.0
+	senseCodons := 0
+	for _, aminoAcid := range table.AminoAcids {
+		if aminoAcid.Letter == "*" {
+			continue
+		}
+		degeneracy := len(aminoAcid.Codons)
+		senseCodons += degeneracy
+		if degeneracy == 1 {
+			encoding++
+			continue
+		}
+		aminoAcidCounts[degeneracy]++
+		total := 0
+		for _, codon := range aminoAcid.Codons {
+			total += usage[codon.Triplet]
+		}
+		if total < 2 {
+			continue
+		}
+		squares := 0.0
+		for _, codon := range aminoAcid.Codons {
+			frequency := float64(usage[codon.Triplet]) / float64(total)
+			squares += frequency * frequency
+		}
+		homozygositySums[degeneracy] += (float64(total)*squares - 1) / float64(total-1)
+		homozygosityCounts[degeneracy]++
+	}
+	if len(homozygosityCounts) == 0 {
+		return 0, errNoScorableCodons
+	}
+
+	average := func(degeneracy int) (float64, bool) {
+		if homozygosityCounts[degeneracy] == 0 || homozygositySums[degeneracy] == 0 {
+			return 0, false
+		}
+		return homozygositySums[degeneracy] / float64(homozygosityCounts[degeneracy]), true
+	}
+	for degeneracy, count := range aminoAcidCounts {
+		homozygosity, ok := average(degeneracy)
+		if !ok && degeneracy == 3 {
+			two, twoOK := average(2)
+			four, fourOK := average(4)
+			homozygosity, ok = (two+four)/2, twoOK && fourOK
+		}
+		if !ok {
+			homozygosity = 1 / float64(degeneracy)
+		}
+		encoding += float64(count) / homozygosity
+	}
+	return math.Min(encoding, float64(senseCodons)), nil
+}
+
+// geometricMean is the geometric mean of the adaptiveness of every scorable
+// codon of a sequence.
+func (table *TranslationTable) geometricMean(sequence string, adaptiveness map[string]float64) (float64, error) {
+	codons, err := splitCodons(sequence)
+	if err != nil {
+		return 0, err
+	}
+	skipped := make(map[string]bool)
+	for _, aminoAcid := range table.AminoAcids {
+		if !scorable(aminoAcid) {
+			for _, codon := range aminoAcid.Codons {
+				skipped[codon.Triplet] = true
+			}
+		}
+	}
+	logSum, scored := 0.0, 0
+	for _, triplet := range codons {
+		value, ok := adaptiveness[triplet]
+		if !ok || skipped[triplet] {
+			continue
+		}
+		logSum += math.Log(value)
+		scored++
+	}
+	if scored == 0 {
+		return 0, errNoScorableCodons
+	}
+	return math.Exp(logSum / float64(scored)), nil
+}
+
+// scorable is whether an amino acid's codons say anything about codon choice.
+func scorable(aminoAcid AminoAcid) bool {
+	return aminoAcid.Letter != "*" && len(aminoAcid.Codons) > 1
+}
+
+// splitCodons splits a coding sequence into uppercase codons.
+func splitCodons(sequence string) ([]string, error) {
+	if sequence == "" {
+		return nil, errEmptySequenceString
+	}
+	if len(sequence)%3 != 0 {
+		return nil, fmt.Errorf("coding sequence length %d isn't a multiple of 3", len(sequence))
+	}
+	sequence = strings.ToUpper(sequence)
+	codons := make([]string, 0, len(sequence)/3)
+	for position := 0; position < len(sequence); position += 3 {
+		codons = append(codons, sequence[position:position+3])
+	}
+	return codons, nil
+}
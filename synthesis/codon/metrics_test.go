@@ -0,0 +1,104 @@
+package codon
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodonAdaptationIndex(t *testing.T) {
+	table, err := NewTranslationTable(11)
+	require.NoError(t, err)
+
+	// every codon is used equally by default.
+	cai, err := table.CodonAdaptationIndex("ATGCTGCTTCTATAA")
+	require.NoError(t, err)
+	assert.InDelta(t, 1, cai, 1e-9)
+
+	setWeight(table, "CTG", 4)
+	setWeight(table, "CTA", 0)
+	// methionine and stops are left out.
+	cai, err = table.CodonAdaptationIndex("ATGCTGCTTTAA")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.5, cai, 1e-9)
+	cai, err = table.CodonAdaptationIndex("cta")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.125, cai, 1e-9)
+
+	_, err = table.CodonAdaptationIndex("ATGTGGTAA")
+	assert.ErrorIs(t, err, errNoScorableCodons)
+	_, err = table.CodonAdaptationIndex("ATGC")
+	assert.Error(t, err)
+	_, err = table.CodonAdaptationIndex("")
+	assert.ErrorIs(t, err, errEmptySequenceString)
+}
+
+func TestTRNAAdaptationIndex(t *testing.T) {
+	table, err := NewTranslationTable(11)
+	require.NoError(t, err)
+
+	// tRNA-Ile(GAU) reads ATC, and ATT by a G:U wobble. Nothing reads ATA, so
+	// it gets the geometric mean of the codons that are read.
+	tRNAs := map[string]int{"GAU": 1}
+	for sequence, expected := range map[string]float64{
+		"ATC":    1,
+		"ATT":    0.59,
+		"ATA":    math.Sqrt(0.59),
+		"ATCATT": math.Sqrt(0.59),
+	} {
+		tai, err := table.TRNAAdaptationIndex(sequence, tRNAs)
+		require.NoError(t, err)
+		assert.InDelta(t, expected, tai, 1e-9, sequence)
+	}
+
+	// an inosine at the wobble position reads codons ending in T, C, and A,
+	// but not G.
+	tRNAs = map[string]int{"AGC": 2, "CGC": 1}
+	for sequence, expected := range map[string]float64{
+		"GCT": 1,
+		"GCC": 0.72,
+		"GCA": 0.0001,
+		"GCG": 0.5,
+	} {
+		tai, err := table.TRNAAdaptationIndex(sequence, tRNAs)
+		require.NoError(t, err)
+		assert.InDelta(t, expected, tai, 1e-9, sequence)
+	}
+
+	_, err = table.TRNAAdaptationIndex("ATC", map[string]int{})
+	assert.Error(t, err)
+}
+
+func TestEffectiveNumberOfCodons(t *testing.T) {
+	table, err := NewTranslationTable(11)
+	require.NoError(t, err)
+
+	var onePerAminoAcid, everyCodon string
+	for _, aminoAcid := range table.AminoAcids {
+		if aminoAcid.Letter == "*" {
+			continue
+		}
+		onePerAminoAcid += aminoAcid.Codons[0].Triplet + aminoAcid.Codons[0].Triplet
+		for _, codon := range aminoAcid.Codons {
+			everyCodon += codon.Triplet + codon.Triplet
+		}
+	}
+	enc, err := table.EffectiveNumberOfCodons(onePerAminoAcid)
+	require.NoError(t, err)
+	assert.InDelta(t, 20, enc, 1e-9)
+
+	enc, err = table.EffectiveNumberOfCodons(everyCodon)
+	require.NoError(t, err)
+	assert.InDelta(t, 61, enc, 1e-9)
+
+	// with only a two codon amino acid seen, the amino acids of every other
+	// group are assumed to use their codons equally.
+	enc, err = table.EffectiveNumberOfCodons("AAAAAA")
+	require.NoError(t, err)
+	assert.InDelta(t, 2+9+3+20+18, enc, 1e-9)
+
+	_, err = table.EffectiveNumberOfCodons("ATGTGG")
+	assert.ErrorIs(t, err, errNoScorableCodons)
+}
@@ -0,0 +1,400 @@
+/*
+Package difficulty scores how hard a DNA sequence will be to synthesize.
+
+Synthesis companies won't make everything. Long runs of a single base, long
+repeats, stretches of very high or low GC content, and strong hairpins all
+make synthesis and assembly fail, so vendors screen every order and reject
+the sequences that have them, usually a day or two after it was placed.
+
+Score checks a sequence against a set of Rules like the ones vendors publish
+and reports every violation along with where it is, so a sequence can be
+fixed before it's ordered instead of after it's rejected. Twist and IDT are
+rules modelled on the public guidelines of those two companies. Vendors keep
+changing their rules and screen for more than they publish, so a clean report
+is a good sign rather than a guarantee.
+
+Hairpins are found by folding the sequence in overlapping windows with
+fold.Zuker, which is by far the slowest check. Leave HairpinWindow at zero
+to skip it.
+*/
+package difficulty
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/bebop/poly/fold"
+	"github.com/bebop/poly/search/motif"
+	"github.com/bebop/poly/stats"
+	"github.com/bebop/poly/transform"
+)
+
+// Rules are what a vendor will synthesize. Zero values turn a check off
+// unless a default is noted.
+type Rules struct {
+	Name                 string
+	MinLength, MaxLength int
+	// MinGC and MaxGC are limits on the GC content of the whole sequence, as
+	// fractions.
+	MinGC, MaxGC float64
+	// GCWindow is how many bases the windows MinWindowGC, MaxWindowGC, and
+	// MaxWindowGCDifference are checked over are.
+	GCWindow int
+	// MinWindowGC and MaxWindowGC are limits on the GC content of every
+	// window.
+	MinWindowGC, MaxWindowGC float64
+	// MaxWindowGCDifference is the most the GC content of the richest and
+	// poorest windows can differ by.
+	MaxWindowGCDifference float64
+	// MaxHomopolymer is the longest run of A or T allowed.
+	MaxHomopolymer int
+	// MaxGCHomopolymer is the longest run of G or C allowed. Defaults to
+	// MaxHomopolymer.
+	MaxGCHomopolymer int
+	// MaxRepeat is the longest stretch allowed to occur more than once, on
+	// either strand.
+	MaxRepeat int
+	// HairpinWindow is how many bases the windows folded to find hairpins
+	// are. Windows overlap by half.
+	HairpinWindow int
+	// MinHairpinEnergy is the most stable a window can fold, in kcal/mol.
+	MinHairpinEnergy float64
+	// Forbidden are motifs, which can have IUPAC ambiguity codes, that can't
+	// appear on either strand, like the sites of the enzymes a vendor clones
+	// with.
+	Forbidden []string
+}
+
+// Twist is modelled on Twist Bioscience's guidelines for gene fragments.
+var Twist = Rules{
+	Name:                  "Twist",
+	MinLength:             300,
+	MaxLength:             5000,
+	MinGC:                 0.25,
+	MaxGC:                 0.65,
+	GCWindow:              50,
+	MaxWindowGCDifference: 0.52,
+	MaxHomopolymer:        9,
+	MaxRepeat:             20,
+	HairpinWindow:         60,
+	MinHairpinEnergy:      -20,
+}
+
+// IDT is modelled on Integrated DNA Technologies' guidelines for gBlocks.
+var IDT = Rules{
+	Name:             "IDT",
+	MinLength:        125,
+	MaxLength:        3000,
+	MinGC:            0.25,
+	MaxGC:            0.75,
+	GCWindow:         50,
+	MinWindowGC:      0.15,
+	MaxWindowGC:      0.85,
+	MaxHomopolymer:   9,
+	MaxGCHomopolymer: 5,
+	MaxRepeat:        20,
+	HairpinWindow:    60,
+	MinHairpinEnergy: -20,
+}
+
+// Kind is the kind of rule a violation breaks.
+type Kind string
+
+// The kinds of violations.
+const (
+	Length             Kind = "length"
+	Ambiguous          Kind = "ambiguous"
+	GC                 Kind = "gc"
+	WindowGC           Kind = "window gc"
+	WindowGCDifference Kind = "window gc difference"
+	Homopolymer        Kind = "homopolymer"
+	Repeat             Kind = "repeat"
+	Hairpin            Kind = "hairpin"
+	Forbidden          Kind = "forbidden"
+)
+
+// Violation is a place a sequence breaks a rule.
+type Violation struct {
+	Kind Kind
+	// Start and End are where the violation is, as a half-open range. Length
+	// and GC violations cover the whole sequence.
+	Start, End int
+	// Value is what was measured, like a GC fraction, the length of a run or
+	// repeat, or the energy of a hairpin, and Limit is what it broke.
+	Value, Limit float64
+	Message      string
+}
+
+// Report is how a sequence does against a set of rules.
+type Report struct {
+	Rules      string // name of the rules.
+	Length     int
+	Violations []Violation // sorted by where they start.
+	// Difficulty is the fraction of the sequence covered by violations, from
+	// 0 for a sequence that breaks no rules to 1.
+	Difficulty float64
+}
+
+// Synthesizable is whether a sequence breaks none of the rules.
+func (report Report) Synthesizable() bool {
+	return len(report.Violations) == 0
+}
+
+// Score checks a sequence against rules.
+func Score(sequence string, rules Rules) (Report, error) {
+	return score(strings.ToUpper(sequence), rules, foldEnergy)
+}
+
+// score checks an uppercase sequence against rules, folding hairpin windows
+// with energy.
+func score(sequence string, rules Rules, energy func(start int, window string) (float64, error)) (Report, error) {
+	if rules.MaxGCHomopolymer == 0 {
+		rules.MaxGCHomopolymer = rules.MaxHomopolymer
+	}
+	report := Report{Rules: rules.Name, Length: len(sequence)}
+	add := func(violation Violation) {
+		report.Violations = append(report.Violations, violation)
+	}
+	whole := func(kind Kind, value, limit float64, message string) {
+		add(Violation{Kind: kind, Start: 0, End: len(sequence), Value: value, Limit: limit, Message: message})
+	}
+
+	length := float64(len(sequence))
+	switch {
+	case rules.MinLength > 0 && len(sequence) < rules.MinLength:
+		whole(Length, length, float64(rules.MinLength), fmt.Sprintf("%d bases is shorter than %d", len(sequence), rules.MinLength))
+	case rules.MaxLength > 0 && len(sequence) > rules.MaxLength:
+		whole(Length, length, float64(rules.MaxLength), fmt.Sprintf("%d bases is longer than %d", len(sequence), rules.MaxLength))
+	}
+	gc := stats.GCContent(sequence)
+	switch {
+	case rules.MinGC > 0 && gc < rules.MinGC:
+		whole(GC, gc, rules.MinGC, fmt.Sprintf("GC content %.2f is below %.2f", gc, rules.MinGC))
+	case rules.MaxGC > 0 && gc > rules.MaxGC:
+		whole(GC, gc, rules.MaxGC, fmt.Sprintf("GC content %.2f is above %.2f", gc, rules.MaxGC))
+	}
+
+	for _, run := range ambiguousRuns(sequence) {
+		add(Violation{Kind: Ambiguous, Start: run[0], End: run[1], Value: float64(run[1] - run[0]), Message: fmt.Sprintf("%d ambiguous bases", run[1]-run[0])})
+	}
+	for _, violation := range gcWindowViolations(sequence, rules) {
+		add(violation)
+	}
+	for _, homopolymer := range stats.Homopolymers(sequence, min(rules.MaxHomopolymer, rules.MaxGCHomopolymer)+1) {
+		limit := rules.MaxHomopolymer
+		if homopolymer.Base == 'G' || homopolymer.Base == 'C' {
+			limit = rules.MaxGCHomopolymer
+		}
+		if limit > 0 && homopolymer.Len() > limit {
+			add(Violation{Kind: Homopolymer, Start: homopolymer.Start, End: homopolymer.End, Value: float64(homopolymer.Len()), Limit: float64(limit), Message: fmt.Sprintf("run of %d %cs is longer than %d", homopolymer.Len(), homopolymer.Base, limit)})
+		}
+	}
+	if rules.MaxRepeat > 0 {
+		for _, violation := range repeatViolations(sequence, rules.MaxRepeat) {
+			add(violation)
+		}
+	}
+	if rules.HairpinWindow > 0 {
+		violations, err := hairpinViolations(sequence, rules, energy)
+		if err != nil {
+			return Report{}, err
+		}
+		report.Violations = append(report.Violations, violations...)
+	}
+	for _, forbidden := range rules.Forbidden {
+		pattern, err := motif.CompileIUPAC(forbidden)
+		if err != nil {
+			return Report{}, err
+		}
+		for _, match := range pattern.Find(sequence, motif.Options{}) {
+			add(Violation{Kind: Forbidden, Start: match.Start, End: match.End, Message: fmt.Sprintf("forbidden motif %s", forbidden)})
+		}
+	}
+
+	sort.SliceStable(report.Violations, func(i, j int) bool {
+		return report.Violations[i].Start < report.Violations[j].Start
+	})
+	if len(sequence) > 0 {
+		report.Difficulty = float64(covered(report.Violations)) / length
+	}
+	return report, nil
+}
+
+// ambiguousRuns returns the runs of bases other than A, C, G, and T, as
+// half-open ranges.
+func ambiguousRuns(sequence string) [][2]int {
+	var runs [][2]int
+	for start := 0; start < len(sequence); start++ {
+		if strings.IndexByte("ACGT", sequence[start]) != -1 {
+			continue
+		}
+		end := start + 1
+		for end < len(sequence) && strings.IndexByte("ACGT", sequence[end]) == -1 {
+			end++
+		}
+		runs = append(runs, [2]int{start, end})
+		start = end
+	}
+	return runs
+}
+
+// gcWindowViolations checks the GC content of every window of a sequence,
+// merging overlapping windows that break the same limit into one violation.
+func gcWindowViolations(sequence string, rules Rules) []Violation {
+	size := rules.GCWindow
+	if size == 0 || len(sequence) < size || (rules.MinWindowGC == 0 && rules.MaxWindowGC == 0 && rules.MaxWindowGCDifference == 0) {
+		return nil
+	}
+	var violations []Violation
+	lowest, highest := stats.Window{GC: math.Inf(1)}, stats.Window{GC: math.Inf(-1)}
+	extend := func(window stats.Window, limit float64, low bool) {
+		last := len(violations) - 1
+		if last >= 0 && violations[last].Limit == limit && window.Start < violations[last].End {
+			violations[last].End = window.End
+			if low == (window.GC < violations[last].Value) {
+				violations[last].Value = window.GC
+			}
+			return
+		}
+		violations = append(violations, Violation{Kind: WindowGC, Start: window.Start, End: window.End, Value: window.GC, Limit: limit})
+	}
+	for _, window := range stats.Profile(sequence, stats.Options{Window: size, Step: 1}) {
+		switch {
+		case rules.MinWindowGC > 0 && window.GC < rules.MinWindowGC:
+			extend(window, rules.MinWindowGC, true)
+		case rules.MaxWindowGC > 0 && window.GC > rules.MaxWindowGC:
+			extend(window, rules.MaxWindowGC, false)
+		}
+		if window.GC < lowest.GC {
+			lowest = window
+		}
+		if window.GC > highest.GC {
+			highest = window
+		}
+	}
+	for index := range violations {
+		violations[index].Message = fmt.Sprintf("%d base windows with GC content %.2f, past %.2f", size, violations[index].Value, violations[index].Limit)
+	}
+	if difference := highest.GC - lowest.GC; rules.MaxWindowGCDifference > 0 && difference > rules.MaxWindowGCDifference {
+		violations = append(violations, Violation{
+			Kind:    WindowGCDifference,
+			Start:   min(lowest.Start, highest.Start),
+			End:     max(lowest.End, highest.End),
+			Value:   difference,
+			Limit:   rules.MaxWindowGCDifference,
+			Message: fmt.Sprintf("GC content of %d base windows ranges from %.2f at %d to %.2f at %d", size, lowest.GC, lowest.Start, highest.GC, highest.Start),
+		})
+	}
+	return violations
+}
+
+// repeatViolations finds every stretch longer than maxRepeat that occurs
+// somewhere else in a sequence, directly or inverted, merging overlapping
+// stretches into one violation.
+func repeatViolations(sequence string, maxRepeat int) []Violation {
+	size := maxRepeat + 1
+	if len(sequence) < size {
+		return nil
+	}
+	positions := make(map[string][]int)
+	for start := 0; start+size <= len(sequence); start++ {
+		kmer := sequence[start : start+size]
+		positions[kmer] = append(positions[kmer], start)
+	}
+	var violations []Violation
+	for start := 0; start+size <= len(sequence); start++ {
+		kmer := sequence[start : start+size]
+		if strings.Trim(kmer, "ACGT") != "" {
+			continue
+		}
+		inverted := positions[transform.ReverseComplement(kmer)]
+		// a palindrome isn't an inverted repeat of itself.
+		invertedRepeat := len(inverted) > 1 || (len(inverted) == 1 && inverted[0] != start)
+		if len(positions[kmer]) < 2 && !invertedRepeat {
+			continue
+		}
+		last := len(violations) - 1
+		if last >= 0 && start <= violations[last].End {
+			violations[last].End = start + size
+			continue
+		}
+		violations = append(violations, Violation{Kind: Repeat, Start: start, End: start + size, Limit: float64(maxRepeat)})
+	}
+	for index, violation := range violations {
+		violations[index].Value = float64(violation.End - violation.Start)
+		violations[index].Message = fmt.Sprintf("%d bases of repeats longer than %d", violation.End-violation.Start, maxRepeat)
+	}
+	return violations
+}
+
+// hairpinWindows returns where the windows folded to find hairpins start.
+// They overlap by half, and the last is moved back to end with the sequence.
+func hairpinWindows(length, size int) []int {
+	size = min(size, length)
+	step := max(size/2, 1)
+	var starts []int
+	for start := 0; size > 0; start += step {
+		if start+size >= length {
+			starts = append(starts, length-size)
+			break
+		}
+		starts = append(starts, start)
+	}
+	return starts
+}
+
+// foldEnergy returns the minimum free energy of a window.
+func foldEnergy(start int, window string) (float64, error) {
+	result, err := fold.Fold(window, fold.Options{})
+	if err != nil {
+		return 0, fmt.Errorf("could not fold %d to %d: %w", start, start+len(window), err)
+	}
+	return result.MinimumFreeEnergy(), nil
+}
+
+// hairpinViolations finds the windows of a sequence that fold too stably,
+// using energy to fold them, merging overlapping windows into one violation.
+// Windows with ambiguous bases aren't folded.
+func hairpinViolations(sequence string, rules Rules, energy func(start int, window string) (float64, error)) ([]Violation, error) {
+	size := min(rules.HairpinWindow, len(sequence))
+	var violations []Violation
+	for _, start := range hairpinWindows(len(sequence), size) {
+		window := sequence[start : start+size]
+		if strings.Trim(window, "ACGT") != "" {
+			continue
+		}
+		windowEnergy, err := energy(start, window)
+		if err != nil {
+			return nil, err
+		}
+		if windowEnergy >= rules.MinHairpinEnergy {
+			continue
+		}
+		last := len(violations) - 1
+		if last >= 0 && start < violations[last].End {
+			violations[last].End = start + size
+			violations[last].Value = math.Min(violations[last].Value, windowEnergy)
+			continue
+		}
+		violations = append(violations, Violation{Kind: Hairpin, Start: start, End: start + size, Value: windowEnergy, Limit: rules.MinHairpinEnergy})
+	}
+	for index, violation := range violations {
+		violations[index].Message = fmt.Sprintf("folds with %.1f kcal/mol, more stable than %.1f", violation.Value, violation.Limit)
+	}
+	return violations, nil
+}
+
+// covered counts the bases covered by at least one violation.
+func covered(violations []Violation) int {
+	total, end := 0, 0
+	for _, violation := range violations {
+		// violations are sorted by start.
+		if violation.End > end {
+			total += violation.End - max(violation.Start, end)
+			end = violation.End
+		}
+	}
+	return total
+}
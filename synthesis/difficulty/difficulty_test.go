@@ -0,0 +1,164 @@
+package difficulty
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/random"
+	"github.com/bebop/poly/transform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomSequence makes a sequence without runs longer than three, so it
+// passes the vendor rules.
+func randomSequence(seed int64, length int) string {
+	bases, _ := random.DNASequence(2*length, seed)
+	return breakRuns(bases, length)
+}
+
+// randomATRich is randomSequence with only As and Ts.
+func randomATRich(seed int64, length int) string {
+	bases, _ := random.DNASequence(2*length, seed)
+	return breakRuns(strings.NewReplacer("C", "A", "G", "T").Replace(bases), length)
+}
+
+// breakRuns returns the first length bases of a sequence, skipping any that
+// would make a run longer than three.
+func breakRuns(bases string, length int) string {
+	sequence := make([]byte, 0, length)
+	for index := 0; index < len(bases) && len(sequence) < length; index++ {
+		base := bases[index]
+		if n := len(sequence); n >= 3 && sequence[n-1] == base && sequence[n-2] == base && sequence[n-3] == base {
+			continue
+		}
+		sequence = append(sequence, base)
+	}
+	return string(sequence)
+}
+
+// withoutHairpins is Twist without folding, which keeps tests fast.
+func withoutHairpins(rules Rules) Rules {
+	rules.HairpinWindow = 0
+	return rules
+}
+
+func kinds(report Report) []Kind {
+	var found []Kind
+	for _, violation := range report.Violations {
+		found = append(found, violation.Kind)
+	}
+	return found
+}
+
+func TestScoreClean(t *testing.T) {
+	sequence := randomSequence(1, 400)
+	for _, rules := range []Rules{Twist, IDT} {
+		report, err := Score(sequence, rules)
+		require.NoError(t, err)
+		assert.True(t, report.Synthesizable(), "%s: %v", rules.Name, report.Violations)
+		assert.Equal(t, 0.0, report.Difficulty)
+		assert.Equal(t, rules.Name, report.Rules)
+		assert.Equal(t, 400, report.Length)
+	}
+}
+
+func TestScoreHomopolymer(t *testing.T) {
+	sequence := randomSequence(2, 200) + "C" + strings.Repeat("A", 12) + "C" + randomSequence(3, 200)
+	report, err := Score(sequence, withoutHairpins(Twist))
+	require.NoError(t, err)
+	require.Equal(t, []Kind{Homopolymer}, kinds(report))
+	violation := report.Violations[0]
+	assert.Equal(t, 201, violation.Start)
+	assert.Equal(t, 213, violation.End)
+	assert.Equal(t, 12.0, violation.Value)
+	assert.Equal(t, 9.0, violation.Limit)
+	assert.InDelta(t, 12.0/414, report.Difficulty, 1e-9)
+
+	// IDT is stricter about runs of G and C.
+	sequence = randomSequence(2, 200) + "TGGGGGGT" + randomSequence(3, 200)
+	report, err = Score(sequence, withoutHairpins(IDT))
+	require.NoError(t, err)
+	assert.Equal(t, []Kind{Homopolymer}, kinds(report))
+	report, err = Score(sequence, withoutHairpins(Twist))
+	require.NoError(t, err)
+	assert.True(t, report.Synthesizable())
+}
+
+func TestScoreRepeats(t *testing.T) {
+	repeat := randomSequence(4, 30)
+	sequence := randomSequence(5, 150) + repeat + randomSequence(6, 150) + repeat + randomSequence(7, 100)
+	report, err := Score(sequence, withoutHairpins(Twist))
+	require.NoError(t, err)
+	require.Equal(t, []Kind{Repeat, Repeat}, kinds(report))
+	assert.Equal(t, 150, report.Violations[0].Start)
+	assert.Equal(t, 180, report.Violations[0].End)
+	assert.Equal(t, 330, report.Violations[1].Start)
+	assert.Equal(t, 360, report.Violations[1].End)
+
+	// inverted repeats count too.
+	sequence = randomSequence(5, 150) + repeat + randomSequence(6, 150) + transform.ReverseComplement(repeat) + randomSequence(7, 100)
+	report, err = Score(sequence, withoutHairpins(Twist))
+	require.NoError(t, err)
+	assert.Equal(t, []Kind{Repeat, Repeat}, kinds(report))
+}
+
+func TestScoreGC(t *testing.T) {
+	sequence := randomSequence(8, 200) + randomATRich(13, 80) + randomSequence(9, 200)
+	report, err := Score(sequence, withoutHairpins(Twist))
+	require.NoError(t, err)
+	require.Equal(t, []Kind{WindowGCDifference}, kinds(report))
+	assert.Greater(t, report.Violations[0].Value, 0.52)
+
+	report, err = Score(sequence, withoutHairpins(IDT))
+	require.NoError(t, err)
+	require.Equal(t, []Kind{WindowGC}, kinds(report))
+	// every window mostly in the AT rich stretch, merged into one.
+	assert.Equal(t, 0.0, report.Violations[0].Value)
+	assert.Less(t, report.Violations[0].Start, 200)
+	assert.Greater(t, report.Violations[0].End, 280)
+
+	report, err = Score(strings.Repeat("GCGGCCAT", 50), Rules{MaxGC: 0.65})
+	require.NoError(t, err)
+	require.Equal(t, []Kind{GC}, kinds(report))
+	assert.Equal(t, 0.75, report.Violations[0].Value)
+	assert.Equal(t, 1.0, report.Difficulty)
+}
+
+func TestScoreOther(t *testing.T) {
+	report, err := Score(randomSequence(10, 100), withoutHairpins(Twist))
+	require.NoError(t, err)
+	assert.Equal(t, []Kind{Length}, kinds(report))
+	assert.Equal(t, 1.0, report.Difficulty)
+
+	rules := Rules{Forbidden: []string{"GGTCTC"}}
+	report, err = Score("ATGAGACCATNNNGT", rules)
+	require.NoError(t, err)
+	require.Equal(t, []Kind{Forbidden, Ambiguous}, kinds(report))
+	assert.Equal(t, 2, report.Violations[0].Start)
+	assert.Equal(t, 8, report.Violations[0].End)
+	assert.Equal(t, 10, report.Violations[1].Start)
+	assert.Equal(t, 13, report.Violations[1].End)
+
+	_, err = Score("ATG", Rules{Forbidden: []string{"GG!"}})
+	assert.Error(t, err)
+}
+
+func TestHairpinWindows(t *testing.T) {
+	assert.Equal(t, []int{0, 30, 52}, hairpinWindows(112, 60))
+	assert.Equal(t, []int{0, 30, 60}, hairpinWindows(120, 60))
+	assert.Equal(t, []int{0}, hairpinWindows(40, 60))
+	assert.Empty(t, hairpinWindows(0, 60))
+}
+
+func TestScoreHairpin(t *testing.T) {
+	stem := "GCGGCCGCAGCCGC"
+	sequence := randomSequence(11, 100) + stem + "TTTT" + transform.ReverseComplement(stem) + randomSequence(12, 100)
+	report, err := Score(sequence, Rules{HairpinWindow: 60, MinHairpinEnergy: -20})
+	require.NoError(t, err)
+	require.Equal(t, []Kind{Hairpin}, kinds(report))
+	violation := report.Violations[0]
+	assert.LessOrEqual(t, violation.Start, 100)
+	assert.GreaterOrEqual(t, violation.End, 132)
+	assert.Less(t, violation.Value, -20.0)
+}
@@ -0,0 +1,38 @@
+package difficulty_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/synthesis/difficulty"
+)
+
+func ExampleScore() {
+	sequence := "ATGACCATGATTACGCCAAGCAAAAAAAAAAAAGCTTGCATGCCTGCAGGTCGACTCTAGAGGATCC"
+	rules := difficulty.Rules{Name: "example", MaxHomopolymer: 9, Forbidden: []string{"GGATCC"}}
+	report, _ := difficulty.Score(sequence, rules)
+	for _, violation := range report.Violations {
+		fmt.Println(violation.Kind, violation.Start, violation.End, violation.Message)
+	}
+	fmt.Println(report.Synthesizable())
+	// Output:
+	// homopolymer 21 33 run of 12 As is longer than 9
+	// forbidden 61 67 forbidden motif GGATCC
+	// false
+}
+
+func ExampleFix() {
+	// a short CDS, MKKKKD*, with a run of 12 As.
+	sequence := "ATGAAAAAAAAAAAAGATTAA"
+	options := difficulty.FixOptions{
+		Features: []genbank.Feature{{Type: "CDS", Attributes: map[string]string{"label": "gene"}, Location: genbank.Location{End: len(sequence)}}},
+	}
+	fixed, changes, report, _ := difficulty.Fix(sequence, difficulty.Rules{MaxHomopolymer: 8}, options)
+	for _, change := range changes {
+		fmt.Println(change.Position, change.From, change.To, change.Feature, change.Reason)
+	}
+	fmt.Println(fixed, report.Synthesizable())
+	// Output:
+	// 9 AAA AAG gene homopolymer
+	// ATGAAAAAAAAGAAAGATTAA true
+}
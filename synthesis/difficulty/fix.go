@@ -0,0 +1,444 @@
+package difficulty
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/synthesis/codon"
+	"github.com/bebop/poly/transform"
+)
+
+/******************************************************************************
+
+Fixing begins here.
+
+Fix edits a sequence until it breaks none of the rules, or until it runs out
+of edits that help. Every round it takes the first violation it hasn't given
+up on and tries edits inside it: swapping codons of CDS features for
+synonymous ones, which keeps the proteins the same, and changing single bases
+outside of them. Protected regions, like promoters, primer binding sites, or
+anything else whose exact sequence matters, are never touched.
+
+Each edit is scored by how much it shrinks the violations of the whole
+sequence, and the best one is kept if it helps at all. If none of them do,
+Fix gives up on that violation and moves on to the next. Folding is what
+makes scoring slow, so windows are only refolded when an edit lands in them,
+and only a sample of the possible edits is tried for each violation.
+
+Editing can't fix a sequence that's too long or too short, or has ambiguous
+bases, so those violations are left alone.
+
+******************************************************************************/
+
+// FixOptions changes how Fix edits a sequence. Zero values are replaced with
+// the defaults noted on each field.
+type FixOptions struct {
+	// Features are the features of the sequence. Codons of CDS features are
+	// only ever swapped for synonymous ones, and their start codons, and
+	// bases in more than one CDS, aren't changed at all.
+	Features []genbank.Feature
+	// Protected are regions of the sequence that can't be changed.
+	Protected []genbank.Location
+	// Table picks the synonymous codons CDS features can use, which are the
+	// ones it gives any weight. Defaults to NCBI translation table 11.
+	Table *codon.TranslationTable
+	// MaxChanges is the most edits Fix makes. Defaults to 200.
+	MaxChanges int
+	// Candidates is how many edits are tried for each violation. Defaults to
+	// 32.
+	Candidates int
+	// Seed seeds the sampling of edits.
+	Seed int64
+}
+
+// Change is an edit Fix made.
+type Change struct {
+	Position int    // where the edit starts.
+	From, To string // bases as written on the forward strand.
+	// Feature is the label of the CDS a codon was swapped in, or empty for
+	// edits outside of CDS features.
+	Feature string
+	Reason  Kind // kind of violation the edit was made to fix.
+}
+
+// position states, for positions that aren't in an editable codon.
+const (
+	intergenic = -1
+	frozen     = -2
+)
+
+// codonSite is a codon of a CDS feature that can be swapped.
+type codonSite struct {
+	start   int // the codon's bases are start to start+3 on the forward strand.
+	reverse bool
+	feature string
+}
+
+// edit is a candidate edit.
+type edit struct {
+	start   int
+	to      string // forward strand.
+	feature string
+}
+
+// fixer holds the state of a single sequence's fixing.
+type fixer struct {
+	rules, cheap Rules
+	options      FixOptions
+	sequence     []byte
+	codons       []codonSite
+	codonAt      []int // index into codons of every position, or intergenic, or frozen.
+	synonyms     map[string][]string
+	energies     map[int]float64 // hairpin window energies by where the window starts.
+	random       *rand.Rand
+}
+
+// Fix edits a sequence to remove its violations of rules, keeping the
+// proteins of its CDS features and its protected regions the same. It
+// returns the fixed sequence, the edits made to it, and its report, which
+// lists any violations Fix couldn't remove.
+func Fix(sequence string, rules Rules, options FixOptions) (string, []Change, Report, error) {
+	if options.Table == nil {
+		table, err := codon.NewTranslationTable(11)
+		if err != nil {
+			return "", nil, Report{}, err
+		}
+		options.Table = table
+	}
+	if options.MaxChanges == 0 {
+		options.MaxChanges = 200
+	}
+	if options.Candidates == 0 {
+		options.Candidates = 32
+	}
+	fixer := newFixer(strings.ToUpper(sequence), rules, options)
+
+	var changes []Change
+	givenUp := make(map[Violation]bool)
+	for {
+		report, err := score(string(fixer.sequence), fixer.rules, fixer.cachedEnergy)
+		if err != nil {
+			return "", nil, Report{}, err
+		}
+		target, ok := Violation{}, false
+		for _, violation := range report.Violations {
+			if violation.Kind != Length && violation.Kind != Ambiguous && !givenUp[violation] {
+				target, ok = violation, true
+				break
+			}
+		}
+		if !ok || len(changes) >= options.MaxChanges {
+			return string(fixer.sequence), changes, report, nil
+		}
+		best, improvement, err := fixer.bestEdit(target)
+		if err != nil {
+			return "", nil, Report{}, err
+		}
+		if improvement <= 0 {
+			givenUp[target] = true
+			continue
+		}
+		changes = append(changes, Change{
+			Position: best.start,
+			From:     string(fixer.sequence[best.start : best.start+len(best.to)]),
+			To:       best.to,
+			Feature:  best.feature,
+			Reason:   target.Kind,
+		})
+		fixer.apply(best)
+	}
+}
+
+// newFixer works out which positions of a sequence can be edited, and how.
+func newFixer(sequence string, rules Rules, options FixOptions) *fixer {
+	cheap := rules
+	cheap.HairpinWindow = 0
+	fixer := &fixer{
+		rules:    rules,
+		cheap:    cheap,
+		options:  options,
+		sequence: []byte(sequence),
+		codonAt:  make([]int, len(sequence)),
+		synonyms: synonymousCodons(options.Table),
+		energies: make(map[int]float64),
+		random:   rand.New(rand.NewSource(options.Seed)),
+	}
+	for position := range fixer.codonAt {
+		fixer.codonAt[position] = intergenic
+	}
+	inside := func(position int) bool { return position >= 0 && position < len(sequence) }
+	protected := make([]bool, len(sequence))
+	for _, location := range options.Protected {
+		for _, base := range locationPositions(location) {
+			if inside(base.position) {
+				protected[base.position] = true
+				fixer.codonAt[base.position] = frozen
+			}
+		}
+	}
+
+	owners := make([]int, len(sequence))
+	var features []genbank.Feature
+	var coding [][]strandedPosition
+	for _, feature := range options.Features {
+		if feature.Type != "CDS" {
+			continue
+		}
+		positions := locationPositions(feature.Location)
+		features = append(features, feature)
+		coding = append(coding, positions)
+		for _, base := range positions {
+			if inside(base.position) {
+				owners[base.position]++
+				fixer.codonAt[base.position] = frozen
+			}
+		}
+	}
+	editable := func(position int) bool {
+		return inside(position) && owners[position] == 1 && !protected[position]
+	}
+	for index, positions := range coding {
+		offset := 0
+		if codonStart, err := strconv.Atoi(features[index].Attributes["codon_start"]); err == nil {
+			offset = max(codonStart-1, 0)
+		}
+		// the first codon is the start codon, which is kept.
+		for first := offset + 3; first+3 <= len(positions); first += 3 {
+			site, ok := codonSiteOf(positions[first:first+3], editable)
+			if !ok {
+				continue
+			}
+			site.feature = featureLabel(features[index])
+			for position := site.start; position < site.start+3; position++ {
+				fixer.codonAt[position] = len(fixer.codons)
+			}
+			fixer.codons = append(fixer.codons, site)
+		}
+	}
+	return fixer
+}
+
+// strandedPosition is a position of a location, and which strand it's read
+// on.
+type strandedPosition struct {
+	position int
+	reverse  bool
+}
+
+// locationPositions returns the positions of a location in the order they're
+// read, 5' to 3' on their own strand.
+func locationPositions(location genbank.Location) []strandedPosition {
+	var positions []strandedPosition
+	if len(location.SubLocations) > 0 {
+		for _, sublocation := range location.SubLocations {
+			positions = append(positions, locationPositions(sublocation)...)
+		}
+	} else {
+		for position := location.Start; position < location.End; position++ {
+			positions = append(positions, strandedPosition{position: position})
+		}
+	}
+	if location.Complement {
+		for left, right := 0, len(positions)-1; left < right; left, right = left+1, right-1 {
+			positions[left], positions[right] = positions[right], positions[left]
+		}
+		for index := range positions {
+			positions[index].reverse = !positions[index].reverse
+		}
+	}
+	return positions
+}
+
+// codonSiteOf returns the codon at three positions of a CDS, if it can be
+// swapped: its bases have to be editable, and next to each other on one
+// strand.
+func codonSiteOf(positions []strandedPosition, editable func(position int) bool) (codonSite, bool) {
+	step := 1
+	if positions[0].reverse {
+		step = -1
+	}
+	for index, base := range positions {
+		if base.reverse != positions[0].reverse || base.position != positions[0].position+index*step {
+			return codonSite{}, false
+		}
+		if !editable(base.position) {
+			return codonSite{}, false
+		}
+	}
+	start := positions[0].position
+	if step < 0 {
+		start = positions[2].position
+	}
+	return codonSite{start: start, reverse: step < 0}, true
+}
+
+// featureLabel names a feature for the change log.
+func featureLabel(feature genbank.Feature) string {
+	for _, attribute := range []string{"label", "gene", "locus_tag", "product"} {
+		if value, ok := feature.Attributes[attribute]; ok {
+			return value
+		}
+	}
+	return feature.Type
+}
+
+// synonymousCodons maps every codon a table gives weight to, to the other
+// codons of its amino acid it gives weight to.
+func synonymousCodons(table *codon.TranslationTable) map[string][]string {
+	synonyms := make(map[string][]string)
+	for _, aminoAcid := range table.AminoAcids {
+		var triplets []string
+		for _, candidate := range aminoAcid.Codons {
+			if candidate.Weight > 0 {
+				triplets = append(triplets, strings.ToUpper(candidate.Triplet))
+			}
+		}
+		for _, triplet := range triplets {
+			for _, other := range triplets {
+				if other != triplet {
+					synonyms[triplet] = append(synonyms[triplet], other)
+				}
+			}
+		}
+	}
+	return synonyms
+}
+
+// candidates returns every edit inside a violation. Only the windows at the
+// ends of a window GC difference violation, which are the richest and
+// poorest, are edited.
+func (fixer *fixer) candidates(violation Violation) []edit {
+	positions := make([]int, 0, violation.End-violation.Start)
+	for position := violation.Start; position < violation.End; position++ {
+		if violation.Kind == WindowGCDifference && position >= violation.Start+fixer.rules.GCWindow && position < violation.End-fixer.rules.GCWindow {
+			continue
+		}
+		positions = append(positions, position)
+	}
+
+	var edits []edit
+	seen := make(map[int]bool)
+	for _, position := range positions {
+		switch site := fixer.codonAt[position]; {
+		case site == intergenic:
+			for _, base := range []byte("ACGT") {
+				if base != fixer.sequence[position] {
+					edits = append(edits, edit{start: position, to: string(base)})
+				}
+			}
+		case site >= 0 && !seen[site]:
+			seen[site] = true
+			codonSite := fixer.codons[site]
+			current := string(fixer.sequence[codonSite.start : codonSite.start+3])
+			if codonSite.reverse {
+				current = transform.ReverseComplement(current)
+			}
+			for _, synonym := range fixer.synonyms[current] {
+				if codonSite.reverse {
+					synonym = transform.ReverseComplement(synonym)
+				}
+				edits = append(edits, edit{start: codonSite.start, to: synonym, feature: codonSite.feature})
+			}
+		}
+	}
+	fixer.random.Shuffle(len(edits), func(i, j int) { edits[i], edits[j] = edits[j], edits[i] })
+	return edits[:min(len(edits), fixer.options.Candidates)]
+}
+
+// bestEdit returns the edit inside a violation that lowers the penalty of
+// the sequence's violations the most, and by how much.
+func (fixer *fixer) bestEdit(violation Violation) (edit, float64, error) {
+	current, err := score(string(fixer.sequence), fixer.cheap, nil)
+	if err != nil {
+		return edit{}, 0, err
+	}
+	currentPenalty := penalty(current.Violations)
+
+	var best edit
+	bestImprovement := math.Inf(-1)
+	for _, candidate := range fixer.candidates(violation) {
+		edited := []byte(string(fixer.sequence))
+		copy(edited[candidate.start:], candidate.to)
+		report, err := score(string(edited), fixer.cheap, nil)
+		if err != nil {
+			return edit{}, 0, err
+		}
+		improvement := currentPenalty - penalty(report.Violations)
+		if fixer.rules.HairpinWindow > 0 {
+			for _, start := range fixer.windowsOf(candidate) {
+				end := start + min(fixer.rules.HairpinWindow, len(edited))
+				before, err := fixer.cachedEnergy(start, string(fixer.sequence[start:end]))
+				if err != nil {
+					return edit{}, 0, err
+				}
+				after, err := foldEnergy(start, string(edited[start:end]))
+				if err != nil {
+					return edit{}, 0, err
+				}
+				improvement += hairpinPenalty(before, fixer.rules) - hairpinPenalty(after, fixer.rules)
+			}
+		}
+		if improvement > bestImprovement {
+			best, bestImprovement = candidate, improvement
+		}
+	}
+	return best, bestImprovement, nil
+}
+
+// apply makes an edit, forgetting the energies of the windows it changes.
+func (fixer *fixer) apply(change edit) {
+	if fixer.rules.HairpinWindow > 0 {
+		for _, start := range fixer.windowsOf(change) {
+			delete(fixer.energies, start)
+		}
+	}
+	copy(fixer.sequence[change.start:], change.to)
+}
+
+// windowsOf returns where the hairpin windows an edit lands in start.
+func (fixer *fixer) windowsOf(change edit) []int {
+	size := min(fixer.rules.HairpinWindow, len(fixer.sequence))
+	var windows []int
+	for _, start := range hairpinWindows(len(fixer.sequence), size) {
+		if start < change.start+len(change.to) && change.start < start+size {
+			windows = append(windows, start)
+		}
+	}
+	return windows
+}
+
+// cachedEnergy folds a window of the sequence being fixed, unless it was
+// already folded since it last changed.
+func (fixer *fixer) cachedEnergy(start int, window string) (float64, error) {
+	if energy, ok := fixer.energies[start]; ok {
+		return energy, nil
+	}
+	energy, err := foldEnergy(start, window)
+	if err != nil {
+		return 0, err
+	}
+	fixer.energies[start] = energy
+	return energy, nil
+}
+
+// penalty weighs violations by how many there are, then how far past their
+// limits they are, then how much of the sequence they cover, so edits that
+// shrink a violation without removing it still count.
+func penalty(violations []Violation) float64 {
+	total := 0.0
+	for _, violation := range violations {
+		total += 1000 + 100*math.Abs(violation.Value-violation.Limit) + float64(violation.End-violation.Start)
+	}
+	return total
+}
+
+// hairpinPenalty is the penalty of a single folded window.
+func hairpinPenalty(energy float64, rules Rules) float64 {
+	if energy >= rules.MinHairpinEnergy {
+		return 0
+	}
+	return 1000 + 100*(rules.MinHairpinEnergy-energy)
+}
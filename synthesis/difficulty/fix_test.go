@@ -0,0 +1,87 @@
+package difficulty
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/synthesis/codon"
+	"github.com/bebop/poly/transform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFix(t *testing.T) {
+	forward := "ATGAGCAAAAAAAAAAAAGGTCTCGATTGA" // MSKKKKGLD*, with 12 As and a BsaI site.
+	intergenic := "CGATCG" + strings.Repeat("T", 12) + "CGATCG"
+	protected := "ATC" + strings.Repeat("G", 10) + "CTA"
+	reverse := transform.ReverseComplement("ATGAAAAAAAAAAAAGATTAA") // MKKKKD*
+	sequence := forward + intergenic + protected + reverse
+
+	protectedStart := len(forward) + len(intergenic)
+	reverseStart := protectedStart + len(protected)
+	options := FixOptions{
+		Features: []genbank.Feature{
+			{Type: "CDS", Attributes: map[string]string{"label": "forward"}, Location: genbank.Location{Start: 0, End: len(forward)}},
+			{Type: "CDS", Attributes: map[string]string{"gene": "reverse"}, Location: genbank.Location{Start: reverseStart, End: len(sequence), Complement: true}},
+		},
+		Protected: []genbank.Location{{Start: protectedStart, End: reverseStart}},
+	}
+	rules := Rules{MaxHomopolymer: 8, Forbidden: []string{"GGTCTC"}}
+	fixed, changes, report, err := Fix(sequence, rules, options)
+	require.NoError(t, err)
+	require.Len(t, fixed, len(sequence))
+
+	// only the protected homopolymer is left.
+	require.Equal(t, []Kind{Homopolymer}, kinds(report))
+	assert.Equal(t, protectedStart+3, report.Violations[0].Start)
+	assert.Equal(t, protected, fixed[protectedStart:reverseStart])
+
+	// the proteins are the same.
+	table, err := codon.NewTranslationTable(11)
+	require.NoError(t, err)
+	protein, err := table.Translate(fixed[:len(forward)])
+	require.NoError(t, err)
+	assert.Equal(t, "MSKKKKGLD*", protein)
+	protein, err = table.Translate(transform.ReverseComplement(fixed[reverseStart:]))
+	require.NoError(t, err)
+	assert.Equal(t, "MKKKKD*", protein)
+
+	// and every change is logged.
+	reasons := make(map[string]bool)
+	edited := []byte(sequence)
+	for _, change := range changes {
+		assert.Equal(t, change.From, string(edited[change.Position:change.Position+len(change.From)]))
+		copy(edited[change.Position:], change.To)
+		reasons[change.Feature+" "+string(change.Reason)] = true
+	}
+	assert.Equal(t, fixed, string(edited))
+	assert.True(t, reasons["forward homopolymer"])
+	assert.True(t, reasons["forward forbidden"])
+	assert.True(t, reasons[" homopolymer"])
+	assert.True(t, reasons["reverse homopolymer"])
+}
+
+func TestFixHairpin(t *testing.T) {
+	stem := "GCGGCCGCAGCCGC"
+	sequence := randomSequence(11, 40) + stem + "TTTT" + transform.ReverseComplement(stem) + randomSequence(12, 40)
+	rules := Rules{HairpinWindow: 60, MinHairpinEnergy: -20}
+	fixed, changes, report, err := Fix(sequence, rules, FixOptions{})
+	require.NoError(t, err)
+	assert.True(t, report.Synthesizable(), "%v", report.Violations)
+	assert.NotEmpty(t, changes)
+
+	// the report matches scoring from scratch.
+	rescored, err := Score(fixed, rules)
+	require.NoError(t, err)
+	assert.Equal(t, report, rescored)
+}
+
+func TestFixUnfixable(t *testing.T) {
+	sequence := "ATGNNNAAAAAAAAAA"
+	fixed, changes, report, err := Fix(sequence, Rules{MinLength: 100, MaxHomopolymer: 8}, FixOptions{Protected: []genbank.Location{{Start: 0, End: len(sequence)}}})
+	require.NoError(t, err)
+	assert.Equal(t, sequence, fixed)
+	assert.Empty(t, changes)
+	assert.Equal(t, []Kind{Length, Ambiguous, Homopolymer}, kinds(report))
+}
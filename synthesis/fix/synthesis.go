@@ -29,6 +29,7 @@ import (
 	"sync"
 
 	"github.com/bebop/poly/checks"
+	"github.com/bebop/poly/stats"
 	"github.com/bebop/poly/synthesis/codon"
 	"github.com/bebop/poly/transform"
 )
@@ -109,6 +110,32 @@ func RemoveRepeat(repeatLen int) func(string, chan DnaSuggestion, *sync.WaitGrou
 	}
 }
 
+// RemoveHomopolymers is a generator to make a problematicSequenceFunc for
+// runs of a single base longer than maxLength.
+func RemoveHomopolymers(maxLength int) func(string, chan DnaSuggestion, *sync.WaitGroup) {
+	return func(sequence string, c chan DnaSuggestion, waitgroup *sync.WaitGroup) {
+		codonLength := 3
+		for _, homopolymer := range stats.Homopolymers(sequence, maxLength+1) {
+			c <- DnaSuggestion{homopolymer.Start / codonLength, (homopolymer.End - 1) / codonLength, "NA", 1, "Homopolymer"}
+		}
+		waitgroup.Done()
+	}
+}
+
+// RemoveCpGIslands is a generator to make a problematicSequenceFunc for CpG
+// islands, which get transgenes methylated and silenced in mammalian cells.
+// Islands are found with stats.CpGIslands and its default options, and fixed
+// by lowering their GC content.
+func RemoveCpGIslands() func(string, chan DnaSuggestion, *sync.WaitGroup) {
+	return func(sequence string, c chan DnaSuggestion, waitgroup *sync.WaitGroup) {
+		codonLength := 3
+		for _, island := range stats.CpGIslands(sequence, stats.CpGOptions{}) {
+			c <- DnaSuggestion{island.Start / codonLength, (island.End - 1) / codonLength, "AT", 1, "CpG island"}
+		}
+		waitgroup.Done()
+	}
+}
+
 // GcContentFixer is a generator to increase or decrease the overall GcContent
 // of a CDS. GcContent is defined as the percentage of guanine and cytosine
 // base pairs in comparison to adenine and thymine base pairs. Usually, you
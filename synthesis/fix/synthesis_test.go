@@ -231,3 +231,16 @@ func TestNdeIFix(t *testing.T) {
 		t.Errorf("Failed to NdeIFix with error: %s", err)
 	}
 }
+
+func TestRemoveHomopolymers(t *testing.T) {
+	// bla starts with a run of ten As, from three lysine codons in a row.
+	bla := "ATGAAAAAAAAAAGTATTCAACATTTCCGTGTCGCCCTTATTCCCTTTTTTGCGGCATTTTGCCTTCCTGTTTTTGCTCACCCAGAAACGCTGGTGAAAGTAAAAGATGCTGAAGATCAGTTGGGTGCACGAGTGGGTTACATCGAACTGGATCTCAACAGCGGTAAGATCCTTGAGAGTTTTCGCCCCGAAGAACGTTTTCCAATGATGAGCACTTTTAAAGTTCTGCTATGTGGCGCGGTATTATCCCGTATTGACGCCGGGCAAGAGCAACTCGGTCGCCGCATACACTATTCTCAGAATGACTTGGTTGAGTACTCACCAGTCACAGAAAAGCATCTTACGGATGGCATGACAGTAAGAGAATTATGCAGTGCTGCCATAACCATGAGTGATAACACTGCGGCCAACTTACTTCTGACAACGATCGGAGGACCGAAGGAGCTAACCGCTTTTTTGCACAACATGGGGGATCATGTAACTCGCCTTGATCGTTGGGAACCGGAGCTGAATGAAGCCATACCAAACGACGAGCGTGACACCACGATGCCTGTAGCAATGGCAACAACGTTGCGCAAACTATTAACTGGCGAACTACTTACTCTAGCTTCCCGGCAACAATTAATAGACTGGATGGAGGCGGATAAAGTTGCAGGACCACTTCTGCGCTCGGCCCTTCCGGCTGGCTGGTTTATTGCTGATAAATCTGGAGCCGGTGAGCGTGGGTCTCGCGGTATCATTGCAGCACTGGGGCCAGATGGTAAGCCCTCCCGTATCGTAGTTATCTACACGACGGGGAGTCAGGCAACTATGGATGAACGAAATAGACAGATCGCTGAGATAGGTGCCTCACTGATTAAGCATTGGTAA"
+	codonTable := codon.ReadCodonJSON(dataDir + "pichiaTable.json")
+	fixedSeq, _, err := Cds(bla, codonTable, []func(string, chan DnaSuggestion, *sync.WaitGroup){RemoveHomopolymers(6)})
+	if err != nil {
+		t.Errorf("Failed to remove homopolymers with error: %s", err)
+	}
+	if strings.Contains(fixedSeq, "AAAAAAA") {
+		t.Errorf("Failed to remove the run of As at the start of bla")
+	}
+}
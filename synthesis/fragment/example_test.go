@@ -36,3 +36,15 @@ func ExampleFragment() {
 	fmt.Printf("%s : %f", fragments[1], efficiency)
 	// Output: CTGGGAAAACCCTGGCGTTACCCAACTTAATCGCCTTGCAGCACATCCCCCTTTCGCCAGCTGGCGTAATAGCGAAGAGGCCCGCACCGATCGCCCTTCCCAACA : 1.000000
 }
+
+func ExampleSplit() {
+	lacZ := "ATGACCATGATTACGCCAAGCTTGCATGCCTGCAGGTCGACTCTAGAGGATCCCCGGGTACCGAGCTCGAATTCACTGGCCGTCGTTTTACAACGTCGTGACTGGGAAAACCCTGGCGTTACCCAACTTAATCGCCTTGCAGCACATCCCCCTTTCGCCAGCTGGCGTAATAGCGAAGAGGCCCGCACCGATCGCCCTTCCCAACAGTTGCGCAGCCTGAATGGCGAATGGCGCCTGATGCGGTATTTTCTCCTTACGCATCTGTGCGGTATTTCACACCGCATATGGTGCACTCTCAGTACAATCTGCTCTGATGCCGCATAG"
+	plan, _ := fragment.Split(lacZ, fragment.PlanOptions{MinFragmentSize: 100, MaxFragmentSize: 200})
+	for _, junction := range plan.Junctions {
+		fmt.Println(junction.Start, junction.Overlap)
+	}
+	fmt.Println(len(plan.Fragments))
+	// Output:
+	// 124 AACTTAATCGCCTTGCAGCACATCCCCCTT
+	// 2
+}
@@ -8,6 +8,9 @@ data provided in table S1.
 
 Paper link: https://doi.org/10.1371/journal.pone.0238592
 Data link: https://doi.org/10.1371/journal.pone.0238592.s001
+
+Split plans the fragments of a whole construct at once, for Gibson or Golden
+Gate assembly, placing junctions away from repeats and secondary structure.
 */
 package fragment
 
@@ -0,0 +1,341 @@
+package fragment
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/bebop/poly/checks"
+	"github.com/bebop/poly/fold"
+	"github.com/bebop/poly/primers"
+	"github.com/bebop/poly/synthesis/difficulty"
+	"github.com/bebop/poly/transform"
+)
+
+/******************************************************************************
+
+Fragmentation planning begins here.
+
+Fragment picks one junction at a time, each the best it can do given the ones
+before it. Split plans all of a construct's junctions together instead, with
+dynamic programming over every place a junction could go, so a bad stretch
+of sequence near one junction can push it, and every junction after it, to
+somewhere better.
+
+Split makes as few fragments as the size limits allow, and of the plans with
+that many, the one whose junctions are best:
+
+Junctions inside repeats are avoided, since a fragment whose end is repeated
+elsewhere in the construct is hard to synthesize and assemble.
+
+For Gibson assembly, fragments overlap by OverlapLength bases. Overlaps have
+to occur nowhere else in the construct, on either strand, and melt above
+MinOverlapTm, and are better with balanced GC content. The overlap of every
+junction is folded, and junctions whose overlap folds on itself more stably
+than MinOverlapEnergy are thrown out and the plan made again.
+
+For Golden Gate assembly, fragments overlap by the 4 base overhangs a Type IIS
+enzyme leaves, which have to be distinct and not palindromic. They're scored
+by their fidelity against the overhangs of the ends of the construct and
+ExcludeOverhangs, and plans that use an overhang twice are made again without
+it. Flanks like a BsaI site are added to every fragment with FivePrimeFlank
+and ThreePrimeFlank.
+
+******************************************************************************/
+
+// Method is how the fragments of a plan are assembled.
+type Method int
+
+// Assembly methods.
+const (
+	Gibson Method = iota
+	GoldenGate
+)
+
+// PlanOptions changes how Split splits a construct. Zero values are replaced
+// with the defaults noted on each field.
+type PlanOptions struct {
+	Method Method
+	// MinFragmentSize and MaxFragmentSize are limits on the length of
+	// fragments, counting their overlaps and flanks. They default to the
+	// limits of difficulty.IDT.
+	MinFragmentSize, MaxFragmentSize int
+	// OverlapLength is how many bases Gibson fragments overlap. Defaults to
+	// 30.
+	OverlapLength int
+	// MinOverlapTm is the lowest melting temperature a Gibson overlap can
+	// have, in °C. Defaults to 50.
+	MinOverlapTm float64
+	// MinOverlapEnergy is the most stable a Gibson overlap can fold, in
+	// kcal/mol. Defaults to -3.
+	MinOverlapEnergy float64
+	// ExcludeOverhangs are Golden Gate overhangs used elsewhere in the
+	// assembly, like by the vector.
+	ExcludeOverhangs []string
+	// FivePrimeFlank and ThreePrimeFlank are added to both ends of every
+	// fragment, like Type IIS sites for Golden Gate.
+	FivePrimeFlank, ThreePrimeFlank string
+	// RepeatLength is the length of the shortest repeat junctions avoid.
+	// Defaults to 20.
+	RepeatLength int
+}
+
+// Junction is where two neighboring fragments of a plan overlap.
+type Junction struct {
+	Start, End int    // where the overlap is on the construct, as a half-open range.
+	Overlap    string // overlap or overhang.
+	Tm         float64
+	// Energy is the minimum free energy of a Gibson overlap folded on
+	// itself, in kcal/mol.
+	Energy float64
+}
+
+// Plan is a construct split into fragments.
+type Plan struct {
+	Fragments []string // in order, with their flanks.
+	Junctions []Junction
+	// Fidelity is the estimated fidelity of a Golden Gate plan's overhangs,
+	// from SetEfficiency, or 1 for Gibson plans.
+	Fidelity float64
+}
+
+// junctionCost is what every junction costs, more than the worst penalty,
+// so plans with fewer fragments always win.
+const junctionCost = 100
+
+// planner holds the state of a single construct's planning.
+type planner struct {
+	sequence     string
+	options      PlanOptions
+	overlap      int
+	flanks       int
+	penalties    []float64 // of a junction starting at every position, or +Inf.
+	endOverhangs []string  // the overhangs of the construct's own ends.
+}
+
+// Split splits a construct into fragments under a size limit that assemble
+// back into it.
+func Split(sequence string, options PlanOptions) (Plan, error) {
+	sequence = strings.ToUpper(sequence)
+	options = options.withDefaults()
+	planner := planner{sequence: sequence, options: options, flanks: len(options.FivePrimeFlank) + len(options.ThreePrimeFlank)}
+	planner.overlap = options.OverlapLength
+	if options.Method == GoldenGate {
+		planner.overlap = 4
+	}
+	if options.MinFragmentSize > options.MaxFragmentSize {
+		return Plan{}, fmt.Errorf("MinFragmentSize (%d) larger than MaxFragmentSize (%d)", options.MinFragmentSize, options.MaxFragmentSize)
+	}
+	if len(sequence) < planner.overlap {
+		return Plan{}, fmt.Errorf("%d base construct is shorter than an overlap", len(sequence))
+	}
+	if len(sequence)+planner.flanks <= options.MaxFragmentSize {
+		return planner.plan(nil), nil
+	}
+	if err := planner.scoreJunctions(); err != nil {
+		return Plan{}, err
+	}
+
+	folded := make(map[int]float64)
+	for {
+		starts, ok := planner.bestJunctions()
+		if !ok {
+			return Plan{}, fmt.Errorf("no way to split %d bases into fragments of %d to %d bases", len(sequence), options.MinFragmentSize, options.MaxFragmentSize)
+		}
+		retry := false
+		seen := make(map[string]bool)
+		for _, start := range starts {
+			overlap := sequence[start : start+planner.overlap]
+			switch options.Method {
+			case Gibson:
+				if _, ok := folded[start]; !ok {
+					result, err := fold.Zuker(overlap, 37)
+					if err != nil {
+						return Plan{}, err
+					}
+					folded[start] = math.Min(result.MinimumFreeEnergy(), 0)
+				}
+				if folded[start] < options.MinOverlapEnergy {
+					planner.penalties[start] = math.Inf(1)
+					retry = true
+				}
+			case GoldenGate:
+				if seen[overlap] || seen[transform.ReverseComplement(overlap)] {
+					planner.penalties[start] = math.Inf(1)
+					retry = true
+				}
+				seen[overlap] = true
+			}
+		}
+		if !retry {
+			plan := planner.plan(starts)
+			for index := range plan.Junctions {
+				plan.Junctions[index].Energy = folded[plan.Junctions[index].Start]
+			}
+			return plan, nil
+		}
+	}
+}
+
+func (options PlanOptions) withDefaults() PlanOptions {
+	if options.MinFragmentSize == 0 {
+		options.MinFragmentSize = difficulty.IDT.MinLength
+	}
+	if options.MaxFragmentSize == 0 {
+		options.MaxFragmentSize = difficulty.IDT.MaxLength
+	}
+	if options.OverlapLength == 0 {
+		options.OverlapLength = 30
+	}
+	if options.MinOverlapTm == 0 {
+		options.MinOverlapTm = 50
+	}
+	if options.MinOverlapEnergy == 0 {
+		options.MinOverlapEnergy = -3
+	}
+	if options.RepeatLength == 0 {
+		options.RepeatLength = 20
+	}
+	excluded := make([]string, len(options.ExcludeOverhangs))
+	for index, overhang := range options.ExcludeOverhangs {
+		excluded[index] = strings.ToUpper(overhang)
+	}
+	options.ExcludeOverhangs = excluded
+	return options
+}
+
+// scoreJunctions works out the penalty of a junction at every position.
+func (planner *planner) scoreJunctions() error {
+	sequence, options := planner.sequence, planner.options
+	report, err := difficulty.Score(sequence, difficulty.Rules{MaxRepeat: options.RepeatLength - 1})
+	if err != nil {
+		return err
+	}
+	repeated := make([]bool, len(sequence))
+	for _, violation := range report.Violations {
+		if violation.Kind == difficulty.Repeat {
+			for position := violation.Start; position < violation.End; position++ {
+				repeated[position] = true
+			}
+		}
+	}
+
+	occurrences := make(map[string]int)
+	for start := 0; start+planner.overlap <= len(sequence); start++ {
+		occurrences[sequence[start:start+planner.overlap]]++
+	}
+	planner.endOverhangs = []string{sequence[:4], sequence[len(sequence)-4:]}
+	fixed := append(append([]string{}, planner.endOverhangs...), options.ExcludeOverhangs...)
+
+	planner.penalties = make([]float64, len(sequence)-planner.overlap+1)
+	for start := range planner.penalties {
+		overlap := sequence[start : start+planner.overlap]
+		penalty := 0.0
+		if strings.Trim(overlap, "ACGT") != "" {
+			penalty = math.Inf(1)
+		}
+		switch options.Method {
+		case Gibson:
+			reverse := transform.ReverseComplement(overlap)
+			if occurrences[overlap] > 1 || (reverse != overlap && occurrences[reverse] > 0) || reverse == overlap {
+				penalty = math.Inf(1)
+			}
+			if tm, err := primers.Tm(overlap, primers.DefaultConditions); err != nil || tm < options.MinOverlapTm {
+				penalty = math.Inf(1)
+			}
+			penalty += 2 * math.Abs(checks.GcContent(overlap)-0.5)
+		case GoldenGate:
+			if checks.IsPalindromic(overlap) {
+				penalty = math.Inf(1)
+			}
+			for _, other := range fixed {
+				if other == overlap || other == transform.ReverseComplement(overlap) {
+					penalty = math.Inf(1)
+				}
+			}
+			penalty += 1 - SetEfficiency(append(append([]string{}, fixed...), overlap))
+		}
+		for position := start; position < start+planner.overlap; position++ {
+			if repeated[position] {
+				penalty++
+				break
+			}
+		}
+		planner.penalties[start] = penalty
+	}
+	return nil
+}
+
+// fits is whether a fragment from start to end of the construct fits the
+// size limits, with its flanks.
+func (planner *planner) fits(start, end int) bool {
+	size := end - start + planner.flanks
+	return size >= planner.options.MinFragmentSize && size <= planner.options.MaxFragmentSize
+}
+
+// bestJunctions returns where the junctions of the cheapest plan start.
+func (planner *planner) bestJunctions() ([]int, bool) {
+	length, overlap := len(planner.sequence), planner.overlap
+	// costs[start] is the cheapest plan up to the end of a junction at start,
+	// and previous[start] the junction before it, or -1 for the first.
+	costs := make([]float64, len(planner.penalties))
+	previous := make([]int, len(planner.penalties))
+	for start := range costs {
+		costs[start], previous[start] = math.Inf(1), -1
+		if math.IsInf(planner.penalties[start], 1) {
+			continue
+		}
+		if planner.fits(0, start+overlap) {
+			costs[start] = junctionCost + planner.penalties[start]
+		}
+		minimum := max(0, start+overlap-planner.options.MaxFragmentSize+planner.flanks)
+		for before := minimum; before < start; before++ {
+			if math.IsInf(costs[before], 1) || !planner.fits(before, start+overlap) {
+				continue
+			}
+			if cost := costs[before] + junctionCost + planner.penalties[start]; cost < costs[start] {
+				costs[start], previous[start] = cost, before
+			}
+		}
+	}
+
+	last, best := -1, math.Inf(1)
+	for start := range costs {
+		if !math.IsInf(costs[start], 1) && planner.fits(start, length) && costs[start] < best {
+			last, best = start, costs[start]
+		}
+	}
+	if last == -1 {
+		return nil, false
+	}
+	var starts []int
+	for start := last; start != -1; start = previous[start] {
+		starts = append(starts, start)
+	}
+	sort.Ints(starts)
+	return starts, true
+}
+
+// plan makes a plan from where its junctions start.
+func (planner *planner) plan(starts []int) Plan {
+	sequence, options := planner.sequence, planner.options
+	plan := Plan{Fidelity: 1}
+	fragmentStart := 0
+	var overhangs []string
+	for _, start := range starts {
+		end := start + planner.overlap
+		overlap := sequence[start:end]
+		plan.Fragments = append(plan.Fragments, options.FivePrimeFlank+sequence[fragmentStart:end]+options.ThreePrimeFlank)
+		junction := Junction{Start: start, End: end, Overlap: overlap}
+		junction.Tm, _ = primers.Tm(overlap, primers.DefaultConditions)
+		plan.Junctions = append(plan.Junctions, junction)
+		overhangs = append(overhangs, overlap)
+		fragmentStart = start
+	}
+	plan.Fragments = append(plan.Fragments, options.FivePrimeFlank+sequence[fragmentStart:]+options.ThreePrimeFlank)
+	if options.Method == GoldenGate && len(starts) > 0 {
+		plan.Fidelity = SetEfficiency(append(append(append([]string{}, planner.endOverhangs...), options.ExcludeOverhangs...), overhangs...))
+	}
+	return plan
+}
@@ -0,0 +1,126 @@
+package fragment
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/random"
+	"github.com/bebop/poly/transform"
+)
+
+// reassemble joins the fragments of a plan back together by their overlaps.
+func reassemble(plan Plan, options PlanOptions) string {
+	var construct string
+	for index, fragment := range plan.Fragments {
+		fragment = strings.TrimSuffix(strings.TrimPrefix(fragment, options.FivePrimeFlank), options.ThreePrimeFlank)
+		if index > 0 {
+			fragment = fragment[len(plan.Junctions[index-1].Overlap):]
+		}
+		construct += fragment
+	}
+	return construct
+}
+
+func TestSplitGibson(t *testing.T) {
+	construct, _ := random.DNASequence(2500, 1)
+	options := PlanOptions{MinFragmentSize: 300, MaxFragmentSize: 1000}
+	plan, err := Split(construct, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Fragments) != 3 || len(plan.Junctions) != 2 {
+		t.Fatalf("expected 3 fragments, got %d", len(plan.Fragments))
+	}
+	for _, fragment := range plan.Fragments {
+		if len(fragment) < 300 || len(fragment) > 1000 {
+			t.Errorf("fragment of %d bases is out of bounds", len(fragment))
+		}
+	}
+	for _, junction := range plan.Junctions {
+		if len(junction.Overlap) != 30 || construct[junction.Start:junction.End] != junction.Overlap {
+			t.Errorf("junction %v doesn't match the construct", junction)
+		}
+		if strings.Count(construct, junction.Overlap) != 1 || strings.Contains(construct, transform.ReverseComplement(junction.Overlap)) {
+			t.Errorf("overlap %s isn't unique", junction.Overlap)
+		}
+		if junction.Tm < 50 || junction.Energy < -3 {
+			t.Errorf("overlap %s melts at %.1f and folds with %.1f", junction.Overlap, junction.Tm, junction.Energy)
+		}
+	}
+	if reassemble(plan, options) != construct {
+		t.Errorf("fragments don't reassemble into the construct")
+	}
+	if plan.Fidelity != 1 {
+		t.Errorf("Gibson plans should have a fidelity of 1, got %f", plan.Fidelity)
+	}
+}
+
+func TestSplitAvoidsRepeats(t *testing.T) {
+	// a repeat right where a junction splitting the construct in half would go.
+	repeat, _ := random.DNASequence(200, 2)
+	start, _ := random.DNASequence(500, 3)
+	middle, _ := random.DNASequence(300, 4)
+	end, _ := random.DNASequence(500, 5)
+	construct := start + repeat + middle + repeat + end
+	options := PlanOptions{MinFragmentSize: 300, MaxFragmentSize: 1000}
+	plan, err := Split(construct, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, junction := range plan.Junctions {
+		inFirst := junction.End > 500 && junction.Start < 700
+		inSecond := junction.End > 1000 && junction.Start < 1200
+		if inFirst || inSecond {
+			t.Errorf("junction at %d is inside a repeat", junction.Start)
+		}
+	}
+	if reassemble(plan, options) != construct {
+		t.Errorf("fragments don't reassemble into the construct")
+	}
+}
+
+func TestSplitGoldenGate(t *testing.T) {
+	construct, _ := random.DNASequence(2500, 6)
+	options := PlanOptions{Method: GoldenGate, MinFragmentSize: 300, MaxFragmentSize: 600, FivePrimeFlank: "GGTCTCA", ThreePrimeFlank: "TGAGACC", ExcludeOverhangs: []string{"aatg"}}
+	plan, err := Split(construct, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Fragments) != 5 {
+		t.Errorf("expected 5 fragments, got %d", len(plan.Fragments))
+	}
+	seen := map[string]bool{construct[:4]: true, construct[len(construct)-4:]: true, "AATG": true, "CATT": true}
+	for _, junction := range plan.Junctions {
+		if seen[junction.Overlap] || seen[transform.ReverseComplement(junction.Overlap)] {
+			t.Errorf("overhang %s is used twice", junction.Overlap)
+		}
+		seen[junction.Overlap] = true
+	}
+	for _, fragment := range plan.Fragments {
+		if len(fragment) > 600 || !strings.HasPrefix(fragment, "GGTCTCA") || !strings.HasSuffix(fragment, "TGAGACC") {
+			t.Errorf("fragment %s is too long or missing its flanks", fragment)
+		}
+	}
+	if reassemble(plan, options) != construct {
+		t.Errorf("fragments don't reassemble into the construct")
+	}
+	if plan.Fidelity <= 0 || plan.Fidelity > 1 {
+		t.Errorf("fidelity %f is out of bounds", plan.Fidelity)
+	}
+}
+
+func TestSplitSmallAndImpossible(t *testing.T) {
+	construct, _ := random.DNASequence(500, 7)
+	plan, err := Split(construct, PlanOptions{})
+	if err != nil || len(plan.Fragments) != 1 || plan.Fragments[0] != construct {
+		t.Errorf("a construct under the size limit should be a single fragment")
+	}
+	_, err = Split(strings.Repeat("A", 2500), PlanOptions{MinFragmentSize: 300, MaxFragmentSize: 1000})
+	if err == nil {
+		t.Errorf("a homopolymer shouldn't have any unique overlaps")
+	}
+	_, err = Split(construct, PlanOptions{MinFragmentSize: 300, MaxFragmentSize: 200})
+	if err == nil {
+		t.Errorf("MinFragmentSize over MaxFragmentSize should fail")
+	}
+}
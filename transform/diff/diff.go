@@ -0,0 +1,260 @@
+/*
+Package diff finds the differences between two versions of an annotated
+sequence, as a patch that turns one into the other.
+
+Constructs get revised: a mutation is fixed, a tag is added, a promoter is
+swapped. Reviewing a revision means working out what changed, which for
+sequences means aligning them, and for their annotations means working out
+which features are new, which are gone, and which only moved because bases
+were added or removed before them.
+
+Diff aligns the two sequences and describes where they differ as Edits, like
+a substitution or an insertion. Then it carries the old version's features
+through those edits the way genbank.Genbank's Replace does, and compares
+them to the new version's features: old features that don't end up in the
+new version were removed, and new features nothing ended up as were added.
+Features that only moved aren't changes.
+
+A Patch can be written as JSON for review, and Apply replays it on the old
+version to make the new one.
+*/
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/search/align"
+)
+
+// Kind is the kind of an Edit.
+type Kind string
+
+// Kinds of edits.
+const (
+	Substitution Kind = "substitution" // bases replaced by as many other bases.
+	Insertion    Kind = "insertion"
+	Deletion     Kind = "deletion"
+	Replacement  Kind = "replacement" // bases replaced by a different number of bases.
+)
+
+// Edit is one difference between two sequences.
+type Edit struct {
+	Kind Kind `json:"kind"`
+	// Position is where the edit starts in the old sequence, zero-indexed.
+	Position int    `json:"position"`
+	Old      string `json:"old"` // bases of the old sequence the edit replaces.
+	New      string `json:"new"` // bases that replace them.
+}
+
+// Patch is the differences between two versions of an annotated sequence.
+type Patch struct {
+	Edits           []Edit            `json:"edits"`            // sorted by position.
+	RemovedFeatures []genbank.Feature `json:"removed_features"` // where they are in the old sequence.
+	AddedFeatures   []genbank.Feature `json:"added_features"`   // where they are in the new sequence.
+}
+
+// maxAlignmentCells is the most cells Diff aligns with. Differing stretches
+// too long to align are described as one replacement.
+const maxAlignmentCells = 1 << 21
+
+// markerAttribute tags the old features Diff carries through the edits with
+// their index.
+const markerAttribute = "\x00diff"
+
+// Diff returns the patch that turns before into after.
+func Diff(before, after genbank.Genbank) (Patch, error) {
+	edits, err := diffSequences(before.Sequence, after.Sequence)
+	if err != nil {
+		return Patch{}, err
+	}
+	marked := before
+	marked.Features = make([]genbank.Feature, len(before.Features))
+	for index, feature := range before.Features {
+		attributes := map[string]string{markerAttribute: strconv.Itoa(index)}
+		for key, value := range feature.Attributes {
+			attributes[key] = value
+		}
+		feature.Attributes = attributes
+		marked.Features[index] = feature
+	}
+	edited, err := applyEdits(marked, edits)
+	if err != nil {
+		return Patch{}, err
+	}
+
+	unmatched := make(map[string]int)
+	for _, feature := range after.Features {
+		unmatched[featureKey(feature)]++
+	}
+	kept := make([]bool, len(before.Features))
+	for _, feature := range edited.Features {
+		index, _ := strconv.Atoi(feature.Attributes[markerAttribute])
+		delete(feature.Attributes, markerAttribute)
+		if key := featureKey(feature); unmatched[key] > 0 {
+			unmatched[key]--
+			kept[index] = true
+		}
+	}
+	patch := Patch{Edits: edits}
+	for index, feature := range before.Features {
+		if !kept[index] {
+			patch.RemovedFeatures = append(patch.RemovedFeatures, feature)
+		}
+	}
+	for _, feature := range after.Features {
+		if key := featureKey(feature); unmatched[key] > 0 {
+			unmatched[key]--
+			patch.AddedFeatures = append(patch.AddedFeatures, feature)
+		}
+	}
+	return patch, nil
+}
+
+// Apply returns a sequence with a patch applied to it.
+func (patch Patch) Apply(sequence genbank.Genbank) (genbank.Genbank, error) {
+	removed := make(map[string]int)
+	for _, feature := range patch.RemovedFeatures {
+		removed[featureKey(feature)]++
+	}
+	kept := sequence
+	kept.Features = nil
+	for _, feature := range sequence.Features {
+		if key := featureKey(feature); removed[key] > 0 {
+			removed[key]--
+			continue
+		}
+		kept.Features = append(kept.Features, feature)
+	}
+	for key, count := range removed {
+		if count > 0 {
+			parts := strings.SplitN(key, "\x00", 3)
+			return genbank.Genbank{}, fmt.Errorf("patch removes a %s at %s that isn't in the sequence", parts[0], parts[1])
+		}
+	}
+	edited, err := applyEdits(kept, patch.Edits)
+	if err != nil {
+		return genbank.Genbank{}, err
+	}
+	edited.Features = append(edited.Features, patch.AddedFeatures...)
+	for index := range edited.Features {
+		edited.Features[index].ParentSequence = &edited
+	}
+	return edited, nil
+}
+
+// diffSequences returns the edits that turn before into after.
+func diffSequences(before, after string) ([]Edit, error) {
+	upperBefore, upperAfter := strings.ToUpper(before), strings.ToUpper(after)
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && upperBefore[prefix] == upperAfter[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(before)-prefix && suffix < len(after)-prefix && upperBefore[len(before)-1-suffix] == upperAfter[len(after)-1-suffix] {
+		suffix++
+	}
+	oldMiddle, newMiddle := before[prefix:len(before)-suffix], after[prefix:len(after)-suffix]
+	switch {
+	case oldMiddle == "" && newMiddle == "":
+		return nil, nil
+	case oldMiddle == "" || newMiddle == "":
+		return []Edit{newEdit(prefix, oldMiddle, newMiddle)}, nil
+	}
+
+	band := align.GlobalBand(len(oldMiddle), len(newMiddle), 32)
+	if len(oldMiddle)*min(2*band.Band+1, len(newMiddle)+1) > maxAlignmentCells {
+		return []Edit{newEdit(prefix, oldMiddle, newMiddle)}, nil
+	}
+	scoring, err := align.NewAffineScoring(nil, -5, -1)
+	if err != nil {
+		return nil, err
+	}
+	alignment, err := align.NeedlemanWunschAffine(strings.ToUpper(oldMiddle), strings.ToUpper(newMiddle), scoring, band)
+	if err != nil {
+		return nil, err
+	}
+
+	// walk the alignment, gathering every run of columns that don't match
+	// into one edit.
+	var edits []Edit
+	oldPosition, newPosition := 0, 0
+	runOld, runNew := -1, -1
+	flush := func() {
+		if runOld != -1 {
+			edits = append(edits, newEdit(prefix+runOld, oldMiddle[runOld:oldPosition], newMiddle[runNew:newPosition]))
+			runOld, runNew = -1, -1
+		}
+	}
+	for column := 0; column < len(alignment.AlignedA); column++ {
+		oldBase, newBase := alignment.AlignedA[column], alignment.AlignedB[column]
+		if oldBase == newBase {
+			flush()
+		} else if runOld == -1 {
+			runOld, runNew = oldPosition, newPosition
+		}
+		if oldBase != '-' {
+			oldPosition++
+		}
+		if newBase != '-' {
+			newPosition++
+		}
+	}
+	flush()
+	return edits, nil
+}
+
+// newEdit returns an edit of the right kind.
+func newEdit(position int, from, to string) Edit {
+	edit := Edit{Position: position, Old: from, New: to}
+	switch {
+	case from == "":
+		edit.Kind = Insertion
+	case to == "":
+		edit.Kind = Deletion
+	case len(from) == len(to):
+		edit.Kind = Substitution
+	default:
+		edit.Kind = Replacement
+	}
+	return edit
+}
+
+// applyEdits applies edits to a sequence, last first so the positions of the
+// ones before stay put.
+func applyEdits(sequence genbank.Genbank, edits []Edit) (genbank.Genbank, error) {
+	sorted := make([]Edit, len(edits))
+	copy(sorted, edits)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Position > sorted[j].Position })
+	edited := sequence
+	for _, edit := range sorted {
+		end := edit.Position + len(edit.Old)
+		if edit.Position < 0 || end > len(edited.Sequence) || !strings.EqualFold(edited.Sequence[edit.Position:end], edit.Old) {
+			return genbank.Genbank{}, fmt.Errorf("%s at %d doesn't apply: the sequence there isn't %q", edit.Kind, edit.Position, edit.Old)
+		}
+		var err error
+		edited, err = edited.Replace(edit.Position, end, edit.New)
+		if err != nil {
+			return genbank.Genbank{}, err
+		}
+	}
+	return edited, nil
+}
+
+// featureKey identifies a feature by its type, location, and attributes.
+func featureKey(feature genbank.Feature) string {
+	keys := make([]string, 0, len(feature.Attributes))
+	for key := range feature.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var builder strings.Builder
+	builder.WriteString(feature.Type + "\x00" + genbank.BuildLocationString(feature.Location))
+	for _, key := range keys {
+		builder.WriteString("\x00" + key + "=" + feature.Attributes[key])
+	}
+	return builder.String()
+}
@@ -0,0 +1,138 @@
+package diff
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/genbank"
+)
+
+// annotated returns a record with one feature per location string.
+func annotated(sequence string, features map[string]string) genbank.Genbank {
+	record := genbank.Genbank{Sequence: sequence}
+	for label, locationString := range features {
+		location, _ := genbank.ParseLocation(locationString)
+		location.GbkLocationString = ""
+		_ = record.AddFeature(&genbank.Feature{Type: "misc_feature", Attributes: map[string]string{"label": label}, Location: location})
+	}
+	return record
+}
+
+// sameFeatures reports whether two records have the same features, in any
+// order.
+func sameFeatures(first, second genbank.Genbank) bool {
+	counts := make(map[string]int)
+	for _, feature := range first.Features {
+		counts[featureKey(feature)]++
+	}
+	for _, feature := range second.Features {
+		counts[featureKey(feature)]--
+	}
+	for _, count := range counts {
+		if count != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDiffSequences(t *testing.T) {
+	tests := []struct {
+		before, after string
+		want          []Edit
+	}{
+		{"GATTACA", "GATTACA", nil},
+		{"GATTACA", "GATCACA", []Edit{{Kind: Substitution, Position: 3, Old: "T", New: "C"}}},
+		{"GATTACA", "GATTTTACA", []Edit{{Kind: Insertion, Position: 4, Old: "", New: "TT"}}},
+		{"GATTACA", "GACA", []Edit{{Kind: Deletion, Position: 2, Old: "TTA", New: ""}}},
+		{"gattaca", "GATTACA", nil},
+		{
+			"ATGAAACCCGGGTTTAAATAG", "ATGAAAGCCGGGTTTAAACCCTAG",
+			[]Edit{{Kind: Substitution, Position: 6, Old: "C", New: "G"}, {Kind: Insertion, Position: 18, Old: "", New: "CCC"}},
+		},
+	}
+	for _, test := range tests {
+		got, err := diffSequences(test.before, test.after)
+		if err != nil {
+			t.Fatalf("diffSequences(%q, %q) error = %v", test.before, test.after, err)
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("diffSequences(%q, %q) = %+v, want %+v", test.before, test.after, got, test.want)
+			continue
+		}
+		for index := range got {
+			if got[index] != test.want[index] {
+				t.Errorf("diffSequences(%q, %q) = %+v, want %+v", test.before, test.after, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestDiffFeatures(t *testing.T) {
+	before := annotated("ATGAAACCCGGGTTTAAATAG", map[string]string{"start": "1..3", "middle": "7..12", "end": "19..21"})
+	after := annotated("ATGAAACCCGGGTTTAAACCCTAG", map[string]string{"start": "1..3", "middle": "7..12", "end": "22..24", "new": "19..21"})
+	after.Sequence = "ATGAAACCCGGGTTTAAACCCTAG"
+
+	patch, err := Diff(before, after)
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if len(patch.Edits) != 1 || patch.Edits[0].Kind != Insertion {
+		t.Errorf("Diff() edits = %+v, want one insertion", patch.Edits)
+	}
+	// the end feature only moved, so it isn't a change.
+	if len(patch.AddedFeatures) != 1 || patch.AddedFeatures[0].Attributes["label"] != "new" || len(patch.RemovedFeatures) != 0 {
+		t.Errorf("Diff() added %+v and removed %+v, want only the new feature added", patch.AddedFeatures, patch.RemovedFeatures)
+	}
+
+	applied, err := patch.Apply(before)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if applied.Sequence != after.Sequence || !sameFeatures(applied, after) {
+		t.Errorf("Apply() = %q with %d features, want the new version", applied.Sequence, len(applied.Features))
+	}
+
+	// going back deletes the new feature's bases, which removes it.
+	reverse, _ := Diff(after, before)
+	if len(reverse.RemovedFeatures) != 1 || reverse.RemovedFeatures[0].Attributes["label"] != "new" || len(reverse.AddedFeatures) != 0 {
+		t.Errorf("Diff() removed %+v and added %+v, want only the new feature removed", reverse.RemovedFeatures, reverse.AddedFeatures)
+	}
+	if reverted, err := reverse.Apply(after); err != nil || reverted.Sequence != before.Sequence || !sameFeatures(reverted, before) {
+		t.Errorf("Apply() of the reverse patch = %q, %v, want the old version", reverted.Sequence, err)
+	}
+	if _, err := reverse.Apply(before); err == nil {
+		t.Errorf("Apply() of a patch to the wrong sequence should fail")
+	}
+}
+
+func TestDiffRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	puc19, err := genbank.Read("../../data/puc19.gbk")
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	revised := puc19
+	for edit := 0; edit < 5; edit++ {
+		position := random.Intn(len(revised.Sequence) - 20)
+		revised, _ = revised.Replace(position, position+random.Intn(20), strings.Repeat("g", random.Intn(20)))
+	}
+	revised.Features = revised.Features[1:]
+
+	patch, err := Diff(puc19, revised)
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	applied, err := patch.Apply(puc19)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if applied.Sequence != revised.Sequence {
+		t.Errorf("Apply() sequence differs from the revised sequence")
+	}
+	if !sameFeatures(applied, revised) {
+		t.Errorf("Apply() features differ from the revised features")
+	}
+}
@@ -0,0 +1,29 @@
+package diff_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/transform/diff"
+)
+
+func ExampleDiff() {
+	before := genbank.Genbank{Sequence: "ATGAAACCCGGGTAA"}
+	after := genbank.Genbank{Sequence: "ATGAAAGCCGGGCATCATCATTAA"}
+	tag, _ := genbank.ParseLocation("13..21")
+	_ = after.AddFeature(&genbank.Feature{Type: "misc_feature", Attributes: map[string]string{"label": "His tag"}, Location: tag})
+
+	patch, _ := diff.Diff(before, after)
+	for _, edit := range patch.Edits {
+		fmt.Println(edit.Kind, edit.Position, edit.Old, edit.New)
+	}
+	fmt.Println(patch.AddedFeatures[0].Attributes["label"])
+
+	applied, _ := patch.Apply(before)
+	fmt.Println(applied.Sequence == after.Sequence)
+	// Output:
+	// substitution 6 C G
+	// insertion 12  CATCATCAT
+	// His tag
+	// true
+}
@@ -0,0 +1,47 @@
+package hgvs_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/synthesis/codon"
+	"github.com/bebop/poly/transform/hgvs"
+)
+
+func ExampleParse() {
+	variant, _ := hgvs.Parse("NM_004006.2:c.4375C>T")
+	fmt.Println(variant.Accession, string(variant.System), variant.Start.Index, variant.Reference, variant.Alternate)
+
+	// Output: NM_004006.2 c 4375 C T
+}
+
+func ExampleDescribe() {
+	for _, variant := range hgvs.Describe("ATGAAACGTTAA", "ATGAAAAACGTTAA", hgvs.Coding) {
+		fmt.Println(variant)
+	}
+	for _, variant := range hgvs.Describe("MKRL", "MGRL", hgvs.Protein) {
+		fmt.Println(variant)
+	}
+
+	// Output:
+	// c.5_6dup
+	// p.Lys2Gly
+}
+
+func ExampleToGenomic() {
+	record := genbank.Genbank{Sequence: "GGGATGAAACGTTAACCC"}
+	cds := genbank.Feature{Type: "CDS", Location: genbank.Location{Start: 3, End: 15}}
+	table, _ := codon.NewTranslationTable(11)
+
+	// change the lysine to a glutamate with as few base changes as possible.
+	variant, _ := hgvs.Parse("p.Lys2Glu")
+	genomic, _ := hgvs.ToGenomic(record, cds, variant, table)
+	fmt.Println(genomic)
+
+	edited, _ := hgvs.Apply(record, genomic)
+	fmt.Println(edited.Sequence)
+
+	// Output:
+	// g.7A>G
+	// GGGATGGAACGTTAACCC
+}
@@ -0,0 +1,997 @@
+/*
+Package hgvs parses, applies, and generates HGVS variant descriptions.
+
+When you send a construct to a collaborator and tell them "I changed the
+arginine at 97 to a glycine", there are a dozen ways to write that down. The
+Human Genome Variation Society (HGVS) nomenclature is the closest thing our
+field has to a standard way of writing it down:
+
+	NM_004006.2:c.4375C>T   (a substitution in the coding sequence)
+	g.32_33insGA            (an insertion in the genomic sequence)
+	p.Arg97Gly              (an amino acid substitution)
+
+This package provides three things:
+
+ 1. Parse turns an HGVS string into a Variant and Variant.String turns it back.
+ 2. ToGenomic resolves c. and p. variants to g. variants on an annotated
+    record, and Apply applies g. variants to a record, updating feature
+    coordinates along the way.
+ 3. Describe generates variants from an observed reference and alternate
+    sequence.
+
+Protein (p.) variants can not be applied directly since many DNA sequences
+encode the same protein. ToGenomic is reverse-translation aware: it picks the
+replacement codon that needs the fewest nucleotide changes from the current
+codon, breaking ties by codon table weight, so the resulting DNA edit is as
+small as possible.
+
+Only the commonly used subset of the nomenclature is supported:
+substitutions, deletions, duplications, insertions, deletion-insertions,
+inversions and unchanged (=) sequences, with c. positions upstream (c.-14),
+downstream (c.*5) and inside introns (c.88+1). Frameshifts (p.Arg97fs) and
+protein extensions (p.*110Glnext*17 and p.Met1ext-5) can be parsed and printed
+but not applied.
+
+Spec: https://varnomen.hgvs.org/
+*/
+package hgvs
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/synthesis/codon"
+	"github.com/bebop/poly/transform"
+)
+
+// System is the HGVS coordinate system prefix of a variant.
+type System byte
+
+// Coordinate systems supported by this package.
+const (
+	Genomic   System = 'g' // linear genomic reference sequence.
+	Circular  System = 'o' // circular genomic reference sequence, like a plasmid.
+	Coding    System = 'c' // coding DNA reference sequence, c.1 is the A of the ATG.
+	NonCoding System = 'n' // non-coding DNA reference sequence.
+	Protein   System = 'p' // protein reference sequence.
+)
+
+// Kind is the type of change a variant describes.
+type Kind int
+
+// Kinds of variants supported by this package.
+const (
+	Substitution Kind = iota
+	Deletion
+	Duplication
+	Insertion
+	DeletionInsertion
+	Inversion
+	Identity
+	Frameshift
+	Extension
+)
+
+// Position is a single HGVS position.
+type Position struct {
+	// Index is the 1-based position. For c. variants Index is negative for
+	// positions upstream of the start codon (c.-14).
+	Index int
+	// Offset is the intronic offset from Index, as in c.88+1 or c.89-2.
+	Offset int
+	// Downstream is true for c. positions counted from the stop codon (c.*5).
+	Downstream bool
+	// Residue is the one letter amino acid at a p. position.
+	Residue byte
+}
+
+// Variant is a single parsed HGVS variant.
+//
+// Extensions are described by where they start and end. A C-terminal
+// extension (p.*110Glnext*17) starts at the stop codon it changes, and its End
+// is the downstream position of the new stop codon counted from Start, or *0
+// if there is none (p.*110Glnext*?). An N-terminal extension (p.Met1ext-5)
+// starts at Met1, and its End is the upstream position of the new start codon.
+// Alternate holds the residues added, of which HGVS only writes the first of a
+// C-terminal extension and none of an N-terminal one.
+type Variant struct {
+	Accession string // reference sequence accession, like NM_004006.2. Optional.
+	System    System
+	Start     Position
+	End       Position // equal to Start for single position variants.
+	Kind      Kind
+	Reference string // reference bases (or one letter amino acids) if stated.
+	Alternate string // alternate, inserted, or substituted bases (or amino acids).
+	Predicted bool   // p.(Arg97Gly) style predicted consequences.
+}
+
+var (
+	errNotGenomic        = errors.New("variant is not in genomic (g. or o.) coordinates")
+	errNoCodonTable      = errors.New("a codon table is required to resolve protein variants")
+	errFrameshift        = errors.New("frameshift variants can not be applied")
+	errExtension         = errors.New("extension variants can not be applied")
+	errReferenceMismatch = errors.New("variant reference does not match sequence")
+)
+
+// threeLetterCodes maps one letter amino acid codes to HGVS three letter codes.
+var threeLetterCodes = map[byte]string{
+	'A': "Ala", 'R': "Arg", 'N': "Asn", 'D': "Asp", 'C': "Cys",
+	'Q': "Gln", 'E': "Glu", 'G': "Gly", 'H': "His", 'I': "Ile",
+	'L': "Leu", 'K': "Lys", 'M': "Met", 'F': "Phe", 'P': "Pro",
+	'S': "Ser", 'T': "Thr", 'W': "Trp", 'Y': "Tyr", 'V': "Val",
+	'U': "Sec", 'O': "Pyl", 'X': "Xaa", '*': "Ter",
+}
+
+var oneLetterCodes = func() map[string]byte {
+	codes := make(map[string]byte, len(threeLetterCodes))
+	for oneLetter, threeLetter := range threeLetterCodes {
+		codes[threeLetter] = oneLetter
+	}
+	return codes
+}()
+
+/******************************************************************************
+
+Parsing and printing begin here.
+
+******************************************************************************/
+
+// Precompiled regular expressions:
+var (
+	prefixRegex         = regexp.MustCompile(`^(?:([^:\s]+):)?([gocnp])\.(.*)$`)
+	nucleotidePositions = `([-*]?\d+)([+-]\d+)?(?:_([-*]?\d+)([+-]\d+)?)?`
+	nucleotideRegex     = regexp.MustCompile(`^` + nucleotidePositions + `(.*)$`)
+	substitutionRegex   = regexp.MustCompile(`^([ACGTUN])>([ACGTUN])$`)
+	deletionRegex       = regexp.MustCompile(`^del([ACGTUN]*)$`)
+	deletionInsertRegex = regexp.MustCompile(`^del([ACGTUN]*)ins([ACGTUN]+)$`)
+	insertionRegex      = regexp.MustCompile(`^ins([ACGTUN]+)$`)
+	duplicationRegex    = regexp.MustCompile(`^dup([ACGTUN]*)$`)
+	aminoAcid           = `([A-Z][a-z]{2}|\*)`
+	proteinRegex        = regexp.MustCompile(`^` + aminoAcid + `(\d+)(?:_` + aminoAcid + `(\d+))?(.*)$`)
+	aminoAcidsRegex     = regexp.MustCompile(`^(?:[A-Z][a-z]{2}|\*)+$`)
+	aminoAcidSplitRegex = regexp.MustCompile(`[A-Z][a-z]{2}|\*`)
+	cTerminalExtension  = regexp.MustCompile(`^([A-Z][a-z]{2})ext(?:\*|Ter)(\d+|\?)$`)
+	nTerminalExtension  = regexp.MustCompile(`^ext-(\d+)$`)
+)
+
+// Parse parses a single HGVS variant description.
+func Parse(description string) (Variant, error) {
+	description = strings.TrimSpace(description)
+	match := prefixRegex.FindStringSubmatch(description)
+	if match == nil {
+		return Variant{}, fmt.Errorf("%q is not an HGVS variant: missing coordinate system prefix", description)
+	}
+	variant := Variant{Accession: match[1], System: System(match[2][0])}
+	var err error
+	if variant.System == Protein {
+		err = parseProtein(match[3], &variant)
+	} else {
+		err = parseNucleotide(match[3], &variant)
+	}
+	if err != nil {
+		return Variant{}, fmt.Errorf("could not parse %q: %w", description, err)
+	}
+	return variant, nil
+}
+
+func parsePosition(index, offset string) (Position, error) {
+	var position Position
+	if strings.HasPrefix(index, "*") {
+		position.Downstream = true
+		index = index[1:]
+	}
+	var err error
+	position.Index, err = strconv.Atoi(index)
+	if err != nil {
+		return Position{}, err
+	}
+	if offset != "" {
+		position.Offset, err = strconv.Atoi(offset)
+		if err != nil {
+			return Position{}, err
+		}
+	}
+	return position, nil
+}
+
+func parseNucleotide(body string, variant *Variant) error {
+	match := nucleotideRegex.FindStringSubmatch(body)
+	if match == nil {
+		return errors.New("invalid position")
+	}
+	var err error
+	if variant.Start, err = parsePosition(match[1], match[2]); err != nil {
+		return err
+	}
+	variant.End = variant.Start
+	if match[3] != "" {
+		if variant.End, err = parsePosition(match[3], match[4]); err != nil {
+			return err
+		}
+	}
+	if variant.System != Coding {
+		for _, position := range []Position{variant.Start, variant.End} {
+			if position.Downstream || position.Offset != 0 || position.Index < 1 {
+				return errors.New("only c. variants may use upstream, downstream, or intronic positions")
+			}
+		}
+	}
+
+	change := match[5]
+	switch {
+	case change == "=":
+		variant.Kind = Identity
+	case change == "inv":
+		variant.Kind = Inversion
+	case substitutionRegex.MatchString(change):
+		alleles := substitutionRegex.FindStringSubmatch(change)
+		variant.Kind = Substitution
+		variant.Reference, variant.Alternate = alleles[1], alleles[2]
+	case deletionInsertRegex.MatchString(change):
+		alleles := deletionInsertRegex.FindStringSubmatch(change)
+		variant.Kind = DeletionInsertion
+		variant.Reference, variant.Alternate = alleles[1], alleles[2]
+	case strings.HasPrefix(change, "delins"):
+		variant.Kind = DeletionInsertion
+		variant.Alternate = change[len("delins"):]
+		if !insertionRegex.MatchString("ins" + variant.Alternate) {
+			return fmt.Errorf("invalid inserted sequence %q", variant.Alternate)
+		}
+	case deletionRegex.MatchString(change):
+		variant.Kind = Deletion
+		variant.Reference = deletionRegex.FindStringSubmatch(change)[1]
+	case insertionRegex.MatchString(change):
+		variant.Kind = Insertion
+		variant.Alternate = insertionRegex.FindStringSubmatch(change)[1]
+		if match[3] == "" {
+			return errors.New("insertions must be flanked by two positions, like 32_33insGA")
+		}
+	case duplicationRegex.MatchString(change):
+		variant.Kind = Duplication
+		variant.Reference = duplicationRegex.FindStringSubmatch(change)[1]
+	default:
+		return fmt.Errorf("unsupported change %q", change)
+	}
+	return nil
+}
+
+func parseAminoAcids(threeLetters string) (string, error) {
+	if !aminoAcidsRegex.MatchString(threeLetters) {
+		return "", fmt.Errorf("invalid amino acids %q", threeLetters)
+	}
+	var oneLetters strings.Builder
+	for _, code := range aminoAcidSplitRegex.FindAllString(threeLetters, -1) {
+		oneLetter, err := parseAminoAcid(code)
+		if err != nil {
+			return "", err
+		}
+		oneLetters.WriteByte(oneLetter)
+	}
+	return oneLetters.String(), nil
+}
+
+func parseAminoAcid(code string) (byte, error) {
+	if code == "*" {
+		return '*', nil
+	}
+	oneLetter, ok := oneLetterCodes[code]
+	if !ok {
+		return 0, fmt.Errorf("unknown amino acid %q", code)
+	}
+	return oneLetter, nil
+}
+
+func parseProtein(body string, variant *Variant) error {
+	if strings.HasPrefix(body, "(") && strings.HasSuffix(body, ")") {
+		variant.Predicted = true
+		body = body[1 : len(body)-1]
+	}
+	if body == "=" {
+		variant.Kind = Identity
+		return nil
+	}
+	match := proteinRegex.FindStringSubmatch(body)
+	if match == nil {
+		return errors.New("invalid protein position")
+	}
+	var err error
+	if variant.Start.Residue, err = parseAminoAcid(match[1]); err != nil {
+		return err
+	}
+	if variant.Start.Index, err = strconv.Atoi(match[2]); err != nil {
+		return err
+	}
+	variant.End = variant.Start
+	if match[3] != "" {
+		if variant.End.Residue, err = parseAminoAcid(match[3]); err != nil {
+			return err
+		}
+		if variant.End.Index, err = strconv.Atoi(match[4]); err != nil {
+			return err
+		}
+	}
+
+	change := match[5]
+	switch {
+	case change == "del":
+		variant.Kind = Deletion
+	case change == "dup":
+		variant.Kind = Duplication
+	case strings.Contains(change, "fs"):
+		variant.Kind = Frameshift
+		variant.Alternate = change
+	case strings.HasPrefix(change, "delins"):
+		variant.Kind = DeletionInsertion
+		variant.Alternate, err = parseAminoAcids(change[len("delins"):])
+	case strings.HasPrefix(change, "ins"):
+		if match[3] == "" {
+			return errors.New("insertions must be flanked by two positions, like Lys2_Leu3insGln")
+		}
+		variant.Kind = Insertion
+		variant.Alternate, err = parseAminoAcids(change[len("ins"):])
+	case change == "=":
+		variant.Kind = Identity
+	case cTerminalExtension.MatchString(change):
+		if variant.Start.Residue != '*' || match[3] != "" {
+			return errors.New("C-terminal extensions must start at the stop codon, like *110Glnext*17")
+		}
+		extension := cTerminalExtension.FindStringSubmatch(change)
+		variant.Kind = Extension
+		var alternate byte
+		if alternate, err = parseAminoAcid(extension[1]); err != nil {
+			return err
+		}
+		variant.Alternate = string(alternate)
+		variant.End = Position{Downstream: true}
+		if extension[2] != "?" {
+			variant.End.Index, err = strconv.Atoi(extension[2])
+		}
+	case nTerminalExtension.MatchString(change):
+		if variant.Start.Residue != 'M' || variant.Start.Index != 1 || match[3] != "" {
+			return errors.New("N-terminal extensions must start at Met1, like Met1ext-5")
+		}
+		variant.Kind = Extension
+		var upstream int
+		upstream, err = strconv.Atoi(nTerminalExtension.FindStringSubmatch(change)[1])
+		variant.End = Position{Index: -upstream}
+	default:
+		variant.Kind = Substitution
+		var alternate byte
+		alternate, err = parseAminoAcid(change)
+		variant.Alternate = string(alternate)
+		if match[3] != "" {
+			return errors.New("amino acid substitutions must be a single position")
+		}
+	}
+	return err
+}
+
+// String returns the position in HGVS notation.
+func (position Position) String() string {
+	var builder strings.Builder
+	if position.Residue != 0 {
+		builder.WriteString(threeLetterCodes[position.Residue])
+	}
+	if position.Downstream {
+		builder.WriteByte('*')
+	}
+	builder.WriteString(strconv.Itoa(position.Index))
+	if position.Offset > 0 {
+		builder.WriteByte('+')
+	}
+	if position.Offset != 0 {
+		builder.WriteString(strconv.Itoa(position.Offset))
+	}
+	return builder.String()
+}
+
+func threeLetterString(aminoAcids string) string {
+	var builder strings.Builder
+	for index := 0; index < len(aminoAcids); index++ {
+		builder.WriteString(threeLetterCodes[aminoAcids[index]])
+	}
+	return builder.String()
+}
+
+// String returns the variant in HGVS notation.
+func (variant Variant) String() string {
+	var builder strings.Builder
+	if variant.Accession != "" {
+		builder.WriteString(variant.Accession + ":")
+	}
+	builder.WriteString(string(variant.System) + ".")
+	if variant.System == Protein && variant.Predicted {
+		builder.WriteByte('(')
+	}
+
+	switch {
+	case variant.Kind == Identity && variant.Start.Index == 0:
+		builder.WriteByte('=')
+	case variant.Kind == Extension && variant.Start.Residue == '*':
+		builder.WriteString("*" + strconv.Itoa(variant.Start.Index))
+		if variant.Alternate != "" {
+			builder.WriteString(threeLetterCodes[variant.Alternate[0]])
+		}
+		builder.WriteString("ext*")
+		if variant.End.Index == 0 {
+			builder.WriteByte('?')
+		} else {
+			builder.WriteString(strconv.Itoa(variant.End.Index))
+		}
+	case variant.Kind == Extension:
+		builder.WriteString(variant.Start.String() + "ext" + strconv.Itoa(variant.End.Index))
+	default:
+		builder.WriteString(variant.Start.String())
+		if variant.End != variant.Start {
+			builder.WriteString("_" + variant.End.String())
+		}
+		alternate := variant.Alternate
+		if variant.System == Protein && variant.Kind != Frameshift {
+			alternate = threeLetterString(alternate)
+		}
+		switch variant.Kind {
+		case Substitution:
+			if variant.System == Protein {
+				builder.WriteString(alternate)
+			} else {
+				builder.WriteString(variant.Reference + ">" + alternate)
+			}
+		case Deletion:
+			builder.WriteString("del" + variant.Reference)
+		case Duplication:
+			builder.WriteString("dup" + variant.Reference)
+		case Insertion:
+			builder.WriteString("ins" + alternate)
+		case DeletionInsertion:
+			builder.WriteString("delins" + alternate)
+		case Inversion:
+			builder.WriteString("inv")
+		case Identity:
+			builder.WriteString("=")
+		case Frameshift:
+			builder.WriteString(alternate)
+		}
+	}
+
+	if variant.System == Protein && variant.Predicted {
+		builder.WriteByte(')')
+	}
+	return builder.String()
+}
+
+/******************************************************************************
+
+Coordinate resolution and application begin here.
+
+******************************************************************************/
+
+// segment is a stretch of a feature's location in transcript order.
+type segment struct {
+	start, end int // 0-based, half-open genomic coordinates.
+	complement bool
+}
+
+// transcriptSegments flattens a location into segments ordered 5' to 3' along
+// the feature, following GenBank's complement and join semantics.
+func transcriptSegments(location genbank.Location, complemented bool) []segment {
+	if location.Complement {
+		location.Complement = false
+		segments := transcriptSegments(location, !complemented)
+		for left, right := 0, len(segments)-1; left < right; left, right = left+1, right-1 {
+			segments[left], segments[right] = segments[right], segments[left]
+		}
+		return segments
+	}
+	if len(location.SubLocations) == 0 {
+		return []segment{{location.Start, location.End, complemented}}
+	}
+	var segments []segment
+	for _, subLocation := range location.SubLocations {
+		segments = append(segments, transcriptSegments(subLocation, complemented)...)
+	}
+	return segments
+}
+
+// codingToGenomic converts a c. position into a 0-based genomic index, the
+// strand direction at that position (1 forward, -1 reverse), and the index of
+// the segment it was resolved against.
+func codingToGenomic(position Position, segments []segment) (int, int, int, error) {
+	if len(segments) == 0 {
+		return 0, 0, 0, errors.New("coding feature has no location")
+	}
+	direction := func(seg segment) int {
+		if seg.complement {
+			return -1
+		}
+		return 1
+	}
+	// genomic index of the base at 0-based transcript index within a segment.
+	baseAt := func(seg segment, index int) int {
+		if seg.complement {
+			return seg.end - 1 - index
+		}
+		return seg.start + index
+	}
+
+	var genomic, strand, segmentIndex int
+	switch {
+	case position.Downstream:
+		segmentIndex = len(segments) - 1
+		last := segments[segmentIndex]
+		strand = direction(last)
+		genomic = baseAt(last, last.end-last.start-1) + strand*position.Index
+	case position.Index < 0:
+		first := segments[0]
+		strand = direction(first)
+		genomic = baseAt(first, 0) + strand*position.Index
+	default:
+		remaining := position.Index - 1
+		found := false
+		for index, seg := range segments {
+			length := seg.end - seg.start
+			if remaining < length {
+				genomic, strand, segmentIndex, found = baseAt(seg, remaining), direction(seg), index, true
+				break
+			}
+			remaining -= length
+		}
+		if !found || position.Index == 0 {
+			return 0, 0, 0, fmt.Errorf("position c.%s is outside of the coding sequence", position)
+		}
+	}
+	return genomic + strand*position.Offset, strand, segmentIndex, nil
+}
+
+// ToGenomic resolves a c. or p. variant against the coding feature cds of
+// record, returning the equivalent g. (or o. for circular records) variant.
+// Genomic variants are returned unchanged.
+//
+// Bases in c. variants are written 5' to 3' along the feature, so variants
+// on reverse strand features are reverse complemented. Protein variants are
+// reverse translated with table, choosing codons that minimize the number of
+// nucleotide changes. table may be nil for non-protein variants.
+func ToGenomic(record genbank.Genbank, cds genbank.Feature, variant Variant, table codon.Table) (Variant, error) {
+	switch variant.System {
+	case Genomic, Circular:
+		return variant, nil
+	case Protein:
+		return proteinToGenomic(record, cds, variant, table)
+	case Coding, NonCoding:
+	default:
+		return Variant{}, fmt.Errorf("unsupported coordinate system %q", variant.System)
+	}
+
+	segments := transcriptSegments(cds.Location, false)
+	start, strand, startSegment, err := codingToGenomic(variant.Start, segments)
+	if err != nil {
+		return Variant{}, err
+	}
+	end, _, endSegment, err := codingToGenomic(variant.End, segments)
+	if err != nil {
+		return Variant{}, err
+	}
+	// a single g. variant can't describe an edit on both sides of an intron.
+	if startSegment != endSegment {
+		return Variant{}, fmt.Errorf("variant %s spans more than one exon", variant)
+	}
+
+	genomic := variant
+	genomic.Accession = ""
+	genomic.System = genomicSystem(record)
+	genomic.Predicted = false
+	if strand < 0 {
+		start, end = end, start
+		genomic.Reference = transform.ReverseComplement(variant.Reference)
+		genomic.Alternate = transform.ReverseComplement(variant.Alternate)
+	}
+	genomic.Start = Position{Index: start + 1}
+	genomic.End = Position{Index: end + 1}
+	return genomic, nil
+}
+
+func genomicSystem(record genbank.Genbank) System {
+	if record.Meta.Locus.Circular {
+		return Circular
+	}
+	return Genomic
+}
+
+// proteinToGenomic reverse translates a p. variant into a g. variant.
+func proteinToGenomic(record genbank.Genbank, cds genbank.Feature, variant Variant, table codon.Table) (Variant, error) {
+	if table == nil {
+		return Variant{}, errNoCodonTable
+	}
+	if variant.Kind == Frameshift {
+		return Variant{}, errFrameshift
+	}
+	if variant.Kind == Extension {
+		return Variant{}, errExtension
+	}
+	if variant.Kind == Identity {
+		return Variant{System: genomicSystem(record), Kind: Identity}, nil
+	}
+
+	// Transcript coordinates of the affected codons, 1-based like c. positions.
+	firstBase := (variant.Start.Index-1)*3 + 1
+	lastBase := variant.End.Index * 3
+	cds.ParentSequence = &record
+	coding, err := cds.GetSequence()
+	if err != nil {
+		return Variant{}, err
+	}
+	coding = strings.ToUpper(coding)
+	if lastBase > len(coding) || firstBase < 1 {
+		return Variant{}, fmt.Errorf("protein position %s is outside of the coding sequence", variant.End)
+	}
+	for _, position := range []Position{variant.Start, variant.End} {
+		codonStart := (position.Index - 1) * 3
+		translated, err := table.Translate(coding[codonStart : codonStart+3])
+		if err != nil {
+			return Variant{}, err
+		}
+		if translated != string(position.Residue) {
+			return Variant{}, fmt.Errorf("%w: expected %s at %d, found %s", errReferenceMismatch, threeLetterString(string(position.Residue)), position.Index, threeLetterString(translated))
+		}
+	}
+
+	codonsByAminoAcid := make(map[byte][]codon.Codon)
+	for _, aminoAcid := range table.GetWeightedAminoAcids() {
+		if len(aminoAcid.Letter) == 1 {
+			codonsByAminoAcid[aminoAcid.Letter[0]] = aminoAcid.Codons
+		}
+	}
+	reverseTranslate := func(aminoAcids, current string) (string, error) {
+		var dna strings.Builder
+		for index := 0; index < len(aminoAcids); index++ {
+			var currentCodon string
+			if len(current) >= (index+1)*3 {
+				currentCodon = current[index*3 : index*3+3]
+			}
+			triplet, err := closestCodon(codonsByAminoAcid[aminoAcids[index]], currentCodon)
+			if err != nil {
+				return "", fmt.Errorf("amino acid %q: %w", aminoAcids[index], err)
+			}
+			dna.WriteString(triplet)
+		}
+		return dna.String(), nil
+	}
+
+	codingVariant := Variant{System: Coding, Start: Position{Index: firstBase}, End: Position{Index: lastBase}}
+	affected := coding[firstBase-1 : lastBase]
+	switch variant.Kind {
+	case Substitution:
+		replacement, err := reverseTranslate(variant.Alternate, affected)
+		if err != nil {
+			return Variant{}, err
+		}
+		codingVariant = describeReplacement(firstBase, affected, replacement)
+	case Deletion:
+		codingVariant.Kind = Deletion
+		codingVariant.Reference = affected
+	case Duplication:
+		codingVariant.Kind = Duplication
+		codingVariant.Reference = affected
+	case DeletionInsertion:
+		replacement, err := reverseTranslate(variant.Alternate, affected)
+		if err != nil {
+			return Variant{}, err
+		}
+		codingVariant = describeReplacement(firstBase, affected, replacement)
+	case Insertion:
+		inserted, err := reverseTranslate(variant.Alternate, "")
+		if err != nil {
+			return Variant{}, err
+		}
+		codingVariant.Kind = Insertion
+		codingVariant.Start = Position{Index: firstBase + 2}
+		codingVariant.End = Position{Index: firstBase + 3}
+		codingVariant.Alternate = inserted
+	default:
+		return Variant{}, fmt.Errorf("unsupported protein variant kind %d", variant.Kind)
+	}
+	return ToGenomic(record, cds, codingVariant, nil)
+}
+
+// describeReplacement returns the smallest c. variant turning the bases
+// starting at 1-based position start from reference into alternate.
+func describeReplacement(start int, reference, alternate string) Variant {
+	prefix := 0
+	for prefix < len(reference) && prefix < len(alternate) && reference[prefix] == alternate[prefix] {
+		prefix++
+	}
+	if prefix == len(reference) && len(reference) == len(alternate) {
+		return Variant{System: Coding, Kind: Identity, Start: Position{Index: start}, End: Position{Index: start + len(reference) - 1}}
+	}
+	suffix := 0
+	for suffix < len(reference)-prefix && suffix < len(alternate)-prefix && reference[len(reference)-1-suffix] == alternate[len(alternate)-1-suffix] {
+		suffix++
+	}
+	reference = reference[prefix : len(reference)-suffix]
+	alternate = alternate[prefix : len(alternate)-suffix]
+	variant := Variant{
+		System:    Coding,
+		Start:     Position{Index: start + prefix},
+		End:       Position{Index: start + prefix + len(reference) - 1},
+		Kind:      DeletionInsertion,
+		Reference: reference,
+		Alternate: alternate,
+	}
+	switch {
+	case len(reference) == 0:
+		// nothing is replaced, so alternate goes between the bases either
+		// side of it. There's no c.0, c.-1 comes right before c.1.
+		before := start + prefix - 1
+		if before == 0 {
+			before = -1
+		}
+		variant.Kind = Insertion
+		variant.Start, variant.End = Position{Index: before}, Position{Index: start + prefix}
+	case len(alternate) == 0:
+		variant.Kind = Deletion
+	case len(reference) == 1 && len(alternate) == 1:
+		variant.Kind = Substitution
+	}
+	return variant
+}
+
+// closestCodon returns the codon from codons with the fewest differences to
+// current, breaking ties by weight and then alphabetically.
+func closestCodon(codons []codon.Codon, current string) (string, error) {
+	if len(codons) == 0 {
+		return "", errors.New("no codons in codon table")
+	}
+	candidates := append([]codon.Codon{}, codons...)
+	distance := func(triplet string) int {
+		if len(current) != len(triplet) {
+			return 0
+		}
+		differences := 0
+		for index := range triplet {
+			if triplet[index] != current[index] {
+				differences++
+			}
+		}
+		return differences
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		distanceI, distanceJ := distance(candidates[i].Triplet), distance(candidates[j].Triplet)
+		if distanceI != distanceJ {
+			return distanceI < distanceJ
+		}
+		if candidates[i].Weight != candidates[j].Weight {
+			return candidates[i].Weight > candidates[j].Weight
+		}
+		return candidates[i].Triplet < candidates[j].Triplet
+	})
+	return candidates[0].Triplet, nil
+}
+
+// Apply applies a genomic (g. or o.) variant to a copy of record, shifting,
+// growing, or shrinking feature locations to follow the edit. Use ToGenomic
+// to apply c. and p. variants.
+func Apply(record genbank.Genbank, variant Variant) (genbank.Genbank, error) {
+	if variant.System != Genomic && variant.System != Circular {
+		return genbank.Genbank{}, errNotGenomic
+	}
+	if variant.Kind == Identity {
+		return record, nil
+	}
+	sequence := record.Sequence
+	start, end := variant.Start.Index-1, variant.End.Index // 0-based, half-open
+	if start < 0 || end > len(sequence) || start >= end {
+		return genbank.Genbank{}, fmt.Errorf("variant %s is outside of the sequence", variant)
+	}
+	affected := sequence[start:end]
+	if variant.Reference != "" && !strings.EqualFold(variant.Reference, affected) && variant.Kind != Insertion {
+		return genbank.Genbank{}, fmt.Errorf("%w: expected %s at %d, found %s", errReferenceMismatch, variant.Reference, variant.Start.Index, affected)
+	}
+
+	var replacement string
+	switch variant.Kind {
+	case Substitution, DeletionInsertion:
+		replacement = variant.Alternate
+	case Deletion:
+		replacement = ""
+	case Duplication:
+		// model as an insertion of the duplicated bases after the original.
+		start, replacement = end, affected
+	case Insertion:
+		if end-start != 2 {
+			return genbank.Genbank{}, fmt.Errorf("insertion %s must be between two adjacent positions", variant)
+		}
+		start, end = start+1, start+1
+		replacement = variant.Alternate
+	case Inversion:
+		replacement = transform.ReverseComplement(affected)
+	default:
+		return genbank.Genbank{}, errFrameshift
+	}
+	if isLower(sequence) {
+		replacement = strings.ToLower(replacement)
+	}
+
+	edited := record
+	edited.Sequence = sequence[:start] + replacement + sequence[end:]
+	edited.Features = make([]genbank.Feature, len(record.Features))
+	for index, feature := range record.Features {
+		feature.Location = shiftLocation(feature.Location, start, end, len(replacement))
+		edited.Features[index] = feature
+	}
+	for index := range edited.Features {
+		edited.Features[index].ParentSequence = &edited
+	}
+	if record.Meta.Locus.SequenceLength != "" {
+		edited.Meta.Locus.SequenceLength = strconv.Itoa(len(edited.Sequence))
+	}
+	return edited, nil
+}
+
+// isLower reports whether a sequence is written in lowercase, like most GenBank files.
+func isLower(sequence string) bool {
+	for index := 0; index < len(sequence); index++ {
+		switch {
+		case sequence[index] >= 'a' && sequence[index] <= 'z':
+			return true
+		case sequence[index] >= 'A' && sequence[index] <= 'Z':
+			return false
+		}
+	}
+	return false
+}
+
+// shiftLocation moves a location to account for [start, end) being replaced
+// by replacementLength bases.
+func shiftLocation(location genbank.Location, start, end, replacementLength int) genbank.Location {
+	delta := replacementLength - (end - start)
+	location.GbkLocationString = ""
+	if len(location.SubLocations) > 0 {
+		subLocations := make([]genbank.Location, len(location.SubLocations))
+		for index, subLocation := range location.SubLocations {
+			subLocations[index] = shiftLocation(subLocation, start, end, replacementLength)
+		}
+		location.SubLocations = subLocations
+		return location
+	}
+	switch {
+	case location.Start >= end:
+		location.Start += delta
+	case location.Start > start:
+		location.Start = start
+	}
+	switch {
+	case location.End <= start:
+	case location.End >= end:
+		location.End += delta
+	default:
+		location.End = start
+	}
+	return location
+}
+
+/******************************************************************************
+
+Variant generation begins here.
+
+******************************************************************************/
+
+// Describe generates the variants that turn reference into alternate. For
+// nucleotide systems differences are described 3' shifted as the HGVS
+// nomenclature requires. For the Protein system reference and alternate are
+// one letter amino acid sequences, and residues added past either end of
+// reference are described as an extension.
+//
+// Sequences of equal length are described as one substitution (or
+// deletion-insertion) per run of mismatches. Sequences of different lengths
+// are described as a single variant spanning the difference.
+func Describe(reference, alternate string, system System) []Variant {
+	reference, alternate = strings.ToUpper(reference), strings.ToUpper(alternate)
+	if reference == alternate {
+		return []Variant{{System: system, Kind: Identity}}
+	}
+	if len(reference) != len(alternate) {
+		return []Variant{describeSingle(reference, alternate, 0, system)}
+	}
+	var variants []Variant
+	for index := 0; index < len(reference); {
+		if reference[index] == alternate[index] {
+			index++
+			continue
+		}
+		runEnd := index
+		for runEnd < len(reference) && reference[runEnd] != alternate[runEnd] {
+			runEnd++
+		}
+		variants = append(variants, describeSingle(reference[index:runEnd], alternate[index:runEnd], index, system))
+		index = runEnd
+	}
+	return variants
+}
+
+// describeSingle describes a single edit of reference into alternate, where
+// reference starts at 0-based offset in the reference sequence.
+func describeSingle(reference, alternate string, offset int, system System) Variant {
+	// trimming the common prefix first pushes indels in repeats 3'.
+	prefix := 0
+	for prefix < len(reference) && prefix < len(alternate) && reference[prefix] == alternate[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(reference)-prefix && suffix < len(alternate)-prefix && reference[len(reference)-1-suffix] == alternate[len(alternate)-1-suffix] {
+		suffix++
+	}
+	deleted := reference[prefix : len(reference)-suffix]
+	inserted := alternate[prefix : len(alternate)-suffix]
+	start := offset + prefix // 0-based start of the deleted region.
+
+	position := func(index int) Position { // 0-based index to Position
+		// insertions at the very end of a protein have no residue after them.
+		if system == Protein && index-offset >= 0 && index-offset < len(reference) {
+			return Position{Index: index + 1, Residue: reference[index-offset]}
+		}
+		return Position{Index: index + 1}
+	}
+	variant := Variant{System: system}
+	if system != Protein && len(inserted) > 0 {
+		variant.Reference = deleted
+	}
+	switch {
+	case len(deleted) == 0:
+		// insertions of a copy of the preceding bases are duplications.
+		if insertedStart := start - len(inserted); insertedStart >= offset && reference[insertedStart-offset:start-offset] == inserted {
+			variant.Kind = Duplication
+			variant.Start, variant.End = position(insertedStart), position(start-1)
+			return variant
+		}
+		if system == Protein && (start-offset == len(reference) || start == 0) {
+			return describeExtension(reference, inserted, start)
+		}
+		variant.Kind = Insertion
+		variant.Start, variant.End = position(start-1), position(start)
+		variant.Alternate = inserted
+	case len(inserted) == 0:
+		variant.Kind = Deletion
+		variant.Start, variant.End = position(start), position(start+len(deleted)-1)
+	case len(deleted) == 1 && len(inserted) == 1:
+		variant.Kind = Substitution
+		variant.Start, variant.End = position(start), position(start)
+		variant.Alternate = inserted
+	case system != Protein && len(deleted) > 1 && inserted == transform.ReverseComplement(deleted):
+		variant.Kind = Inversion
+		variant.Start, variant.End = position(start), position(start+len(deleted)-1)
+		variant.Reference = ""
+	default:
+		variant.Kind = DeletionInsertion
+		variant.Start, variant.End = position(start), position(start+len(deleted)-1)
+		variant.Alternate = inserted
+	}
+	return variant
+}
+
+// describeExtension describes residues inserted at 0-based start, before the
+// first or after the last residue of a protein, as an extension.
+func describeExtension(reference, inserted string, start int) Variant {
+	variant := Variant{System: Protein, Kind: Extension, Alternate: inserted}
+	if start == 0 && reference != "" {
+		variant.Start = Position{Index: 1, Residue: reference[0]}
+		variant.End = Position{Index: -len(inserted)}
+		return variant
+	}
+	// the stop codon after the last residue is what's extended, and the
+	// extension ends at the first new stop codon, if there is one.
+	stop := strings.IndexByte(inserted, '*')
+	if stop == 0 {
+		return Variant{System: Protein, Kind: Identity}
+	}
+	variant.Start = Position{Index: start + 1, Residue: '*'}
+	variant.End = Position{Downstream: true}
+	if stop > 0 {
+		variant.Alternate = inserted[:stop]
+		variant.End.Index = stop
+	}
+	return variant
+}
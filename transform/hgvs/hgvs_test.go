@@ -0,0 +1,390 @@
+package hgvs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/synthesis/codon"
+	"github.com/bebop/poly/transform"
+)
+
+// testRecord returns a small record with a forward CDS, a reverse CDS, and a
+// spliced CDS, all encoding MKR*.
+func testRecord() genbank.Genbank {
+	forward := "ATGAAACGTTAA"
+	reverse := transform.ReverseComplement(forward)
+	// spliced: ATGA | GTAAGT intron | AACGTTAA
+	spliced := "ATGA" + "GTAAGT" + "AACGTTAA"
+	sequence := "GGG" + forward + "CCC" + reverse + "CCC" + spliced + "TTT"
+
+	record := genbank.Genbank{Sequence: sequence}
+	record.Meta.Locus.SequenceLength = "54"
+	record.Features = []genbank.Feature{
+		{Type: "CDS", Location: genbank.Location{Start: 3, End: 15}},
+		{Type: "CDS", Location: genbank.Location{Start: 18, End: 30, Complement: true}},
+		{Type: "CDS", Location: genbank.Location{Join: true, SubLocations: []genbank.Location{{Start: 33, End: 37}, {Start: 43, End: 51}}}},
+	}
+	for index := range record.Features {
+		record.Features[index].ParentSequence = &record
+	}
+	return record
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	descriptions := []string{
+		"NM_004006.2:c.4375C>T",
+		"g.32_33insGA",
+		"g.12del",
+		"g.12_14delACG",
+		"g.5dup",
+		"c.-14G>C",
+		"c.*5A>G",
+		"c.88+1G>T",
+		"c.89-2_89-1del",
+		"g.10_20inv",
+		"g.10_12delinsTT",
+		"g.10=",
+		"p.Arg97Gly",
+		"p.(Arg97Gly)",
+		"p.Trp24Ter",
+		"p.Lys2del",
+		"p.Lys2_Leu5dup",
+		"p.Lys2_Leu3insGlnSer",
+		"p.Cys28delinsTrpVal",
+		"p.Arg97ProfsTer23",
+		"p.*110Glnext*17",
+		"p.*327Argext*?",
+		"p.Met1ext-5",
+		"p.=",
+	}
+	for _, description := range descriptions {
+		variant, err := Parse(description)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %s", description, err)
+			continue
+		}
+		if got := variant.String(); got != description {
+			t.Errorf("Parse(%q).String() = %q", description, got)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	variant, err := Parse("NM_004006.2:c.88+1G>T")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Variant{
+		Accession: "NM_004006.2",
+		System:    Coding,
+		Start:     Position{Index: 88, Offset: 1},
+		End:       Position{Index: 88, Offset: 1},
+		Kind:      Substitution,
+		Reference: "G",
+		Alternate: "T",
+	}
+	if variant != expected {
+		t.Errorf("got %+v, expected %+v", variant, expected)
+	}
+
+	variant, err = Parse("p.Ter110GlnextTer17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = Variant{
+		System:    Protein,
+		Start:     Position{Index: 110, Residue: '*'},
+		End:       Position{Index: 17, Downstream: true},
+		Kind:      Extension,
+		Alternate: "Q",
+	}
+	if variant != expected {
+		t.Errorf("got %+v, expected %+v", variant, expected)
+	}
+
+	variant, err = Parse("p.Trp24*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if variant.Kind != Substitution || variant.Alternate != "*" || variant.Start.Residue != 'W' {
+		t.Errorf("unexpected nonsense variant %+v", variant)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, description := range []string{
+		"",
+		"4375C>T",
+		"x.12A>G",
+		"g.12A>",
+		"g.12insA",
+		"g.-12A>G",
+		"g.5_*3del",
+		"n.5_10+2del",
+		"p.Xyz12Gly",
+		"p.Arg12_Gly14Ala",
+		"g.12delinsXYZ",
+		"p.Arg110Glnext*17",
+		"p.Lys2ext-5",
+	} {
+		if variant, err := Parse(description); err == nil {
+			t.Errorf("Parse(%q) should have failed, got %+v", description, variant)
+		}
+	}
+}
+
+func TestToGenomic(t *testing.T) {
+	record := testRecord()
+	table, err := codon.NewTranslationTable(11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		feature  int
+		variant  string
+		expected string
+	}{
+		// forward strand, c.1 is g.4
+		{0, "c.4A>G", "g.7A>G"},
+		{0, "c.-1G>A", "g.3G>A"},
+		{0, "c.*1C>T", "g.16C>T"},
+		{0, "c.4_6del", "g.7_9del"},
+		{0, "p.Lys2Glu", "g.7A>G"},
+		{0, "p.Arg3Gly", "g.10C>G"},
+		{0, "p.Lys2_Arg3insGly", "g.9_10insGGA"},
+		{0, "p.Arg3del", "g.10_12delCGT"},
+		// delins that only add or only remove codons are insertions and deletions.
+		{0, "p.Lys2delinsLysGln", "g.9_10insCAA"},
+		{0, "p.Lys2_Arg3delinsLys", "g.10_12delCGT"},
+		// reverse strand, c.1 is g.30
+		{1, "c.4A>G", "g.27T>C"},
+		{1, "c.4_5insC", "g.26_27insG"},
+		{1, "p.Lys2Glu", "g.27T>C"},
+		// spliced, c.4 is the last base of exon one and c.5 the first of exon two
+		{2, "c.4A>G", "g.37A>G"},
+		{2, "c.4+1G>A", "g.38G>A"},
+		{2, "c.5-1T>C", "g.43T>C"},
+		{2, "c.5A>T", "g.44A>T"},
+		{2, "p.Lys2Glu", "g.37A>G"},
+	}
+	for _, testCase := range testCases {
+		variant, err := Parse(testCase.variant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		genomic, err := ToGenomic(record, record.Features[testCase.feature], variant, table)
+		if err != nil {
+			t.Errorf("ToGenomic(%s) on feature %d returned error: %s", testCase.variant, testCase.feature, err)
+			continue
+		}
+		if got := genomic.String(); got != testCase.expected {
+			t.Errorf("ToGenomic(%s) on feature %d = %s, expected %s", testCase.variant, testCase.feature, got, testCase.expected)
+		}
+	}
+}
+
+func TestToGenomicErrors(t *testing.T) {
+	record := testRecord()
+	table, _ := codon.NewTranslationTable(11)
+	for _, description := range []string{"p.Gly2Glu", "p.Arg97ProfsTer23", "p.Met1ext-5", "p.Lys20Glu", "c.40A>G"} {
+		variant, err := Parse(description)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if genomic, err := ToGenomic(record, record.Features[0], variant, table); err == nil {
+			t.Errorf("ToGenomic(%s) should have failed, got %s", description, genomic)
+		}
+	}
+	// edits across the intron of the spliced CDS can't be one g. variant.
+	for _, description := range []string{"c.4_5del", "p.Lys2_Arg3delinsGlyGlyGly"} {
+		variant, _ := Parse(description)
+		if genomic, err := ToGenomic(record, record.Features[2], variant, table); err == nil {
+			t.Errorf("ToGenomic(%s) on spliced CDS should have failed, got %s", description, genomic)
+		}
+	}
+
+	variant, _ := Parse("p.Lys2Glu")
+	if _, err := ToGenomic(record, record.Features[0], variant, nil); !errors.Is(err, errNoCodonTable) {
+		t.Errorf("expected errNoCodonTable, got %v", err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	record := testRecord()
+	table, _ := codon.NewTranslationTable(11)
+
+	testCases := []struct {
+		variant      string
+		sequence     string // sequence of the first CDS after applying the variant
+		startsOfCDSs []int
+		endsOfCDSs   []int
+	}{
+		{"g.7A>G", "ATGGAACGTTAA", []int{3, 18, 0}, []int{15, 30, 0}},
+		{"g.1del", "ATGAAACGTTAA", []int{2, 17, 0}, []int{14, 29, 0}},
+		{"g.7_9del", "ATGCGTTAA", []int{3, 15, 0}, []int{12, 27, 0}},
+		{"g.9_10insGGG", "ATGAAAGGGCGTTAA", []int{3, 21, 0}, []int{18, 33, 0}},
+		{"g.7_9dup", "ATGAAAAAACGTTAA", []int{3, 21, 0}, []int{18, 33, 0}},
+		{"g.4_9inv", "TTTCATCGTTAA", []int{3, 18, 0}, []int{15, 30, 0}},
+		{"g.7_9delinsGA", "ATGGACGTTAA", []int{3, 17, 0}, []int{14, 29, 0}},
+		{"g.2_5del", "GAAACGTTAA", []int{1, 14, 0}, []int{11, 26, 0}},
+	}
+	for _, testCase := range testCases {
+		variant, err := Parse(testCase.variant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		edited, err := Apply(record, variant)
+		if err != nil {
+			t.Errorf("Apply(%s) returned error: %s", testCase.variant, err)
+			continue
+		}
+		sequence, err := edited.Features[0].GetSequence()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sequence != testCase.sequence {
+			t.Errorf("Apply(%s) CDS = %s, expected %s", testCase.variant, sequence, testCase.sequence)
+		}
+		for index := range testCase.startsOfCDSs {
+			location := edited.Features[index].Location
+			if location.Start != testCase.startsOfCDSs[index] || location.End != testCase.endsOfCDSs[index] {
+				t.Errorf("Apply(%s) feature %d location = [%d, %d), expected [%d, %d)", testCase.variant, index, location.Start, location.End, testCase.startsOfCDSs[index], testCase.endsOfCDSs[index])
+			}
+		}
+	}
+
+	// the original record must be untouched.
+	if record.Features[0].Location.End != 15 || len(record.Sequence) != 54 {
+		t.Errorf("Apply modified the input record")
+	}
+
+	// spliced features move exon by exon.
+	variant, _ := Parse("g.40_41insAAA")
+	edited, err := Apply(record, variant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exons := edited.Features[2].Location.SubLocations
+	if exons[0].Start != 33 || exons[0].End != 37 || exons[1].Start != 46 || exons[1].End != 54 {
+		t.Errorf("unexpected spliced location after intronic insertion: %+v", exons)
+	}
+	if edited.Meta.Locus.SequenceLength != "57" {
+		t.Errorf("expected sequence length to be updated to 57, got %s", edited.Meta.Locus.SequenceLength)
+	}
+
+	// protein edits through ToGenomic translate as expected.
+	for _, description := range []string{"p.Lys2Glu", "p.Arg3Trp", "p.Lys2_Arg3delinsGlyGlyGly", "p.Lys2delinsLysGln", "p.Lys2_Arg3delinsLys"} {
+		variant, _ := Parse(description)
+		for _, feature := range []int{0, 1} {
+			genomic, err := ToGenomic(record, record.Features[feature], variant, table)
+			if err != nil {
+				t.Fatal(err)
+			}
+			edited, err := Apply(record, genomic)
+			if err != nil {
+				t.Fatalf("Apply(%s) returned error: %s", genomic, err)
+			}
+			sequence, _ := edited.Features[feature].GetSequence()
+			protein, _ := table.Translate(sequence)
+			expected := map[string]string{
+				"p.Lys2Glu":                  "MER*",
+				"p.Arg3Trp":                  "MKW*",
+				"p.Lys2_Arg3delinsGlyGlyGly": "MGGG*",
+				"p.Lys2delinsLysGln":         "MKQR*",
+				"p.Lys2_Arg3delinsLys":       "MK*",
+			}[description]
+			if protein != expected {
+				t.Errorf("%s on feature %d via %s translated to %s, expected %s", description, feature, genomic, protein, expected)
+			}
+		}
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	record := testRecord()
+	for _, description := range []string{"c.4A>G", "g.7C>G", "g.100del", "g.7_9insA"} {
+		variant, err := Parse(description)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Apply(record, variant); err == nil {
+			t.Errorf("Apply(%s) should have failed", description)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	testCases := []struct {
+		reference string
+		alternate string
+		system    System
+		expected  []string
+	}{
+		{"ACGTACGT", "ACGTACGT", Genomic, []string{"g.="}},
+		{"ACGTACGT", "ACCTACGA", Genomic, []string{"g.3G>C", "g.8T>A"}},
+		{"ACGTACGT", "ATTTACGT", Genomic, []string{"g.2_3delinsTT"}},
+		{"ACGTACGT", "ACGGTACGT", Genomic, []string{"g.3dup"}},
+		{"ACGTACGT", "ACGTTTACGT", Genomic, []string{"g.4_5insTT"}},
+		{"ACGTAAACGT", "ACGTAACGT", Genomic, []string{"g.7del"}}, // shifted 3'
+		{"ACGTAAACGT", "ACGTACGT", Genomic, []string{"g.6_7del"}},
+		{"AACCGGTTA", "AAACCGGTTA", Coding, []string{"c.2dup"}},
+		{"GGAACGCC", "GGCGTTCC", Coding, []string{"c.3_6inv"}},
+		{"ACGTACGT", "ACGTTTTTT", Genomic, []string{"g.5_7delinsTTTT"}},
+		{"MKRL", "MGRL", Protein, []string{"p.Lys2Gly"}},
+		{"MKRL", "MKL", Protein, []string{"p.Arg3del"}},
+		{"MKRL", "MKKRL", Protein, []string{"p.Lys2dup"}},
+		{"MKRL", "MKWWRL", Protein, []string{"p.Lys2_Arg3insTrpTrp"}},
+		{"MKRL", "MK*", Protein, []string{"p.Arg3_Leu4delinsTer"}},
+	}
+	for _, testCase := range testCases {
+		variants := Describe(testCase.reference, testCase.alternate, testCase.system)
+		if len(variants) != len(testCase.expected) {
+			t.Errorf("Describe(%s, %s) = %v, expected %v", testCase.reference, testCase.alternate, variants, testCase.expected)
+			continue
+		}
+		for index, variant := range variants {
+			if got := variant.String(); got != testCase.expected[index] {
+				t.Errorf("Describe(%s, %s)[%d] = %s, expected %s", testCase.reference, testCase.alternate, index, got, testCase.expected[index])
+			}
+		}
+	}
+}
+
+func TestDescribeProteinEnds(t *testing.T) {
+	// residues added past either end of a protein extend it.
+	testCases := []struct {
+		alternate string
+		expected  string
+	}{
+		{"MKRLA", "p.*5Alaext*?"},
+		{"MKRLAQ*", "p.*5Alaext*2"},
+		{"MKRL*", "p.="},
+		{"AMKRL", "p.Met1ext-1"},
+		{"MKRLL", "p.Leu4dup"},
+	}
+	for _, testCase := range testCases {
+		variants := Describe("MKRL", testCase.alternate, Protein)
+		if len(variants) != 1 || variants[0].String() != testCase.expected {
+			t.Errorf("Describe(MKRL, %s) = %v, expected %s", testCase.alternate, variants, testCase.expected)
+		}
+	}
+}
+
+func TestDescribeApplyRoundTrip(t *testing.T) {
+	reference := "ACGTAAACGTTTGCA"
+	alternates := []string{"ACGTAACGTTTGCA", "ACGTAAAACGTTTGCA", "ACGTAAACGGGTTTGCA", "ACGTTTACGTTTGCA", "TCGTAAACGTTTGCC"}
+	for _, alternate := range alternates {
+		record := genbank.Genbank{Sequence: reference}
+		for _, variant := range Describe(reference, alternate, Genomic) {
+			var err error
+			record, err = Apply(record, variant)
+			if err != nil {
+				t.Fatalf("Apply(%s) returned error: %s", variant, err)
+			}
+		}
+		if record.Sequence != alternate {
+			t.Errorf("applying Describe(%s, %s) produced %s", reference, alternate, record.Sequence)
+		}
+	}
+}
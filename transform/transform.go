@@ -3,7 +3,11 @@ Package transform provides functions for transforming sequences.
 */
 package transform
 
-import "unsafe"
+import (
+	"unsafe"
+
+	"github.com/bebop/poly/alphabet"
+)
 
 // ReverseComplement returns the reversed complement of sequence.
 // It is the equivalent of calling
@@ -16,7 +20,7 @@ func ReverseComplement(sequence string) string {
 	sequenceLength := len(sequence)
 	newSequence := make([]byte, sequenceLength)
 	for index := 0; index < sequenceLength; index++ {
-		newSequence[index] = complementTable[sequence[sequenceLength-index-1]]
+		newSequence[index] = alphabet.ComplementDNA(sequence[sequenceLength-index-1])
 	}
 	// This is how strings.Builder works with the String() method. If Mr. Go says it's safe...
 	return *(*string)(unsafe.Pointer(&newSequence))
@@ -40,7 +44,7 @@ func Complement(sequence string) string {
 	sequenceLength := len(sequence)
 	newSequence := make([]byte, sequenceLength)
 	for index := 0; index < sequenceLength; index++ {
-		newSequence[index] = complementTable[sequence[index]]
+		newSequence[index] = alphabet.ComplementDNA(sequence[index])
 	}
 	// This is how strings.Builder works with the String() method. If Mr. Go says it's safe...
 	return *(*string)(unsafe.Pointer(&newSequence))
@@ -67,47 +71,16 @@ func Reverse(sequence string) string {
 // will return a space ' ' (U+0020) for characters that are not matched
 // to any known base. This is subject to change.
 func ComplementBase(basePair rune) rune {
-	got := rune(complementTable[basePair])
+	if basePair < 0 || basePair > 255 {
+		return ' '
+	}
+	got := rune(alphabet.ComplementDNA(byte(basePair)))
 	if got == 0 {
 		return ' ' // invalid sequence returns empty space.
 	}
 	return got
 }
 
-// complementTable provides 1:1 mapping between bases and their complements
-var complementTable = [256]byte{
-	'A': 'T',
-	'B': 'V',
-	'C': 'G',
-	'D': 'H',
-	'G': 'C',
-	'H': 'D',
-	'K': 'M',
-	'M': 'K',
-	'N': 'N',
-	'R': 'Y',
-	'S': 'S',
-	'T': 'A',
-	'V': 'B',
-	'W': 'W',
-	'Y': 'R',
-	'a': 't',
-	'b': 'v',
-	'c': 'g',
-	'd': 'h',
-	'g': 'c',
-	'h': 'd',
-	'k': 'm',
-	'm': 'k',
-	'n': 'n',
-	'r': 'y',
-	's': 's',
-	't': 'a',
-	'v': 'b',
-	'w': 'w',
-	'y': 'r',
-}
-
 // ReverseComplementRNA returns the reversed complement of sequence.
 // It is the equivalent of calling
 //
@@ -119,7 +92,7 @@ func ReverseComplementRNA(sequence string) string {
 	sequenceLength := len(sequence)
 	newSequence := make([]byte, sequenceLength)
 	for index := 0; index < sequenceLength; index++ {
-		newSequence[index] = complementTableRNA[sequence[sequenceLength-index-1]]
+		newSequence[index] = alphabet.ComplementRNA(sequence[sequenceLength-index-1])
 	}
 	// This is how strings.Builder works with the String() method. If Mr. Go says it's safe...
 	return *(*string)(unsafe.Pointer(&newSequence))
@@ -143,7 +116,7 @@ func ComplementRNA(sequence string) string {
 	sequenceLength := len(sequence)
 	newSequence := make([]byte, sequenceLength)
 	for index := 0; index < sequenceLength; index++ {
-		newSequence[index] = complementTableRNA[sequence[index]]
+		newSequence[index] = alphabet.ComplementRNA(sequence[index])
 	}
 	// This is how strings.Builder works with the String() method. If Mr. Go says it's safe...
 	return *(*string)(unsafe.Pointer(&newSequence))
@@ -156,46 +129,12 @@ func ComplementRNA(sequence string) string {
 // will return a space ' ' (U+0020) for characters that are not matched
 // to any known base. This is subject to change.
 func ComplementBaseRNA(basePair rune) rune {
-	got := rune(complementTableRNA[basePair])
+	if basePair < 0 || basePair > 255 {
+		return ' '
+	}
+	got := rune(alphabet.ComplementRNA(byte(basePair)))
 	if got == 0 {
 		return ' ' // invalid sequence returns empty space.
 	}
 	return got
 }
-
-// complementTable provides 1:1 mapping between bases and their complements
-// see https://www.dnabaser.com/articles/IUPAC%20ambiguity%20codes.html
-var complementTableRNA = [256]byte{
-	'A': 'U',
-	'B': 'V',
-	'C': 'G',
-	'D': 'H',
-	'G': 'C',
-	'H': 'D',
-	'K': 'M',
-	'M': 'K',
-	'N': 'N',
-	'R': 'Y',
-	'S': 'S',
-	'U': 'A',
-	'V': 'B',
-	'W': 'W',
-	'Y': 'R',
-	'X': 'X',
-	'a': 'u',
-	'b': 'v',
-	'c': 'g',
-	'd': 'h',
-	'g': 'c',
-	'h': 'd',
-	'k': 'm',
-	'm': 'k',
-	'n': 'n',
-	'r': 'y',
-	's': 's',
-	'u': 'a',
-	'v': 'b',
-	'w': 'w',
-	'y': 'r',
-	'x': 'x',
-}
@@ -11,6 +11,8 @@ package variants
 import (
 	"errors"
 	"strings"
+
+	"github.com/bebop/poly/alphabet"
 )
 
 // AllVariantsIUPAC takes a string as input
@@ -19,28 +21,13 @@ func AllVariantsIUPAC(seq string) ([]string, error) {
 	seqVariantList := [][]rune{}
 	seqVariants := []string{}
 
-	iupac := map[rune][]rune{ // rune map of all iupac nucleotide variants
-		'G': {'G'},
-		'A': {'A'},
-		'T': {'T'},
-		'C': {'C'},
-		'R': {'G', 'A'},
-		'Y': {'T', 'C'},
-		'M': {'A', 'C'},
-		'K': {'G', 'T'},
-		'S': {'G', 'C'},
-		'W': {'A', 'T'},
-		'H': {'A', 'C', 'T'},
-		'B': {'G', 'T', 'C'},
-		'V': {'G', 'C', 'A'},
-		'D': {'G', 'A', 'T'},
-		'N': {'G', 'A', 'T', 'C'},
-	}
-
 	for _, s := range strings.ToUpper(seq) {
-		variantsIUPAC, ok := iupac[s]
-		if ok {
-			seqVariantList = append(seqVariantList, variantsIUPAC)
+		variantsIUPAC := ""
+		if s < 256 && s != 'U' {
+			variantsIUPAC = alphabet.Ambiguities(byte(s))
+		}
+		if variantsIUPAC != "" {
+			seqVariantList = append(seqVariantList, []rune(variantsIUPAC))
 		} else {
 			return seqVariants, errors.New("Error:" + string(s) + " is not a supported IUPAC character")
 		}
@@ -0,0 +1,33 @@
+package verify_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bebop/poly/io/fastq"
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/verify"
+)
+
+func ExampleVerify() {
+	lacZ := "ATGACCATGATTACGCCAAGCTTGCATGCCTGCAGGTCGACTCTAGAGGATCCCCGGGTACCGAGCTCGAATTCACTGGCCGTCGTTTTACAACGTCGTGACTGGGAAAACCCTGGCG"
+	reference := genbank.Genbank{Meta: genbank.Meta{Locus: genbank.Locus{Name: "lacZ"}}, Sequence: lacZ}
+	_ = reference.AddFeature(&genbank.Feature{Type: "CDS", Attributes: map[string]string{"label": "lacZ alpha"}, Location: genbank.Location{Start: 0, End: len(lacZ)}})
+
+	// the clone has an A to T substitution at base 60, and twelve reads
+	// covering all of it.
+	clone := lacZ[:59] + "T" + lacZ[60:]
+	var reads []fastq.Fastq
+	for copy := 0; copy < 12; copy++ {
+		reads = append(reads, fastq.Fastq{Identifier: fmt.Sprint(copy), Sequence: clone, Quality: strings.Repeat("I", len(clone))})
+	}
+
+	report, _ := verify.Verify("clone 1", reference, reads, verify.Options{})
+	for _, variant := range report.Variants {
+		fmt.Println(variant.Description, variant.Features)
+	}
+	fmt.Println(report.Passed)
+	// Output:
+	// g.60A>T [lacZ alpha]
+	// false
+}
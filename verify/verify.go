@@ -0,0 +1,418 @@
+/*
+Package verify checks clones against their intended sequence with sequencing reads.
+
+Every construct that comes back from cloning or synthesis has to be checked
+before it's used, and the check is always the same: map the reads to the
+plasmid map, see whether they cover all of it, and see whether they say the
+same thing it does. Verify does all of that for one clone and rolls the answer
+up into a Report:
+
+A consensus sequence called from the reads, with N wherever there weren't
+enough of them to say.
+
+Every variant between the consensus and the reference, described in HGVS
+notation, along with the annotated features it lands in.
+
+Mixed positions, where a sizable minority of reads disagree with the rest.
+That's usually a sign of a mixed colony rather than sequencing errors.
+
+Coverage, from the search/mapper package, and any stretch without enough
+reads.
+
+The report can be written out as JSON, for attaching to a LIMS record, or as a
+standalone HTML page for people to read.
+*/
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/io/fastq"
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/search/mapper"
+	"github.com/bebop/poly/transform"
+	"github.com/bebop/poly/transform/hgvs"
+)
+
+// Options changes how a clone is verified. Zero values are replaced with
+// the defaults noted on each field.
+type Options struct {
+	MinDepth int // fewest reads needed to call a base. Defaults to 10.
+	// MixedFrequency is the smallest fraction of reads a second allele needs
+	// for its position to be reported as mixed. Defaults to 0.2.
+	MixedFrequency float64
+	// Mapper changes how reads are mapped. Circular is always taken from the
+	// reference's locus.
+	Mapper mapper.Options
+}
+
+// Variant is a difference between the consensus and the reference.
+type Variant struct {
+	Position    int      `json:"position"`    // 0-based position in the reference. Insertions go before it.
+	Reference   string   `json:"reference"`   // reference bases, empty for insertions.
+	Alternate   string   `json:"alternate"`   // consensus bases, empty for deletions.
+	Depth       int      `json:"depth"`       // reads covering the variant.
+	Frequency   float64  `json:"frequency"`   // fraction of those reads that have it.
+	Description string   `json:"description"` // HGVS description, like "g.123A>G".
+	Features    []string `json:"features"`    // labels of the features it lands in.
+}
+
+// Mixed is a position where reads disagree.
+type Mixed struct {
+	Position       int     `json:"position"` // 0-based position in the reference.
+	Depth          int     `json:"depth"`
+	Major          string  `json:"major"` // allele of most reads, "-" for a deletion.
+	Minor          string  `json:"minor"` // allele of the next most reads.
+	MinorFrequency float64 `json:"minor_frequency"`
+}
+
+// Check is a single pass or fail test of a clone.
+type Check struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail"`
+}
+
+// Report is the verification of a single clone.
+type Report struct {
+	Clone       string               `json:"clone"`
+	Reference   string               `json:"reference"`
+	Length      int                  `json:"length"`
+	Reads       int                  `json:"reads"`
+	MappedReads int                  `json:"mapped_reads"`
+	MeanDepth   float64              `json:"mean_depth"`
+	Breadth     float64              `json:"breadth"`
+	Evenness    float64              `json:"evenness"`
+	LowCoverage []mapper.LowCoverage `json:"low_coverage"`
+	Consensus   string               `json:"consensus"`
+	Variants    []Variant            `json:"variants"`
+	Mixed       []Mixed              `json:"mixed"`
+	Checks      []Check              `json:"checks"`
+	Passed      bool                 `json:"passed"` // whether every check passed.
+}
+
+// Verify maps a clone's reads to its reference and reports how well they
+// agree.
+func Verify(clone string, reference genbank.Genbank, reads []fastq.Fastq, options Options) (Report, error) {
+	if options.MinDepth == 0 {
+		options.MinDepth = 10
+	}
+	if options.MixedFrequency == 0 {
+		options.MixedFrequency = 0.2
+	}
+	sequence := strings.ToUpper(reference.Sequence)
+	if sequence == "" {
+		return Report{}, fmt.Errorf("reference %s has no sequence", reference.Meta.Locus.Name)
+	}
+	name := reference.Meta.Locus.Name
+	if name == "" {
+		name = reference.Meta.Name
+	}
+	circular := reference.Meta.Locus.Circular
+	options.Mapper.Circular = circular
+	readMapper, err := mapper.New([]fasta.Fasta{{Name: name, Sequence: sequence}}, options.Mapper)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{Clone: clone, Reference: name, Length: len(sequence), Reads: len(reads)}
+	pile := newPileup(len(sequence))
+	for _, read := range reads {
+		alignment, ok := readMapper.Map(read.Sequence)
+		if !ok {
+			continue
+		}
+		report.MappedReads++
+		oriented := strings.ToUpper(read.Sequence)
+		if !alignment.Forward {
+			oriented = transform.ReverseComplement(oriented)
+		}
+		pile.add(alignment, oriented)
+	}
+	// reads with a deletion still cover it, so depths come from the pileup.
+	depths := make([]int, len(sequence))
+	for position := range depths {
+		depths[position] = pile.depth(position)
+	}
+	coverage := mapper.Summarize(name, depths, options.MinDepth)
+	report.MeanDepth, report.Breadth, report.Evenness = coverage.MeanDepth, coverage.Breadth, coverage.Evenness
+	report.LowCoverage = coverage.LowCoverage
+
+	report.Consensus, report.Variants, report.Mixed = pile.call(sequence, options)
+	system := hgvs.Genomic
+	if circular {
+		system = hgvs.Circular
+	}
+	features := reference.Index()
+	for index := range report.Variants {
+		variant := &report.Variants[index]
+		alternate := sequence[:variant.Position] + variant.Alternate + sequence[variant.Position+len(variant.Reference):]
+		if descriptions := hgvs.Describe(sequence, alternate, system); len(descriptions) == 1 {
+			variant.Description = descriptions[0].String()
+		}
+		start, end := variant.Position, variant.Position+len(variant.Reference)
+		if variant.Reference == "" {
+			// an insertion lands in the features on both sides of it.
+			start, end = max(start-1, 0), min(end+1, len(sequence))
+		}
+		for _, feature := range features.FeaturesOverlapping(start, end) {
+			if feature.Type != "source" {
+				variant.Features = append(variant.Features, label(feature))
+			}
+		}
+	}
+
+	lowBases := 0
+	for _, interval := range report.LowCoverage {
+		lowBases += interval.End - interval.Start
+	}
+	report.Checks = []Check{
+		{Name: "coverage", Passed: lowBases == 0},
+		{Name: "variants", Passed: len(report.Variants) == 0, Detail: fmt.Sprintf("%d variants", len(report.Variants))},
+		{Name: "mixed positions", Passed: len(report.Mixed) == 0, Detail: fmt.Sprintf("%d mixed positions", len(report.Mixed))},
+	}
+	if lowBases == 0 {
+		report.Checks[0].Detail = fmt.Sprintf("every base covered by at least %d reads", options.MinDepth)
+	} else {
+		report.Checks[0].Detail = fmt.Sprintf("%d bases in %d intervals covered by fewer than %d reads", lowBases, len(report.LowCoverage), options.MinDepth)
+	}
+	var hit []string
+	for _, variant := range report.Variants {
+		hit = append(hit, variant.Features...)
+	}
+	featureCheck := Check{Name: "features", Passed: len(hit) == 0, Detail: "no variants in annotated features"}
+	if len(hit) > 0 {
+		featureCheck.Detail = "variants in " + strings.Join(unique(hit), ", ")
+	}
+	report.Checks = append(report.Checks, featureCheck)
+
+	report.Passed = true
+	for _, check := range report.Checks {
+		report.Passed = report.Passed && check.Passed
+	}
+	return report, nil
+}
+
+// label returns the most readable name of a feature.
+func label(feature genbank.Feature) string {
+	for _, qualifier := range []string{"label", "gene", "product", "note"} {
+		if value := feature.Attributes[qualifier]; value != "" {
+			return value
+		}
+	}
+	return feature.Type
+}
+
+// unique returns the distinct strings of values, in order.
+func unique(values []string) []string {
+	seen := make(map[string]bool)
+	var distinct []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			distinct = append(distinct, value)
+		}
+	}
+	return distinct
+}
+
+/******************************************************************************
+
+Consensus calling begins here.
+
+Every mapped read is walked along its CIGAR string, and every reference base
+it covers gets a vote for the read's base there, or for a deletion. Inserted
+bases get a vote at the reference position they come before. The consensus
+takes the majority at every position with enough reads, and the variants are
+wherever the majority disagrees with the reference.
+
+******************************************************************************/
+
+// pileup counts the alleles of mapped reads at every reference position.
+type pileup struct {
+	alleles    []map[string]int // base, or "-" for a deletion, at every position.
+	insertions []map[string]int // bases inserted before every position.
+}
+
+func newPileup(length int) *pileup {
+	pile := &pileup{alleles: make([]map[string]int, length), insertions: make([]map[string]int, length)}
+	for position := range pile.alleles {
+		pile.alleles[position] = make(map[string]int)
+		pile.insertions[position] = make(map[string]int)
+	}
+	return pile
+}
+
+// add counts a read, oriented to the forward strand of the reference.
+func (pile *pileup) add(alignment mapper.Alignment, read string) {
+	length := len(pile.alleles)
+	position, readPosition := alignment.Position, 0
+	for _, operation := range alignment.Operations() {
+		switch operation.Kind {
+		case '=', 'X', 'M':
+			for step := 0; step < operation.Length; step++ {
+				pile.alleles[(position+step)%length][read[readPosition+step:readPosition+step+1]]++
+			}
+			position += operation.Length
+			readPosition += operation.Length
+		case 'D':
+			for step := 0; step < operation.Length; step++ {
+				pile.alleles[(position+step)%length]["-"]++
+			}
+			position += operation.Length
+		case 'I':
+			pile.insertions[position%length][read[readPosition:readPosition+operation.Length]]++
+			readPosition += operation.Length
+		}
+	}
+}
+
+// depth is the number of reads covering a position.
+func (pile *pileup) depth(position int) int {
+	depth := 0
+	for _, count := range pile.alleles[position] {
+		depth += count
+	}
+	return depth
+}
+
+// ranked returns the alleles of counts from most to least common.
+func ranked(counts map[string]int) []string {
+	alleles := make([]string, 0, len(counts))
+	for allele := range counts {
+		alleles = append(alleles, allele)
+	}
+	sort.Slice(alleles, func(i, j int) bool {
+		if counts[alleles[i]] != counts[alleles[j]] {
+			return counts[alleles[i]] > counts[alleles[j]]
+		}
+		return alleles[i] < alleles[j]
+	})
+	return alleles
+}
+
+// call calls the consensus, variants, and mixed positions of a pileup.
+func (pile *pileup) call(reference string, options Options) (string, []Variant, []Mixed) {
+	var consensus strings.Builder
+	var variants []Variant
+	var mixed []Mixed
+	var deletion *Variant // deletion being extended, if any.
+	for position := range reference {
+		depth := pile.depth(position)
+		if depth < options.MinDepth {
+			consensus.WriteByte('N')
+			deletion = nil
+			continue
+		}
+
+		if inserted := ranked(pile.insertions[position]); len(inserted) > 0 && pile.insertions[position][inserted[0]]*2 > depth {
+			consensus.WriteString(inserted[0])
+			variants = append(variants, Variant{
+				Position:  position,
+				Alternate: inserted[0],
+				Depth:     depth,
+				Frequency: float64(pile.insertions[position][inserted[0]]) / float64(depth),
+			})
+			deletion = nil
+		}
+
+		alleles := ranked(pile.alleles[position])
+		major := alleles[0]
+		if len(alleles) > 1 {
+			if frequency := float64(pile.alleles[position][alleles[1]]) / float64(depth); frequency >= options.MixedFrequency {
+				mixed = append(mixed, Mixed{Position: position, Depth: depth, Major: major, Minor: alleles[1], MinorFrequency: frequency})
+			}
+		}
+		frequency := float64(pile.alleles[position][major]) / float64(depth)
+		switch {
+		case major == "-" && deletion != nil:
+			deletion.Reference += reference[position : position+1]
+			deletion.Depth = min(deletion.Depth, depth)
+			deletion.Frequency = min(deletion.Frequency, frequency)
+		case major == "-":
+			variants = append(variants, Variant{Position: position, Reference: reference[position : position+1], Depth: depth, Frequency: frequency})
+			deletion = &variants[len(variants)-1]
+		default:
+			consensus.WriteString(major)
+			deletion = nil
+			if major != reference[position:position+1] {
+				variants = append(variants, Variant{Position: position, Reference: reference[position : position+1], Alternate: major, Depth: depth, Frequency: frequency})
+			}
+		}
+	}
+	return consensus.String(), variants, mixed
+}
+
+/******************************************************************************
+
+Report writing begins here.
+
+******************************************************************************/
+
+// WriteJSON writes a report as indented JSON.
+func (report Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// WriteHTML writes a report as a standalone HTML page.
+func (report Report) WriteHTML(w io.Writer) error {
+	return reportTemplate.Execute(w, report)
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent":  func(fraction float64) string { return fmt.Sprintf("%.1f%%", 100*fraction) },
+	"oneBased": func(position int) int { return position + 1 },
+	"join":     strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Clone}} verification</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.8em; text-align: left; }
+.pass { color: #1a7f37; }
+.fail { color: #cf222e; }
+</style>
+</head>
+<body>
+<h1>{{.Clone}}: {{if .Passed}}<span class="pass">passed</span>{{else}}<span class="fail">failed</span>{{end}}</h1>
+<table>
+<tr><th>Reference</th><td>{{.Reference}} ({{.Length}} bp)</td></tr>
+<tr><th>Reads mapped</th><td>{{.MappedReads}} of {{.Reads}}</td></tr>
+<tr><th>Mean depth</th><td>{{printf "%.1f" .MeanDepth}}</td></tr>
+<tr><th>Breadth</th><td>{{percent .Breadth}}</td></tr>
+<tr><th>Evenness</th><td>{{printf "%.3f" .Evenness}}</td></tr>
+</table>
+<h2>Checks</h2>
+<table>
+<tr><th>Check</th><th>Result</th><th>Detail</th></tr>
+{{range .Checks}}<tr><td>{{.Name}}</td><td>{{if .Passed}}<span class="pass">pass</span>{{else}}<span class="fail">fail</span>{{end}}</td><td>{{.Detail}}</td></tr>
+{{end}}</table>
+{{if .Variants}}<h2>Variants</h2>
+<table>
+<tr><th>Position</th><th>Variant</th><th>Depth</th><th>Frequency</th><th>Features</th></tr>
+{{range .Variants}}<tr><td>{{oneBased .Position}}</td><td>{{.Description}}</td><td>{{.Depth}}</td><td>{{percent .Frequency}}</td><td>{{join .Features ", "}}</td></tr>
+{{end}}</table>
+{{end}}{{if .Mixed}}<h2>Mixed positions</h2>
+<table>
+<tr><th>Position</th><th>Major</th><th>Minor</th><th>Minor frequency</th><th>Depth</th></tr>
+{{range .Mixed}}<tr><td>{{oneBased .Position}}</td><td>{{.Major}}</td><td>{{.Minor}}</td><td>{{percent .MinorFrequency}}</td><td>{{.Depth}}</td></tr>
+{{end}}</table>
+{{end}}{{if .LowCoverage}}<h2>Low coverage</h2>
+<table>
+<tr><th>Start</th><th>End</th><th>Mean depth</th></tr>
+{{range .LowCoverage}}<tr><td>{{oneBased .Start}}</td><td>{{.End}}</td><td>{{printf "%.1f" .MeanDepth}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
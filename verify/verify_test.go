@@ -0,0 +1,158 @@
+package verify
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/fastq"
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/random"
+	"github.com/bebop/poly/transform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testReference is a random circular plasmid with a single CDS at 400 to 1200.
+func testReference(seed int64) genbank.Genbank {
+	sequence, _ := random.DNASequence(2000, seed)
+	reference := genbank.Genbank{
+		Meta:     genbank.Meta{Locus: genbank.Locus{Name: "pTest", Circular: true}},
+		Sequence: sequence,
+	}
+	_ = reference.AddFeature(&genbank.Feature{Type: "source", Location: genbank.Location{Start: 0, End: 2000}})
+	_ = reference.AddFeature(&genbank.Feature{Type: "CDS", Attributes: map[string]string{"label": "gfp"}, Location: genbank.Location{Start: 400, End: 1200}})
+	return reference
+}
+
+// tile sequences a circular clone with 150 bp reads starting every step
+// bases, alternating strands.
+func tile(clone string, step int) []fastq.Fastq {
+	doubled := clone + clone
+	var reads []fastq.Fastq
+	for start := 0; start < len(clone); start += step {
+		sequence := doubled[start : start+150]
+		if (start/step)%2 == 1 {
+			sequence = transform.ReverseComplement(sequence)
+		}
+		reads = append(reads, fastq.Fastq{Identifier: "read" + strconv.Itoa(start), Sequence: sequence, Quality: strings.Repeat("I", 150)})
+	}
+	return reads
+}
+
+func TestVerifyPerfect(t *testing.T) {
+	reference := testReference(1)
+	report, err := Verify("clone1", reference, tile(reference.Sequence, 10), Options{})
+	require.NoError(t, err)
+	assert.True(t, report.Passed)
+	assert.Equal(t, reference.Sequence, report.Consensus)
+	assert.Empty(t, report.Variants)
+	assert.Empty(t, report.Mixed)
+	assert.Empty(t, report.LowCoverage)
+	assert.Equal(t, report.Reads, report.MappedReads)
+	assert.InDelta(t, 15, report.MeanDepth, 0.01)
+	assert.Len(t, report.Checks, 4)
+}
+
+func TestVerifyVariants(t *testing.T) {
+	reference := testReference(2)
+	sequence := reference.Sequence
+	substitution := string("CGTA"[strings.IndexByte("ACGT", sequence[500])])
+	clone := sequence[:500] + substitution + sequence[501:1000] + sequence[1003:1500] + "GATTACA" + sequence[1500:]
+	report, err := Verify("clone2", reference, tile(clone, 10), Options{})
+	require.NoError(t, err)
+	assert.False(t, report.Passed)
+	assert.Equal(t, clone, report.Consensus)
+
+	require.Len(t, report.Variants, 3)
+	assert.Equal(t, 500, report.Variants[0].Position)
+	assert.Equal(t, sequence[500:501], report.Variants[0].Reference)
+	assert.Equal(t, substitution, report.Variants[0].Alternate)
+	assert.Equal(t, "o.501"+sequence[500:501]+">"+substitution, report.Variants[0].Description)
+	assert.Equal(t, []string{"gfp"}, report.Variants[0].Features)
+
+	// indels are placed as far left as they can go.
+	deletion := report.Variants[1]
+	assert.InDelta(t, 1000, deletion.Position, 3)
+	assert.Equal(t, sequence[deletion.Position:deletion.Position+3], deletion.Reference)
+	assert.Equal(t, "", deletion.Alternate)
+	assert.Equal(t, []string{"gfp"}, deletion.Features)
+
+	insertion := report.Variants[2]
+	assert.InDelta(t, 1500, insertion.Position, 7)
+	assert.Len(t, insertion.Alternate, 7)
+	assert.Equal(t, "", insertion.Reference)
+	assert.Empty(t, insertion.Features)
+
+	checks := make(map[string]Check)
+	for _, check := range report.Checks {
+		checks[check.Name] = check
+	}
+	assert.True(t, checks["coverage"].Passed)
+	assert.False(t, checks["variants"].Passed)
+	assert.False(t, checks["features"].Passed)
+	assert.Equal(t, "variants in gfp", checks["features"].Detail)
+}
+
+func TestVerifyMixed(t *testing.T) {
+	reference := testReference(3)
+	sequence := reference.Sequence
+	substitution := string("CGTA"[strings.IndexByte("ACGT", sequence[700])])
+	mutant := sequence[:700] + substitution + sequence[701:]
+	// a third of the colony has the substitution.
+	reads := append(tile(sequence, 10), tile(sequence, 10)...)
+	reads = append(reads, tile(mutant, 10)...)
+	report, err := Verify("clone3", reference, reads, Options{})
+	require.NoError(t, err)
+	assert.Empty(t, report.Variants)
+	require.Len(t, report.Mixed, 1)
+	assert.Equal(t, 700, report.Mixed[0].Position)
+	assert.Equal(t, substitution, report.Mixed[0].Minor)
+	assert.InDelta(t, 1.0/3, report.Mixed[0].MinorFrequency, 0.01)
+	assert.False(t, report.Passed)
+}
+
+func TestVerifyLowCoverage(t *testing.T) {
+	reference := testReference(4)
+	var reads []fastq.Fastq
+	for _, read := range tile(reference.Sequence, 10) {
+		// drop the reads of a stretch in the middle.
+		if start, _ := strconv.Atoi(strings.TrimPrefix(read.Identifier, "read")); start < 700 || start > 1000 {
+			reads = append(reads, read)
+		}
+	}
+	report, err := Verify("clone4", reference, reads, Options{})
+	require.NoError(t, err)
+	assert.False(t, report.Passed)
+	require.NotEmpty(t, report.LowCoverage)
+	assert.Contains(t, report.Consensus, "NNNN")
+	assert.Empty(t, report.Variants)
+	assert.False(t, report.Checks[0].Passed)
+
+	_, err = Verify("empty", genbank.Genbank{}, reads, Options{})
+	assert.Error(t, err)
+}
+
+func TestWrite(t *testing.T) {
+	reference := testReference(5)
+	sequence := reference.Sequence
+	clone := sequence[:500] + sequence[501:]
+	report, err := Verify("clone<5>", reference, tile(clone, 10), Options{})
+	require.NoError(t, err)
+
+	var jsonOutput bytes.Buffer
+	require.NoError(t, report.WriteJSON(&jsonOutput))
+	var decoded Report
+	require.NoError(t, json.Unmarshal(jsonOutput.Bytes(), &decoded))
+	assert.Equal(t, report, decoded)
+
+	var htmlOutput bytes.Buffer
+	require.NoError(t, report.WriteHTML(&htmlOutput))
+	page := htmlOutput.String()
+	assert.Contains(t, page, "clone&lt;5&gt;")
+	assert.Contains(t, page, report.Variants[0].Description)
+	assert.Contains(t, page, "gfp")
+	assert.Contains(t, page, `<span class="fail">failed</span>`)
+}
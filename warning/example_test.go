@@ -0,0 +1,23 @@
+package warning_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/warning"
+)
+
+func ExampleStrict() {
+	sequence, _ := genbank.Read("../data/sample.gbk")
+	for _, parseWarning := range sequence.Warnings {
+		fmt.Println(parseWarning)
+	}
+
+	// callers that want to be strict can promote warnings to an error.
+	err := warning.Strict(sequence.Warnings)
+	fmt.Println(err)
+
+	// Output:
+	// line 1: LOCUS does not specify topology, assuming linear
+	// warning treated as error: line 1: LOCUS does not specify topology, assuming linear
+}
@@ -0,0 +1,78 @@
+/*
+Package warning provides a standard way for parsers and validators to report
+problems that aren't worth failing over.
+
+Real world files are messy. A GenBank file might not say whether its sequence
+is circular, a LOCUS line might claim a different length than the sequence
+that follows it, or a parser might have to guess at what a malformed line
+meant. None of these should stop you from getting your data, but you probably
+want to know about them.
+
+Errors mean "I couldn't do what you asked". Warnings mean "I did what you asked,
+but you should double check this". Results that can carry warnings expose
+them as a Warnings []warning.Warning field, and callers that would rather be
+safe than sorry can promote them to an error with Strict:
+
+	sequence, err := genbank.Read("data/puc19.gbk")
+	if err != nil {
+		return err
+	}
+	if err := warning.Strict(sequence.Warnings); err != nil {
+		return err
+	}
+*/
+package warning
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Warning is a single non-fatal issue found while parsing or validating.
+type Warning struct {
+	Line    int    `json:"line"`    // 1-based line number the warning refers to, or 0 if it doesn't apply to a line.
+	Message string `json:"message"` // human readable description of the issue.
+}
+
+// New returns a Warning that does not refer to a specific line.
+func New(format string, arguments ...any) Warning {
+	return Warning{Message: fmt.Sprintf(format, arguments...)}
+}
+
+// AtLine returns a Warning that refers to a specific 1-based line.
+func AtLine(line int, format string, arguments ...any) Warning {
+	return Warning{Line: line, Message: fmt.Sprintf(format, arguments...)}
+}
+
+// String returns the warning as a single line, prefixed with its line number if it has one.
+func (warning Warning) String() string {
+	if warning.Line > 0 {
+		return fmt.Sprintf("line %d: %s", warning.Line, warning.Message)
+	}
+	return warning.Message
+}
+
+// Error is returned by Strict when warnings are promoted to an error.
+type Error struct {
+	Warnings []Warning
+}
+
+// Error lists every promoted warning.
+func (err *Error) Error() string {
+	messages := make([]string, len(err.Warnings))
+	for index, warning := range err.Warnings {
+		messages[index] = warning.String()
+	}
+	if len(messages) == 1 {
+		return "warning treated as error: " + messages[0]
+	}
+	return fmt.Sprintf("%d warnings treated as errors: %s", len(messages), strings.Join(messages, "; "))
+}
+
+// Strict promotes warnings to an *Error. It returns nil if there are no warnings.
+func Strict(warnings []Warning) error {
+	if len(warnings) == 0 {
+		return nil
+	}
+	return &Error{Warnings: warnings}
+}
@@ -0,0 +1,37 @@
+package warning
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	if got := AtLine(12, "unknown qualifier %q", "foo").String(); got != `line 12: unknown qualifier "foo"` {
+		t.Errorf("unexpected String() %q", got)
+	}
+	if got := New("sequence looks like %s", "RNA").String(); got != "sequence looks like RNA" {
+		t.Errorf("unexpected String() %q", got)
+	}
+}
+
+func TestStrict(t *testing.T) {
+	if err := Strict(nil); err != nil {
+		t.Errorf("expected no error for no warnings, got %s", err)
+	}
+
+	warnings := []Warning{AtLine(1, "first"), New("second")}
+	err := Strict(warnings)
+	var strictErr *Error
+	if !errors.As(err, &strictErr) {
+		t.Fatalf("expected an *Error, got %T", err)
+	}
+	if len(strictErr.Warnings) != 2 {
+		t.Errorf("expected 2 promoted warnings, got %d", len(strictErr.Warnings))
+	}
+	if got := err.Error(); got != "2 warnings treated as errors: line 1: first; second" {
+		t.Errorf("unexpected error message %q", got)
+	}
+	if got := Strict(warnings[:1]).Error(); got != "warning treated as error: line 1: first" {
+		t.Errorf("unexpected error message %q", got)
+	}
+}
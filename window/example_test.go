@@ -0,0 +1,39 @@
+package window_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bebop/poly/window"
+)
+
+func Example_basic() {
+	windows := window.Sliding("ATGCGCATAT", 4, 3)
+	for windows.Next() {
+		fmt.Println(windows.Start(), windows.Window())
+	}
+	// Output:
+	// 0 ATGC
+	// 3 CGCA
+	// 6 ATAT
+}
+
+func ExampleCodons() {
+	codons := window.Codons("ATGGCCTAA", 0)
+	for codons.Next() {
+		fmt.Println(codons.Window())
+	}
+	// Output:
+	// ATG
+	// GCC
+	// TAA
+}
+
+func ExampleCollect() {
+	gcCount := func(window string) int {
+		return strings.Count(window, "G") + strings.Count(window, "C")
+	}
+	profile := window.Collect(window.Tiles("GGGGATATCCAT", 4), gcCount)
+	fmt.Println(profile)
+	// Output: [4 0 2]
+}
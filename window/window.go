@@ -0,0 +1,134 @@
+/*
+Package window provides iterators for walking fixed size windows over sequences.
+
+Lots of sequence analysis boils down to sliding a window along a sequence and
+computing something for every window: GC content profiles, k-mer counting,
+motif scanning, codon usage, local folding, and so on. Writing that loop by
+hand over and over again is a great way to introduce off-by-one errors, so this
+package provides a single iterator that every module can share.
+
+Iterators work on both strings and byte slices and follow the same pattern as
+bufio.Scanner:
+
+	windows := window.Sliding(sequence, 50, 10)
+	for windows.Next() {
+		fmt.Println(windows.Start(), windows.Window())
+	}
+
+Windows over circular sequences wrap around the origin so that every position
+of a plasmid starts exactly one window.
+*/
+package window
+
+// Sequence is the set of types windows can be taken over.
+type Sequence interface {
+	~string | ~[]byte
+}
+
+// Iterator walks windows of a fixed size over a sequence. The zero value
+// yields no windows.
+type Iterator[S Sequence] struct {
+	sequence  S   // sequence being windowed, extended by size-1 bases if circular.
+	length    int // length of the original sequence region being windowed.
+	offset    int // offset of the windowed region in the caller's sequence.
+	size      int
+	step      int
+	nextStart int
+	start     int
+	started   bool
+}
+
+// New returns an Iterator over windows of the given size, moving step bases at
+// a time. If circular is true, windows wrap around the end of the sequence so
+// that there is one window starting at every step position of the sequence.
+// A size or step less than one, or a size longer than the sequence, yields no
+// windows.
+func New[S Sequence](sequence S, size, step int, circular bool) *Iterator[S] {
+	iterator := &Iterator[S]{
+		sequence: sequence,
+		length:   len(sequence),
+		size:     size,
+		step:     step,
+	}
+	if size < 1 || step < 1 || size > len(sequence) {
+		iterator.length = 0
+		return iterator
+	}
+	if circular {
+		iterator.sequence = S(append([]byte(sequence), sequence[:size-1]...))
+		// allow windows to start at every position of the original sequence.
+		iterator.length = len(sequence) + size - 1
+	}
+	return iterator
+}
+
+// Sliding returns an Iterator over overlapping windows of the given size,
+// moving step bases at a time along a linear sequence.
+func Sliding[S Sequence](sequence S, size, step int) *Iterator[S] {
+	return New(sequence, size, step, false)
+}
+
+// Kmers returns an Iterator over every k-mer of a linear sequence.
+func Kmers[S Sequence](sequence S, k int) *Iterator[S] {
+	return New(sequence, k, 1, false)
+}
+
+// Tiles returns an Iterator over non-overlapping, adjacent windows of the
+// given size. A trailing partial tile is not returned.
+func Tiles[S Sequence](sequence S, size int) *Iterator[S] {
+	return New(sequence, size, size, false)
+}
+
+// Codons returns an Iterator over the codons of a sequence in the given
+// reading frame (0, 1 or 2). A trailing partial codon is not returned.
+func Codons[S Sequence](sequence S, frame int) *Iterator[S] {
+	if frame < 0 || frame > len(sequence) {
+		return &Iterator[S]{}
+	}
+	iterator := New(sequence[frame:], 3, 3, false)
+	iterator.offset = frame
+	return iterator
+}
+
+// Next advances the iterator to the next window, returning false once there
+// are no windows left.
+func (iterator *Iterator[S]) Next() bool {
+	if iterator.size < 1 || iterator.nextStart+iterator.size > iterator.length {
+		return false
+	}
+	iterator.start = iterator.nextStart
+	iterator.nextStart += iterator.step
+	iterator.started = true
+	return true
+}
+
+// Window returns the current window. It shares memory with the input
+// sequence when the input is a byte slice.
+func (iterator *Iterator[S]) Window() S {
+	if !iterator.started {
+		return iterator.sequence[:0]
+	}
+	return iterator.sequence[iterator.start : iterator.start+iterator.size]
+}
+
+// Start returns the 0-based position of the current window in the input sequence.
+func (iterator *Iterator[S]) Start() int {
+	return iterator.offset + iterator.start
+}
+
+// End returns the 0-based, exclusive end of the current window in the input
+// sequence. For circular windows that wrap around the origin End is larger
+// than the length of the sequence.
+func (iterator *Iterator[S]) End() int {
+	return iterator.Start() + iterator.size
+}
+
+// Collect applies function to every remaining window and returns the results
+// in order, which is handy for building profiles like GC content.
+func Collect[S Sequence, T any](iterator *Iterator[S], function func(S) T) []T {
+	var results []T
+	for iterator.Next() {
+		results = append(results, function(iterator.Window()))
+	}
+	return results
+}
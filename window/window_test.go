@@ -0,0 +1,78 @@
+package window
+
+import (
+	"reflect"
+	"testing"
+)
+
+func collectWindows[S Sequence](iterator *Iterator[S]) ([]string, []int) {
+	var windows []string
+	var starts []int
+	for iterator.Next() {
+		windows = append(windows, string(iterator.Window()))
+		starts = append(starts, iterator.Start())
+	}
+	return windows, starts
+}
+
+func TestIterators(t *testing.T) {
+	tests := []struct {
+		name        string
+		iterator    *Iterator[string]
+		wantWindows []string
+		wantStarts  []int
+	}{
+		{"sliding", Sliding("ATGCAT", 3, 1), []string{"ATG", "TGC", "GCA", "CAT"}, []int{0, 1, 2, 3}},
+		{"sliding step", Sliding("ATGCATG", 3, 2), []string{"ATG", "GCA", "ATG"}, []int{0, 2, 4}},
+		{"kmers", Kmers("ATGC", 2), []string{"AT", "TG", "GC"}, []int{0, 1, 2}},
+		{"tiles", Tiles("ATGCATGC", 3), []string{"ATG", "CAT"}, []int{0, 3}},
+		{"codons frame 0", Codons("ATGAAATAA", 0), []string{"ATG", "AAA", "TAA"}, []int{0, 3, 6}},
+		{"codons frame 1", Codons("ATGAAATAA", 1), []string{"TGA", "AAT"}, []int{1, 4}},
+		{"circular", New("ATGC", 3, 1, true), []string{"ATG", "TGC", "GCA", "CAT"}, []int{0, 1, 2, 3}},
+		{"circular step", New("ATGCAT", 4, 3, true), []string{"ATGC", "CATA"}, []int{0, 3}},
+		{"window too large", Sliding("ATG", 4, 1), nil, nil},
+		{"zero size", Sliding("ATG", 0, 1), nil, nil},
+		{"zero step", Sliding("ATG", 1, 0), nil, nil},
+		{"bad frame", Codons("ATG", 4), nil, nil},
+		{"zero value", &Iterator[string]{}, nil, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			windows, starts := collectWindows(test.iterator)
+			if !reflect.DeepEqual(windows, test.wantWindows) {
+				t.Errorf("expected windows %v, got %v", test.wantWindows, windows)
+			}
+			if !reflect.DeepEqual(starts, test.wantStarts) {
+				t.Errorf("expected starts %v, got %v", test.wantStarts, starts)
+			}
+		})
+	}
+}
+
+func TestBytes(t *testing.T) {
+	sequence := []byte("ATGCAT")
+	windows, _ := collectWindows(New(sequence, 4, 2, true))
+	if want := []string{"ATGC", "GCAT", "ATAT"}; !reflect.DeepEqual(windows, want) {
+		t.Errorf("expected windows %v, got %v", want, windows)
+	}
+	if string(sequence) != "ATGCAT" {
+		t.Errorf("circular windowing modified the input sequence: %s", sequence)
+	}
+}
+
+func TestEnd(t *testing.T) {
+	iterator := New("ATGC", 3, 1, true)
+	var ends []int
+	for iterator.Next() {
+		ends = append(ends, iterator.End())
+	}
+	if want := []int{3, 4, 5, 6}; !reflect.DeepEqual(ends, want) {
+		t.Errorf("expected ends %v, got %v", want, ends)
+	}
+}
+
+func TestWindowBeforeNext(t *testing.T) {
+	if window := Sliding("ATGC", 2, 1).Window(); window != "" {
+		t.Errorf("expected an empty window before Next is called, got %q", window)
+	}
+}